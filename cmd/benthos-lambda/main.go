@@ -0,0 +1,111 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/log"
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/serverless"
+	"github.com/Jeffail/benthos/lib/util/config"
+	"github.com/Jeffail/benthos/lib/util/text"
+	yaml "gopkg.in/yaml.v2"
+)
+
+//------------------------------------------------------------------------------
+
+// Build stamps.
+var (
+	Version   string
+	DateBuilt string
+)
+
+// defaultConfigPath is the path of the config within the deployment package of
+// a function, relative to the working directory.
+const defaultConfigPath = "./benthos.yaml"
+
+//------------------------------------------------------------------------------
+
+// readConfig reads the config from the environment variable BENTHOS_CONFIG
+// when it is set, or otherwise from the file at BENTHOS_CONFIG_PATH, which
+// defaults to ./benthos.yaml.
+func readConfig() (serverless.Config, error) {
+	conf := serverless.NewConfig()
+	if confStr := os.Getenv("BENTHOS_CONFIG"); len(confStr) > 0 {
+		err := yaml.Unmarshal(text.ReplaceEnvVariables([]byte(confStr)), &conf)
+		return conf, err
+	}
+	path := os.Getenv("BENTHOS_CONFIG_PATH")
+	if len(path) == 0 {
+		path = defaultConfigPath
+	}
+	err := config.Read(path, true, &conf)
+	return conf, err
+}
+
+func main() {
+	runtimeAPI := os.Getenv("AWS_LAMBDA_RUNTIME_API")
+	if len(runtimeAPI) == 0 {
+		fmt.Fprintln(os.Stderr, "Benthos lambda must be run as an AWS Lambda function, AWS_LAMBDA_RUNTIME_API is not set.")
+		os.Exit(1)
+	}
+
+	fail := func(err error) {
+		fmt.Fprintln(os.Stderr, err)
+		if rerr := serverless.ReportLambdaInitError(runtimeAPI, err); rerr != nil {
+			fmt.Fprintf(os.Stderr, "Failed to report error: %v\n", rerr)
+		}
+		os.Exit(1)
+	}
+
+	conf, err := readConfig()
+	if err != nil {
+		fail(fmt.Errorf("configuration read error: %v", err))
+	}
+
+	logger := log.New(os.Stdout, conf.Logger)
+	logger.Infof("Launching benthos lambda %v\n", Version)
+
+	stats, err := metrics.New(conf.Metrics, metrics.OptSetLogger(logger))
+	if err != nil {
+		fail(fmt.Errorf("failed to create metrics: %v", err))
+	}
+
+	handler, err := serverless.NewHandler(conf, logger, stats)
+	if err != nil {
+		fail(err)
+	}
+
+	// Functions are stopped by the platform, and therefore this only returns
+	// when the runtime API cannot be reached.
+	err = serverless.RunLambda(runtimeAPI, handler)
+	logger.Errorf("Lambda runtime error: %v\n", err)
+	if err = handler.Close(time.Second * 5); err != nil {
+		logger.Errorf("Failed to close output: %v\n", err)
+	}
+	stats.Close()
+	os.Exit(1)
+}
+
+//------------------------------------------------------------------------------
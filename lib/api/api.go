@@ -23,10 +23,12 @@ package api
 import (
 	"context"
 	"encoding/json"
+	"expvar"
 	"fmt"
 	"net/http"
 	"net/http/pprof"
 	"runtime"
+	"strings"
 	"sync"
 	"time"
 
@@ -40,10 +42,12 @@ import (
 
 // Config contains the configuration fields for the Benthos API.
 type Config struct {
-	Address        string `json:"address" yaml:"address"`
-	ReadTimeoutMS  int    `json:"read_timeout_ms" yaml:"read_timeout_ms"`
-	RootPath       string `json:"root_path" yaml:"root_path"`
-	DebugEndpoints bool   `json:"debug_endpoints" yaml:"debug_endpoints"`
+	Address        string     `json:"address" yaml:"address"`
+	ReadTimeoutMS  int        `json:"read_timeout_ms" yaml:"read_timeout_ms"`
+	RootPath       string     `json:"root_path" yaml:"root_path"`
+	DebugEndpoints bool       `json:"debug_endpoints" yaml:"debug_endpoints"`
+	TLS            TLSConfig  `json:"tls" yaml:"tls"`
+	Auth           AuthConfig `json:"auth" yaml:"auth"`
 }
 
 // NewConfig creates a new API config with default values.
@@ -53,6 +57,8 @@ func NewConfig() Config {
 		ReadTimeoutMS:  5000,
 		RootPath:       "/benthos",
 		DebugEndpoints: false,
+		TLS:            NewTLSConfig(),
+		Auth:           NewAuthConfig(),
 	}
 }
 
@@ -67,6 +73,9 @@ type Type struct {
 	handlers    map[string]http.HandlerFunc
 	handlersMut sync.RWMutex
 
+	wholeConf    interface{}
+	wholeConfMut sync.RWMutex
+
 	mux    *mux.Router
 	server *http.Server
 }
@@ -83,7 +92,7 @@ func New(
 	handler := mux.NewRouter()
 	server := &http.Server{
 		Addr:        conf.Address,
-		Handler:     handler,
+		Handler:     conf.Auth.wrap(conf.RootPath, handler),
 		ReadTimeout: time.Millisecond * time.Duration(conf.ReadTimeoutMS),
 	}
 
@@ -91,6 +100,7 @@ func New(
 		conf:      conf,
 		endpoints: map[string]string{},
 		handlers:  map[string]http.HandlerFunc{},
+		wholeConf: wholeConf,
 		mux:       handler,
 		server:    server,
 	}
@@ -99,14 +109,25 @@ func New(
 		w.Write([]byte("pong"))
 	}
 
+	started := time.Now()
+	handleLive := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"live":true,"uptime":"%v"}`, time.Since(started).Round(time.Second))
+	}
+
 	handleStackTrace := func(w http.ResponseWriter, r *http.Request) {
+		// Grow the buffer until the stacks of all goroutines fit.
 		stackSlice := make([]byte, 1024*100)
 		s := runtime.Stack(stackSlice, true)
+		for s == len(stackSlice) {
+			stackSlice = make([]byte, len(stackSlice)*2)
+			s = runtime.Stack(stackSlice, true)
+		}
 		w.Write(stackSlice[:s])
 	}
 
 	handlePrintJSONConfig := func(w http.ResponseWriter, r *http.Request) {
-		resBytes, err := json.Marshal(wholeConf)
+		resBytes, err := json.Marshal(t.config())
 		if err != nil {
 			w.WriteHeader(http.StatusBadGateway)
 			return
@@ -115,7 +136,7 @@ func New(
 	}
 
 	handlePrintYAMLConfig := func(w http.ResponseWriter, r *http.Request) {
-		resBytes, err := yaml.Marshal(wholeConf)
+		resBytes, err := yaml.Marshal(t.config())
 		if err != nil {
 			w.WriteHeader(http.StatusBadGateway)
 			return
@@ -158,15 +179,37 @@ func New(
 		)
 		t.RegisterEndpoint(
 			"/debug/pprof/heap", "DEBUG: Responds with a pprof-formatted heap profile.",
-			pprof.Index,
+			pprof.Handler("heap").ServeHTTP,
+		)
+		t.RegisterEndpoint(
+			"/debug/pprof/allocs", "DEBUG: Responds with a pprof-formatted"+
+				" profile of all past memory allocations.",
+			pprof.Handler("allocs").ServeHTTP,
+		)
+		t.RegisterEndpoint(
+			"/debug/pprof/goroutine", "DEBUG: Responds with a pprof-formatted"+
+				" profile of all current goroutines, or a full dump of their"+
+				" stacks with the GET parameter debug=2.",
+			pprof.Handler("goroutine").ServeHTTP,
+		)
+		t.RegisterEndpoint(
+			"/debug/pprof/threadcreate", "DEBUG: Responds with a pprof-formatted"+
+				" profile of the stack traces that led to the creation of new OS"+
+				" threads.",
+			pprof.Handler("threadcreate").ServeHTTP,
 		)
 		t.RegisterEndpoint(
 			"/debug/pprof/block", "DEBUG: Responds with a pprof-formatted block profile.",
-			pprof.Index,
+			pprof.Handler("block").ServeHTTP,
 		)
 		t.RegisterEndpoint(
 			"/debug/pprof/mutex", "DEBUG: Responds with a pprof-formatted mutex profile.",
-			pprof.Index,
+			pprof.Handler("mutex").ServeHTTP,
+		)
+		t.RegisterEndpoint(
+			"/debug/pprof/cmdline", "DEBUG: Responds with the command line"+
+				" arguments of the service.",
+			pprof.Cmdline,
 		)
 		t.RegisterEndpoint(
 			"/debug/pprof/symbol", "DEBUG: looks up the program counters listed"+
@@ -181,9 +224,37 @@ func New(
 				" parameter, or for 1 second if not specified.",
 			pprof.Trace,
 		)
+		t.RegisterEndpoint(
+			"/debug/vars", "DEBUG: Returns a JSON object of runtime variables,"+
+				" including memory statistics.",
+			expvar.Handler().ServeHTTP,
+		)
+		if handleLogLevel, ok := newLogLevelHandler(log); ok {
+			t.RegisterEndpoint(
+				"/debug/log/level",
+				"DEBUG: Returns the log levels of the service on GET, sets the"+
+					" level of an optional namespace on PUT and removes the"+
+					" level of a namespace on DELETE.",
+				handleLogLevel,
+			)
+		}
 	}
 
 	t.RegisterEndpoint("/ping", "Ping me.", handlePing)
+	t.RegisterEndpoint(
+		"/config/json", "Returns the config of the running service as JSON.",
+		handlePrintJSONConfig,
+	)
+	t.RegisterEndpoint(
+		"/config/yaml", "Returns the config of the running service as YAML.",
+		handlePrintYAMLConfig,
+	)
+	t.RegisterEndpoint(
+		"/live",
+		"Returns whether the service is running, regardless of the"+
+			" connection state of its inputs and outputs.",
+		handleLive,
+	)
 	t.RegisterEndpoint("/version", "Returns the service version.", handleVersion)
 	t.RegisterEndpoint("/endpoints", "Returns this map of endpoints.", handleEndpoints)
 
@@ -202,6 +273,86 @@ func New(
 	return t
 }
 
+// SetConfig replaces the config of the service returned by the config
+// endpoints, which is useful when parts of the config are reloaded.
+func (t *Type) SetConfig(wholeConf interface{}) {
+	t.wholeConfMut.Lock()
+	t.wholeConf = wholeConf
+	t.wholeConfMut.Unlock()
+}
+
+func (t *Type) config() interface{} {
+	t.wholeConfMut.RLock()
+	defer t.wholeConfMut.RUnlock()
+	return t.wholeConf
+}
+
+// logLevelReq is the body of a request to change a log level.
+type logLevelReq struct {
+	Namespace string `json:"namespace"`
+	Level     string `json:"level"`
+}
+
+// logLevelRes is the body of a response listing log levels.
+type logLevelRes struct {
+	Level      string            `json:"level"`
+	Namespaces map[string]string `json:"namespaces"`
+}
+
+// newLogLevelHandler returns a handler for reading and changing the log levels
+// of a logger at runtime, or false if the logger does not support it.
+func newLogLevelHandler(logger log.Modular) (http.HandlerFunc, bool) {
+	levelled, ok := logger.(log.Levelled)
+	if !ok {
+		return nil, false
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "GET":
+		case "PUT", "POST":
+			var req logLevelReq
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				http.Error(w, fmt.Sprintf("Failed to parse request: %v", err), http.StatusBadRequest)
+				return
+			}
+			if len(req.Level) == 0 {
+				http.Error(w, "A level must be specified", http.StatusBadRequest)
+				return
+			}
+			if err := levelled.SetLevel(req.Namespace, req.Level); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			logger.Infof("Set log level of namespace '%v' to %v\n", req.Namespace, strings.ToUpper(req.Level))
+		case "DELETE":
+			namespace := r.URL.Query().Get("namespace")
+			if len(namespace) == 0 {
+				http.Error(w, "A namespace must be specified", http.StatusBadRequest)
+				return
+			}
+			if err := levelled.SetLevel(namespace, ""); err != nil {
+				http.Error(w, err.Error(), http.StatusNotFound)
+				return
+			}
+			logger.Infof("Removed log level of namespace '%v'\n", namespace)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var res logLevelRes
+		res.Level, res.Namespaces = levelled.Levels()
+		resBytes, err := json.Marshal(res)
+		if err != nil {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Write(resBytes)
+	}, true
+}
+
+//------------------------------------------------------------------------------
+
 // RegisterEndpoint registers a http.HandlerFunc under a path with a
 // description that will be displayed under the /endpoints path.
 func (t *Type) RegisterEndpoint(path, desc string, handler http.HandlerFunc) {
@@ -228,7 +379,18 @@ func (t *Type) RegisterEndpoint(path, desc string, handler http.HandlerFunc) {
 
 // ListenAndServe launches the API and blocks until the server closes or fails.
 func (t *Type) ListenAndServe() error {
-	return t.server.ListenAndServe()
+	if err := t.conf.Auth.validate(); err != nil {
+		return err
+	}
+	if !t.conf.TLS.Enabled {
+		return t.server.ListenAndServe()
+	}
+	tlsConf, err := t.conf.TLS.get()
+	if err != nil {
+		return err
+	}
+	t.server.TLSConfig = tlsConf
+	return t.server.ListenAndServeTLS(t.conf.TLS.CertFile, t.conf.TLS.KeyFile)
 }
 
 // Shutdown attempts to close the http server.
@@ -0,0 +1,265 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package api
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Jeffail/benthos/lib/log"
+	"github.com/Jeffail/benthos/lib/metrics"
+)
+
+//------------------------------------------------------------------------------
+
+func TestAPILogLevel(t *testing.T) {
+	conf := NewConfig()
+	conf.DebugEndpoints = true
+
+	logger := log.Noop()
+	api := New("", "", conf, nil, logger, metrics.Noop())
+
+	tests := []struct {
+		method string
+		path   string
+		body   string
+		code   int
+		res    string
+	}{
+		{
+			method: "GET",
+			path:   "/debug/log/level",
+			code:   http.StatusOK,
+			res:    `{"level":"OFF","namespaces":{}}`,
+		},
+		{
+			method: "PUT",
+			path:   "/debug/log/level",
+			body:   `{"namespace":"input","level":"debug"}`,
+			code:   http.StatusOK,
+			res:    `{"level":"OFF","namespaces":{"input":"DEBUG"}}`,
+		},
+		{
+			method: "PUT",
+			path:   "/benthos/debug/log/level",
+			body:   `{"level":"warn"}`,
+			code:   http.StatusOK,
+			res:    `{"level":"WARN","namespaces":{"input":"DEBUG"}}`,
+		},
+		{
+			method: "PUT",
+			path:   "/debug/log/level",
+			body:   `{"level":"nope"}`,
+			code:   http.StatusBadRequest,
+		},
+		{
+			method: "PUT",
+			path:   "/debug/log/level",
+			body:   `{"namespace":"input"}`,
+			code:   http.StatusBadRequest,
+		},
+		{
+			method: "DELETE",
+			path:   "/debug/log/level?namespace=output",
+			code:   http.StatusNotFound,
+		},
+		{
+			method: "DELETE",
+			path:   "/debug/log/level?namespace=input",
+			code:   http.StatusOK,
+			res:    `{"level":"WARN","namespaces":{}}`,
+		},
+	}
+
+	for i, test := range tests {
+		req := httptest.NewRequest(test.method, test.path, bytes.NewReader([]byte(test.body)))
+		w := httptest.NewRecorder()
+		api.server.Handler.ServeHTTP(w, req)
+		if exp, act := test.code, w.Code; exp != act {
+			t.Errorf("Test %v: Wrong status code: %v != %v: %s", i, act, exp, w.Body.String())
+			continue
+		}
+		if len(test.res) == 0 {
+			continue
+		}
+		if exp, act := test.res, w.Body.String(); exp != act {
+			t.Errorf("Test %v: Wrong response: %v != %v", i, act, exp)
+		}
+	}
+}
+
+func TestAPILogLevelDisabled(t *testing.T) {
+	api := New("", "", NewConfig(), nil, log.Noop(), metrics.Noop())
+
+	req := httptest.NewRequest("GET", "/debug/log/level", nil)
+	w := httptest.NewRecorder()
+	api.server.Handler.ServeHTTP(w, req)
+	if exp, act := http.StatusNotFound, w.Code; exp != act {
+		t.Errorf("Wrong status code: %v != %v", act, exp)
+	}
+}
+
+func TestAPIConfig(t *testing.T) {
+	api := New("", "", NewConfig(), map[string]string{"foo": "bar"}, log.Noop(), metrics.Noop())
+
+	get := func(path string) string {
+		req := httptest.NewRequest("GET", path, nil)
+		w := httptest.NewRecorder()
+		api.server.Handler.ServeHTTP(w, req)
+		if exp, act := http.StatusOK, w.Code; exp != act {
+			t.Errorf("Wrong status code for %v: %v != %v", path, act, exp)
+		}
+		return w.Body.String()
+	}
+
+	if exp, act := `{"foo":"bar"}`, get("/config/json"); exp != act {
+		t.Errorf("Wrong config: %v != %v", act, exp)
+	}
+
+	api.SetConfig(map[string]string{"foo": "baz"})
+	if exp, act := "foo: baz\n", get("/config/yaml"); exp != act {
+		t.Errorf("Wrong config: %v != %v", act, exp)
+	}
+}
+
+func TestAPIDebugEndpoints(t *testing.T) {
+	paths := []string{
+		"/debug/vars",
+		"/debug/stack",
+		"/debug/pprof/goroutine?debug=2",
+		"/benthos/debug/pprof/heap",
+		"/debug/pprof/cmdline",
+	}
+
+	conf := NewConfig()
+	conf.DebugEndpoints = true
+	api := New("", "", conf, nil, log.Noop(), metrics.Noop())
+	for _, path := range paths {
+		req := httptest.NewRequest("GET", path, nil)
+		w := httptest.NewRecorder()
+		api.server.Handler.ServeHTTP(w, req)
+		if exp, act := http.StatusOK, w.Code; exp != act {
+			t.Errorf("Wrong status code for %v: %v != %v", path, act, exp)
+		}
+		if w.Body.Len() == 0 {
+			t.Errorf("Empty response for %v", path)
+		}
+	}
+
+	req := httptest.NewRequest("GET", "/debug/vars", nil)
+	w := httptest.NewRecorder()
+	api.server.Handler.ServeHTTP(w, req)
+	if !strings.Contains(w.Body.String(), `"memstats"`) {
+		t.Errorf("Missing memstats from vars: %s", w.Body.String())
+	}
+
+	req = httptest.NewRequest("GET", "/debug/pprof/goroutine?debug=2", nil)
+	w = httptest.NewRecorder()
+	api.server.Handler.ServeHTTP(w, req)
+	if !strings.Contains(w.Body.String(), "TestAPIDebugEndpoints") {
+		t.Errorf("Missing test goroutine from dump: %s", w.Body.String())
+	}
+
+	api = New("", "", NewConfig(), nil, log.Noop(), metrics.Noop())
+	for _, path := range paths {
+		req := httptest.NewRequest("GET", path, nil)
+		w := httptest.NewRecorder()
+		api.server.Handler.ServeHTTP(w, req)
+		if exp, act := http.StatusNotFound, w.Code; exp != act {
+			t.Errorf("Wrong status code for %v: %v != %v", path, act, exp)
+		}
+	}
+}
+
+//------------------------------------------------------------------------------
+
+func TestAPIAuth(t *testing.T) {
+	conf := NewConfig()
+	conf.Auth.Enabled = true
+	conf.Auth.Username = "foo"
+	conf.Auth.Password = "bar"
+	conf.Auth.BearerToken = "baz"
+	conf.Auth.ExemptPaths = []string{"/ping"}
+
+	api := New("", "", conf, map[string]string{"foo": "bar"}, log.Noop(), metrics.Noop())
+
+	tests := []struct {
+		path   string
+		user   string
+		pass   string
+		bearer string
+		code   int
+	}{
+		{path: "/config/json", code: http.StatusUnauthorized},
+		{path: "/config/json", user: "foo", pass: "nope", code: http.StatusUnauthorized},
+		{path: "/config/json", bearer: "nope", code: http.StatusUnauthorized},
+		{path: "/config/json", user: "foo", pass: "bar", code: http.StatusOK},
+		{path: "/benthos/config/json", bearer: "baz", code: http.StatusOK},
+		{path: "/ping", code: http.StatusOK},
+		{path: "/benthos/ping", code: http.StatusOK},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest("GET", test.path, nil)
+		if len(test.user) > 0 {
+			req.SetBasicAuth(test.user, test.pass)
+		}
+		if len(test.bearer) > 0 {
+			req.Header.Set("Authorization", "Bearer "+test.bearer)
+		}
+		w := httptest.NewRecorder()
+		api.server.Handler.ServeHTTP(w, req)
+		if exp, act := test.code, w.Code; exp != act {
+			t.Errorf("Wrong response code for %+v: %v != %v", test, act, exp)
+		}
+	}
+}
+
+func TestAPIAuthValidation(t *testing.T) {
+	conf := NewConfig()
+	conf.Auth.Enabled = true
+
+	api := New("", "", conf, nil, log.Noop(), metrics.Noop())
+	if err := api.ListenAndServe(); err == nil {
+		t.Error("Expected error from auth without credentials")
+	}
+}
+
+func TestAPITLSValidation(t *testing.T) {
+	conf := NewConfig()
+	conf.TLS.Enabled = true
+
+	api := New("", "", conf, nil, log.Noop(), metrics.Noop())
+	if err := api.ListenAndServe(); err == nil {
+		t.Error("Expected error from TLS without a cert and key")
+	}
+
+	conf.TLS.CertFile = "foo.pem"
+	conf.TLS.KeyFile = "foo.key"
+	conf.TLS.ClientCAsFile = "./does_not_exist.pem"
+	if _, err := conf.TLS.get(); err == nil {
+		t.Error("Expected error from missing client CAs")
+	}
+}
+
+//------------------------------------------------------------------------------
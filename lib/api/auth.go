@@ -0,0 +1,156 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package api
+
+import (
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+//------------------------------------------------------------------------------
+
+// TLSConfig contains fields for serving the API over TLS, optionally requiring
+// clients to present a certificate signed by a trusted authority.
+type TLSConfig struct {
+	Enabled       bool   `json:"enabled" yaml:"enabled"`
+	CertFile      string `json:"cert_file" yaml:"cert_file"`
+	KeyFile       string `json:"key_file" yaml:"key_file"`
+	ClientCAsFile string `json:"client_cas_file" yaml:"client_cas_file"`
+}
+
+// NewTLSConfig creates a new TLSConfig with default values.
+func NewTLSConfig() TLSConfig {
+	return TLSConfig{
+		Enabled:       false,
+		CertFile:      "",
+		KeyFile:       "",
+		ClientCAsFile: "",
+	}
+}
+
+// get returns a *tls.Config for the server. When client CAs are configured the
+// server requires and verifies client certificates against them.
+func (c TLSConfig) get() (*tls.Config, error) {
+	if len(c.CertFile) == 0 || len(c.KeyFile) == 0 {
+		return nil, errors.New("tls requires both a cert_file and key_file")
+	}
+	tlsConf := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+	}
+	if len(c.ClientCAsFile) > 0 {
+		caCert, err := ioutil.ReadFile(c.ClientCAsFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read client_cas_file: %v", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caCert) {
+			return nil, errors.New("failed to parse client_cas_file PEM contents")
+		}
+		tlsConf.ClientCAs = pool
+		tlsConf.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return tlsConf, nil
+}
+
+//------------------------------------------------------------------------------
+
+// AuthConfig contains fields for authenticating requests to the API with
+// either basic authentication or a static bearer token.
+type AuthConfig struct {
+	Enabled     bool     `json:"enabled" yaml:"enabled"`
+	Username    string   `json:"username" yaml:"username"`
+	Password    string   `json:"password" yaml:"password"`
+	BearerToken string   `json:"bearer_token" yaml:"bearer_token"`
+	ExemptPaths []string `json:"exempt_paths" yaml:"exempt_paths"`
+}
+
+// NewAuthConfig creates a new AuthConfig with default values.
+func NewAuthConfig() AuthConfig {
+	return AuthConfig{
+		Enabled:     false,
+		Username:    "",
+		Password:    "",
+		BearerToken: "",
+		ExemptPaths: []string{},
+	}
+}
+
+func (a AuthConfig) validate() error {
+	if a.Enabled && len(a.Username) == 0 && len(a.BearerToken) == 0 {
+		return errors.New("auth is enabled but neither a username nor a bearer_token is configured")
+	}
+	return nil
+}
+
+func secureEquals(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
+
+// authorised returns true if a request carries credentials matching either of
+// the configured methods.
+func (a AuthConfig) authorised(r *http.Request) bool {
+	if len(a.Username) > 0 {
+		if user, pass, ok := r.BasicAuth(); ok &&
+			secureEquals(user, a.Username) && secureEquals(pass, a.Password) {
+			return true
+		}
+	}
+	if len(a.BearerToken) > 0 {
+		header := r.Header.Get("Authorization")
+		if strings.HasPrefix(header, "Bearer ") &&
+			secureEquals(strings.TrimPrefix(header, "Bearer "), a.BearerToken) {
+			return true
+		}
+	}
+	return false
+}
+
+// wrap returns a handler that rejects requests without valid credentials
+// before passing them to the underlying handler. Exempt paths match with or
+// without the API root path.
+func (a AuthConfig) wrap(rootPath string, handler http.Handler) http.Handler {
+	if !a.Enabled {
+		return handler
+	}
+	exempt := map[string]struct{}{}
+	for _, p := range a.ExemptPaths {
+		exempt[p] = struct{}{}
+		exempt[rootPath+p] = struct{}{}
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := exempt[r.URL.Path]; ok || a.authorised(r) {
+			handler.ServeHTTP(w, r)
+			return
+		}
+		if len(a.Username) > 0 {
+			w.Header().Set("WWW-Authenticate", `Basic realm="benthos"`)
+		}
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+	})
+}
+
+//------------------------------------------------------------------------------
@@ -97,6 +97,7 @@ func (d *dynamicConfMgr) Remove(id string) {
 type Dynamic struct {
 	onUpdate func(id string, conf []byte) error
 	onDelete func(id string) error
+	onStatus func(id string) bool
 
 	// configs is a map of the latest sanitised configs from our CRUD clients.
 	configs      map[string][]byte
@@ -136,6 +137,12 @@ func (d *Dynamic) OnDelete(onDelete func(id string) error) {
 	d.onDelete = onDelete
 }
 
+// OnStatus registers a func that reports whether an active dynamic component
+// is currently connected, which is included in the listing of components.
+func (d *Dynamic) OnStatus(onStatus func(id string) bool) {
+	d.onStatus = onStatus
+}
+
 // Stopped should be called whenever an active dynamic component has closed,
 // whether by naturally winding down or from a request.
 func (d *Dynamic) Stopped(id string) {
@@ -176,8 +183,9 @@ func (d *Dynamic) HandleList(w http.ResponseWriter, r *http.Request) {
 	}()
 
 	type confInfo struct {
-		Uptime string          `json:"uptime"`
-		Config json.RawMessage `json:"config"`
+		Uptime    string          `json:"uptime"`
+		Connected *bool           `json:"connected,omitempty"`
+		Config    json.RawMessage `json:"config"`
 	}
 	uptimes := map[string]confInfo{}
 
@@ -190,6 +198,14 @@ func (d *Dynamic) HandleList(w http.ResponseWriter, r *http.Request) {
 	}
 	d.idsMut.Unlock()
 
+	if d.onStatus != nil {
+		for k, info := range uptimes {
+			connected := d.onStatus(k)
+			info.Connected = &connected
+			uptimes[k] = info
+		}
+	}
+
 	d.configsMut.Lock()
 	for k, v := range d.configs {
 		if info, exists := uptimes[k]; exists {
@@ -22,6 +22,7 @@ package api
 
 import (
 	"bytes"
+	"encoding/json"
 	"errors"
 	"net/http"
 	"net/http/httptest"
@@ -270,4 +271,41 @@ func TestDynamicListing(t *testing.T) {
 	}
 }
 
+func TestDynamicListStatus(t *testing.T) {
+	dAPI := NewDynamic()
+	r := router(dAPI)
+
+	dAPI.OnStatus(func(id string) bool {
+		return id == "foo"
+	})
+	dAPI.Started("foo", []byte(`{"type":"foo"}`))
+	dAPI.Started("bar", []byte(`{"type":"bar"}`))
+	dAPI.Started("baz", []byte(`{"type":"baz"}`))
+	dAPI.Stopped("baz")
+
+	request, _ := http.NewRequest("GET", "/inputs", nil)
+	response := httptest.NewRecorder()
+	r.ServeHTTP(response, request)
+	if exp, act := http.StatusOK, response.Code; exp != act {
+		t.Errorf("Unexpected response code: %v != %v", act, exp)
+	}
+
+	var list map[string]map[string]interface{}
+	if err := json.Unmarshal(response.Body.Bytes(), &list); err != nil {
+		t.Fatal(err)
+	}
+	if exp, act := true, list["foo"]["connected"]; exp != act {
+		t.Errorf("Wrong connected status of foo: %v != %v", act, exp)
+	}
+	if exp, act := false, list["bar"]["connected"]; exp != act {
+		t.Errorf("Wrong connected status of bar: %v != %v", act, exp)
+	}
+	if _, exists := list["baz"]["connected"]; exists {
+		t.Error("Unexpected connected status of stopped component")
+	}
+	if exp, act := "stopped", list["baz"]["uptime"]; exp != act {
+		t.Errorf("Wrong uptime of baz: %v != %v", act, exp)
+	}
+}
+
 //------------------------------------------------------------------------------
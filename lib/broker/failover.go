@@ -0,0 +1,316 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package broker
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/log"
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+)
+
+//------------------------------------------------------------------------------
+
+// Failover is a broker that implements types.Consumer and sends each message
+// to a single active output, which is the first output in the list that is
+// considered healthy. The health of each output is determined by periodic
+// probes, and an output changes state only after a number of consecutive probes
+// agree. If the active output fails to send a message then the remaining
+// outputs are attempted in order.
+type Failover struct {
+	running int32
+
+	log   log.Modular
+	stats metrics.Type
+
+	transactions <-chan types.Transaction
+
+	probes             []func() error
+	probeInterval      time.Duration
+	unhealthyThreshold int
+	healthyThreshold   int
+
+	healthy []int32
+
+	outputTsChans []chan types.Transaction
+	outputs       []types.Output
+
+	closedChan chan struct{}
+	closeChan  chan struct{}
+}
+
+// NewFailover creates a new Failover type by providing outputs in order of
+// preference.
+func NewFailover(
+	outputs []types.Output,
+	logger log.Modular,
+	stats metrics.Type,
+	options ...func(*Failover),
+) (*Failover, error) {
+	f := &Failover{
+		running:            1,
+		log:                logger,
+		stats:              stats,
+		transactions:       nil,
+		probeInterval:      time.Second * 5,
+		unhealthyThreshold: 3,
+		healthyThreshold:   3,
+		outputs:            outputs,
+		closedChan:         make(chan struct{}),
+		closeChan:          make(chan struct{}),
+	}
+	for _, opt := range options {
+		opt(f)
+	}
+	if len(f.probes) > 0 && len(f.probes) != len(outputs) {
+		return nil, fmt.Errorf(
+			"number of probes (%v) does not match number of outputs (%v)",
+			len(f.probes), len(outputs),
+		)
+	}
+	if f.unhealthyThreshold < 1 || f.healthyThreshold < 1 {
+		return nil, fmt.Errorf(
+			"probe thresholds must be greater than zero, got: %v and %v",
+			f.unhealthyThreshold, f.healthyThreshold,
+		)
+	}
+	f.healthy = make([]int32, len(outputs))
+	for i := range f.healthy {
+		f.healthy[i] = 1
+	}
+	f.outputTsChans = make([]chan types.Transaction, len(f.outputs))
+	for i := range f.outputTsChans {
+		f.outputTsChans[i] = make(chan types.Transaction)
+		if err := f.outputs[i].Consume(f.outputTsChans[i]); err != nil {
+			return nil, err
+		}
+	}
+	return f, nil
+}
+
+//------------------------------------------------------------------------------
+
+// OptFailoverSetProbes sets a health probe for each output, where a nil probe
+// means the output is always considered healthy. A probe returns an error when
+// its output is unhealthy.
+func OptFailoverSetProbes(probes []func() error) func(*Failover) {
+	return func(f *Failover) {
+		f.probes = probes
+	}
+}
+
+// OptFailoverSetProbeInterval sets the period between health probes.
+func OptFailoverSetProbeInterval(interval time.Duration) func(*Failover) {
+	return func(f *Failover) {
+		f.probeInterval = interval
+	}
+}
+
+// OptFailoverSetThresholds sets the number of consecutive failed probes before
+// an output is considered unhealthy, and the number of consecutive successful
+// probes before an unhealthy output is considered healthy again.
+func OptFailoverSetThresholds(unhealthy, healthy int) func(*Failover) {
+	return func(f *Failover) {
+		f.unhealthyThreshold = unhealthy
+		f.healthyThreshold = healthy
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// Consume assigns a new messages channel for the broker to read.
+func (f *Failover) Consume(ts <-chan types.Transaction) error {
+	if f.transactions != nil {
+		return types.ErrAlreadyStarted
+	}
+	f.transactions = ts
+
+	if len(f.probes) > 0 {
+		go f.probeLoop()
+	}
+	go f.loop()
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+// active returns the index of the first healthy output, or the first output if
+// none are healthy.
+func (f *Failover) active() int {
+	for i := range f.healthy {
+		if atomic.LoadInt32(&f.healthy[i]) == 1 {
+			return i
+		}
+	}
+	return 0
+}
+
+// probeLoop periodically probes the health of each output.
+func (f *Failover) probeLoop() {
+	var (
+		mProbeErrs = []metrics.StatCounter{}
+		mHealthy   = []metrics.StatGauge{}
+	)
+	for i := range f.outputs {
+		mProbeErrs = append(mProbeErrs, f.stats.GetCounter(fmt.Sprintf("broker.failover.%v.probe.failed", i)))
+		mHealthy = append(mHealthy, f.stats.GetGauge(fmt.Sprintf("broker.failover.%v.healthy", i)))
+		mHealthy[i].Set(1)
+	}
+
+	// Count consecutive probe results that disagree with the current state.
+	streaks := make([]int, len(f.outputs))
+
+	for {
+		select {
+		case <-time.After(f.probeInterval):
+		case <-f.closeChan:
+			return
+		}
+		for i, probe := range f.probes {
+			if probe == nil {
+				continue
+			}
+			err := probe()
+			if err != nil {
+				mProbeErrs[i].Incr(1)
+			}
+			isHealthy := atomic.LoadInt32(&f.healthy[i]) == 1
+			if (err == nil) == isHealthy {
+				streaks[i] = 0
+				continue
+			}
+			streaks[i]++
+			if isHealthy && streaks[i] >= f.unhealthyThreshold {
+				f.log.Warnf("Output %v is unhealthy: %v\n", i, err)
+				atomic.StoreInt32(&f.healthy[i], 0)
+				mHealthy[i].Set(0)
+				streaks[i] = 0
+			} else if !isHealthy && streaks[i] >= f.healthyThreshold {
+				f.log.Infof("Output %v is healthy\n", i)
+				atomic.StoreInt32(&f.healthy[i], 1)
+				mHealthy[i].Set(1)
+				streaks[i] = 0
+			}
+		}
+	}
+}
+
+// loop is an internal loop that brokers incoming messages to many outputs.
+func (f *Failover) loop() {
+	defer func() {
+		for _, c := range f.outputTsChans {
+			close(c)
+		}
+		close(f.closedChan)
+	}()
+
+	var (
+		mMsgsRcvd = f.stats.GetCounter("broker.failover.messages.received")
+		mActive   = f.stats.GetGauge("broker.failover.active")
+		mSwitch   = f.stats.GetCounter("broker.failover.switch")
+		mErrs     = []metrics.StatCounter{}
+	)
+	for i := range f.outputs {
+		mErrs = append(mErrs, f.stats.GetCounter(fmt.Sprintf("broker.failover.%v.failed", i)))
+	}
+
+	lastActive := 0
+	mActive.Set(0)
+
+	var open bool
+	resChan := make(chan types.Response)
+	for atomic.LoadInt32(&f.running) == 1 {
+		var ts types.Transaction
+		var res types.Response
+		select {
+		case ts, open = <-f.transactions:
+			if !open {
+				return
+			}
+		case <-f.closeChan:
+			return
+		}
+		mMsgsRcvd.Incr(1)
+
+		active := f.active()
+		if active != lastActive {
+			f.log.Infof("Switching active output from %v to %v\n", lastActive, active)
+			mSwitch.Incr(1)
+			mActive.Set(int64(active))
+			lastActive = active
+		}
+
+		for n := range f.outputTsChans {
+			// Attempt the active output first, followed by the remaining
+			// outputs in order.
+			i := n - 1
+			if n == 0 {
+				i = active
+			} else if i >= active {
+				i = n
+			}
+			select {
+			case f.outputTsChans[i] <- types.NewTransaction(ts.Payload, resChan):
+			case <-f.closeChan:
+				return
+			}
+			select {
+			case res, open = <-resChan:
+				if !open {
+					return
+				}
+			case <-f.closeChan:
+				return
+			}
+			if res.Error() == nil {
+				break
+			}
+			mErrs[i].Incr(1)
+		}
+		select {
+		case ts.ResponseChan <- res:
+		case <-f.closeChan:
+			return
+		}
+	}
+}
+
+// CloseAsync shuts down the Failover broker and stops processing requests.
+func (f *Failover) CloseAsync() {
+	if atomic.CompareAndSwapInt32(&f.running, 1, 0) {
+		close(f.closeChan)
+	}
+}
+
+// WaitForClose blocks until the Failover broker has closed down.
+func (f *Failover) WaitForClose(timeout time.Duration) error {
+	select {
+	case <-f.closedChan:
+	case <-time.After(timeout):
+		return types.ErrTimeout
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,226 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package broker
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/log"
+	"github.com/Jeffail/benthos/lib/message"
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/response"
+	"github.com/Jeffail/benthos/lib/types"
+)
+
+//------------------------------------------------------------------------------
+
+func TestFailoverInterfaces(t *testing.T) {
+	f := &Failover{}
+	if types.Consumer(f) == nil {
+		t.Errorf("Failover: nil types.Consumer")
+	}
+	if types.Closable(f) == nil {
+		t.Errorf("Failover: nil types.Closable")
+	}
+}
+
+func TestFailoverBadProbes(t *testing.T) {
+	outputs := []types.Output{&MockOutputType{}, &MockOutputType{}}
+	if _, err := NewFailover(
+		outputs, log.Noop(), metrics.Noop(),
+		OptFailoverSetProbes([]func() error{nil}),
+	); err == nil {
+		t.Error("Expected error from mismatched probes")
+	}
+	if _, err := NewFailover(
+		outputs, log.Noop(), metrics.Noop(),
+		OptFailoverSetThresholds(0, 1),
+	); err == nil {
+		t.Error("Expected error from bad thresholds")
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// sendFailover sends a message through the broker and returns the index of the
+// output that successfully received it. Outputs listed in fail respond with an
+// error.
+func sendFailover(
+	t *testing.T,
+	readChan chan<- types.Transaction,
+	mockOutputs []*MockOutputType,
+	fail map[int]bool,
+) int {
+	t.Helper()
+
+	resChan := make(chan types.Response)
+	select {
+	case readChan <- types.NewTransaction(message.New([][]byte{[]byte("foo")}), resChan):
+	case <-time.After(time.Second):
+		t.Fatal("Timed out waiting for broker send")
+	}
+
+	received := -1
+	for received == -1 {
+		var ts types.Transaction
+		var index int
+		select {
+		case ts = <-mockOutputs[0].TChan:
+			index = 0
+		case ts = <-mockOutputs[1].TChan:
+			index = 1
+		case ts = <-mockOutputs[2].TChan:
+			index = 2
+		case <-time.After(time.Second):
+			t.Fatal("Timed out waiting for broker propagate")
+		}
+		if fail[index] {
+			ts.ResponseChan <- response.NewError(errors.New("nope"))
+			continue
+		}
+		ts.ResponseChan <- response.NewAck()
+		received = index
+	}
+
+	select {
+	case res := <-resChan:
+		if res.Error() != nil {
+			t.Error(res.Error())
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Timed out waiting for response")
+	}
+	return received
+}
+
+func TestFailoverProbes(t *testing.T) {
+	mockOutputs := []*MockOutputType{{}, {}, {}}
+	outputs := []types.Output{}
+	for _, o := range mockOutputs {
+		outputs = append(outputs, o)
+	}
+
+	var primaryDown, secondaryDown int32
+	probes := []func() error{
+		func() error {
+			if atomic.LoadInt32(&primaryDown) == 1 {
+				return errors.New("primary down")
+			}
+			return nil
+		},
+		func() error {
+			if atomic.LoadInt32(&secondaryDown) == 1 {
+				return errors.New("secondary down")
+			}
+			return nil
+		},
+		nil,
+	}
+
+	readChan := make(chan types.Transaction)
+	f, err := NewFailover(
+		outputs, log.Noop(), metrics.Noop(),
+		OptFailoverSetProbes(probes),
+		OptFailoverSetProbeInterval(time.Millisecond),
+		OptFailoverSetThresholds(2, 2),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = f.Consume(readChan); err != nil {
+		t.Fatal(err)
+	}
+
+	waitForActive := func(exp int) {
+		t.Helper()
+		for i := 0; i < 1000; i++ {
+			if f.active() == exp {
+				return
+			}
+			<-time.After(time.Millisecond)
+		}
+		t.Fatalf("Active output never became %v", exp)
+	}
+
+	if act := sendFailover(t, readChan, mockOutputs, nil); act != 0 {
+		t.Errorf("Wrong output: %v != %v", act, 0)
+	}
+
+	atomic.StoreInt32(&primaryDown, 1)
+	waitForActive(1)
+	if act := sendFailover(t, readChan, mockOutputs, nil); act != 1 {
+		t.Errorf("Wrong output: %v != %v", act, 1)
+	}
+
+	atomic.StoreInt32(&secondaryDown, 1)
+	waitForActive(2)
+	if act := sendFailover(t, readChan, mockOutputs, nil); act != 2 {
+		t.Errorf("Wrong output: %v != %v", act, 2)
+	}
+
+	atomic.StoreInt32(&primaryDown, 0)
+	waitForActive(0)
+	if act := sendFailover(t, readChan, mockOutputs, nil); act != 0 {
+		t.Errorf("Wrong output: %v != %v", act, 0)
+	}
+
+	f.CloseAsync()
+	if err = f.WaitForClose(time.Second); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestFailoverMessageErrors(t *testing.T) {
+	mockOutputs := []*MockOutputType{{}, {}, {}}
+	outputs := []types.Output{}
+	for _, o := range mockOutputs {
+		outputs = append(outputs, o)
+	}
+
+	readChan := make(chan types.Transaction)
+	f, err := NewFailover(outputs, log.Noop(), metrics.Noop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = f.Consume(readChan); err != nil {
+		t.Fatal(err)
+	}
+
+	if act := sendFailover(t, readChan, mockOutputs, map[int]bool{0: true}); act != 1 {
+		t.Errorf("Wrong output: %v != %v", act, 1)
+	}
+	if act := sendFailover(t, readChan, mockOutputs, map[int]bool{0: true, 1: true}); act != 2 {
+		t.Errorf("Wrong output: %v != %v", act, 2)
+	}
+	if act := sendFailover(t, readChan, mockOutputs, nil); act != 0 {
+		t.Errorf("Wrong output: %v != %v", act, 0)
+	}
+
+	f.CloseAsync()
+	if err = f.WaitForClose(time.Second); err != nil {
+		t.Error(err)
+	}
+}
+
+//------------------------------------------------------------------------------
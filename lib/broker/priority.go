@@ -0,0 +1,176 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package broker
+
+import (
+	"reflect"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+)
+
+//------------------------------------------------------------------------------
+
+// Priority is a broker that implements types.Producer, takes tiers of inputs
+// and routes them through a single message channel. Transactions from a tier
+// are always consumed before those of the tiers that follow it, and a lower
+// tier is only read from when all tiers above it have nothing to offer.
+type Priority struct {
+	stats metrics.Type
+
+	transactions chan types.Transaction
+
+	closables []types.Closable
+
+	// Each tier is a list of select cases terminated by a default case.
+	tiers [][]reflect.SelectCase
+
+	closedChan chan struct{}
+}
+
+// NewPriority creates a new Priority type by providing tiers of inputs, where
+// the first tier has the highest priority. Inputs within the same tier share
+// the same priority.
+func NewPriority(tiers [][]types.Producer, stats metrics.Type) (*Priority, error) {
+	p := &Priority{
+		stats:        stats,
+		transactions: make(chan types.Transaction),
+		closables:    []types.Closable{},
+		closedChan:   make(chan struct{}),
+	}
+
+	for _, tier := range tiers {
+		cases := []reflect.SelectCase{}
+		for _, input := range tier {
+			if closable, ok := input.(types.Closable); ok {
+				p.closables = append(p.closables, closable)
+			}
+			cases = append(cases, reflect.SelectCase{
+				Dir:  reflect.SelectRecv,
+				Chan: reflect.ValueOf(input.TransactionChan()),
+			})
+		}
+		if len(cases) > 0 {
+			p.tiers = append(p.tiers, append(cases, reflect.SelectCase{
+				Dir: reflect.SelectDefault,
+			}))
+		}
+	}
+
+	go p.loop()
+	return p, nil
+}
+
+//------------------------------------------------------------------------------
+
+// TransactionChan returns the channel used for consuming transactions from this
+// broker.
+func (p *Priority) TransactionChan() <-chan types.Transaction {
+	return p.transactions
+}
+
+//------------------------------------------------------------------------------
+
+// removeInput removes a closed input from a tier, and removes the tier if it no
+// longer has any inputs.
+func (p *Priority) removeInput(tier, index int) {
+	cases := p.tiers[tier]
+	cases = append(cases[:index], cases[index+1:]...)
+	if len(cases) == 1 {
+		p.tiers = append(p.tiers[:tier], p.tiers[tier+1:]...)
+		return
+	}
+	p.tiers[tier] = cases
+}
+
+// next returns the next transaction to be forwarded, blocking until one is
+// available. Returns false if all inputs are closed.
+func (p *Priority) next() (types.Transaction, bool) {
+tierLoop:
+	for len(p.tiers) > 0 {
+		// Attempt to read from each tier in order without blocking.
+		for i, cases := range p.tiers {
+			chosen, recv, open := reflect.Select(cases)
+			if chosen == len(cases)-1 {
+				continue
+			}
+			if !open {
+				p.removeInput(i, chosen)
+				continue tierLoop
+			}
+			return recv.Interface().(types.Transaction), true
+		}
+
+		// Nothing is ready, therefore block until any input is.
+		var all []reflect.SelectCase
+		var tierOf, indexOf []int
+		for i, cases := range p.tiers {
+			for j, c := range cases[:len(cases)-1] {
+				all = append(all, c)
+				tierOf = append(tierOf, i)
+				indexOf = append(indexOf, j)
+			}
+		}
+		chosen, recv, open := reflect.Select(all)
+		if !open {
+			p.removeInput(tierOf[chosen], indexOf[chosen])
+			continue
+		}
+		return recv.Interface().(types.Transaction), true
+	}
+	return types.Transaction{}, false
+}
+
+// loop is an internal loop that brokers incoming messages to the output.
+func (p *Priority) loop() {
+	defer func() {
+		close(p.transactions)
+		close(p.closedChan)
+	}()
+
+	for {
+		tran, open := p.next()
+		if !open {
+			return
+		}
+		p.transactions <- tran
+	}
+}
+
+// CloseAsync shuts down the Priority broker and stops processing requests.
+func (p *Priority) CloseAsync() {
+	for _, closable := range p.closables {
+		closable.CloseAsync()
+	}
+}
+
+// WaitForClose blocks until the Priority broker has closed down.
+func (p *Priority) WaitForClose(timeout time.Duration) error {
+	select {
+	case <-p.closedChan:
+	case <-time.After(timeout):
+		return types.ErrTimeout
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,140 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package broker
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/message"
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+)
+
+//------------------------------------------------------------------------------
+
+func TestPriorityInterfaces(t *testing.T) {
+	p := &Priority{}
+	if types.Producer(p) == nil {
+		t.Errorf("Priority: nil types.Producer")
+	}
+	if types.Closable(p) == nil {
+		t.Errorf("Priority: nil types.Closable")
+	}
+}
+
+//------------------------------------------------------------------------------
+
+func TestPriorityOrder(t *testing.T) {
+	nMsgs := 10
+
+	resChan := make(chan types.Response)
+
+	high := &MockInputType{TChan: make(chan types.Transaction, nMsgs)}
+	lowA := &MockInputType{TChan: make(chan types.Transaction, nMsgs)}
+	lowB := &MockInputType{TChan: make(chan types.Transaction, nMsgs)}
+
+	for i := 0; i < nMsgs; i++ {
+		lowA.TChan <- types.NewTransaction(message.New([][]byte{
+			[]byte(fmt.Sprintf("low %v", i)),
+		}), resChan)
+		lowB.TChan <- types.NewTransaction(message.New([][]byte{
+			[]byte(fmt.Sprintf("low %v", i)),
+		}), resChan)
+		high.TChan <- types.NewTransaction(message.New([][]byte{
+			[]byte(fmt.Sprintf("high %v", i)),
+		}), resChan)
+	}
+
+	p, err := NewPriority([][]types.Producer{
+		{high},
+		{lowA, lowB},
+	}, metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < nMsgs*3; i++ {
+		var tran types.Transaction
+		select {
+		case tran = <-p.TransactionChan():
+		case <-time.After(time.Second):
+			t.Fatalf("Timed out waiting for message %v", i)
+		}
+		act := string(tran.Payload.Get(0).Get())
+		if i < nMsgs {
+			if exp := fmt.Sprintf("high %v", i); act != exp {
+				t.Errorf("Wrong message %v: %v != %v", i, act, exp)
+			}
+		} else if !strings.HasPrefix(act, "low ") {
+			t.Errorf("Wrong message %v: %v", i, act)
+		}
+	}
+
+	p.CloseAsync()
+	if err = p.WaitForClose(time.Second); err != nil {
+		t.Error(err)
+	}
+	if _, open := <-p.TransactionChan(); open {
+		t.Error("Transaction chan not closed")
+	}
+}
+
+func TestPriorityInputClosed(t *testing.T) {
+	resChan := make(chan types.Response)
+
+	high := &MockInputType{TChan: make(chan types.Transaction)}
+	low := &MockInputType{TChan: make(chan types.Transaction)}
+
+	p, err := NewPriority([][]types.Producer{
+		{high}, {low},
+	}, metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	high.CloseAsync()
+
+	for i := 0; i < 10; i++ {
+		go func() {
+			low.TChan <- types.NewTransaction(message.New([][]byte{
+				[]byte("foo"),
+			}), resChan)
+		}()
+		select {
+		case tran := <-p.TransactionChan():
+			if act := string(tran.Payload.Get(0).Get()); act != "foo" {
+				t.Errorf("Wrong message: %v", act)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("Timed out waiting for message")
+		}
+	}
+
+	low.CloseAsync()
+	if err = p.WaitForClose(time.Second); err != nil {
+		t.Error(err)
+	}
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,201 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package broker
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"sync/atomic"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+)
+
+//------------------------------------------------------------------------------
+
+// Weighted is a broker that implements types.Consumer and sends each message
+// out to a single consumer, where the proportion of messages sent to each
+// consumer is determined by its weight. Consumers are chosen either randomly or
+// by hashing a key extracted from each message. Consumers that apply
+// backpressure will block all consumers.
+type Weighted struct {
+	running int32
+
+	stats metrics.Type
+
+	transactions <-chan types.Transaction
+
+	keyFn      func(types.Message) []byte
+	thresholds []uint32
+	total      uint32
+
+	outputTsChans []chan types.Transaction
+	outputs       []types.Output
+
+	closedChan chan struct{}
+	closeChan  chan struct{}
+}
+
+// NewWeighted creates a new Weighted type by providing consumers and a weight
+// for each consumer. If keyFn is non-nil it is used to extract a key from each
+// message, and messages with the same key are always sent to the same consumer
+// for as long as the weights are unchanged. Otherwise consumers are chosen at
+// random.
+func NewWeighted(
+	outputs []types.Output,
+	weights []int,
+	keyFn func(types.Message) []byte,
+	stats metrics.Type,
+) (*Weighted, error) {
+	if len(weights) != len(outputs) {
+		return nil, fmt.Errorf(
+			"number of weights (%v) does not match number of outputs (%v)",
+			len(weights), len(outputs),
+		)
+	}
+	o := &Weighted{
+		running:      1,
+		stats:        stats,
+		transactions: nil,
+		keyFn:        keyFn,
+		outputs:      outputs,
+		closedChan:   make(chan struct{}),
+		closeChan:    make(chan struct{}),
+	}
+	o.thresholds = make([]uint32, len(weights))
+	for i, w := range weights {
+		if w < 0 {
+			return nil, fmt.Errorf("weight of output %v is negative: %v", i, w)
+		}
+		o.total += uint32(w)
+		o.thresholds[i] = o.total
+	}
+	if o.total == 0 {
+		return nil, errors.New("at least one output must have a weight greater than zero")
+	}
+	o.outputTsChans = make([]chan types.Transaction, len(o.outputs))
+	for i := range o.outputTsChans {
+		o.outputTsChans[i] = make(chan types.Transaction)
+		if err := o.outputs[i].Consume(o.outputTsChans[i]); err != nil {
+			return nil, err
+		}
+	}
+	return o, nil
+}
+
+//------------------------------------------------------------------------------
+
+// Consume assigns a new messages channel for the broker to read.
+func (o *Weighted) Consume(ts <-chan types.Transaction) error {
+	if o.transactions != nil {
+		return types.ErrAlreadyStarted
+	}
+	o.transactions = ts
+
+	go o.loop()
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+// pick returns the index of the output that a message should be sent to given
+// a value within the range of the total weight.
+func (o *Weighted) pick(v uint32) int {
+	for i, t := range o.thresholds {
+		if v < t {
+			return i
+		}
+	}
+	return len(o.thresholds) - 1
+}
+
+// loop is an internal loop that brokers incoming messages to many outputs.
+func (o *Weighted) loop() {
+	defer func() {
+		for _, c := range o.outputTsChans {
+			close(c)
+		}
+		close(o.closedChan)
+	}()
+
+	var (
+		mMsgsRcvd = o.stats.GetCounter("broker.weighted.messages.received")
+		mOutputs  = make([]metrics.StatCounter, len(o.outputs))
+	)
+	for i := range mOutputs {
+		mOutputs[i] = o.stats.GetCounter(fmt.Sprintf("broker.weighted.output.%v.sent", i))
+	}
+
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+
+	var open bool
+	for atomic.LoadInt32(&o.running) == 1 {
+		var ts types.Transaction
+		select {
+		case ts, open = <-o.transactions:
+			if !open {
+				return
+			}
+		case <-o.closeChan:
+			return
+		}
+		mMsgsRcvd.Incr(1)
+
+		var v uint32
+		if o.keyFn != nil {
+			h := fnv.New32a()
+			h.Write(o.keyFn(ts.Payload))
+			v = h.Sum32() % o.total
+		} else {
+			v = uint32(rnd.Int63n(int64(o.total)))
+		}
+		i := o.pick(v)
+
+		select {
+		case o.outputTsChans[i] <- ts:
+		case <-o.closeChan:
+			return
+		}
+		mOutputs[i].Incr(1)
+	}
+}
+
+// CloseAsync shuts down the Weighted broker and stops processing requests.
+func (o *Weighted) CloseAsync() {
+	if atomic.CompareAndSwapInt32(&o.running, 1, 0) {
+		close(o.closeChan)
+	}
+}
+
+// WaitForClose blocks until the Weighted broker has closed down.
+func (o *Weighted) WaitForClose(timeout time.Duration) error {
+	select {
+	case <-o.closedChan:
+	case <-time.After(timeout):
+		return types.ErrTimeout
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
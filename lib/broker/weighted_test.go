@@ -0,0 +1,170 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package broker
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/message"
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+)
+
+//------------------------------------------------------------------------------
+
+func TestWeightedInterfaces(t *testing.T) {
+	f := &Weighted{}
+	if types.Consumer(f) == nil {
+		t.Errorf("Weighted: nil types.Consumer")
+	}
+	if types.Closable(f) == nil {
+		t.Errorf("Weighted: nil types.Closable")
+	}
+}
+
+func TestWeightedBadWeights(t *testing.T) {
+	outputs := []types.Output{&MockOutputType{}, &MockOutputType{}}
+
+	tests := [][]int{
+		{1},
+		{0, 0},
+		{-1, 2},
+	}
+	for _, weights := range tests {
+		if _, err := NewWeighted(outputs, weights, nil, metrics.Noop()); err == nil {
+			t.Errorf("Expected error from weights: %v", weights)
+		}
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// sendWeighted sends messages through a weighted broker and returns the index
+// of the output that each message was received by.
+func sendWeighted(t *testing.T, weights []int, keyFn func(types.Message) []byte, contents []string) []int {
+	t.Helper()
+
+	mockOutputs := []*MockOutputType{}
+	outputs := []types.Output{}
+	for range weights {
+		o := &MockOutputType{}
+		mockOutputs = append(mockOutputs, o)
+		outputs = append(outputs, o)
+	}
+
+	readChan := make(chan types.Transaction)
+	resChan := make(chan types.Response)
+
+	oTM, err := NewWeighted(outputs, weights, keyFn, metrics.Noop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = oTM.Consume(readChan); err != nil {
+		t.Fatal(err)
+	}
+
+	received := make(chan int)
+	for i, o := range mockOutputs {
+		go func(index int, tChan <-chan types.Transaction) {
+			for range tChan {
+				received <- index
+			}
+		}(i, o.TChan)
+	}
+
+	results := make([]int, len(contents))
+	for i, content := range contents {
+		select {
+		case readChan <- types.NewTransaction(message.New([][]byte{[]byte(content)}), resChan):
+		case <-time.After(time.Second):
+			t.Fatal("Timed out waiting for broker send")
+		}
+		select {
+		case results[i] = <-received:
+		case <-time.After(time.Second):
+			t.Fatal("Timed out waiting for broker propagate")
+		}
+	}
+
+	oTM.CloseAsync()
+	if err = oTM.WaitForClose(time.Second); err != nil {
+		t.Error(err)
+	}
+	return results
+}
+
+func TestWeightedRandom(t *testing.T) {
+	contents := make([]string, 2000)
+	for i := range contents {
+		contents[i] = fmt.Sprintf("hello world %v", i)
+	}
+
+	counts := make([]int, 3)
+	for _, index := range sendWeighted(t, []int{90, 10, 0}, nil, contents) {
+		counts[index]++
+	}
+
+	if counts[0] < 1700 || counts[0] > 1900 {
+		t.Errorf("Unexpected count for first output: %v", counts[0])
+	}
+	if counts[1] < 100 || counts[1] > 300 {
+		t.Errorf("Unexpected count for second output: %v", counts[1])
+	}
+	if counts[2] != 0 {
+		t.Errorf("Unexpected count for third output: %v", counts[2])
+	}
+}
+
+func TestWeightedKey(t *testing.T) {
+	keyFn := func(msg types.Message) []byte {
+		return msg.Get(0).Get()
+	}
+
+	contents := []string{}
+	for i := 0; i < 100; i++ {
+		contents = append(contents, fmt.Sprintf("key %v", i%20))
+	}
+
+	keyOutputs := map[string]int{}
+	counts := make([]int, 2)
+	for i, index := range sendWeighted(t, []int{50, 50}, keyFn, contents) {
+		if prev, exists := keyOutputs[contents[i]]; exists && prev != index {
+			t.Errorf("Key '%v' sent to different outputs: %v != %v", contents[i], prev, index)
+		}
+		keyOutputs[contents[i]] = index
+		counts[index]++
+	}
+	if counts[0] == 0 || counts[1] == 0 {
+		t.Errorf("Expected keys to be spread across outputs: %v", counts)
+	}
+
+	// Increasing the weight of the second output should only move keys from
+	// the first output to the second.
+	for i, index := range sendWeighted(t, []int{30, 70}, keyFn, contents) {
+		if keyOutputs[contents[i]] == 1 && index != 1 {
+			t.Errorf("Key '%v' moved away from second output", contents[i])
+		}
+	}
+}
+
+//------------------------------------------------------------------------------
@@ -39,10 +39,15 @@ import (
 
 // TypeSpec is a constructor and usage description for each buffer type.
 type TypeSpec struct {
-	constructor func(conf Config, log log.Modular, stats metrics.Type) (Type, error)
+	constructor func(conf Config, mgr types.Manager, log log.Modular, stats metrics.Type) (Type, error)
 	description string
 }
 
+// Description returns the markdown description of the type.
+func (t TypeSpec) Description() string {
+	return t.description
+}
+
 // Constructors is a map of all buffer types with their specs.
 var Constructors = map[string]TypeSpec{}
 
@@ -50,6 +55,7 @@ var Constructors = map[string]TypeSpec{}
 
 // String constants representing each buffer type.
 const (
+	TypeDisk   = "disk"
 	TypeMemory = "memory"
 	TypeMMAP   = "mmap_file"
 	TypeNone   = "none"
@@ -60,6 +66,7 @@ const (
 // Config is the all encompassing configuration struct for all buffer types.
 type Config struct {
 	Type   string                  `json:"type" yaml:"type"`
+	Disk   single.DiskBufferConfig `json:"disk" yaml:"disk"`
 	Memory single.MemoryConfig     `json:"memory" yaml:"memory"`
 	Mmap   single.MmapBufferConfig `json:"mmap_file" yaml:"mmap_file"`
 	None   struct{}                `json:"none" yaml:"none"`
@@ -69,6 +76,7 @@ type Config struct {
 func NewConfig() Config {
 	return Config{
 		Type:   "none",
+		Disk:   single.NewDiskBufferConfig(),
 		Memory: single.NewMemoryConfig(),
 		Mmap:   single.NewMmapBufferConfig(),
 		None:   struct{}{},
@@ -121,13 +129,15 @@ different options and their qualities:
 | --------- | ---------- | --------- | -------- |
 | Memory    | Highest    | Parallel  | RAM      |
 | Mmap File | High       | Single    | Disk     |
+| Disk      | Medium     | Single    | Disk     |
 
 #### Delivery Guarantees
 
 | Type      | On Restart | On Crash  | On Disk Corruption |
 | --------- | ---------- | --------- | ------------------ |
 | Memory    | Lost       | Lost      | Lost               |
-| Mmap File | Persisted  | Lost      | Lost               |`
+| Mmap File | Persisted  | Lost      | Lost               |
+| Disk      | Persisted  | Persisted | Lost               |`
 
 // Descriptions returns a formatted string of collated descriptions of each type.
 func Descriptions() string {
@@ -178,9 +188,9 @@ func Descriptions() string {
 }
 
 // New creates a buffer type based on a buffer configuration.
-func New(conf Config, log log.Modular, stats metrics.Type) (Type, error) {
+func New(conf Config, mgr types.Manager, log log.Modular, stats metrics.Type) (Type, error) {
 	if c, ok := Constructors[conf.Type]; ok {
-		return c.constructor(conf, log, stats)
+		return c.constructor(conf, mgr, log, stats)
 	}
 	return nil, types.ErrInvalidBufferType
 }
@@ -28,6 +28,7 @@ import (
 
 	"github.com/Jeffail/benthos/lib/log"
 	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
 )
 
 func TestConstructorDescription(t *testing.T) {
@@ -40,7 +41,7 @@ func TestConstructorBadType(t *testing.T) {
 	conf := NewConfig()
 	conf.Type = "not_exist"
 
-	if _, err := New(conf, log.New(os.Stdout, logConfig), metrics.DudType{}); err == nil {
+	if _, err := New(conf, types.DudMgr{}, log.New(os.Stdout, logConfig), metrics.DudType{}); err == nil {
 		t.Error("Expected error, received nil for invalid type")
 	}
 }
@@ -72,7 +73,9 @@ func TestSanitise(t *testing.T) {
 	exp = `{` +
 		`"type":"memory",` +
 		`"memory":{` +
-		`"limit":20` +
+		`"limit":20,` +
+		`"max_messages":0,` +
+		`"on_full":"block"` +
 		`}` +
 		`}`
 
@@ -0,0 +1,174 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package buffer
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/buffer/single"
+	"github.com/Jeffail/benthos/lib/log"
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors[TypeDisk] = TypeSpec{
+		constructor: NewDisk,
+		description: `
+The disk buffer type stores messages in an embedded key/value store (Badger)
+within a directory at the configured ` + "`path`" + `. Messages are only
+removed from the store once they have been acknowledged by the output, and
+therefore any messages that were buffered or in flight when the service stopped
+or crashed are delivered once the service is restarted.
+
+The ` + "`limit`" + ` field sets the maximum total size in bytes of buffered
+messages. When the limit is reached the ` + "`on_full`" + ` field determines
+the behaviour of the buffer, which can be either ` + "`block`" + `, where
+back pressure is applied to inputs until space becomes available, or
+` + "`drop_oldest`" + `, where the oldest messages are removed from the buffer
+in order to make space.
+
+The ` + "`sync_policy`" + ` field determines when writes are flushed to disk
+and can be one of ` + "`always`" + `, where each write is flushed before it is
+acknowledged, ` + "`interval`" + `, where writes are flushed periodically at
+the interval ` + "`sync_interval_ms`" + `, or ` + "`none`" + `, where flushing
+is left to the operating system. Policies other than ` + "`always`" + ` are
+faster but can lose acknowledged writes on a machine crash.
+
+Buffered messages can be compressed by setting ` + "`compression`" + ` to
+` + "`snappy`, `gzip` or `zstd`" + `, and encrypted with AES-GCM by
+setting ` + "`encryption_key`" + ` to a hex encoded key of 16, 24 or 32 bytes.
+Messages written with previous settings can still be read after these fields are
+changed, as long as the key used to encrypt them is still set.
+
+### Retention
+
+By default messages are removed from the buffer as soon as they are
+acknowledged. Setting either ` + "`retention.period_ms`" + ` or
+` + "`retention.bytes`" + ` to a value greater than zero instead retains
+acknowledged messages until they are older than the period, or until the total
+size of retained messages exceeds the byte limit, whichever happens first.
+Retained messages do not count towards the ` + "`limit`" + ` of the buffer.
+
+Each message is identified by an incrementing offset, and when retention is
+enabled two HTTP endpoints are registered that allow you to replay retained
+messages, which can be useful for recovering from data corruption downstream
+without consuming the data from the source again:
+
+- ` + "`GET /buffer/offsets`" + ` returns the oldest, newest and last
+  acknowledged offsets of the buffer as a JSON object.
+- ` + "`POST /buffer/rewind`" + ` rewinds the buffer so that messages are read
+  from a point in the past, specified either with the query parameter
+  ` + "`offset`" + `, or with the query parameter ` + "`timestamp`" + ` as an
+  RFC3339 timestamp. If the point is older than the oldest retained message
+  then the buffer is rewound to the oldest retained message.`,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// NewDisk creates a buffer persisted to disk within an embedded key/value
+// store.
+func NewDisk(config Config, mgr types.Manager, log log.Modular, stats metrics.Type) (Type, error) {
+	b, err := single.NewDiskBuffer(config.Disk, log.NewModule(".buffer.disk"), stats)
+	if err != nil {
+		return nil, err
+	}
+	if config.Disk.Retention.PeriodMS > 0 || config.Disk.Retention.Bytes > 0 {
+		mgr.RegisterEndpoint(
+			"/buffer/offsets",
+			"Returns the range of offsets stored within the buffer.",
+			diskOffsetsHandler(b),
+		)
+		mgr.RegisterEndpoint(
+			"/buffer/rewind",
+			"Rewind the buffer to an offset or timestamp in order to replay"+
+				" retained messages.",
+			diskRewindHandler(b),
+		)
+	}
+	return NewSingleWrapper(config, b, log, stats), nil
+}
+
+func diskOffsetsHandler(b *single.DiskBuffer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" {
+			http.Error(w, "Method not supported", http.StatusMethodNotAllowed)
+			return
+		}
+		offsets, err := b.Offsets()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		resBytes, _ := json.Marshal(offsets)
+		w.Write(resBytes)
+	}
+}
+
+func diskRewindHandler(b *single.DiskBuffer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" {
+			http.Error(w, "Method not supported", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var offset uint64
+		var err error
+
+		query := r.URL.Query()
+		if offsetStr := query.Get("offset"); len(offsetStr) > 0 {
+			if offset, err = strconv.ParseUint(offsetStr, 10, 64); err != nil {
+				http.Error(w, "Failed to parse offset: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+			offset, err = b.Rewind(offset)
+		} else if tsStr := query.Get("timestamp"); len(tsStr) > 0 {
+			var ts time.Time
+			if ts, err = time.Parse(time.RFC3339, tsStr); err != nil {
+				http.Error(w, "Failed to parse timestamp: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+			offset, err = b.RewindToTime(ts)
+		} else {
+			http.Error(w, "Either an offset or timestamp must be specified", http.StatusBadRequest)
+			return
+		}
+
+		if err == types.ErrTypeClosed {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		resBytes, _ := json.Marshal(map[string]uint64{"offset": offset})
+		w.Write(resBytes)
+	}
+}
+
+//------------------------------------------------------------------------------
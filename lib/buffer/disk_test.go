@@ -0,0 +1,97 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package buffer
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Jeffail/benthos/lib/buffer/single"
+	"github.com/Jeffail/benthos/lib/log"
+	"github.com/Jeffail/benthos/lib/message"
+	"github.com/Jeffail/benthos/lib/metrics"
+)
+
+func TestDiskRewindEndpoints(t *testing.T) {
+	dir, err := ioutil.TempDir("", "benthos_test_")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	conf := single.NewDiskBufferConfig()
+	conf.Path = filepath.Join(dir, "buffer")
+	conf.Retention.Bytes = 1024
+
+	b, err := single.NewDiskBuffer(conf, log.Noop(), metrics.Noop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+
+	for i := 0; i < 3; i++ {
+		if _, err = b.PushMessage(message.New([][]byte{[]byte("foo")})); err != nil {
+			t.Fatal(err)
+		}
+		if _, err = b.NextMessage(); err != nil {
+			t.Fatal(err)
+		}
+		if _, err = b.ShiftMessage(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		handler http.HandlerFunc
+		method  string
+		url     string
+		code    int
+		body    string
+	}{
+		{diskOffsetsHandler(b), "GET", "/buffer/offsets", 200, `{"oldest":1,"acked":3,"newest":3}`},
+		{diskOffsetsHandler(b), "POST", "/buffer/offsets", 405, ""},
+		{diskRewindHandler(b), "GET", "/buffer/rewind?offset=2", 405, ""},
+		{diskRewindHandler(b), "POST", "/buffer/rewind", 400, ""},
+		{diskRewindHandler(b), "POST", "/buffer/rewind?offset=nope", 400, ""},
+		{diskRewindHandler(b), "POST", "/buffer/rewind?timestamp=nope", 400, ""},
+		{diskRewindHandler(b), "POST", "/buffer/rewind?offset=10", 400, ""},
+		{diskRewindHandler(b), "POST", "/buffer/rewind?offset=2", 200, `{"offset":2}`},
+		{diskOffsetsHandler(b), "GET", "/buffer/offsets", 200, `{"oldest":1,"acked":1,"newest":3}`},
+		{diskRewindHandler(b), "POST", "/buffer/rewind?timestamp=2000-01-01T00:00:00Z", 200, `{"offset":1}`},
+	}
+
+	for _, test := range tests {
+		rec := httptest.NewRecorder()
+		test.handler(rec, httptest.NewRequest(test.method, test.url, nil))
+		if exp, act := test.code, rec.Code; exp != act {
+			t.Errorf("Wrong status code for %v %v: %v != %v", test.method, test.url, act, exp)
+		}
+		if test.code == 200 {
+			if exp, act := test.body, rec.Body.String(); exp != act {
+				t.Errorf("Wrong response for %v %v: %v != %v", test.method, test.url, act, exp)
+			}
+		}
+	}
+}
@@ -21,9 +21,13 @@
 package buffer
 
 import (
+	"errors"
+	"fmt"
+
 	"github.com/Jeffail/benthos/lib/buffer/parallel"
 	"github.com/Jeffail/benthos/lib/log"
 	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
 )
 
 //------------------------------------------------------------------------------
@@ -34,15 +38,37 @@ func init() {
 		description: `
 The memory buffer type simply allocates a set amount of RAM for buffering
 messages. This can be useful when reading from sources that produce large bursts
-of data. Messages inside the buffer are lost if the service is stopped.`,
+of data. Messages inside the buffer are lost if the service is stopped.
+
+The ` + "`limit`" + ` field sets the maximum total size in bytes of buffered
+messages, and ` + "`max_messages`" + ` optionally sets the maximum number of
+buffered messages, where zero means no limit. When either limit is reached the
+` + "`on_full`" + ` field determines the behaviour of the buffer, which can be
+one of ` + "`block`" + `, where back pressure is applied to inputs until space
+becomes available, ` + "`drop_newest`" + `, where incoming messages are
+discarded, or ` + "`drop_oldest`" + `, where the oldest messages that are not
+currently being processed are discarded in order to make space.`,
 	}
 }
 
 //------------------------------------------------------------------------------
 
 // NewMemory - Create a buffer held in memory.
-func NewMemory(config Config, log log.Modular, stats metrics.Type) (Type, error) {
-	return NewParallelWrapper(config, parallel.NewMemory(config.Memory.Limit), log, stats), nil
+func NewMemory(config Config, mgr types.Manager, log log.Modular, stats metrics.Type) (Type, error) {
+	switch config.Memory.OnFull {
+	case parallel.OnFullBlock, parallel.OnFullDropNewest, parallel.OnFullDropOldest:
+	default:
+		return nil, fmt.Errorf("on_full policy not recognised: %v", config.Memory.OnFull)
+	}
+	if config.Memory.MaxMessages < 0 {
+		return nil, errors.New("max_messages must not be negative")
+	}
+	return NewParallelWrapper(config, parallel.NewMemory(
+		config.Memory.Limit,
+		parallel.OptMemorySetMaxMessages(config.Memory.MaxMessages),
+		parallel.OptMemorySetOnFull(config.Memory.OnFull),
+		parallel.OptMemorySetStats(stats),
+	), log, stats), nil
 }
 
 //------------------------------------------------------------------------------
@@ -36,7 +36,7 @@ func TestMemoryBuffer(t *testing.T) {
 	conf := NewConfig()
 	conf.Type = "memory"
 
-	buf, err := New(conf, log.New(os.Stdout, logConfig), metrics.DudType{})
+	buf, err := New(conf, types.DudMgr{}, log.New(os.Stdout, logConfig), metrics.DudType{})
 	if err != nil {
 		t.Error(err)
 		return
@@ -101,3 +101,19 @@ func TestMemoryBuffer(t *testing.T) {
 		t.Error(err)
 	}
 }
+
+func TestMemoryBufferBadConfig(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = "memory"
+	conf.Memory.OnFull = "nope"
+
+	if _, err := New(conf, types.DudMgr{}, log.Noop(), metrics.Noop()); err == nil {
+		t.Error("Expected error from bad on_full")
+	}
+
+	conf.Memory.OnFull = "drop_newest"
+	conf.Memory.MaxMessages = -1
+	if _, err := New(conf, types.DudMgr{}, log.Noop(), metrics.Noop()); err == nil {
+		t.Error("Expected error from bad max_messages")
+	}
+}
@@ -24,6 +24,7 @@ import (
 	"github.com/Jeffail/benthos/lib/buffer/single"
 	"github.com/Jeffail/benthos/lib/log"
 	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
 )
 
 //------------------------------------------------------------------------------
@@ -53,7 +54,7 @@ preferably all buffers altogether.`,
 
 // NewMmapFile creates a buffer held in memory and persisted to file through
 // memory map.
-func NewMmapFile(config Config, log log.Modular, stats metrics.Type) (Type, error) {
+func NewMmapFile(config Config, mgr types.Manager, log log.Modular, stats metrics.Type) (Type, error) {
 	b, err := single.NewMmapBuffer(config.Mmap, log.NewModule(".buffer.mmap_file"), stats)
 	if err != nil {
 		return nil, err
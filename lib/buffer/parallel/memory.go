@@ -23,30 +23,99 @@ package parallel
 import (
 	"sync"
 
+	"github.com/Jeffail/benthos/lib/metrics"
 	"github.com/Jeffail/benthos/lib/types"
 )
 
 //------------------------------------------------------------------------------
 
+// String constants representing the behaviour of a memory buffer when it is
+// full.
+const (
+	OnFullBlock      = "block"
+	OnFullDropNewest = "drop_newest"
+	OnFullDropOldest = "drop_oldest"
+)
+
 // Memory is a parallel buffer implementation that allows multiple parallel
 // consumers to read and purge messages from the buffer asynchronously.
 type Memory struct {
 	messages []types.Message
 	bytes    int
+	count    int
 
-	cap  int
-	cond *sync.Cond
+	cap         int
+	maxMessages int
+	onFull      string
+	cond        *sync.Cond
+
+	mBlocked       metrics.StatCounter
+	mDroppedNewest metrics.StatCounter
+	mDroppedOldest metrics.StatCounter
+	mCount         metrics.StatGauge
 
 	closed bool
 }
 
 // NewMemory creates a memory based parallel buffer.
-func NewMemory(cap int) *Memory {
-	return &Memory{
-		bytes: 0,
-		cap:   cap,
-		cond:  sync.NewCond(&sync.Mutex{}),
+func NewMemory(cap int, opts ...func(*Memory)) *Memory {
+	m := &Memory{
+		bytes:  0,
+		cap:    cap,
+		onFull: OnFullBlock,
+		cond:   sync.NewCond(&sync.Mutex{}),
+	}
+	OptMemorySetStats(metrics.Noop())(m)
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// OptMemorySetMaxMessages sets the maximum number of messages the buffer can
+// hold, in addition to its byte capacity. Zero means no limit.
+func OptMemorySetMaxMessages(n int) func(*Memory) {
+	return func(m *Memory) {
+		m.maxMessages = n
+	}
+}
+
+// OptMemorySetOnFull sets the behaviour of the buffer when pushing a message
+// would exceed its limits, which can be either OnFullBlock, OnFullDropNewest or
+// OnFullDropOldest.
+func OptMemorySetOnFull(policy string) func(*Memory) {
+	return func(m *Memory) {
+		m.onFull = policy
+	}
+}
+
+// OptMemorySetStats sets the metrics aggregator used by the buffer.
+func OptMemorySetStats(stats metrics.Type) func(*Memory) {
+	return func(m *Memory) {
+		m.mBlocked = stats.GetCounter("buffer.blocked")
+		m.mDroppedNewest = stats.GetCounter("buffer.dropped_newest")
+		m.mDroppedOldest = stats.GetCounter("buffer.dropped_oldest")
+		m.mCount = stats.GetGauge("buffer.backlog_messages")
+	}
+}
+
+// full returns true if adding a message of a given size would exceed the
+// limits of the buffer.
+func (m *Memory) full(size int) bool {
+	if m.bytes+size > m.cap {
+		return true
 	}
+	return m.maxMessages > 0 && m.count+1 > m.maxMessages
+}
+
+// messageSize returns the size in bytes of a message.
+func messageSize(msg types.Message) int {
+	size := 0
+	msg.Iter(func(i int, b types.Part) error {
+		size += len(b.Get())
+		return nil
+	})
+	return size
 }
 
 //------------------------------------------------------------------------------
@@ -69,11 +138,7 @@ func (m *Memory) NextMessage() (types.Message, AckFunc, error) {
 	m.messages[0] = nil
 	m.messages = m.messages[1:]
 
-	messageSize := 0
-	msg.Iter(func(i int, b types.Part) error {
-		messageSize += len(b.Get())
-		return nil
-	})
+	msgSize := messageSize(msg)
 
 	m.cond.L.Unlock()
 
@@ -84,7 +149,9 @@ func (m *Memory) NextMessage() (types.Message, AckFunc, error) {
 			return 0, types.ErrTypeClosed
 		}
 		if ack {
-			m.bytes -= messageSize
+			m.bytes -= msgSize
+			m.count--
+			m.mCount.Set(int64(m.count))
 		} else {
 			m.messages = append([]types.Message{msg}, m.messages...)
 		}
@@ -99,11 +166,7 @@ func (m *Memory) NextMessage() (types.Message, AckFunc, error) {
 
 // PushMessage adds a new message to the stack. Returns the backlog in bytes.
 func (m *Memory) PushMessage(msg types.Message) (int, error) {
-	extraBytes := 0
-	msg.Iter(func(i int, b types.Part) error {
-		extraBytes += len(b.Get())
-		return nil
-	})
+	extraBytes := messageSize(msg)
 
 	if extraBytes > m.cap {
 		return 0, types.ErrMessageTooLarge
@@ -116,7 +179,31 @@ func (m *Memory) PushMessage(msg types.Message) (int, error) {
 		return 0, types.ErrTypeClosed
 	}
 
-	for (m.bytes + extraBytes) > m.cap {
+	if m.full(extraBytes) {
+		switch m.onFull {
+		case OnFullDropNewest:
+			m.mDroppedNewest.Incr(1)
+			backlog := m.bytes
+			m.cond.L.Unlock()
+			return backlog, nil
+		case OnFullDropOldest:
+			// Messages that are currently being read cannot be dropped, so if
+			// there aren't enough queued messages to make room we fall back to
+			// blocking.
+			for m.full(extraBytes) && len(m.messages) > 0 {
+				m.bytes -= messageSize(m.messages[0])
+				m.count--
+				m.messages[0] = nil
+				m.messages = m.messages[1:]
+				m.mDroppedOldest.Incr(1)
+			}
+		}
+		if m.full(extraBytes) {
+			m.mBlocked.Incr(1)
+		}
+	}
+
+	for m.full(extraBytes) {
 		m.cond.Wait()
 		if m.closed {
 			m.cond.L.Unlock()
@@ -126,6 +213,8 @@ func (m *Memory) PushMessage(msg types.Message) (int, error) {
 
 	m.messages = append(m.messages, msg.DeepCopy())
 	m.bytes += extraBytes
+	m.count++
+	m.mCount.Set(int64(m.count))
 
 	backlog := m.bytes
 
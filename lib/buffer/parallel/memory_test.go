@@ -297,3 +297,103 @@ func TestMemoryRejectLargeMessage(t *testing.T) {
 		t.Errorf("Unexpected error: %v != %v", exp, actual)
 	}
 }
+
+func TestMemoryMaxMessagesDropNewest(t *testing.T) {
+	block := NewMemory(1000, OptMemorySetMaxMessages(2), OptMemorySetOnFull(OnFullDropNewest))
+
+	for i := 0; i < 4; i++ {
+		if _, err := block.PushMessage(message.New([][]byte{[]byte(fmt.Sprintf("test%v", i))})); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for i := 0; i < 2; i++ {
+		m, ackFunc, err := block.NextMessage()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if exp, act := fmt.Sprintf("test%v", i), string(m.Get(0).Get()); exp != act {
+			t.Errorf("Wrong message: %v != %v", act, exp)
+		}
+		if _, err = ackFunc(true); err != nil {
+			t.Error(err)
+		}
+	}
+
+	block.cond.L.Lock()
+	remaining := len(block.messages)
+	block.cond.L.Unlock()
+	if remaining != 0 {
+		t.Errorf("Expected empty buffer: %v", remaining)
+	}
+}
+
+func TestMemoryDropOldest(t *testing.T) {
+	block := NewMemory(10, OptMemorySetOnFull(OnFullDropOldest))
+
+	for i := 0; i < 4; i++ {
+		if _, err := block.PushMessage(message.New([][]byte{[]byte(fmt.Sprintf("msg%v", i))})); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Only the last two messages fit within 10 bytes.
+	for i := 2; i < 4; i++ {
+		m, ackFunc, err := block.NextMessage()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if exp, act := fmt.Sprintf("msg%v", i), string(m.Get(0).Get()); exp != act {
+			t.Errorf("Wrong message: %v != %v", act, exp)
+		}
+		if _, err = ackFunc(true); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestMemoryDropOldestInFlight(t *testing.T) {
+	block := NewMemory(1000, OptMemorySetMaxMessages(1), OptMemorySetOnFull(OnFullDropOldest))
+
+	if _, err := block.PushMessage(message.New([][]byte{[]byte("first")})); err != nil {
+		t.Fatal(err)
+	}
+	_, ackFunc, err := block.NextMessage()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The only message is in flight and can't be dropped, so pushing blocks
+	// until it is acknowledged.
+	pushed := make(chan error)
+	go func() {
+		_, perr := block.PushMessage(message.New([][]byte{[]byte("second")}))
+		pushed <- perr
+	}()
+
+	select {
+	case <-pushed:
+		t.Fatal("Push did not block")
+	case <-time.After(time.Millisecond * 50):
+	}
+
+	if _, err = ackFunc(true); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err = <-pushed:
+		if err != nil {
+			t.Error(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Timed out")
+	}
+
+	m, _, err := block.NextMessage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp, act := "second", string(m.Get(0).Get()); exp != act {
+		t.Errorf("Wrong message: %v != %v", act, exp)
+	}
+}
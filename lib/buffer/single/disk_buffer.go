@@ -0,0 +1,1005 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package single
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/log"
+	"github.com/Jeffail/benthos/lib/message"
+	"github.com/Jeffail/benthos/lib/message/metadata"
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/dgraph-io/badger"
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
+)
+
+//------------------------------------------------------------------------------
+
+// DiskBufferConfig is config options for an embedded key/value store based
+// buffer.
+type DiskBufferConfig struct {
+	Path           string                    `json:"path" yaml:"path"`
+	Limit          int                       `json:"limit" yaml:"limit"`
+	OnFull         string                    `json:"on_full" yaml:"on_full"`
+	SyncPolicy     string                    `json:"sync_policy" yaml:"sync_policy"`
+	SyncIntervalMS int                       `json:"sync_interval_ms" yaml:"sync_interval_ms"`
+	Compression    string                    `json:"compression" yaml:"compression"`
+	EncryptionKey  string                    `json:"encryption_key" yaml:"encryption_key"`
+	Retention      DiskBufferRetentionConfig `json:"retention" yaml:"retention"`
+}
+
+// DiskBufferRetentionConfig contains config fields for retaining acknowledged
+// messages within a disk buffer so that they can be replayed.
+type DiskBufferRetentionConfig struct {
+	PeriodMS int `json:"period_ms" yaml:"period_ms"`
+	Bytes    int `json:"bytes" yaml:"bytes"`
+}
+
+// NewDiskBufferConfig creates a DiskBufferConfig with default values.
+func NewDiskBufferConfig() DiskBufferConfig {
+	return DiskBufferConfig{
+		Path:           "",
+		Limit:          1024 * 1024 * 1024, // 1GB
+		OnFull:         "block",
+		SyncPolicy:     "always",
+		SyncIntervalMS: 1000,
+		Compression:    "none",
+		EncryptionKey:  "",
+		Retention: DiskBufferRetentionConfig{
+			PeriodMS: 0,
+			Bytes:    0,
+		},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// Keys of the store begin with a single byte identifying the type of record,
+// messages, their metadata and their timestamps are each followed by the
+// sequence number they were written with.
+const (
+	diskPrefixMessage byte = 'm'
+	diskPrefixMeta    byte = 'd'
+	diskPrefixTime    byte = 't'
+)
+
+var (
+	diskBufferAckedKey = []byte("s:acked")
+	diskBufferSeqKey   = []byte("s:seq")
+)
+
+// diskGCInterval is the period at which the value log of the store is garbage
+// collected in order to reclaim space from removed messages.
+const diskGCInterval = time.Minute
+
+// Each stored value begins with a single byte describing how it was encoded,
+// where the lower bits identify the compression algorithm and the highest bit
+// is set when the value is encrypted. This allows the compression and
+// encryption settings of a buffer to change without breaking existing records.
+const (
+	diskCompressNone   byte = 0
+	diskCompressSnappy byte = 1
+	diskCompressGzip   byte = 2
+	diskCompressZstd   byte = 3
+
+	diskEncrypted byte = 0x80
+)
+
+var (
+	diskZstdOnce sync.Once
+	diskZstdEnc  *zstd.Encoder
+	diskZstdDec  *zstd.Decoder
+)
+
+// diskZstd returns a zstd encoder and decoder shared by all disk buffers, both
+// are safe for concurrent use with EncodeAll and DecodeAll.
+func diskZstd() (*zstd.Encoder, *zstd.Decoder) {
+	diskZstdOnce.Do(func() {
+		// Neither constructor can fail without options.
+		diskZstdEnc, _ = zstd.NewWriter(nil)
+		diskZstdDec, _ = zstd.NewReader(nil)
+	})
+	return diskZstdEnc, diskZstdDec
+}
+
+// DiskBuffer is a buffer that stores messages in an embedded key/value store.
+// Messages are only removed from the store once they have been shifted, and
+// therefore messages that were read but not acknowledged before a crash are
+// read again once the buffer is reopened.
+//
+// When retention is enabled shifted messages are kept in the store until they
+// fall outside of the retention window, and the buffer can be rewound in order
+// to read them again. Each message is identified by an offset, which is the
+// sequence number it was written with.
+type DiskBuffer struct {
+	conf DiskBufferConfig
+	db   *badger.DB
+
+	compression byte
+	aead        cipher.AEAD
+
+	log log.Modular
+
+	mDropped  metrics.StatCounter
+	mSyncErr  metrics.StatCounter
+	mRewind   metrics.StatCounter
+	mRetained metrics.StatGauge
+
+	retention bool
+	backlog   int
+	retained  int
+	ackedSeq  uint64
+	lastSeq   uint64
+	readKey   []byte
+	closed    bool
+
+	cond       *sync.Cond
+	closeChan  chan struct{}
+	closedChan chan struct{}
+}
+
+// NewDiskBuffer opens, or creates, a disk based buffer at the configured path.
+// Any messages stored within an existing buffer are read first.
+func NewDiskBuffer(conf DiskBufferConfig, log log.Modular, stats metrics.Type) (*DiskBuffer, error) {
+	if len(conf.Path) == 0 {
+		return nil, errors.New("a path must be specified")
+	}
+	if conf.Limit <= 0 {
+		return nil, errors.New("limit must be greater than zero")
+	}
+	switch conf.OnFull {
+	case "block", "drop_oldest":
+	default:
+		return nil, fmt.Errorf("on_full policy not recognised: %v", conf.OnFull)
+	}
+
+	var syncInterval time.Duration
+	switch conf.SyncPolicy {
+	case "always", "none":
+	case "interval":
+		if syncInterval = time.Duration(conf.SyncIntervalMS) * time.Millisecond; syncInterval <= 0 {
+			return nil, errors.New("sync_interval_ms must be greater than zero")
+		}
+	default:
+		return nil, fmt.Errorf("sync_policy not recognised: %v", conf.SyncPolicy)
+	}
+	if conf.Retention.PeriodMS < 0 || conf.Retention.Bytes < 0 {
+		return nil, errors.New("retention fields must not be negative")
+	}
+
+	var compression byte
+	switch conf.Compression {
+	case "none", "":
+		compression = diskCompressNone
+	case "snappy":
+		compression = diskCompressSnappy
+	case "gzip":
+		compression = diskCompressGzip
+	case "zstd":
+		compression = diskCompressZstd
+	default:
+		return nil, fmt.Errorf("compression not recognised: %v", conf.Compression)
+	}
+
+	var aead cipher.AEAD
+	if len(conf.EncryptionKey) > 0 {
+		key, err := hex.DecodeString(conf.EncryptionKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode encryption_key as hex: %v", err)
+		}
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create cipher from encryption_key: %v", err)
+		}
+		if aead, err = cipher.NewGCM(block); err != nil {
+			return nil, err
+		}
+	}
+
+	// Truncating the value log allows the store to be opened after a crash
+	// left a partially written entry at its tail.
+	db, err := badger.Open(badger.DefaultOptions(conf.Path).
+		WithSyncWrites(conf.SyncPolicy == "always").
+		WithTruncate(true).
+		WithLogger(diskLogger{log}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open buffer directory: %v", err)
+	}
+
+	d := &DiskBuffer{
+		conf:        conf,
+		db:          db,
+		compression: compression,
+		aead:        aead,
+		log:         log,
+		mDropped:    stats.GetCounter("buffer.dropped"),
+		mSyncErr:    stats.GetCounter("buffer.sync.error"),
+		mRewind:     stats.GetCounter("buffer.rewind"),
+		mRetained:   stats.GetGauge("buffer.retention.bytes"),
+		retention:   conf.Retention.PeriodMS > 0 || conf.Retention.Bytes > 0,
+		cond:        sync.NewCond(&sync.Mutex{}),
+		closeChan:   make(chan struct{}),
+		closedChan:  make(chan struct{}),
+	}
+
+	if err = db.Update(func(txn *badger.Txn) error {
+		acked, berr := diskGet(txn, diskBufferAckedKey)
+		if berr != nil {
+			return berr
+		}
+		if len(acked) == 8 {
+			d.ackedSeq = binary.BigEndian.Uint64(acked)
+		}
+		seq, berr := diskGet(txn, diskBufferSeqKey)
+		if berr != nil {
+			return berr
+		}
+		if len(seq) == 8 {
+			d.lastSeq = binary.BigEndian.Uint64(seq)
+		}
+		if berr = d.count(txn); berr != nil {
+			return berr
+		}
+
+		// If retention has been disabled since the buffer was last open then
+		// this removes any messages that were retained.
+		_, berr = d.prune(txn, time.Now())
+		return berr
+	}); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to initialise buffer directory: %v", err)
+	}
+
+	if d.backlog > 0 {
+		d.log.Infof("Recovered %v bytes of buffered messages from: %s\n", d.backlog, conf.Path)
+	}
+	d.log.Infof("Storing messages to directory: %s\n", conf.Path)
+
+	go d.loop(syncInterval)
+	return d, nil
+}
+
+//------------------------------------------------------------------------------
+
+// diskLogger adapts a log.Modular to the logger of the store. The store is
+// chatty at the info level and so those logs are demoted to debug.
+type diskLogger struct {
+	log log.Modular
+}
+
+func (l diskLogger) Errorf(format string, v ...interface{}) {
+	l.log.Errorf(format, v...)
+}
+
+func (l diskLogger) Warningf(format string, v ...interface{}) {
+	l.log.Warnf(format, v...)
+}
+
+func (l diskLogger) Infof(format string, v ...interface{}) {
+	l.log.Debugf(format, v...)
+}
+
+func (l diskLogger) Debugf(format string, v ...interface{}) {
+	l.log.Tracef(format, v...)
+}
+
+//------------------------------------------------------------------------------
+
+func (d *DiskBuffer) loop(syncInterval time.Duration) {
+	defer close(d.closedChan)
+
+	var syncChan <-chan time.Time
+	if syncInterval > 0 {
+		syncTicker := time.NewTicker(syncInterval)
+		defer syncTicker.Stop()
+		syncChan = syncTicker.C
+	}
+
+	gcTicker := time.NewTicker(diskGCInterval)
+	defer gcTicker.Stop()
+
+	for {
+		select {
+		case <-syncChan:
+			if err := d.db.Sync(); err != nil {
+				d.mSyncErr.Incr(1)
+				d.log.Errorf("Failed to sync buffer directory: %v\n", err)
+			}
+		case <-gcTicker.C:
+			// Each successful run rewrites a single value log file, and so
+			// we keep going until there's nothing left to collect.
+			for d.db.RunValueLogGC(0.5) == nil {
+			}
+		case <-d.closeChan:
+			return
+		}
+	}
+}
+
+// diskUint64 returns the big endian encoding of an integer.
+func diskUint64(v uint64) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, v)
+	return b
+}
+
+// diskKeyFor returns the store key of a record type for a sequence number, keys
+// are big endian so that they are iterated in the order they were written.
+func diskKeyFor(prefix byte, seq uint64) []byte {
+	return append([]byte{prefix}, diskUint64(seq)...)
+}
+
+// diskEncodeMetadata returns a serialised form of the metadata of each message
+// part, or nil if no part contains metadata.
+func diskEncodeMetadata(msg types.Message) ([]byte, error) {
+	parts := make([]map[string]string, msg.Len())
+	hasMeta := false
+	msg.Iter(func(i int, p types.Part) error {
+		p.Metadata().Iter(func(k, v string) error {
+			if parts[i] == nil {
+				parts[i] = map[string]string{}
+			}
+			parts[i][k] = v
+			hasMeta = true
+			return nil
+		})
+		return nil
+	})
+	if !hasMeta {
+		return nil, nil
+	}
+	return json.Marshal(parts)
+}
+
+// diskSeqOf returns the sequence number of a store key.
+func diskSeqOf(k []byte) uint64 {
+	return binary.BigEndian.Uint64(k[1:])
+}
+
+// diskGet returns a copy of the value of a key, or nil if the key does not
+// exist.
+func diskGet(txn *badger.Txn, k []byte) ([]byte, error) {
+	item, err := txn.Get(k)
+	if err == badger.ErrKeyNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return item.ValueCopy(nil)
+}
+
+// diskSize returns the size of the value of a key, or zero if the key does not
+// exist.
+func diskSize(txn *badger.Txn, k []byte) (int, error) {
+	item, err := txn.Get(k)
+	if err == badger.ErrKeyNotFound {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return int(item.ValueSize()), nil
+}
+
+// diskSeek returns the first key of a record type with a sequence number at or
+// after seq along with a copy of its value, or a nil key if there isn't one.
+// When reverse is true the last key at or before seq is returned instead.
+func diskSeek(txn *badger.Txn, prefix byte, seq uint64, reverse bool) ([]byte, []byte, error) {
+	opts := badger.DefaultIteratorOptions
+	opts.PrefetchValues = false
+	opts.Prefix = []byte{prefix}
+	opts.Reverse = reverse
+
+	it := txn.NewIterator(opts)
+	defer it.Close()
+
+	if it.Seek(diskKeyFor(prefix, seq)); !it.Valid() {
+		return nil, nil, nil
+	}
+	v, err := it.Item().ValueCopy(nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	return it.Item().KeyCopy(nil), v, nil
+}
+
+// diskRemove deletes a message from the store and returns the number of bytes
+// removed from the backlog.
+func diskRemove(txn *badger.Txn, seq uint64) (int, error) {
+	msgKey := diskKeyFor(diskPrefixMessage, seq)
+	metaKey := diskKeyFor(diskPrefixMeta, seq)
+
+	msgSize, err := diskSize(txn, msgKey)
+	if err != nil || msgSize == 0 {
+		return 0, err
+	}
+	metaSize, err := diskSize(txn, metaKey)
+	if err != nil {
+		return 0, err
+	}
+	for _, k := range [][]byte{msgKey, metaKey, diskKeyFor(diskPrefixTime, seq)} {
+		if err = txn.Delete(k); err != nil {
+			return 0, err
+		}
+	}
+	return msgSize + metaSize, nil
+}
+
+// count walks the store and calculates the size of both the backlog and
+// retained messages.
+func (d *DiskBuffer) count(txn *badger.Txn) error {
+	opts := badger.DefaultIteratorOptions
+	opts.PrefetchValues = false
+	opts.Prefix = []byte{diskPrefixMessage}
+
+	sizes := map[uint64]int{}
+	it := txn.NewIterator(opts)
+	for it.Rewind(); it.Valid(); it.Next() {
+		sizes[diskSeqOf(it.Item().Key())] = int(it.Item().ValueSize())
+	}
+	it.Close()
+
+	backlog, retained := 0, 0
+	for seq, size := range sizes {
+		metaSize, err := diskSize(txn, diskKeyFor(diskPrefixMeta, seq))
+		if err != nil {
+			return err
+		}
+		if seq <= d.ackedSeq {
+			retained += size + metaSize
+		} else {
+			backlog += size + metaSize
+		}
+	}
+	d.backlog, d.retained = backlog, retained
+	d.mRetained.Set(int64(d.retained))
+	return nil
+}
+
+// setAcked updates the offset of the last acknowledged message.
+func (d *DiskBuffer) setAcked(txn *badger.Txn, seq uint64) error {
+	if err := txn.Set(diskBufferAckedKey, diskUint64(seq)); err != nil {
+		return err
+	}
+	d.ackedSeq = seq
+	return nil
+}
+
+// resetAcked restores the acknowledged offset and recalculates sizes after a
+// failed transaction.
+func (d *DiskBuffer) resetAcked(seq uint64) {
+	d.ackedSeq = seq
+	d.db.View(func(txn *badger.Txn) error {
+		return d.count(txn)
+	})
+}
+
+// prune removes acknowledged messages that fall outside of the retention
+// window, starting with the oldest, and returns the number of bytes removed.
+func (d *DiskBuffer) prune(txn *badger.Txn, now time.Time) (int, error) {
+	var oldest time.Time
+	if d.conf.Retention.PeriodMS > 0 {
+		oldest = now.Add(-time.Duration(d.conf.Retention.PeriodMS) * time.Millisecond)
+	}
+
+	var removed int
+	for {
+		k, _, err := diskSeek(txn, diskPrefixMessage, 0, false)
+		if err != nil {
+			return removed, err
+		}
+		if k == nil || diskSeqOf(k) > d.ackedSeq {
+			break
+		}
+		seq := diskSeqOf(k)
+		if d.retention {
+			expired := false
+			if d.conf.Retention.Bytes > 0 && d.retained-removed > d.conf.Retention.Bytes {
+				expired = true
+			}
+			if !oldest.IsZero() {
+				ts, err := diskGet(txn, diskKeyFor(diskPrefixTime, seq))
+				if err != nil {
+					return removed, err
+				}
+				expired = expired || len(ts) != 8 ||
+					time.Unix(0, int64(binary.BigEndian.Uint64(ts))).Before(oldest)
+			}
+			if !expired {
+				break
+			}
+		}
+		n, err := diskRemove(txn, seq)
+		if err != nil {
+			return removed, err
+		}
+		removed += n
+	}
+	d.retained -= removed
+	d.mRetained.Set(int64(d.retained))
+	return removed, nil
+}
+
+// encode compresses and encrypts a value according to the config of the buffer.
+func (d *DiskBuffer) encode(b []byte) ([]byte, error) {
+	header := d.compression
+	switch d.compression {
+	case diskCompressSnappy:
+		b = snappy.Encode(nil, b)
+	case diskCompressGzip:
+		buf := &bytes.Buffer{}
+		zw := gzip.NewWriter(buf)
+		if _, err := zw.Write(b); err != nil {
+			return nil, err
+		}
+		if err := zw.Close(); err != nil {
+			return nil, err
+		}
+		b = buf.Bytes()
+	case diskCompressZstd:
+		enc, _ := diskZstd()
+		b = enc.EncodeAll(b, nil)
+	}
+
+	if d.aead != nil {
+		header |= diskEncrypted
+		nonce := make([]byte, d.aead.NonceSize())
+		if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+			return nil, err
+		}
+		b = d.aead.Seal(nonce, nonce, b, nil)
+	}
+
+	return append([]byte{header}, b...), nil
+}
+
+// decode reverses encode, using the header of the value rather than the config
+// of the buffer to determine how it was encoded.
+func (d *DiskBuffer) decode(b []byte) ([]byte, error) {
+	if len(b) == 0 {
+		return nil, types.ErrBlockCorrupted
+	}
+	header, b := b[0], b[1:]
+
+	if header&diskEncrypted != 0 {
+		if d.aead == nil {
+			return nil, errors.New("buffered message is encrypted but no encryption_key is set")
+		}
+		nonceSize := d.aead.NonceSize()
+		if len(b) < nonceSize {
+			return nil, types.ErrBlockCorrupted
+		}
+		var err error
+		if b, err = d.aead.Open(nil, b[:nonceSize], b[nonceSize:], nil); err != nil {
+			return nil, fmt.Errorf("failed to decrypt buffered message: %v", err)
+		}
+	}
+
+	switch header &^ diskEncrypted {
+	case diskCompressNone:
+		return b, nil
+	case diskCompressSnappy:
+		return snappy.Decode(nil, b)
+	case diskCompressGzip:
+		zr, err := gzip.NewReader(bytes.NewReader(b))
+		if err != nil {
+			return nil, err
+		}
+		return ioutil.ReadAll(zr)
+	case diskCompressZstd:
+		_, dec := diskZstd()
+		return dec.DecodeAll(b, nil)
+	}
+	return nil, types.ErrBlockCorrupted
+}
+
+//------------------------------------------------------------------------------
+
+// CloseOnceEmpty closes the buffer once the backlog reaches 0.
+func (d *DiskBuffer) CloseOnceEmpty() {
+	d.cond.L.Lock()
+	for d.backlog > 0 && !d.closed {
+		d.cond.Wait()
+	}
+	d.cond.L.Unlock()
+	d.Close()
+}
+
+// Close unblocks any blocked calls and closes the underlying store, messages
+// remaining in the store are preserved.
+func (d *DiskBuffer) Close() {
+	d.cond.L.Lock()
+	defer d.cond.L.Unlock()
+
+	if d.closed {
+		return
+	}
+	d.closed = true
+	d.cond.Broadcast()
+
+	close(d.closeChan)
+	<-d.closedChan
+
+	if err := d.db.Sync(); err != nil {
+		d.log.Errorf("Failed to sync buffer directory: %v\n", err)
+	}
+	if err := d.db.Close(); err != nil {
+		d.log.Errorf("Failed to close buffer directory: %v\n", err)
+	}
+}
+
+// ShiftMessage removes the last read message from the store, or marks it as
+// acknowledged when retention is enabled. Returns the backlog in bytes.
+func (d *DiskBuffer) ShiftMessage() (int, error) {
+	d.cond.L.Lock()
+	defer func() {
+		d.cond.Broadcast()
+		d.cond.L.Unlock()
+	}()
+
+	if d.closed {
+		return d.backlog, types.ErrTypeClosed
+	}
+
+	// If the buffer was rewound whilst a message was in flight then the read
+	// position has already been reset and there's nothing to acknowledge.
+	if d.retention && d.readKey == nil {
+		return d.backlog, nil
+	}
+
+	var removed int
+	prevAcked := d.ackedSeq
+	err := d.db.Update(func(txn *badger.Txn) error {
+		k := d.readKey
+		if k == nil {
+			var err error
+			if k, _, err = diskSeek(txn, diskPrefixMessage, d.ackedSeq+1, false); err != nil || k == nil {
+				return err
+			}
+		}
+		seq := diskSeqOf(k)
+		if !d.retention {
+			var err error
+			removed, err = diskRemove(txn, seq)
+			return err
+		}
+
+		msgSize, err := diskSize(txn, k)
+		if err != nil {
+			return err
+		}
+		if msgSize > 0 {
+			metaSize, err := diskSize(txn, diskKeyFor(diskPrefixMeta, seq))
+			if err != nil {
+				return err
+			}
+			removed = msgSize + metaSize
+		}
+		if err = d.setAcked(txn, seq); err != nil {
+			return err
+		}
+		d.retained += removed
+		_, err = d.prune(txn, time.Now())
+		return err
+	})
+	if err == nil {
+		d.backlog -= removed
+		d.readKey = nil
+	} else if d.retention {
+		d.resetAcked(prevAcked)
+	}
+	return d.backlog, err
+}
+
+// NextMessage reads the oldest message in the store, this call blocks until
+// there's something to read. The message is preserved until ShiftMessage is
+// called.
+func (d *DiskBuffer) NextMessage() (types.Message, error) {
+	d.cond.L.Lock()
+	defer d.cond.L.Unlock()
+
+	for d.backlog == 0 && !d.closed {
+		d.cond.Wait()
+	}
+	if d.closed {
+		return nil, types.ErrTypeClosed
+	}
+
+	var msg *message.Type
+	err := d.db.View(func(txn *badger.Txn) error {
+		k, v, err := diskSeek(txn, diskPrefixMessage, d.ackedSeq+1, false)
+		if err != nil {
+			return err
+		}
+		if k == nil {
+			return types.ErrBlockCorrupted
+		}
+		d.readKey = k
+
+		if v, err = d.decode(v); err != nil {
+			return err
+		}
+		if msg, err = message.FromBytes(v); err != nil {
+			return err
+		}
+
+		metaBytes, err := diskGet(txn, diskKeyFor(diskPrefixMeta, diskSeqOf(k)))
+		if err != nil {
+			return err
+		}
+		if metaBytes != nil {
+			if metaBytes, err = d.decode(metaBytes); err != nil {
+				return err
+			}
+			var parts []map[string]string
+			if err = json.Unmarshal(metaBytes, &parts); err != nil {
+				return err
+			}
+			for i, m := range parts {
+				if m != nil && i < msg.Len() {
+					msg.Get(i).SetMetadata(metadata.New(m))
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+// PushMessage writes a new message to the store, returns the backlog in bytes.
+func (d *DiskBuffer) PushMessage(msg types.Message) (int, error) {
+	d.cond.L.Lock()
+	defer func() {
+		d.cond.Broadcast()
+		d.cond.L.Unlock()
+	}()
+
+	blob, err := d.encode(message.ToBytes(msg))
+	if err != nil {
+		return 0, err
+	}
+	metaBlob, err := diskEncodeMetadata(msg)
+	if err != nil {
+		return 0, err
+	}
+	if metaBlob != nil {
+		if metaBlob, err = d.encode(metaBlob); err != nil {
+			return 0, err
+		}
+	}
+
+	size := len(blob) + len(metaBlob)
+	if size > d.conf.Limit {
+		return 0, types.ErrMessageTooLarge
+	}
+
+	if d.conf.OnFull == "block" {
+		for d.backlog+size > d.conf.Limit && !d.closed {
+			d.cond.Wait()
+		}
+	}
+	if d.closed {
+		return 0, types.ErrTypeClosed
+	}
+
+	var removed, dropped int
+	seq := d.lastSeq + 1
+	err = d.db.Update(func(txn *badger.Txn) error {
+		removed, dropped = 0, 0
+		for d.backlog-removed+size > d.conf.Limit {
+			k, _, err := diskSeek(txn, diskPrefixMessage, d.ackedSeq+1, false)
+			if err != nil {
+				return err
+			}
+			if k == nil {
+				break
+			}
+			n, err := diskRemove(txn, diskSeqOf(k))
+			if err != nil {
+				return err
+			}
+			removed += n
+			dropped++
+		}
+
+		if err := txn.Set(diskBufferSeqKey, diskUint64(seq)); err != nil {
+			return err
+		}
+		if err := txn.Set(diskKeyFor(diskPrefixMessage, seq), blob); err != nil {
+			return err
+		}
+		if metaBlob != nil {
+			if err := txn.Set(diskKeyFor(diskPrefixMeta, seq), metaBlob); err != nil {
+				return err
+			}
+		}
+		if d.retention {
+			ts := diskUint64(uint64(time.Now().UnixNano()))
+			if err := txn.Set(diskKeyFor(diskPrefixTime, seq), ts); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err == nil {
+		d.lastSeq = seq
+		d.backlog += size - removed
+		if dropped > 0 {
+			d.mDropped.Incr(int64(dropped))
+		}
+	}
+	return d.backlog, err
+}
+
+//------------------------------------------------------------------------------
+
+// ErrRetentionDisabled is returned when attempting to rewind a disk buffer that
+// does not have retention enabled.
+var ErrRetentionDisabled = errors.New("buffer retention is not enabled")
+
+// DiskBufferOffsets describes the range of messages stored within a disk
+// buffer. Offsets up to and including Acked have been acknowledged and are only
+// stored when retention is enabled.
+type DiskBufferOffsets struct {
+	Oldest uint64 `json:"oldest"`
+	Acked  uint64 `json:"acked"`
+	Newest uint64 `json:"newest"`
+}
+
+// Offsets returns the range of offsets currently stored in the buffer.
+func (d *DiskBuffer) Offsets() (DiskBufferOffsets, error) {
+	d.cond.L.Lock()
+	defer d.cond.L.Unlock()
+
+	if d.closed {
+		return DiskBufferOffsets{}, types.ErrTypeClosed
+	}
+
+	offsets := DiskBufferOffsets{Acked: d.ackedSeq}
+	err := d.db.View(func(txn *badger.Txn) error {
+		k, _, err := diskSeek(txn, diskPrefixMessage, 0, false)
+		if err != nil || k == nil {
+			return err
+		}
+		offsets.Oldest = diskSeqOf(k)
+		if k, _, err = diskSeek(txn, diskPrefixMessage, math.MaxUint64, true); err != nil || k == nil {
+			return err
+		}
+		offsets.Newest = diskSeqOf(k)
+		return nil
+	})
+	return offsets, err
+}
+
+// Rewind resets the read position of the buffer so that the next message read
+// is the message at the given offset, or the oldest retained message if the
+// offset is no longer stored. Returns the offset of the next message to be
+// read.
+func (d *DiskBuffer) Rewind(offset uint64) (uint64, error) {
+	d.cond.L.Lock()
+	defer func() {
+		d.cond.Broadcast()
+		d.cond.L.Unlock()
+	}()
+
+	if d.closed {
+		return 0, types.ErrTypeClosed
+	}
+	if !d.retention {
+		return 0, ErrRetentionDisabled
+	}
+	if offset > d.ackedSeq+1 {
+		return 0, fmt.Errorf("offset %v has not yet been acknowledged", offset)
+	}
+	return d.rewind(offset)
+}
+
+// RewindToTime resets the read position of the buffer so that the next message
+// read is the oldest message written at or after the given time. Returns the
+// offset of the next message to be read.
+func (d *DiskBuffer) RewindToTime(t time.Time) (uint64, error) {
+	d.cond.L.Lock()
+	defer func() {
+		d.cond.Broadcast()
+		d.cond.L.Unlock()
+	}()
+
+	if d.closed {
+		return 0, types.ErrTypeClosed
+	}
+	if !d.retention {
+		return 0, ErrRetentionDisabled
+	}
+
+	offset := d.ackedSeq + 1
+	d.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = []byte{diskPrefixTime}
+
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		for it.Rewind(); it.Valid() && diskSeqOf(it.Item().Key()) <= d.ackedSeq; it.Next() {
+			v, err := it.Item().ValueCopy(nil)
+			if err != nil {
+				return err
+			}
+			if len(v) == 8 && !time.Unix(0, int64(binary.BigEndian.Uint64(v))).Before(t) {
+				offset = diskSeqOf(it.Item().Key())
+				return nil
+			}
+		}
+		return nil
+	})
+	return d.rewind(offset)
+}
+
+// rewind moves the acknowledged offset to just before the given offset, the
+// lock must be held by the caller.
+func (d *DiskBuffer) rewind(offset uint64) (uint64, error) {
+	prevAcked := d.ackedSeq
+	err := d.db.Update(func(txn *badger.Txn) error {
+		k, _, err := diskSeek(txn, diskPrefixMessage, 0, false)
+		if err != nil {
+			return err
+		}
+		if k != nil && diskSeqOf(k) > offset {
+			offset = diskSeqOf(k)
+		}
+		if offset == 0 {
+			offset = 1
+		}
+		if err = d.setAcked(txn, offset-1); err != nil {
+			return err
+		}
+		return d.count(txn)
+	})
+	if err != nil {
+		d.resetAcked(prevAcked)
+		return 0, err
+	}
+	d.readKey = nil
+	d.mRewind.Incr(1)
+	d.log.Infof("Rewound buffer to offset: %v\n", offset)
+	return offset, nil
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,580 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package single
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/log"
+	"github.com/Jeffail/benthos/lib/message"
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+)
+
+func newDiskBufferTestConf(t *testing.T) (DiskBufferConfig, func()) {
+	dir, err := ioutil.TempDir("", "benthos_test_")
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf := NewDiskBufferConfig()
+	conf.Path = filepath.Join(dir, "buffer")
+	return conf, func() {
+		os.RemoveAll(dir)
+	}
+}
+
+func TestDiskBufferBasic(t *testing.T) {
+	conf, cleanUp := newDiskBufferTestConf(t)
+	defer cleanUp()
+
+	block, err := NewDiskBuffer(conf, log.Noop(), metrics.Noop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer block.Close()
+
+	n := 100
+	for i := 0; i < n; i++ {
+		if _, err = block.PushMessage(message.New(
+			[][]byte{
+				[]byte("hello"),
+				[]byte("world"),
+				[]byte("12345"),
+				[]byte(fmt.Sprintf("test%v", i)),
+			},
+		)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for i := 0; i < n; i++ {
+		m, err := block.NextMessage()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if exp, act := 4, m.Len(); exp != act {
+			t.Fatalf("Wrong # parts: %v != %v", act, exp)
+		}
+		if exp, act := fmt.Sprintf("test%v", i), string(m.Get(3).Get()); exp != act {
+			t.Errorf("Wrong order of messages: %v != %v", act, exp)
+		}
+		if _, err = block.ShiftMessage(); err != nil {
+			t.Error(err)
+		}
+	}
+
+	if backlog, err := block.ShiftMessage(); err != nil {
+		t.Error(err)
+	} else if backlog != 0 {
+		t.Errorf("Expected empty backlog: %v", backlog)
+	}
+}
+
+func TestDiskBufferRecovery(t *testing.T) {
+	conf, cleanUp := newDiskBufferTestConf(t)
+	defer cleanUp()
+
+	block, err := NewDiskBuffer(conf, log.Noop(), metrics.Noop())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 3; i++ {
+		msg := message.New([][]byte{[]byte(fmt.Sprintf("test%v", i))})
+		msg.Get(0).Metadata().Set("foo", fmt.Sprintf("bar%v", i))
+		if _, err = block.PushMessage(msg); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Read and acknowledge the first message, then read the second without
+	// acknowledging it.
+	if _, err = block.NextMessage(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = block.ShiftMessage(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = block.NextMessage(); err != nil {
+		t.Fatal(err)
+	}
+	block.Close()
+
+	if block, err = NewDiskBuffer(conf, log.Noop(), metrics.Noop()); err != nil {
+		t.Fatal(err)
+	}
+	defer block.Close()
+
+	for i := 1; i < 3; i++ {
+		m, err := block.NextMessage()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if exp, act := fmt.Sprintf("test%v", i), string(m.Get(0).Get()); exp != act {
+			t.Errorf("Wrong message: %v != %v", act, exp)
+		}
+		if exp, act := fmt.Sprintf("bar%v", i), m.Get(0).Metadata().Get("foo"); exp != act {
+			t.Errorf("Wrong metadata: %v != %v", act, exp)
+		}
+		if _, err = block.ShiftMessage(); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestDiskBufferDropOldest(t *testing.T) {
+	conf, cleanUp := newDiskBufferTestConf(t)
+	defer cleanUp()
+
+	conf.OnFull = "drop_oldest"
+	conf.Limit = 36
+	conf.SyncPolicy = "none"
+
+	block, err := NewDiskBuffer(conf, log.Noop(), metrics.Noop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer block.Close()
+
+	// Each message is 12 bytes when stored.
+	for i := 0; i < 5; i++ {
+		if _, err = block.PushMessage(message.New([][]byte{[]byte(fmt.Sprintf("te%v", i))})); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for i := 2; i < 5; i++ {
+		m, err := block.NextMessage()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if exp, act := fmt.Sprintf("te%v", i), string(m.Get(0).Get()); exp != act {
+			t.Errorf("Wrong message: %v != %v", act, exp)
+		}
+		if _, err = block.ShiftMessage(); err != nil {
+			t.Error(err)
+		}
+	}
+
+	if _, err = block.PushMessage(message.New([][]byte{make([]byte, 100)})); err != types.ErrMessageTooLarge {
+		t.Errorf("Wrong error returned: %v != %v", err, types.ErrMessageTooLarge)
+	}
+}
+
+func TestDiskBufferBlocks(t *testing.T) {
+	conf, cleanUp := newDiskBufferTestConf(t)
+	defer cleanUp()
+
+	conf.Limit = 24
+	conf.SyncPolicy = "interval"
+	conf.SyncIntervalMS = 10
+
+	block, err := NewDiskBuffer(conf, log.Noop(), metrics.Noop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer block.Close()
+
+	for i := 0; i < 2; i++ {
+		if _, err = block.PushMessage(message.New([][]byte{[]byte(fmt.Sprintf("te%v", i))})); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	pushed := make(chan error)
+	go func() {
+		_, perr := block.PushMessage(message.New([][]byte{[]byte("te2")}))
+		pushed <- perr
+	}()
+
+	select {
+	case <-pushed:
+		t.Fatal("Expected push to block")
+	case <-time.After(time.Millisecond * 50):
+	}
+
+	if _, err = block.NextMessage(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = block.ShiftMessage(); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case err = <-pushed:
+		if err != nil {
+			t.Error(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Timed out waiting for push")
+	}
+}
+
+func TestDiskBufferClose(t *testing.T) {
+	conf, cleanUp := newDiskBufferTestConf(t)
+	defer cleanUp()
+
+	block, err := NewDiskBuffer(conf, log.Noop(), metrics.Noop())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	read := make(chan error)
+	go func() {
+		_, rerr := block.NextMessage()
+		read <- rerr
+	}()
+
+	block.Close()
+	block.Close()
+
+	select {
+	case err = <-read:
+		if err != types.ErrTypeClosed {
+			t.Errorf("Wrong error returned: %v != %v", err, types.ErrTypeClosed)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Timed out waiting for read")
+	}
+}
+
+func TestDiskBufferBadConfig(t *testing.T) {
+	conf, cleanUp := newDiskBufferTestConf(t)
+	defer cleanUp()
+
+	tests := map[string]func(c *DiskBufferConfig){
+		"no path": func(c *DiskBufferConfig) {
+			c.Path = ""
+		},
+		"bad on_full": func(c *DiskBufferConfig) {
+			c.OnFull = "nope"
+		},
+		"bad sync_policy": func(c *DiskBufferConfig) {
+			c.SyncPolicy = "nope"
+		},
+		"bad sync_interval_ms": func(c *DiskBufferConfig) {
+			c.SyncPolicy = "interval"
+			c.SyncIntervalMS = 0
+		},
+		"bad compression": func(c *DiskBufferConfig) {
+			c.Compression = "nope"
+		},
+		"bad encryption_key encoding": func(c *DiskBufferConfig) {
+			c.EncryptionKey = "not hex"
+		},
+		"bad encryption_key length": func(c *DiskBufferConfig) {
+			c.EncryptionKey = "0001"
+		},
+	}
+
+	for name, mod := range tests {
+		c := conf
+		mod(&c)
+		if _, err := NewDiskBuffer(c, log.Noop(), metrics.Noop()); err == nil {
+			t.Errorf("%v: expected error", name)
+		}
+	}
+}
+
+func TestDiskBufferEncoding(t *testing.T) {
+	key := "000102030405060708090a0b0c0d0e0f"
+
+	for _, comp := range []string{"none", "snappy", "gzip", "zstd"} {
+		for _, encKey := range []string{"", key} {
+			conf, cleanUp := newDiskBufferTestConf(t)
+
+			conf.Compression = comp
+			conf.EncryptionKey = encKey
+
+			block, err := NewDiskBuffer(conf, log.Noop(), metrics.Noop())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			msg := message.New([][]byte{[]byte("hello world hello world hello world")})
+			msg.Get(0).Metadata().Set("foo", "bar")
+			if _, err = block.PushMessage(msg); err != nil {
+				t.Fatal(err)
+			}
+			block.Close()
+
+			var fileBytes []byte
+			if err = filepath.Walk(conf.Path, func(path string, info os.FileInfo, err error) error {
+				if err != nil || info.IsDir() {
+					return err
+				}
+				b, err := ioutil.ReadFile(path)
+				fileBytes = append(fileBytes, b...)
+				return err
+			}); err != nil {
+				t.Fatal(err)
+			}
+			if exp, act := encKey == "", bytes.Contains(fileBytes, []byte("hello world")); exp != act {
+				t.Errorf("%v %v: plain text found in file: %v", comp, encKey, act)
+			}
+
+			// Records remain readable after the compression changes.
+			conf.Compression = "snappy"
+			if block, err = NewDiskBuffer(conf, log.Noop(), metrics.Noop()); err != nil {
+				t.Fatal(err)
+			}
+
+			m, err := block.NextMessage()
+			if err != nil {
+				t.Errorf("%v %v: %v", comp, encKey, err)
+			} else {
+				if exp, act := "hello world hello world hello world", string(m.Get(0).Get()); exp != act {
+					t.Errorf("%v %v: wrong message: %v != %v", comp, encKey, act, exp)
+				}
+				if exp, act := "bar", m.Get(0).Metadata().Get("foo"); exp != act {
+					t.Errorf("%v %v: wrong metadata: %v != %v", comp, encKey, act, exp)
+				}
+			}
+			block.Close()
+
+			if encKey != "" {
+				conf.EncryptionKey = ""
+				if block, err = NewDiskBuffer(conf, log.Noop(), metrics.Noop()); err != nil {
+					t.Fatal(err)
+				}
+				if _, err = block.NextMessage(); err == nil {
+					t.Errorf("%v: expected error reading encrypted message without key", comp)
+				}
+				block.Close()
+			}
+
+			cleanUp()
+		}
+	}
+}
+
+func TestDiskBufferRetention(t *testing.T) {
+	conf, cleanUp := newDiskBufferTestConf(t)
+	defer cleanUp()
+
+	conf.Retention.PeriodMS = 60000
+
+	block, err := NewDiskBuffer(conf, log.Noop(), metrics.Noop())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 5; i++ {
+		if _, err = block.PushMessage(message.New([][]byte{[]byte(fmt.Sprintf("test%v", i))})); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := 0; i < 5; i++ {
+		if _, err = block.NextMessage(); err != nil {
+			t.Fatal(err)
+		}
+		if _, err = block.ShiftMessage(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if block.backlog != 0 {
+		t.Errorf("Expected empty backlog: %v", block.backlog)
+	}
+
+	offsets, err := block.Offsets()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp, act := (DiskBufferOffsets{Oldest: 1, Acked: 5, Newest: 5}), offsets; exp != act {
+		t.Errorf("Wrong offsets: %+v != %+v", act, exp)
+	}
+
+	if _, err = block.Rewind(7); err == nil {
+		t.Error("Expected error when rewinding past unacknowledged offset")
+	}
+
+	offset, err := block.Rewind(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if offset != 3 {
+		t.Errorf("Wrong offset: %v != %v", offset, 3)
+	}
+
+	// The buffer should still be rewound after reopening.
+	block.Close()
+	if block, err = NewDiskBuffer(conf, log.Noop(), metrics.Noop()); err != nil {
+		t.Fatal(err)
+	}
+	defer block.Close()
+
+	for i := 2; i < 5; i++ {
+		m, err := block.NextMessage()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if exp, act := fmt.Sprintf("test%v", i), string(m.Get(0).Get()); exp != act {
+			t.Errorf("Wrong message: %v != %v", act, exp)
+		}
+		if _, err = block.ShiftMessage(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Rewinding beyond the oldest message starts from the oldest message.
+	if offset, err = block.Rewind(0); err != nil {
+		t.Fatal(err)
+	}
+	if offset != 1 {
+		t.Errorf("Wrong offset: %v != %v", offset, 1)
+	}
+	m, err := block.NextMessage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp, act := "test0", string(m.Get(0).Get()); exp != act {
+		t.Errorf("Wrong message: %v != %v", act, exp)
+	}
+
+	// Rewinding with a message in flight means the in flight message is not
+	// acknowledged.
+	if _, err = block.Rewind(1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = block.ShiftMessage(); err != nil {
+		t.Fatal(err)
+	}
+	if m, err = block.NextMessage(); err != nil {
+		t.Fatal(err)
+	}
+	if exp, act := "test0", string(m.Get(0).Get()); exp != act {
+		t.Errorf("Wrong message: %v != %v", act, exp)
+	}
+}
+
+func TestDiskBufferRetentionBytes(t *testing.T) {
+	conf, cleanUp := newDiskBufferTestConf(t)
+	defer cleanUp()
+
+	// Each message is 14 bytes.
+	conf.Retention.Bytes = 28
+
+	block, err := NewDiskBuffer(conf, log.Noop(), metrics.Noop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer block.Close()
+
+	for i := 0; i < 5; i++ {
+		if _, err = block.PushMessage(message.New([][]byte{[]byte(fmt.Sprintf("test%v", i))})); err != nil {
+			t.Fatal(err)
+		}
+		if _, err = block.NextMessage(); err != nil {
+			t.Fatal(err)
+		}
+		if _, err = block.ShiftMessage(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	offsets, err := block.Offsets()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp, act := (DiskBufferOffsets{Oldest: 4, Acked: 5, Newest: 5}), offsets; exp != act {
+		t.Errorf("Wrong offsets: %+v != %+v", act, exp)
+	}
+	if exp, act := 28, block.retained; exp != act {
+		t.Errorf("Wrong retained size: %v != %v", act, exp)
+	}
+}
+
+func TestDiskBufferRewindToTime(t *testing.T) {
+	conf, cleanUp := newDiskBufferTestConf(t)
+	defer cleanUp()
+
+	conf.Retention.PeriodMS = 60000
+
+	block, err := NewDiskBuffer(conf, log.Noop(), metrics.Noop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer block.Close()
+
+	var tsMiddle time.Time
+	for i := 0; i < 4; i++ {
+		if i == 2 {
+			<-time.After(time.Millisecond * 10)
+			tsMiddle = time.Now()
+		}
+		if _, err = block.PushMessage(message.New([][]byte{[]byte(fmt.Sprintf("test%v", i))})); err != nil {
+			t.Fatal(err)
+		}
+		if _, err = block.NextMessage(); err != nil {
+			t.Fatal(err)
+		}
+		if _, err = block.ShiftMessage(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	offset, err := block.RewindToTime(tsMiddle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if offset != 3 {
+		t.Errorf("Wrong offset: %v != %v", offset, 3)
+	}
+	m, err := block.NextMessage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp, act := "test2", string(m.Get(0).Get()); exp != act {
+		t.Errorf("Wrong message: %v != %v", act, exp)
+	}
+
+	// A time in the future means nothing is replayed.
+	if _, err = block.ShiftMessage(); err != nil {
+		t.Fatal(err)
+	}
+	if offset, err = block.RewindToTime(time.Now().Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if offset != 4 {
+		t.Errorf("Wrong offset: %v != %v", offset, 4)
+	}
+}
+
+func TestDiskBufferRewindDisabled(t *testing.T) {
+	conf, cleanUp := newDiskBufferTestConf(t)
+	defer cleanUp()
+
+	block, err := NewDiskBuffer(conf, log.Noop(), metrics.Noop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer block.Close()
+
+	if _, err = block.Rewind(0); err != ErrRetentionDisabled {
+		t.Errorf("Wrong error: %v != %v", err, ErrRetentionDisabled)
+	}
+}
@@ -31,13 +31,17 @@ import (
 
 // MemoryConfig is config values for a purely memory based ring buffer type.
 type MemoryConfig struct {
-	Limit int `json:"limit" yaml:"limit"`
+	Limit       int    `json:"limit" yaml:"limit"`
+	MaxMessages int    `json:"max_messages" yaml:"max_messages"`
+	OnFull      string `json:"on_full" yaml:"on_full"`
 }
 
 // NewMemoryConfig creates a new MemoryConfig with default values.
 func NewMemoryConfig() MemoryConfig {
 	return MemoryConfig{
-		Limit: 1024 * 1024 * 500, // 500MB
+		Limit:       1024 * 1024 * 500, // 500MB
+		MaxMessages: 0,
+		OnFull:      "block",
 	}
 }
 
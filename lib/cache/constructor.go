@@ -42,6 +42,11 @@ type TypeSpec struct {
 	description string
 }
 
+// Description returns the markdown description of the type.
+func (t TypeSpec) Description() string {
+	return t.description
+}
+
 // Constructors is a map of all cache types with their specs.
 var Constructors = map[string]TypeSpec{}
 
@@ -49,28 +54,42 @@ var Constructors = map[string]TypeSpec{}
 
 // String constants representing each cache type.
 const (
-	TypeDynamoDB  = "dynamodb"
-	TypeMemcached = "memcached"
-	TypeMemory    = "memory"
+	TypeDynamoDB   = "dynamodb"
+	TypeFile       = "file"
+	TypeMemcached  = "memcached"
+	TypeMemory     = "memory"
+	TypeMultilevel = "multilevel"
+	TypeRedis      = "redis"
+	TypeS3         = "s3"
 )
 
 //------------------------------------------------------------------------------
 
 // Config is the all encompassing configuration struct for all cache types.
 type Config struct {
-	Type      string          `json:"type" yaml:"type"`
-	DynamoDB  DynamoDBConfig  `json:"dynamodb" yaml:"dynamodb"`
-	Memcached MemcachedConfig `json:"memcached" yaml:"memcached"`
-	Memory    MemoryConfig    `json:"memory" yaml:"memory"`
+	Type       string           `json:"type" yaml:"type"`
+	DynamoDB   DynamoDBConfig   `json:"dynamodb" yaml:"dynamodb"`
+	File       FileConfig       `json:"file" yaml:"file"`
+	Memcached  MemcachedConfig  `json:"memcached" yaml:"memcached"`
+	Memory     MemoryConfig     `json:"memory" yaml:"memory"`
+	Multilevel MultilevelConfig `json:"multilevel" yaml:"multilevel"`
+	Plugin     interface{}      `json:"plugin,omitempty" yaml:"plugin,omitempty"`
+	Redis      RedisConfig      `json:"redis" yaml:"redis"`
+	S3         S3Config         `json:"s3" yaml:"s3"`
 }
 
 // NewConfig returns a configuration struct fully populated with default values.
 func NewConfig() Config {
 	return Config{
-		Type:      "memory",
-		DynamoDB:  NewDynamoDBConfig(),
-		Memcached: NewMemcachedConfig(),
-		Memory:    NewMemoryConfig(),
+		Type:       "memory",
+		DynamoDB:   NewDynamoDBConfig(),
+		File:       NewFileConfig(),
+		Memcached:  NewMemcachedConfig(),
+		Memory:     NewMemoryConfig(),
+		Multilevel: NewMultilevelConfig(),
+		Plugin:     nil,
+		Redis:      NewRedisConfig(),
+		S3:         NewS3Config(),
 	}
 }
 
@@ -91,7 +110,16 @@ func SanitiseConfig(conf Config) (interface{}, error) {
 	outputMap := config.Sanitised{}
 
 	outputMap["type"] = conf.Type
-	outputMap[conf.Type] = hashMap[conf.Type]
+	if _, exists := hashMap[conf.Type]; exists {
+		outputMap[conf.Type] = hashMap[conf.Type]
+	}
+	if spec, exists := pluginSpecs[conf.Type]; exists {
+		if spec.confSanitiser != nil {
+			outputMap["plugin"] = spec.confSanitiser(conf.Plugin)
+		} else {
+			outputMap["plugin"] = hashMap["plugin"]
+		}
+	}
 
 	return outputMap, nil
 }
@@ -108,6 +136,20 @@ func (c *Config) UnmarshalJSON(bytes []byte) error {
 		return err
 	}
 
+	if spec, exists := pluginSpecs[aliased.Type]; exists {
+		dummy := struct {
+			Conf interface{} `json:"plugin"`
+		}{
+			Conf: spec.confConstructor(),
+		}
+		if err := json.Unmarshal(bytes, &dummy); err != nil {
+			return fmt.Errorf("failed to parse plugin config: %v", err)
+		}
+		aliased.Plugin = dummy.Conf
+	} else {
+		aliased.Plugin = nil
+	}
+
 	*c = Config(aliased)
 	return nil
 }
@@ -122,6 +164,21 @@ func (c *Config) UnmarshalYAML(unmarshal func(interface{}) error) error {
 		return err
 	}
 
+	if spec, exists := pluginSpecs[aliased.Type]; exists {
+		confBytes, err := yaml.Marshal(aliased.Plugin)
+		if err != nil {
+			return err
+		}
+
+		conf := spec.confConstructor()
+		if err = yaml.Unmarshal(confBytes, conf); err != nil {
+			return err
+		}
+		aliased.Plugin = conf
+	} else {
+		aliased.Plugin = nil
+	}
+
 	*c = Config(aliased)
 	return nil
 }
@@ -234,6 +291,9 @@ func New(
 		}
 		return cache, nil
 	}
+	if c, ok := pluginSpecs[conf.Type]; ok {
+		return c.constructor(conf.Plugin, mgr, log, stats)
+	}
 	return nil, types.ErrInvalidCacheType
 }
 
@@ -22,16 +22,15 @@ package cache
 
 import (
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/Jeffail/benthos/lib/log"
 	"github.com/Jeffail/benthos/lib/metrics"
 	"github.com/Jeffail/benthos/lib/types"
+	sess "github.com/Jeffail/benthos/lib/util/aws/session"
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/awserr"
-	"github.com/aws/aws-sdk-go/aws/credentials"
-	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
-	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
 	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
 	"github.com/aws/aws-sdk-go/service/dynamodb/expression"
@@ -48,8 +47,18 @@ table. The key is stored as a string value and used as table hash key. The value
 is stored as a binary value using the ` + "`data_key`" + ` field name. A prefix
 can be specified to allow multiple cache types to share a single DynamoDB table.
 An optional TTL duration (` + "`ttl`" + `) and field (` + "`ttl_key`" + `) can
-be specified if the backing table has TTL enabled. Strong read consistency can
-be enabled using the ` + "`consistent_read`" + ` configuration field.`,
+be specified if the backing table has TTL enabled, in which case the expiry time
+of each item is written to the TTL field as a unix timestamp in seconds. Since
+DynamoDB may take some time to delete expired items they are treated as missing
+by this cache as soon as they expire. Strong read consistency can be enabled
+using the ` + "`consistent_read`" + ` configuration field.
+
+### Credentials
+
+By default Benthos will use a shared credentials file when connecting to AWS
+services. It's also possible to set them explicitly at the component level,
+allowing you to transfer data across accounts. You can find out more
+[in this document](../aws.md).`,
 	}
 }
 
@@ -57,28 +66,25 @@ be enabled using the ` + "`consistent_read`" + ` configuration field.`,
 
 // DynamoDBConfig contains config fields for the DynamoDB cache type.
 type DynamoDBConfig struct {
-	ConsistentRead bool                       `json:"consistent_read" yaml:"consistent_read"`
-	Credentials    AmazonAWSCredentialsConfig `json:"credentials" yaml:"credentials"`
-	DataKey        string                     `json:"data_key" yaml:"data_key"`
-	Endpoint       string                     `json:"endpoint" yaml:"endpoint"`
-	HashKey        string                     `json:"hash_key" yaml:"hash_key"`
-	Region         string                     `json:"region" yaml:"region"`
-	Table          string                     `json:"table" yaml:"table"`
-	TTL            string                     `json:"ttl" yaml:"ttl"`
-	TTLKey         string                     `json:"ttl_key" yaml:"ttl_key"`
+	sess.Config    `json:",inline" yaml:",inline"`
+	ConsistentRead bool   `json:"consistent_read" yaml:"consistent_read"`
+	DataKey        string `json:"data_key" yaml:"data_key"`
+	HashKey        string `json:"hash_key" yaml:"hash_key"`
+	Table          string `json:"table" yaml:"table"`
+	TTL            string `json:"ttl" yaml:"ttl"`
+	TTLKey         string `json:"ttl_key" yaml:"ttl_key"`
 }
 
 // AmazonAWSCredentialsConfig contains configuration params for AWS credentials.
-type AmazonAWSCredentialsConfig struct {
-	ID     string `json:"id" yaml:"id"`
-	Secret string `json:"secret" yaml:"secret"`
-	Token  string `json:"token" yaml:"token"`
-	Role   string `json:"role" yaml:"role"`
-}
+type AmazonAWSCredentialsConfig = sess.CredentialsConfig
 
 // NewDynamoDBConfig creates a MemoryConfig populated with default values.
 func NewDynamoDBConfig() DynamoDBConfig {
-	return DynamoDBConfig{}
+	sessConf := sess.NewConfig()
+	sessConf.Region = ""
+	return DynamoDBConfig{
+		Config: sessConf,
+	}
 }
 
 //------------------------------------------------------------------------------
@@ -110,32 +116,11 @@ func NewDynamoDB(conf Config, mgr types.Manager, log log.Modular, stats metrics.
 		d.ttl = ttl
 	}
 
-	awsConf := aws.NewConfig()
-	if len(d.conf.Region) > 0 {
-		awsConf = awsConf.WithRegion(d.conf.Region)
-	}
-	if len(d.conf.Endpoint) > 0 {
-		awsConf = awsConf.WithEndpoint(d.conf.Endpoint)
-	}
-	if len(d.conf.Credentials.ID) > 0 {
-		awsConf = awsConf.WithCredentials(credentials.NewStaticCredentials(
-			d.conf.Credentials.ID,
-			d.conf.Credentials.Secret,
-			d.conf.Credentials.Token,
-		))
-	}
-
-	sess, err := session.NewSession(awsConf)
+	sess, err := d.conf.GetSession()
 	if err != nil {
 		return nil, err
 	}
 
-	if len(d.conf.Credentials.Role) > 0 {
-		sess.Config = sess.Config.WithCredentials(
-			stscreds.NewCredentials(sess, d.conf.Credentials.Role),
-		)
-	}
-
 	d.client = dynamodb.New(sess)
 	out, err := d.client.DescribeTable(&dynamodb.DescribeTableInput{
 		TableName: d.table,
@@ -160,16 +145,16 @@ func (d *DynamoDB) Get(key string) ([]byte, error) {
 				S: aws.String(key),
 			},
 		},
-		TableName: d.table,
+		TableName:      d.table,
+		ConsistentRead: aws.Bool(d.conf.ConsistentRead),
 	})
 	if err != nil {
 		return nil, err
 	}
 
 	val, ok := res.Item[d.conf.DataKey]
-	if !ok || val.B == nil {
-		d.log.Warnf("cache key not found: %s", key)
-		return nil, types.ErrCacheNotFound
+	if !ok || val.B == nil || d.expired(res.Item) {
+		return nil, types.ErrKeyNotFound
 	}
 	return val.B, nil
 }
@@ -188,13 +173,21 @@ func (d *DynamoDB) Set(key string, value []byte) error {
 func (d *DynamoDB) Add(key string, value []byte) error {
 	input := d.putItemInput(key, value)
 
-	expr, err := expression.NewBuilder().
-		WithCondition(expression.AttributeNotExists(expression.Name(d.conf.HashKey))).
-		Build()
+	cond := expression.AttributeNotExists(expression.Name(d.conf.HashKey))
+	if d.ttl != 0 && d.conf.TTLKey != "" {
+		// Items that have expired but are yet to be deleted by DynamoDB can be
+		// overwritten.
+		cond = cond.Or(expression.Name(d.conf.TTLKey).LessThan(
+			expression.Value(time.Now().Unix()),
+		))
+	}
+
+	expr, err := expression.NewBuilder().WithCondition(cond).Build()
 	if err != nil {
 		return err
 	}
 	input.ExpressionAttributeNames = expr.Names()
+	input.ExpressionAttributeValues = expr.Values()
 	input.ConditionExpression = expr.Condition()
 
 	if _, err = d.client.PutItem(input); err != nil {
@@ -238,11 +231,27 @@ func (d *DynamoDB) putItemInput(key string, value []byte) *dynamodb.PutItemInput
 
 	if d.ttl != 0 && d.conf.TTLKey != "" {
 		input.Item[d.conf.TTLKey] = &dynamodb.AttributeValue{
-			S: aws.String(time.Now().Add(d.ttl).Format(time.RFC3339Nano)),
+			N: aws.String(strconv.FormatInt(time.Now().Add(d.ttl).Unix(), 10)),
 		}
 	}
 
 	return &input
 }
 
+// expired returns true if an item has a TTL field that is in the past.
+func (d *DynamoDB) expired(item map[string]*dynamodb.AttributeValue) bool {
+	if d.conf.TTLKey == "" {
+		return false
+	}
+	val, ok := item[d.conf.TTLKey]
+	if !ok || val.N == nil {
+		return false
+	}
+	expiry, err := strconv.ParseInt(*val.N, 10, 64)
+	if err != nil {
+		return false
+	}
+	return expiry < time.Now().Unix()
+}
+
 //------------------------------------------------------------------------------
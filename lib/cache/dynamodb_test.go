@@ -3,7 +3,9 @@ package cache
 import (
 	"fmt"
 	"os"
+	"strconv"
 	"testing"
+	"time"
 
 	"github.com/Jeffail/benthos/lib/log"
 	"github.com/Jeffail/benthos/lib/metrics"
@@ -13,9 +15,91 @@ import (
 	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
 	"github.com/ory/dockertest"
 )
 
+type mockDynamoDB struct {
+	dynamodbiface.DynamoDBAPI
+	items map[string]map[string]*dynamodb.AttributeValue
+	reads []*dynamodb.GetItemInput
+}
+
+func (m *mockDynamoDB) GetItem(input *dynamodb.GetItemInput) (*dynamodb.GetItemOutput, error) {
+	m.reads = append(m.reads, input)
+	return &dynamodb.GetItemOutput{
+		Item: m.items[*input.Key["id"].S],
+	}, nil
+}
+
+func TestDynamoDBGetExpired(t *testing.T) {
+	unixStr := func(t time.Time) *string {
+		return aws.String(strconv.FormatInt(t.Unix(), 10))
+	}
+
+	mock := &mockDynamoDB{
+		items: map[string]map[string]*dynamodb.AttributeValue{
+			"live": {
+				"data": {B: []byte("foo")},
+				"ttl":  {N: unixStr(time.Now().Add(time.Hour))},
+			},
+			"expired": {
+				"data": {B: []byte("bar")},
+				"ttl":  {N: unixStr(time.Now().Add(-time.Hour))},
+			},
+			"no_ttl": {
+				"data": {B: []byte("baz")},
+			},
+		},
+	}
+
+	conf := NewDynamoDBConfig()
+	conf.ConsistentRead = true
+	conf.DataKey = "data"
+	conf.HashKey = "id"
+	conf.TTL = "1h"
+	conf.TTLKey = "ttl"
+
+	c := &DynamoDB{
+		client: mock,
+		conf:   conf,
+		log:    log.Noop(),
+		stats:  metrics.DudType{},
+		table:  aws.String("foo"),
+		ttl:    time.Hour,
+	}
+
+	if act, err := c.Get("live"); err != nil {
+		t.Error(err)
+	} else if exp := "foo"; string(act) != exp {
+		t.Errorf("Wrong result: %s != %s", act, exp)
+	}
+	if act, err := c.Get("no_ttl"); err != nil {
+		t.Error(err)
+	} else if exp := "baz"; string(act) != exp {
+		t.Errorf("Wrong result: %s != %s", act, exp)
+	}
+	if _, err := c.Get("expired"); err != types.ErrKeyNotFound {
+		t.Errorf("Wrong error returned: %v != %v", err, types.ErrKeyNotFound)
+	}
+	if _, err := c.Get("missing"); err != types.ErrKeyNotFound {
+		t.Errorf("Wrong error returned: %v != %v", err, types.ErrKeyNotFound)
+	}
+
+	for _, r := range mock.reads {
+		if r.ConsistentRead == nil || !*r.ConsistentRead {
+			t.Error("Expected consistent read")
+		}
+	}
+
+	input := c.putItemInput("foo", []byte("bar"))
+	if v := input.Item["ttl"]; v == nil || v.N == nil {
+		t.Fatal("Expected numeric ttl field")
+	} else if _, err := strconv.ParseInt(*v.N, 10, 64); err != nil {
+		t.Errorf("Expected unix timestamp ttl: %v", err)
+	}
+}
+
 func TestDynamoDBIntegration(t *testing.T) {
 	if testing.Short() {
 		t.Skip("Skipping integration test in short mode")
@@ -0,0 +1,267 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/log"
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors[TypeFile] = TypeSpec{
+		constructor: NewFile,
+		description: `
+The file cache stores each item as a file within a directory, and therefore
+persists between restarts of the service. The path of each item is derived from
+a hash of its key, so any key can be stored regardless of its contents.
+
+If a TTL (in seconds) is set then items are treated as missing once that long
+has passed since they were last edited, based on the modification time of the
+file. Expired files are removed from the directory during compactions, which only
+occur during a write where the time since the last compaction is above the
+compaction interval.`,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// FileConfig contains config fields for the File cache type.
+type FileConfig struct {
+	Directory           string `json:"directory" yaml:"directory"`
+	TTL                 int    `json:"ttl" yaml:"ttl"`
+	CompactionIntervalS int    `json:"compaction_interval_s" yaml:"compaction_interval_s"`
+}
+
+// NewFileConfig creates a FileConfig populated with default values.
+func NewFileConfig() FileConfig {
+	return FileConfig{
+		Directory:           "",
+		TTL:                 0,
+		CompactionIntervalS: 60,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// File is a file system based cache implementation.
+type File struct {
+	dir            string
+	ttl            time.Duration
+	compInterval   time.Duration
+	lastCompaction time.Time
+
+	log log.Modular
+
+	mCompactions metrics.StatCounter
+	mExpired     metrics.StatCounter
+
+	sync.Mutex
+}
+
+// NewFile creates a new File cache type.
+func NewFile(conf Config, mgr types.Manager, log log.Modular, stats metrics.Type) (types.Cache, error) {
+	if len(conf.File.Directory) == 0 {
+		return nil, errors.New("a directory must be specified")
+	}
+	if err := os.MkdirAll(conf.File.Directory, 0755); err != nil {
+		return nil, err
+	}
+	return &File{
+		dir:            conf.File.Directory,
+		ttl:            time.Second * time.Duration(conf.File.TTL),
+		compInterval:   time.Second * time.Duration(conf.File.CompactionIntervalS),
+		lastCompaction: time.Now(),
+
+		log: log.NewModule(".cache.file"),
+
+		mCompactions: stats.GetCounter("cache.file.compaction.count"),
+		mExpired:     stats.GetCounter("cache.file.compaction.expired"),
+	}, nil
+}
+
+//------------------------------------------------------------------------------
+
+// pathFor returns the path of the file that stores a key. Files are spread
+// across sub directories named after the first two characters of the hash in
+// order to keep directory sizes manageable.
+func (f *File) pathFor(key string) string {
+	hash := sha256.Sum256([]byte(key))
+	name := hex.EncodeToString(hash[:])
+	return filepath.Join(f.dir, name[:2], name)
+}
+
+// expired returns true if a file with the given info has outlived the TTL.
+func (f *File) expired(info os.FileInfo) bool {
+	return f.ttl > 0 && time.Since(info.ModTime()) >= f.ttl
+}
+
+// writeTemp writes a value to a temporary file next to its target path and
+// returns the path of the temporary file.
+func (f *File) writeTemp(target string, value []byte) (string, error) {
+	dir := filepath.Dir(target)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	tmp, err := ioutil.TempFile(dir, ".tmp")
+	if err != nil {
+		return "", err
+	}
+	if _, err = tmp.Write(value); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return "", err
+	}
+	if err = tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return "", err
+	}
+	return tmp.Name(), nil
+}
+
+func (f *File) compaction() {
+	if f.ttl <= 0 || time.Since(f.lastCompaction) < f.compInterval {
+		return
+	}
+	f.lastCompaction = time.Now()
+	f.mCompactions.Incr(1)
+
+	err := filepath.Walk(f.dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if info.IsDir() || !f.expired(info) {
+			return nil
+		}
+		if err = os.Remove(path); err == nil {
+			f.mExpired.Incr(1)
+		} else if !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		f.log.Errorf("Failed to compact cache directory: %v\n", err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// Get attempts to locate and return a cached value by its key, returns an error
+// if the key does not exist.
+func (f *File) Get(key string) ([]byte, error) {
+	path := f.pathFor(key)
+	info, err := os.Stat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, types.ErrKeyNotFound
+		}
+		return nil, err
+	}
+	if f.expired(info) {
+		return nil, types.ErrKeyNotFound
+	}
+	value, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, types.ErrKeyNotFound
+	}
+	return value, err
+}
+
+// Set attempts to set the value of a key.
+func (f *File) Set(key string, value []byte) error {
+	f.Lock()
+	defer f.Unlock()
+	f.compaction()
+
+	path := f.pathFor(key)
+	tmp, err := f.writeTemp(path, value)
+	if err != nil {
+		return err
+	}
+	if err = os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// Add attempts to set the value of a key only if the key does not already exist
+// and returns an error if the key already exists.
+func (f *File) Add(key string, value []byte) error {
+	f.Lock()
+	defer f.Unlock()
+	f.compaction()
+
+	path := f.pathFor(key)
+	if info, err := os.Stat(path); err == nil {
+		if !f.expired(info) {
+			return types.ErrKeyAlreadyExists
+		}
+		if err = os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	tmp, err := f.writeTemp(path, value)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp)
+
+	// Linking fails if the target already exists, which protects against other
+	// processes sharing the directory.
+	if err = os.Link(tmp, path); err != nil {
+		if os.IsExist(err) {
+			return types.ErrKeyAlreadyExists
+		}
+		return err
+	}
+	return nil
+}
+
+// Delete attempts to remove a key.
+func (f *File) Delete(key string) error {
+	f.Lock()
+	defer f.Unlock()
+	f.compaction()
+
+	if err := os.Remove(f.pathFor(key)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
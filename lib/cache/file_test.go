@@ -0,0 +1,197 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cache
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/log"
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+)
+
+//------------------------------------------------------------------------------
+
+func TestFileCache(t *testing.T) {
+	dir, err := ioutil.TempDir("", "benthos_file_cache_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	testLog := log.New(os.Stdout, log.Config{LogLevel: "NONE"})
+
+	conf := NewConfig()
+	conf.Type = "file"
+	conf.File.Directory = dir
+
+	c, err := New(conf, nil, testLog, metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expErr := types.ErrKeyNotFound
+	if _, act := c.Get("foo"); act != expErr {
+		t.Errorf("Wrong error returned: %v != %v", act, expErr)
+	}
+
+	if err = c.Set("foo", []byte("1")); err != nil {
+		t.Error(err)
+	}
+
+	exp := "1"
+	if act, err := c.Get("foo"); err != nil {
+		t.Error(err)
+	} else if string(act) != exp {
+		t.Errorf("Wrong result: %v != %v", string(act), exp)
+	}
+
+	if err = c.Add("bar/../baz", []byte("2")); err != nil {
+		t.Error(err)
+	}
+
+	exp = "2"
+	if act, err := c.Get("bar/../baz"); err != nil {
+		t.Error(err)
+	} else if string(act) != exp {
+		t.Errorf("Wrong result: %v != %v", string(act), exp)
+	}
+
+	expErr = types.ErrKeyAlreadyExists
+	if act := c.Add("foo", []byte("2")); expErr != act {
+		t.Errorf("Wrong error returned: %v != %v", act, expErr)
+	}
+
+	if err = c.Set("foo", []byte("3")); err != nil {
+		t.Error(err)
+	}
+
+	// A new cache on the same directory sees the same items.
+	if c, err = New(conf, nil, testLog, metrics.DudType{}); err != nil {
+		t.Fatal(err)
+	}
+
+	exp = "3"
+	if act, err := c.Get("foo"); err != nil {
+		t.Error(err)
+	} else if string(act) != exp {
+		t.Errorf("Wrong result: %v != %v", string(act), exp)
+	}
+
+	if err = c.Delete("foo"); err != nil {
+		t.Error(err)
+	}
+	if err = c.Delete("foo"); err != nil {
+		t.Error(err)
+	}
+
+	expErr = types.ErrKeyNotFound
+	if _, act := c.Get("foo"); act != expErr {
+		t.Errorf("Wrong error returned: %v != %v", act, expErr)
+	}
+}
+
+func TestFileCacheTTL(t *testing.T) {
+	dir, err := ioutil.TempDir("", "benthos_file_cache_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	testLog := log.New(os.Stdout, log.Config{LogLevel: "NONE"})
+
+	conf := NewConfig()
+	conf.Type = "file"
+	conf.File.Directory = dir
+	conf.File.TTL = 60
+	conf.File.CompactionIntervalS = 0
+
+	c, err := New(conf, nil, testLog, metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = c.Set("foo", []byte("1")); err != nil {
+		t.Fatal(err)
+	}
+	if err = c.Set("bar", []byte("2")); err != nil {
+		t.Fatal(err)
+	}
+
+	path := c.(*File).pathFor("foo")
+	old := time.Now().Add(-time.Hour)
+	if err = os.Chtimes(path, old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	expErr := types.ErrKeyNotFound
+	if _, act := c.Get("foo"); act != expErr {
+		t.Errorf("Wrong error returned: %v != %v", act, expErr)
+	}
+
+	// Expired keys can be added again.
+	if err = c.Add("foo", []byte("3")); err != nil {
+		t.Error(err)
+	}
+	if act, err := c.Get("foo"); err != nil {
+		t.Error(err)
+	} else if exp := "3"; string(act) != exp {
+		t.Errorf("Wrong result: %v != %v", string(act), exp)
+	}
+
+	// Expired files are removed during compaction.
+	barPath := c.(*File).pathFor("bar")
+	if err = os.Chtimes(barPath, old, old); err != nil {
+		t.Fatal(err)
+	}
+	if err = c.Delete("baz"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = os.Stat(barPath); !os.IsNotExist(err) {
+		t.Errorf("Expected expired file to be removed: %v", err)
+	}
+	if _, err = os.Stat(path); err != nil {
+		t.Errorf("Expected live file to remain: %v", err)
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*", ".tmp*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) > 0 {
+		t.Errorf("Temporary files left behind: %v", files)
+	}
+}
+
+func TestFileCacheNoDirectory(t *testing.T) {
+	testLog := log.New(os.Stdout, log.Config{LogLevel: "NONE"})
+
+	conf := NewConfig()
+	conf.Type = "file"
+
+	if _, err := New(conf, nil, testLog, metrics.DudType{}); err == nil {
+		t.Error("Expected error from empty directory")
+	}
+}
@@ -21,6 +21,11 @@
 package cache
 
 import (
+	"fmt"
+	"hash/crc32"
+	"net"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -38,7 +43,13 @@ func init() {
 		constructor: NewMemcached,
 		description: `
 Connects to a cluster of memcached services, a prefix can be specified to allow
-multiple cache types to share a memcached cluster under different namespaces.`,
+multiple cache types to share a memcached cluster under different namespaces.
+
+By default keys are distributed across the addresses by taking a hash of the key
+modulo the number of servers, which means adding or removing a server moves most
+keys. Setting ` + "`consistent_hashing`" + ` to true distributes keys on a hash
+ring instead, so that changing the server list only moves the keys belonging to
+the servers that were added or removed.`,
 	}
 }
 
@@ -46,21 +57,23 @@ multiple cache types to share a memcached cluster under different namespaces.`,
 
 // MemcachedConfig is a config struct for a memcached connection.
 type MemcachedConfig struct {
-	Addresses     []string `json:"addresses" yaml:"addresses"`
-	Prefix        string   `json:"prefix" yaml:"prefix"`
-	TTL           int32    `json:"ttl" yaml:"ttl"`
-	Retries       int      `json:"retries" yaml:"retries"`
-	RetryPeriodMS int      `json:"retry_period_ms" yaml:"retry_period_ms"`
+	Addresses         []string `json:"addresses" yaml:"addresses"`
+	ConsistentHashing bool     `json:"consistent_hashing" yaml:"consistent_hashing"`
+	Prefix            string   `json:"prefix" yaml:"prefix"`
+	TTL               int32    `json:"ttl" yaml:"ttl"`
+	Retries           int      `json:"retries" yaml:"retries"`
+	RetryPeriodMS     int      `json:"retry_period_ms" yaml:"retry_period_ms"`
 }
 
 // NewMemcachedConfig returns a MemcachedConfig with default values.
 func NewMemcachedConfig() MemcachedConfig {
 	return MemcachedConfig{
-		Addresses:     []string{"localhost:11211"},
-		Prefix:        "",
-		TTL:           300,
-		Retries:       3,
-		RetryPeriodMS: 500,
+		Addresses:         []string{"localhost:11211"},
+		ConsistentHashing: false,
+		Prefix:            "",
+		TTL:               300,
+		Retries:           3,
+		RetryPeriodMS:     500,
 	}
 }
 
@@ -84,7 +97,6 @@ type Memcached struct {
 	mSetSuccess    metrics.StatCounter
 	mSetLatency    metrics.StatTimer
 	mAddCount      metrics.StatCounter
-	mAddDupe       metrics.StatCounter
 	mAddRetry      metrics.StatCounter
 	mAddFailedDupe metrics.StatCounter
 	mAddFailedErr  metrics.StatCounter
@@ -112,6 +124,18 @@ func NewMemcached(
 			}
 		}
 	}
+
+	var mc *memcache.Client
+	if conf.Memcached.ConsistentHashing {
+		ring, err := newMemcachedHashRing(addresses)
+		if err != nil {
+			return nil, err
+		}
+		mc = memcache.NewFromSelector(ring)
+	} else {
+		mc = memcache.New(addresses...)
+	}
+
 	return &Memcached{
 		conf:  conf,
 		log:   log.NewModule(".cache.memcached"),
@@ -129,7 +153,6 @@ func NewMemcached(
 		mSetSuccess:    stats.GetCounter("cache.memcached.set.success"),
 		mSetLatency:    stats.GetTimer("cache.memcached.set.latency"),
 		mAddCount:      stats.GetCounter("cache.memcached.add.count"),
-		mAddDupe:       stats.GetCounter("cache.memcached.add.failed.duplicate"),
 		mAddRetry:      stats.GetCounter("cache.memcached.add.retry"),
 		mAddFailedDupe: stats.GetCounter("cache.memcached.add.failed.duplicate"),
 		mAddFailedErr:  stats.GetCounter("cache.memcached.add.failed.error"),
@@ -142,7 +165,7 @@ func NewMemcached(
 		mDelLatency:    stats.GetTimer("cache.memcached.del.latency"),
 
 		retryPeriod: time.Duration(conf.Memcached.RetryPeriodMS) * time.Millisecond,
-		mc:          memcache.New(addresses...),
+		mc:          mc,
 	}, nil
 }
 
@@ -229,7 +252,7 @@ func (m *Memcached) Add(key string, value []byte) error {
 		m.log.Errorf("Add command failed: %v\n", err)
 		<-time.After(m.retryPeriod)
 		m.mAddRetry.Incr(1)
-		if err := m.mc.Add(m.getItemFor(key, value)); memcache.ErrNotStored == err {
+		if err = m.mc.Add(m.getItemFor(key, value)); memcache.ErrNotStored == err {
 			m.mAddFailedDupe.Incr(1)
 
 			latency := int64(time.Since(tStarted))
@@ -282,4 +305,79 @@ func (m *Memcached) Delete(key string) error {
 	return err
 }
 
-//-----------------------------------------------------------------------------
+//------------------------------------------------------------------------------
+
+// memcachedHashRingReplicas is the number of points each server occupies on the
+// hash ring, more points results in a more even distribution of keys.
+const memcachedHashRingReplicas = 160
+
+// memcachedHashRing is a memcache.ServerSelector that distributes keys across
+// servers using consistent hashing.
+type memcachedHashRing struct {
+	addrs  []net.Addr
+	points []uint32
+	owners map[uint32]net.Addr
+}
+
+// newMemcachedHashRing resolves a list of server addresses and places each of
+// them on a hash ring.
+func newMemcachedHashRing(servers []string) (*memcachedHashRing, error) {
+	r := &memcachedHashRing{
+		owners: map[uint32]net.Addr{},
+	}
+	for _, server := range servers {
+		var addr net.Addr
+		var err error
+		if strings.Contains(server, "/") {
+			addr, err = net.ResolveUnixAddr("unix", server)
+		} else {
+			addr, err = net.ResolveTCPAddr("tcp", server)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve address '%v': %v", server, err)
+		}
+		r.addrs = append(r.addrs, addr)
+
+		// Points are derived from the configured name rather than the resolved
+		// address so that key placement is stable across DNS changes.
+		for i := 0; i < memcachedHashRingReplicas; i++ {
+			point := crc32.ChecksumIEEE([]byte(server + "-" + strconv.Itoa(i)))
+			if _, exists := r.owners[point]; exists {
+				continue
+			}
+			r.owners[point] = addr
+			r.points = append(r.points, point)
+		}
+	}
+	sort.Slice(r.points, func(i, j int) bool {
+		return r.points[i] < r.points[j]
+	})
+	return r, nil
+}
+
+// PickServer returns the server address that a given key should be sent to.
+func (r *memcachedHashRing) PickServer(key string) (net.Addr, error) {
+	if len(r.points) == 0 {
+		return nil, memcache.ErrNoServers
+	}
+	h := crc32.ChecksumIEEE([]byte(key))
+	i := sort.Search(len(r.points), func(i int) bool {
+		return r.points[i] >= h
+	})
+	if i == len(r.points) {
+		i = 0
+	}
+	return r.owners[r.points[i]], nil
+}
+
+// Each iterates over each server calling the given function.
+func (r *memcachedHashRing) Each(f func(net.Addr) error) error {
+	for _, a := range r.addrs {
+		if err := f(a); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
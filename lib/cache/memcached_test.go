@@ -158,3 +158,60 @@ func testMemcachedGetAndSet(addrs []string, t *testing.T) {
 		t.Error(err)
 	}
 }
+
+func TestMemcachedHashRing(t *testing.T) {
+	servers := []string{"127.0.0.1:11211", "127.0.0.1:11212", "127.0.0.1:11213"}
+
+	ring, err := newMemcachedHashRing(servers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reducedRing, err := newMemcachedHashRing(servers[:2])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	counts := map[string]int{}
+	for i := 0; i < 1000; i++ {
+		key := fmt.Sprintf("key%v", i)
+
+		addr, err := ring.PickServer(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		counts[addr.String()]++
+
+		again, err := ring.PickServer(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if again.String() != addr.String() {
+			t.Errorf("Key %v moved between picks: %v != %v", key, again, addr)
+		}
+
+		if addr.String() == servers[2] {
+			continue
+		}
+		reducedAddr, err := reducedRing.PickServer(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if reducedAddr.String() != addr.String() {
+			t.Errorf("Key %v moved after removing a server: %v != %v", key, reducedAddr, addr)
+		}
+	}
+
+	for _, s := range servers {
+		if counts[s] == 0 {
+			t.Errorf("Server %v received no keys", s)
+		}
+	}
+
+	empty, err := newMemcachedHashRing(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = empty.PickServer("foo"); err == nil {
+		t.Error("Expected error from empty ring")
+	}
+}
@@ -21,6 +21,7 @@
 package cache
 
 import (
+	"container/list"
 	"sync"
 	"time"
 
@@ -37,12 +38,17 @@ func init() {
 		description: `
 The memory cache simply stores key/value pairs in a map held in memory. This
 cache is therefore reset every time the service restarts. Each item in the cache
-has a TTL set from the moment it was last edited, after which it will be removed
-during the next compaction.
+has a TTL set from the moment it was last edited, after which it is no longer
+returned and will be removed during the next compaction. A TTL of zero means
+items do not expire.
 
 A compaction only occurs during a write where the time since the last compaction
-is above the compaction interval. It is therefore possible to obtain values of
-keys that have expired between compactions.`,
+is above the compaction interval.
+
+The number of items and the total size in bytes of all keys and values can be
+capped with ` + "`max_items`" + ` and ` + "`max_size`" + ` respectively, where
+a value of zero means no limit. When a cap is exceeded the least recently used
+items are evicted until the cache is back within bounds.`,
 	}
 }
 
@@ -52,6 +58,8 @@ keys that have expired between compactions.`,
 type MemoryConfig struct {
 	TTL                 int `json:"ttl" yaml:"ttl"`
 	CompactionIntervalS int `json:"compaction_interval_s" yaml:"compaction_interval_s"`
+	MaxItems            int `json:"max_items" yaml:"max_items"`
+	MaxSize             int `json:"max_size" yaml:"max_size"`
 }
 
 // NewMemoryConfig creates a MemoryConfig populated with default values.
@@ -59,65 +67,167 @@ func NewMemoryConfig() MemoryConfig {
 	return MemoryConfig{
 		TTL:                 300, // 5 Mins
 		CompactionIntervalS: 60,
+		MaxItems:            0,
+		MaxSize:             0,
 	}
 }
 
 //------------------------------------------------------------------------------
 
 type item struct {
-	value []byte
-	ts    time.Time
+	key     string
+	value   []byte
+	expires time.Time
+}
+
+func (i *item) size() int {
+	return len(i.key) + len(i.value)
 }
 
 // Memory is a memory based cache implementation.
 type Memory struct {
-	items          map[string]item
-	ttl            time.Duration
+	items    map[string]*list.Element
+	lru      *list.List
+	size     int
+	ttl      time.Duration
+	maxItems int
+	maxSize  int
+
 	compInterval   time.Duration
 	lastCompaction time.Time
-	sync.RWMutex
+
+	mHit     metrics.StatCounter
+	mMiss    metrics.StatCounter
+	mEvicted metrics.StatCounter
+	mExpired metrics.StatCounter
+	mItems   metrics.StatGauge
+	mSize    metrics.StatGauge
+
+	sync.Mutex
 }
 
 // NewMemory creates a new Memory cache type.
 func NewMemory(conf Config, mgr types.Manager, log log.Modular, stats metrics.Type) (types.Cache, error) {
-	return &Memory{
-		items:          map[string]item{},
-		ttl:            time.Second * time.Duration(conf.Memory.TTL),
+	m := &Memory{
+		items:    map[string]*list.Element{},
+		lru:      list.New(),
+		ttl:      time.Second * time.Duration(conf.Memory.TTL),
+		maxItems: conf.Memory.MaxItems,
+		maxSize:  conf.Memory.MaxSize,
+
 		compInterval:   time.Second * time.Duration(conf.Memory.CompactionIntervalS),
 		lastCompaction: time.Now(),
-	}, nil
+
+		mHit:     stats.GetCounter("cache.memory.hit"),
+		mMiss:    stats.GetCounter("cache.memory.miss"),
+		mEvicted: stats.GetCounter("cache.memory.evicted"),
+		mExpired: stats.GetCounter("cache.memory.expired"),
+		mItems:   stats.GetGauge("cache.memory.items"),
+		mSize:    stats.GetGauge("cache.memory.size"),
+	}
+	return m, nil
 }
 
 //------------------------------------------------------------------------------
 
+// compaction removes all expired items if the time since the last compaction is
+// above the compaction interval, the lock must be held by the caller.
 func (m *Memory) compaction() {
 	if time.Since(m.lastCompaction) < m.compInterval {
 		return
 	}
-	for k, v := range m.items {
-		if time.Since(v.ts) >= m.ttl {
-			delete(m.items, k)
+	now := time.Now()
+	m.lastCompaction = now
+	for _, e := range m.items {
+		if i := e.Value.(*item); !i.expires.IsZero() && !now.Before(i.expires) {
+			m.remove(e)
+			m.mExpired.Incr(1)
 		}
 	}
+	m.updateGauges()
+}
+
+// remove deletes an element from the cache, the lock must be held by the
+// caller.
+func (m *Memory) remove(e *list.Element) {
+	i := e.Value.(*item)
+	m.lru.Remove(e)
+	delete(m.items, i.key)
+	m.size -= i.size()
+}
+
+// lookup returns the element of a key if it exists and has not expired, the
+// lock must be held by the caller.
+func (m *Memory) lookup(key string) *list.Element {
+	e, exists := m.items[key]
+	if !exists {
+		return nil
+	}
+	if i := e.Value.(*item); !i.expires.IsZero() && !time.Now().Before(i.expires) {
+		m.remove(e)
+		m.mExpired.Incr(1)
+		return nil
+	}
+	return e
+}
+
+// store sets the value of a key and evicts the least recently used items until
+// the cache is within its limits, the lock must be held by the caller.
+func (m *Memory) store(key string, value []byte, ttl time.Duration) {
+	m.compaction()
+	if e, exists := m.items[key]; exists {
+		m.remove(e)
+	}
+
+	i := &item{key: key, value: value}
+	if ttl > 0 {
+		i.expires = time.Now().Add(ttl)
+	}
+	m.items[key] = m.lru.PushFront(i)
+	m.size += i.size()
+
+	for m.lru.Len() > 1 &&
+		((m.maxItems > 0 && m.lru.Len() > m.maxItems) ||
+			(m.maxSize > 0 && m.size > m.maxSize)) {
+		m.remove(m.lru.Back())
+		m.mEvicted.Incr(1)
+	}
+	m.updateGauges()
 }
 
+func (m *Memory) updateGauges() {
+	m.mItems.Set(int64(m.lru.Len()))
+	m.mSize.Set(int64(m.size))
+}
+
+//------------------------------------------------------------------------------
+
 // Get attempts to locate and return a cached value by its key, returns an error
 // if the key does not exist.
 func (m *Memory) Get(key string) ([]byte, error) {
-	m.RLock()
-	k, exists := m.items[key]
-	m.RUnlock()
-	if !exists {
+	m.Lock()
+	defer m.Unlock()
+
+	e := m.lookup(key)
+	if e == nil {
+		m.mMiss.Incr(1)
 		return nil, types.ErrKeyNotFound
 	}
-	return k.value, nil
+	m.lru.MoveToFront(e)
+	m.mHit.Incr(1)
+	return e.Value.(*item).value, nil
 }
 
 // Set attempts to set the value of a key.
 func (m *Memory) Set(key string, value []byte) error {
+	return m.SetWithTTL(key, value, m.ttl)
+}
+
+// SetWithTTL attempts to set the value of a key with a TTL that overrides the
+// default TTL of the cache. A TTL of zero means the key does not expire.
+func (m *Memory) SetWithTTL(key string, value []byte, ttl time.Duration) error {
 	m.Lock()
-	m.compaction()
-	m.items[key] = item{value: value, ts: time.Now()}
+	m.store(key, value, ttl)
 	m.Unlock()
 	return nil
 }
@@ -126,13 +236,12 @@ func (m *Memory) Set(key string, value []byte) error {
 // and returns an error if the key already exists.
 func (m *Memory) Add(key string, value []byte) error {
 	m.Lock()
-	if _, exists := m.items[key]; exists {
-		m.Unlock()
+	defer m.Unlock()
+
+	if e := m.lookup(key); e != nil {
 		return types.ErrKeyAlreadyExists
 	}
-	m.compaction()
-	m.items[key] = item{value: value, ts: time.Now()}
-	m.Unlock()
+	m.store(key, value, m.ttl)
 	return nil
 }
 
@@ -140,7 +249,10 @@ func (m *Memory) Add(key string, value []byte) error {
 func (m *Memory) Delete(key string) error {
 	m.Lock()
 	m.compaction()
-	delete(m.items, key)
+	if e, exists := m.items[key]; exists {
+		m.remove(e)
+	}
+	m.updateGauges()
 	m.Unlock()
 	return nil
 }
@@ -23,6 +23,7 @@ package cache
 import (
 	"os"
 	"testing"
+	"time"
 
 	"github.com/Jeffail/benthos/lib/log"
 	"github.com/Jeffail/benthos/lib/metrics"
@@ -106,39 +107,144 @@ func TestMemoryCacheCompaction(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
+	m := c.(*Memory)
+
+	if err = m.SetWithTTL("foo", []byte("1"), time.Nanosecond); err != nil {
+		t.Error(err)
+	}
+	<-time.After(time.Millisecond)
+
+	// Writes trigger a compaction.
+	if err = m.Set("bar", []byte("2")); err != nil {
+		t.Error(err)
+	}
+
+	m.Lock()
+	_, exists := m.items["foo"]
+	m.Unlock()
+	if exists {
+		t.Error("Expected expired key to be removed by compaction")
+	}
+
+	exp := "2"
+	if act, err := c.Get("bar"); err != nil {
+		t.Error(err)
+	} else if string(act) != exp {
+		t.Errorf("Wrong result: %v != %v", string(act), exp)
+	}
+}
+
+func TestMemoryCacheTTL(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = "memory"
+	conf.Memory.TTL = 300
+
+	c, err := New(conf, nil, log.Noop(), metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tc, ok := c.(types.CacheWithTTL)
+	if !ok {
+		t.Fatal("Expected memory cache to support per key TTLs")
+	}
+
+	if err = tc.SetWithTTL("foo", []byte("1"), time.Nanosecond); err != nil {
+		t.Error(err)
+	}
+	if err = tc.Set("bar", []byte("2")); err != nil {
+		t.Error(err)
+	}
+	<-time.After(time.Millisecond)
 
 	expErr := types.ErrKeyNotFound
 	if _, act := c.Get("foo"); act != expErr {
 		t.Errorf("Wrong error returned: %v != %v", act, expErr)
 	}
 
-	if err = c.Set("foo", []byte("1")); err != nil {
+	// Expired keys can be added again.
+	if err = c.Add("foo", []byte("3")); err != nil {
 		t.Error(err)
 	}
 
-	exp := "1"
+	exp := "3"
 	if act, err := c.Get("foo"); err != nil {
 		t.Error(err)
 	} else if string(act) != exp {
 		t.Errorf("Wrong result: %v != %v", string(act), exp)
 	}
 
-	// This should trigger compaction.
-	if err = c.Add("bar", []byte("2")); err != nil {
-		t.Error(err)
-	}
-
 	exp = "2"
 	if act, err := c.Get("bar"); err != nil {
 		t.Error(err)
 	} else if string(act) != exp {
 		t.Errorf("Wrong result: %v != %v", string(act), exp)
 	}
+}
 
-	// This key should have been removed from compaction.
-	if _, act := c.Get("foo"); act != expErr {
+func TestMemoryCacheLRU(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = "memory"
+	conf.Memory.CompactionIntervalS = 0
+	conf.Memory.MaxItems = 3
+	conf.Memory.MaxSize = 20
+
+	c, err := New(conf, nil, log.Noop(), metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, k := range []string{"a", "b", "c"} {
+		if err = c.Set(k, []byte("1")); err != nil {
+			t.Error(err)
+		}
+	}
+
+	// Touch a so that b becomes the least recently used.
+	if _, err = c.Get("a"); err != nil {
+		t.Error(err)
+	}
+	if err = c.Set("d", []byte("1")); err != nil {
+		t.Error(err)
+	}
+
+	expErr := types.ErrKeyNotFound
+	if _, act := c.Get("b"); act != expErr {
+		t.Errorf("Wrong error returned: %v != %v", act, expErr)
+	}
+	for _, k := range []string{"a", "c", "d"} {
+		if _, err = c.Get(k); err != nil {
+			t.Errorf("Key %v: %v", k, err)
+		}
+	}
+
+	// The reads above leave a as the least recently used.
+	if err = c.Set("e", []byte("0123456789")); err != nil {
+		t.Error(err)
+	}
+	if _, act := c.Get("a"); act != expErr {
+		t.Errorf("Wrong error returned: %v != %v", act, expErr)
+	}
+
+	m := c.(*Memory)
+	if exp, act := 15, m.size; exp != act {
+		t.Errorf("Wrong size: %v != %v", act, exp)
+	}
+
+	// Exceeding the size cap evicts until within bounds.
+	if err = c.Set("f", []byte("12345")); err != nil {
+		t.Error(err)
+	}
+	if _, act := c.Get("c"); act != expErr {
 		t.Errorf("Wrong error returned: %v != %v", act, expErr)
 	}
+	for _, k := range []string{"d", "e", "f"} {
+		if _, err = c.Get(k); err != nil {
+			t.Errorf("Key %v: %v", k, err)
+		}
+	}
+	if exp, act := 19, m.size; exp != act {
+		t.Errorf("Wrong size: %v != %v", act, exp)
+	}
 }
 
 //------------------------------------------------------------------------------
@@ -0,0 +1,243 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cache
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Jeffail/benthos/lib/log"
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors[TypeMultilevel] = TypeSpec{
+		constructor: NewMultilevel,
+		description: `
+Combines multiple cache resources into a layered cache, where the caches are
+listed by their names in order from the fastest (usually local) to the slowest
+(usually shared). For example, an in-memory cache can be layered in front of a
+Redis cache in order to reduce the number of round trips for frequently used
+keys:
+
+` + "``` yaml" + `
+resources:
+  caches:
+    leveled:
+      type: multilevel
+      multilevel: [ hot, cold ]
+    hot:
+      type: memory
+      memory:
+        ttl: 60
+    cold:
+      type: redis
+      redis:
+        urls: [ tcp://localhost:6379 ]
+        ttl: 5m
+` + "```" + `
+
+Reads attempt each level in order, and when a key is found at a lower level it
+is written to each of the levels above it. Writes and deletes are applied to all
+levels.
+
+The ` + "`add`" + ` operation is applied to the last level only, as that is
+expected to be the source of truth, and when it succeeds the value is then set
+on all levels above it.`,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// MultilevelConfig contains config fields for the Multilevel cache type, which
+// is a list of cache resource names.
+type MultilevelConfig []string
+
+// NewMultilevelConfig creates a MultilevelConfig populated with default values.
+func NewMultilevelConfig() MultilevelConfig {
+	return MultilevelConfig{}
+}
+
+//------------------------------------------------------------------------------
+
+// Multilevel is a cache that layers multiple cache resources.
+type Multilevel struct {
+	mgr    types.Manager
+	levels []string
+	log    log.Modular
+
+	mHit        metrics.StatCounter
+	mMiss       metrics.StatCounter
+	mPopulate   metrics.StatCounter
+	mFailed     metrics.StatCounter
+	mLevelError metrics.StatCounter
+}
+
+// NewMultilevel creates a new Multilevel cache type.
+func NewMultilevel(conf Config, mgr types.Manager, log log.Modular, stats metrics.Type) (types.Cache, error) {
+	if len(conf.Multilevel) < 2 {
+		return nil, errors.New("at least two cache levels must be specified")
+	}
+	for _, name := range conf.Multilevel {
+		if _, err := mgr.GetCache(name); err != nil {
+			return nil, fmt.Errorf("failed to obtain cache resource '%v': %v", name, err)
+		}
+	}
+	return &Multilevel{
+		mgr:    mgr,
+		levels: conf.Multilevel,
+		log:    log.NewModule(".cache.multilevel"),
+
+		mHit:        stats.GetCounter("cache.multilevel.get.hit"),
+		mMiss:       stats.GetCounter("cache.multilevel.get.miss"),
+		mPopulate:   stats.GetCounter("cache.multilevel.get.populate"),
+		mFailed:     stats.GetCounter("cache.multilevel.failed"),
+		mLevelError: stats.GetCounter("cache.multilevel.level.error"),
+	}, nil
+}
+
+//------------------------------------------------------------------------------
+
+// getLevels obtains each cache level from the manager. Cache resources are
+// obtained on each call as they might not have existed at construction time.
+func (m *Multilevel) getLevels() ([]types.Cache, error) {
+	caches := make([]types.Cache, len(m.levels))
+	for i, name := range m.levels {
+		c, err := m.mgr.GetCache(name)
+		if err == nil && c == nil {
+			err = types.ErrCacheNotFound
+		}
+		if err != nil {
+			m.mFailed.Incr(1)
+			m.log.Errorf("Failed to obtain cache resource '%v': %v\n", name, err)
+			return nil, err
+		}
+		caches[i] = c
+	}
+	return caches, nil
+}
+
+// setLevels sets a key on each of the provided caches, attempting all of them
+// and returning the first error encountered.
+func (m *Multilevel) setLevels(caches []types.Cache, key string, value []byte) error {
+	var firstErr error
+	for _, c := range caches {
+		if err := c.Set(key, value); err != nil {
+			m.mLevelError.Incr(1)
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return firstErr
+}
+
+// Get attempts to locate and return a cached value by its key, returns an error
+// if the key does not exist in any level or if the operation failed.
+func (m *Multilevel) Get(key string) ([]byte, error) {
+	caches, err := m.getLevels()
+	if err != nil {
+		return nil, err
+	}
+
+	lastErr := types.ErrKeyNotFound
+	for i, c := range caches {
+		value, err := c.Get(key)
+		if err != nil {
+			if err != types.ErrKeyNotFound {
+				m.mLevelError.Incr(1)
+				m.log.Debugf("Failed to get key from cache resource '%v': %v\n", m.levels[i], err)
+				lastErr = err
+			}
+			continue
+		}
+		m.mHit.Incr(1)
+		if i > 0 {
+			m.mPopulate.Incr(1)
+			if err = m.setLevels(caches[:i], key, value); err != nil {
+				m.log.Debugf("Failed to populate upper cache levels: %v\n", err)
+			}
+		}
+		return value, nil
+	}
+
+	m.mMiss.Incr(1)
+	return nil, lastErr
+}
+
+// Set attempts to set the value of a key on all levels.
+func (m *Multilevel) Set(key string, value []byte) error {
+	caches, err := m.getLevels()
+	if err != nil {
+		return err
+	}
+	if err = m.setLevels(caches, key, value); err != nil {
+		m.mFailed.Incr(1)
+	}
+	return err
+}
+
+// Add attempts to set the value of a key on the last level only if the key
+// does not already exist, and if successful sets the key on all other levels.
+// Returns an error if the key already exists or if the operation fails.
+func (m *Multilevel) Add(key string, value []byte) error {
+	caches, err := m.getLevels()
+	if err != nil {
+		return err
+	}
+	last := len(caches) - 1
+	if err = caches[last].Add(key, value); err != nil {
+		if err != types.ErrKeyAlreadyExists {
+			m.mFailed.Incr(1)
+		}
+		return err
+	}
+	if err = m.setLevels(caches[:last], key, value); err != nil {
+		m.log.Debugf("Failed to populate upper cache levels: %v\n", err)
+	}
+	return nil
+}
+
+// Delete attempts to remove a key from all levels.
+func (m *Multilevel) Delete(key string) error {
+	caches, err := m.getLevels()
+	if err != nil {
+		return err
+	}
+	var firstErr error
+	for _, c := range caches {
+		if err = c.Delete(key); err != nil {
+			m.mLevelError.Incr(1)
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	if firstErr != nil {
+		m.mFailed.Incr(1)
+	}
+	return firstErr
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,212 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cache
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Jeffail/benthos/lib/log"
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+)
+
+//------------------------------------------------------------------------------
+
+type fakeCacheMgr struct {
+	types.DudMgr
+	caches map[string]types.Cache
+}
+
+func (f *fakeCacheMgr) GetCache(name string) (types.Cache, error) {
+	if c, exists := f.caches[name]; exists {
+		return c, nil
+	}
+	return nil, types.ErrCacheNotFound
+}
+
+type erroringCache struct {
+	err error
+}
+
+func (e erroringCache) Get(key string) ([]byte, error)     { return nil, e.err }
+func (e erroringCache) Set(key string, value []byte) error { return e.err }
+func (e erroringCache) Add(key string, value []byte) error { return e.err }
+func (e erroringCache) Delete(key string) error            { return e.err }
+
+func newMultilevelTestMgr(t *testing.T, names ...string) *fakeCacheMgr {
+	mgr := &fakeCacheMgr{caches: map[string]types.Cache{}}
+	for _, name := range names {
+		conf := NewConfig()
+		conf.Memory.CompactionIntervalS = 0
+		c, err := NewMemory(conf, nil, log.Noop(), metrics.Noop())
+		if err != nil {
+			t.Fatal(err)
+		}
+		mgr.caches[name] = c
+	}
+	return mgr
+}
+
+func TestMultilevelBadConfig(t *testing.T) {
+	mgr := newMultilevelTestMgr(t, "foo", "bar")
+
+	conf := NewConfig()
+	conf.Type = TypeMultilevel
+	conf.Multilevel = []string{"foo"}
+	if _, err := New(conf, mgr, log.Noop(), metrics.Noop()); err == nil {
+		t.Error("Expected error from single level")
+	}
+
+	conf.Multilevel = []string{"foo", "baz"}
+	if _, err := New(conf, mgr, log.Noop(), metrics.Noop()); err == nil {
+		t.Error("Expected error from missing level")
+	}
+}
+
+func TestMultilevelGetPopulates(t *testing.T) {
+	mgr := newMultilevelTestMgr(t, "foo", "bar", "baz")
+
+	conf := NewConfig()
+	conf.Type = TypeMultilevel
+	conf.Multilevel = []string{"foo", "bar", "baz"}
+
+	c, err := New(conf, mgr, log.Noop(), metrics.Noop())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = mgr.caches["baz"].Set("key", []byte("value")); err != nil {
+		t.Fatal(err)
+	}
+
+	if act, err := c.Get("key"); err != nil {
+		t.Error(err)
+	} else if exp := "value"; string(act) != exp {
+		t.Errorf("Wrong result: %s != %s", act, exp)
+	}
+
+	for _, name := range []string{"foo", "bar"} {
+		if act, err := mgr.caches[name].Get("key"); err != nil {
+			t.Errorf("Level %v: %v", name, err)
+		} else if exp := "value"; string(act) != exp {
+			t.Errorf("Level %v wrong result: %s != %s", name, act, exp)
+		}
+	}
+
+	expErr := types.ErrKeyNotFound
+	if _, act := c.Get("nope"); act != expErr {
+		t.Errorf("Wrong error returned: %v != %v", act, expErr)
+	}
+}
+
+func TestMultilevelWrites(t *testing.T) {
+	mgr := newMultilevelTestMgr(t, "foo", "bar")
+
+	conf := NewConfig()
+	conf.Type = TypeMultilevel
+	conf.Multilevel = []string{"foo", "bar"}
+
+	c, err := New(conf, mgr, log.Noop(), metrics.Noop())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = c.Set("a", []byte("1")); err != nil {
+		t.Error(err)
+	}
+	for _, name := range []string{"foo", "bar"} {
+		if act, err := mgr.caches[name].Get("a"); err != nil {
+			t.Errorf("Level %v: %v", name, err)
+		} else if exp := "1"; string(act) != exp {
+			t.Errorf("Level %v wrong result: %s != %s", name, act, exp)
+		}
+	}
+
+	// Add only checks the last level.
+	if err = mgr.caches["foo"].Set("b", []byte("stale")); err != nil {
+		t.Fatal(err)
+	}
+	if err = c.Add("b", []byte("2")); err != nil {
+		t.Error(err)
+	}
+	if act, err := mgr.caches["foo"].Get("b"); err != nil {
+		t.Error(err)
+	} else if exp := "2"; string(act) != exp {
+		t.Errorf("Wrong result: %s != %s", act, exp)
+	}
+
+	expErr := types.ErrKeyAlreadyExists
+	if act := c.Add("b", []byte("3")); act != expErr {
+		t.Errorf("Wrong error returned: %v != %v", act, expErr)
+	}
+
+	if err = c.Delete("b"); err != nil {
+		t.Error(err)
+	}
+	for _, name := range []string{"foo", "bar"} {
+		if _, act := mgr.caches[name].Get("b"); act != types.ErrKeyNotFound {
+			t.Errorf("Level %v wrong error returned: %v != %v", name, act, types.ErrKeyNotFound)
+		}
+	}
+}
+
+func TestMultilevelErrors(t *testing.T) {
+	mgr := newMultilevelTestMgr(t, "bar")
+	errTest := errors.New("test err")
+	mgr.caches["foo"] = erroringCache{err: errTest}
+
+	conf := NewConfig()
+	conf.Type = TypeMultilevel
+	conf.Multilevel = []string{"foo", "bar"}
+
+	c, err := New(conf, mgr, log.Noop(), metrics.Noop())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = mgr.caches["bar"].Set("a", []byte("1")); err != nil {
+		t.Fatal(err)
+	}
+
+	// A failing level is skipped during reads.
+	if act, err := c.Get("a"); err != nil {
+		t.Error(err)
+	} else if exp := "1"; string(act) != exp {
+		t.Errorf("Wrong result: %s != %s", act, exp)
+	}
+	if _, act := c.Get("b"); act != errTest {
+		t.Errorf("Wrong error returned: %v != %v", act, errTest)
+	}
+
+	if act := c.Set("b", []byte("2")); act != errTest {
+		t.Errorf("Wrong error returned: %v != %v", act, errTest)
+	}
+	if act, err := mgr.caches["bar"].Get("b"); err != nil {
+		t.Error(err)
+	} else if exp := "2"; string(act) != exp {
+		t.Errorf("Wrong result: %s != %s", act, exp)
+	}
+
+	if act := c.Delete("b"); act != errTest {
+		t.Errorf("Wrong error returned: %v != %v", act, errTest)
+	}
+}
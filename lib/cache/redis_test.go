@@ -0,0 +1,187 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cache
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/Jeffail/benthos/lib/log"
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/ory/dockertest"
+)
+
+func TestRedisBadConfig(t *testing.T) {
+	testLog := log.New(os.Stdout, log.Config{LogLevel: "NONE"})
+
+	tests := map[string]func(c *RedisConfig){
+		"no urls": func(c *RedisConfig) {
+			c.URLs = []string{""}
+		},
+		"bad ttl": func(c *RedisConfig) {
+			c.TTL = "not a duration"
+		},
+		"bad kind": func(c *RedisConfig) {
+			c.Kind = "nope"
+		},
+		"failover without master": func(c *RedisConfig) {
+			c.Kind = "failover"
+		},
+	}
+
+	for name, mod := range tests {
+		conf := NewConfig()
+		conf.Type = TypeRedis
+		mod(&conf.Redis)
+		if _, err := NewRedis(conf, nil, testLog, metrics.DudType{}); err == nil {
+			t.Errorf("%v: expected error", name)
+		}
+	}
+}
+
+func TestRedisIntegration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	pool, err := dockertest.NewPool("")
+	if err != nil {
+		t.Skipf("Could not connect to docker: %s", err)
+	}
+
+	resource, err := pool.Run("redis", "latest", nil)
+	if err != nil {
+		t.Fatalf("Could not start resource: %s", err)
+	}
+
+	urls := []string{fmt.Sprintf("tcp://localhost:%v", resource.GetPort("6379/tcp"))}
+
+	if err = pool.Retry(func() error {
+		conf := NewConfig()
+		conf.Redis.URLs = urls
+
+		testLog := log.New(os.Stdout, log.Config{LogLevel: "NONE"})
+		r, cErr := NewRedis(conf, nil, testLog, metrics.DudType{})
+		if cErr != nil {
+			return cErr
+		}
+		return r.Set("benthos_test_connection", []byte("hello world"))
+	}); err != nil {
+		t.Fatalf("Could not connect to docker resource: %s", err)
+	}
+
+	defer func() {
+		if err = pool.Purge(resource); err != nil {
+			t.Logf("Failed to clean up docker resource: %v", err)
+		}
+	}()
+
+	t.Run("TestRedisAddDuplicate", func(te *testing.T) {
+		testRedisAddDuplicate(urls, te)
+	})
+	t.Run("TestRedisGetAndSet", func(te *testing.T) {
+		testRedisGetAndSet(urls, te)
+	})
+}
+
+func testRedisAddDuplicate(urls []string, t *testing.T) {
+	conf := NewConfig()
+	conf.Redis.URLs = urls
+
+	testLog := log.New(os.Stdout, log.Config{LogLevel: "NONE"})
+	c, err := NewRedis(conf, nil, testLog, metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = c.Delete("benthos_test_foo"); err != nil {
+		t.Error(err)
+	}
+
+	if err = c.Add("benthos_test_foo", []byte("bar")); err != nil {
+		t.Error(err)
+	}
+	if err = c.Add("benthos_test_foo", []byte("baz")); err != types.ErrKeyAlreadyExists {
+		t.Errorf("Wrong error returned: %v != %v", err, types.ErrKeyAlreadyExists)
+	}
+
+	exp := "bar"
+	var act []byte
+
+	if act, err = c.Get("benthos_test_foo"); err != nil {
+		t.Error(err)
+	} else if string(act) != exp {
+		t.Errorf("Wrong value returned: %v != %v", string(act), exp)
+	}
+
+	if err = c.Delete("benthos_test_foo"); err != nil {
+		t.Error(err)
+	}
+}
+
+func testRedisGetAndSet(urls []string, t *testing.T) {
+	conf := NewConfig()
+	conf.Redis.URLs = urls
+
+	testLog := log.New(os.Stdout, log.Config{LogLevel: "NONE"})
+	c, err := NewRedis(conf, nil, testLog, metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = c.Delete("benthos_test_foo"); err != nil {
+		t.Error(err)
+	}
+
+	if _, err = c.Get("benthos_test_foo"); err != types.ErrKeyNotFound {
+		t.Errorf("Wrong error returned: %v != %v", err, types.ErrKeyNotFound)
+	}
+
+	if err = c.Set("benthos_test_foo", []byte("bar")); err != nil {
+		t.Error(err)
+	}
+
+	exp := "bar"
+	var act []byte
+
+	if act, err = c.Get("benthos_test_foo"); err != nil {
+		t.Error(err)
+	} else if string(act) != exp {
+		t.Errorf("Wrong value returned: %v != %v", string(act), exp)
+	}
+
+	if err = c.Set("benthos_test_foo", []byte("baz")); err != nil {
+		t.Error(err)
+	}
+
+	exp = "baz"
+	if act, err = c.Get("benthos_test_foo"); err != nil {
+		t.Error(err)
+	} else if string(act) != exp {
+		t.Errorf("Wrong value returned: %v != %v", string(act), exp)
+	}
+
+	if err = c.Delete("benthos_test_foo"); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,234 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cache
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+
+	"github.com/Jeffail/benthos/lib/log"
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	sess "github.com/Jeffail/benthos/lib/util/aws/session"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors[TypeS3] = TypeSpec{
+		constructor: NewS3,
+		description: `
+The s3 cache stores each item as an object within an Amazon S3 bucket, where the
+object key is the cache key with an optional ` + "`prefix`" + `. A prefix
+allows multiple cache types to share a single bucket.
+
+S3 does not support conditional writes, therefore the ` + "`add`" + ` operation
+checks for the existence of an object before writing it and is not atomic across
+multiple instances sharing a bucket. Expiry of items can be achieved with bucket
+lifecycle rules.
+
+### Credentials
+
+By default Benthos will use a shared credentials file when connecting to AWS
+services. It's also possible to set them explicitly at the component level,
+allowing you to transfer data across accounts. You can find out more
+[in this document](../aws.md).`,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// S3Config contains config fields for the S3 cache type.
+type S3Config struct {
+	sess.Config `json:",inline" yaml:",inline"`
+	Bucket      string `json:"bucket" yaml:"bucket"`
+	Prefix      string `json:"prefix" yaml:"prefix"`
+	ContentType string `json:"content_type" yaml:"content_type"`
+}
+
+// NewS3Config creates a S3Config populated with default values.
+func NewS3Config() S3Config {
+	return S3Config{
+		Config:      sess.NewConfig(),
+		Bucket:      "",
+		Prefix:      "",
+		ContentType: "application/octet-stream",
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// S3 is a cache implementation that stores items as objects in an S3 bucket.
+type S3 struct {
+	client s3iface.S3API
+	conf   S3Config
+	bucket *string
+	log    log.Modular
+
+	mGetNotFound metrics.StatCounter
+	mGetFailed   metrics.StatCounter
+	mGetSuccess  metrics.StatCounter
+	mSetFailed   metrics.StatCounter
+	mSetSuccess  metrics.StatCounter
+	mAddDupe     metrics.StatCounter
+	mAddFailed   metrics.StatCounter
+	mAddSuccess  metrics.StatCounter
+	mDelFailed   metrics.StatCounter
+	mDelSuccess  metrics.StatCounter
+}
+
+// NewS3 creates a new S3 cache type.
+func NewS3(conf Config, mgr types.Manager, log log.Modular, stats metrics.Type) (types.Cache, error) {
+	if len(conf.S3.Bucket) == 0 {
+		return nil, errors.New("a bucket must be specified")
+	}
+
+	sess, err := conf.S3.GetSession()
+	if err != nil {
+		return nil, err
+	}
+
+	return newS3(s3.New(sess), conf.S3, log, stats), nil
+}
+
+func newS3(client s3iface.S3API, conf S3Config, log log.Modular, stats metrics.Type) *S3 {
+	return &S3{
+		client: client,
+		conf:   conf,
+		bucket: aws.String(conf.Bucket),
+		log:    log.NewModule(".cache.s3"),
+
+		mGetNotFound: stats.GetCounter("cache.s3.get.failed.not_found"),
+		mGetFailed:   stats.GetCounter("cache.s3.get.failed.error"),
+		mGetSuccess:  stats.GetCounter("cache.s3.get.success"),
+		mSetFailed:   stats.GetCounter("cache.s3.set.failed.error"),
+		mSetSuccess:  stats.GetCounter("cache.s3.set.success"),
+		mAddDupe:     stats.GetCounter("cache.s3.add.failed.duplicate"),
+		mAddFailed:   stats.GetCounter("cache.s3.add.failed.error"),
+		mAddSuccess:  stats.GetCounter("cache.s3.add.success"),
+		mDelFailed:   stats.GetCounter("cache.s3.delete.failed.error"),
+		mDelSuccess:  stats.GetCounter("cache.s3.delete.success"),
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// isNotFound returns true if an error returned by S3 indicates that an object
+// does not exist.
+func isNotFound(err error) bool {
+	if aerr, ok := err.(awserr.Error); ok {
+		switch aerr.Code() {
+		case s3.ErrCodeNoSuchKey, "NotFound":
+			return true
+		}
+	}
+	return false
+}
+
+func (s *S3) put(key string, value []byte) error {
+	_, err := s.client.PutObject(&s3.PutObjectInput{
+		Bucket:      s.bucket,
+		Key:         aws.String(s.conf.Prefix + key),
+		Body:        bytes.NewReader(value),
+		ContentType: aws.String(s.conf.ContentType),
+	})
+	return err
+}
+
+// Get attempts to locate and return a cached value by its key, returns an error
+// if the key does not exist or if the operation failed.
+func (s *S3) Get(key string) ([]byte, error) {
+	obj, err := s.client.GetObject(&s3.GetObjectInput{
+		Bucket: s.bucket,
+		Key:    aws.String(s.conf.Prefix + key),
+	})
+	if err != nil {
+		if isNotFound(err) {
+			s.mGetNotFound.Incr(1)
+			return nil, types.ErrKeyNotFound
+		}
+		s.mGetFailed.Incr(1)
+		return nil, err
+	}
+	defer obj.Body.Close()
+
+	value, err := ioutil.ReadAll(obj.Body)
+	if err != nil {
+		s.mGetFailed.Incr(1)
+		return nil, err
+	}
+	s.mGetSuccess.Incr(1)
+	return value, nil
+}
+
+// Set attempts to set the value of a key.
+func (s *S3) Set(key string, value []byte) error {
+	if err := s.put(key, value); err != nil {
+		s.mSetFailed.Incr(1)
+		return err
+	}
+	s.mSetSuccess.Incr(1)
+	return nil
+}
+
+// Add attempts to set the value of a key only if the key does not already exist
+// and returns an error if the key already exists or if the operation fails.
+func (s *S3) Add(key string, value []byte) error {
+	_, err := s.client.HeadObject(&s3.HeadObjectInput{
+		Bucket: s.bucket,
+		Key:    aws.String(s.conf.Prefix + key),
+	})
+	if err == nil {
+		s.mAddDupe.Incr(1)
+		return types.ErrKeyAlreadyExists
+	}
+	if !isNotFound(err) {
+		s.mAddFailed.Incr(1)
+		return err
+	}
+	if err = s.put(key, value); err != nil {
+		s.mAddFailed.Incr(1)
+		return err
+	}
+	s.mAddSuccess.Incr(1)
+	return nil
+}
+
+// Delete attempts to remove a key.
+func (s *S3) Delete(key string) error {
+	_, err := s.client.DeleteObject(&s3.DeleteObjectInput{
+		Bucket: s.bucket,
+		Key:    aws.String(s.conf.Prefix + key),
+	})
+	if err != nil && !isNotFound(err) {
+		s.mDelFailed.Incr(1)
+		return err
+	}
+	s.mDelSuccess.Incr(1)
+	return nil
+}
+
+//------------------------------------------------------------------------------
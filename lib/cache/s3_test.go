@@ -0,0 +1,148 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cache
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"testing"
+
+	"github.com/Jeffail/benthos/lib/log"
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+)
+
+//------------------------------------------------------------------------------
+
+type mockS3 struct {
+	s3iface.S3API
+	objects map[string][]byte
+	err     error
+}
+
+func (m *mockS3) GetObject(input *s3.GetObjectInput) (*s3.GetObjectOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	obj, exists := m.objects[*input.Bucket+"/"+*input.Key]
+	if !exists {
+		return nil, awserr.New(s3.ErrCodeNoSuchKey, "not found", nil)
+	}
+	return &s3.GetObjectOutput{
+		Body: ioutil.NopCloser(bytes.NewReader(obj)),
+	}, nil
+}
+
+func (m *mockS3) HeadObject(input *s3.HeadObjectInput) (*s3.HeadObjectOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	if _, exists := m.objects[*input.Bucket+"/"+*input.Key]; !exists {
+		return nil, awserr.New("NotFound", "not found", nil)
+	}
+	return &s3.HeadObjectOutput{}, nil
+}
+
+func (m *mockS3) PutObject(input *s3.PutObjectInput) (*s3.PutObjectOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	value, err := ioutil.ReadAll(input.Body)
+	if err != nil {
+		return nil, err
+	}
+	m.objects[*input.Bucket+"/"+*input.Key] = value
+	return &s3.PutObjectOutput{}, nil
+}
+
+func (m *mockS3) DeleteObject(input *s3.DeleteObjectInput) (*s3.DeleteObjectOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	delete(m.objects, *input.Bucket+"/"+*input.Key)
+	return &s3.DeleteObjectOutput{}, nil
+}
+
+func TestS3Cache(t *testing.T) {
+	mock := &mockS3{objects: map[string][]byte{}}
+
+	conf := NewS3Config()
+	conf.Bucket = "foo"
+	conf.Prefix = "bar/"
+
+	c := newS3(mock, conf, log.Noop(), metrics.DudType{})
+
+	expErr := types.ErrKeyNotFound
+	if _, act := c.Get("baz"); act != expErr {
+		t.Errorf("Wrong error returned: %v != %v", act, expErr)
+	}
+
+	if err := c.Set("baz", []byte("1")); err != nil {
+		t.Error(err)
+	}
+	if _, exists := mock.objects["foo/bar/baz"]; !exists {
+		t.Errorf("Object not stored under prefix: %v", mock.objects)
+	}
+
+	exp := "1"
+	if act, err := c.Get("baz"); err != nil {
+		t.Error(err)
+	} else if string(act) != exp {
+		t.Errorf("Wrong result: %v != %v", string(act), exp)
+	}
+
+	expErr = types.ErrKeyAlreadyExists
+	if act := c.Add("baz", []byte("2")); act != expErr {
+		t.Errorf("Wrong error returned: %v != %v", act, expErr)
+	}
+
+	if err := c.Add("qux", []byte("2")); err != nil {
+		t.Error(err)
+	}
+
+	exp = "2"
+	if act, err := c.Get("qux"); err != nil {
+		t.Error(err)
+	} else if string(act) != exp {
+		t.Errorf("Wrong result: %v != %v", string(act), exp)
+	}
+
+	if err := c.Delete("baz"); err != nil {
+		t.Error(err)
+	}
+
+	expErr = types.ErrKeyNotFound
+	if _, act := c.Get("baz"); act != expErr {
+		t.Errorf("Wrong error returned: %v != %v", act, expErr)
+	}
+
+	mock.err = errors.New("nope")
+	if _, act := c.Get("qux"); act != mock.err {
+		t.Errorf("Wrong error returned: %v != %v", act, mock.err)
+	}
+	if act := c.Add("quz", []byte("3")); act != mock.err {
+		t.Errorf("Wrong error returned: %v != %v", act, mock.err)
+	}
+}
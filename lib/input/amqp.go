@@ -38,7 +38,10 @@ message brokers, including RabbitMQ.
 
 It's possible for this input type to declare the target queue by setting
 ` + "`queue_declare.enabled` to `true`" + `, if the queue already exists then
-the declaration passively verifies that they match the target fields.
+the declaration passively verifies that they match the target fields. The
+field ` + "`queue_declare.type`" + ` can be set to ` + "`quorum`" + ` in order to declare a
+quorum queue, which must be durable, and classic queues can be declared in lazy
+mode by setting ` + "`queue_declare.lazy` to `true`" + `.
 
 Similarly, it is possible to declare queue bindings by adding objects to the
 ` + "`bindings_declare`" + ` array. Binding declare objects take the form of:
@@ -50,6 +53,20 @@ Similarly, it is possible to declare queue bindings by adding objects to the
 }
 ` + "```" + `
 
+Bindings to a headers exchange can specify the headers to match with the
+` + "`arguments`" + ` field:
+
+` + "``` yaml" + `
+{
+  "exchange": "benthos-headers-exchange",
+  "key": "",
+  "arguments": {
+    "x-match": "all",
+    "region": "eu"
+  }
+}
+` + "```" + `
+
 TLS is automatic when connecting to an ` + "`amqps`" + ` URL, but custom
 settings can be enabled in the ` + "`tls`" + ` section.
 
@@ -80,7 +97,11 @@ This input adds the following metadata fields to each message:
 ` + "```" + `
 
 You can access these metadata fields using
-[function interpolation](../config_interpolation.md#metadata).`,
+[function interpolation](../config_interpolation.md#metadata).
+
+Which headers are added to messages as metadata, and the names they are
+given, can be configured within the ` + "`metadata`" + ` section as described
+[here](../metadata.md).`,
 	}
 }
 
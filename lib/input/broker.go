@@ -60,6 +60,7 @@ one. For example, reading from both RabbitMQ and Kafka:
 type: broker
 broker:
   copies: 1
+  pattern: fan_in
   inputs:
   - type: amqp
     amqp:
@@ -84,6 +85,24 @@ If the number of copies is greater than zero the list will be copied that number
 of times. For example, if your inputs were of type foo and bar, with 'copies'
 set to '2', you would end up with two 'foo' inputs and two 'bar' inputs.
 
+The broker pattern determines the way in which messages are consumed from the
+inputs and can be chosen from the following:
+
+#### ` + "`fan_in`" + `
+
+With the fan in pattern messages are consumed from all inputs as soon as they
+are available, resulting in messages from each input being interleaved.
+
+#### ` + "`priority`" + `
+
+With the priority pattern inputs are prioritised by their order in the list,
+where the first input has the highest priority. Messages are only consumed from
+an input when all inputs listed before it have no messages available. Copies of
+an input share the same priority.
+
+This pattern is useful when you wish to drain one source before another, such
+as a queue of messages to retry taking precedence over the main queue.
+
 ### Processors
 
 It is possible to configure [processors](../processors/README.md) at the broker
@@ -102,8 +121,9 @@ nodes processors.`,
 				inSlice = append(inSlice, sanInput)
 			}
 			return map[string]interface{}{
-				"copies": conf.Broker.Copies,
-				"inputs": inSlice,
+				"copies":  conf.Broker.Copies,
+				"pattern": conf.Broker.Pattern,
+				"inputs":  inSlice,
 			}, nil
 		},
 	}
@@ -113,15 +133,17 @@ nodes processors.`,
 
 // BrokerConfig contains configuration fields for the Broker input type.
 type BrokerConfig struct {
-	Copies int             `json:"copies" yaml:"copies"`
-	Inputs brokerInputList `json:"inputs" yaml:"inputs"`
+	Copies  int             `json:"copies" yaml:"copies"`
+	Pattern string          `json:"pattern" yaml:"pattern"`
+	Inputs  brokerInputList `json:"inputs" yaml:"inputs"`
 }
 
 // NewBrokerConfig creates a new BrokerConfig with default values.
 func NewBrokerConfig() BrokerConfig {
 	return BrokerConfig{
-		Copies: 1,
-		Inputs: brokerInputList{},
+		Copies:  1,
+		Pattern: "fan_in",
+		Inputs:  brokerInputList{},
 	}
 }
 
@@ -268,19 +290,34 @@ func NewBroker(
 		return New(conf.Broker.Inputs[0], mgr, log, stats, pipelines...)
 	}
 
-	inputs := make([]types.Producer, lInputs)
-
-	var err error
-	for j := 0; j < conf.Broker.Copies; j++ {
+	switch conf.Broker.Pattern {
+	case "fan_in":
+		inputs := make([]types.Producer, lInputs)
+		for j := 0; j < conf.Broker.Copies; j++ {
+			for i, iConf := range conf.Broker.Inputs {
+				input, err := New(iConf, mgr, log, stats, pipelines...)
+				if err != nil {
+					return nil, err
+				}
+				inputs[len(conf.Broker.Inputs)*j+i] = input
+			}
+		}
+		return broker.NewFanIn(inputs, stats)
+	case "priority":
+		tiers := make([][]types.Producer, len(conf.Broker.Inputs))
 		for i, iConf := range conf.Broker.Inputs {
-			inputs[len(conf.Broker.Inputs)*j+i], err = New(iConf, mgr, log, stats, pipelines...)
-			if err != nil {
-				return nil, err
+			for j := 0; j < conf.Broker.Copies; j++ {
+				input, err := New(iConf, mgr, log, stats, pipelines...)
+				if err != nil {
+					return nil, err
+				}
+				tiers[i] = append(tiers[i], input)
 			}
 		}
+		return broker.NewPriority(tiers, stats)
 	}
 
-	return broker.NewFanIn(inputs, stats)
+	return nil, fmt.Errorf("broker pattern was not recognised: %v", conf.Broker.Pattern)
 }
 
 //------------------------------------------------------------------------------
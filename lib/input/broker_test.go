@@ -23,6 +23,10 @@ package input
 import (
 	"encoding/json"
 	"testing"
+
+	"github.com/Jeffail/benthos/lib/log"
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
 )
 
 func TestBrokerConfigDefaults(t *testing.T) {
@@ -275,3 +279,17 @@ func TestBrokerConfigDittoZeroed(t *testing.T) {
 		t.Errorf("Unexpected value from config: %v != %v", exp, actual)
 	}
 }
+
+func TestBrokerBadPattern(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = TypeBroker
+	conf.Broker.Pattern = "not_a_pattern"
+
+	iConf := NewConfig()
+	iConf.Type = TypeInproc
+	conf.Broker.Inputs = append(conf.Broker.Inputs, iConf, iConf)
+
+	if _, err := New(conf, types.NoopMgr(), log.Noop(), metrics.Noop()); err == nil {
+		t.Error("Expected error from bad pattern")
+	}
+}
@@ -23,6 +23,7 @@ package input
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"sort"
 	"strings"
@@ -34,6 +35,7 @@ import (
 	"github.com/Jeffail/benthos/lib/processor"
 	"github.com/Jeffail/benthos/lib/types"
 	"github.com/Jeffail/benthos/lib/util/config"
+	"github.com/Jeffail/benthos/lib/util/lineage"
 	yaml "gopkg.in/yaml.v2"
 )
 
@@ -54,6 +56,11 @@ type TypeSpec struct {
 	sanitiseConfigFunc func(conf Config) (interface{}, error)
 }
 
+// Description returns the markdown description of the type.
+func (t TypeSpec) Description() string {
+	return t.description
+}
+
 // Constructors is a map of all input types with their specs.
 var Constructors = map[string]TypeSpec{}
 
@@ -73,6 +80,7 @@ const (
 	TypeKafka         = "kafka"
 	TypeKafkaBalanced = "kafka_balanced"
 	TypeKinesis       = "kinesis"
+	TypeMock          = "mock"
 	TypeMQTT          = "mqtt"
 	TypeNanomsg       = "nanomsg"
 	TypeNATS          = "nats"
@@ -89,6 +97,10 @@ const (
 	TypeZMQ4          = "zmq4"
 )
 
+// errZMQ4Unavailable is returned when a zmq4 input is configured but Benthos was
+// built without the ZMQ4 tag, which requires cgo and libzmq.
+var errZMQ4Unavailable = errors.New("input type zmq4 is not available in this build, Benthos must be compiled with libzmq installed and the build tag ZMQ4")
+
 //------------------------------------------------------------------------------
 
 // Config is the all encompassing configuration struct for all input types.
@@ -106,6 +118,7 @@ type Config struct {
 	Kafka         reader.KafkaConfig         `json:"kafka" yaml:"kafka"`
 	KafkaBalanced reader.KafkaBalancedConfig `json:"kafka_balanced" yaml:"kafka_balanced"`
 	Kinesis       reader.KinesisConfig       `json:"kinesis" yaml:"kinesis"`
+	Mock          reader.MockConfig          `json:"mock" yaml:"mock"`
 	MQTT          reader.MQTTConfig          `json:"mqtt" yaml:"mqtt"`
 	Nanomsg       reader.ScaleProtoConfig    `json:"nanomsg" yaml:"nanomsg"`
 	NATS          reader.NATSConfig          `json:"nats" yaml:"nats"`
@@ -121,6 +134,7 @@ type Config struct {
 	STDIN         STDINConfig                `json:"stdin" yaml:"stdin"`
 	Websocket     reader.WebsocketConfig     `json:"websocket" yaml:"websocket"`
 	ZMQ4          *reader.ZMQ4Config         `json:"zmq4,omitempty" yaml:"zmq4,omitempty"`
+	Lineage       lineage.Config             `json:"lineage" yaml:"lineage"`
 	Processors    []processor.Config         `json:"processors" yaml:"processors"`
 }
 
@@ -140,6 +154,7 @@ func NewConfig() Config {
 		Kafka:         reader.NewKafkaConfig(),
 		KafkaBalanced: reader.NewKafkaBalancedConfig(),
 		Kinesis:       reader.NewKinesisConfig(),
+		Mock:          reader.NewMockConfig(),
 		MQTT:          reader.NewMQTTConfig(),
 		Nanomsg:       reader.NewScaleProtoConfig(),
 		NATS:          reader.NewNATSConfig(),
@@ -155,6 +170,7 @@ func NewConfig() Config {
 		STDIN:         NewSTDINConfig(),
 		Websocket:     reader.NewWebsocketConfig(),
 		ZMQ4:          reader.NewZMQ4Config(),
+		Lineage:       lineage.NewConfig(),
 		Processors:    []processor.Config{},
 	}
 }
@@ -193,6 +209,10 @@ func SanitiseConfig(conf Config) (interface{}, error) {
 		}
 	}
 
+	if conf.Lineage.Enabled {
+		outputMap["lineage"] = hashMap["lineage"]
+	}
+
 	if len(conf.Processors) == 0 {
 		return outputMap, nil
 	}
@@ -303,7 +323,25 @@ input:
 
 Note that in this example we have specified a processor at the broker level
 which will be applied to _all_ inputs, and we also have a processor at the baz
-level which is only applied to messages from the baz input.`
+level which is only applied to messages from the baz input.
+
+### Lineage
+
+Any input can stamp lineage metadata onto the messages it reads by setting the
+field ` + "`lineage.enabled`" + ` to ` + "`true`" + `, which adds the
+following metadata fields to each message before it reaches the processors of
+the input:
+
+- ` + "`<prefix>input`" + `: The type of the input, e.g. ` + "`kafka`" + `.
+- ` + "`<prefix>consumed_at`" + `: The time the message was read in RFC 3339
+  format.
+- ` + "`<prefix>instance_id`" + `: An identifier of the Benthos instance,
+  consisting of the hostname followed by a random UUID generated at start up.
+
+Where ` + "`<prefix>`" + ` is the field ` + "`lineage.prefix`" + `, which
+defaults to ` + "`lineage_`" + `. These fields are not overwritten when they
+already exist, and so when lineage is enabled for the inputs of a broker the
+fields reflect the input that the message was read from.`
 
 // Descriptions returns a formatted string of descriptions for each type.
 func Descriptions() string {
@@ -362,15 +400,18 @@ func New(
 	stats metrics.Type,
 	pipelines ...types.PipelineConstructorFunc,
 ) (Type, error) {
-	if len(conf.Processors) > 0 {
+	if len(conf.Processors) > 0 || conf.Lineage.Enabled {
 		pipelines = append([]types.PipelineConstructorFunc{func() (types.Pipeline, error) {
-			processors := make([]types.Processor, len(conf.Processors))
-			for i, procConf := range conf.Processors {
-				var err error
-				processors[i], err = processor.New(procConf, mgr, log.NewModule("."+conf.Type), stats)
+			processors := make([]types.Processor, 0, len(conf.Processors)+1)
+			if conf.Lineage.Enabled {
+				processors = append(processors, newLineageStamp(conf))
+			}
+			for _, procConf := range conf.Processors {
+				proc, err := processor.New(procConf, mgr, log.NewModule("."+conf.Type), stats)
 				if err != nil {
 					return nil, fmt.Errorf("failed to create processor '%v': %v", procConf.Type, err)
 				}
+				processors = append(processors, proc)
 			}
 			return pipeline.NewProcessor(log, stats, processors...), nil
 		}}, pipelines...)
@@ -383,6 +424,7 @@ func New(
 		if err != nil {
 			return nil, fmt.Errorf("failed to create input '%v': %v", conf.Type, err)
 		}
+		registerConnector(mgr, conf.Type, input)
 		return WrapWithPipelines(input, pipelines...)
 	}
 	if c, ok := pluginSpecs[conf.Type]; ok {
@@ -390,9 +432,39 @@ func New(
 		if err != nil {
 			return nil, err
 		}
+		registerConnector(mgr, conf.Type, input)
 		return WrapWithPipelines(input, pipelines...)
 	}
+	if conf.Type == TypeZMQ4 {
+		return nil, errZMQ4Unavailable
+	}
 	return nil, types.ErrInvalidInputType
 }
 
 //------------------------------------------------------------------------------
+
+// newLineageStamp creates a processor that stamps the type of an input, the
+// time at which messages were consumed and the instance ID onto messages. The
+// fields are only set when absent so that the inputs of a broker take
+// precedence over the broker itself.
+func newLineageStamp(conf Config) types.Processor {
+	prefix := conf.Lineage.Prefix
+	return lineage.NewStamp(map[string]string{
+		prefix + "input":       conf.Type,
+		prefix + "instance_id": lineage.InstanceID(),
+	}, prefix+"consumed_at", false)
+}
+
+// registerConnector registers an input with the manager when the input reports
+// its connection state and the manager tracks it.
+func registerConnector(mgr types.Manager, typeStr string, input Type) {
+	c, ok := input.(types.Connector)
+	if !ok {
+		return
+	}
+	if reg, ok := mgr.(types.ConnectionRegistry); ok {
+		reg.RegisterConnector("input."+typeStr, c)
+	}
+}
+
+//------------------------------------------------------------------------------
@@ -22,10 +22,18 @@ package input
 
 import (
 	"encoding/json"
+	"io/ioutil"
+	"os"
 	"reflect"
 	"testing"
+	"time"
 
+	"github.com/Jeffail/benthos/lib/log"
+	"github.com/Jeffail/benthos/lib/metrics"
 	"github.com/Jeffail/benthos/lib/processor"
+	"github.com/Jeffail/benthos/lib/response"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/lineage"
 )
 
 func TestSanitise(t *testing.T) {
@@ -76,3 +84,54 @@ func TestSanitise(t *testing.T) {
 		t.Errorf("Wrong sanitised output: %v != %v", act, exp)
 	}
 }
+
+func TestLineage(t *testing.T) {
+	tmpfile, err := ioutil.TempFile("", "benthos_lineage_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(tmpfile.Name())
+	tmpfile.Write([]byte("foo\n"))
+	tmpfile.Close()
+
+	conf := NewConfig()
+	conf.Type = TypeFile
+	conf.File.Path = tmpfile.Name()
+	conf.Lineage.Enabled = true
+	conf.Lineage.Prefix = "foo_"
+
+	in, err := New(conf, types.NoopMgr(), log.Noop(), metrics.Noop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		in.CloseAsync()
+		if err := in.WaitForClose(time.Second); err != nil {
+			t.Error(err)
+		}
+	}()
+
+	var ts types.Transaction
+	select {
+	case ts = <-in.TransactionChan():
+	case <-time.After(time.Second):
+		t.Fatal("timed out")
+	}
+
+	meta := ts.Payload.Get(0).Metadata()
+	if exp, act := "file", meta.Get("foo_input"); exp != act {
+		t.Errorf("Wrong input: %v != %v", act, exp)
+	}
+	if exp, act := lineage.InstanceID(), meta.Get("foo_instance_id"); exp != act {
+		t.Errorf("Wrong instance ID: %v != %v", act, exp)
+	}
+	if _, err = time.Parse(time.RFC3339Nano, meta.Get("foo_consumed_at")); err != nil {
+		t.Errorf("Failed to parse consumed_at: %v", err)
+	}
+
+	select {
+	case ts.ResponseChan <- response.NewAck():
+	case <-time.After(time.Second):
+		t.Fatal("timed out")
+	}
+}
@@ -43,13 +43,14 @@ The dynamic type is a special broker type where the inputs are identified by
 unique labels and can be created, changed and removed during runtime via a REST
 HTTP interface.
 
-To GET a JSON map of input identifiers with their current uptimes use the
-` + "`/inputs`" + ` endpoint.
+To GET a JSON map of input identifiers with their current uptimes, whether
+they are connected and their configurations use the ` + "`/inputs`" + `
+endpoint.
 
 To perform CRUD actions on the inputs themselves use POST, DELETE, and GET
 methods on the ` + "`/inputs/{input_id}`" + ` endpoint. When using POST the body
 of the request should be a JSON configuration for the input, if the input
-already exists it will be changed.`,
+already exists it will be stopped and replaced.`,
 		sanitiseConfigFunc: func(conf Config) (interface{}, error) {
 			nestedInputs := conf.Dynamic.Inputs
 			inMap := map[string]interface{}{}
@@ -98,13 +99,16 @@ func NewDynamic(
 	pipelines ...types.PipelineConstructorFunc,
 ) (Type, error) {
 	dynAPI := api.NewDynamic()
+	connectors := newDynamicConnectors()
 
 	inputs := map[string]broker.DynamicInput{}
 	for k, v := range conf.Dynamic.Inputs {
-		newInput, err := New(v, mgr, log, stats, pipelines...)
+		iMgr := newDynamicConnectorMgr(mgr)
+		newInput, err := New(v, iMgr, log, stats, pipelines...)
 		if err != nil {
 			return nil, err
 		}
+		connectors.set(k, iMgr)
 		inputs[k] = newInput
 	}
 
@@ -147,7 +151,8 @@ func NewDynamic(
 		if err := json.Unmarshal(c, &newConf); err != nil {
 			return err
 		}
-		newInput, err := New(Config(newConf), mgr, log, stats, pipelines...)
+		iMgr := newDynamicConnectorMgr(mgr)
+		newInput, err := New(Config(newConf), iMgr, log, stats, pipelines...)
 		if err != nil {
 			return err
 		}
@@ -158,12 +163,19 @@ func NewDynamic(
 			inputConfigsMut.Lock()
 			delete(inputConfigs, id)
 			inputConfigsMut.Unlock()
+			return err
 		}
-		return err
+		connectors.set(id, iMgr)
+		return nil
 	})
 	dynAPI.OnDelete(func(id string) error {
-		return fanIn.SetInput(id, nil, reqTimeout)
+		err := fanIn.SetInput(id, nil, reqTimeout)
+		if err == nil {
+			connectors.set(id, nil)
+		}
+		return err
 	})
+	dynAPI.OnStatus(connectors.connected)
 
 	mgr.RegisterEndpoint(
 		path.Join(conf.Dynamic.Prefix, "/inputs/{id}"),
@@ -181,3 +193,73 @@ func NewDynamic(
 }
 
 //------------------------------------------------------------------------------
+
+// dynamicConnectorMgr wraps a manager in order to capture the connectors
+// registered by a dynamic input during its construction.
+type dynamicConnectorMgr struct {
+	types.Manager
+
+	mut        sync.Mutex
+	connectors []types.Connector
+}
+
+func newDynamicConnectorMgr(mgr types.Manager) *dynamicConnectorMgr {
+	return &dynamicConnectorMgr{Manager: mgr}
+}
+
+// RegisterConnector captures a connector of a dynamic input and forwards it to
+// the wrapped manager.
+func (d *dynamicConnectorMgr) RegisterConnector(name string, c types.Connector) {
+	d.mut.Lock()
+	d.connectors = append(d.connectors, c)
+	d.mut.Unlock()
+	if reg, ok := d.Manager.(types.ConnectionRegistry); ok {
+		reg.RegisterConnector(name, c)
+	}
+}
+
+// dynamicConnectors tracks the connectors of each dynamic input by its label.
+type dynamicConnectors struct {
+	mut    sync.Mutex
+	byName map[string]*dynamicConnectorMgr
+}
+
+func newDynamicConnectors() *dynamicConnectors {
+	return &dynamicConnectors{
+		byName: map[string]*dynamicConnectorMgr{},
+	}
+}
+
+// set replaces the connectors of a dynamic input label with those captured by
+// a manager, a nil manager removes the label.
+func (d *dynamicConnectors) set(label string, mgr *dynamicConnectorMgr) {
+	d.mut.Lock()
+	if mgr == nil {
+		delete(d.byName, label)
+	} else {
+		d.byName[label] = mgr
+	}
+	d.mut.Unlock()
+}
+
+// connected returns true if all connectors of a dynamic input that have not
+// closed are currently connected.
+func (d *dynamicConnectors) connected(label string) bool {
+	d.mut.Lock()
+	mgr, exists := d.byName[label]
+	d.mut.Unlock()
+	if !exists {
+		return false
+	}
+
+	mgr.mut.Lock()
+	defer mgr.mut.Unlock()
+	for _, c := range mgr.connectors {
+		if err := c.ConnectionStatus(); err != nil && err != types.ErrTypeClosed {
+			return false
+		}
+	}
+	return true
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,159 @@
+// Copyright (c) 2014 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package input
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/log"
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/gorilla/mux"
+)
+
+type fakeDynamicMgr struct {
+	types.DudMgr
+	router     *mux.Router
+	registered []string
+}
+
+func (f *fakeDynamicMgr) RegisterEndpoint(path, desc string, h http.HandlerFunc) {
+	f.router.HandleFunc(path, h)
+}
+
+func (f *fakeDynamicMgr) RegisterConnector(name string, c types.Connector) {
+	f.registered = append(f.registered, name)
+}
+
+type fakeConnector struct {
+	err error
+}
+
+func (f *fakeConnector) ConnectionStatus() error {
+	return f.err
+}
+
+func TestDynamicConnectors(t *testing.T) {
+	parent := &fakeDynamicMgr{router: mux.NewRouter()}
+	connectors := newDynamicConnectors()
+
+	fooConn, barConn := &fakeConnector{}, &fakeConnector{}
+	fooMgr := newDynamicConnectorMgr(parent)
+	fooMgr.RegisterConnector("input.foo", fooConn)
+	fooMgr.RegisterConnector("input.bar", barConn)
+	connectors.set("foo", fooMgr)
+
+	if exp, act := []string{"input.foo", "input.bar"}, parent.registered; len(act) != 2 || act[0] != exp[0] || act[1] != exp[1] {
+		t.Errorf("Wrong forwarded connectors: %v != %v", act, exp)
+	}
+
+	if !connectors.connected("foo") {
+		t.Error("Expected foo to be connected")
+	}
+	barConn.err = types.ErrNotConnected
+	if connectors.connected("foo") {
+		t.Error("Expected foo to not be connected")
+	}
+	barConn.err = types.ErrTypeClosed
+	if !connectors.connected("foo") {
+		t.Error("Expected closed connectors to be ignored")
+	}
+
+	connectors.set("foo", nil)
+	if connectors.connected("foo") {
+		t.Error("Expected removed input to not be connected")
+	}
+}
+
+func TestDynamicAPI(t *testing.T) {
+	mgr := &fakeDynamicMgr{router: mux.NewRouter()}
+
+	conf := NewConfig()
+	conf.Type = TypeDynamic
+	conf.Processors = nil
+
+	in, err := New(conf, mgr, log.Noop(), metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		in.CloseAsync()
+		if err := in.WaitForClose(time.Second); err != nil {
+			t.Error(err)
+		}
+	}()
+
+	inConf := []byte(`{"type":"http_server","http_server":{"path":"/foo"}}`)
+	request := httptest.NewRequest("POST", "/inputs/foo", bytes.NewReader(inConf))
+	response := httptest.NewRecorder()
+	mgr.router.ServeHTTP(response, request)
+	if exp, act := http.StatusOK, response.Code; exp != act {
+		t.Fatalf("Unexpected response code: %v != %v: %s", act, exp, response.Body.Bytes())
+	}
+
+	var list map[string]struct {
+		Uptime    string                 `json:"uptime"`
+		Connected *bool                  `json:"connected"`
+		Config    map[string]interface{} `json:"config"`
+	}
+	// Inputs are listed once they have started asynchronously.
+	for i := 0; i < 100; i++ {
+		request = httptest.NewRequest("GET", "/inputs", nil)
+		response = httptest.NewRecorder()
+		mgr.router.ServeHTTP(response, request)
+		if err = json.Unmarshal(response.Body.Bytes(), &list); err != nil {
+			t.Fatal(err)
+		}
+		if info, exists := list["foo"]; exists && info.Connected != nil {
+			break
+		}
+		<-time.After(time.Millisecond * 10)
+	}
+	info, exists := list["foo"]
+	if !exists {
+		t.Fatalf("Input missing from list: %s", response.Body.Bytes())
+	}
+	if info.Connected == nil || !*info.Connected {
+		t.Errorf("Expected input to be connected: %s", response.Body.Bytes())
+	}
+	if exp, act := "http_server", info.Config["type"]; exp != act {
+		t.Errorf("Wrong input type: %v != %v", act, exp)
+	}
+
+	request = httptest.NewRequest("DELETE", "/inputs/foo", nil)
+	response = httptest.NewRecorder()
+	mgr.router.ServeHTTP(response, request)
+	if exp, act := http.StatusOK, response.Code; exp != act {
+		t.Errorf("Unexpected response code: %v != %v", act, exp)
+	}
+
+	request = httptest.NewRequest("GET", "/inputs", nil)
+	response = httptest.NewRecorder()
+	mgr.router.ServeHTTP(response, request)
+	if exp, act := "{}", response.Body.String(); exp != act {
+		t.Errorf("Wrong list after delete: %v != %v", act, exp)
+	}
+}
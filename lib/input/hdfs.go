@@ -27,6 +27,7 @@ import (
 	"github.com/Jeffail/benthos/lib/log"
 	"github.com/Jeffail/benthos/lib/metrics"
 	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/kerberos"
 )
 
 //------------------------------------------------------------------------------
@@ -48,7 +49,13 @@ This input adds the following metadata fields to each message:
 ` + "```" + `
 
 You can access these metadata fields using
-[function interpolation](../config_interpolation.md#metadata).`,
+[function interpolation](../config_interpolation.md#metadata).
+
+Kerberos authentication is enabled by setting a principal in the
+` + "`kerberos`" + ` section, where ` + "`service_name`" + ` is the service
+principal of the namenode.
+
+` + kerberos.Documentation + ``,
 	}
 }
 
@@ -22,8 +22,12 @@ package input
 
 import (
 	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"net/http"
+	"strconv"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -34,7 +38,10 @@ import (
 	"github.com/Jeffail/benthos/lib/message"
 	"github.com/Jeffail/benthos/lib/metrics"
 	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/checkpoint"
 	"github.com/Jeffail/benthos/lib/util/http/client"
+	"github.com/Jeffail/benthos/lib/util/text"
+	"github.com/Jeffail/gabs"
 )
 
 //------------------------------------------------------------------------------
@@ -52,6 +59,43 @@ target server.
 The URL and header values of this type can be dynamically set using function
 interpolations described [here](../config_interpolation.md#functions).
 
+### OAuth2
+
+When ` + "`oauth2.enabled`" + ` is set the client obtains an access token from
+` + "`oauth2.token_url`" + ` using the client credentials grant and sets it as a
+bearer token on each request. Tokens are cached and refreshed automatically
+shortly before they expire.
+
+### Pagination
+
+When ` + "`pagination.enabled`" + ` is set the input follows paginated responses
+by resolving ` + "`pagination.next_url`" + ` after each page in order to obtain the
+URL of the following page. The field supports function interpolations, which are
+resolved against the body of the previous response, with the response headers
+and the following metadata fields available:
+
+` + "```" + `
+- http_page
+- http_next_page
+` + "```" + `
+
+Header names are lowercased. For example, a next page link within the body can
+be followed with ` + "`${!json_field:links.next}`" + `, a cursor can be passed with
+` + "`https://example.com/items?cursor=${!json_field:cursor}`" + `, a link header
+can be followed with ` + "`${!metadata:next-page}`" + ` and an incrementing page
+parameter can be set with ` + "`https://example.com/items?page=${!metadata:http_next_page}`" + `.
+
+If ` + "`pagination.items_path`" + ` is set then each page is parsed as JSON and
+the elements of the array at that path are emitted as the parts of a batch,
+otherwise the whole page is emitted.
+
+Pagination ends when a page is empty, contains no items, or the next URL
+resolves to an empty string or ` + "`null`" + `, after which the input starts
+again from the configured ` + "`url`" + `. The position of the next page is only
+moved forward once the current page has been delivered, and can be checkpointed
+to a [cache resource](../caches/README.md) by setting ` + "`pagination.cache`" + `
+so that it is resumed after a restart. Pagination cannot be used with streaming.
+
 ### Streaming
 
 If you enable streaming then Benthos will consume the body of the response as a
@@ -73,11 +117,22 @@ type StreamConfig struct {
 	Delim     string `json:"delimiter" yaml:"delimiter"`
 }
 
+// PaginationConfig contains fields for specifying how paginated responses
+// should be followed when polling.
+type PaginationConfig struct {
+	Enabled   bool   `json:"enabled" yaml:"enabled"`
+	NextURL   string `json:"next_url" yaml:"next_url"`
+	ItemsPath string `json:"items_path" yaml:"items_path"`
+	Cache     string `json:"cache" yaml:"cache"`
+	CacheKey  string `json:"cache_key" yaml:"cache_key"`
+}
+
 // HTTPClientConfig contains configuration for the HTTPClient output type.
 type HTTPClientConfig struct {
 	client.Config `json:",inline" yaml:",inline"`
-	Payload       string       `json:"payload" yaml:"payload"`
-	Stream        StreamConfig `json:"stream" yaml:"stream"`
+	Payload       string           `json:"payload" yaml:"payload"`
+	Stream        StreamConfig     `json:"stream" yaml:"stream"`
+	Pagination    PaginationConfig `json:"pagination" yaml:"pagination"`
 }
 
 // NewHTTPClientConfig creates a new HTTPClientConfig with default values.
@@ -95,6 +150,13 @@ func NewHTTPClientConfig() HTTPClientConfig {
 			MaxBuffer: 1000000,
 			Delim:     "",
 		},
+		Pagination: PaginationConfig{
+			Enabled:   false,
+			NextURL:   "",
+			ItemsPath: "",
+			Cache:     "",
+			CacheKey:  "http_client_page",
+		},
 	}
 }
 
@@ -115,6 +177,10 @@ type HTTPClient struct {
 
 	payload types.Message
 
+	nextURL     *text.InterpolatedString
+	checkpoints checkpoint.Store
+	page        pageState
+
 	transactions chan types.Transaction
 
 	closeChan  chan struct{}
@@ -153,6 +219,21 @@ func NewHTTPClient(conf Config, mgr types.Manager, log log.Modular, stats metric
 		return nil, err
 	}
 
+	if pConf := h.conf.HTTPClient.Pagination; pConf.Enabled {
+		if h.conf.HTTPClient.Stream.Enabled {
+			return nil, errors.New("pagination cannot be enabled when streaming")
+		}
+		if len(pConf.NextURL) == 0 {
+			return nil, errors.New("pagination requires a next_url")
+		}
+		h.nextURL = text.NewInterpolatedString(pConf.NextURL)
+		if len(pConf.Cache) > 0 {
+			if h.checkpoints, err = checkpoint.NewCache(pConf.Cache, "", mgr); err != nil {
+				return nil, err
+			}
+		}
+	}
+
 	if !h.conf.HTTPClient.Stream.Enabled {
 		go h.loop()
 		return &h, nil
@@ -243,7 +324,7 @@ func NewHTTPClient(conf Config, mgr types.Manager, log log.Modular, stats metric
 //------------------------------------------------------------------------------
 
 func (h *HTTPClient) doRequest() (*http.Response, error) {
-	return h.client.Do(h.payload)
+	return h.client.DoURL(h.payload, h.page.URL)
 }
 
 func (h *HTTPClient) parseResponse(res *http.Response) (types.Message, error) {
@@ -256,6 +337,127 @@ func (h *HTTPClient) parseResponse(res *http.Response) (types.Message, error) {
 
 //------------------------------------------------------------------------------
 
+// pageState describes the next page to be requested when following paginated
+// responses, an empty URL indicates the configured URL.
+type pageState struct {
+	URL  string `json:"url"`
+	Page int    `json:"page"`
+}
+
+func firstPage() pageState {
+	return pageState{Page: 1}
+}
+
+// loadPage reads the next page to request from the checkpoint cache, if one
+// is configured.
+func (h *HTTPClient) loadPage() {
+	h.page = firstPage()
+	if h.checkpoints == nil {
+		return
+	}
+	stateBytes, err := h.checkpoints.Get(h.conf.HTTPClient.Pagination.CacheKey)
+	if err != nil {
+		h.log.Errorf("Failed to read pagination checkpoint: %v\n", err)
+		return
+	}
+	if stateBytes == nil {
+		return
+	}
+	if err = json.Unmarshal(stateBytes, &h.page); err != nil {
+		h.log.Errorf("Failed to parse pagination checkpoint: %v\n", err)
+		h.page = firstPage()
+	}
+}
+
+// commitPage moves the pagination forward to the next page, checkpointing it
+// to a cache if one is configured.
+func (h *HTTPClient) commitPage(state pageState) {
+	h.page = state
+	if h.checkpoints == nil {
+		return
+	}
+	stateBytes, err := json.Marshal(state)
+	if err == nil {
+		err = h.checkpoints.Set(h.conf.HTTPClient.Pagination.CacheKey, stateBytes)
+	}
+	if err != nil {
+		h.log.Errorf("Failed to write pagination checkpoint: %v\n", err)
+	}
+}
+
+// pageItems extracts the elements of an array within a JSON page as the parts
+// of a message.
+func pageItems(page types.Part, path string) (types.Message, error) {
+	jObj, err := page.JSON()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse page as JSON: %v", err)
+	}
+	gObj, _ := gabs.Consume(jObj)
+	msg := message.New(nil)
+	switch t := gObj.Path(path).Data().(type) {
+	case nil:
+	case []interface{}:
+		for _, item := range t {
+			part := message.NewPart(nil)
+			if err = part.SetJSON(item); err != nil {
+				return nil, err
+			}
+			msg.Append(part)
+		}
+	default:
+		return nil, fmt.Errorf("page items path '%v' is not an array", path)
+	}
+	return msg, nil
+}
+
+// parsePage parses a response as a page of a paginated request, returning the
+// items of the page and the state of the following page. If the page is empty
+// then a nil message is returned along with the state of the first page.
+func (h *HTTPClient) parsePage(res *http.Response) (types.Message, pageState, error) {
+	headers := res.Header
+
+	msg, err := h.parseResponse(res)
+	if err != nil {
+		return nil, h.page, err
+	}
+	if msg == nil || msg.Len() == 0 {
+		return nil, firstPage(), nil
+	}
+
+	page := msg.Get(0).Copy()
+	for k, v := range headers {
+		if len(v) > 0 {
+			page.Metadata().Set(strings.ToLower(k), v[0])
+		}
+	}
+	page.Metadata().
+		Set("http_page", strconv.Itoa(h.page.Page)).
+		Set("http_next_page", strconv.Itoa(h.page.Page+1))
+
+	if path := h.conf.HTTPClient.Pagination.ItemsPath; len(path) > 0 {
+		if msg, err = pageItems(page, path); err != nil {
+			return nil, h.page, err
+		}
+		if msg.Len() == 0 {
+			return nil, firstPage(), nil
+		}
+	}
+
+	pageMsg := message.New(nil)
+	pageMsg.Append(page)
+
+	next := pageState{
+		URL:  h.nextURL.Get(pageMsg),
+		Page: h.page.Page + 1,
+	}
+	if len(next.URL) == 0 || next.URL == "null" {
+		next = firstPage()
+	}
+	return msg, next, nil
+}
+
+//------------------------------------------------------------------------------
+
 // loop is an internal loop brokers incoming messages to output pipe through
 // POST requests.
 func (h *HTTPClient) loop() {
@@ -281,9 +483,14 @@ func (h *HTTPClient) loop() {
 	mRunning.Incr(1)
 	h.log.Infof("Polling for HTTP messages from: %s\n", h.conf.HTTPClient.URL)
 
+	if h.nextURL != nil {
+		h.loadPage()
+	}
+
 	resOut := make(chan types.Response)
 
 	var msgOut types.Message
+	var nextPage pageState
 	for atomic.LoadInt32(&h.running) == 1 {
 		if msgOut == nil {
 			var res *http.Response
@@ -298,7 +505,14 @@ func (h *HTTPClient) loop() {
 					mReqErr.Incr(1)
 				}
 			} else {
-				if msgOut, err = h.parseResponse(res); err != nil {
+				if h.nextURL != nil {
+					if msgOut, nextPage, err = h.parsePage(res); err == nil && msgOut == nil {
+						h.commitPage(nextPage)
+					}
+				} else {
+					msgOut, err = h.parseResponse(res)
+				}
+				if err != nil {
 					mReqParseErr.Incr(1)
 					h.log.Errorf("Failed to decode response: %v\n", err)
 				} else {
@@ -325,6 +539,9 @@ func (h *HTTPClient) loop() {
 				} else {
 					msgOut = nil
 					mSendSucc.Incr(1)
+					if h.nextURL != nil {
+						h.commitPage(nextPage)
+					}
 				}
 			case <-h.closeChan:
 				return
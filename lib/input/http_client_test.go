@@ -2,18 +2,23 @@ package input
 
 import (
 	"bytes"
+	"fmt"
 	"io"
 	"io/ioutil"
 	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
 	"net/textproto"
+	"reflect"
 	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/Jeffail/benthos/lib/cache"
 	"github.com/Jeffail/benthos/lib/log"
+	"github.com/Jeffail/benthos/lib/manager"
+	"github.com/Jeffail/benthos/lib/message"
 	"github.com/Jeffail/benthos/lib/metrics"
 	"github.com/Jeffail/benthos/lib/response"
 	"github.com/Jeffail/benthos/lib/types"
@@ -816,3 +821,136 @@ func BenchmarkHTTPClientGETMultipart(b *testing.B) {
 		b.Error(err)
 	}
 }
+
+func readHTTPClientPage(t *testing.T, h Type) [][]byte {
+	t.Helper()
+
+	var tr types.Transaction
+	var open bool
+	select {
+	case tr, open = <-h.TransactionChan():
+		if !open {
+			t.Fatal("Chan not open")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Action timed out")
+	}
+	select {
+	case tr.ResponseChan <- response.NewAck():
+	case <-time.After(time.Second):
+		t.Fatal("Action timed out")
+	}
+	return message.GetAllBytes(tr.Payload)
+}
+
+func TestHTTPClientPaginationNextURL(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("cursor") {
+		case "":
+			w.Write([]byte(`{"items":[{"id":1},{"id":2}],"next":"` + "http://" + r.Host + `/items?cursor=b"}`))
+		case "b":
+			w.Write([]byte(`{"items":[{"id":3}],"next":"` + "http://" + r.Host + `/items?cursor=c"}`))
+		case "c":
+			w.Write([]byte(`{"items":[{"id":4}]}`))
+		}
+	}))
+	defer ts.Close()
+
+	conf := NewConfig()
+	conf.HTTPClient.URL = ts.URL + "/items"
+	conf.HTTPClient.RetryMS = 1
+	conf.HTTPClient.Pagination.Enabled = true
+	conf.HTTPClient.Pagination.NextURL = "${!json_field:next}"
+	conf.HTTPClient.Pagination.ItemsPath = "items"
+
+	h, err := NewHTTPClient(conf, nil, log.Noop(), metrics.Noop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		h.CloseAsync()
+		h.WaitForClose(time.Second)
+	}()
+
+	exp := [][][]byte{
+		{[]byte(`{"id":1}`), []byte(`{"id":2}`)},
+		{[]byte(`{"id":3}`)},
+		{[]byte(`{"id":4}`)},
+		{[]byte(`{"id":1}`), []byte(`{"id":2}`)},
+	}
+	for i, e := range exp {
+		if act := readHTTPClientPage(t, h); !reflect.DeepEqual(e, act) {
+			t.Errorf("Wrong page %v: %s != %s", i, act, e)
+		}
+	}
+}
+
+func TestHTTPClientPaginationPageCache(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch page := r.URL.Query().Get("page"); page {
+		case "1", "2", "3":
+			w.Write([]byte(fmt.Sprintf(`{"page":%v}`, page)))
+		}
+	}))
+	defer ts.Close()
+
+	mConf := manager.NewConfig()
+	cConf := cache.NewConfig()
+	cConf.Type = cache.TypeMemory
+	mConf.Caches["pages"] = cConf
+	mgr, err := manager.New(mConf, nil, log.Noop(), metrics.Noop())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	conf := NewConfig()
+	conf.HTTPClient.URL = ts.URL + "/items?page=1"
+	conf.HTTPClient.RetryMS = 1
+	conf.HTTPClient.Pagination.Enabled = true
+	conf.HTTPClient.Pagination.NextURL = ts.URL + "/items?page=${!metadata:http_next_page}"
+	conf.HTTPClient.Pagination.Cache = "pages"
+
+	h, err := NewHTTPClient(conf, mgr, log.Noop(), metrics.Noop())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, e := range []string{`{"page":1}`, `{"page":2}`} {
+		if act := readHTTPClientPage(t, h); !reflect.DeepEqual([][]byte{[]byte(e)}, act) {
+			t.Errorf("Wrong page %v: %s != %s", i, act, e)
+		}
+	}
+	h.CloseAsync()
+	if err = h.WaitForClose(time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	// A new input should resume from the checkpoint.
+	if h, err = NewHTTPClient(conf, mgr, log.Noop(), metrics.Noop()); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		h.CloseAsync()
+		h.WaitForClose(time.Second)
+	}()
+
+	for i, e := range []string{`{"page":3}`, `{"page":1}`} {
+		if act := readHTTPClientPage(t, h); !reflect.DeepEqual([][]byte{[]byte(e)}, act) {
+			t.Errorf("Wrong page %v: %s != %s", i, act, e)
+		}
+	}
+}
+
+func TestHTTPClientPaginationBadConfig(t *testing.T) {
+	conf := NewConfig()
+	conf.HTTPClient.Pagination.Enabled = true
+	if _, err := NewHTTPClient(conf, nil, log.Noop(), metrics.Noop()); err == nil {
+		t.Error("Expected error from missing next_url")
+	}
+
+	conf.HTTPClient.Pagination.NextURL = "foo"
+	conf.HTTPClient.Stream.Enabled = true
+	if _, err := NewHTTPClient(conf, nil, log.Noop(), metrics.Noop()); err == nil {
+		t.Error("Expected error from streaming")
+	}
+}
@@ -35,6 +35,7 @@ import (
 	"github.com/Jeffail/benthos/lib/message"
 	"github.com/Jeffail/benthos/lib/message/metadata"
 	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/tracing"
 	"github.com/Jeffail/benthos/lib/types"
 	"github.com/Jeffail/benthos/lib/util/throttle"
 	"github.com/gorilla/websocket"
@@ -58,12 +59,16 @@ This input adds the following metadata fields to each message:
 
 ` + "```" + `
 - http_server_user_agent
-- All headers (only first values are taken)
+- All headers (only first values are taken) matching the metadata rules
 - All cookies
 ` + "```" + `
 
 You can access these metadata fields using
-[function interpolation](../config_interpolation.md#metadata).`,
+[function interpolation](../config_interpolation.md#metadata).
+
+Which headers are added to messages as metadata, and the names they are
+given, can be configured within the ` + "`metadata`" + ` section as described
+[here](../metadata.md).`,
 	}
 }
 
@@ -71,12 +76,13 @@ You can access these metadata fields using
 
 // HTTPServerConfig contains configuration for the HTTPServer input type.
 type HTTPServerConfig struct {
-	Address   string `json:"address" yaml:"address"`
-	Path      string `json:"path" yaml:"path"`
-	WSPath    string `json:"ws_path" yaml:"ws_path"`
-	TimeoutMS int64  `json:"timeout_ms" yaml:"timeout_ms"`
-	CertFile  string `json:"cert_file" yaml:"cert_file"`
-	KeyFile   string `json:"key_file" yaml:"key_file"`
+	Address   string                 `json:"address" yaml:"address"`
+	Path      string                 `json:"path" yaml:"path"`
+	WSPath    string                 `json:"ws_path" yaml:"ws_path"`
+	TimeoutMS int64                  `json:"timeout_ms" yaml:"timeout_ms"`
+	CertFile  string                 `json:"cert_file" yaml:"cert_file"`
+	KeyFile   string                 `json:"key_file" yaml:"key_file"`
+	Metadata  metadata.MappingConfig `json:"metadata" yaml:"metadata"`
 }
 
 // NewHTTPServerConfig creates a new HTTPServerConfig with default values.
@@ -88,6 +94,7 @@ func NewHTTPServerConfig() HTTPServerConfig {
 		TimeoutMS: 5000,
 		CertFile:  "",
 		KeyFile:   "",
+		Metadata:  metadata.NewMappingConfigAll(),
 	}
 }
 
@@ -102,6 +109,7 @@ type HTTPServer struct {
 	running int32
 
 	conf  Config
+	meta  *metadata.Mapping
 	stats metrics.Type
 	log   log.Modular
 
@@ -140,6 +148,7 @@ func NewHTTPServer(conf Config, mgr types.Manager, log log.Modular, stats metric
 	h := HTTPServer{
 		running:      1,
 		conf:         conf,
+		meta:         metadata.NewMapping(conf.HTTPServer.Metadata),
 		stats:        stats,
 		log:          log.NewModule(".input.http"),
 		mux:          mux,
@@ -241,8 +250,8 @@ func (h *HTTPServer) postHandler(w http.ResponseWriter, r *http.Request) {
 	meta := metadata.New(nil)
 	meta.Set("http_server_user_agent", r.UserAgent())
 	for k, v := range r.Header {
-		if len(v) > 0 {
-			meta.Set(k, v[0])
+		if mk, ok := h.meta.Key(k); ok && len(v) > 0 {
+			meta.Set(mk, v[0])
 		}
 	}
 	for _, c := range r.Cookies() {
@@ -250,14 +259,18 @@ func (h *HTTPServer) postHandler(w http.ResponseWriter, r *http.Request) {
 	}
 	message.SetAllMetadata(msg, meta)
 
+	spans := tracing.InitInputSpans("http_server", msg)
+
 	resChan := make(chan types.Response)
 	select {
 	case h.transactions <- types.NewTransaction(msg, resChan):
 	case <-time.After(time.Millisecond * time.Duration(h.conf.HTTPServer.TimeoutMS)):
+		tracing.FinishSpans(spans, types.ErrTimeout)
 		h.mTimeout.Incr(1)
 		http.Error(w, "Request timed out", http.StatusRequestTimeout)
 		return
 	case <-h.closeChan:
+		tracing.FinishSpans(spans, types.ErrTypeClosed)
 		http.Error(w, "Server closing", http.StatusServiceUnavailable)
 		return
 	}
@@ -265,9 +278,12 @@ func (h *HTTPServer) postHandler(w http.ResponseWriter, r *http.Request) {
 	select {
 	case res, open := <-resChan:
 		if !open {
+			tracing.FinishSpans(spans, types.ErrTypeClosed)
 			http.Error(w, "Server closing", http.StatusServiceUnavailable)
 			return
-		} else if res.Error() != nil {
+		}
+		tracing.FinishSpans(spans, res.Error())
+		if res.Error() != nil {
 			h.mErr.Incr(1)
 			h.mErrF.Incr(1)
 			http.Error(w, res.Error().Error(), http.StatusBadGateway)
@@ -281,6 +297,7 @@ func (h *HTTPServer) postHandler(w http.ResponseWriter, r *http.Request) {
 		go func() {
 			// Even if the request times out, we still need to drain a response.
 			resAsync := <-resChan
+			tracing.FinishSpans(spans, resAsync.Error())
 			if resAsync.Error() != nil {
 				h.mAsyncErr.Incr(1)
 				h.mErrF.Incr(1)
@@ -329,14 +346,16 @@ func (h *HTTPServer) wsHandler(w http.ResponseWriter, r *http.Request) {
 		meta := msg.Get(0).Metadata()
 		meta.Set("http_server_user_agent", r.UserAgent())
 		for k, v := range r.Header {
-			if len(v) > 0 {
-				meta.Set(k, v[0])
+			if mk, ok := h.meta.Key(k); ok && len(v) > 0 {
+				meta.Set(mk, v[0])
 			}
 		}
 		for _, c := range r.Cookies() {
 			meta.Set(c.Name, c.Value)
 		}
 
+		spans := tracing.InitInputSpans("http_server", msg)
+
 		select {
 		case h.transactions <- types.NewTransaction(msg, resChan):
 		case <-h.closeChan:
@@ -347,6 +366,7 @@ func (h *HTTPServer) wsHandler(w http.ResponseWriter, r *http.Request) {
 			if !open {
 				return
 			}
+			tracing.FinishSpans(spans, res.Error())
 			if res.Error() != nil {
 				h.mWSErr.Incr(1)
 				h.mErrF.Incr(1)
@@ -43,7 +43,12 @@ feedback loops can lead to deadlocks in your message flow.
 
 It is possible to connect multiple inputs to the same inproc ID, but only one
 output can connect to an inproc ID, and will replace existing outputs if a
-collision occurs.`,
+collision occurs. Whether messages are shared amongst the connected inputs or
+each input receives its own copy is determined by the ` + "`pattern`" + ` field
+of the output.
+
+If the output of an ID is closed the input will continue to attempt to
+reconnect until a new output connects to the ID.`,
 	}
 }
 
@@ -114,7 +119,14 @@ func (i *Inproc) loop() {
 		mCountF      = i.stats.GetCounter("input.count")
 	)
 
+	// Subscribe in order to receive messages from outputs that fan out. The
+	// subscription chan is never closed as outputs may still attempt to send
+	// to it after we unsubscribe.
+	subChan := make(chan types.Transaction)
+	i.mgr.SubscribePipe(i.pipe, subChan)
+
 	defer func() {
+		i.mgr.UnsubscribePipe(i.pipe, subChan)
 		mRunning.Decr(1)
 		mRunningF.Decr(1)
 		close(i.transactions)
@@ -124,44 +136,47 @@ func (i *Inproc) loop() {
 	mRunningF.Incr(1)
 
 	var inprocChan <-chan types.Transaction
+	var retryChan <-chan time.Time
+
+	connect := func() {
+		var err error
+		if inprocChan, err = i.mgr.GetPipe(i.pipe); err != nil {
+			mFailedConn.Incr(1)
+			mFailedConnF.Incr(1)
+			i.log.Errorf("Failed to connect to inproc output '%v': %v\n", i.pipe, err)
+			retryChan = time.After(time.Second)
+			return
+		}
+		i.log.Infof("Receiving inproc messages from ID: %s\n", i.pipe)
+		mConn.Incr(1)
+		mConnF.Incr(1)
+		retryChan = nil
+	}
+	connect()
 
-messageLoop:
 	for atomic.LoadInt32(&i.running) == 1 {
-		if inprocChan == nil {
-			for {
-				var err error
-				if inprocChan, err = i.mgr.GetPipe(i.pipe); err != nil {
-					mFailedConn.Incr(1)
-					mFailedConnF.Incr(1)
-					i.log.Errorf("Failed to connect to inproc output '%v': %v\n", i.pipe, err)
-					select {
-					case <-time.After(time.Second):
-					case <-i.closeChan:
-						return
-					}
-				} else {
-					i.log.Infof("Receiving inproc messages from ID: %s\n", i.pipe)
-					break
-				}
-			}
-			mConn.Incr(1)
-			mConnF.Incr(1)
-		}
+		var t types.Transaction
 		select {
-		case t, open := <-inprocChan:
+		case <-retryChan:
+			connect()
+			continue
+		case tran, open := <-inprocChan:
 			if !open {
 				mLostConn.Incr(1)
 				mLostConnF.Incr(1)
 				inprocChan = nil
-				continue messageLoop
-			}
-			mCount.Incr(1)
-			mCountF.Incr(1)
-			select {
-			case i.transactions <- t:
-			case <-i.closeChan:
-				return
+				connect()
+				continue
 			}
+			t = tran
+		case t = <-subChan:
+		case <-i.closeChan:
+			return
+		}
+		mCount.Incr(1)
+		mCountF.Incr(1)
+		select {
+		case i.transactions <- t:
 		case <-i.closeChan:
 			return
 		}
@@ -26,6 +26,7 @@ import (
 
 	"github.com/Jeffail/benthos/lib/log"
 	"github.com/Jeffail/benthos/lib/manager"
+	"github.com/Jeffail/benthos/lib/message"
 	"github.com/Jeffail/benthos/lib/metrics"
 	"github.com/Jeffail/benthos/lib/types"
 )
@@ -82,4 +83,113 @@ func TestInprocDryRunNoConn(t *testing.T) {
 	}
 }
 
+func TestInprocReconnect(t *testing.T) {
+	t.Parallel()
+
+	mgr, err := manager.New(manager.NewConfig(), nil, log.Noop(), metrics.Noop())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pipeChan := make(chan types.Transaction)
+	mgr.SetPipe("foo", pipeChan)
+
+	conf := NewConfig()
+	conf.Inproc = "foo"
+
+	var ip Type
+	if ip, err = NewInproc(conf, mgr, log.Noop(), metrics.Noop()); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case pipeChan <- types.NewTransaction(message.New([][]byte{[]byte("foo")}), nil):
+	case <-time.After(time.Second):
+		t.Fatal("Timed out")
+	}
+	select {
+	case tran := <-ip.TransactionChan():
+		if act := string(tran.Payload.Get(0).Get()); act != "foo" {
+			t.Errorf("Wrong message: %v", act)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Timed out")
+	}
+
+	// Simulate an output restarting.
+	mgr.UnsetPipe("foo", pipeChan)
+	close(pipeChan)
+
+	pipeChan = make(chan types.Transaction)
+	mgr.SetPipe("foo", pipeChan)
+
+	select {
+	case pipeChan <- types.NewTransaction(message.New([][]byte{[]byte("bar")}), nil):
+	case <-time.After(time.Second * 5):
+		t.Fatal("Timed out")
+	}
+	select {
+	case tran := <-ip.TransactionChan():
+		if act := string(tran.Payload.Get(0).Get()); act != "bar" {
+			t.Errorf("Wrong message: %v", act)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Timed out")
+	}
+
+	ip.CloseAsync()
+	if err = ip.WaitForClose(time.Second); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestInprocSubscription(t *testing.T) {
+	t.Parallel()
+
+	mgr, err := manager.New(manager.NewConfig(), nil, log.Noop(), metrics.Noop())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	conf := NewConfig()
+	conf.Inproc = "foo"
+
+	var ip Type
+	if ip, err = NewInproc(conf, mgr, log.Noop(), metrics.Noop()); err != nil {
+		t.Fatal(err)
+	}
+
+	var subs []chan<- types.Transaction
+	for i := 0; i < 100 && len(subs) == 0; i++ {
+		<-time.After(time.Millisecond * 10)
+		subs = mgr.GetPipeSubscribers("foo")
+	}
+	if len(subs) != 1 {
+		t.Fatalf("Wrong count of subscribers: %v", len(subs))
+	}
+
+	select {
+	case subs[0] <- types.NewTransaction(message.New([][]byte{[]byte("foo")}), nil):
+	case <-time.After(time.Second):
+		t.Fatal("Timed out")
+	}
+	select {
+	case tran := <-ip.TransactionChan():
+		if act := string(tran.Payload.Get(0).Get()); act != "foo" {
+			t.Errorf("Wrong message: %v", act)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Timed out")
+	}
+
+	ip.CloseAsync()
+	if err = ip.WaitForClose(time.Second); err != nil {
+		t.Error(err)
+	}
+
+	if subs = mgr.GetPipeSubscribers("foo"); len(subs) != 0 {
+		t.Errorf("Subscription remains after close: %v", subs)
+	}
+}
+
 //------------------------------------------------------------------------------
@@ -21,10 +21,14 @@
 package input
 
 import (
+	"fmt"
+	"net/http"
+
 	"github.com/Jeffail/benthos/lib/input/reader"
 	"github.com/Jeffail/benthos/lib/log"
 	"github.com/Jeffail/benthos/lib/metrics"
 	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/kafka/sasl"
 	"github.com/Jeffail/benthos/lib/util/tls"
 )
 
@@ -44,8 +48,36 @@ that the server will be backwards compatible. In order to support newer client
 features you should increase this version up to the known version of the target
 server.
 
+### Start Offset
+
+By default the input resumes from the offset last committed for the consumer
+group, and if there isn't one it starts from either the oldest or newest offset
+depending on ` + "`start_from_oldest`" + `. Setting ` + "`start_offset`" + `
+ignores the committed offset when the input first connects and starts from the
+given position instead, which can be ` + "`oldest`" + `, ` + "`newest`" + `, an
+explicit offset (e.g. ` + "`1024`" + `) or an RFC 3339 timestamp (e.g.
+` + "`2019-01-01T00:00:00Z`" + `), in which case consumption starts from the
+earliest message with a timestamp at or after it. Seeking by timestamp requires
+a ` + "`target_version`" + ` of at least 0.10.1.
+
+### Seeking
+
+A running consumer can be moved to a new position by sending a POST request to
+the endpoint ` + "`/kafka/{topic}/{partition}/seek`" + ` with the query
+parameter ` + "`position`" + `, which accepts the same values as
+` + "`start_offset`" + `. For example:
+
+` + "``` sh" + `
+curl -X POST "http://localhost:4195/kafka/foo/0/seek?position=2019-01-01T00:00:00Z"
+` + "```" + `
+
+Messages that have already been read but not yet acknowledged are unaffected,
+and the new offset is committed as messages are acknowledged.
+
 ` + tls.Documentation + `
 
+` + sasl.Documentation + `
+
 ### Metadata
 
 This input adds the following metadata fields to each message:
@@ -60,7 +92,11 @@ This input adds the following metadata fields to each message:
 ` + "```" + `
 
 You can access these metadata fields using
-[function interpolation](../config_interpolation.md#metadata).`,
+[function interpolation](../config_interpolation.md#metadata).
+
+Which headers are added to messages as metadata, and the names they are
+given, can be configured within the ` + "`metadata`" + ` section as described
+[here](../metadata.md).`,
 	}
 }
 
@@ -72,7 +108,33 @@ func NewKafka(conf Config, mgr types.Manager, log log.Modular, stats metrics.Typ
 	if err != nil {
 		return nil, err
 	}
+	mgr.RegisterEndpoint(
+		fmt.Sprintf("/kafka/%v/%v/seek", conf.Kafka.Topic, conf.Kafka.Partition),
+		"Move the consumer of a kafka input to a new position.",
+		kafkaSeekHandler(k),
+	)
 	return NewReader("kafka", reader.NewPreserver(k), log, stats)
 }
 
+func kafkaSeekHandler(k *reader.Kafka) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		position := r.URL.Query().Get("position")
+		if len(position) == 0 {
+			http.Error(w, "Position required", http.StatusBadRequest)
+			return
+		}
+		switch err := k.Seek(position); err {
+		case nil:
+		case types.ErrNotConnected:
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		default:
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		}
+	}
+}
+
 //------------------------------------------------------------------------------
@@ -25,6 +25,7 @@ import (
 	"github.com/Jeffail/benthos/lib/log"
 	"github.com/Jeffail/benthos/lib/metrics"
 	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/kafka/sasl"
 	"github.com/Jeffail/benthos/lib/util/tls"
 )
 
@@ -40,6 +41,8 @@ across any members of the consumer group.
 
 ` + tls.Documentation + `
 
+` + sasl.Documentation + `
+
 ### Metadata
 
 This input adds the following metadata fields to each message:
@@ -54,7 +57,18 @@ This input adds the following metadata fields to each message:
 ` + "```" + `
 
 You can access these metadata fields using
-[function interpolation](../config_interpolation.md#metadata).`,
+[function interpolation](../config_interpolation.md#metadata).
+
+### Metrics
+
+Consumer lag per topic partition is exposed as the gauge
+` + "`input.kafka_balanced.lag`" + `, along with the partitions currently
+assigned to the consumer and a count of rebalance events. Read more about these
+metrics [here](../metrics.md#input).
+
+Which headers are added to messages as metadata, and the names they are
+given, can be configured within the ` + "`metadata`" + ` section as described
+[here](../metadata.md).`,
 	}
 }
 
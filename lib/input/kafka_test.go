@@ -0,0 +1,72 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package input
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/log"
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/gorilla/mux"
+)
+
+func TestKafkaSeekEndpoint(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = TypeKafka
+	conf.Kafka.Addresses = []string{"localhost:1"}
+	conf.Kafka.Topic = "foo"
+	conf.Kafka.Partition = 2
+
+	mgr := &fakeDynamicMgr{router: mux.NewRouter()}
+	k, err := New(conf, mgr, log.Noop(), metrics.Noop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		k.CloseAsync()
+		if err := k.WaitForClose(time.Second * 5); err != nil {
+			t.Error(err)
+		}
+	}()
+
+	tests := []struct {
+		method string
+		url    string
+		code   int
+	}{
+		{method: "GET", url: "/kafka/foo/2/seek?position=oldest", code: http.StatusMethodNotAllowed},
+		{method: "POST", url: "/kafka/foo/2/seek", code: http.StatusBadRequest},
+		{method: "POST", url: "/kafka/foo/2/seek?position=nope", code: http.StatusBadRequest},
+		{method: "POST", url: "/kafka/foo/2/seek?position=oldest", code: http.StatusServiceUnavailable},
+		{method: "POST", url: "/kafka/foo/1/seek?position=oldest", code: http.StatusNotFound},
+	}
+	for _, test := range tests {
+		req := httptest.NewRequest(test.method, test.url, nil)
+		res := httptest.NewRecorder()
+		mgr.router.ServeHTTP(res, req)
+		if exp, act := test.code, res.Code; exp != act {
+			t.Errorf("%v %v: Wrong status code: %v != %v", test.method, test.url, act, exp)
+		}
+	}
+}
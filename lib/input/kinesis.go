@@ -21,10 +21,13 @@
 package input
 
 import (
+	"errors"
+
 	"github.com/Jeffail/benthos/lib/input/reader"
 	"github.com/Jeffail/benthos/lib/log"
 	"github.com/Jeffail/benthos/lib/metrics"
 	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/checkpoint"
 )
 
 //------------------------------------------------------------------------------
@@ -38,7 +41,20 @@ Receive messages from a Kinesis stream.
 It's possible to use DynamoDB for persisting shard iterators by setting the
 table name. Offsets will then be tracked per ` + "`client_id`" + ` per
 ` + "`shard_id`" + `. When using this mode you should create a table with
-` + "`namespace`" + ` as the primary key and ` + "`shard_id`" + ` as a sort key.`,
+` + "`namespace`" + ` as the primary key and ` + "`shard_id`" + ` as a sort key.
+
+Alternatively, shard iterators can be persisted to any
+[cache resource](../caches/README.md) by setting ` + "`checkpoint_cache`" + ` to
+the name of the cache, in which case they are stored under keys of the form
+` + "`<client_id>-<stream>/<shard>`" + `. Only one of ` + "`dynamodb_table`" + `
+and ` + "`checkpoint_cache`" + ` can be set.
+
+### Credentials
+
+By default Benthos will use a shared credentials file when connecting to AWS
+services. It's also possible to set them explicitly at the component level,
+allowing you to transfer data across accounts. You can find out more
+[in this document](../aws.md).`,
 	}
 }
 
@@ -46,11 +62,20 @@ table name. Offsets will then be tracked per ` + "`client_id`" + ` per
 
 // NewKinesis creates a new AWS Kinesis input type.
 func NewKinesis(conf Config, mgr types.Manager, log log.Modular, stats metrics.Type) (Type, error) {
+	rdr := reader.NewKinesis(conf.Kinesis, log, stats)
+	if cacheName := conf.Kinesis.CheckpointCache; len(cacheName) > 0 {
+		if len(conf.Kinesis.DynamoDBTable) > 0 {
+			return nil, errors.New("cannot set both dynamodb_table and checkpoint_cache")
+		}
+		store, err := checkpoint.NewCache(cacheName, rdr.Namespace(), mgr)
+		if err != nil {
+			return nil, err
+		}
+		reader.OptKinesisSetCheckpoints(store)(rdr)
+	}
 	return NewReader(
 		"kinesis",
-		reader.NewPreserver(
-			reader.NewKinesis(conf.Kinesis, log, stats),
-		),
+		reader.NewPreserver(rdr),
 		log, stats,
 	)
 }
@@ -0,0 +1,66 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package input
+
+import (
+	"github.com/Jeffail/benthos/lib/input/reader"
+	"github.com/Jeffail/benthos/lib/log"
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors[TypeMock] = TypeSpec{
+		constructor: NewMock,
+		description: `
+Replays a fixed set of fixture messages and then closes, which shuts down the
+pipeline once they have been delivered. This allows full configs to be
+exercised in CI without any external infrastructure.
+
+Messages are read from the ` + "`messages`" + ` field in order, followed by
+each non-empty line of the file at ` + "`path`" + ` when it is set.
+
+` + "``` yaml" + `
+input:
+  type: mock
+  mock:
+    messages:
+    - '{"id":"foo","value":10}'
+    - '{"id":"bar","value":20}'
+    path: ./tests/fixtures.jsonl
+` + "```" + ``,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// NewMock creates a new Mock input type.
+func NewMock(conf Config, mgr types.Manager, log log.Modular, stats metrics.Type) (Type, error) {
+	m, err := reader.NewMock(conf.Mock)
+	if err != nil {
+		return nil, err
+	}
+	return NewReader("mock", reader.NewPreserver(m), log, stats)
+}
+
+//------------------------------------------------------------------------------
@@ -48,7 +48,10 @@ This input adds the following metadata fields to each message:
 ` + "```" + `
 
 You can access these metadata fields using
-[function interpolation](../config_interpolation.md#metadata).`,
+[function interpolation](../config_interpolation.md#metadata).
+
+Custom TLS settings for ` + "`ssl`" + ` URLs can be set in the ` + "`tls`" + `
+section as described [here](../tls.md).`,
 	}
 }
 
@@ -33,7 +33,10 @@ func init() {
 	Constructors[TypeNSQ] = TypeSpec{
 		constructor: NewNSQ,
 		description: `
-Subscribe to an NSQ instance topic and channel.`,
+Subscribe to an NSQ instance topic and channel.
+
+TLS can be customised in the ` + "`tls`" + ` section as described
+[here](../tls.md).`,
 	}
 }
 
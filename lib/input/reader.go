@@ -27,6 +27,7 @@ import (
 	"github.com/Jeffail/benthos/lib/input/reader"
 	"github.com/Jeffail/benthos/lib/log"
 	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/tracing"
 	"github.com/Jeffail/benthos/lib/types"
 	"github.com/Jeffail/benthos/lib/util/throttle"
 )
@@ -35,7 +36,8 @@ import (
 
 // Reader is an input implementation that reads messages from a reader.Type.
 type Reader struct {
-	running int32
+	running   int32
+	connected int32
 
 	typeStr string
 	reader  reader.Type
@@ -139,6 +141,7 @@ func (r *Reader) loop() {
 	}
 	mConn.Incr(1)
 	mConnF.Incr(1)
+	atomic.StoreInt32(&r.connected, 1)
 
 	for atomic.LoadInt32(&r.running) == 1 {
 		msg, err := r.reader.Read()
@@ -147,6 +150,7 @@ func (r *Reader) loop() {
 		if err == types.ErrNotConnected {
 			mLostConn.Incr(1)
 			mLostConnF.Incr(1)
+			atomic.StoreInt32(&r.connected, 0)
 
 			// Continue to try to reconnect while still active.
 			for atomic.LoadInt32(&r.running) == 1 {
@@ -166,6 +170,7 @@ func (r *Reader) loop() {
 				} else if msg, err = r.reader.Read(); err != types.ErrNotConnected {
 					mConn.Incr(1)
 					mConnF.Incr(1)
+					atomic.StoreInt32(&r.connected, 1)
 					r.connThrot.Reset()
 					break
 				}
@@ -195,6 +200,8 @@ func (r *Reader) loop() {
 			mReadSuccessF.Incr(1)
 		}
 
+		spans := tracing.InitInputSpans(r.typeStr, msg)
+
 		select {
 		case r.transactions <- types.NewTransaction(msg, r.responses):
 		case <-r.closeChan:
@@ -206,6 +213,7 @@ func (r *Reader) loop() {
 			if !open {
 				return
 			}
+			tracing.FinishSpans(spans, res.Error())
 			if res.Error() != nil {
 				mSendError.Incr(1)
 				mSendErrorF.Incr(1)
@@ -245,6 +253,21 @@ func (r *Reader) CloseAsync() {
 	}
 }
 
+// ConnectionStatus returns nil if the Reader is currently connected,
+// types.ErrNotConnected if it is not, and types.ErrTypeClosed once it has
+// closed.
+func (r *Reader) ConnectionStatus() error {
+	select {
+	case <-r.closedChan:
+		return types.ErrTypeClosed
+	default:
+	}
+	if atomic.LoadInt32(&r.connected) == 1 {
+		return nil
+	}
+	return types.ErrNotConnected
+}
+
 // WaitForClose blocks until the Reader input has closed down.
 func (r *Reader) WaitForClose(timeout time.Duration) error {
 	select {
@@ -21,41 +21,42 @@
 package reader
 
 import (
+	"errors"
+	"fmt"
+	"sync"
 	"time"
 
 	"github.com/Jeffail/benthos/lib/log"
 	"github.com/Jeffail/benthos/lib/message"
+	"github.com/Jeffail/benthos/lib/message/metadata"
 	"github.com/Jeffail/benthos/lib/metrics"
 	"github.com/Jeffail/benthos/lib/types"
+	sess "github.com/Jeffail/benthos/lib/util/aws/session"
 	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/credentials"
-	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/aws/aws-sdk-go/service/sqs/sqsiface"
 )
 
 //------------------------------------------------------------------------------
 
 // AmazonSQSConfig contains configuration values for the input type.
 type AmazonSQSConfig struct {
-	Region      string                     `json:"region" yaml:"region"`
-	URL         string                     `json:"url" yaml:"url"`
-	Credentials AmazonAWSCredentialsConfig `json:"credentials" yaml:"credentials"`
-	TimeoutS    int64                      `json:"timeout_s" yaml:"timeout_s"`
+	sess.Config       `json:",inline" yaml:",inline"`
+	URL               string                 `json:"url" yaml:"url"`
+	TimeoutS          int64                  `json:"timeout_s" yaml:"timeout_s"`
+	VisibilityTimeout string                 `json:"visibility_timeout" yaml:"visibility_timeout"`
+	Metadata          metadata.MappingConfig `json:"metadata" yaml:"metadata"`
 }
 
 // NewAmazonSQSConfig creates a new Config with default values.
 func NewAmazonSQSConfig() AmazonSQSConfig {
 	return AmazonSQSConfig{
-		Region: "eu-west-1",
-		URL:    "",
-		Credentials: AmazonAWSCredentialsConfig{
-			ID:     "",
-			Secret: "",
-			Token:  "",
-			Role:   "",
-		},
-		TimeoutS: 5,
+		Config:            sess.NewConfig(),
+		URL:               "",
+		TimeoutS:          5,
+		VisibilityTimeout: "",
+		Metadata:          metadata.NewMappingConfig(),
 	}
 }
 
@@ -64,15 +65,25 @@ func NewAmazonSQSConfig() AmazonSQSConfig {
 // AmazonSQS is a benthos reader.Type implementation that reads messages from an
 // Amazon SQS queue.
 type AmazonSQS struct {
-	conf AmazonSQSConfig
+	conf       AmazonSQSConfig
+	meta       *metadata.Mapping
+	visibility time.Duration
 
+	handlesMut     sync.Mutex
 	pendingHandles []*sqs.DeleteMessageBatchRequestEntry
 
 	session *session.Session
-	sqs     *sqs.SQS
+	sqs     sqsiface.SQSAPI
 
 	log   log.Modular
 	stats metrics.Type
+
+	mExtended    metrics.StatCounter
+	mExtendedErr metrics.StatCounter
+
+	closeOnce  sync.Once
+	closeChan  chan struct{}
+	closedChan chan struct{}
 }
 
 // NewAmazonSQS creates a new Amazon SQS reader.Type.
@@ -80,12 +91,27 @@ func NewAmazonSQS(
 	conf AmazonSQSConfig,
 	log log.Modular,
 	stats metrics.Type,
-) *AmazonSQS {
-	return &AmazonSQS{
-		conf:  conf,
-		log:   log.NewModule(".input.amazon_sqs"),
-		stats: stats,
+) (*AmazonSQS, error) {
+	a := &AmazonSQS{
+		conf:         conf,
+		meta:         metadata.NewMapping(conf.Metadata),
+		log:          log.NewModule(".input.amazon_sqs"),
+		stats:        stats,
+		mExtended:    stats.GetCounter("input.sqs.visibility.extended"),
+		mExtendedErr: stats.GetCounter("input.sqs.visibility.error"),
+		closeChan:    make(chan struct{}),
+		closedChan:   make(chan struct{}),
 	}
+	if len(conf.VisibilityTimeout) > 0 {
+		var err error
+		if a.visibility, err = time.ParseDuration(conf.VisibilityTimeout); err != nil {
+			return nil, fmt.Errorf("failed to parse visibility timeout: %v", err)
+		}
+		if a.visibility < time.Second {
+			return nil, errors.New("visibility timeout must be at least one second")
+		}
+	}
+	return a, nil
 }
 
 // Connect attempts to establish a connection to the target SQS queue.
@@ -94,47 +120,82 @@ func (a *AmazonSQS) Connect() error {
 		return nil
 	}
 
-	awsConf := aws.NewConfig()
-	if len(a.conf.Region) > 0 {
-		awsConf = awsConf.WithRegion(a.conf.Region)
-	}
-	if len(a.conf.Credentials.ID) > 0 {
-		awsConf = awsConf.WithCredentials(credentials.NewStaticCredentials(
-			a.conf.Credentials.ID,
-			a.conf.Credentials.Secret,
-			a.conf.Credentials.Token,
-		))
-	}
-
-	sess, err := session.NewSession(awsConf)
+	sess, err := a.conf.GetSession()
 	if err != nil {
 		return err
 	}
 
-	if len(a.conf.Credentials.Role) > 0 {
-		sess.Config = sess.Config.WithCredentials(
-			stscreds.NewCredentials(sess, a.conf.Credentials.Role),
-		)
-	}
-
 	a.sqs = sqs.New(sess)
 	a.session = sess
 
+	if a.visibility > 0 {
+		go a.extendLoop()
+	} else {
+		close(a.closedChan)
+	}
+
 	a.log.Infof("Receiving Amazon SQS messages from URL: %v\n", a.conf.URL)
 	return nil
 }
 
+// extendLoop periodically extends the visibility timeout of messages that have
+// been read but not yet acknowledged, preventing them from being redelivered
+// whilst they are still in flight.
+func (a *AmazonSQS) extendLoop() {
+	defer close(a.closedChan)
+
+	ticker := time.NewTicker(a.visibility / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-a.closeChan:
+			return
+		}
+
+		a.handlesMut.Lock()
+		handles := make([]*sqs.DeleteMessageBatchRequestEntry, len(a.pendingHandles))
+		copy(handles, a.pendingHandles)
+		a.handlesMut.Unlock()
+
+		for _, h := range handles {
+			if _, err := a.sqs.ChangeMessageVisibility(&sqs.ChangeMessageVisibilityInput{
+				QueueUrl:          aws.String(a.conf.URL),
+				ReceiptHandle:     h.ReceiptHandle,
+				VisibilityTimeout: aws.Int64(int64(a.visibility / time.Second)),
+			}); err != nil {
+				a.mExtendedErr.Incr(1)
+				a.log.Errorf("Failed to extend message visibility: %v\n", err)
+			} else {
+				a.mExtended.Incr(1)
+			}
+		}
+	}
+}
+
 // Read attempts to read a new message from the target SQS.
 func (a *AmazonSQS) Read() (types.Message, error) {
 	if a.session == nil {
 		return nil, types.ErrNotConnected
 	}
 
-	output, err := a.sqs.ReceiveMessage(&sqs.ReceiveMessageInput{
+	input := &sqs.ReceiveMessageInput{
 		QueueUrl:            aws.String(a.conf.URL),
 		MaxNumberOfMessages: aws.Int64(1),
 		WaitTimeSeconds:     aws.Int64(a.conf.TimeoutS),
-	})
+		AttributeNames: []*string{
+			aws.String("ApproximateReceiveCount"),
+			aws.String("SentTimestamp"),
+		},
+	}
+	if a.visibility > 0 {
+		input.VisibilityTimeout = aws.Int64(int64(a.visibility / time.Second))
+	}
+	if a.meta.Enabled() {
+		input.MessageAttributeNames = []*string{aws.String("All")}
+	}
+	output, err := a.sqs.ReceiveMessage(input)
 	if err != nil {
 		return nil, err
 	}
@@ -145,6 +206,7 @@ func (a *AmazonSQS) Read() (types.Message, error) {
 		return nil, types.ErrTimeout
 	}
 
+	a.handlesMut.Lock()
 	for _, sqsMsg := range output.Messages {
 		if sqsMsg.ReceiptHandle != nil {
 			a.pendingHandles = append(a.pendingHandles, &sqs.DeleteMessageBatchRequestEntry{
@@ -154,9 +216,25 @@ func (a *AmazonSQS) Read() (types.Message, error) {
 		}
 
 		if sqsMsg.Body != nil {
-			msg.Append(message.NewPart([]byte(*sqsMsg.Body)))
+			part := message.NewPart([]byte(*sqsMsg.Body))
+			if sqsMsg.MessageId != nil {
+				part.Metadata().Set("sqs_message_id", *sqsMsg.MessageId)
+			}
+			if v := sqsMsg.Attributes["ApproximateReceiveCount"]; v != nil {
+				part.Metadata().Set("sqs_approximate_receive_count", *v)
+			}
+			if v := sqsMsg.Attributes["SentTimestamp"]; v != nil {
+				part.Metadata().Set("sqs_sent_timestamp", *v)
+			}
+			for k, v := range sqsMsg.MessageAttributes {
+				if mk, ok := a.meta.Key(k); ok && v.StringValue != nil {
+					part.Metadata().Set(mk, *v.StringValue)
+				}
+			}
+			msg.Append(part)
 		}
 	}
+	a.handlesMut.Unlock()
 
 	if msg.Len() == 0 {
 		return nil, types.ErrTimeout
@@ -168,6 +246,12 @@ func (a *AmazonSQS) Read() (types.Message, error) {
 // Acknowledge confirms whether or not our unacknowledged messages have been
 // successfully propagated or not.
 func (a *AmazonSQS) Acknowledge(err error) error {
+	a.handlesMut.Lock()
+	defer a.handlesMut.Unlock()
+
+	if len(a.pendingHandles) == 0 {
+		return nil
+	}
 	if _, err := a.sqs.DeleteMessageBatch(&sqs.DeleteMessageBatchInput{
 		QueueUrl: aws.String(a.conf.URL),
 		Entries:  a.pendingHandles,
@@ -181,11 +265,22 @@ func (a *AmazonSQS) Acknowledge(err error) error {
 
 // CloseAsync begins cleaning up resources used by this reader asynchronously.
 func (a *AmazonSQS) CloseAsync() {
+	a.closeOnce.Do(func() {
+		close(a.closeChan)
+	})
 }
 
 // WaitForClose will block until either the reader is closed or a specified
 // timeout occurs.
-func (a *AmazonSQS) WaitForClose(time.Duration) error {
+func (a *AmazonSQS) WaitForClose(timeout time.Duration) error {
+	if a.session == nil {
+		return nil
+	}
+	select {
+	case <-a.closedChan:
+	case <-time.After(timeout):
+		return types.ErrTimeout
+	}
 	return nil
 }
 
@@ -0,0 +1,183 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package reader
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/log"
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/aws/aws-sdk-go/service/sqs/sqsiface"
+)
+
+type mockSQS struct {
+	sqsiface.SQSAPI
+
+	sync.Mutex
+	received  *sqs.ReceiveMessageInput
+	extended  []string
+	deleted   []string
+	toReceive []*sqs.Message
+}
+
+func (m *mockSQS) ReceiveMessage(input *sqs.ReceiveMessageInput) (*sqs.ReceiveMessageOutput, error) {
+	m.Lock()
+	defer m.Unlock()
+	m.received = input
+	msgs := m.toReceive
+	m.toReceive = nil
+	return &sqs.ReceiveMessageOutput{Messages: msgs}, nil
+}
+
+func (m *mockSQS) ChangeMessageVisibility(input *sqs.ChangeMessageVisibilityInput) (*sqs.ChangeMessageVisibilityOutput, error) {
+	m.Lock()
+	defer m.Unlock()
+	m.extended = append(m.extended, *input.ReceiptHandle)
+	return &sqs.ChangeMessageVisibilityOutput{}, nil
+}
+
+func (m *mockSQS) DeleteMessageBatch(input *sqs.DeleteMessageBatchInput) (*sqs.DeleteMessageBatchOutput, error) {
+	m.Lock()
+	defer m.Unlock()
+	for _, e := range input.Entries {
+		m.deleted = append(m.deleted, *e.ReceiptHandle)
+	}
+	return &sqs.DeleteMessageBatchOutput{}, nil
+}
+
+func TestAmazonSQSMetadata(t *testing.T) {
+	conf := NewAmazonSQSConfig()
+	conf.Metadata.IncludePrefixes = []string{"foo"}
+
+	a, err := NewAmazonSQS(conf, log.Noop(), metrics.Noop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	mock := &mockSQS{
+		toReceive: []*sqs.Message{{
+			Body:          aws.String("hello world"),
+			MessageId:     aws.String("id1"),
+			ReceiptHandle: aws.String("handle1"),
+			Attributes: map[string]*string{
+				"ApproximateReceiveCount": aws.String("3"),
+				"SentTimestamp":           aws.String("1546300800000"),
+			},
+			MessageAttributes: map[string]*sqs.MessageAttributeValue{
+				"foo": {StringValue: aws.String("bar")},
+				"baz": {StringValue: aws.String("qux")},
+			},
+		}},
+	}
+	a.session = session.Must(session.NewSession(&aws.Config{}))
+	a.sqs = mock
+
+	msg, err := a.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp, act := "hello world", string(msg.Get(0).Get()); exp != act {
+		t.Errorf("Wrong content: %v != %v", act, exp)
+	}
+	meta := msg.Get(0).Metadata()
+	for k, exp := range map[string]string{
+		"sqs_message_id":                "id1",
+		"sqs_approximate_receive_count": "3",
+		"sqs_sent_timestamp":            "1546300800000",
+		"foo":                           "bar",
+		"baz":                           "",
+	} {
+		if act := meta.Get(k); exp != act {
+			t.Errorf("Wrong metadata value for '%v': %v != %v", k, act, exp)
+		}
+	}
+	if mock.received.VisibilityTimeout != nil {
+		t.Error("Expected default visibility timeout")
+	}
+
+	if err = a.Acknowledge(nil); err != nil {
+		t.Fatal(err)
+	}
+	if exp, act := []string{"handle1"}, mock.deleted; len(act) != 1 || exp[0] != act[0] {
+		t.Errorf("Wrong deleted handles: %v != %v", act, exp)
+	}
+}
+
+func TestAmazonSQSBadVisibility(t *testing.T) {
+	conf := NewAmazonSQSConfig()
+	conf.VisibilityTimeout = "nope"
+	if _, err := NewAmazonSQS(conf, log.Noop(), metrics.Noop()); err == nil {
+		t.Error("Expected error from bad duration")
+	}
+	conf.VisibilityTimeout = "10ms"
+	if _, err := NewAmazonSQS(conf, log.Noop(), metrics.Noop()); err == nil {
+		t.Error("Expected error from short duration")
+	}
+}
+
+func TestAmazonSQSExtendVisibility(t *testing.T) {
+	conf := NewAmazonSQSConfig()
+	conf.VisibilityTimeout = "1s"
+
+	a, err := NewAmazonSQS(conf, log.Noop(), metrics.Noop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	mock := &mockSQS{
+		toReceive: []*sqs.Message{{
+			Body:          aws.String("hello world"),
+			MessageId:     aws.String("id1"),
+			ReceiptHandle: aws.String("handle1"),
+		}},
+	}
+	a.session = session.Must(session.NewSession(&aws.Config{}))
+	a.sqs = mock
+	go a.extendLoop()
+
+	if _, err = a.Read(); err != nil {
+		t.Fatal(err)
+	}
+	if exp, act := int64(1), *mock.received.VisibilityTimeout; exp != act {
+		t.Errorf("Wrong visibility timeout: %v != %v", act, exp)
+	}
+
+	<-time.After(time.Millisecond * 700)
+
+	mock.Lock()
+	extended := mock.extended
+	mock.Unlock()
+	if len(extended) == 0 || extended[0] != "handle1" {
+		t.Errorf("Expected visibility of handle1 to be extended: %v", extended)
+	}
+
+	if err = a.Acknowledge(nil); err != nil {
+		t.Fatal(err)
+	}
+
+	a.CloseAsync()
+	if err = a.WaitForClose(time.Second); err != nil {
+		t.Error(err)
+	}
+}
@@ -30,6 +30,7 @@ import (
 
 	"github.com/Jeffail/benthos/lib/log"
 	"github.com/Jeffail/benthos/lib/message"
+	"github.com/Jeffail/benthos/lib/message/metadata"
 	"github.com/Jeffail/benthos/lib/metrics"
 	"github.com/Jeffail/benthos/lib/types"
 	btls "github.com/Jeffail/benthos/lib/util/tls"
@@ -42,14 +43,17 @@ import (
 // queue needs to be declared and bound to an exchange, as well as any fields
 // specifying how to accomplish that.
 type AMQPQueueDeclareConfig struct {
-	Enabled bool `json:"enabled" yaml:"enabled"`
-	Durable bool `json:"durable" yaml:"durable"`
+	Enabled bool   `json:"enabled" yaml:"enabled"`
+	Durable bool   `json:"durable" yaml:"durable"`
+	Type    string `json:"type" yaml:"type"`
+	Lazy    bool   `json:"lazy" yaml:"lazy"`
 }
 
 // AMQPBindingConfig contains fields describing a queue binding to be declared.
 type AMQPBindingConfig struct {
-	Exchange   string `json:"exchange" yaml:"exchange"`
-	RoutingKey string `json:"key" yaml:"key"`
+	Exchange   string            `json:"exchange" yaml:"exchange"`
+	RoutingKey string            `json:"key" yaml:"key"`
+	Arguments  map[string]string `json:"arguments" yaml:"arguments"`
 }
 
 // AMQPConfig contains configuration for the AMQP input type.
@@ -62,6 +66,7 @@ type AMQPConfig struct {
 	PrefetchCount   int                    `json:"prefetch_count" yaml:"prefetch_count"`
 	PrefetchSize    int                    `json:"prefetch_size" yaml:"prefetch_size"`
 	TLS             btls.Config            `json:"tls" yaml:"tls"`
+	Metadata        metadata.MappingConfig `json:"metadata" yaml:"metadata"`
 }
 
 // NewAMQPConfig creates a new AMQPConfig with default values.
@@ -72,12 +77,15 @@ func NewAMQPConfig() AMQPConfig {
 		QueueDeclare: AMQPQueueDeclareConfig{
 			Enabled: false,
 			Durable: true,
+			Type:    "classic",
+			Lazy:    false,
 		},
 		ConsumerTag:     "benthos-consumer",
 		PrefetchCount:   10,
 		PrefetchSize:    0,
 		TLS:             btls.NewConfig(),
 		BindingsDeclare: []AMQPBindingConfig{},
+		Metadata:        metadata.NewMappingConfigAll(),
 	}
 }
 
@@ -92,7 +100,10 @@ type AMQP struct {
 	ackTag  uint64
 	tlsConf *tls.Config
 
+	queueArgs amqp.Table
+
 	conf  AMQPConfig
+	meta  *metadata.Mapping
 	stats metrics.Type
 	log   log.Modular
 
@@ -103,11 +114,15 @@ type AMQP struct {
 func NewAMQP(conf AMQPConfig, log log.Modular, stats metrics.Type) (Type, error) {
 	a := AMQP{
 		conf:  conf,
+		meta:  metadata.NewMapping(conf.Metadata),
 		stats: stats,
 		log:   log.NewModule(".input.amqp"),
 	}
+	var err error
+	if a.queueArgs, err = amqpQueueArgs(conf.QueueDeclare); err != nil {
+		return nil, err
+	}
 	if conf.TLS.Enabled {
-		var err error
 		if a.tlsConf, err = conf.TLS.Get(); err != nil {
 			return nil, err
 		}
@@ -115,6 +130,44 @@ func NewAMQP(conf AMQPConfig, log log.Modular, stats metrics.Type) (Type, error)
 	return &a, nil
 }
 
+// amqpQueueArgs returns the arguments to declare a queue with.
+func amqpQueueArgs(conf AMQPQueueDeclareConfig) (amqp.Table, error) {
+	args := amqp.Table{}
+	switch conf.Type {
+	case "", "classic":
+	case "quorum":
+		if !conf.Durable {
+			return nil, fmt.Errorf("quorum queues must be durable")
+		}
+		if conf.Lazy {
+			return nil, fmt.Errorf("quorum queues cannot be lazy")
+		}
+		args["x-queue-type"] = "quorum"
+	default:
+		return nil, fmt.Errorf("queue type not recognised: %v", conf.Type)
+	}
+	if conf.Lazy {
+		args["x-queue-mode"] = "lazy"
+	}
+	if len(args) == 0 {
+		return nil, nil
+	}
+	return args, nil
+}
+
+// amqpBindingArgs returns the arguments to bind a queue with, which are used
+// by headers exchanges for matching.
+func amqpBindingArgs(conf AMQPBindingConfig) amqp.Table {
+	if len(conf.Arguments) == 0 {
+		return nil
+	}
+	args := amqp.Table{}
+	for k, v := range conf.Arguments {
+		args[k] = v
+	}
+	return args
+}
+
 //------------------------------------------------------------------------------
 
 // Connect establishes a connection to an AMQP server.
@@ -154,7 +207,7 @@ func (a *AMQP) Connect() (err error) {
 			false,                       // delete when unused
 			false,                       // exclusive
 			false,                       // noWait
-			nil,                         // arguments
+			a.queueArgs,                 // arguments
 		); err != nil {
 			return fmt.Errorf("queue Declare: %s", err)
 		}
@@ -162,11 +215,11 @@ func (a *AMQP) Connect() (err error) {
 
 	for _, bConf := range a.conf.BindingsDeclare {
 		if err = amqpChan.QueueBind(
-			a.conf.Queue,     // name of the queue
-			bConf.RoutingKey, // bindingKey
-			bConf.Exchange,   // sourceExchange
-			false,            // noWait
-			nil,              // arguments
+			a.conf.Queue,           // name of the queue
+			bConf.RoutingKey,       // bindingKey
+			bConf.Exchange,         // sourceExchange
+			false,                  // noWait
+			amqpBindingArgs(bConf), // arguments
 		); err != nil {
 			return fmt.Errorf("queue Bind: %s", err)
 		}
@@ -297,7 +350,9 @@ func (a *AMQP) Read() (types.Message, error) {
 	msg := message.New([][]byte{data.Body})
 
 	for k, v := range data.Headers {
-		setMetadata(msg, k, v)
+		if mk, ok := a.meta.Key(k); ok {
+			setMetadata(msg, mk, v)
+		}
 	}
 
 	setMetadata(msg, "amqp_content_type", data.ContentType)
@@ -23,6 +23,7 @@ package reader
 import (
 	"fmt"
 	"os"
+	"reflect"
 	"sync"
 	"testing"
 	"time"
@@ -34,6 +35,50 @@ import (
 	"github.com/streadway/amqp"
 )
 
+func TestAMQPQueueArgs(t *testing.T) {
+	tests := []struct {
+		conf AMQPQueueDeclareConfig
+		args amqp.Table
+		err  bool
+	}{
+		{conf: AMQPQueueDeclareConfig{Durable: true, Type: "classic"}},
+		{
+			conf: AMQPQueueDeclareConfig{Durable: true, Type: "classic", Lazy: true},
+			args: amqp.Table{"x-queue-mode": "lazy"},
+		},
+		{
+			conf: AMQPQueueDeclareConfig{Durable: true, Type: "quorum"},
+			args: amqp.Table{"x-queue-type": "quorum"},
+		},
+		{conf: AMQPQueueDeclareConfig{Durable: false, Type: "quorum"}, err: true},
+		{conf: AMQPQueueDeclareConfig{Durable: true, Type: "quorum", Lazy: true}, err: true},
+		{conf: AMQPQueueDeclareConfig{Durable: true, Type: "nope"}, err: true},
+	}
+
+	for i, test := range tests {
+		args, err := amqpQueueArgs(test.conf)
+		if test.err {
+			if err == nil {
+				t.Errorf("Expected error from test %v", i)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Unexpected error from test %v: %v", i, err)
+		}
+		if !reflect.DeepEqual(test.args, args) {
+			t.Errorf("Wrong args from test %v: %v != %v", i, args, test.args)
+		}
+	}
+
+	bArgs := amqpBindingArgs(AMQPBindingConfig{
+		Arguments: map[string]string{"x-match": "all"},
+	})
+	if exp, act := (amqp.Table{"x-match": "all"}), bArgs; !reflect.DeepEqual(exp, act) {
+		t.Errorf("Wrong binding args: %v != %v", act, exp)
+	}
+}
+
 func TestAMQPIntegration(t *testing.T) {
 	if testing.Short() {
 		t.Skip("Skipping integration test in short mode")
@@ -28,16 +28,18 @@ import (
 	"github.com/Jeffail/benthos/lib/message"
 	"github.com/Jeffail/benthos/lib/metrics"
 	"github.com/Jeffail/benthos/lib/types"
-	"github.com/colinmarc/hdfs"
+	"github.com/Jeffail/benthos/lib/util/kerberos"
+	"github.com/colinmarc/hdfs/v2"
 )
 
 //------------------------------------------------------------------------------
 
 // HDFSConfig contains configuration fields for the HDFS input type.
 type HDFSConfig struct {
-	Hosts     []string `json:"hosts" yaml:"hosts"`
-	User      string   `json:"user" yaml:"user"`
-	Directory string   `json:"directory" yaml:"directory"`
+	Hosts     []string        `json:"hosts" yaml:"hosts"`
+	User      string          `json:"user" yaml:"user"`
+	Directory string          `json:"directory" yaml:"directory"`
+	Kerberos  kerberos.Config `json:"kerberos" yaml:"kerberos"`
 }
 
 // NewHDFSConfig creates a new Config with default values.
@@ -46,9 +48,18 @@ func NewHDFSConfig() HDFSConfig {
 		Hosts:     []string{"localhost:9000"},
 		User:      "benthos_hdfs",
 		Directory: "",
+		Kerberos:  newHDFSKerberosConfig(),
 	}
 }
 
+// newHDFSKerberosConfig returns Kerberos defaults for HDFS, where the service
+// principal of the namenode is resolved from its host name.
+func newHDFSKerberosConfig() kerberos.Config {
+	conf := kerberos.NewConfig()
+	conf.ServiceName = "nn/_HOST"
+	return conf
+}
+
 //------------------------------------------------------------------------------
 
 // HDFS is a benthos reader.Type implementation that reads messages from a
@@ -85,10 +96,20 @@ func (h *HDFS) Connect() error {
 		return nil
 	}
 
-	client, err := hdfs.NewClient(hdfs.ClientOptions{
+	opts := hdfs.ClientOptions{
 		Addresses: h.conf.Hosts,
 		User:      h.conf.User,
-	})
+	}
+	if len(h.conf.Kerberos.Principal) > 0 {
+		krbClient, err := h.conf.Kerberos.Client()
+		if err != nil {
+			return err
+		}
+		opts.KerberosClient = krbClient
+		opts.KerberosServicePrincipleName = h.conf.Kerberos.ServiceName
+	}
+
+	client, err := hdfs.NewClient(opts)
 	if err != nil {
 		return err
 	}
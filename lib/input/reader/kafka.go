@@ -22,6 +22,7 @@ package reader
 
 import (
 	"crypto/tls"
+	"fmt"
 	"strconv"
 	"strings"
 	"sync"
@@ -29,8 +30,10 @@ import (
 
 	"github.com/Jeffail/benthos/lib/log"
 	"github.com/Jeffail/benthos/lib/message"
+	"github.com/Jeffail/benthos/lib/message/metadata"
 	"github.com/Jeffail/benthos/lib/metrics"
 	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/kafka/sasl"
 	btls "github.com/Jeffail/benthos/lib/util/tls"
 	"github.com/Shopify/sarama"
 )
@@ -39,15 +42,18 @@ import (
 
 // KafkaConfig contains configuration fields for the Kafka input type.
 type KafkaConfig struct {
-	Addresses       []string    `json:"addresses" yaml:"addresses"`
-	ClientID        string      `json:"client_id" yaml:"client_id"`
-	ConsumerGroup   string      `json:"consumer_group" yaml:"consumer_group"`
-	CommitPeriodMS  int         `json:"commit_period_ms" yaml:"commit_period_ms"`
-	Topic           string      `json:"topic" yaml:"topic"`
-	Partition       int32       `json:"partition" yaml:"partition"`
-	StartFromOldest bool        `json:"start_from_oldest" yaml:"start_from_oldest"`
-	TargetVersion   string      `json:"target_version" yaml:"target_version"`
-	TLS             btls.Config `json:"tls" yaml:"tls"`
+	Addresses       []string               `json:"addresses" yaml:"addresses"`
+	ClientID        string                 `json:"client_id" yaml:"client_id"`
+	ConsumerGroup   string                 `json:"consumer_group" yaml:"consumer_group"`
+	CommitPeriodMS  int                    `json:"commit_period_ms" yaml:"commit_period_ms"`
+	Topic           string                 `json:"topic" yaml:"topic"`
+	Partition       int32                  `json:"partition" yaml:"partition"`
+	StartFromOldest bool                   `json:"start_from_oldest" yaml:"start_from_oldest"`
+	StartOffset     string                 `json:"start_offset" yaml:"start_offset"`
+	TargetVersion   string                 `json:"target_version" yaml:"target_version"`
+	TLS             btls.Config            `json:"tls" yaml:"tls"`
+	SASL            sasl.Config            `json:"sasl" yaml:"sasl"`
+	Metadata        metadata.MappingConfig `json:"metadata" yaml:"metadata"`
 }
 
 // NewKafkaConfig creates a new KafkaConfig with default values.
@@ -60,20 +66,60 @@ func NewKafkaConfig() KafkaConfig {
 		Topic:           "benthos_stream",
 		Partition:       0,
 		StartFromOldest: true,
+		StartOffset:     "",
 		TargetVersion:   sarama.V1_0_0_0.String(),
 		TLS:             btls.NewConfig(),
+		SASL:            sasl.NewConfig(),
+		Metadata:        metadata.NewMappingConfigAll(),
 	}
 }
 
 //------------------------------------------------------------------------------
 
+// kafkaOffset is a parsed offset position, which is either an explicit offset,
+// one of the sarama.OffsetOldest or sarama.OffsetNewest constants, or a
+// timestamp.
+type kafkaOffset struct {
+	offset    int64
+	timestamp time.Time
+}
+
+// parseKafkaOffset parses an offset position from a string, which can be
+// `oldest`, `newest`, an explicit offset or an RFC 3339 formatted timestamp.
+func parseKafkaOffset(str string) (kafkaOffset, error) {
+	switch str {
+	case "oldest":
+		return kafkaOffset{offset: sarama.OffsetOldest}, nil
+	case "newest":
+		return kafkaOffset{offset: sarama.OffsetNewest}, nil
+	}
+	if offset, err := strconv.ParseInt(str, 10, 64); err == nil {
+		if offset < 0 {
+			return kafkaOffset{}, fmt.Errorf("offset must not be negative: %v", offset)
+		}
+		return kafkaOffset{offset: offset}, nil
+	}
+	ts, err := time.Parse(time.RFC3339Nano, str)
+	if err != nil {
+		return kafkaOffset{}, fmt.Errorf(
+			"expected oldest, newest, an offset or an RFC 3339 timestamp: %v", str,
+		)
+	}
+	return kafkaOffset{timestamp: ts}, nil
+}
+
+//------------------------------------------------------------------------------
+
 // Kafka is an input type that reads from a Kafka instance.
 type Kafka struct {
 	client       sarama.Client
 	coordinator  *sarama.Broker
+	consumer     sarama.Consumer
 	partConsumer sarama.PartitionConsumer
 	version      sarama.KafkaVersion
 
+	startOffset *kafkaOffset
+
 	tlsConf *tls.Config
 
 	sMut sync.Mutex
@@ -88,6 +134,7 @@ type Kafka struct {
 
 	addresses []string
 	conf      KafkaConfig
+	meta      *metadata.Mapping
 	stats     metrics.Type
 	log       log.Modular
 }
@@ -102,6 +149,7 @@ func NewKafka(
 		stats:   stats,
 		mRcvErr: stats.GetCounter("input.kafka.recv.error"),
 		log:     log.NewModule(".input.kafka"),
+		meta:    metadata.NewMapping(conf.Metadata),
 	}
 
 	if conf.TLS.Enabled {
@@ -116,6 +164,14 @@ func NewKafka(
 		return nil, err
 	}
 
+	if len(conf.StartOffset) > 0 {
+		start, err := parseKafkaOffset(conf.StartOffset)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse start offset: %v", err)
+		}
+		k.startOffset = &start
+	}
+
 	for _, addr := range conf.Addresses {
 		for _, splitAddr := range strings.Split(addr, ",") {
 			if len(splitAddr) > 0 {
@@ -149,6 +205,7 @@ func (k *Kafka) closeClients() {
 			k.partConsumer = nil
 		}()
 	}
+	k.consumer = nil
 	if k.coordinator != nil {
 		k.coordinator.Close()
 		k.coordinator = nil
@@ -186,6 +243,9 @@ func (k *Kafka) Connect() error {
 	if k.conf.TLS.Enabled {
 		config.Net.TLS.Config = k.tlsConf
 	}
+	if err = k.conf.SASL.Apply(config); err != nil {
+		return err
+	}
 
 	k.client, err = sarama.NewClient(k.addresses, config)
 	if err != nil {
@@ -203,14 +263,23 @@ func (k *Kafka) Connect() error {
 		return err
 	}
 
-	offsetReq := sarama.OffsetFetchRequest{}
-	offsetReq.ConsumerGroup = k.conf.ConsumerGroup
-	offsetReq.AddPartition(k.conf.Topic, k.conf.Partition)
-
-	if offsetRes, err := k.coordinator.FetchOffset(&offsetReq); err == nil {
-		offsetBlock := offsetRes.Blocks[k.conf.Topic][k.conf.Partition]
-		if offsetBlock.Err == sarama.ErrNoError {
-			k.offset = offsetBlock.Offset
+	if k.startOffset != nil {
+		// The configured start offset only applies to the first connection,
+		// after which we resume from wherever we got to.
+		if k.offset, err = k.resolveOffset(*k.startOffset); err != nil {
+			return err
+		}
+		k.startOffset = nil
+	} else {
+		offsetReq := sarama.OffsetFetchRequest{}
+		offsetReq.ConsumerGroup = k.conf.ConsumerGroup
+		offsetReq.AddPartition(k.conf.Topic, k.conf.Partition)
+
+		if offsetRes, err := k.coordinator.FetchOffset(&offsetReq); err == nil {
+			offsetBlock := offsetRes.Blocks[k.conf.Topic][k.conf.Partition]
+			if offsetBlock.Err == sarama.ErrNoError {
+				k.offset = offsetBlock.Offset
+			}
 		}
 	}
 
@@ -245,19 +314,101 @@ func (k *Kafka) Connect() error {
 		return err
 	}
 
+	k.consumer = consumer
 	k.partConsumer = partConsumer
 	k.log.Infof("Receiving Kafka messages from addresses: %s\n", k.addresses)
 
-	go func() {
-		for err := range partConsumer.Errors() {
-			if err != nil {
-				k.log.Errorf("Kafka message recv error: %v\n", err)
-				k.mRcvErr.Incr(1)
-			}
+	go k.logErrors(partConsumer)
+	return err
+}
+
+func (k *Kafka) logErrors(partConsumer sarama.PartitionConsumer) {
+	for err := range partConsumer.Errors() {
+		if err != nil {
+			k.log.Errorf("Kafka message recv error: %v\n", err)
+			k.mRcvErr.Incr(1)
 		}
-	}()
+	}
+}
 
-	return err
+// resolveOffset obtains the concrete offset of the consumed partition that
+// corresponds to an offset position. Must be called with sMut held and an
+// active client.
+func (k *Kafka) resolveOffset(o kafkaOffset) (int64, error) {
+	if o.timestamp.IsZero() && o.offset >= 0 {
+		return o.offset, nil
+	}
+	target := o.offset
+	if !o.timestamp.IsZero() {
+		target = o.timestamp.UnixNano() / int64(time.Millisecond)
+	}
+	offset, err := k.client.GetOffset(k.conf.Topic, k.conf.Partition, target)
+	if err != nil {
+		return 0, err
+	}
+	if offset < 0 {
+		// No messages exist at or after the timestamp.
+		return k.client.GetOffset(k.conf.Topic, k.conf.Partition, sarama.OffsetNewest)
+	}
+	return offset, nil
+}
+
+// Seek moves the consumer of an active connection to a new position, which
+// can be `oldest`, `newest`, an explicit offset or an RFC 3339 formatted
+// timestamp, from where the next message read will be consumed. Messages that
+// have been read but not yet acknowledged are not affected.
+func (k *Kafka) Seek(position string) error {
+	o, err := parseKafkaOffset(position)
+	if err != nil {
+		return err
+	}
+
+	k.sMut.Lock()
+	defer k.sMut.Unlock()
+
+	if k.consumer == nil {
+		return types.ErrNotConnected
+	}
+
+	offset, err := k.resolveOffset(o)
+	if err != nil {
+		return err
+	}
+
+	// A partition can only be consumed once at a time and so the current
+	// consumer must be fully closed before creating the next.
+	k.partConsumer.Close()
+
+	partConsumer, err := k.consumer.ConsumePartition(
+		k.conf.Topic, k.conf.Partition, offset,
+	)
+	if err != nil {
+		// Attempt to resume from our previous position.
+		var rErr error
+		if partConsumer, rErr = k.consumer.ConsumePartition(
+			k.conf.Topic, k.conf.Partition, k.offset,
+		); rErr != nil {
+			// Drop the connection so that we reconnect from scratch.
+			k.log.Errorf("Failed to resume consumer after seek: %v\n", rErr)
+			k.coordinator.Close()
+			k.client.Close()
+			k.partConsumer, k.consumer, k.coordinator, k.client = nil, nil, nil, nil
+			return err
+		}
+		k.partConsumer = partConsumer
+		go k.logErrors(partConsumer)
+		return err
+	}
+
+	k.partConsumer = partConsumer
+	k.offset = offset
+	go k.logErrors(partConsumer)
+
+	k.log.Infof(
+		"Seeked topic %s, partition %v to offset %v\n",
+		k.conf.Topic, k.conf.Partition, offset,
+	)
+	return nil
 }
 
 // Read attempts to read a message from a Kafka topic.
@@ -273,6 +424,20 @@ func (k *Kafka) Read() (types.Message, error) {
 	}
 
 	data, open := <-partConsumer.Messages()
+
+	k.sMut.Lock()
+	current := k.partConsumer
+	k.sMut.Unlock()
+
+	if current != partConsumer {
+		// The consumer was replaced by a seek and so this message, if any, is
+		// no longer wanted. If the seek also failed to resume then the
+		// connection was dropped and must be reestablished.
+		if current == nil {
+			return nil, types.ErrNotConnected
+		}
+		return nil, types.ErrTimeout
+	}
 	if !open {
 		return nil, types.ErrTypeClosed
 	}
@@ -286,7 +451,9 @@ func (k *Kafka) Read() (types.Message, error) {
 	meta.Set("kafka_offset", strconv.Itoa(int(data.Offset)))
 	meta.Set("kafka_timestamp_unix", strconv.FormatInt(data.Timestamp.Unix(), 10))
 	for _, hdr := range data.Headers {
-		meta.Set(string(hdr.Key), string(hdr.Value))
+		if mk, ok := k.meta.Key(string(hdr.Key)); ok {
+			meta.Set(mk, string(hdr.Value))
+		}
 	}
 
 	return msg, nil
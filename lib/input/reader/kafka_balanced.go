@@ -29,8 +29,10 @@ import (
 
 	"github.com/Jeffail/benthos/lib/log"
 	"github.com/Jeffail/benthos/lib/message"
+	"github.com/Jeffail/benthos/lib/message/metadata"
 	"github.com/Jeffail/benthos/lib/metrics"
 	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/kafka/sasl"
 	btls "github.com/Jeffail/benthos/lib/util/tls"
 	"github.com/Shopify/sarama"
 	cluster "github.com/bsm/sarama-cluster"
@@ -40,14 +42,16 @@ import (
 
 // KafkaBalancedConfig contains configuration for the KafkaBalanced input type.
 type KafkaBalancedConfig struct {
-	Addresses       []string    `json:"addresses" yaml:"addresses"`
-	ClientID        string      `json:"client_id" yaml:"client_id"`
-	ConsumerGroup   string      `json:"consumer_group" yaml:"consumer_group"`
-	CommitPeriodMS  int         `json:"commit_period_ms" yaml:"commit_period_ms"`
-	Topics          []string    `json:"topics" yaml:"topics"`
-	StartFromOldest bool        `json:"start_from_oldest" yaml:"start_from_oldest"`
-	TargetVersion   string      `json:"target_version" yaml:"target_version"`
-	TLS             btls.Config `json:"tls" yaml:"tls"`
+	Addresses       []string               `json:"addresses" yaml:"addresses"`
+	ClientID        string                 `json:"client_id" yaml:"client_id"`
+	ConsumerGroup   string                 `json:"consumer_group" yaml:"consumer_group"`
+	CommitPeriodMS  int                    `json:"commit_period_ms" yaml:"commit_period_ms"`
+	Topics          []string               `json:"topics" yaml:"topics"`
+	StartFromOldest bool                   `json:"start_from_oldest" yaml:"start_from_oldest"`
+	TargetVersion   string                 `json:"target_version" yaml:"target_version"`
+	TLS             btls.Config            `json:"tls" yaml:"tls"`
+	SASL            sasl.Config            `json:"sasl" yaml:"sasl"`
+	Metadata        metadata.MappingConfig `json:"metadata" yaml:"metadata"`
 }
 
 // NewKafkaBalancedConfig creates a new KafkaBalancedConfig with default values.
@@ -61,11 +65,16 @@ func NewKafkaBalancedConfig() KafkaBalancedConfig {
 		StartFromOldest: true,
 		TargetVersion:   sarama.V1_0_0_0.String(),
 		TLS:             btls.NewConfig(),
+		SASL:            sasl.NewConfig(),
+		Metadata:        metadata.NewMappingConfigAll(),
 	}
 }
 
 //------------------------------------------------------------------------------
 
+// kafkaBalancedLagPeriod is the period between updates of the lag metrics.
+const kafkaBalancedLagPeriod = time.Second * 5
+
 // KafkaBalanced is an input type that reads from a Kafka cluster by balancing
 // partitions across other consumers of the same consumer group.
 type KafkaBalanced struct {
@@ -77,13 +86,18 @@ type KafkaBalanced struct {
 
 	offsetLastCommitted time.Time
 	offsets             map[string]map[int32]int64
+	offsetsMut          sync.Mutex
 
 	mRcvErr     metrics.StatCounter
 	mRebalanced metrics.StatCounter
+	mAssigned   metrics.StatGauge
+	mPartAssign metrics.StatGaugeVec
+	mLag        metrics.StatGaugeVec
 
 	addresses []string
 	topics    []string
 	conf      KafkaBalancedConfig
+	meta      *metadata.Mapping
 	stats     metrics.Type
 	log       log.Modular
 }
@@ -97,8 +111,12 @@ func NewKafkaBalanced(
 		stats:       stats,
 		mRcvErr:     stats.GetCounter("input.kafka_balanced.recv.error"),
 		mRebalanced: stats.GetCounter("input.kafka_balanced.rebalanced"),
+		mAssigned:   stats.GetGauge("input.kafka_balanced.partitions.assigned"),
+		mPartAssign: stats.GetGaugeVec("input.kafka_balanced.partition.assigned", []string{"topic", "partition"}),
+		mLag:        stats.GetGaugeVec("input.kafka_balanced.lag", []string{"topic", "partition"}),
 		offsets:     map[string]map[int32]int64{},
 		log:         log.NewModule(".input.kafka_balanced"),
+		meta:        metadata.NewMapping(conf.Metadata),
 	}
 	if conf.TLS.Enabled {
 		var err error
@@ -170,6 +188,9 @@ func (k *KafkaBalanced) Connect() error {
 	if k.conf.TLS.Enabled {
 		config.Net.TLS.Config = k.tlsConf
 	}
+	if err := k.conf.SASL.Apply(&config.Config); err != nil {
+		return err
+	}
 
 	if k.conf.StartFromOldest {
 		config.Consumer.Offsets.Initial = sarama.OffsetOldest
@@ -188,6 +209,8 @@ func (k *KafkaBalanced) Connect() error {
 	}
 
 	go func() {
+		lagTicker := time.NewTicker(kafkaBalancedLagPeriod)
+		defer lagTicker.Stop()
 		for {
 			select {
 			case err, open := <-consumer.Errors():
@@ -198,11 +221,16 @@ func (k *KafkaBalanced) Connect() error {
 					k.log.Errorf("KafkaBalanced message recv error: %v\n", err)
 					k.mRcvErr.Incr(1)
 				}
-			case _, open := <-consumer.Notifications():
+			case n, open := <-consumer.Notifications():
 				if !open {
 					return
 				}
 				k.mRebalanced.Incr(1)
+				if n != nil && n.Type == cluster.RebalanceOK {
+					k.updateAssignments(n)
+				}
+			case <-lagTicker.C:
+				k.updateLag(consumer.HighWaterMarks())
 			}
 		}
 	}()
@@ -212,7 +240,60 @@ func (k *KafkaBalanced) Connect() error {
 	return nil
 }
 
+// updateAssignments updates the partition assignment metrics following a
+// successful rebalance.
+func (k *KafkaBalanced) updateAssignments(n *cluster.Notification) {
+	total := 0
+	for topic, partitions := range n.Current {
+		total += len(partitions)
+		for _, p := range partitions {
+			k.mPartAssign.With(topic, strconv.Itoa(int(p))).Set(1)
+		}
+	}
+	for topic, partitions := range n.Released {
+		for _, p := range partitions {
+			k.mPartAssign.With(topic, strconv.Itoa(int(p))).Set(0)
+			k.mLag.With(topic, strconv.Itoa(int(p))).Set(0)
+		}
+	}
+	k.mAssigned.Set(int64(total))
+
+	// Offsets of released partitions are no longer ours to track.
+	k.offsetsMut.Lock()
+	for topic, partitions := range n.Released {
+		for _, p := range partitions {
+			delete(k.offsets[topic], p)
+		}
+	}
+	k.offsetsMut.Unlock()
+}
+
+// updateLag sets the lag of each partition that we have consumed from, which
+// is the number of messages between the last message read and the high water
+// mark of the partition.
+func (k *KafkaBalanced) updateLag(highWaterMarks map[string]map[int32]int64) {
+	k.offsetsMut.Lock()
+	defer k.offsetsMut.Unlock()
+
+	for topic, partitions := range k.offsets {
+		for p, offset := range partitions {
+			hwm, exists := highWaterMarks[topic][p]
+			if !exists {
+				continue
+			}
+			lag := hwm - offset - 1
+			if lag < 0 {
+				lag = 0
+			}
+			k.mLag.With(topic, strconv.Itoa(int(p))).Set(lag)
+		}
+	}
+}
+
 func (k *KafkaBalanced) setOffset(topic string, partition int32, offset int64) {
+	k.offsetsMut.Lock()
+	defer k.offsetsMut.Unlock()
+
 	var topicMap map[int32]int64
 	var exists bool
 	if topicMap, exists = k.offsets[topic]; !exists {
@@ -251,7 +332,9 @@ func (k *KafkaBalanced) Read() (types.Message, error) {
 	meta.Set("kafka_offset", strconv.Itoa(int(data.Offset)))
 	meta.Set("kafka_timestamp_unix", strconv.FormatInt(data.Timestamp.Unix(), 10))
 	for _, hdr := range data.Headers {
-		meta.Set(string(hdr.Key), string(hdr.Value))
+		if mk, ok := k.meta.Key(string(hdr.Key)); ok {
+			meta.Set(mk, string(hdr.Value))
+		}
 	}
 
 	k.setOffset(data.Topic, data.Partition, data.Offset)
@@ -263,11 +346,13 @@ func (k *KafkaBalanced) Acknowledge(err error) error {
 	if err == nil {
 		k.cMut.Lock()
 		if k.consumer != nil {
+			k.offsetsMut.Lock()
 			for topic, v := range k.offsets {
 				for part, offset := range v {
 					k.consumer.MarkPartitionOffset(topic, part, offset, "")
 				}
 			}
+			k.offsetsMut.Unlock()
 		}
 		k.cMut.Unlock()
 	}
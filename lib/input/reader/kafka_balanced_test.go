@@ -0,0 +1,81 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package reader
+
+import (
+	"testing"
+
+	"github.com/Jeffail/benthos/lib/log"
+	"github.com/Jeffail/benthos/lib/metrics"
+	cluster "github.com/bsm/sarama-cluster"
+)
+
+func TestKafkaBalancedLagMetrics(t *testing.T) {
+	stats := metrics.NewLocal()
+	k, err := NewKafkaBalanced(NewKafkaBalancedConfig(), log.Noop(), stats)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	k.setOffset("foo", 0, 10)
+	k.updateLag(map[string]map[int32]int64{
+		"foo": {0: 16},
+	})
+	if exp, act := int64(5), stats.GetCounters()["input.kafka_balanced.lag"]; exp != act {
+		t.Errorf("Wrong lag: %v != %v", act, exp)
+	}
+
+	k.setOffset("foo", 0, 15)
+	k.updateLag(map[string]map[int32]int64{
+		"foo": {0: 16},
+	})
+	if exp, act := int64(0), stats.GetCounters()["input.kafka_balanced.lag"]; exp != act {
+		t.Errorf("Wrong lag: %v != %v", act, exp)
+	}
+}
+
+func TestKafkaBalancedAssignmentMetrics(t *testing.T) {
+	stats := metrics.NewLocal()
+	k, err := NewKafkaBalanced(NewKafkaBalancedConfig(), log.Noop(), stats)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	k.setOffset("foo", 0, 10)
+	k.setOffset("foo", 1, 10)
+
+	k.updateAssignments(&cluster.Notification{
+		Type:     cluster.RebalanceOK,
+		Current:  map[string][]int32{"foo": {1, 2}, "bar": {0}},
+		Released: map[string][]int32{"foo": {0}},
+	})
+	if exp, act := int64(3), stats.GetCounters()["input.kafka_balanced.partitions.assigned"]; exp != act {
+		t.Errorf("Wrong assigned count: %v != %v", act, exp)
+	}
+
+	k.offsetsMut.Lock()
+	if _, exists := k.offsets["foo"][0]; exists {
+		t.Error("Expected released partition offset to be removed")
+	}
+	if _, exists := k.offsets["foo"][1]; !exists {
+		t.Error("Expected current partition offset to remain")
+	}
+	k.offsetsMut.Unlock()
+}
@@ -0,0 +1,160 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package reader
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/log"
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Shopify/sarama"
+)
+
+func TestParseKafkaOffset(t *testing.T) {
+	tests := map[string]kafkaOffset{
+		"oldest":               {offset: sarama.OffsetOldest},
+		"newest":               {offset: sarama.OffsetNewest},
+		"0":                    {offset: 0},
+		"1024":                 {offset: 1024},
+		"2019-01-01T00:00:00Z": {timestamp: time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	for input, exp := range tests {
+		act, err := parseKafkaOffset(input)
+		if err != nil {
+			t.Errorf("%v: %v", input, err)
+			continue
+		}
+		if exp.offset != act.offset || !exp.timestamp.Equal(act.timestamp) {
+			t.Errorf("%v: Wrong result: %v != %v", input, act, exp)
+		}
+	}
+
+	for _, input := range []string{"", "nope", "-5", "2019-01-01"} {
+		if _, err := parseKafkaOffset(input); err == nil {
+			t.Errorf("%v: Expected error", input)
+		}
+	}
+}
+
+func TestKafkaStartOffset(t *testing.T) {
+	conf := NewKafkaConfig()
+	conf.StartOffset = "nope"
+	if _, err := NewKafka(conf, log.Noop(), metrics.Noop()); err == nil {
+		t.Error("Expected error from bad start offset")
+	}
+
+	conf.StartOffset = "1024"
+	k, err := NewKafka(conf, log.Noop(), metrics.Noop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if k.startOffset == nil || k.startOffset.offset != 1024 {
+		t.Errorf("Wrong start offset: %v", k.startOffset)
+	}
+}
+
+func TestKafkaSeekNotConnected(t *testing.T) {
+	k, err := NewKafka(NewKafkaConfig(), log.Noop(), metrics.Noop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = k.Seek("nope"); err == nil || err == types.ErrNotConnected {
+		t.Errorf("Expected parse error, received: %v", err)
+	}
+	if exp, act := types.ErrNotConnected, k.Seek("oldest"); exp != act {
+		t.Errorf("Wrong error: %v != %v", act, exp)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type fakeKafkaClient struct {
+	sarama.Client
+}
+
+func (f fakeKafkaClient) Close() error {
+	return nil
+}
+
+type fakeKafkaConsumer struct {
+	sarama.Consumer
+	err error
+}
+
+func (f fakeKafkaConsumer) ConsumePartition(string, int32, int64) (sarama.PartitionConsumer, error) {
+	return nil, f.err
+}
+
+type fakeKafkaPartConsumer struct {
+	sarama.PartitionConsumer
+	msgs chan *sarama.ConsumerMessage
+}
+
+func (f *fakeKafkaPartConsumer) Messages() <-chan *sarama.ConsumerMessage {
+	return f.msgs
+}
+
+func (f *fakeKafkaPartConsumer) Close() error {
+	close(f.msgs)
+	return nil
+}
+
+func TestKafkaSeekResumeFailed(t *testing.T) {
+	k, err := NewKafka(NewKafkaConfig(), log.Noop(), metrics.Noop())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	seekErr := errors.New("test err")
+	k.client = fakeKafkaClient{}
+	k.coordinator = sarama.NewBroker("localhost:9092")
+	k.consumer = fakeKafkaConsumer{err: seekErr}
+	k.partConsumer = &fakeKafkaPartConsumer{
+		msgs: make(chan *sarama.ConsumerMessage),
+	}
+
+	errChan := make(chan error)
+	go func() {
+		_, rErr := k.Read()
+		errChan <- rErr
+	}()
+
+	if exp, act := seekErr, k.Seek("1024"); exp != act {
+		t.Errorf("Wrong error: %v != %v", act, exp)
+	}
+
+	// A failed resume drops the connection, which must result in a reconnect
+	// rather than the input being closed.
+	select {
+	case err = <-errChan:
+		if exp, act := types.ErrNotConnected, err; exp != act {
+			t.Errorf("Wrong error: %v != %v", act, exp)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out")
+	}
+	if _, err = k.Read(); err != types.ErrNotConnected {
+		t.Errorf("Wrong error: %v != %v", err, types.ErrNotConnected)
+	}
+}
@@ -29,9 +29,9 @@ import (
 	"github.com/Jeffail/benthos/lib/message"
 	"github.com/Jeffail/benthos/lib/metrics"
 	"github.com/Jeffail/benthos/lib/types"
+	sess "github.com/Jeffail/benthos/lib/util/aws/session"
+	"github.com/Jeffail/benthos/lib/util/checkpoint"
 	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/credentials"
-	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
 	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
@@ -42,34 +42,27 @@ import (
 
 // KinesisConfig is configuration values for the input type.
 type KinesisConfig struct {
-	Endpoint        string                     `json:"endpoint" yaml:"endpoint"`
-	Region          string                     `json:"region" yaml:"region"`
-	Credentials     AmazonAWSCredentialsConfig `json:"credentials" yaml:"credentials"`
-	Limit           int64                      `json:"limit" yaml:"limit"`
-	Stream          string                     `json:"stream" yaml:"stream"`
-	Shard           string                     `json:"shard" yaml:"shard"`
-	DynamoDBTable   string                     `json:"dynamodb_table" yaml:"dynamodb_table"`
-	ClientID        string                     `json:"client_id" yaml:"client_id"`
-	CommitPeriodMS  int                        `json:"commit_period_ms" yaml:"commit_period_ms"`
-	StartFromOldest bool                       `json:"start_from_oldest" yaml:"start_from_oldest"`
-	TimeoutMS       int64                      `json:"timeout_ms" yaml:"timeout_ms"`
+	sess.Config     `json:",inline" yaml:",inline"`
+	Limit           int64  `json:"limit" yaml:"limit"`
+	Stream          string `json:"stream" yaml:"stream"`
+	Shard           string `json:"shard" yaml:"shard"`
+	DynamoDBTable   string `json:"dynamodb_table" yaml:"dynamodb_table"`
+	CheckpointCache string `json:"checkpoint_cache" yaml:"checkpoint_cache"`
+	ClientID        string `json:"client_id" yaml:"client_id"`
+	CommitPeriodMS  int    `json:"commit_period_ms" yaml:"commit_period_ms"`
+	StartFromOldest bool   `json:"start_from_oldest" yaml:"start_from_oldest"`
+	TimeoutMS       int64  `json:"timeout_ms" yaml:"timeout_ms"`
 }
 
 // NewKinesisConfig creates a new Config with default values.
 func NewKinesisConfig() KinesisConfig {
 	return KinesisConfig{
-		Endpoint: "",
-		Region:   "eu-west-1",
-		Credentials: AmazonAWSCredentialsConfig{
-			ID:     "",
-			Secret: "",
-			Token:  "",
-			Role:   "",
-		},
+		Config:          sess.NewConfig(),
 		Limit:           100,
 		Stream:          "",
 		Shard:           "0",
 		DynamoDBTable:   "",
+		CheckpointCache: "",
 		ClientID:        "benthos_consumer",
 		CommitPeriodMS:  1000,
 		StartFromOldest: true,
@@ -84,9 +77,9 @@ func NewKinesisConfig() KinesisConfig {
 type Kinesis struct {
 	conf KinesisConfig
 
-	session *session.Session
-	kinesis *kinesis.Kinesis
-	dynamo  *dynamodb.DynamoDB
+	session     *session.Session
+	kinesis     *kinesis.Kinesis
+	checkpoints checkpoint.Store
 
 	offsetLastCommitted time.Time
 	sharditerCommit     string
@@ -104,79 +97,127 @@ func NewKinesis(
 	conf KinesisConfig,
 	log log.Modular,
 	stats metrics.Type,
+	opts ...func(*Kinesis),
 ) *Kinesis {
-	return &Kinesis{
+	k := &Kinesis{
 		conf:      conf,
 		log:       log.NewModule(".input.kinesis"),
 		timeout:   time.Duration(conf.TimeoutMS) * time.Millisecond,
 		namespace: fmt.Sprintf("%v-%v", conf.ClientID, conf.Stream),
 		stats:     stats,
 	}
+	for _, opt := range opts {
+		opt(k)
+	}
+	return k
 }
 
-// Connect attempts to establish a connection to the target SQS queue.
-func (k *Kinesis) Connect() error {
-	if k.session != nil {
-		return nil
+// OptKinesisSetCheckpoints sets a checkpoint store to be used for persisting
+// shard iterators instead of a DynamoDB table.
+func OptKinesisSetCheckpoints(store checkpoint.Store) func(*Kinesis) {
+	return func(k *Kinesis) {
+		k.checkpoints = store
 	}
+}
 
-	awsConf := aws.NewConfig()
-	if len(k.conf.Region) > 0 {
-		awsConf = awsConf.WithRegion(k.conf.Region)
+// Namespace returns the namespace that shard iterators of this reader are
+// persisted under.
+func (k *Kinesis) Namespace() string {
+	return k.namespace
+}
+
+//------------------------------------------------------------------------------
+
+// dynamoCheckpoints is a checkpoint.Store that persists shard iterators to a
+// DynamoDB table per namespace per shard.
+type dynamoCheckpoints struct {
+	dynamo    *dynamodb.DynamoDB
+	table     string
+	namespace string
+	timeout   time.Duration
+}
+
+func (d *dynamoCheckpoints) Get(shard string) ([]byte, error) {
+	resp, err := d.dynamo.GetItemWithContext(
+		aws.BackgroundContext(),
+		&dynamodb.GetItemInput{
+			TableName:      aws.String(d.table),
+			ConsistentRead: aws.Bool(true),
+			Key: map[string]*dynamodb.AttributeValue{
+				"namespace": {
+					S: aws.String(d.namespace),
+				},
+				"shard_id": {
+					S: aws.String(shard),
+				},
+			},
+		},
+		request.WithResponseReadTimeout(d.timeout),
+	)
+	if err != nil {
+		if err.Error() == request.ErrCodeResponseTimeout {
+			return nil, types.ErrTimeout
+		}
+		return nil, err
 	}
-	if len(k.conf.Endpoint) > 0 {
-		awsConf = awsConf.WithEndpoint(k.conf.Endpoint)
+	if seqAttr := resp.Item["sequence_number"]; seqAttr != nil {
+		if seqAttr.S != nil {
+			return []byte(*seqAttr.S), nil
+		}
 	}
-	if len(k.conf.Credentials.ID) > 0 {
-		awsConf = awsConf.WithCredentials(credentials.NewStaticCredentials(
-			k.conf.Credentials.ID,
-			k.conf.Credentials.Secret,
-			k.conf.Credentials.Token,
-		))
+	return nil, nil
+}
+
+func (d *dynamoCheckpoints) Set(shard string, value []byte) error {
+	_, err := d.dynamo.PutItemWithContext(
+		aws.BackgroundContext(),
+		&dynamodb.PutItemInput{
+			TableName: aws.String(d.table),
+			Item: map[string]*dynamodb.AttributeValue{
+				"namespace": {
+					S: aws.String(d.namespace),
+				},
+				"shard_id": {
+					S: aws.String(shard),
+				},
+				"sequence_number": {
+					S: aws.String(string(value)),
+				},
+			},
+		},
+		request.WithResponseReadTimeout(d.timeout),
+	)
+	return err
+}
+
+// Connect attempts to establish a connection to the target SQS queue.
+func (k *Kinesis) Connect() error {
+	if k.session != nil {
+		return nil
 	}
 
-	sess, err := session.NewSession(awsConf)
+	sess, err := k.conf.GetSession()
 	if err != nil {
 		return err
 	}
 
-	if len(k.conf.Credentials.Role) > 0 {
-		sess.Config = sess.Config.WithCredentials(
-			stscreds.NewCredentials(sess, k.conf.Credentials.Role),
-		)
-	}
-
-	dynamo := dynamodb.New(sess)
 	kin := kinesis.New(sess)
 
-	if len(k.sharditer) == 0 && len(k.conf.DynamoDBTable) > 0 {
-		resp, err := dynamo.GetItemWithContext(
-			aws.BackgroundContext(),
-			&dynamodb.GetItemInput{
-				TableName:      aws.String(k.conf.DynamoDBTable),
-				ConsistentRead: aws.Bool(true),
-				Key: map[string]*dynamodb.AttributeValue{
-					"namespace": {
-						S: aws.String(k.namespace),
-					},
-					"shard_id": {
-						S: aws.String(k.conf.Shard),
-					},
-				},
-			},
-			request.WithResponseReadTimeout(k.timeout),
-		)
+	if k.checkpoints == nil && len(k.conf.DynamoDBTable) > 0 {
+		k.checkpoints = &dynamoCheckpoints{
+			dynamo:    dynamodb.New(sess),
+			table:     k.conf.DynamoDBTable,
+			namespace: k.namespace,
+			timeout:   k.timeout,
+		}
+	}
+
+	if len(k.sharditer) == 0 && k.checkpoints != nil {
+		iter, err := k.checkpoints.Get(k.conf.Shard)
 		if err != nil {
-			if err.Error() == request.ErrCodeResponseTimeout {
-				return types.ErrTimeout
-			}
 			return err
 		}
-		if seqAttr := resp.Item["sequence_number"]; seqAttr != nil {
-			if seqAttr.S != nil {
-				k.sharditer = *seqAttr.S
-			}
-		}
+		k.sharditer = string(iter)
 	}
 
 	if len(k.sharditer) == 0 {
@@ -214,7 +255,6 @@ func (k *Kinesis) Connect() error {
 	k.sharditerCommit = k.sharditer
 
 	k.kinesis = kin
-	k.dynamo = dynamo
 	k.session = sess
 
 	k.log.Infof("Receiving Amazon Kinesis messages from stream: %v\n", k.conf.Stream)
@@ -270,25 +310,8 @@ func (k *Kinesis) commit() error {
 	if k.session == nil {
 		return nil
 	}
-	if len(k.conf.DynamoDBTable) > 0 {
-		if _, err := k.dynamo.PutItemWithContext(
-			aws.BackgroundContext(),
-			&dynamodb.PutItemInput{
-				TableName: aws.String(k.conf.DynamoDBTable),
-				Item: map[string]*dynamodb.AttributeValue{
-					"namespace": {
-						S: aws.String(k.namespace),
-					},
-					"shard_id": {
-						S: aws.String(k.conf.Shard),
-					},
-					"sequence_number": {
-						S: aws.String(k.sharditerCommit),
-					},
-				},
-			},
-			request.WithResponseReadTimeout(k.timeout),
-		); err != nil {
+	if k.checkpoints != nil {
+		if err := k.checkpoints.Set(k.conf.Shard, []byte(k.sharditerCommit)); err != nil {
 			return err
 		}
 		k.offsetLastCommitted = time.Now()
@@ -0,0 +1,118 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package reader
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/message"
+	"github.com/Jeffail/benthos/lib/types"
+)
+
+//------------------------------------------------------------------------------
+
+// MockConfig contains configuration for the Mock input type.
+type MockConfig struct {
+	Messages []string `json:"messages" yaml:"messages"`
+	Path     string   `json:"path" yaml:"path"`
+}
+
+// NewMockConfig creates a new MockConfig with default values.
+func NewMockConfig() MockConfig {
+	return MockConfig{
+		Messages: []string{},
+		Path:     "",
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// Mock is an input type that replays a fixed set of fixture messages, read from
+// its config and optionally a file, and then closes.
+type Mock struct {
+	messages []string
+}
+
+// NewMock creates a new Mock input type.
+func NewMock(conf MockConfig) (*Mock, error) {
+	m := Mock{}
+	m.messages = append(m.messages, conf.Messages...)
+
+	if len(conf.Path) == 0 {
+		return &m, nil
+	}
+
+	file, err := os.Open(conf.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open fixture file: %v", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(nil, 1024*1024)
+	for scanner.Scan() {
+		if line := scanner.Text(); len(line) > 0 {
+			m.messages = append(m.messages, line)
+		}
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read fixture file: %v", err)
+	}
+	return &m, nil
+}
+
+//------------------------------------------------------------------------------
+
+// Connect establishes a connection.
+func (m *Mock) Connect() error {
+	return nil
+}
+
+// Read the next fixture message.
+func (m *Mock) Read() (types.Message, error) {
+	if len(m.messages) == 0 {
+		return nil, types.ErrTypeClosed
+	}
+
+	msg := message.New([][]byte{[]byte(m.messages[0])})
+	m.messages = m.messages[1:]
+	return msg, nil
+}
+
+// Acknowledge instructs whether unacknowledged messages have been successfully
+// propagated.
+func (m *Mock) Acknowledge(err error) error {
+	return nil
+}
+
+// CloseAsync shuts down the Mock input and stops processing requests.
+func (m *Mock) CloseAsync() {
+}
+
+// WaitForClose blocks until the Mock input has closed down.
+func (m *Mock) WaitForClose(timeout time.Duration) error {
+	return nil
+}
+
+//------------------------------------------------------------------------------
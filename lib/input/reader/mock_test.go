@@ -0,0 +1,82 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package reader
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/Jeffail/benthos/lib/message"
+	"github.com/Jeffail/benthos/lib/types"
+)
+
+func TestMockReader(t *testing.T) {
+	dir, err := ioutil.TempDir("", "benthos_mock_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "fixtures.txt")
+	if err = ioutil.WriteFile(path, []byte("baz\n\nqux\n"), 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	conf := NewMockConfig()
+	conf.Messages = []string{"foo", "bar"}
+	conf.Path = path
+
+	m, err := NewMock(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = m.Connect(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, exp := range []string{"foo", "bar", "baz", "qux"} {
+		msg, err := m.Read()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if act := message.GetAllBytes(msg); !reflect.DeepEqual([][]byte{[]byte(exp)}, act) {
+			t.Errorf("Wrong message: %s != %v", act, exp)
+		}
+		if err = m.Acknowledge(nil); err != nil {
+			t.Error(err)
+		}
+	}
+
+	if _, err = m.Read(); err != types.ErrTypeClosed {
+		t.Errorf("Wrong error: %v != %v", err, types.ErrTypeClosed)
+	}
+}
+
+func TestMockReaderMissingFile(t *testing.T) {
+	conf := NewMockConfig()
+	conf.Path = "/does/not/exist"
+	if _, err := NewMock(conf); err == nil {
+		t.Error("Expected error from missing fixture file")
+	}
+}
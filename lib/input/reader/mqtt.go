@@ -21,6 +21,7 @@
 package reader
 
 import (
+	"crypto/tls"
 	"strconv"
 	"strings"
 	"sync"
@@ -30,6 +31,7 @@ import (
 	"github.com/Jeffail/benthos/lib/message"
 	"github.com/Jeffail/benthos/lib/metrics"
 	"github.com/Jeffail/benthos/lib/types"
+	btls "github.com/Jeffail/benthos/lib/util/tls"
 	mqtt "github.com/eclipse/paho.mqtt.golang"
 )
 
@@ -37,10 +39,11 @@ import (
 
 // MQTTConfig contains configuration fields for the MQTT input type.
 type MQTTConfig struct {
-	URLs     []string `json:"urls" yaml:"urls"`
-	QoS      uint8    `json:"qos" yaml:"qos"`
-	Topics   []string `json:"topics" yaml:"topics"`
-	ClientID string   `json:"client_id" yaml:"client_id"`
+	URLs     []string    `json:"urls" yaml:"urls"`
+	QoS      uint8       `json:"qos" yaml:"qos"`
+	Topics   []string    `json:"topics" yaml:"topics"`
+	ClientID string      `json:"client_id" yaml:"client_id"`
+	TLS      btls.Config `json:"tls" yaml:"tls"`
 }
 
 // NewMQTTConfig creates a new MQTTConfig with default values.
@@ -50,6 +53,7 @@ func NewMQTTConfig() MQTTConfig {
 		QoS:      1,
 		Topics:   []string{"benthos_topic"},
 		ClientID: "benthos_input",
+		TLS:      btls.NewConfig(),
 	}
 }
 
@@ -65,7 +69,8 @@ type MQTT struct {
 	msgChan       chan mqtt.Message
 	interruptChan chan struct{}
 
-	urls []string
+	urls    []string
+	tlsConf *tls.Config
 
 	stats metrics.Type
 	log   log.Modular
@@ -91,6 +96,13 @@ func NewMQTT(
 		}
 	}
 
+	if conf.TLS.Enabled {
+		var err error
+		if m.tlsConf, err = conf.TLS.Get(); err != nil {
+			return nil, err
+		}
+	}
+
 	return m, nil
 }
 
@@ -118,6 +130,10 @@ func (m *MQTT) Connect() error {
 			}
 		})
 
+	if m.tlsConf != nil {
+		conf = conf.SetTLSConfig(m.tlsConf)
+	}
+
 	for _, u := range m.urls {
 		conf = conf.AddBroker(u)
 	}
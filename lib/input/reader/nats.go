@@ -21,6 +21,7 @@
 package reader
 
 import (
+	"crypto/tls"
 	"errors"
 	"strings"
 	"sync"
@@ -30,6 +31,7 @@ import (
 	"github.com/Jeffail/benthos/lib/message"
 	"github.com/Jeffail/benthos/lib/metrics"
 	"github.com/Jeffail/benthos/lib/types"
+	btls "github.com/Jeffail/benthos/lib/util/tls"
 	nats "github.com/nats-io/go-nats"
 )
 
@@ -37,10 +39,11 @@ import (
 
 // NATSConfig contains configuration fields for the NATS input type.
 type NATSConfig struct {
-	URLs          []string `json:"urls" yaml:"urls"`
-	Subject       string   `json:"subject" yaml:"subject"`
-	QueueID       string   `json:"queue" yaml:"queue"`
-	PrefetchCount int      `json:"prefetch_count" yaml:"prefetch_count"`
+	URLs          []string    `json:"urls" yaml:"urls"`
+	Subject       string      `json:"subject" yaml:"subject"`
+	QueueID       string      `json:"queue" yaml:"queue"`
+	PrefetchCount int         `json:"prefetch_count" yaml:"prefetch_count"`
+	TLS           btls.Config `json:"tls" yaml:"tls"`
 }
 
 // NewNATSConfig creates a new NATSConfig with default values.
@@ -50,6 +53,7 @@ func NewNATSConfig() NATSConfig {
 		Subject:       "benthos_messages",
 		QueueID:       "benthos_queue",
 		PrefetchCount: 32,
+		TLS:           btls.NewConfig(),
 	}
 }
 
@@ -57,10 +61,11 @@ func NewNATSConfig() NATSConfig {
 
 // NATS is an input type that receives NATS messages.
 type NATS struct {
-	urls  string
-	conf  NATSConfig
-	stats metrics.Type
-	log   log.Modular
+	urls    string
+	tlsConf *tls.Config
+	conf    NATSConfig
+	stats   metrics.Type
+	log     log.Modular
 
 	cMut sync.Mutex
 
@@ -79,6 +84,12 @@ func NewNATS(conf NATSConfig, log log.Modular, stats metrics.Type) (Type, error)
 		interruptChan: make(chan struct{}),
 	}
 	n.urls = strings.Join(conf.URLs, ",")
+	if conf.TLS.Enabled {
+		var err error
+		if n.tlsConf, err = conf.TLS.Get(); err != nil {
+			return nil, err
+		}
+	}
 	if conf.PrefetchCount < 0 {
 		return nil, errors.New("prefetch count must be greater than or equal to zero")
 	}
@@ -101,7 +112,11 @@ func (n *NATS) Connect() error {
 	var natsSub *nats.Subscription
 	var err error
 
-	if natsConn, err = nats.Connect(n.urls); err != nil {
+	var opts []nats.Option
+	if n.tlsConf != nil {
+		opts = append(opts, nats.Secure(n.tlsConf))
+	}
+	if natsConn, err = nats.Connect(n.urls, opts...); err != nil {
 		return err
 	}
 	natsChan := make(chan *nats.Msg, n.conf.PrefetchCount)
@@ -21,6 +21,7 @@
 package reader
 
 import (
+	"crypto/tls"
 	"strings"
 	"sync"
 	"time"
@@ -29,7 +30,9 @@ import (
 	"github.com/Jeffail/benthos/lib/message"
 	"github.com/Jeffail/benthos/lib/metrics"
 	"github.com/Jeffail/benthos/lib/types"
+	btls "github.com/Jeffail/benthos/lib/util/tls"
 	"github.com/gofrs/uuid"
+	nats "github.com/nats-io/go-nats"
 	"github.com/nats-io/go-nats-streaming"
 )
 
@@ -37,13 +40,14 @@ import (
 
 // NATSStreamConfig contains configuration fields for the NATSStream input type.
 type NATSStreamConfig struct {
-	URLs            []string `json:"urls" yaml:"urls"`
-	ClusterID       string   `json:"cluster_id" yaml:"cluster_id"`
-	ClientID        string   `json:"client_id" yaml:"client_id"`
-	QueueID         string   `json:"queue" yaml:"queue"`
-	DurableName     string   `json:"durable_name" yaml:"durable_name"`
-	StartFromOldest bool     `json:"start_from_oldest" yaml:"start_from_oldest"`
-	Subject         string   `json:"subject" yaml:"subject"`
+	URLs            []string    `json:"urls" yaml:"urls"`
+	ClusterID       string      `json:"cluster_id" yaml:"cluster_id"`
+	ClientID        string      `json:"client_id" yaml:"client_id"`
+	QueueID         string      `json:"queue" yaml:"queue"`
+	DurableName     string      `json:"durable_name" yaml:"durable_name"`
+	StartFromOldest bool        `json:"start_from_oldest" yaml:"start_from_oldest"`
+	Subject         string      `json:"subject" yaml:"subject"`
+	TLS             btls.Config `json:"tls" yaml:"tls"`
 }
 
 // NewNATSStreamConfig creates a new NATSStreamConfig with default values.
@@ -56,6 +60,7 @@ func NewNATSStreamConfig() NATSStreamConfig {
 		DurableName:     "benthos_offset",
 		StartFromOldest: true,
 		Subject:         "benthos_messages",
+		TLS:             btls.NewConfig(),
 	}
 }
 
@@ -63,14 +68,16 @@ func NewNATSStreamConfig() NATSStreamConfig {
 
 // NATSStream is an input type that receives NATSStream messages.
 type NATSStream struct {
-	urls  string
-	conf  NATSStreamConfig
-	stats metrics.Type
-	log   log.Modular
+	urls    string
+	tlsConf *tls.Config
+	conf    NATSStreamConfig
+	stats   metrics.Type
+	log     log.Modular
 
 	unAckMsgs []*stan.Msg
 
 	natsConn stan.Conn
+	tlsConn  *nats.Conn
 	natsSub  stan.Subscription
 	cMut     sync.Mutex
 
@@ -95,6 +102,12 @@ func NewNATSStream(conf NATSStreamConfig, log log.Modular, stats metrics.Type) (
 		interruptChan: make(chan struct{}),
 	}
 	n.urls = strings.Join(conf.URLs, ",")
+	if conf.TLS.Enabled {
+		var err error
+		if n.tlsConf, err = conf.TLS.Get(); err != nil {
+			return nil, err
+		}
+	}
 
 	return &n, nil
 }
@@ -108,9 +121,13 @@ func (n *NATSStream) disconnect() {
 	if n.natsSub != nil {
 		n.natsSub.Unsubscribe()
 		n.natsConn.Close()
+		if n.tlsConn != nil {
+			n.tlsConn.Close()
+		}
 
 		n.natsSub = nil
 		n.natsConn = nil
+		n.tlsConn = nil
 	}
 }
 
@@ -123,12 +140,26 @@ func (n *NATSStream) Connect() error {
 		return nil
 	}
 
+	opts := []stan.Option{stan.NatsURL(n.urls)}
+
+	var tlsConn *nats.Conn
+	if n.tlsConf != nil {
+		var err error
+		if tlsConn, err = nats.Connect(n.urls, nats.Secure(n.tlsConf)); err != nil {
+			return err
+		}
+		opts = append(opts, stan.NatsConn(tlsConn))
+	}
+
 	natsConn, err := stan.Connect(
 		n.conf.ClusterID,
 		n.conf.ClientID,
-		stan.NatsURL(n.urls),
+		opts...,
 	)
 	if err != nil {
+		if tlsConn != nil {
+			tlsConn.Close()
+		}
 		return err
 	}
 
@@ -169,10 +200,14 @@ func (n *NATSStream) Connect() error {
 	}
 	if err != nil {
 		natsConn.Close()
+		if tlsConn != nil {
+			tlsConn.Close()
+		}
 		return err
 	}
 
 	n.natsConn = natsConn
+	n.tlsConn = tlsConn
 	n.natsSub = natsSub
 	n.log.Infof("Receiving NATS Streaming messages from URLs: %s\n", n.urls)
 	return nil
@@ -21,6 +21,7 @@
 package reader
 
 import (
+	"crypto/tls"
 	"io/ioutil"
 	llog "log"
 	"strings"
@@ -31,6 +32,7 @@ import (
 	"github.com/Jeffail/benthos/lib/message"
 	"github.com/Jeffail/benthos/lib/metrics"
 	"github.com/Jeffail/benthos/lib/types"
+	btls "github.com/Jeffail/benthos/lib/util/tls"
 	nsq "github.com/nsqio/go-nsq"
 )
 
@@ -38,12 +40,13 @@ import (
 
 // NSQConfig contains configuration fields for the NSQ input type.
 type NSQConfig struct {
-	Addresses       []string `json:"nsqd_tcp_addresses" yaml:"nsqd_tcp_addresses"`
-	LookupAddresses []string `json:"lookupd_http_addresses" yaml:"lookupd_http_addresses"`
-	Topic           string   `json:"topic" yaml:"topic"`
-	Channel         string   `json:"channel" yaml:"channel"`
-	UserAgent       string   `json:"user_agent" yaml:"user_agent"`
-	MaxInFlight     int      `json:"max_in_flight" yaml:"max_in_flight"`
+	Addresses       []string    `json:"nsqd_tcp_addresses" yaml:"nsqd_tcp_addresses"`
+	LookupAddresses []string    `json:"lookupd_http_addresses" yaml:"lookupd_http_addresses"`
+	Topic           string      `json:"topic" yaml:"topic"`
+	Channel         string      `json:"channel" yaml:"channel"`
+	UserAgent       string      `json:"user_agent" yaml:"user_agent"`
+	MaxInFlight     int         `json:"max_in_flight" yaml:"max_in_flight"`
+	TLS             btls.Config `json:"tls" yaml:"tls"`
 }
 
 // NewNSQConfig creates a new NSQConfig with default values.
@@ -55,6 +58,7 @@ func NewNSQConfig() NSQConfig {
 		Channel:         "benthos_stream",
 		UserAgent:       "benthos_consumer",
 		MaxInFlight:     100,
+		TLS:             btls.NewConfig(),
 	}
 }
 
@@ -69,6 +73,7 @@ type NSQ struct {
 
 	addresses       []string
 	lookupAddresses []string
+	tlsConf         *tls.Config
 	conf            NSQConfig
 	stats           metrics.Type
 	log             log.Modular
@@ -100,6 +105,12 @@ func NewNSQ(conf NSQConfig, log log.Modular, stats metrics.Type) (Type, error) {
 			}
 		}
 	}
+	if conf.TLS.Enabled {
+		var err error
+		if n.tlsConf, err = conf.TLS.Get(); err != nil {
+			return nil, err
+		}
+	}
 
 	return &n, nil
 }
@@ -131,6 +142,10 @@ func (n *NSQ) Connect() (err error) {
 
 	cfg := nsq.NewConfig()
 	cfg.UserAgent = n.conf.UserAgent
+	if n.tlsConf != nil {
+		cfg.TlsV1 = true
+		cfg.TlsConfig = n.tlsConf
+	}
 	cfg.MaxInFlight = n.conf.MaxInFlight
 
 	var consumer *nsq.Consumer
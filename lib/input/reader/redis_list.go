@@ -21,6 +21,7 @@
 package reader
 
 import (
+	"crypto/tls"
 	"net/url"
 	"sync"
 	"time"
@@ -29,6 +30,7 @@ import (
 	"github.com/Jeffail/benthos/lib/message"
 	"github.com/Jeffail/benthos/lib/metrics"
 	"github.com/Jeffail/benthos/lib/types"
+	btls "github.com/Jeffail/benthos/lib/util/tls"
 	"github.com/go-redis/redis"
 )
 
@@ -36,9 +38,10 @@ import (
 
 // RedisListConfig contains configuration fields for the RedisList input type.
 type RedisListConfig struct {
-	URL       string `json:"url" yaml:"url"`
-	Key       string `json:"key" yaml:"key"`
-	TimeoutMS int    `json:"timeout_ms" yaml:"timeout_ms"`
+	URL       string      `json:"url" yaml:"url"`
+	Key       string      `json:"key" yaml:"key"`
+	TimeoutMS int         `json:"timeout_ms" yaml:"timeout_ms"`
+	TLS       btls.Config `json:"tls" yaml:"tls"`
 }
 
 // NewRedisListConfig creates a new RedisListConfig with default values.
@@ -47,6 +50,7 @@ func NewRedisListConfig() RedisListConfig {
 		URL:       "tcp://localhost:6379",
 		Key:       "benthos_list",
 		TimeoutMS: 5000,
+		TLS:       btls.NewConfig(),
 	}
 }
 
@@ -57,8 +61,9 @@ type RedisList struct {
 	client *redis.Client
 	cMut   sync.Mutex
 
-	url  *url.URL
-	conf RedisListConfig
+	url     *url.URL
+	tlsConf *tls.Config
+	conf    RedisListConfig
 
 	stats metrics.Type
 	log   log.Modular
@@ -80,6 +85,12 @@ func NewRedisList(
 		return nil, err
 	}
 
+	if conf.TLS.Enabled {
+		if r.tlsConf, err = conf.TLS.Get(); err != nil {
+			return nil, err
+		}
+	}
+
 	return r, nil
 }
 
@@ -99,9 +110,10 @@ func (r *RedisList) Connect() error {
 		pass, _ = r.url.User.Password()
 	}
 	client := redis.NewClient(&redis.Options{
-		Addr:     r.url.Host,
-		Network:  r.url.Scheme,
-		Password: pass,
+		Addr:      r.url.Host,
+		Network:   r.url.Scheme,
+		Password:  pass,
+		TLSConfig: r.tlsConf,
 	})
 
 	if _, err := client.Ping().Result(); err != nil {
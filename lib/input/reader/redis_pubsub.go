@@ -21,6 +21,7 @@
 package reader
 
 import (
+	"crypto/tls"
 	"net/url"
 	"sync"
 	"time"
@@ -29,6 +30,7 @@ import (
 	"github.com/Jeffail/benthos/lib/message"
 	"github.com/Jeffail/benthos/lib/metrics"
 	"github.com/Jeffail/benthos/lib/types"
+	btls "github.com/Jeffail/benthos/lib/util/tls"
 	"github.com/go-redis/redis"
 )
 
@@ -37,8 +39,9 @@ import (
 // RedisPubSubConfig contains configuration fields for the RedisPubSub input
 // type.
 type RedisPubSubConfig struct {
-	URL      string   `json:"url" yaml:"url"`
-	Channels []string `json:"channels" yaml:"channels"`
+	URL      string      `json:"url" yaml:"url"`
+	Channels []string    `json:"channels" yaml:"channels"`
+	TLS      btls.Config `json:"tls" yaml:"tls"`
 }
 
 // NewRedisPubSubConfig creates a new RedisPubSubConfig with default values.
@@ -46,6 +49,7 @@ func NewRedisPubSubConfig() RedisPubSubConfig {
 	return RedisPubSubConfig{
 		URL:      "tcp://localhost:6379",
 		Channels: []string{"benthos_chan"},
+		TLS:      btls.NewConfig(),
 	}
 }
 
@@ -57,8 +61,9 @@ type RedisPubSub struct {
 	pubsub *redis.PubSub
 	cMut   sync.Mutex
 
-	url  *url.URL
-	conf RedisPubSubConfig
+	url     *url.URL
+	tlsConf *tls.Config
+	conf    RedisPubSubConfig
 
 	stats metrics.Type
 	log   log.Modular
@@ -80,6 +85,12 @@ func NewRedisPubSub(
 		return nil, err
 	}
 
+	if conf.TLS.Enabled {
+		if r.tlsConf, err = conf.TLS.Get(); err != nil {
+			return nil, err
+		}
+	}
+
 	return r, nil
 }
 
@@ -99,9 +110,10 @@ func (r *RedisPubSub) Connect() error {
 		pass, _ = r.url.User.Password()
 	}
 	client := redis.NewClient(&redis.Options{
-		Addr:     r.url.Host,
-		Network:  r.url.Scheme,
-		Password: pass,
+		Addr:      r.url.Host,
+		Network:   r.url.Scheme,
+		Password:  pass,
+		TLSConfig: r.tlsConf,
 	})
 
 	if _, err := client.Ping().Result(); err != nil {
@@ -21,6 +21,7 @@
 package reader
 
 import (
+	"crypto/tls"
 	"fmt"
 	"net/url"
 	"strings"
@@ -31,6 +32,7 @@ import (
 	"github.com/Jeffail/benthos/lib/message"
 	"github.com/Jeffail/benthos/lib/metrics"
 	"github.com/Jeffail/benthos/lib/types"
+	btls "github.com/Jeffail/benthos/lib/util/tls"
 	"github.com/go-redis/redis"
 )
 
@@ -39,15 +41,16 @@ import (
 // RedisStreamsConfig contains configuration fields for the RedisStreams input
 // type.
 type RedisStreamsConfig struct {
-	URL             string   `json:"url" yaml:"url"`
-	BodyKey         string   `json:"body_key" yaml:"body_key"`
-	Streams         []string `json:"streams" yaml:"streams"`
-	ConsumerGroup   string   `json:"consumer_group" yaml:"consumer_group"`
-	ClientID        string   `json:"client_id" yaml:"client_id"`
-	Limit           int64    `json:"limit" yaml:"limit"`
-	StartFromOldest bool     `json:"start_from_oldest" yaml:"start_from_oldest"`
-	CommitPeriodMS  int      `json:"commit_period_ms" yaml:"commit_period_ms"`
-	TimeoutMS       int      `json:"timeout_ms" yaml:"timeout_ms"`
+	URL             string      `json:"url" yaml:"url"`
+	BodyKey         string      `json:"body_key" yaml:"body_key"`
+	Streams         []string    `json:"streams" yaml:"streams"`
+	ConsumerGroup   string      `json:"consumer_group" yaml:"consumer_group"`
+	ClientID        string      `json:"client_id" yaml:"client_id"`
+	Limit           int64       `json:"limit" yaml:"limit"`
+	StartFromOldest bool        `json:"start_from_oldest" yaml:"start_from_oldest"`
+	CommitPeriodMS  int         `json:"commit_period_ms" yaml:"commit_period_ms"`
+	TimeoutMS       int         `json:"timeout_ms" yaml:"timeout_ms"`
+	TLS             btls.Config `json:"tls" yaml:"tls"`
 }
 
 // NewRedisStreamsConfig creates a new RedisStreamsConfig with default values.
@@ -62,6 +65,7 @@ func NewRedisStreamsConfig() RedisStreamsConfig {
 		StartFromOldest: true,
 		CommitPeriodMS:  1000,
 		TimeoutMS:       5000,
+		TLS:             btls.NewConfig(),
 	}
 }
 
@@ -72,8 +76,9 @@ type RedisStreams struct {
 	client *redis.Client
 	cMut   sync.Mutex
 
-	url  *url.URL
-	conf RedisStreamsConfig
+	url     *url.URL
+	tlsConf *tls.Config
+	conf    RedisStreamsConfig
 
 	backlogs map[string]string
 
@@ -109,6 +114,12 @@ func NewRedisStreams(
 		return nil, err
 	}
 
+	if conf.TLS.Enabled {
+		if r.tlsConf, err = conf.TLS.Get(); err != nil {
+			return nil, err
+		}
+	}
+
 	return r, nil
 }
 
@@ -179,9 +190,10 @@ func (r *RedisStreams) Connect() error {
 		pass, _ = r.url.User.Password()
 	}
 	client := redis.NewClient(&redis.Options{
-		Addr:     r.url.Host,
-		Network:  r.url.Scheme,
-		Password: pass,
+		Addr:      r.url.Host,
+		Network:   r.url.Scheme,
+		Password:  pass,
+		TLSConfig: r.tlsConf,
 	})
 
 	if _, err := client.Ping().Result(); err != nil {
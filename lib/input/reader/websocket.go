@@ -21,6 +21,8 @@
 package reader
 
 import (
+	"crypto/tls"
+	"fmt"
 	"net/http"
 	"net/url"
 	"sync"
@@ -31,6 +33,9 @@ import (
 	"github.com/Jeffail/benthos/lib/metrics"
 	"github.com/Jeffail/benthos/lib/types"
 	"github.com/Jeffail/benthos/lib/util/http/auth"
+	"github.com/Jeffail/benthos/lib/util/retries"
+	btls "github.com/Jeffail/benthos/lib/util/tls"
+	"github.com/cenkalti/backoff"
 	"github.com/gorilla/websocket"
 )
 
@@ -38,17 +43,32 @@ import (
 
 // WebsocketConfig contains configuration fields for the Websocket input type.
 type WebsocketConfig struct {
-	URL         string `json:"url" yaml:"url"`
-	OpenMsg     string `json:"open_message" yaml:"open_message"`
-	auth.Config `json:",inline" yaml:",inline"`
+	URL          string            `json:"url" yaml:"url"`
+	OpenMsg      string            `json:"open_message" yaml:"open_message"`
+	OpenMsgs     []string          `json:"open_messages" yaml:"open_messages"`
+	OpenMsgType  string            `json:"open_message_type" yaml:"open_message_type"`
+	Headers      map[string]string `json:"headers" yaml:"headers"`
+	Subprotocols []string          `json:"subprotocols" yaml:"subprotocols"`
+	Reconnect    retries.Config    `json:"reconnect" yaml:"reconnect"`
+	TLS          btls.Config       `json:"tls" yaml:"tls"`
+	auth.Config  `json:",inline" yaml:",inline"`
 }
 
 // NewWebsocketConfig creates a new WebsocketConfig with default values.
 func NewWebsocketConfig() WebsocketConfig {
+	rConf := retries.NewConfig()
+	rConf.Backoff.InitialInterval = "1s"
+	rConf.Backoff.MaxInterval = "30s"
 	return WebsocketConfig{
-		URL:     "ws://localhost:4195/get/ws",
-		OpenMsg: "",
-		Config:  auth.NewConfig(),
+		URL:          "ws://localhost:4195/get/ws",
+		OpenMsg:      "",
+		OpenMsgs:     []string{},
+		OpenMsgType:  "binary",
+		Headers:      map[string]string{},
+		Subprotocols: []string{},
+		Reconnect:    rConf,
+		TLS:          btls.NewConfig(),
+		Config:       auth.NewConfig(),
 	}
 }
 
@@ -61,8 +81,19 @@ type Websocket struct {
 
 	lock *sync.Mutex
 
-	conf   WebsocketConfig
-	client *websocket.Conn
+	conf     WebsocketConfig
+	tlsConf  *tls.Config
+	client   *websocket.Conn
+	openMsgs [][]byte
+	openType int
+
+	boff    backoff.BackOff
+	retryIn time.Duration
+
+	closeChan chan struct{}
+	closeOnce sync.Once
+
+	mReconnectWait metrics.StatTimer
 }
 
 // NewWebsocket creates a new Websocket input type.
@@ -72,10 +103,36 @@ func NewWebsocket(
 	stats metrics.Type,
 ) (*Websocket, error) {
 	ws := &Websocket{
-		log:   log.NewModule(".input.websocket"),
-		stats: stats,
-		lock:  &sync.Mutex{},
-		conf:  conf,
+		log:       log.NewModule(".input.websocket"),
+		stats:     stats,
+		lock:      &sync.Mutex{},
+		conf:      conf,
+		closeChan: make(chan struct{}),
+
+		mReconnectWait: stats.GetTimer("input.websocket.reconnect.wait"),
+	}
+	switch conf.OpenMsgType {
+	case "", "binary":
+		ws.openType = websocket.BinaryMessage
+	case "text":
+		ws.openType = websocket.TextMessage
+	default:
+		return nil, fmt.Errorf("open message type not recognised: %v", conf.OpenMsgType)
+	}
+	if len(conf.OpenMsg) > 0 {
+		ws.openMsgs = append(ws.openMsgs, []byte(conf.OpenMsg))
+	}
+	for _, msg := range conf.OpenMsgs {
+		ws.openMsgs = append(ws.openMsgs, []byte(msg))
+	}
+	var err error
+	if ws.boff, err = conf.Reconnect.Get(); err != nil {
+		return nil, err
+	}
+	if conf.TLS.Enabled {
+		if ws.tlsConf, err = conf.TLS.Get(); err != nil {
+			return nil, err
+		}
 	}
 	return ws, nil
 }
@@ -100,35 +157,66 @@ func (w *Websocket) Connect() error {
 		return nil
 	}
 
+	// Wait before reattempting a connection that previously failed.
+	if w.retryIn > 0 {
+		w.mReconnectWait.Timing(int64(w.retryIn))
+		select {
+		case <-time.After(w.retryIn):
+		case <-w.closeChan:
+			return types.ErrTypeClosed
+		}
+	}
+
+	client, err := w.dial()
+	if err != nil {
+		if w.retryIn = w.boff.NextBackOff(); w.retryIn == backoff.Stop {
+			w.log.Errorf("Giving up reconnecting to websocket: %v\n", err)
+			return types.ErrTypeClosed
+		}
+		return err
+	}
+
+	w.boff.Reset()
+	w.retryIn = 0
+	w.client = client
+	return nil
+}
+
+// dial opens a new websocket connection and writes any configured opening
+// messages to it.
+func (w *Websocket) dial() (*websocket.Conn, error) {
 	headers := http.Header{}
+	for k, v := range w.conf.Headers {
+		headers.Add(k, v)
+	}
 
 	purl, err := url.Parse(w.conf.URL)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	if err = w.conf.Sign(&http.Request{
 		URL:    purl,
 		Header: headers,
 	}); err != nil {
-		return err
+		return nil, err
 	}
 
 	var client *websocket.Conn
-	if client, _, err = websocket.DefaultDialer.Dial(w.conf.URL, headers); err != nil {
-		return err
+	dialer := *websocket.DefaultDialer
+	dialer.TLSClientConfig = w.tlsConf
+	dialer.Subprotocols = w.conf.Subprotocols
+	if client, _, err = dialer.Dial(w.conf.URL, headers); err != nil {
+		return nil, err
 	}
 
-	if len(w.conf.OpenMsg) > 0 {
-		if err = client.WriteMessage(
-			websocket.BinaryMessage, []byte(w.conf.OpenMsg),
-		); err != nil {
-			return err
+	for _, msg := range w.openMsgs {
+		if err = client.WriteMessage(w.openType, msg); err != nil {
+			client.Close()
+			return nil, err
 		}
 	}
-
-	w.client = client
-	return nil
+	return client, nil
 }
 
 //------------------------------------------------------------------------------
@@ -160,6 +248,9 @@ func (w *Websocket) Acknowledge(err error) error {
 
 // CloseAsync shuts down the Websocket input and stops reading messages.
 func (w *Websocket) CloseAsync() {
+	w.closeOnce.Do(func() {
+		close(w.closeChan)
+	})
 	w.lock.Lock()
 	if w.client != nil {
 		w.client.Close()
@@ -164,6 +164,106 @@ func TestWebsocketOpenMsg(t *testing.T) {
 	}
 }
 
+func TestWebsocketHandshake(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if exp, act := "bar", r.Header.Get("foo"); exp != act {
+			t.Errorf("Wrong header: %v != %v", act, exp)
+		}
+
+		upgrader := websocket.Upgrader{
+			Subprotocols: []string{"v2.stream"},
+		}
+
+		var ws *websocket.Conn
+		var err error
+		if ws, err = upgrader.Upgrade(w, r, nil); err != nil {
+			return
+		}
+		defer ws.Close()
+
+		for _, exp := range []string{"first", "second"} {
+			mType, data, err := ws.ReadMessage()
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if mType != websocket.TextMessage {
+				t.Errorf("Wrong message type: %v", mType)
+			}
+			if act := string(data); exp != act {
+				t.Errorf("Wrong open message: %v != %v", act, exp)
+			}
+		}
+		if err = ws.WriteMessage(websocket.TextMessage, []byte(ws.Subprotocol())); err != nil {
+			t.Error(err)
+		}
+	}))
+	defer server.Close()
+
+	conf := NewWebsocketConfig()
+	conf.OpenMsg = "first"
+	conf.OpenMsgs = []string{"second"}
+	conf.OpenMsgType = "text"
+	conf.Headers = map[string]string{"foo": "bar"}
+	conf.Subprotocols = []string{"v1.stream", "v2.stream"}
+	if wsURL, err := url.Parse(server.URL); err != nil {
+		t.Fatal(err)
+	} else {
+		wsURL.Scheme = "ws"
+		conf.URL = wsURL.String()
+	}
+
+	m, err := NewWebsocket(conf, log.Noop(), metrics.Noop())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = m.Connect(); err != nil {
+		t.Fatal(err)
+	}
+
+	var msg types.Message
+	if msg, err = m.Read(); err != nil {
+		t.Fatal(err)
+	}
+	if exp, act := "v2.stream", string(msg.Get(0).Get()); exp != act {
+		t.Errorf("Wrong subprotocol: %v != %v", act, exp)
+	}
+
+	m.CloseAsync()
+	if err = m.WaitForClose(time.Second); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestWebsocketReconnectGiveUp(t *testing.T) {
+	conf := NewWebsocketConfig()
+	conf.URL = "ws://localhost:1/nope"
+	conf.Reconnect.MaxRetries = 1
+	conf.Reconnect.Backoff.InitialInterval = "1ms"
+	conf.Reconnect.Backoff.MaxInterval = "1ms"
+
+	m, err := NewWebsocket(conf, log.Noop(), metrics.Noop())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = m.Connect(); err == nil || err == types.ErrTypeClosed {
+		t.Errorf("Expected connection error, received: %v", err)
+	}
+	if err = m.Connect(); err != types.ErrTypeClosed {
+		t.Errorf("Expected to give up, received: %v", err)
+	}
+}
+
+func TestWebsocketBadOpenType(t *testing.T) {
+	conf := NewWebsocketConfig()
+	conf.OpenMsgType = "nope"
+	if _, err := NewWebsocket(conf, log.Noop(), metrics.Noop()); err == nil {
+		t.Error("Expected error from bad open message type")
+	}
+}
+
 func TestWebsocketClose(t *testing.T) {
 	closeChan := make(chan struct{})
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
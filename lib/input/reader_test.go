@@ -73,6 +73,51 @@ func (r *mockReader) WaitForClose(time.Duration) error {
 
 //------------------------------------------------------------------------------
 
+func waitForConnectionStatus(t *testing.T, c types.Connector, exp error) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for {
+		err := c.ConnectionStatus()
+		if err == exp {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Wrong connection status: %v != %v", err, exp)
+		}
+		<-time.After(time.Millisecond)
+	}
+}
+
+func TestReaderConnectionStatus(t *testing.T) {
+	t.Parallel()
+
+	readerImpl := newMockReader()
+
+	r, err := NewReader(
+		"foo", readerImpl,
+		log.Noop(), metrics.DudType{},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := r.(types.Connector)
+
+	waitForConnectionStatus(t, c, types.ErrNotConnected)
+	readerImpl.connChan <- nil
+	waitForConnectionStatus(t, c, nil)
+	readerImpl.readChan <- types.ErrNotConnected
+	waitForConnectionStatus(t, c, types.ErrNotConnected)
+
+	r.CloseAsync()
+	readerImpl.connChan <- types.ErrTypeClosed
+	if err = r.WaitForClose(time.Second); err != nil {
+		t.Error(err)
+	}
+	waitForConnectionStatus(t, c, types.ErrTypeClosed)
+}
+
+//------------------------------------------------------------------------------
+
 type readerCantConnect struct{}
 
 func (r readerCantConnect) Connect() error { return types.ErrNotConnected }
@@ -33,7 +33,10 @@ func init() {
 	Constructors[TypeRedisList] = TypeSpec{
 		constructor: NewRedisList,
 		description: `
-Pops messages from the beginning of a Redis list using the BLPop command.`,
+Pops messages from the beginning of a Redis list using the BLPop command.
+
+TLS can be customised in the ` + "`tls`" + ` section as described
+[here](../tls.md).`,
 	}
 }
 
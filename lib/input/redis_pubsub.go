@@ -34,7 +34,10 @@ func init() {
 		constructor: NewRedisPubSub,
 		description: `
 Redis supports a publish/subscribe model, it's possible to subscribe to multiple
-channels using this input.`,
+channels using this input.
+
+TLS can be customised in the ` + "`tls`" + ` section as described
+[here](../tls.md).`,
 	}
 }
 
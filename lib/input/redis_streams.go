@@ -42,7 +42,10 @@ can be split into individual messages with the ` + "`split`" + ` processor.
 
 Redis stream entries are key/value pairs, as such it is necessary to specify the
 key that contains the body of the message. All other keys/value pairs are saved
-as metadata fields.`,
+as metadata fields.
+
+TLS can be customised in the ` + "`tls`" + ` section as described
+[here](../tls.md).`,
 	}
 }
 
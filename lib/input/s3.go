@@ -52,6 +52,10 @@ bucket objects:
 
 https://docs.aws.amazon.com/AmazonS3/latest/dev/ways-to-add-notification-config-to-bucket.html
 
+The field ` + "`force_path_style_urls`" + ` addresses objects with path style
+URLs (` + "`endpoint/bucket/key`" + `) rather than virtual hosted URLs, which is
+required by some S3 compatible services such as Minio.
+
 ### Metadata
 
 This input adds the following metadata fields to each message:
@@ -61,7 +65,14 @@ This input adds the following metadata fields to each message:
 ` + "```" + `
 
 You can access these metadata fields using
-[function interpolation](../config_interpolation.md#metadata).`,
+[function interpolation](../config_interpolation.md#metadata).
+
+### Credentials
+
+By default Benthos will use a shared credentials file when connecting to AWS
+services. It's also possible to set them explicitly at the component level,
+allowing you to transfer data across accounts. You can find out more
+[in this document](../aws.md).`,
 	}
 }
 
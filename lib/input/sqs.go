@@ -34,7 +34,41 @@ func init() {
 		constructor: NewAmazonSQS,
 		description: `
 Receive messages from an Amazon SQS URL, only the body is extracted into
-messages.`,
+messages.
+
+Message attributes are added to messages as metadata according to the rules of
+the ` + "`metadata`" + ` section described [here](../metadata.md), which by
+default adds none.
+
+### Metadata
+
+This input adds the following metadata fields to each message:
+
+` + "```" + `
+- sqs_message_id
+- sqs_approximate_receive_count
+- sqs_sent_timestamp
+- All message attributes permitted by the metadata mapping
+` + "```" + `
+
+You can access these metadata fields using
+[function interpolation](../config_interpolation.md#metadata).
+
+### Visibility Timeout
+
+When ` + "`visibility_timeout`" + ` is set to a duration string (e.g. ` + "`30s`" + `)
+messages are received with that visibility timeout, and the timeout of each
+message that has been read but not yet acknowledged is extended periodically
+until the message has been delivered. This prevents slow outputs from causing
+the same message to be redelivered whilst it is still in flight. The timeout
+must be at least one second and is left to the queue default when empty.
+
+### Credentials
+
+By default Benthos will use a shared credentials file when connecting to AWS
+services. It's also possible to set them explicitly at the component level,
+allowing you to transfer data across accounts. You can find out more
+[in this document](../aws.md).`,
 	}
 }
 
@@ -42,11 +76,13 @@ messages.`,
 
 // NewAmazonSQS creates a new AWS SQS input type.
 func NewAmazonSQS(conf Config, mgr types.Manager, log log.Modular, stats metrics.Type) (Type, error) {
+	rdr, err := reader.NewAmazonSQS(conf.SQS, log, stats)
+	if err != nil {
+		return nil, err
+	}
 	return NewReader(
 		"sqs",
-		reader.NewPreserver(
-			reader.NewAmazonSQS(conf.SQS, log, stats),
-		),
+		reader.NewPreserver(rdr),
 		log, stats,
 	)
 }
@@ -33,7 +33,21 @@ func init() {
 	Constructors[TypeWebsocket] = TypeSpec{
 		constructor: NewWebsocket,
 		description: `
-Sends messages to an HTTP server via a websocket connection.`,
+Connects to a websocket server and continuously receives messages.
+
+It is possible to configure a list of ` + "`open_messages`" + `, which are sent
+in order each time a connection is established and can be used in order to
+subscribe to streams. These are sent as binary frames unless
+` + "`open_message_type`" + ` is set to ` + "`text`" + `. Custom headers and a list
+of ` + "`subprotocols`" + ` to request can also be set for the handshake.
+
+When the connection is lost the input reconnects automatically, and failed
+reconnection attempts are retried with an exponential backoff configured within
+the ` + "`reconnect`" + ` section. If ` + "`reconnect.max_retries`" + ` is
+non-zero then the input gives up and shuts down once it is exceeded.
+
+Custom TLS settings for ` + "`wss`" + ` URLs can be set in the ` + "`tls`" + `
+section as described [here](../tls.md).`,
 	}
 }
 
@@ -25,6 +25,7 @@ package log
 // Modular is a log printer that allows you to branch new modules.
 type Modular interface {
 	NewModule(prefix string) Modular
+	WithFields(fields map[string]string) Modular
 
 	Fatalf(format string, v ...interface{})
 	Errorf(format string, v ...interface{})
@@ -0,0 +1,132 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package log
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+//------------------------------------------------------------------------------
+
+// Levelled is implemented by loggers that support changing log levels at
+// runtime.
+type Levelled interface {
+	// SetLevel sets the log level of a namespace, which is a component path
+	// such as input.kafka that applies to the component and all of its
+	// children. An empty namespace sets the root level, and an empty level
+	// removes the level of a namespace so that it inherits from its parent.
+	SetLevel(namespace, level string) error
+
+	// Levels returns the root log level and the levels of any namespaces.
+	Levels() (root string, namespaces map[string]string)
+}
+
+//------------------------------------------------------------------------------
+
+type levelSnapshot struct {
+	root       int
+	namespaces map[string]int
+}
+
+// levelState holds the log levels shared by a logger and all of its modules.
+// Levels are read on every log call and therefore are stored as an immutable
+// snapshot that is replaced on each change.
+type levelState struct {
+	mut      sync.Mutex
+	snapshot atomic.Value
+}
+
+func newLevelState(root int) *levelState {
+	s := &levelState{}
+	s.snapshot.Store(levelSnapshot{root: root})
+	return s
+}
+
+// levelFor returns the level of the most specific namespace that matches a
+// component path, or the root level if none match.
+func (s *levelState) levelFor(component string) int {
+	snap := s.snapshot.Load().(levelSnapshot)
+	if len(snap.namespaces) == 0 {
+		return snap.root
+	}
+	level, matched := snap.root, -1
+	for ns, l := range snap.namespaces {
+		if len(ns) > matched && (component == ns || strings.HasPrefix(component, ns+".")) {
+			level, matched = l, len(ns)
+		}
+	}
+	return level
+}
+
+//------------------------------------------------------------------------------
+
+// SetLevel sets the log level of a namespace, or the root level if the
+// namespace is empty. An empty level removes the level of a namespace.
+func (l *Logger) SetLevel(namespace, level string) error {
+	namespace = strings.Trim(namespace, ".")
+
+	var levelInt int
+	if len(level) > 0 || len(namespace) == 0 {
+		if levelInt = logLevelToInt(level); levelInt < 0 {
+			return fmt.Errorf("log level not recognised: %v", level)
+		}
+	}
+
+	s := l.levels
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	prev := s.snapshot.Load().(levelSnapshot)
+	if len(namespace) == 0 {
+		s.snapshot.Store(levelSnapshot{root: levelInt, namespaces: prev.namespaces})
+		return nil
+	}
+
+	namespaces := make(map[string]int, len(prev.namespaces)+1)
+	for k, v := range prev.namespaces {
+		namespaces[k] = v
+	}
+	if len(level) == 0 {
+		if _, exists := namespaces[namespace]; !exists {
+			return errors.New("namespace does not have a log level")
+		}
+		delete(namespaces, namespace)
+	} else {
+		namespaces[namespace] = levelInt
+	}
+	s.snapshot.Store(levelSnapshot{root: prev.root, namespaces: namespaces})
+	return nil
+}
+
+// Levels returns the root log level and the levels of any namespaces.
+func (l *Logger) Levels() (string, map[string]string) {
+	snap := l.levels.snapshot.Load().(levelSnapshot)
+	namespaces := make(map[string]string, len(snap.namespaces))
+	for k, v := range snap.namespaces {
+		namespaces[k] = intToLogLevel(v)
+	}
+	return intToLogLevel(snap.root), namespaces
+}
+
+//------------------------------------------------------------------------------
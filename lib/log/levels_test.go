@@ -0,0 +1,100 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package log
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLevelsPerNamespace(t *testing.T) {
+	loggerConfig := NewConfig()
+	loggerConfig.AddTimeStamp = false
+	loggerConfig.JSONFormat = false
+	loggerConfig.Prefix = "root"
+	loggerConfig.LogLevel = "WARN"
+
+	buf := LogBuffer{data: ""}
+
+	root := New(&buf, loggerConfig)
+	foo := root.NewModule(".foo")
+	fooBar := foo.NewModule(".bar")
+	fooBaz := foo.NewModule(".baz")
+	food := root.NewModule(".food")
+
+	logAll := func() {
+		for _, l := range []Modular{root, foo, fooBar, fooBaz, food} {
+			l.Infoln("info")
+		}
+	}
+
+	levelled := root.(Levelled)
+	if err := levelled.SetLevel("foo", "info"); err != nil {
+		t.Fatal(err)
+	}
+	if err := levelled.SetLevel(".foo.baz.", "error"); err != nil {
+		t.Fatal(err)
+	}
+	logAll()
+
+	expected := "INFO | root.foo | info\n" +
+		"INFO | root.foo.bar | info\n"
+	if expected != buf.data {
+		t.Errorf("%v != %v", expected, buf.data)
+	}
+
+	rootLevel, namespaces := levelled.Levels()
+	if exp, act := "WARN", rootLevel; exp != act {
+		t.Errorf("Wrong root level: %v != %v", act, exp)
+	}
+	if exp, act := map[string]string{"foo": "INFO", "foo.baz": "ERROR"}, namespaces; !reflect.DeepEqual(exp, act) {
+		t.Errorf("Wrong namespace levels: %v != %v", act, exp)
+	}
+
+	buf.data = ""
+	if err := fooBar.(Levelled).SetLevel("foo", ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := levelled.SetLevel("", "INFO"); err != nil {
+		t.Fatal(err)
+	}
+	logAll()
+
+	expected = "INFO | root | info\n" +
+		"INFO | root.foo | info\n" +
+		"INFO | root.foo.bar | info\n" +
+		"INFO | root.food | info\n"
+	if expected != buf.data {
+		t.Errorf("%v != %v", expected, buf.data)
+	}
+}
+
+func TestLevelsErrors(t *testing.T) {
+	levelled := Noop().(Levelled)
+	if err := levelled.SetLevel("", "nope"); err == nil {
+		t.Error("Expected error from bad level")
+	}
+	if err := levelled.SetLevel("", ""); err == nil {
+		t.Error("Expected error from empty root level")
+	}
+	if err := levelled.SetLevel("foo", ""); err == nil {
+		t.Error("Expected error from removing missing namespace")
+	}
+}
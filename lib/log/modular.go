@@ -21,12 +21,16 @@
 package log
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
+	"unicode"
 )
 
 //------------------------------------------------------------------------------
@@ -92,12 +96,23 @@ func logLevelToInt(level string) int {
 
 //------------------------------------------------------------------------------
 
+// Log format constants.
+const (
+	FormatJSON    = "json"
+	FormatLogfmt  = "logfmt"
+	FormatClassic = "classic"
+)
+
 // Config holds configuration options for a logger object.
 type Config struct {
-	Prefix       string `json:"prefix" yaml:"prefix"`
-	LogLevel     string `json:"level" yaml:"level"`
-	AddTimeStamp bool   `json:"add_timestamp" yaml:"add_timestamp"`
-	JSONFormat   bool   `json:"json_format" yaml:"json_format"`
+	Prefix       string            `json:"prefix" yaml:"prefix"`
+	LogLevel     string            `json:"level" yaml:"level"`
+	Format       string            `json:"format" yaml:"format"`
+	AddTimeStamp bool              `json:"add_timestamp" yaml:"add_timestamp"`
+	JSONFormat   bool              `json:"json_format" yaml:"json_format"`
+	StaticFields map[string]string `json:"static_fields" yaml:"static_fields"`
+	File         FileConfig        `json:"file" yaml:"file"`
+	Sampling     SamplingConfig    `json:"sampling" yaml:"sampling"`
 }
 
 // NewConfig returns a config struct with the default values for each field.
@@ -105,152 +120,280 @@ func NewConfig() Config {
 	return Config{
 		Prefix:       "benthos",
 		LogLevel:     "INFO",
+		Format:       FormatJSON,
 		AddTimeStamp: true,
 		JSONFormat:   true,
+		StaticFields: map[string]string{},
+		File:         NewFileConfig(),
+		Sampling:     NewSamplingConfig(),
 	}
 }
 
+// format returns the format of log lines, where the deprecated field
+// json_format set to false results in the classic format when the format is
+// otherwise json.
+func (c Config) format() string {
+	if c.Format == FormatJSON && !c.JSONFormat {
+		return FormatClassic
+	}
+	if len(c.Format) == 0 {
+		if c.JSONFormat {
+			return FormatJSON
+		}
+		return FormatClassic
+	}
+	return c.Format
+}
+
 //------------------------------------------------------------------------------
 
 // Logger is an object with support for levelled logging and modular components.
 type Logger struct {
 	stream io.Writer
 	config Config
-	level  int
+	levels *levelState
+	format string
+
+	// sampler limits repeated warning and error logs, and is nil when
+	// sampling is disabled.
+	sampler *sampler
+
+	// service is the root prefix of the logger and component is the path of
+	// modules appended to it.
+	service   string
+	component string
+
+	// fields contains both static fields and those added with WithFields,
+	// sorted by key.
+	fields []field
+}
+
+type field struct {
+	key   string
+	value string
 }
 
 // New creates and returns a new logger object.
 func New(stream io.Writer, config Config) Modular {
 	logger := Logger{
-		stream: stream,
-		config: config,
-		level:  logLevelToInt(config.LogLevel),
+		stream:  stream,
+		config:  config,
+		levels:  newLevelState(logLevelToInt(config.LogLevel)),
+		format:  config.format(),
+		service: config.Prefix,
 	}
-	return &logger
+	var samplerErr error
+	if config.Sampling.Enabled {
+		logger.sampler, samplerErr = newSampler(config.Sampling)
+	}
+	newLogger := logger.withFields(config.StaticFields)
+	if samplerErr != nil {
+		newLogger.Errorf("Log sampling is disabled due to invalid config: %v\n", samplerErr)
+	}
+	return newLogger
 }
 
 // Noop creates and returns a new logger object that writes nothing.
 func Noop() Modular {
 	return &Logger{
-		stream: ioutil.Discard,
-		config: NewConfig(),
-		level:  LogOff,
+		stream:  ioutil.Discard,
+		config:  NewConfig(),
+		levels:  newLevelState(LogOff),
+		format:  FormatJSON,
+		service: "benthos",
 	}
 }
 
 // NewModule creates a new logger object from the previous, using the same
 // configuration, but adds an extra prefix to represent a submodule.
 func (l *Logger) NewModule(prefix string) Modular {
-	config := l.config
-	config.Prefix = fmt.Sprintf("%v%v", config.Prefix, prefix)
+	newLogger := *l
+	newLogger.config.Prefix = fmt.Sprintf("%v%v", l.config.Prefix, prefix)
+	newLogger.component = strings.TrimPrefix(l.component+prefix, ".")
+	return &newLogger
+}
 
-	return &Logger{
-		stream: l.stream,
-		config: config,
-		level:  l.level,
+// WithFields creates a new logger object from the previous that adds a set of
+// fields to each log line.
+func (l *Logger) WithFields(fields map[string]string) Modular {
+	return l.withFields(fields)
+}
+
+func (l *Logger) withFields(fields map[string]string) *Logger {
+	newLogger := *l
+	if len(fields) == 0 {
+		return &newLogger
 	}
+
+	merged := make(map[string]string, len(l.fields)+len(fields))
+	for _, f := range l.fields {
+		merged[f.key] = f.value
+	}
+	for k, v := range fields {
+		merged[k] = v
+	}
+
+	newLogger.fields = make([]field, 0, len(merged))
+	for k, v := range merged {
+		newLogger.fields = append(newLogger.fields, field{key: k, value: v})
+	}
+	sort.Slice(newLogger.fields, func(i, j int) bool {
+		return newLogger.fields[i].key < newLogger.fields[j].key
+	})
+	return &newLogger
 }
 
 //------------------------------------------------------------------------------
 
-// writeFormatted prints a log message with any configured extras prepended.
-func (l *Logger) writeFormatted(message string, level string, other ...interface{}) {
-	if l.config.JSONFormat {
+// jsonString writes a string as a JSON value.
+func jsonString(buf *bytes.Buffer, s string) {
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	enc.Encode(s)
+	// The encoder appends a newline.
+	buf.Truncate(buf.Len() - 1)
+}
+
+// logfmtValue writes a string as a logfmt value, quoting it when necessary.
+func logfmtValue(buf *bytes.Buffer, s string) {
+	if len(s) > 0 && strings.IndexFunc(s, func(r rune) bool {
+		return r <= ' ' || r == '=' || r == '"' || !unicode.IsPrint(r)
+	}) == -1 {
+		buf.WriteString(s)
+		return
+	}
+	buf.WriteString(strconv.Quote(s))
+}
+
+// write prints a log message with any configured extras added.
+func (l *Logger) write(message string, level string) {
+	message = strings.TrimSuffix(message, "\n")
+
+	var buf bytes.Buffer
+	switch l.format {
+	case FormatJSON:
+		buf.WriteByte('{')
 		if l.config.AddTimeStamp {
-			fmt.Fprintf(l.stream, fmt.Sprintf(
-				"{\"@timestamp\":\"%v\",\"level\":\"%v\",\"@service\":\"%v\",\"message\":%v}\n",
-				time.Now().Format(time.RFC3339), level, l.config.Prefix,
-				strconv.QuoteToASCII(message),
-			), other...)
-		} else {
-			fmt.Fprintf(l.stream, fmt.Sprintf(
-				"{\"level\":\"%v\",\"@service\":\"%v\",\"message\":%v}\n",
-				level, l.config.Prefix,
-				strconv.QuoteToASCII(message),
-			), other...)
+			buf.WriteString(`"@timestamp":`)
+			jsonString(&buf, time.Now().Format(time.RFC3339))
+			buf.WriteByte(',')
+		}
+		buf.WriteString(`"level":`)
+		jsonString(&buf, level)
+		buf.WriteString(`,"@service":`)
+		jsonString(&buf, l.service)
+		if len(l.component) > 0 {
+			buf.WriteString(`,"component":`)
+			jsonString(&buf, l.component)
+		}
+		for _, f := range l.fields {
+			buf.WriteByte(',')
+			jsonString(&buf, f.key)
+			buf.WriteByte(':')
+			jsonString(&buf, f.value)
+		}
+		buf.WriteString(`,"message":`)
+		jsonString(&buf, message)
+		buf.WriteString("}\n")
+	case FormatLogfmt:
+		if l.config.AddTimeStamp {
+			buf.WriteString("time=")
+			logfmtValue(&buf, time.Now().Format(time.RFC3339))
+			buf.WriteByte(' ')
+		}
+		buf.WriteString("level=")
+		logfmtValue(&buf, level)
+		buf.WriteString(" service=")
+		logfmtValue(&buf, l.service)
+		if len(l.component) > 0 {
+			buf.WriteString(" component=")
+			logfmtValue(&buf, l.component)
+		}
+		for _, f := range l.fields {
+			buf.WriteByte(' ')
+			logfmtValue(&buf, f.key)
+			buf.WriteByte('=')
+			logfmtValue(&buf, f.value)
 		}
-	} else {
+		buf.WriteString(" msg=")
+		logfmtValue(&buf, message)
+		buf.WriteByte('\n')
+	default:
 		if l.config.AddTimeStamp {
-			fmt.Fprintf(l.stream, fmt.Sprintf(
-				"%v | %v | %v | %v",
-				time.Now().Format(time.RFC3339), level, l.config.Prefix, message,
-			), other...)
-		} else {
-			fmt.Fprintf(l.stream, fmt.Sprintf(
-				"%v | %v | %v", level, l.config.Prefix, message,
-			), other...)
+			buf.WriteString(time.Now().Format(time.RFC3339))
+			buf.WriteString(" | ")
 		}
+		buf.WriteString(level)
+		buf.WriteString(" | ")
+		buf.WriteString(l.config.Prefix)
+		buf.WriteString(" | ")
+		buf.WriteString(message)
+		buf.WriteByte('\n')
 	}
+	l.stream.Write(buf.Bytes())
+}
+
+// writeSampled prints a log message unless it is suppressed by sampling, which
+// only applies to warnings and errors.
+func (l *Logger) writeSampled(message string, level string) {
+	message = strings.TrimSuffix(message, "\n")
+	if l.sampler != nil && (level == "WARN" || level == "ERROR") &&
+		!l.sampler.allow(l, level, message) {
+		return
+	}
+	l.write(message, level)
+}
+
+// writeFormatted prints a log message with any configured extras prepended.
+func (l *Logger) writeFormatted(message string, level string, other ...interface{}) {
+	l.writeSampled(fmt.Sprintf(message, other...), level)
 }
 
 // writeLine prints a log message with any configured extras prepended.
 func (l *Logger) writeLine(message string, level string) {
-	if l.config.JSONFormat {
-		if l.config.AddTimeStamp {
-			fmt.Fprintf(l.stream,
-				"{\"@timestamp\":\"%v\",\"level\":\"%v\",\"@service\":\"%v\",\"message\":%v}\n",
-				time.Now().Format(time.RFC3339), level, l.config.Prefix,
-				strconv.QuoteToASCII(message),
-			)
-		} else {
-			fmt.Fprintf(l.stream,
-				"{\"level\":\"%v\",\"@service\":\"%v\",\"message\":%v}\n",
-				level, l.config.Prefix,
-				strconv.QuoteToASCII(message),
-			)
-		}
-	} else {
-		if l.config.AddTimeStamp {
-			fmt.Fprintf(
-				l.stream, "%v | %v | %v | %v\n",
-				time.Now().Format(time.RFC3339), level, l.config.Prefix, message,
-			)
-		} else {
-			fmt.Fprintf(l.stream, "%v | %v | %v\n", level, l.config.Prefix, message)
-		}
-	}
+	l.writeSampled(message, level)
 }
 
 //------------------------------------------------------------------------------
 
 // Fatalf prints a fatal message to the console. Does NOT cause panic.
 func (l *Logger) Fatalf(format string, v ...interface{}) {
-	if LogFatal <= l.level {
+	if LogFatal <= l.levels.levelFor(l.component) {
 		l.writeFormatted(format, "FATAL", v...)
 	}
 }
 
 // Errorf prints an error message to the console.
 func (l *Logger) Errorf(format string, v ...interface{}) {
-	if LogError <= l.level {
+	if LogError <= l.levels.levelFor(l.component) {
 		l.writeFormatted(format, "ERROR", v...)
 	}
 }
 
 // Warnf prints a warning message to the console.
 func (l *Logger) Warnf(format string, v ...interface{}) {
-	if LogWarn <= l.level {
+	if LogWarn <= l.levels.levelFor(l.component) {
 		l.writeFormatted(format, "WARN", v...)
 	}
 }
 
 // Infof prints an information message to the console.
 func (l *Logger) Infof(format string, v ...interface{}) {
-	if LogInfo <= l.level {
+	if LogInfo <= l.levels.levelFor(l.component) {
 		l.writeFormatted(format, "INFO", v...)
 	}
 }
 
 // Debugf prints a debug message to the console.
 func (l *Logger) Debugf(format string, v ...interface{}) {
-	if LogDebug <= l.level {
+	if LogDebug <= l.levels.levelFor(l.component) {
 		l.writeFormatted(format, "DEBUG", v...)
 	}
 }
 
 // Tracef prints a trace message to the console.
 func (l *Logger) Tracef(format string, v ...interface{}) {
-	if LogTrace <= l.level {
+	if LogTrace <= l.levels.levelFor(l.component) {
 		l.writeFormatted(format, "TRACE", v...)
 	}
 }
@@ -259,42 +402,42 @@ func (l *Logger) Tracef(format string, v ...interface{}) {
 
 // Fatalln prints a fatal message to the console. Does NOT cause panic.
 func (l *Logger) Fatalln(message string) {
-	if LogFatal <= l.level {
+	if LogFatal <= l.levels.levelFor(l.component) {
 		l.writeLine(message, "FATAL")
 	}
 }
 
 // Errorln prints an error message to the console.
 func (l *Logger) Errorln(message string) {
-	if LogError <= l.level {
+	if LogError <= l.levels.levelFor(l.component) {
 		l.writeLine(message, "ERROR")
 	}
 }
 
 // Warnln prints a warning message to the console.
 func (l *Logger) Warnln(message string) {
-	if LogWarn <= l.level {
+	if LogWarn <= l.levels.levelFor(l.component) {
 		l.writeLine(message, "WARN")
 	}
 }
 
 // Infoln prints an information message to the console.
 func (l *Logger) Infoln(message string) {
-	if LogInfo <= l.level {
+	if LogInfo <= l.levels.levelFor(l.component) {
 		l.writeLine(message, "INFO")
 	}
 }
 
 // Debugln prints a debug message to the console.
 func (l *Logger) Debugln(message string) {
-	if LogDebug <= l.level {
+	if LogDebug <= l.levels.levelFor(l.component) {
 		l.writeLine(message, "DEBUG")
 	}
 }
 
 // Traceln prints a trace message to the console.
 func (l *Logger) Traceln(message string) {
-	if LogTrace <= l.level {
+	if LogTrace <= l.levels.levelFor(l.component) {
 		l.writeLine(message, "TRACE")
 	}
 }
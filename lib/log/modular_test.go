@@ -191,3 +191,81 @@ func TestLoggerFanOut(t *testing.T) {
 	}
 }
 */
+
+func TestJSONLogging(t *testing.T) {
+	loggerConfig := NewConfig()
+	loggerConfig.AddTimeStamp = false
+	loggerConfig.Prefix = "root"
+	loggerConfig.LogLevel = "WARN"
+	loggerConfig.StaticFields = map[string]string{
+		"region": "eu-west-1",
+		"env":    "prod",
+	}
+
+	buf := LogBuffer{data: ""}
+
+	logger := New(&buf, loggerConfig)
+	logger.Warnf("warn test \"%v\"\n", 1)
+
+	logger = logger.NewModule(".foo").WithFields(map[string]string{
+		"stream": "bar",
+		"env":    "dev",
+	})
+	logger.NewModule(".baz").Errorln("error <test>\tline")
+
+	expected := `{"level":"WARN","@service":"root","env":"prod","region":"eu-west-1","message":"warn test \"1\""}` + "\n" +
+		`{"level":"ERROR","@service":"root","component":"foo.baz","env":"dev","region":"eu-west-1","stream":"bar","message":"error <test>\tline"}` + "\n"
+
+	if expected != buf.data {
+		t.Errorf("%v != %v", expected, buf.data)
+	}
+}
+
+func TestLogfmtLogging(t *testing.T) {
+	loggerConfig := NewConfig()
+	loggerConfig.AddTimeStamp = false
+	loggerConfig.Format = FormatLogfmt
+	loggerConfig.Prefix = "root"
+	loggerConfig.LogLevel = "WARN"
+	loggerConfig.StaticFields = map[string]string{
+		"env": "prod",
+	}
+
+	buf := LogBuffer{data: ""}
+
+	logger := New(&buf, loggerConfig).NewModule(".foo").WithFields(map[string]string{
+		"stream": "a b",
+	})
+	logger.Warnf("warn test %v\n", 1)
+	logger.Errorln("error=test")
+
+	expected := `level=WARN service=root component=foo env=prod stream="a b" msg="warn test 1"` + "\n" +
+		`level=ERROR service=root component=foo env=prod stream="a b" msg="error=test"` + "\n"
+
+	if expected != buf.data {
+		t.Errorf("%v != %v", expected, buf.data)
+	}
+}
+
+func TestLogFormatCompatibility(t *testing.T) {
+	conf := NewConfig()
+	if exp, act := FormatJSON, conf.format(); exp != act {
+		t.Errorf("Wrong format: %v != %v", act, exp)
+	}
+
+	conf.JSONFormat = false
+	if exp, act := FormatClassic, conf.format(); exp != act {
+		t.Errorf("Wrong format: %v != %v", act, exp)
+	}
+
+	conf.Format = FormatLogfmt
+	if exp, act := FormatLogfmt, conf.format(); exp != act {
+		t.Errorf("Wrong format: %v != %v", act, exp)
+	}
+
+	conf.Format = ""
+	conf.JSONFormat = true
+	if exp, act := FormatJSON, conf.format(); exp != act {
+		t.Errorf("Wrong format: %v != %v", act, exp)
+	}
+}
@@ -0,0 +1,272 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package log
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+//------------------------------------------------------------------------------
+
+// FileConfig holds configuration options for writing logs to a file with
+// rotation.
+type FileConfig struct {
+	Path         string `json:"path" yaml:"path"`
+	MaxSizeMB    int    `json:"max_size_mb" yaml:"max_size_mb"`
+	MaxAge       string `json:"max_age" yaml:"max_age"`
+	MaxBackups   int    `json:"max_backups" yaml:"max_backups"`
+	MaxBackupAge string `json:"max_backup_age" yaml:"max_backup_age"`
+}
+
+// NewFileConfig returns a FileConfig struct with the default values for each
+// field.
+func NewFileConfig() FileConfig {
+	return FileConfig{
+		Path:         "",
+		MaxSizeMB:    100,
+		MaxAge:       "",
+		MaxBackups:   10,
+		MaxBackupAge: "",
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// backupTimeFormat is the format of the timestamp added to the names of
+// rotated files, chosen to be sortable and free of path separators.
+const backupTimeFormat = "2006-01-02T15-04-05.000"
+
+// RotatingFile is an io.WriteCloser that writes to a file, rotating it once it
+// reaches a maximum size or age. Rotated files are renamed with a timestamp
+// and removed once they exceed the configured retention.
+type RotatingFile struct {
+	path         string
+	maxSize      int64
+	maxAge       time.Duration
+	maxBackups   int
+	maxBackupAge time.Duration
+
+	mut      sync.Mutex
+	closed   bool
+	file     *os.File
+	size     int64
+	openedAt time.Time
+
+	// now is overridden in tests.
+	now func() time.Time
+}
+
+// NewRotatingFile opens a file for writing logs according to a configuration,
+// appending to it if it already exists.
+func NewRotatingFile(conf FileConfig) (*RotatingFile, error) {
+	if len(conf.Path) == 0 {
+		return nil, errors.New("a path must be specified")
+	}
+	r := &RotatingFile{
+		path:       conf.Path,
+		maxSize:    int64(conf.MaxSizeMB) * 1024 * 1024,
+		maxBackups: conf.MaxBackups,
+		now:        time.Now,
+	}
+	var err error
+	if len(conf.MaxAge) > 0 {
+		if r.maxAge, err = time.ParseDuration(conf.MaxAge); err != nil {
+			return nil, fmt.Errorf("failed to parse max age: %v", err)
+		}
+	}
+	if len(conf.MaxBackupAge) > 0 {
+		if r.maxBackupAge, err = time.ParseDuration(conf.MaxBackupAge); err != nil {
+			return nil, fmt.Errorf("failed to parse max backup age: %v", err)
+		}
+	}
+	if err = r.open(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+//------------------------------------------------------------------------------
+
+func (r *RotatingFile) open() error {
+	if dir := filepath.Dir(r.path); len(dir) > 0 {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+	}
+	file, err := os.OpenFile(r.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	r.file = file
+	r.size = info.Size()
+	r.openedAt = r.now()
+	return nil
+}
+
+// backupName returns the name of a rotated file, which is the original name
+// with a timestamp inserted before the extension.
+func (r *RotatingFile) backupName(t time.Time) string {
+	ext := filepath.Ext(r.path)
+	return strings.TrimSuffix(r.path, ext) + "-" + t.Format(backupTimeFormat) + ext
+}
+
+// backups returns the paths of all rotated files, oldest first, along with
+// the times they were rotated.
+func (r *RotatingFile) backups() ([]string, []time.Time, error) {
+	ext := filepath.Ext(r.path)
+	prefix := filepath.Base(strings.TrimSuffix(r.path, ext)) + "-"
+
+	entries, err := ioutil.ReadDir(filepath.Dir(r.path))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var names []string
+	times := map[string]time.Time{}
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, ext) {
+			continue
+		}
+		tStr := strings.TrimSuffix(strings.TrimPrefix(name, prefix), ext)
+		t, err := time.ParseInLocation(backupTimeFormat, tStr, time.Local)
+		if err != nil {
+			continue
+		}
+		names = append(names, name)
+		times[name] = t
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return times[names[i]].Before(times[names[j]])
+	})
+
+	paths := make([]string, len(names))
+	rotated := make([]time.Time, len(names))
+	for i, name := range names {
+		paths[i] = filepath.Join(filepath.Dir(r.path), name)
+		rotated[i] = times[name]
+	}
+	return paths, rotated, nil
+}
+
+// prune removes rotated files that exceed the configured retention.
+func (r *RotatingFile) prune() error {
+	if r.maxBackups <= 0 && r.maxBackupAge <= 0 {
+		return nil
+	}
+	paths, rotated, err := r.backups()
+	if err != nil {
+		return err
+	}
+	now := r.now()
+	for i, p := range paths {
+		remove := r.maxBackups > 0 && len(paths)-i > r.maxBackups
+		if r.maxBackupAge > 0 && now.Sub(rotated[i]) > r.maxBackupAge {
+			remove = true
+		}
+		if remove {
+			if err = os.Remove(p); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// rotate closes the current file, renames it and opens a new one.
+func (r *RotatingFile) rotate() error {
+	if err := r.file.Close(); err != nil {
+		return err
+	}
+	r.file = nil
+	if err := os.Rename(r.path, r.backupName(r.now())); err != nil {
+		return err
+	}
+	if err := r.open(); err != nil {
+		return err
+	}
+	return r.prune()
+}
+
+//------------------------------------------------------------------------------
+
+// Write writes bytes to the file, rotating it beforehand if the write would
+// exceed the maximum size or if the file has reached its maximum age.
+func (r *RotatingFile) Write(p []byte) (int, error) {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+
+	if r.closed {
+		return 0, os.ErrClosed
+	}
+
+	// A previous rotation may have failed to open a new file.
+	if r.file == nil {
+		if err := r.open(); err != nil {
+			return 0, err
+		}
+	}
+
+	if r.size > 0 {
+		tooBig := r.maxSize > 0 && r.size+int64(len(p)) > r.maxSize
+		tooOld := r.maxAge > 0 && r.now().Sub(r.openedAt) >= r.maxAge
+		if tooBig || tooOld {
+			if err := r.rotate(); err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to rotate log file: %v\n", err)
+			}
+			if r.file == nil {
+				return 0, errors.New("log file is not open")
+			}
+		}
+	}
+
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+// Close closes the file.
+func (r *RotatingFile) Close() error {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+
+	r.closed = true
+	if r.file == nil {
+		return nil
+	}
+	err := r.file.Close()
+	r.file = nil
+	return err
+}
+
+//------------------------------------------------------------------------------
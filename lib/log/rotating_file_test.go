@@ -0,0 +1,183 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package log
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+func listDir(t *testing.T, dir string) []string {
+	t.Helper()
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := []string{}
+	for _, info := range infos {
+		names = append(names, info.Name())
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestRotatingFileSize(t *testing.T) {
+	dir, err := ioutil.TempDir("", "benthos_log_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	conf := NewFileConfig()
+	conf.Path = filepath.Join(dir, "benthos.log")
+	conf.MaxBackups = 2
+
+	r, err := NewRotatingFile(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	r.maxSize = 10
+	now := time.Date(2018, 10, 30, 12, 0, 0, 0, time.Local)
+	r.now = func() time.Time {
+		return now
+	}
+
+	for i, line := range []string{"foo1\n", "foo2\n", "bar1\n", "bar2\n", "baz1\n", "baz2\n", "buz1\n"} {
+		now = time.Date(2018, 10, 30, 12, 0, i, 0, time.Local)
+		if _, err = r.Write([]byte(line)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	exp := []string{
+		"benthos-2018-10-30T12-00-04.000.log",
+		"benthos-2018-10-30T12-00-06.000.log",
+		"benthos.log",
+	}
+	if act := listDir(t, dir); !reflect.DeepEqual(exp, act) {
+		t.Errorf("Wrong files: %v != %v", act, exp)
+	}
+
+	contents, err := ioutil.ReadFile(filepath.Join(dir, "benthos-2018-10-30T12-00-06.000.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp, act := "baz1\nbaz2\n", string(contents); exp != act {
+		t.Errorf("Wrong backup contents: %v != %v", act, exp)
+	}
+	if contents, err = ioutil.ReadFile(conf.Path); err != nil {
+		t.Fatal(err)
+	}
+	if exp, act := "buz1\n", string(contents); exp != act {
+		t.Errorf("Wrong contents: %v != %v", act, exp)
+	}
+}
+
+func TestRotatingFileAge(t *testing.T) {
+	dir, err := ioutil.TempDir("", "benthos_log_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	conf := NewFileConfig()
+	conf.Path = filepath.Join(dir, "benthos")
+	conf.MaxAge = "1h"
+	conf.MaxBackups = 0
+	conf.MaxBackupAge = "90m"
+
+	r, err := NewRotatingFile(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Date(2018, 10, 30, 12, 0, 0, 0, time.Local)
+	r.now = func() time.Time {
+		return now
+	}
+	r.openedAt = now
+
+	write := func(s string) {
+		if _, err := r.Write([]byte(s)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write("foo\n")
+	now = now.Add(time.Minute * 30)
+	write("bar\n")
+
+	if exp, act := []string{"benthos"}, listDir(t, dir); !reflect.DeepEqual(exp, act) {
+		t.Errorf("Wrong files: %v != %v", act, exp)
+	}
+
+	now = now.Add(time.Minute * 30)
+	write("baz\n")
+	now = now.Add(time.Hour)
+	write("buz\n")
+
+	exp := []string{
+		"benthos",
+		"benthos-2018-10-30T13-00-00.000",
+		"benthos-2018-10-30T14-00-00.000",
+	}
+	if act := listDir(t, dir); !reflect.DeepEqual(exp, act) {
+		t.Errorf("Wrong files: %v != %v", act, exp)
+	}
+
+	now = now.Add(time.Hour)
+	write("qux\n")
+
+	exp = []string{
+		"benthos",
+		"benthos-2018-10-30T14-00-00.000",
+		"benthos-2018-10-30T15-00-00.000",
+	}
+	if act := listDir(t, dir); !reflect.DeepEqual(exp, act) {
+		t.Errorf("Wrong files: %v != %v", act, exp)
+	}
+
+	if err = r.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = r.Write([]byte("nope")); err != os.ErrClosed {
+		t.Errorf("Wrong error after close: %v", err)
+	}
+}
+
+func TestRotatingFileBadConfig(t *testing.T) {
+	conf := NewFileConfig()
+	if _, err := NewRotatingFile(conf); err == nil {
+		t.Error("Expected error from empty path")
+	}
+
+	conf.Path = filepath.Join(os.TempDir(), "benthos_log_test_bad")
+	conf.MaxAge = "nope"
+	if _, err := NewRotatingFile(conf); err == nil {
+		t.Error("Expected error from bad max age")
+	}
+}
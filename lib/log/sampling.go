@@ -0,0 +1,132 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package log
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+//------------------------------------------------------------------------------
+
+// SamplingConfig holds configuration options for limiting the number of
+// identical warning and error logs that are written.
+type SamplingConfig struct {
+	Enabled bool   `json:"enabled" yaml:"enabled"`
+	Initial int    `json:"initial" yaml:"initial"`
+	Period  string `json:"period" yaml:"period"`
+}
+
+// NewSamplingConfig returns a SamplingConfig struct with the default values
+// for each field.
+func NewSamplingConfig() SamplingConfig {
+	return SamplingConfig{
+		Enabled: false,
+		Initial: 10,
+		Period:  "10s",
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type sampleKey struct {
+	component string
+	level     string
+	message   string
+}
+
+type sampleEntry struct {
+	logger     *Logger
+	count      int
+	suppressed int
+}
+
+// sampler is shared by a logger and all of its modules, and limits identical
+// logs of a component to an initial number within each period. Logs beyond
+// that are dropped and counted, and at the end of the period a summary of
+// each dropped log is written with the number of times it was suppressed.
+type sampler struct {
+	initial int
+	period  time.Duration
+
+	mut     sync.Mutex
+	entries map[sampleKey]*sampleEntry
+}
+
+func newSampler(conf SamplingConfig) (*sampler, error) {
+	period, err := time.ParseDuration(conf.Period)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse period: %v", err)
+	}
+	if period <= 0 {
+		return nil, fmt.Errorf("period must be greater than zero: %v", conf.Period)
+	}
+	return &sampler{
+		initial: conf.Initial,
+		period:  period,
+		entries: map[sampleKey]*sampleEntry{},
+	}, nil
+}
+
+// allow returns whether a log should be written.
+func (s *sampler) allow(l *Logger, level, message string) bool {
+	key := sampleKey{component: l.component, level: level, message: message}
+
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	if len(s.entries) == 0 {
+		time.AfterFunc(s.period, s.flush)
+	}
+	e, exists := s.entries[key]
+	if !exists {
+		e = &sampleEntry{logger: l}
+		s.entries[key] = e
+	}
+	if e.count++; e.count <= s.initial {
+		return true
+	}
+	e.suppressed++
+	return false
+}
+
+// flush ends the current period, writing a summary of each suppressed log.
+func (s *sampler) flush() {
+	s.mut.Lock()
+	entries := s.entries
+	s.entries = map[sampleKey]*sampleEntry{}
+	s.mut.Unlock()
+
+	for k, e := range entries {
+		if e.suppressed == 0 {
+			continue
+		}
+		e.logger.withFields(map[string]string{
+			"suppressed": fmt.Sprintf("%v", e.suppressed),
+		}).write(fmt.Sprintf(
+			"%v (suppressed %v times in the last %v)",
+			k.message, e.suppressed, s.period,
+		), k.level)
+	}
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,109 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package log
+
+import (
+	"testing"
+)
+
+//------------------------------------------------------------------------------
+
+func TestSampling(t *testing.T) {
+	conf := NewConfig()
+	conf.AddTimeStamp = false
+	conf.Format = FormatLogfmt
+	conf.Prefix = "root"
+	conf.Sampling.Enabled = true
+	conf.Sampling.Initial = 2
+	conf.Sampling.Period = "1h"
+
+	buf := LogBuffer{data: ""}
+
+	logger := New(&buf, conf)
+	fooLogger := logger.NewModule(".foo")
+	for i := 0; i < 5; i++ {
+		fooLogger.Errorf("connection refused\n")
+		fooLogger.Infoln("info is not sampled")
+	}
+	fooLogger.Errorln("different error")
+	logger.Errorln("connection refused")
+	fooLogger.Warnln("connection refused")
+
+	expected := `level=ERROR service=root component=foo msg="connection refused"
+level=INFO service=root component=foo msg="info is not sampled"
+level=ERROR service=root component=foo msg="connection refused"
+level=INFO service=root component=foo msg="info is not sampled"
+level=INFO service=root component=foo msg="info is not sampled"
+level=INFO service=root component=foo msg="info is not sampled"
+level=INFO service=root component=foo msg="info is not sampled"
+level=ERROR service=root component=foo msg="different error"
+level=ERROR service=root msg="connection refused"
+level=WARN service=root component=foo msg="connection refused"
+`
+	if act := buf.data; act != expected {
+		t.Errorf("Wrong output: %v != %v", act, expected)
+	}
+
+	buf.data = ""
+	logger.(*Logger).sampler.flush()
+
+	expected = `level=ERROR service=root component=foo suppressed=3 msg="connection refused (suppressed 3 times in the last 1h0m0s)"
+`
+	if act := buf.data; act != expected {
+		t.Errorf("Wrong output: %v != %v", act, expected)
+	}
+
+	buf.data = ""
+	fooLogger.Errorln("connection refused")
+	logger.(*Logger).sampler.flush()
+
+	expected = `level=ERROR service=root component=foo msg="connection refused"
+`
+	if act := buf.data; act != expected {
+		t.Errorf("Wrong output: %v != %v", act, expected)
+	}
+}
+
+func TestSamplingBadPeriod(t *testing.T) {
+	conf := NewConfig()
+	conf.AddTimeStamp = false
+	conf.Format = FormatLogfmt
+	conf.Prefix = "root"
+	conf.Sampling.Enabled = true
+	conf.Sampling.Initial = 1
+	conf.Sampling.Period = "nope"
+
+	buf := LogBuffer{data: ""}
+
+	logger := New(&buf, conf)
+	buf.data = ""
+	logger.Errorln("foo")
+	logger.Errorln("foo")
+
+	expected := `level=ERROR service=root msg=foo
+level=ERROR service=root msg=foo
+`
+	if act := buf.data; act != expected {
+		t.Errorf("Wrong output: %v != %v", act, expected)
+	}
+}
+
+//------------------------------------------------------------------------------
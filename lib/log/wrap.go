@@ -58,6 +58,10 @@ func (l *wrapped) NewModule(prefix string) Modular {
 	return l
 }
 
+func (l *wrapped) WithFields(fields map[string]string) Modular {
+	return l
+}
+
 //------------------------------------------------------------------------------
 
 // Fatalf prints a fatal message to the console. Does NOT cause panic.
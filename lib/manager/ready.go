@@ -0,0 +1,113 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package manager
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+
+	"github.com/Jeffail/benthos/lib/types"
+)
+
+//------------------------------------------------------------------------------
+
+type namedConnector struct {
+	name string
+	c    types.Connector
+}
+
+// connectors tracks the connection state of the inputs and outputs of a
+// service.
+type connectors struct {
+	mut  sync.Mutex
+	list []namedConnector
+}
+
+type connectorStatus struct {
+	Name      string `json:"name"`
+	Connected bool   `json:"connected"`
+}
+
+type readyRes struct {
+	Ready      bool              `json:"ready"`
+	Components []connectorStatus `json:"components"`
+}
+
+// status returns the connection state of each registered component, and
+// forgets components that have closed.
+func (c *connectors) status() readyRes {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	res := readyRes{
+		Ready:      true,
+		Components: []connectorStatus{},
+	}
+	open := c.list[:0]
+	for _, nc := range c.list {
+		err := nc.c.ConnectionStatus()
+		if err == types.ErrTypeClosed {
+			continue
+		}
+		open = append(open, nc)
+		res.Components = append(res.Components, connectorStatus{
+			Name:      nc.name,
+			Connected: err == nil,
+		})
+		if err != nil {
+			res.Ready = false
+		}
+	}
+	for i := len(open); i < len(c.list); i++ {
+		c.list[i] = namedConnector{}
+	}
+	c.list = open
+	return res
+}
+
+//------------------------------------------------------------------------------
+
+// RegisterConnector registers a component that reports its connection state,
+// which determines whether the service is ready.
+func (t *Type) RegisterConnector(name string, c types.Connector) {
+	t.connectors.mut.Lock()
+	t.connectors.list = append(t.connectors.list, namedConnector{name: name, c: c})
+	t.connectors.mut.Unlock()
+}
+
+// handleReady responds with the connection state of each registered
+// component, and with a 503 status code unless all of them are connected.
+func (t *Type) handleReady(w http.ResponseWriter, r *http.Request) {
+	res := t.connectors.status()
+	resBytes, err := json.Marshal(res)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if !res.Ready {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	w.Write(resBytes)
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,101 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package manager
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Jeffail/benthos/lib/log"
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+)
+
+//------------------------------------------------------------------------------
+
+type fakeAPIReg struct {
+	handlers map[string]http.HandlerFunc
+}
+
+func (f *fakeAPIReg) RegisterEndpoint(path, desc string, h http.HandlerFunc) {
+	f.handlers[path] = h
+}
+
+type fakeConnector struct {
+	err error
+}
+
+func (f *fakeConnector) ConnectionStatus() error {
+	return f.err
+}
+
+func TestManagerReady(t *testing.T) {
+	apiReg := &fakeAPIReg{handlers: map[string]http.HandlerFunc{}}
+	mgr, err := New(NewConfig(), apiReg, log.Noop(), metrics.Noop())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	handler, exists := apiReg.handlers["/ready"]
+	if !exists {
+		t.Fatal("Ready endpoint not registered")
+	}
+
+	get := func() (int, string) {
+		rec := httptest.NewRecorder()
+		handler(rec, httptest.NewRequest("GET", "/ready", nil))
+		return rec.Code, rec.Body.String()
+	}
+
+	if code, body := get(); code != http.StatusOK || body != `{"ready":true,"components":[]}` {
+		t.Errorf("Wrong response: %v %v", code, body)
+	}
+
+	in := &fakeConnector{err: types.ErrNotConnected}
+	out := &fakeConnector{err: nil}
+	mgr.RegisterConnector("input.foo", in)
+	mgr.RegisterConnector("output.bar", out)
+
+	exp := `{"ready":false,"components":[{"name":"input.foo","connected":false},{"name":"output.bar","connected":true}]}`
+	if code, body := get(); code != http.StatusServiceUnavailable || body != exp {
+		t.Errorf("Wrong response: %v %v", code, body)
+	}
+
+	in.err = nil
+	exp = `{"ready":true,"components":[{"name":"input.foo","connected":true},{"name":"output.bar","connected":true}]}`
+	if code, body := get(); code != http.StatusOK || body != exp {
+		t.Errorf("Wrong response: %v %v", code, body)
+	}
+
+	in.err = types.ErrTypeClosed
+	exp = `{"ready":true,"components":[{"name":"output.bar","connected":true}]}`
+	if code, body := get(); code != http.StatusOK || body != exp {
+		t.Errorf("Wrong response: %v %v", code, body)
+	}
+
+	in.err = nil
+	if code, body := get(); code != http.StatusOK || body != exp {
+		t.Errorf("Wrong response: %v %v", code, body)
+	}
+}
+
+//------------------------------------------------------------------------------
@@ -46,7 +46,7 @@ type APIReg interface {
 type Config struct {
 	Caches     map[string]cache.Config     `json:"caches" yaml:"caches"`
 	Conditions map[string]condition.Config `json:"conditions" yaml:"conditions"`
-	RateLimits map[string]ratelimit.Config `json:"rate_limit" yaml:"rate_limit"`
+	RateLimits map[string]ratelimit.Config `json:"rate_limits" yaml:"rate_limits"`
 }
 
 // NewConfig returns a Config with default values.
@@ -118,8 +118,11 @@ type Type struct {
 	conditions map[string]types.Condition
 	rateLimits map[string]types.RateLimit
 
-	pipes    map[string]<-chan types.Transaction
-	pipeLock sync.RWMutex
+	pipes       map[string]<-chan types.Transaction
+	subscribers map[string][]chan<- types.Transaction
+	pipeLock    sync.RWMutex
+
+	connectors connectors
 }
 
 // New returns an instance of manager.Type, which can be shared amongst
@@ -136,6 +139,15 @@ func New(
 		conditions: map[string]types.Condition{},
 		rateLimits: map[string]types.RateLimit{},
 		pipes:      map[string]<-chan types.Transaction{},
+
+		subscribers: map[string][]chan<- types.Transaction{},
+	}
+
+	// Some caches might refer to other cache resources. As with conditions below
+	// we create placeholders so that references can be validated regardless of
+	// the order of initialisation.
+	for k := range conf.Caches {
+		t.caches[k] = nil
 	}
 
 	for k, conf := range conf.Caches {
@@ -182,6 +194,15 @@ func New(
 		t.rateLimits[k] = newRL
 	}
 
+	if apiReg != nil {
+		apiReg.RegisterEndpoint(
+			"/ready",
+			"Returns the connection state of each input and output, responding"+
+				" with a 503 status code unless all of them are connected.",
+			t.handleReady,
+		)
+	}
+
 	// Note: Caches, conditions and rate limits are considered READONLY from
 	// this point onwards and are therefore NOT protected by mutexes or
 	// channels.
@@ -231,6 +252,41 @@ func (t *Type) UnsetPipe(name string, tran <-chan types.Transaction) {
 	t.pipeLock.Unlock()
 }
 
+// SubscribePipe registers a transaction chan as a subscriber of a named pipe.
+func (t *Type) SubscribePipe(name string, tran chan<- types.Transaction) {
+	t.pipeLock.Lock()
+	t.subscribers[name] = append(t.subscribers[name], tran)
+	t.pipeLock.Unlock()
+}
+
+// UnsubscribePipe removes a subscribed transaction chan from a named pipe.
+func (t *Type) UnsubscribePipe(name string, tran chan<- types.Transaction) {
+	t.pipeLock.Lock()
+	subs := t.subscribers[name]
+	for i, sub := range subs {
+		if sub == tran {
+			subs = append(subs[:i:i], subs[i+1:]...)
+			break
+		}
+	}
+	if len(subs) == 0 {
+		delete(t.subscribers, name)
+	} else {
+		t.subscribers[name] = subs
+	}
+	t.pipeLock.Unlock()
+}
+
+// GetPipeSubscribers returns a copy of the transaction chans subscribed to a
+// named pipe.
+func (t *Type) GetPipeSubscribers(name string) []chan<- types.Transaction {
+	t.pipeLock.RLock()
+	subs := make([]chan<- types.Transaction, len(t.subscribers[name]))
+	copy(subs, t.subscribers[name])
+	t.pipeLock.RUnlock()
+	return subs
+}
+
 // GetCondition attempts to find a service wide condition by its name.
 func (t *Type) GetCondition(name string) (types.Condition, error) {
 	if c, exists := t.conditions[name]; exists {
@@ -70,6 +70,45 @@ func TestManagerBadCache(t *testing.T) {
 	}
 }
 
+func TestManagerCacheReferences(t *testing.T) {
+	conf := NewConfig()
+	levelConf := cache.NewConfig()
+	levelConf.Type = "multilevel"
+	levelConf.Multilevel = []string{"foo", "bar"}
+	conf.Caches["baz"] = levelConf
+	conf.Caches["foo"] = cache.NewConfig()
+	conf.Caches["bar"] = cache.NewConfig()
+
+	mgr, err := New(conf, nil, log.Noop(), metrics.Noop())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := mgr.GetCache("baz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = c.Set("qux", []byte("quz")); err != nil {
+		t.Fatal(err)
+	}
+
+	foo, err := mgr.GetCache("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if act, err := foo.Get("qux"); err != nil {
+		t.Error(err)
+	} else if exp := "quz"; string(act) != exp {
+		t.Errorf("Wrong result: %s != %s", act, exp)
+	}
+
+	levelConf.Multilevel = []string{"foo", "nope"}
+	conf.Caches["baz"] = levelConf
+	if _, err = New(conf, nil, log.Noop(), metrics.Noop()); err == nil {
+		t.Error("Expected error from missing cache reference")
+	}
+}
+
 func TestManagerRateLimit(t *testing.T) {
 	conf := NewConfig()
 	conf.RateLimits["foo"] = ratelimit.NewConfig()
@@ -240,3 +279,50 @@ func TestManagerPipeGetSet(t *testing.T) {
 }
 
 //------------------------------------------------------------------------------
+
+func TestManagerPipeSubscribers(t *testing.T) {
+	conf := NewConfig()
+	mgr, err := New(conf, nil, log.Noop(), metrics.Noop())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t1 := make(chan types.Transaction)
+	t2 := make(chan types.Transaction)
+	t3 := make(chan types.Transaction)
+
+	if subs := mgr.GetPipeSubscribers("foo"); len(subs) != 0 {
+		t.Errorf("Unexpected subscribers: %v", subs)
+	}
+
+	mgr.SubscribePipe("foo", t1)
+	mgr.SubscribePipe("foo", t2)
+	mgr.SubscribePipe("bar", t3)
+
+	subs := mgr.GetPipeSubscribers("foo")
+	if len(subs) != 2 || subs[0] != t1 || subs[1] != t2 {
+		t.Errorf("Wrong subscribers returned: %v", subs)
+	}
+
+	// Should be a noop
+	mgr.UnsubscribePipe("foo", t3)
+	if subs = mgr.GetPipeSubscribers("foo"); len(subs) != 2 {
+		t.Errorf("Wrong subscribers returned: %v", subs)
+	}
+
+	mgr.UnsubscribePipe("foo", t1)
+	subs = mgr.GetPipeSubscribers("foo")
+	if len(subs) != 1 || subs[0] != t2 {
+		t.Errorf("Wrong subscribers returned: %v", subs)
+	}
+
+	mgr.UnsubscribePipe("foo", t2)
+	if subs = mgr.GetPipeSubscribers("foo"); len(subs) != 0 {
+		t.Errorf("Unexpected subscribers: %v", subs)
+	}
+
+	subs = mgr.GetPipeSubscribers("bar")
+	if len(subs) != 1 || subs[0] != t3 {
+		t.Errorf("Wrong subscribers returned: %v", subs)
+	}
+}
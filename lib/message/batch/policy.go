@@ -0,0 +1,179 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package batch
+
+import (
+	"time"
+
+	"github.com/Jeffail/benthos/lib/log"
+	"github.com/Jeffail/benthos/lib/message"
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/processor/condition"
+	"github.com/Jeffail/benthos/lib/types"
+)
+
+//------------------------------------------------------------------------------
+
+// PolicyConfig contains configuration parameters for a batch policy.
+type PolicyConfig struct {
+	ByteSize  int              `json:"byte_size" yaml:"byte_size"`
+	Count     int              `json:"count" yaml:"count"`
+	Condition condition.Config `json:"condition" yaml:"condition"`
+	PeriodMS  int              `json:"period_ms" yaml:"period_ms"`
+}
+
+// NewPolicyConfig creates a default PolicyConfig.
+func NewPolicyConfig() PolicyConfig {
+	cond := condition.NewConfig()
+	cond.Type = "static"
+	cond.Static = false
+	return PolicyConfig{
+		ByteSize:  0,
+		Count:     0,
+		Condition: cond,
+		PeriodMS:  0,
+	}
+}
+
+// IsNoop returns true if this batch policy configuration does nothing.
+func (p PolicyConfig) IsNoop() bool {
+	if p.ByteSize > 0 || p.Count > 0 || p.PeriodMS > 0 {
+		return false
+	}
+	return p.Condition.Type == "static" && !p.Condition.Static
+}
+
+//------------------------------------------------------------------------------
+
+// Policy implements a batching policy by buffering messages until, based on a
+// set of rules, the buffered messages are ready to be sent onwards as a batch.
+type Policy struct {
+	log log.Modular
+
+	byteSize int
+	count    int
+	period   time.Duration
+	cond     condition.Type
+
+	sizeTally int
+	parts     []types.Part
+
+	lastBatch time.Time
+
+	mSizeBatch   metrics.StatCounter
+	mCountBatch  metrics.StatCounter
+	mPeriodBatch metrics.StatCounter
+	mCondBatch   metrics.StatCounter
+}
+
+// NewPolicy creates an empty policy with default rules.
+func NewPolicy(
+	conf PolicyConfig,
+	mgr types.Manager,
+	log log.Modular,
+	stats metrics.Type,
+) (*Policy, error) {
+	cond, err := condition.New(conf.Condition, mgr, log, metrics.Namespaced(stats, "batch"))
+	if err != nil {
+		return nil, err
+	}
+	return &Policy{
+		log: log,
+
+		byteSize: conf.ByteSize,
+		count:    conf.Count,
+		period:   time.Duration(conf.PeriodMS) * time.Millisecond,
+		cond:     cond,
+
+		lastBatch: time.Now(),
+
+		mSizeBatch:   stats.GetCounter("batch.on_size"),
+		mCountBatch:  stats.GetCounter("batch.on_count"),
+		mPeriodBatch: stats.GetCounter("batch.on_period"),
+		mCondBatch:   stats.GetCounter("batch.on_condition"),
+	}, nil
+}
+
+//------------------------------------------------------------------------------
+
+// Add the parts of a message to the batch, returns true if the batch is ready
+// to be flushed.
+func (p *Policy) Add(msg types.Message) bool {
+	msg.Iter(func(i int, b types.Part) error {
+		p.sizeTally += len(b.Get())
+		p.parts = append(p.parts, b.Copy())
+		return nil
+	})
+
+	if p.count > 0 && len(p.parts) >= p.count {
+		p.mCountBatch.Incr(1)
+		p.log.Traceln("Batching based on count")
+		return true
+	}
+	if p.byteSize > 0 && p.sizeTally >= p.byteSize {
+		p.mSizeBatch.Incr(1)
+		p.log.Traceln("Batching based on byte_size")
+		return true
+	}
+	if p.period > 0 && time.Since(p.lastBatch) > p.period {
+		p.mPeriodBatch.Incr(1)
+		p.log.Traceln("Batching based on period_ms")
+		return true
+	}
+	if p.cond.Check(msg) {
+		p.mCondBatch.Incr(1)
+		p.log.Traceln("Batching based on condition")
+		return true
+	}
+	return false
+}
+
+// Flush clears all messages stored by this batch policy. Returns nil if the
+// policy is currently empty.
+func (p *Policy) Flush() types.Message {
+	var newMsg types.Message
+	if len(p.parts) > 0 {
+		newMsg = message.New(nil)
+		newMsg.Append(p.parts...)
+	}
+	p.parts = nil
+	p.sizeTally = 0
+	p.lastBatch = time.Now()
+	return newMsg
+}
+
+// Count returns the number of currently buffered message parts within this
+// policy.
+func (p *Policy) Count() int {
+	return len(p.parts)
+}
+
+// UntilNext returns a duration indicating how long until the current batch
+// should be flushed due to a configured period. A negative duration indicates
+// a period has not been set.
+func (p *Policy) UntilNext() time.Duration {
+	if p.period <= 0 {
+		return -1
+	}
+	return time.Until(p.lastBatch.Add(p.period))
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,130 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package batch
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/log"
+	"github.com/Jeffail/benthos/lib/message"
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+)
+
+func TestPolicyNoop(t *testing.T) {
+	conf := NewPolicyConfig()
+	if !conf.IsNoop() {
+		t.Error("Expected default policy to be a noop")
+	}
+
+	pol, err := NewPolicy(conf, types.NoopMgr(), log.Noop(), metrics.Noop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pol.Add(message.New([][]byte{[]byte("foo")})) {
+		t.Error("Unexpected batch")
+	}
+	if v := pol.UntilNext(); v >= 0 {
+		t.Errorf("Unexpected period: %v", v)
+	}
+}
+
+func TestPolicyBasic(t *testing.T) {
+	conf := NewPolicyConfig()
+	conf.Count = 2
+	conf.ByteSize = 8
+	if conf.IsNoop() {
+		t.Error("Expected policy not to be a noop")
+	}
+
+	pol, err := NewPolicy(conf, types.NoopMgr(), log.Noop(), metrics.Noop())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if pol.Add(message.New([][]byte{[]byte("foo")})) {
+		t.Error("Unexpected batch")
+	}
+	if exp, act := 1, pol.Count(); exp != act {
+		t.Errorf("Wrong count: %v != %v", act, exp)
+	}
+	if !pol.Add(message.New([][]byte{[]byte("bar")})) {
+		t.Error("Expected batch")
+	}
+	if exp, act := 2, pol.Flush().Len(); exp != act {
+		t.Errorf("Wrong batch size: %v != %v", act, exp)
+	}
+	if msg := pol.Flush(); msg != nil {
+		t.Errorf("Expected nil message from empty policy: %v", msg)
+	}
+
+	if !pol.Add(message.New([][]byte{[]byte("foobarbaz")})) {
+		t.Error("Expected batch from byte size")
+	}
+	if exp, act := 1, pol.Flush().Len(); exp != act {
+		t.Errorf("Wrong batch size: %v != %v", act, exp)
+	}
+}
+
+func TestPolicyPeriod(t *testing.T) {
+	conf := NewPolicyConfig()
+	conf.PeriodMS = 300
+
+	pol, err := NewPolicy(conf, types.NoopMgr(), log.Noop(), metrics.Noop())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if v := pol.UntilNext(); v <= 100*time.Millisecond || v > 300*time.Millisecond {
+		t.Errorf("Wrong period: %v", v)
+	}
+	if pol.Add(message.New([][]byte{[]byte("foo")})) {
+		t.Error("Unexpected batch")
+	}
+
+	<-time.After(300 * time.Millisecond)
+	if v := pol.UntilNext(); v >= 0 {
+		t.Errorf("Period should have passed: %v", v)
+	}
+	if !pol.Add(message.New([][]byte{[]byte("bar")})) {
+		t.Error("Expected batch")
+	}
+}
+
+func TestPolicyCondition(t *testing.T) {
+	conf := NewPolicyConfig()
+	conf.Condition.Type = "text"
+	conf.Condition.Text.Operator = "equals"
+	conf.Condition.Text.Arg = "bar"
+
+	pol, err := NewPolicy(conf, types.NoopMgr(), log.Noop(), metrics.Noop())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if pol.Add(message.New([][]byte{[]byte("foo")})) {
+		t.Error("Unexpected batch")
+	}
+	if !pol.Add(message.New([][]byte{[]byte("bar")})) {
+		t.Error("Expected batch")
+	}
+}
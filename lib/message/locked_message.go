@@ -37,6 +37,16 @@ func Lock(msg types.Message, part int) types.Message {
 	}
 }
 
+// CheckPart tests a single part of a message against a condition as if it were
+// a message of its own. Conditions that implement types.PartCondition are
+// checked directly, otherwise the part is wrapped with Lock.
+func CheckPart(cond types.Condition, msg types.Message, part int) bool {
+	if pc, ok := cond.(types.PartCondition); ok {
+		return pc.CheckPart(msg, part)
+	}
+	return cond.Check(Lock(msg, part))
+}
+
 //------------------------------------------------------------------------------
 
 // lockedMessage wraps a message in a read only restricted type.
@@ -239,6 +239,18 @@ func (t *Type) test(msg types.Message) bool {
 	return true
 }
 
+// testPart tests a single part of a batch against the conditions of a mapper.
+func (t *Type) testPart(msg types.Message, index int) bool {
+	for _, c := range t.conditions {
+		if !message.CheckPart(c, msg, index) {
+			t.mCondFail.Incr(1)
+			return false
+		}
+	}
+	t.mCondPass.Incr(1)
+	return true
+}
+
 func getGabs(msg types.Message, index int) (*gabs.Container, error) {
 	payloadObj, err := msg.Get(index).JSON()
 	if err != nil {
@@ -270,7 +282,7 @@ partLoop:
 		}
 
 		// Skip if message part fails condition.
-		if !t.test(message.Lock(msg, i)) {
+		if !t.testPart(msg, i) {
 			skipped = append(skipped, i)
 			continue partLoop
 		}
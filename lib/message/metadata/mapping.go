@@ -0,0 +1,128 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package metadata
+
+import (
+	"strings"
+
+	"github.com/Jeffail/benthos/lib/types"
+)
+
+//------------------------------------------------------------------------------
+
+// MappingConfig contains rules that determine which metadata keys of message
+// parts are propagated as the headers of a transport by an output, or which
+// headers of a transport are added as metadata to message parts by an input.
+//
+// A key is propagated when it begins with any of the include prefixes and none
+// of the exclude prefixes. An empty include prefix matches all keys. Keys that
+// are propagated are then renamed according to the rename map.
+type MappingConfig struct {
+	IncludePrefixes []string          `json:"include_prefixes" yaml:"include_prefixes"`
+	ExcludePrefixes []string          `json:"exclude_prefixes" yaml:"exclude_prefixes"`
+	Rename          map[string]string `json:"rename" yaml:"rename"`
+}
+
+// NewMappingConfig returns a MappingConfig with default values, where no keys
+// are propagated.
+func NewMappingConfig() MappingConfig {
+	return MappingConfig{
+		IncludePrefixes: []string{},
+		ExcludePrefixes: []string{},
+		Rename:          map[string]string{},
+	}
+}
+
+// NewMappingConfigAll returns a MappingConfig where all keys are propagated.
+func NewMappingConfigAll() MappingConfig {
+	conf := NewMappingConfig()
+	conf.IncludePrefixes = []string{""}
+	return conf
+}
+
+//------------------------------------------------------------------------------
+
+// Mapping applies the rules of a MappingConfig to metadata keys.
+type Mapping struct {
+	include []string
+	exclude []string
+	rename  map[string]string
+}
+
+// NewMapping creates a new Mapping from a config.
+func NewMapping(conf MappingConfig) *Mapping {
+	return &Mapping{
+		include: conf.IncludePrefixes,
+		exclude: conf.ExcludePrefixes,
+		rename:  conf.Rename,
+	}
+}
+
+// Enabled returns true if the mapping is able to propagate any keys.
+func (m *Mapping) Enabled() bool {
+	return len(m.include) > 0
+}
+
+// Key returns the name that a key should be propagated as, and whether it
+// should be propagated at all.
+func (m *Mapping) Key(k string) (string, bool) {
+	included := false
+	for _, p := range m.include {
+		if strings.HasPrefix(k, p) {
+			included = true
+			break
+		}
+	}
+	if !included {
+		return "", false
+	}
+	for _, p := range m.exclude {
+		if strings.HasPrefix(k, p) {
+			return "", false
+		}
+	}
+	if renamed, exists := m.rename[k]; exists {
+		return renamed, true
+	}
+	return k, true
+}
+
+// Renamed returns true if a key has an explicit rename rule.
+func (m *Mapping) Renamed(k string) bool {
+	_, exists := m.rename[k]
+	return exists
+}
+
+// Iter calls f for each key/value pair of a metadata object that should be
+// propagated, with keys already renamed.
+func (m *Mapping) Iter(meta types.Metadata, f func(k, v string) error) error {
+	if !m.Enabled() {
+		return nil
+	}
+	return meta.Iter(func(k, v string) error {
+		if mk, ok := m.Key(k); ok {
+			return f(mk, v)
+		}
+		return nil
+	})
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,105 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package metadata
+
+import (
+	"reflect"
+	"testing"
+)
+
+//------------------------------------------------------------------------------
+
+func TestMappingDefault(t *testing.T) {
+	m := NewMapping(NewMappingConfig())
+	if m.Enabled() {
+		t.Error("Expected default mapping to be disabled")
+	}
+
+	actMap := map[string]string{}
+	m.Iter(New(map[string]string{"foo": "bar"}), func(k, v string) error {
+		actMap[k] = v
+		return nil
+	})
+	if len(actMap) > 0 {
+		t.Errorf("Unexpected keys propagated: %v", actMap)
+	}
+}
+
+func TestMappingAll(t *testing.T) {
+	m := NewMapping(NewMappingConfigAll())
+
+	expMap := map[string]string{"foo": "bar", "baz": "qux"}
+	actMap := map[string]string{}
+	m.Iter(New(expMap), func(k, v string) error {
+		actMap[k] = v
+		return nil
+	})
+	if !reflect.DeepEqual(expMap, actMap) {
+		t.Errorf("Wrong result: %v != %v", actMap, expMap)
+	}
+}
+
+func TestMappingRules(t *testing.T) {
+	conf := NewMappingConfig()
+	conf.IncludePrefixes = []string{"app_", "kafka_key"}
+	conf.ExcludePrefixes = []string{"app_secret"}
+	conf.Rename = map[string]string{
+		"app_id":      "X-App-ID",
+		"app_secret":  "X-Secret",
+		"not_present": "foo",
+	}
+	m := NewMapping(conf)
+
+	tests := map[string]struct {
+		key string
+		ok  bool
+	}{
+		"app_id":           {"X-App-ID", true},
+		"app_name":         {"app_name", true},
+		"app_secret_token": {"", false},
+		"app_secret":       {"", false},
+		"kafka_key":        {"kafka_key", true},
+		"kafka_topic":      {"", false},
+		"not_present":      {"", false},
+	}
+
+	for input, exp := range tests {
+		key, ok := m.Key(input)
+		if exp.key != key || exp.ok != ok {
+			t.Errorf("Wrong result for key '%v': %v, %v != %v, %v", input, key, ok, exp.key, exp.ok)
+		}
+	}
+
+	actMap := map[string]string{}
+	m.Iter(New(map[string]string{
+		"app_id":     "1",
+		"app_secret": "2",
+		"other":      "3",
+	}), func(k, v string) error {
+		actMap[k] = v
+		return nil
+	})
+	if exp := map[string]string{"X-App-ID": "1"}; !reflect.DeepEqual(exp, actMap) {
+		t.Errorf("Wrong result: %v != %v", actMap, exp)
+	}
+}
+
+//------------------------------------------------------------------------------
@@ -21,6 +21,8 @@
 package metadata
 
 import (
+	"sync/atomic"
+
 	"github.com/Jeffail/benthos/lib/types"
 )
 
@@ -28,8 +30,15 @@ import (
 
 // Type is an implementation of types.Metadata representing the metadata of a
 // message part within a batch.
+//
+// Copies of a Type share the same underlying map until either side is edited,
+// at which point the edited side takes its own copy of the map.
 type Type struct {
 	m map[string]string
+
+	// shared is non-zero when m might be referenced by another copy, and must
+	// therefore be cloned before it is modified.
+	shared int32
 }
 
 // New creates a new metadata implementation from a map[string]string. It is
@@ -43,16 +52,30 @@ func New(m map[string]string) *Type {
 //------------------------------------------------------------------------------
 
 // Copy returns a copy of the metadata object that can be edited without
-// changing the contents of the original.
+// changing the contents of the original. The underlying map is only copied
+// once either object is edited.
 func (m *Type) Copy() types.Metadata {
-	var newMap map[string]string
-	if m.m != nil {
-		newMap = make(map[string]string, len(m.m))
-		for k, v := range m.m {
-			newMap[k] = v
-		}
+	if m.m == nil {
+		return New(nil)
+	}
+	atomic.StoreInt32(&m.shared, 1)
+	return &Type{
+		m:      m.m,
+		shared: 1,
 	}
-	return New(newMap)
+}
+
+// ensureOwned clones the underlying map if it might be shared with a copy.
+func (m *Type) ensureOwned() {
+	if atomic.LoadInt32(&m.shared) == 0 {
+		return
+	}
+	newMap := make(map[string]string, len(m.m))
+	for k, v := range m.m {
+		newMap[k] = v
+	}
+	m.m = newMap
+	atomic.StoreInt32(&m.shared, 0)
 }
 
 // Get returns a metadata value if a key exists, otherwise an empty string.
@@ -71,6 +94,7 @@ func (m *Type) Set(key, value string) types.Metadata {
 		}
 		return m
 	}
+	m.ensureOwned()
 	m.m[key] = value
 	return m
 }
@@ -80,6 +104,10 @@ func (m *Type) Delete(key string) types.Metadata {
 	if m.m == nil {
 		return m
 	}
+	if _, exists := m.m[key]; !exists {
+		return m
+	}
+	m.ensureOwned()
 	delete(m.m, key)
 	return m
 }
@@ -77,6 +77,36 @@ func TestMetadataBasic(t *testing.T) {
 	}
 }
 
+func TestMetadataCopyOnWrite(t *testing.T) {
+	m := New(map[string]string{
+		"foo":  "bar",
+		"foo2": "bar2",
+	})
+
+	copied := m.Copy().(*Type)
+	if reflect.ValueOf(m.m).Pointer() != reflect.ValueOf(copied.m).Pointer() {
+		t.Error("Expected map to be shared until modified")
+	}
+	copied2 := copied.Copy()
+
+	m.Set("foo", "changed")
+	m.Delete("foo2")
+	if exp, act := "bar", copied.Get("foo"); exp != act {
+		t.Errorf("Wrong result: %v != %v", act, exp)
+	}
+	if exp, act := "bar2", copied.Get("foo2"); exp != act {
+		t.Errorf("Wrong result: %v != %v", act, exp)
+	}
+
+	copied.Set("foo", "changed again")
+	if exp, act := "changed", m.Get("foo"); exp != act {
+		t.Errorf("Wrong result: %v != %v", act, exp)
+	}
+	if exp, act := "bar", copied2.Get("foo"); exp != act {
+		t.Errorf("Wrong result: %v != %v", act, exp)
+	}
+}
+
 func TestMetadataNil(t *testing.T) {
 	m := New(nil)
 	if exp, act := "", m.Get("foo"); exp != act {
@@ -21,16 +21,19 @@
 package message
 
 import (
-	"encoding/json"
-
 	"github.com/Jeffail/benthos/lib/message/metadata"
 	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/jsonlib"
 )
 
 //------------------------------------------------------------------------------
 
 // Part is an implementation of types.Part, containing the contents and metadata
 // of a message part.
+//
+// The payload of a part is lazily parsed as JSON and cached until the payload
+// is changed. The cached document is cloned into copies of the part, which is
+// far cheaper than parsing the payload again.
 type Part struct {
 	data      []byte
 	metadata  types.Metadata
@@ -46,7 +49,12 @@ func NewPart(data []byte) *Part {
 
 //------------------------------------------------------------------------------
 
-// Copy creates a shallow copy of the message part.
+// Copy creates a shallow copy of the message part. The payload is shared with
+// the original and the metadata is only copied once either part modifies it,
+// making copies cheap regardless of the size of the part.
+//
+// A cached JSON document is cloned into the copy, since it may be referenced
+// and modified by the holder of the original.
 func (p *Part) Copy() types.Part {
 	var clonedMeta types.Metadata
 	if p.metadata != nil {
@@ -104,7 +112,7 @@ func (p *Part) Metadata() types.Metadata {
 }
 
 // JSON attempts to parse the message part as a JSON document and returns the
-// result.
+// result. The document is cached until the part is changed.
 func (p *Part) JSON() (interface{}, error) {
 	if p.jsonCache != nil {
 		return p.jsonCache, nil
@@ -112,7 +120,7 @@ func (p *Part) JSON() (interface{}, error) {
 	if p.data == nil {
 		return nil, ErrMessagePartNotExist
 	}
-	if err := json.Unmarshal(p.data, &p.jsonCache); err != nil {
+	if err := jsonlib.Unmarshal(p.data, &p.jsonCache); err != nil {
 		return nil, err
 	}
 	return p.jsonCache, nil
@@ -134,7 +142,7 @@ func (p *Part) SetMetadata(meta types.Metadata) types.Part {
 // SetJSON attempts to marshal a JSON document into a byte slice and stores the
 // result as the contents of the message part.
 func (p *Part) SetJSON(jObj interface{}) error {
-	partBytes, err := json.Marshal(jObj)
+	partBytes, err := jsonlib.Marshal(jObj)
 	if err != nil {
 		return err
 	}
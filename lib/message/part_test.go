@@ -120,6 +120,46 @@ func TestPartShallowCopy(t *testing.T) {
 	}
 }
 
+func TestPartCopyOnWrite(t *testing.T) {
+	p := NewPart([]byte(`{"hello":"world"}`))
+	p.Metadata().Set("foo", "bar")
+
+	jObj, err := p.JSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p2 := p.Copy()
+	if &p.Get()[0] != &p2.Get()[0] {
+		t.Error("Expected payload to be shared after copy")
+	}
+	if p2.(*Part).jsonCache == nil {
+		t.Error("Expected json document to be carried over after copy")
+	}
+
+	jObj.(map[string]interface{})["hello"] = "mutated"
+	p.Metadata().Set("foo", "changed").Set("baz", "qux")
+
+	p2JSON, err := p2.JSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp, act := map[string]interface{}{"hello": "world"}, p2JSON; !reflect.DeepEqual(exp, act) {
+		t.Errorf("Copy json changed by original: %v != %v", act, exp)
+	}
+	if exp, act := "bar", p2.Metadata().Get("foo"); exp != act {
+		t.Errorf("Copy metadata changed by original: %v != %v", act, exp)
+	}
+	if exp, act := "", p2.Metadata().Get("baz"); exp != act {
+		t.Errorf("Copy metadata changed by original: %v != %v", act, exp)
+	}
+
+	p2.Metadata().Delete("foo")
+	if exp, act := "changed", p.Metadata().Get("foo"); exp != act {
+		t.Errorf("Original metadata changed by copy: %v != %v", act, exp)
+	}
+}
+
 func TestPartDeepCopy(t *testing.T) {
 	p := NewPart([]byte(`{"hello":"world"}`))
 	p.Metadata().
@@ -0,0 +1,454 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package metrics
+
+import (
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/log"
+	sess "github.com/Jeffail/benthos/lib/util/aws/session"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/aws/aws-sdk-go/service/cloudwatch/cloudwatchiface"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	constructors[TypeCloudWatch] = typeSpec{
+		constructor: NewCloudWatch,
+		description: `
+Send metrics to AWS CloudWatch using the PutMetricData endpoint.
+
+Metrics are aggregated in memory and pushed under the namespace
+` + "`namespace`" + ` each ` + "`flush_period`" + `, in batches of up to
+20 metrics per request. Counters are sent as the sum of increments within the
+period, gauges as their most recent value and timings as a statistic set
+measured in microseconds.
+
+The labels of a metric, along with the field ` + "`dimensions`" + `, are sent
+as CloudWatch dimensions. CloudWatch only supports up to ten dimensions per
+metric, and any beyond that limit are dropped.`,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// CloudWatchCredentialsConfig contains configuration params for AWS
+// credentials.
+type CloudWatchCredentialsConfig = sess.CredentialsConfig
+
+// CloudWatchConfig contains config fields for the CloudWatch metrics type.
+type CloudWatchConfig struct {
+	sess.Config `json:",inline" yaml:",inline"`
+	Namespace   string            `json:"namespace" yaml:"namespace"`
+	FlushPeriod string            `json:"flush_period" yaml:"flush_period"`
+	Dimensions  map[string]string `json:"dimensions" yaml:"dimensions"`
+}
+
+// NewCloudWatchConfig creates an CloudWatchConfig struct with default values.
+func NewCloudWatchConfig() CloudWatchConfig {
+	return CloudWatchConfig{
+		Config:      sess.NewConfig(),
+		Namespace:   "Benthos",
+		FlushPeriod: "100ms",
+		Dimensions:  map[string]string{},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+const (
+	cloudWatchMaxDatums     = 20
+	cloudWatchMaxDimensions = 10
+)
+
+type cloudWatchKind int
+
+const (
+	cloudWatchCounter cloudWatchKind = iota
+	cloudWatchGauge
+	cloudWatchTiming
+)
+
+// cloudWatchDatum aggregates the values of a single metric within a flush
+// period.
+type cloudWatchDatum struct {
+	kind       cloudWatchKind
+	name       string
+	dimensions []*cloudwatch.Dimension
+
+	value float64
+	dirty bool
+
+	min, max, sum, count float64
+}
+
+// CloudWatchStat is a representation of a single metric stat. Interactions
+// with this stat are thread safe.
+type CloudWatchStat struct {
+	key string
+	c   *CloudWatch
+}
+
+// Incr increments a metric by an amount.
+func (c *CloudWatchStat) Incr(count int64) error {
+	c.c.update(c.key, func(d *cloudWatchDatum) {
+		d.value += float64(count)
+	})
+	return nil
+}
+
+// Decr decrements a metric by an amount.
+func (c *CloudWatchStat) Decr(count int64) error {
+	c.c.update(c.key, func(d *cloudWatchDatum) {
+		d.value -= float64(count)
+	})
+	return nil
+}
+
+// Timing sets a timing metric, the value is given in nanoseconds and recorded
+// in microseconds.
+func (c *CloudWatchStat) Timing(delta int64) error {
+	v := float64(delta) / 1e3
+	c.c.update(c.key, func(d *cloudWatchDatum) {
+		if d.count == 0 || v < d.min {
+			d.min = v
+		}
+		if d.count == 0 || v > d.max {
+			d.max = v
+		}
+		d.sum += v
+		d.count++
+	})
+	return nil
+}
+
+// Set sets a gauge metric.
+func (c *CloudWatchStat) Set(value int64) error {
+	c.c.update(c.key, func(d *cloudWatchDatum) {
+		d.value = float64(value)
+	})
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+type cloudWatchCounterVec struct {
+	path   string
+	labels []string
+	c      *CloudWatch
+}
+
+func (c *cloudWatchCounterVec) With(labelValues ...string) StatCounter {
+	return c.c.getStat(cloudWatchCounter, c.path, c.labels, labelValues)
+}
+
+type cloudWatchTimerVec struct {
+	path   string
+	labels []string
+	c      *CloudWatch
+}
+
+func (c *cloudWatchTimerVec) With(labelValues ...string) StatTimer {
+	return c.c.getStat(cloudWatchTiming, c.path, c.labels, labelValues)
+}
+
+type cloudWatchGaugeVec struct {
+	path   string
+	labels []string
+	c      *CloudWatch
+}
+
+func (c *cloudWatchGaugeVec) With(labelValues ...string) StatGauge {
+	return c.c.getStat(cloudWatchGauge, c.path, c.labels, labelValues)
+}
+
+//------------------------------------------------------------------------------
+
+// CloudWatch is a stats object that pushes aggregated metrics to AWS
+// CloudWatch.
+type CloudWatch struct {
+	client      cloudwatchiface.CloudWatchAPI
+	namespace   string
+	prefix      string
+	flushPeriod time.Duration
+
+	dimNames  []string
+	dimValues []string
+
+	datums   map[string]*cloudWatchDatum
+	datumMut sync.Mutex
+
+	log log.Modular
+
+	closeOnce  sync.Once
+	closeChan  chan struct{}
+	closedChan chan struct{}
+}
+
+// NewCloudWatch creates and returns a new CloudWatch object.
+func NewCloudWatch(config Config, opts ...func(Type)) (Type, error) {
+	sess, err := config.CloudWatch.GetSession()
+	if err != nil {
+		return nil, err
+	}
+
+	return newCloudWatch(cloudwatch.New(sess), config, opts...)
+}
+
+func newCloudWatch(client cloudwatchiface.CloudWatchAPI, config Config, opts ...func(Type)) (*CloudWatch, error) {
+	flushPeriod, err := time.ParseDuration(config.CloudWatch.FlushPeriod)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse flush period: %s", err)
+	}
+	if flushPeriod <= 0 {
+		return nil, fmt.Errorf("flush period must be greater than zero: %v", flushPeriod)
+	}
+
+	prefix := config.Prefix
+	if len(prefix) > 0 && prefix[len(prefix)-1] != '.' {
+		prefix = prefix + "."
+	}
+
+	c := &CloudWatch{
+		client:      client,
+		namespace:   config.CloudWatch.Namespace,
+		prefix:      prefix,
+		flushPeriod: flushPeriod,
+		datums:      map[string]*cloudWatchDatum{},
+		log:         log.New(ioutil.Discard, log.Config{LogLevel: "OFF"}),
+		closeChan:   make(chan struct{}),
+		closedChan:  make(chan struct{}),
+	}
+
+	for k := range config.CloudWatch.Dimensions {
+		c.dimNames = append(c.dimNames, k)
+	}
+	sort.Strings(c.dimNames)
+	for _, k := range c.dimNames {
+		c.dimValues = append(c.dimValues, config.CloudWatch.Dimensions[k])
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	go c.loop()
+	return c, nil
+}
+
+//------------------------------------------------------------------------------
+
+// getStat returns a stat for a path and set of labels, registering a datum for
+// it if one does not already exist.
+func (c *CloudWatch) getStat(kind cloudWatchKind, path string, labelNames, labelValues []string) *CloudWatchStat {
+	names := append(append([]string{}, c.dimNames...), labelNames...)
+	values := append(append([]string{}, c.dimValues...), labelValues...)
+
+	var dimensions []*cloudwatch.Dimension
+	keyParts := []string{c.prefix + path}
+	for i, n := range names {
+		if i >= len(values) {
+			break
+		}
+		if len(dimensions) >= cloudWatchMaxDimensions {
+			break
+		}
+		dimensions = append(dimensions, &cloudwatch.Dimension{
+			Name:  aws.String(n),
+			Value: aws.String(values[i]),
+		})
+		keyParts = append(keyParts, n+"="+values[i])
+	}
+	key := strings.Join(keyParts, "\x00")
+
+	c.datumMut.Lock()
+	if _, exists := c.datums[key]; !exists {
+		c.datums[key] = &cloudWatchDatum{
+			kind:       kind,
+			name:       c.prefix + path,
+			dimensions: dimensions,
+		}
+	}
+	c.datumMut.Unlock()
+
+	return &CloudWatchStat{
+		key: key,
+		c:   c,
+	}
+}
+
+func (c *CloudWatch) update(key string, fn func(d *cloudWatchDatum)) {
+	c.datumMut.Lock()
+	if d, exists := c.datums[key]; exists {
+		fn(d)
+		d.dirty = true
+	}
+	c.datumMut.Unlock()
+}
+
+// collect returns the metric data that have changed since the last call, along
+// with the current value of all gauges, and resets counters and timings.
+func (c *CloudWatch) collect() []*cloudwatch.MetricDatum {
+	now := time.Now()
+
+	c.datumMut.Lock()
+	defer c.datumMut.Unlock()
+
+	data := []*cloudwatch.MetricDatum{}
+	for _, d := range c.datums {
+		if !d.dirty && d.kind != cloudWatchGauge {
+			continue
+		}
+		datum := &cloudwatch.MetricDatum{
+			MetricName: aws.String(d.name),
+			Dimensions: d.dimensions,
+			Timestamp:  aws.Time(now),
+		}
+		switch d.kind {
+		case cloudWatchCounter:
+			datum.Unit = aws.String(cloudwatch.StandardUnitCount)
+			datum.Value = aws.Float64(d.value)
+			d.value = 0
+		case cloudWatchGauge:
+			datum.Unit = aws.String(cloudwatch.StandardUnitNone)
+			datum.Value = aws.Float64(d.value)
+		case cloudWatchTiming:
+			datum.Unit = aws.String(cloudwatch.StandardUnitMicroseconds)
+			datum.StatisticValues = &cloudwatch.StatisticSet{
+				Maximum:     aws.Float64(d.max),
+				Minimum:     aws.Float64(d.min),
+				SampleCount: aws.Float64(d.count),
+				Sum:         aws.Float64(d.sum),
+			}
+			d.min, d.max, d.sum, d.count = 0, 0, 0, 0
+		}
+		d.dirty = false
+		data = append(data, datum)
+	}
+	return data
+}
+
+// flush sends all collected metrics to CloudWatch in batches.
+func (c *CloudWatch) flush() {
+	data := c.collect()
+	for len(data) > 0 {
+		batch := data
+		if len(batch) > cloudWatchMaxDatums {
+			batch = data[:cloudWatchMaxDatums]
+		}
+		data = data[len(batch):]
+
+		if _, err := c.client.PutMetricData(&cloudwatch.PutMetricDataInput{
+			Namespace:  aws.String(c.namespace),
+			MetricData: batch,
+		}); err != nil {
+			c.log.Errorf("Failed to send metrics: %v\n", err)
+		}
+	}
+}
+
+func (c *CloudWatch) loop() {
+	defer close(c.closedChan)
+
+	ticker := time.NewTicker(c.flushPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			c.flush()
+		case <-c.closeChan:
+			c.flush()
+			return
+		}
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// GetCounter returns a stat counter object for a path.
+func (c *CloudWatch) GetCounter(path string) StatCounter {
+	return c.getStat(cloudWatchCounter, path, nil, nil)
+}
+
+// GetCounterVec returns a stat counter object for a path with the labels
+// provided as dimensions.
+func (c *CloudWatch) GetCounterVec(path string, n []string) StatCounterVec {
+	return &cloudWatchCounterVec{
+		path:   path,
+		labels: n,
+		c:      c,
+	}
+}
+
+// GetTimer returns a stat timer object for a path.
+func (c *CloudWatch) GetTimer(path string) StatTimer {
+	return c.getStat(cloudWatchTiming, path, nil, nil)
+}
+
+// GetTimerVec returns a stat timer object for a path with the labels provided
+// as dimensions.
+func (c *CloudWatch) GetTimerVec(path string, n []string) StatTimerVec {
+	return &cloudWatchTimerVec{
+		path:   path,
+		labels: n,
+		c:      c,
+	}
+}
+
+// GetGauge returns a stat gauge object for a path.
+func (c *CloudWatch) GetGauge(path string) StatGauge {
+	return c.getStat(cloudWatchGauge, path, nil, nil)
+}
+
+// GetGaugeVec returns a stat gauge object for a path with the labels provided
+// as dimensions.
+func (c *CloudWatch) GetGaugeVec(path string, n []string) StatGaugeVec {
+	return &cloudWatchGaugeVec{
+		path:   path,
+		labels: n,
+		c:      c,
+	}
+}
+
+// SetLogger sets the logger used to print connection errors.
+func (c *CloudWatch) SetLogger(log log.Modular) {
+	c.log = log.NewModule(".cloudwatch")
+}
+
+// Close stops the CloudWatch object from aggregating metrics, flushes any
+// remaining metrics and cleans up resources.
+func (c *CloudWatch) Close() error {
+	c.closeOnce.Do(func() {
+		close(c.closeChan)
+	})
+	<-c.closedChan
+	return nil
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,175 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package metrics
+
+import (
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/aws/aws-sdk-go/service/cloudwatch/cloudwatchiface"
+)
+
+type mockCloudWatch struct {
+	cloudwatchiface.CloudWatchAPI
+
+	inputs []*cloudwatch.PutMetricDataInput
+	sync.Mutex
+}
+
+func (m *mockCloudWatch) PutMetricData(in *cloudwatch.PutMetricDataInput) (*cloudwatch.PutMetricDataOutput, error) {
+	m.Lock()
+	m.inputs = append(m.inputs, in)
+	m.Unlock()
+	return &cloudwatch.PutMetricDataOutput{}, nil
+}
+
+func TestCloudWatchInterface(t *testing.T) {
+	c := &CloudWatch{}
+	if Type(c) == nil {
+		t.Errorf("Type does not satisfy Type interface.")
+	}
+}
+
+func TestCloudWatchFlush(t *testing.T) {
+	mock := &mockCloudWatch{}
+
+	conf := NewConfig()
+	conf.CloudWatch.FlushPeriod = "1h"
+	conf.CloudWatch.Dimensions = map[string]string{"env": "prod"}
+
+	c, err := newCloudWatch(mock, conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c.GetCounter("foo.count").Incr(2)
+	c.GetCounter("foo.count").Incr(3)
+	c.GetGauge("foo.gauge").Set(10)
+	c.GetTimer("foo.latency").Timing(1000)
+	c.GetTimer("foo.latency").Timing(3000)
+	c.GetCounterVec("foo.vec", []string{"status"}).With("ok").Incr(1)
+	c.GetCounter("foo.unused")
+
+	c.flush()
+
+	mock.Lock()
+	if exp, act := 1, len(mock.inputs); exp != act {
+		t.Fatalf("Wrong count of requests: %v != %v", act, exp)
+	}
+	data := map[string]*cloudwatch.MetricDatum{}
+	for _, d := range mock.inputs[0].MetricData {
+		data[aws.StringValue(d.MetricName)] = d
+	}
+	if exp, act := "Benthos", aws.StringValue(mock.inputs[0].Namespace); exp != act {
+		t.Errorf("Wrong namespace: %v != %v", act, exp)
+	}
+	mock.inputs = nil
+	mock.Unlock()
+
+	names := []string{}
+	for k := range data {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	if exp, act := []string{
+		"benthos.foo.count", "benthos.foo.gauge", "benthos.foo.latency", "benthos.foo.vec",
+	}, names; len(exp) != len(act) || exp[0] != act[0] || exp[1] != act[1] || exp[2] != act[2] || exp[3] != act[3] {
+		t.Fatalf("Wrong metrics: %v != %v", act, exp)
+	}
+
+	if exp, act := 5.0, aws.Float64Value(data["benthos.foo.count"].Value); exp != act {
+		t.Errorf("Wrong counter value: %v != %v", act, exp)
+	}
+	if exp, act := 10.0, aws.Float64Value(data["benthos.foo.gauge"].Value); exp != act {
+		t.Errorf("Wrong gauge value: %v != %v", act, exp)
+	}
+	stats := data["benthos.foo.latency"].StatisticValues
+	if exp, act := 2.0, aws.Float64Value(stats.SampleCount); exp != act {
+		t.Errorf("Wrong sample count: %v != %v", act, exp)
+	}
+	if exp, act := 4.0, aws.Float64Value(stats.Sum); exp != act {
+		t.Errorf("Wrong sum: %v != %v", act, exp)
+	}
+	if exp, act := 1.0, aws.Float64Value(stats.Minimum); exp != act {
+		t.Errorf("Wrong min: %v != %v", act, exp)
+	}
+	if exp, act := 3.0, aws.Float64Value(stats.Maximum); exp != act {
+		t.Errorf("Wrong max: %v != %v", act, exp)
+	}
+
+	dims := map[string]string{}
+	for _, d := range data["benthos.foo.vec"].Dimensions {
+		dims[aws.StringValue(d.Name)] = aws.StringValue(d.Value)
+	}
+	if exp, act := 2, len(dims); exp != act || dims["env"] != "prod" || dims["status"] != "ok" {
+		t.Errorf("Wrong dimensions: %v", dims)
+	}
+
+	// Only gauges are sent when nothing has changed.
+	c.flush()
+	mock.Lock()
+	if exp, act := 1, len(mock.inputs); exp != act {
+		t.Fatalf("Wrong count of requests: %v != %v", act, exp)
+	}
+	if exp, act := 1, len(mock.inputs[0].MetricData); exp != act {
+		t.Errorf("Wrong count of metrics: %v != %v", act, exp)
+	}
+	mock.Unlock()
+
+	if err = c.Close(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCloudWatchBatching(t *testing.T) {
+	mock := &mockCloudWatch{}
+
+	conf := NewConfig()
+	conf.CloudWatch.FlushPeriod = "1h"
+
+	c, err := newCloudWatch(mock, conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	vec := c.GetCounterVec("foo", []string{"index"})
+	for i := 0; i < 45; i++ {
+		vec.With(string('a' + rune(i))).Incr(1)
+	}
+
+	if err = c.Close(); err != nil {
+		t.Error(err)
+	}
+
+	mock.Lock()
+	defer mock.Unlock()
+
+	sizes := []int{}
+	for _, in := range mock.inputs {
+		sizes = append(sizes, len(in.MetricData))
+	}
+	if exp, act := []int{20, 20, 5}, sizes; len(act) != 3 || act[0] != exp[0] || act[1] != exp[1] || act[2] != exp[2] {
+		t.Errorf("Wrong batch sizes: %v != %v", act, exp)
+	}
+}
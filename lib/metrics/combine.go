@@ -92,6 +92,18 @@ func (c *combinedGauge) Decr(count int64) error {
 	return c.c2.Decr(count)
 }
 
+type combinedHistogram struct {
+	c1 StatHistogram
+	c2 StatHistogram
+}
+
+func (c *combinedHistogram) Observe(value int64) error {
+	if err := c.c1.Observe(value); err != nil {
+		return err
+	}
+	return c.c2.Observe(value)
+}
+
 //------------------------------------------------------------------------------
 
 type combinedCounterVec struct {
@@ -130,6 +142,18 @@ func (c *combinedGaugeVec) With(labelValues ...string) StatGauge {
 	}
 }
 
+type combinedHistogramVec struct {
+	c1 StatHistogramVec
+	c2 StatHistogramVec
+}
+
+func (c *combinedHistogramVec) With(labelValues ...string) StatHistogram {
+	return &combinedHistogram{
+		c1: c.c1.With(labelValues...),
+		c2: c.c2.With(labelValues...),
+	}
+}
+
 //------------------------------------------------------------------------------
 
 func (c *combinedWrapper) GetCounter(path string) StatCounter {
@@ -174,6 +198,13 @@ func (c *combinedWrapper) GetGaugeVec(path string, n []string) StatGaugeVec {
 	}
 }
 
+func (c *combinedWrapper) GetHistogramVec(path string, n []string) StatHistogramVec {
+	return &combinedHistogramVec{
+		c1: GetHistogramVec(c.t1, path, n),
+		c2: GetHistogramVec(c.t2, path, n),
+	}
+}
+
 func (c *combinedWrapper) SetLogger(log log.Modular) {
 	c.t1.SetLogger(log)
 	c.t2.SetLogger(log)
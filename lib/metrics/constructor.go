@@ -52,9 +52,12 @@ var constructors = map[string]typeSpec{}
 
 // String constants representing each metric type.
 const (
-	TypeHTTPServer = "http_server"
-	TypePrometheus = "prometheus"
-	TypeStatsd     = "statsd"
+	TypeCloudWatch    = "cloudwatch"
+	TypeHTTPServer    = "http_server"
+	TypeInfluxDB      = "influxdb"
+	TypeOpenTelemetry = "open_telemetry"
+	TypePrometheus    = "prometheus"
+	TypeStatsd        = "statsd"
 )
 
 //------------------------------------------------------------------------------
@@ -62,21 +65,29 @@ const (
 // Config is the all encompassing configuration struct for all metric output
 // types.
 type Config struct {
-	Type       string       `json:"type" yaml:"type"`
-	Prefix     string       `json:"prefix" yaml:"prefix"`
-	HTTP       struct{}     `json:"http_server" yaml:"http_server"`
-	Prometheus struct{}     `json:"prometheus" yaml:"prometheus"`
-	Statsd     StatsdConfig `json:"statsd" yaml:"statsd"`
+	Type          string              `json:"type" yaml:"type"`
+	Prefix        string              `json:"prefix" yaml:"prefix"`
+	Mapping       MappingConfig       `json:"mapping" yaml:"mapping"`
+	CloudWatch    CloudWatchConfig    `json:"cloudwatch" yaml:"cloudwatch"`
+	HTTP          struct{}            `json:"http_server" yaml:"http_server"`
+	InfluxDB      InfluxDBConfig      `json:"influxdb" yaml:"influxdb"`
+	OpenTelemetry OpenTelemetryConfig `json:"open_telemetry" yaml:"open_telemetry"`
+	Prometheus    PrometheusConfig    `json:"prometheus" yaml:"prometheus"`
+	Statsd        StatsdConfig        `json:"statsd" yaml:"statsd"`
 }
 
 // NewConfig returns a configuration struct fully populated with default values.
 func NewConfig() Config {
 	return Config{
-		Type:       "http_server",
-		Prefix:     "benthos",
-		HTTP:       struct{}{},
-		Prometheus: struct{}{},
-		Statsd:     NewStatsdConfig(),
+		Type:          "http_server",
+		Prefix:        "benthos",
+		Mapping:       NewMappingConfig(),
+		CloudWatch:    NewCloudWatchConfig(),
+		HTTP:          struct{}{},
+		InfluxDB:      NewInfluxDBConfig(),
+		OpenTelemetry: NewOpenTelemetryConfig(),
+		Prometheus:    NewPrometheusConfig(),
+		Statsd:        NewStatsdConfig(),
 	}
 }
 
@@ -96,6 +107,9 @@ func SanitiseConfig(conf Config) (interface{}, error) {
 	outputMap := map[string]interface{}{}
 	outputMap["type"] = hashMap["type"]
 	outputMap[conf.Type] = hashMap[conf.Type]
+	if !conf.Mapping.IsNoop() {
+		outputMap["mapping"] = hashMap["mapping"]
+	}
 
 	return outputMap, nil
 }
@@ -146,10 +160,20 @@ func New(conf Config, opts ...func(Type)) (Type, error) {
 	if conf.Type == "none" {
 		return DudType{}, nil
 	}
-	if c, ok := constructors[conf.Type]; ok {
-		return c.constructor(conf, opts...)
+	c, ok := constructors[conf.Type]
+	if !ok {
+		return nil, ErrInvalidMetricOutputType
+	}
+	t, err := c.constructor(conf, opts...)
+	if err != nil || conf.Mapping.IsNoop() {
+		return t, err
+	}
+	mapped, err := Mapped(t, conf.Mapping)
+	if err != nil {
+		t.Close()
+		return nil, err
 	}
-	return nil, ErrInvalidMetricOutputType
+	return mapped, nil
 }
 
 //------------------------------------------------------------------------------
@@ -48,6 +48,17 @@ func TestSanitise(t *testing.T) {
 			"address":      "foo",
 			"flush_period": "100ms",
 			"network":      "udp",
+			"tag_format":   "none",
+			"tags":         map[string]interface{}{},
+			"tls": map[string]interface{}{
+				"enabled":          false,
+				"root_cas_file":    "",
+				"root_cas":         "",
+				"skip_cert_verify": false,
+				"min_version":      "",
+				"server_name":      "",
+				"client_certs":     []interface{}{},
+			},
 		},
 	}
 
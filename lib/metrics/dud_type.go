@@ -39,6 +39,9 @@ func (d DudStat) Timing(delta int64) error { return nil }
 // Set does nothing.
 func (d DudStat) Set(value int64) error { return nil }
 
+// Observe does nothing.
+func (d DudStat) Observe(value int64) error { return nil }
+
 //------------------------------------------------------------------------------
 
 // DudType implements the Type interface but doesn't actual do anything.
@@ -81,6 +84,13 @@ func (d DudType) GetGaugeVec(path string, n []string) StatGaugeVec {
 	})
 }
 
+// GetHistogramVec returns a DudStat.
+func (d DudType) GetHistogramVec(path string, n []string) StatHistogramVec {
+	return fakeHistogramVec(func() StatHistogram {
+		return DudStat{}
+	})
+}
+
 // SetLogger does nothing.
 func (d DudType) SetLogger(log log.Modular) {}
 
@@ -0,0 +1,86 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package metrics
+
+//------------------------------------------------------------------------------
+
+// StatHistogram is a representation of a single histogram metric stat, which
+// records the distribution of unitless values such as batch sizes.
+// Interactions with this stat are thread safe.
+type StatHistogram interface {
+	// Observe records a value.
+	Observe(value int64) error
+}
+
+// StatHistogramVec creates StatHistograms with dynamic labels.
+type StatHistogramVec interface {
+	// With returns a StatHistogram with a set of label values.
+	With(labelValues ...string) StatHistogram
+}
+
+// WithHistograms is an interface for metrics types that support histograms of
+// unitless values natively. Types that do not implement it have histograms
+// recorded as timers instead, see GetHistogram.
+type WithHistograms interface {
+	// GetHistogramVec returns an editable histogram stat for a given path with
+	// labels, these labels must be consistent with any other metrics
+	// registered on the same path.
+	GetHistogramVec(path string, labelNames []string) StatHistogramVec
+}
+
+//------------------------------------------------------------------------------
+
+// GetHistogram returns an editable histogram stat for a given path. If the
+// metrics type does not support histograms natively then values are recorded
+// with a timer of the same path.
+func GetHistogram(t Type, path string) StatHistogram {
+	return GetHistogramVec(t, path, nil).With()
+}
+
+// GetHistogramVec returns an editable histogram stat for a given path with
+// labels. If the metrics type does not support histograms natively then values
+// are recorded with a timer of the same path.
+func GetHistogramVec(t Type, path string, labelNames []string) StatHistogramVec {
+	if h, ok := t.(WithHistograms); ok {
+		return h.GetHistogramVec(path, labelNames)
+	}
+	return &timerHistogramVec{vec: t.GetTimerVec(path, labelNames)}
+}
+
+//------------------------------------------------------------------------------
+
+type timerHistogram struct {
+	t StatTimer
+}
+
+func (t *timerHistogram) Observe(value int64) error {
+	return t.t.Timing(value)
+}
+
+type timerHistogramVec struct {
+	vec StatTimerVec
+}
+
+func (t *timerHistogramVec) With(labelValues ...string) StatHistogram {
+	return &timerHistogram{t: t.vec.With(labelValues...)}
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,43 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package metrics
+
+import "testing"
+
+func TestHistogramFallback(t *testing.T) {
+	local := NewLocal()
+
+	GetHistogram(Namespaced(local, "foo"), "bar").Observe(5)
+	if exp, act := int64(5), local.GetTimings()["foo.bar"]; exp != act {
+		t.Errorf("Wrong fallback timing: %v != %v", act, exp)
+	}
+
+	local2 := NewLocal()
+	GetHistogram(Combine(local, local2), "baz").Observe(7)
+	if exp, act := int64(7), local.GetTimings()["baz"]; exp != act {
+		t.Errorf("Wrong combined timing: %v != %v", act, exp)
+	}
+	if exp, act := int64(7), local2.GetTimings()["baz"]; exp != act {
+		t.Errorf("Wrong combined timing: %v != %v", act, exp)
+	}
+
+	GetHistogram(DudType{}, "qux").Observe(1)
+}
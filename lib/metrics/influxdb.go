@@ -0,0 +1,500 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package metrics
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/log"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	constructors[TypeInfluxDB] = typeSpec{
+		constructor: NewInfluxDB,
+		description: `
+Send metrics to InfluxDB using the line protocol over HTTP.
+
+The field ` + "`api`" + ` determines which write API is used. With
+` + "`v1`" + ` metrics are written to the database ` + "`db`" + ` and the
+optional retention policy ` + "`retention_policy`" + `, authenticating with
+` + "`username`" + ` and ` + "`password`" + ` when set. With ` + "`v2`" + `
+metrics are written to ` + "`bucket`" + ` within ` + "`org`" + `,
+authenticating with ` + "`token`" + `.
+
+Each metric is written as a measurement named after its path. Counters and
+gauges are written with the field ` + "`value`" + `, where counters are the
+running total since Benthos started. Timings are written with the fields
+` + "`count`" + `, ` + "`sum`" + `, ` + "`min`" + `, ` + "`max`" + ` and
+` + "`mean`" + `, measured in nanoseconds over each ` + "`flush_period`" + `.
+
+The labels of a metric, along with the field ` + "`tags`" + `, are written as
+tags.`,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// InfluxDBConfig is config for the InfluxDB metrics type.
+type InfluxDBConfig struct {
+	URL             string            `json:"url" yaml:"url"`
+	API             string            `json:"api" yaml:"api"`
+	DB              string            `json:"db" yaml:"db"`
+	RetentionPolicy string            `json:"retention_policy" yaml:"retention_policy"`
+	Username        string            `json:"username" yaml:"username"`
+	Password        string            `json:"password" yaml:"password"`
+	Org             string            `json:"org" yaml:"org"`
+	Bucket          string            `json:"bucket" yaml:"bucket"`
+	Token           string            `json:"token" yaml:"token"`
+	FlushPeriod     string            `json:"flush_period" yaml:"flush_period"`
+	Timeout         string            `json:"timeout" yaml:"timeout"`
+	Tags            map[string]string `json:"tags" yaml:"tags"`
+}
+
+// NewInfluxDBConfig creates an InfluxDBConfig struct with default values.
+func NewInfluxDBConfig() InfluxDBConfig {
+	return InfluxDBConfig{
+		URL:             "http://localhost:8086",
+		API:             "v1",
+		DB:              "benthos",
+		RetentionPolicy: "",
+		Username:        "",
+		Password:        "",
+		Org:             "",
+		Bucket:          "",
+		Token:           "",
+		FlushPeriod:     "1s",
+		Timeout:         "5s",
+		Tags:            map[string]string{},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+var (
+	influxMeasurementEscaper = strings.NewReplacer(",", `\,`, " ", `\ `)
+	influxTagEscaper         = strings.NewReplacer(",", `\,`, "=", `\=`, " ", `\ `)
+)
+
+type influxKind int
+
+const (
+	influxCounter influxKind = iota
+	influxGauge
+	influxTiming
+)
+
+// influxSeries aggregates the values of a single series between flushes.
+type influxSeries struct {
+	kind influxKind
+	key  string
+
+	value int64
+
+	count, sum, min, max int64
+}
+
+// InfluxDBStat is a representation of a single metric stat. Interactions with
+// this stat are thread safe.
+type InfluxDBStat struct {
+	s *influxSeries
+	i *InfluxDB
+}
+
+// Incr increments a metric by an amount.
+func (s *InfluxDBStat) Incr(count int64) error {
+	s.i.seriesMut.Lock()
+	s.s.value += count
+	s.i.seriesMut.Unlock()
+	return nil
+}
+
+// Decr decrements a metric by an amount.
+func (s *InfluxDBStat) Decr(count int64) error {
+	s.i.seriesMut.Lock()
+	s.s.value -= count
+	s.i.seriesMut.Unlock()
+	return nil
+}
+
+// Timing sets a timing metric.
+func (s *InfluxDBStat) Timing(delta int64) error {
+	s.i.seriesMut.Lock()
+	if s.s.count == 0 || delta < s.s.min {
+		s.s.min = delta
+	}
+	if s.s.count == 0 || delta > s.s.max {
+		s.s.max = delta
+	}
+	s.s.sum += delta
+	s.s.count++
+	s.i.seriesMut.Unlock()
+	return nil
+}
+
+// Set sets a gauge metric.
+func (s *InfluxDBStat) Set(value int64) error {
+	s.i.seriesMut.Lock()
+	s.s.value = value
+	s.i.seriesMut.Unlock()
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+type influxCounterVec struct {
+	path   string
+	labels []string
+	i      *InfluxDB
+}
+
+func (v *influxCounterVec) With(labelValues ...string) StatCounter {
+	return v.i.getStat(influxCounter, v.path, v.labels, labelValues)
+}
+
+type influxTimerVec struct {
+	path   string
+	labels []string
+	i      *InfluxDB
+}
+
+func (v *influxTimerVec) With(labelValues ...string) StatTimer {
+	return v.i.getStat(influxTiming, v.path, v.labels, labelValues)
+}
+
+type influxGaugeVec struct {
+	path   string
+	labels []string
+	i      *InfluxDB
+}
+
+func (v *influxGaugeVec) With(labelValues ...string) StatGauge {
+	return v.i.getStat(influxGauge, v.path, v.labels, labelValues)
+}
+
+//------------------------------------------------------------------------------
+
+// InfluxDB is a stats object that periodically writes metrics to InfluxDB.
+type InfluxDB struct {
+	writeURL    string
+	conf        InfluxDBConfig
+	prefix      string
+	client      http.Client
+	flushPeriod time.Duration
+
+	tagNames  []string
+	tagValues []string
+
+	series    map[string]*influxSeries
+	seriesMut sync.Mutex
+
+	log log.Modular
+
+	closeOnce  sync.Once
+	closeChan  chan struct{}
+	closedChan chan struct{}
+}
+
+// NewInfluxDB creates and returns a new InfluxDB object.
+func NewInfluxDB(config Config, opts ...func(Type)) (Type, error) {
+	flushPeriod, err := time.ParseDuration(config.InfluxDB.FlushPeriod)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse flush period: %s", err)
+	}
+	if flushPeriod <= 0 {
+		return nil, fmt.Errorf("flush period must be greater than zero: %v", flushPeriod)
+	}
+	timeout, err := time.ParseDuration(config.InfluxDB.Timeout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse timeout: %s", err)
+	}
+
+	writeURL, err := influxWriteURL(config.InfluxDB)
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := config.Prefix
+	if len(prefix) > 0 && prefix[len(prefix)-1] != '.' {
+		prefix = prefix + "."
+	}
+
+	i := &InfluxDB{
+		writeURL:    writeURL,
+		conf:        config.InfluxDB,
+		prefix:      prefix,
+		client:      http.Client{Timeout: timeout},
+		flushPeriod: flushPeriod,
+		series:      map[string]*influxSeries{},
+		log:         log.New(ioutil.Discard, log.Config{LogLevel: "OFF"}),
+		closeChan:   make(chan struct{}),
+		closedChan:  make(chan struct{}),
+	}
+
+	for k := range config.InfluxDB.Tags {
+		i.tagNames = append(i.tagNames, k)
+	}
+	sort.Strings(i.tagNames)
+	for _, k := range i.tagNames {
+		i.tagValues = append(i.tagValues, config.InfluxDB.Tags[k])
+	}
+
+	for _, opt := range opts {
+		opt(i)
+	}
+
+	go i.loop()
+	return i, nil
+}
+
+func influxWriteURL(conf InfluxDBConfig) (string, error) {
+	u, err := url.Parse(conf.URL)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse url: %v", err)
+	}
+	query := url.Values{}
+	switch conf.API {
+	case "v1":
+		if len(conf.DB) == 0 {
+			return "", errors.New("a db must be specified for the v1 api")
+		}
+		u.Path = strings.TrimSuffix(u.Path, "/") + "/write"
+		query.Set("db", conf.DB)
+		if len(conf.RetentionPolicy) > 0 {
+			query.Set("rp", conf.RetentionPolicy)
+		}
+	case "v2":
+		if len(conf.Org) == 0 || len(conf.Bucket) == 0 {
+			return "", errors.New("an org and bucket must be specified for the v2 api")
+		}
+		u.Path = strings.TrimSuffix(u.Path, "/") + "/api/v2/write"
+		query.Set("org", conf.Org)
+		query.Set("bucket", conf.Bucket)
+	default:
+		return "", fmt.Errorf("api version not recognised: %v", conf.API)
+	}
+	query.Set("precision", "ns")
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
+
+//------------------------------------------------------------------------------
+
+// getStat returns a stat for a path and set of labels, registering a series
+// for it if one does not already exist.
+func (i *InfluxDB) getStat(kind influxKind, path string, labelNames, labelValues []string) *InfluxDBStat {
+	type tag struct{ k, v string }
+	tags := []tag{}
+	for j, k := range i.tagNames {
+		tags = append(tags, tag{k, i.tagValues[j]})
+	}
+	for j, k := range labelNames {
+		if j < len(labelValues) && len(labelValues[j]) > 0 {
+			tags = append(tags, tag{k, labelValues[j]})
+		}
+	}
+	// Tags should be sorted by key for the best write performance.
+	sort.SliceStable(tags, func(a, b int) bool {
+		return tags[a].k < tags[b].k
+	})
+
+	var keyBuf bytes.Buffer
+	keyBuf.WriteString(influxMeasurementEscaper.Replace(i.prefix + path))
+	for _, t := range tags {
+		keyBuf.WriteByte(',')
+		keyBuf.WriteString(influxTagEscaper.Replace(t.k))
+		keyBuf.WriteByte('=')
+		keyBuf.WriteString(influxTagEscaper.Replace(t.v))
+	}
+	key := keyBuf.String()
+
+	i.seriesMut.Lock()
+	s, exists := i.series[key]
+	if !exists {
+		s = &influxSeries{
+			kind: kind,
+			key:  key,
+		}
+		i.series[key] = s
+	}
+	i.seriesMut.Unlock()
+
+	return &InfluxDBStat{
+		s: s,
+		i: i,
+	}
+}
+
+// collect returns the line protocol representation of all series and resets
+// timings.
+func (i *InfluxDB) collect() []byte {
+	ts := strconv.FormatInt(time.Now().UnixNano(), 10)
+
+	i.seriesMut.Lock()
+	defer i.seriesMut.Unlock()
+
+	var buf bytes.Buffer
+	for _, s := range i.series {
+		switch s.kind {
+		case influxCounter, influxGauge:
+			fmt.Fprintf(&buf, "%v value=%vi %v\n", s.key, s.value, ts)
+		case influxTiming:
+			if s.count == 0 {
+				continue
+			}
+			fmt.Fprintf(
+				&buf, "%v count=%vi,sum=%vi,min=%vi,max=%vi,mean=%v %v\n",
+				s.key, s.count, s.sum, s.min, s.max,
+				strconv.FormatFloat(float64(s.sum)/float64(s.count), 'f', -1, 64), ts,
+			)
+			s.count, s.sum, s.min, s.max = 0, 0, 0, 0
+		}
+	}
+	return buf.Bytes()
+}
+
+// flush writes all collected metrics to InfluxDB.
+func (i *InfluxDB) flush() {
+	body := i.collect()
+	if len(body) == 0 {
+		return
+	}
+
+	req, err := http.NewRequest("POST", i.writeURL, bytes.NewReader(body))
+	if err != nil {
+		i.log.Errorf("Failed to create request: %v\n", err)
+		return
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	switch i.conf.API {
+	case "v1":
+		if len(i.conf.Username) > 0 {
+			req.SetBasicAuth(i.conf.Username, i.conf.Password)
+		}
+	case "v2":
+		if len(i.conf.Token) > 0 {
+			req.Header.Set("Authorization", "Token "+i.conf.Token)
+		}
+	}
+
+	res, err := i.client.Do(req)
+	if err != nil {
+		i.log.Errorf("Failed to send metrics: %v\n", err)
+		return
+	}
+	defer res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		resBody, _ := ioutil.ReadAll(res.Body)
+		i.log.Errorf("Failed to send metrics, status %v: %s\n", res.StatusCode, resBody)
+	}
+}
+
+func (i *InfluxDB) loop() {
+	defer close(i.closedChan)
+
+	ticker := time.NewTicker(i.flushPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			i.flush()
+		case <-i.closeChan:
+			i.flush()
+			return
+		}
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// GetCounter returns a stat counter object for a path.
+func (i *InfluxDB) GetCounter(path string) StatCounter {
+	return i.getStat(influxCounter, path, nil, nil)
+}
+
+// GetCounterVec returns a stat counter object for a path with the labels
+// provided as tags.
+func (i *InfluxDB) GetCounterVec(path string, n []string) StatCounterVec {
+	return &influxCounterVec{
+		path:   path,
+		labels: n,
+		i:      i,
+	}
+}
+
+// GetTimer returns a stat timer object for a path.
+func (i *InfluxDB) GetTimer(path string) StatTimer {
+	return i.getStat(influxTiming, path, nil, nil)
+}
+
+// GetTimerVec returns a stat timer object for a path with the labels provided
+// as tags.
+func (i *InfluxDB) GetTimerVec(path string, n []string) StatTimerVec {
+	return &influxTimerVec{
+		path:   path,
+		labels: n,
+		i:      i,
+	}
+}
+
+// GetGauge returns a stat gauge object for a path.
+func (i *InfluxDB) GetGauge(path string) StatGauge {
+	return i.getStat(influxGauge, path, nil, nil)
+}
+
+// GetGaugeVec returns a stat gauge object for a path with the labels provided
+// as tags.
+func (i *InfluxDB) GetGaugeVec(path string, n []string) StatGaugeVec {
+	return &influxGaugeVec{
+		path:   path,
+		labels: n,
+		i:      i,
+	}
+}
+
+// SetLogger sets the logger used to print connection errors.
+func (i *InfluxDB) SetLogger(log log.Modular) {
+	i.log = log.NewModule(".influxdb")
+}
+
+// Close stops the InfluxDB object from aggregating metrics, flushes any
+// remaining metrics and cleans up resources.
+func (i *InfluxDB) Close() error {
+	i.closeOnce.Do(func() {
+		close(i.closeChan)
+	})
+	<-i.closedChan
+	return nil
+}
+
+//------------------------------------------------------------------------------
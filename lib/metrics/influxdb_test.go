@@ -0,0 +1,172 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package metrics
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestInfluxDBInterface(t *testing.T) {
+	i := &InfluxDB{}
+	if Type(i) == nil {
+		t.Errorf("Type does not satisfy Type interface.")
+	}
+}
+
+type influxRequest struct {
+	path  string
+	query map[string]string
+	auth  string
+	lines []string
+}
+
+func newInfluxServer(t *testing.T) (*httptest.Server, <-chan influxRequest) {
+	reqChan := make(chan influxRequest, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			t.Error(err)
+		}
+		query := map[string]string{}
+		for k, v := range r.URL.Query() {
+			query[k] = v[0]
+		}
+		lines := []string{}
+		for _, l := range strings.Split(strings.TrimSpace(string(body)), "\n") {
+			// Strip timestamps
+			lines = append(lines, l[:strings.LastIndex(l, " ")])
+		}
+		sort.Strings(lines)
+		reqChan <- influxRequest{
+			path:  r.URL.Path,
+			query: query,
+			auth:  r.Header.Get("Authorization"),
+			lines: lines,
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	return srv, reqChan
+}
+
+func TestInfluxDBV1(t *testing.T) {
+	srv, reqChan := newInfluxServer(t)
+	defer srv.Close()
+
+	conf := NewConfig()
+	conf.InfluxDB.URL = srv.URL
+	conf.InfluxDB.RetentionPolicy = "foo"
+	conf.InfluxDB.Username = "user"
+	conf.InfluxDB.Password = "pass"
+	conf.InfluxDB.FlushPeriod = "1h"
+	conf.InfluxDB.Tags = map[string]string{"env": "prod"}
+
+	m, err := NewInfluxDB(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.GetCounter("foo.count").Incr(2)
+	m.GetCounter("foo.count").Incr(3)
+	m.GetGauge("foo gauge").Set(10)
+	m.GetTimer("foo.latency").Timing(10)
+	m.GetTimer("foo.latency").Timing(30)
+	m.GetCounterVec("foo.vec", []string{"status"}).With("not ok").Incr(1)
+	if err = m.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	req := <-reqChan
+	if exp, act := "/write", req.path; exp != act {
+		t.Errorf("Wrong path: %v != %v", act, exp)
+	}
+	if exp, act := map[string]string{"db": "benthos", "rp": "foo", "precision": "ns"}, req.query; len(act) != 3 || act["db"] != exp["db"] || act["rp"] != exp["rp"] || act["precision"] != exp["precision"] {
+		t.Errorf("Wrong query: %v != %v", act, exp)
+	}
+	if !strings.HasPrefix(req.auth, "Basic ") {
+		t.Errorf("Wrong auth: %v", req.auth)
+	}
+	exp := []string{
+		`benthos.foo.count,env=prod value=5i`,
+		`benthos.foo.latency,env=prod count=2i,sum=40i,min=10i,max=30i,mean=20`,
+		`benthos.foo.vec,env=prod,status=not\ ok value=1i`,
+		`benthos.foo\ gauge,env=prod value=10i`,
+	}
+	if act := req.lines; strings.Join(exp, "\n") != strings.Join(act, "\n") {
+		t.Errorf("Wrong lines: %v != %v", act, exp)
+	}
+}
+
+func TestInfluxDBV2(t *testing.T) {
+	srv, reqChan := newInfluxServer(t)
+	defer srv.Close()
+
+	conf := NewConfig()
+	conf.InfluxDB.URL = srv.URL + "/"
+	conf.InfluxDB.API = "v2"
+	conf.InfluxDB.Org = "foo"
+	conf.InfluxDB.Bucket = "bar"
+	conf.InfluxDB.Token = "baz"
+	conf.InfluxDB.FlushPeriod = "1h"
+
+	m, err := NewInfluxDB(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.GetCounter("foo").Incr(1)
+	if err = m.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	req := <-reqChan
+	if exp, act := "/api/v2/write", req.path; exp != act {
+		t.Errorf("Wrong path: %v != %v", act, exp)
+	}
+	if exp, act := "foo", req.query["org"]; exp != act {
+		t.Errorf("Wrong org: %v != %v", act, exp)
+	}
+	if exp, act := "bar", req.query["bucket"]; exp != act {
+		t.Errorf("Wrong bucket: %v != %v", act, exp)
+	}
+	if exp, act := "Token baz", req.auth; exp != act {
+		t.Errorf("Wrong auth: %v != %v", act, exp)
+	}
+	if exp, act := []string{"benthos.foo value=1i"}, req.lines; len(act) != 1 || act[0] != exp[0] {
+		t.Errorf("Wrong lines: %v != %v", act, exp)
+	}
+}
+
+func TestInfluxDBBadConfig(t *testing.T) {
+	conf := NewConfig()
+	conf.InfluxDB.API = "v3"
+	if _, err := NewInfluxDB(conf); err == nil {
+		t.Error("Expected error from bad api")
+	}
+
+	conf = NewConfig()
+	conf.InfluxDB.API = "v2"
+	if _, err := NewInfluxDB(conf); err == nil {
+		t.Error("Expected error from missing org and bucket")
+	}
+}
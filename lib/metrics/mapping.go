@@ -0,0 +1,348 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package metrics
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+
+	"github.com/Jeffail/benthos/lib/log"
+)
+
+//------------------------------------------------------------------------------
+
+// RenameConfig contains fields for a rule that renames metric paths.
+type RenameConfig struct {
+	Pattern string `json:"pattern" yaml:"pattern"`
+	Value   string `json:"value" yaml:"value"`
+}
+
+// MappingConfig contains fields for filtering, renaming and labelling metrics
+// before they reach a metrics target.
+type MappingConfig struct {
+	Whitelist    []string          `json:"whitelist" yaml:"whitelist"`
+	Blacklist    []string          `json:"blacklist" yaml:"blacklist"`
+	Rename       []RenameConfig    `json:"rename" yaml:"rename"`
+	StaticLabels map[string]string `json:"static_labels" yaml:"static_labels"`
+}
+
+// NewMappingConfig returns a MappingConfig with default values.
+func NewMappingConfig() MappingConfig {
+	return MappingConfig{
+		Whitelist:    []string{},
+		Blacklist:    []string{},
+		Rename:       []RenameConfig{},
+		StaticLabels: map[string]string{},
+	}
+}
+
+// IsNoop returns true if the mapping config would not modify any metrics.
+func (m MappingConfig) IsNoop() bool {
+	return len(m.Whitelist) == 0 &&
+		len(m.Blacklist) == 0 &&
+		len(m.Rename) == 0 &&
+		len(m.StaticLabels) == 0
+}
+
+//------------------------------------------------------------------------------
+
+type renameRule struct {
+	re    *regexp.Regexp
+	value string
+}
+
+// mappedWrapper wraps an existing Type and filters, renames and labels all
+// metrics before they are registered with it.
+type mappedWrapper struct {
+	whitelist []*regexp.Regexp
+	blacklist []*regexp.Regexp
+	rename    []renameRule
+
+	labelNames  []string
+	labelValues []string
+
+	t Type
+}
+
+// mappedWrapperWithHandler is a mappedWrapper for types that expose an HTTP
+// handler.
+type mappedWrapperWithHandler struct {
+	*mappedWrapper
+	h WithHandlerFunc
+}
+
+func (m mappedWrapperWithHandler) HandlerFunc() http.HandlerFunc {
+	return m.h.HandlerFunc()
+}
+
+func compilePatterns(patterns []string) ([]*regexp.Regexp, error) {
+	res := make([]*regexp.Regexp, 0, len(patterns))
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compile pattern '%v': %v", p, err)
+		}
+		res = append(res, re)
+	}
+	return res, nil
+}
+
+// Mapped wraps an existing Type with a mapping config. Metrics with paths that
+// do not match the whitelist (when non-empty) or that match the blacklist are
+// dropped, the remaining paths are renamed by each rename rule in order and the
+// static labels are added to each metric.
+func Mapped(t Type, conf MappingConfig) (Type, error) {
+	m := &mappedWrapper{t: t}
+
+	var err error
+	if m.whitelist, err = compilePatterns(conf.Whitelist); err != nil {
+		return nil, err
+	}
+	if m.blacklist, err = compilePatterns(conf.Blacklist); err != nil {
+		return nil, err
+	}
+	for _, r := range conf.Rename {
+		re, err := regexp.Compile(r.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compile rename pattern '%v': %v", r.Pattern, err)
+		}
+		m.rename = append(m.rename, renameRule{re: re, value: r.Value})
+	}
+
+	for k := range conf.StaticLabels {
+		m.labelNames = append(m.labelNames, k)
+	}
+	sort.Strings(m.labelNames)
+	for _, k := range m.labelNames {
+		m.labelValues = append(m.labelValues, conf.StaticLabels[k])
+	}
+
+	if h, ok := t.(WithHandlerFunc); ok {
+		return mappedWrapperWithHandler{
+			mappedWrapper: m,
+			h:             h,
+		}, nil
+	}
+	return m, nil
+}
+
+// Labelled wraps an existing Type so that all metrics are given a static label
+// with a name and value. If the wrapped Type does not support labels then the
+// value is used as a namespace of metric paths instead, so that metrics with
+// different values remain distinct.
+func Labelled(t Type, name, value string) Type {
+	if l, ok := t.(WithLabels); !ok || !l.SupportsLabels() {
+		return Namespaced(t, value)
+	}
+	m := &mappedWrapper{
+		labelNames:  []string{name},
+		labelValues: []string{value},
+		t:           t,
+	}
+	if h, ok := t.(WithHandlerFunc); ok {
+		return mappedWrapperWithHandler{
+			mappedWrapper: m,
+			h:             h,
+		}
+	}
+	return m
+}
+
+//------------------------------------------------------------------------------
+
+// mapPath returns the renamed path of a metric, or false if the metric should
+// be dropped.
+func (m *mappedWrapper) mapPath(path string) (string, bool) {
+	if len(m.whitelist) > 0 {
+		matched := false
+		for _, re := range m.whitelist {
+			if matched = re.MatchString(path); matched {
+				break
+			}
+		}
+		if !matched {
+			return "", false
+		}
+	}
+	for _, re := range m.blacklist {
+		if re.MatchString(path) {
+			return "", false
+		}
+	}
+	for _, r := range m.rename {
+		path = r.re.ReplaceAllString(path, r.value)
+	}
+	return path, true
+}
+
+func (m *mappedWrapper) withLabels(labels []string) []string {
+	return joinValues(m.labelNames, labels)
+}
+
+func joinValues(a, b []string) []string {
+	joined := make([]string, 0, len(a)+len(b))
+	joined = append(joined, a...)
+	return append(joined, b...)
+}
+
+type mappedCounterVec struct {
+	vec    StatCounterVec
+	values []string
+}
+
+func (m *mappedCounterVec) With(values ...string) StatCounter {
+	return m.vec.With(joinValues(m.values, values)...)
+}
+
+type mappedTimerVec struct {
+	vec    StatTimerVec
+	values []string
+}
+
+func (m *mappedTimerVec) With(values ...string) StatTimer {
+	return m.vec.With(joinValues(m.values, values)...)
+}
+
+type mappedGaugeVec struct {
+	vec    StatGaugeVec
+	values []string
+}
+
+func (m *mappedGaugeVec) With(values ...string) StatGauge {
+	return m.vec.With(joinValues(m.values, values)...)
+}
+
+type mappedHistogramVec struct {
+	vec    StatHistogramVec
+	values []string
+}
+
+func (m *mappedHistogramVec) With(values ...string) StatHistogram {
+	return m.vec.With(joinValues(m.values, values)...)
+}
+
+//------------------------------------------------------------------------------
+
+func (m *mappedWrapper) GetCounter(path string) StatCounter {
+	path, ok := m.mapPath(path)
+	if !ok {
+		return DudStat{}
+	}
+	if len(m.labelNames) == 0 {
+		return m.t.GetCounter(path)
+	}
+	return m.t.GetCounterVec(path, m.labelNames).With(m.labelValues...)
+}
+
+func (m *mappedWrapper) GetCounterVec(path string, labelNames []string) StatCounterVec {
+	path, ok := m.mapPath(path)
+	if !ok {
+		return DudType{}.GetCounterVec(path, labelNames)
+	}
+	if len(m.labelNames) == 0 {
+		return m.t.GetCounterVec(path, labelNames)
+	}
+	return &mappedCounterVec{
+		vec:    m.t.GetCounterVec(path, m.withLabels(labelNames)),
+		values: m.labelValues,
+	}
+}
+
+func (m *mappedWrapper) GetTimer(path string) StatTimer {
+	path, ok := m.mapPath(path)
+	if !ok {
+		return DudStat{}
+	}
+	if len(m.labelNames) == 0 {
+		return m.t.GetTimer(path)
+	}
+	return m.t.GetTimerVec(path, m.labelNames).With(m.labelValues...)
+}
+
+func (m *mappedWrapper) GetTimerVec(path string, labelNames []string) StatTimerVec {
+	path, ok := m.mapPath(path)
+	if !ok {
+		return DudType{}.GetTimerVec(path, labelNames)
+	}
+	if len(m.labelNames) == 0 {
+		return m.t.GetTimerVec(path, labelNames)
+	}
+	return &mappedTimerVec{
+		vec:    m.t.GetTimerVec(path, m.withLabels(labelNames)),
+		values: m.labelValues,
+	}
+}
+
+func (m *mappedWrapper) GetGauge(path string) StatGauge {
+	path, ok := m.mapPath(path)
+	if !ok {
+		return DudStat{}
+	}
+	if len(m.labelNames) == 0 {
+		return m.t.GetGauge(path)
+	}
+	return m.t.GetGaugeVec(path, m.labelNames).With(m.labelValues...)
+}
+
+func (m *mappedWrapper) GetGaugeVec(path string, labelNames []string) StatGaugeVec {
+	path, ok := m.mapPath(path)
+	if !ok {
+		return DudType{}.GetGaugeVec(path, labelNames)
+	}
+	if len(m.labelNames) == 0 {
+		return m.t.GetGaugeVec(path, labelNames)
+	}
+	return &mappedGaugeVec{
+		vec:    m.t.GetGaugeVec(path, m.withLabels(labelNames)),
+		values: m.labelValues,
+	}
+}
+
+func (m *mappedWrapper) GetHistogramVec(path string, labelNames []string) StatHistogramVec {
+	path, ok := m.mapPath(path)
+	if !ok {
+		return DudType{}.GetHistogramVec(path, labelNames)
+	}
+	if len(m.labelNames) == 0 {
+		return GetHistogramVec(m.t, path, labelNames)
+	}
+	return &mappedHistogramVec{
+		vec:    GetHistogramVec(m.t, path, m.withLabels(labelNames)),
+		values: m.labelValues,
+	}
+}
+
+func (m *mappedWrapper) SupportsLabels() bool {
+	l, ok := m.t.(WithLabels)
+	return ok && l.SupportsLabels()
+}
+
+func (m *mappedWrapper) SetLogger(log log.Modular) {
+	m.t.SetLogger(log)
+}
+
+func (m *mappedWrapper) Close() error {
+	return m.t.Close()
+}
+
+//------------------------------------------------------------------------------
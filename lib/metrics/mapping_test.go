@@ -0,0 +1,192 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package metrics
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMappedFilterAndRename(t *testing.T) {
+	conf := NewMappingConfig()
+	conf.Whitelist = []string{`^(input|pipeline)\.`}
+	conf.Blacklist = []string{`\.dropped$`}
+	conf.Rename = []RenameConfig{
+		{Pattern: `^pipeline\.processor\.([0-9]+)\.`, Value: "proc.$1."},
+		{Pattern: `\.count$`, Value: ".total"},
+	}
+
+	local := NewLocal()
+	m, err := Mapped(local, conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m.GetCounter("input.count").Incr(1)
+	m.GetCounter("output.count").Incr(2)
+	m.GetCounter("pipeline.processor.0.count").Incr(3)
+	m.GetCounter("pipeline.processor.0.dropped").Incr(4)
+	m.GetCounterVec("pipeline.processor.1.count", []string{"foo"}).With("bar").Incr(5)
+	m.GetTimer("input.latency").Timing(6)
+	m.GetTimer("output.latency").Timing(7)
+
+	exp := map[string]int64{
+		"input.total":  1,
+		"proc.0.total": 3,
+		"proc.1.total": 5,
+	}
+	if act := local.GetCounters(); !reflect.DeepEqual(exp, act) {
+		t.Errorf("Wrong counters: %v != %v", act, exp)
+	}
+	exp = map[string]int64{
+		"input.latency": 6,
+	}
+	if act := local.GetTimings(); !reflect.DeepEqual(exp, act) {
+		t.Errorf("Wrong timings: %v != %v", act, exp)
+	}
+}
+
+func TestMappedStaticLabels(t *testing.T) {
+	pConf := NewConfig()
+	pConf.Type = TypePrometheus
+	pConf.Prometheus.RuntimeCollectors = false
+	pConf.Mapping.StaticLabels = map[string]string{
+		"env":    "prod",
+		"region": "eu",
+	}
+
+	m, err := New(pConf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := m.(WithHandlerFunc); !ok {
+		t.Error("Mapped type does not expose handler")
+	}
+	p := m.(mappedWrapperWithHandler).mappedWrapper.t.(*Prometheus)
+
+	m.GetCounter("foo").Incr(1)
+	m.GetCounterVec("bar", []string{"status"}).With("ok").Incr(2)
+
+	families := gatherPrometheus(t, p)
+
+	fooFam, exists := families["benthos_foo"]
+	if !exists {
+		t.Fatal("Missing counter")
+	}
+	exp := map[string]string{"env": "prod", "region": "eu"}
+	if act := promLabels(fooFam.GetMetric()[0]); !reflect.DeepEqual(exp, act) {
+		t.Errorf("Wrong labels: %v != %v", act, exp)
+	}
+
+	barFam, exists := families["benthos_bar"]
+	if !exists {
+		t.Fatal("Missing counter vec")
+	}
+	exp = map[string]string{"env": "prod", "region": "eu", "status": "ok"}
+	if act := promLabels(barFam.GetMetric()[0]); !reflect.DeepEqual(exp, act) {
+		t.Errorf("Wrong labels: %v != %v", act, exp)
+	}
+	if exp, act := 2.0, barFam.GetMetric()[0].GetCounter().GetValue(); exp != act {
+		t.Errorf("Wrong value: %v != %v", act, exp)
+	}
+}
+
+func TestMappedBadPatterns(t *testing.T) {
+	conf := NewMappingConfig()
+	conf.Whitelist = []string{"("}
+	if _, err := Mapped(NewLocal(), conf); err == nil {
+		t.Error("Expected error from bad whitelist")
+	}
+
+	conf = NewMappingConfig()
+	conf.Rename = []RenameConfig{{Pattern: "("}}
+	if _, err := Mapped(NewLocal(), conf); err == nil {
+		t.Error("Expected error from bad rename")
+	}
+}
+
+func TestLabelledFlat(t *testing.T) {
+	local := NewLocal()
+	m := Labelled(local, "stream", "foo")
+
+	m.GetCounter("input.count").Incr(1)
+	m.GetCounterVec("output.count", []string{"status"}).With("ok").Incr(2)
+
+	exp := map[string]int64{
+		"foo.input.count":  1,
+		"foo.output.count": 2,
+	}
+	if act := local.GetCounters(); !reflect.DeepEqual(exp, act) {
+		t.Errorf("Wrong counters: %v != %v", act, exp)
+	}
+}
+
+func TestLabelledPrometheus(t *testing.T) {
+	pConf := NewConfig()
+	pConf.Type = TypePrometheus
+	pConf.Prometheus.RuntimeCollectors = false
+	pConf.Mapping.StaticLabels = map[string]string{
+		"env": "prod",
+	}
+
+	pm, err := New(pConf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := pm.(mappedWrapperWithHandler).mappedWrapper.t.(*Prometheus)
+
+	fooStats := Labelled(pm, "stream", "foo")
+	barStats := Labelled(pm, "stream", "bar")
+	if _, ok := fooStats.(WithHandlerFunc); !ok {
+		t.Error("Labelled type does not expose handler")
+	}
+
+	fooStats.GetCounter("input.count").Incr(1)
+	barStats.GetCounter("input.count").Incr(2)
+	fooStats.GetCounterVec("output.count", []string{"status"}).With("ok").Incr(3)
+
+	families := gatherPrometheus(t, p)
+
+	inFam, exists := families["benthos_input_count"]
+	if !exists {
+		t.Fatal("Missing counter")
+	}
+	act := map[string]float64{}
+	for _, metric := range inFam.GetMetric() {
+		labels := promLabels(metric)
+		if exp, act := "prod", labels["env"]; exp != act {
+			t.Errorf("Wrong env label: %v != %v", act, exp)
+		}
+		act[labels["stream"]] = metric.GetCounter().GetValue()
+	}
+	if exp := map[string]float64{"foo": 1, "bar": 2}; !reflect.DeepEqual(exp, act) {
+		t.Errorf("Wrong counters: %v != %v", act, exp)
+	}
+
+	outFam, exists := families["benthos_output_count"]
+	if !exists {
+		t.Fatal("Missing counter vec")
+	}
+	exp := map[string]string{"env": "prod", "status": "ok", "stream": "foo"}
+	if act := promLabels(outFam.GetMetric()[0]); !reflect.DeepEqual(exp, act) {
+		t.Errorf("Wrong labels: %v != %v", act, exp)
+	}
+}
@@ -68,6 +68,10 @@ func (d namespacedWrapper) GetGaugeVec(path string, labelNames []string) StatGau
 	return d.t.GetGaugeVec(d.ns+"."+path, labelNames)
 }
 
+func (d namespacedWrapper) GetHistogramVec(path string, labelNames []string) StatHistogramVec {
+	return GetHistogramVec(d.t, d.ns+"."+path, labelNames)
+}
+
 func (d namespacedWrapper) SetLogger(log log.Modular) {
 	d.t.SetLogger(log.NewModule(d.ns))
 }
@@ -0,0 +1,617 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package metrics
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/log"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	constructors[TypeOpenTelemetry] = typeSpec{
+		constructor: NewOpenTelemetry,
+		description: `
+Export metrics to an OpenTelemetry collector using OTLP over HTTP with the JSON
+encoding. The field ` + "`url`" + ` should point at the metrics endpoint of the
+collector, which is usually ` + "`http://localhost:4318/v1/metrics`" + `.
+
+Metrics are exported each ` + "`flush_period`" + ` with cumulative
+temporality. Counters are exported as monotonic sums, gauges as gauges and
+timings as histograms measured in seconds, where the upper bounds of the
+buckets can be configured with ` + "`histogram_buckets`" + `. The labels of
+metrics are exported as data point attributes.
+
+The field ` + "`resource_attributes`" + ` sets attributes of the resource that
+all metrics are exported under. If the attribute ` + "`service.name`" + ` is
+not set it defaults to ` + "`benthos`" + `. Custom headers, such as those
+required for authentication, can be added to each request with the field
+` + "`headers`" + `.
+
+Exporting over gRPC is not currently supported.`,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// OpenTelemetryConfig is config for the OpenTelemetry metrics type.
+type OpenTelemetryConfig struct {
+	URL                string            `json:"url" yaml:"url"`
+	Headers            map[string]string `json:"headers" yaml:"headers"`
+	ResourceAttributes map[string]string `json:"resource_attributes" yaml:"resource_attributes"`
+	HistogramBuckets   []float64         `json:"histogram_buckets" yaml:"histogram_buckets"`
+	FlushPeriod        string            `json:"flush_period" yaml:"flush_period"`
+	Timeout            string            `json:"timeout" yaml:"timeout"`
+}
+
+// NewOpenTelemetryConfig creates an OpenTelemetryConfig struct with default
+// values.
+func NewOpenTelemetryConfig() OpenTelemetryConfig {
+	return OpenTelemetryConfig{
+		URL:                "http://localhost:4318/v1/metrics",
+		Headers:            map[string]string{},
+		ResourceAttributes: map[string]string{},
+		HistogramBuckets:   []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10},
+		FlushPeriod:        "10s",
+		Timeout:            "5s",
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// The following types are a subset of the OTLP protobuf definitions in their
+// JSON mapping, where 64 bit integers are encoded as strings.
+
+type otlpAnyValue struct {
+	StringValue string `json:"stringValue"`
+}
+
+type otlpKeyValue struct {
+	Key   string       `json:"key"`
+	Value otlpAnyValue `json:"value"`
+}
+
+type otlpNumberDataPoint struct {
+	Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano,omitempty"`
+	TimeUnixNano      string         `json:"timeUnixNano"`
+	AsInt             string         `json:"asInt"`
+}
+
+type otlpHistogramDataPoint struct {
+	Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	TimeUnixNano      string         `json:"timeUnixNano"`
+	Count             string         `json:"count"`
+	Sum               float64        `json:"sum"`
+	BucketCounts      []string       `json:"bucketCounts"`
+	ExplicitBounds    []float64      `json:"explicitBounds"`
+	Min               float64        `json:"min"`
+	Max               float64        `json:"max"`
+}
+
+type otlpSum struct {
+	DataPoints             []otlpNumberDataPoint `json:"dataPoints"`
+	AggregationTemporality int                   `json:"aggregationTemporality"`
+	IsMonotonic            bool                  `json:"isMonotonic"`
+}
+
+type otlpGauge struct {
+	DataPoints []otlpNumberDataPoint `json:"dataPoints"`
+}
+
+type otlpHistogram struct {
+	DataPoints             []otlpHistogramDataPoint `json:"dataPoints"`
+	AggregationTemporality int                      `json:"aggregationTemporality"`
+}
+
+type otlpMetric struct {
+	Name      string         `json:"name"`
+	Unit      string         `json:"unit,omitempty"`
+	Sum       *otlpSum       `json:"sum,omitempty"`
+	Gauge     *otlpGauge     `json:"gauge,omitempty"`
+	Histogram *otlpHistogram `json:"histogram,omitempty"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpScopeMetrics struct {
+	Scope   otlpScope    `json:"scope"`
+	Metrics []otlpMetric `json:"metrics"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes"`
+}
+
+type otlpResourceMetrics struct {
+	Resource     otlpResource       `json:"resource"`
+	ScopeMetrics []otlpScopeMetrics `json:"scopeMetrics"`
+}
+
+type otlpExportRequest struct {
+	ResourceMetrics []otlpResourceMetrics `json:"resourceMetrics"`
+}
+
+// otlpCumulative is the OTLP enum value for cumulative aggregation
+// temporality.
+const otlpCumulative = 2
+
+func otlpAttributes(names, values []string) []otlpKeyValue {
+	attrs := make([]otlpKeyValue, 0, len(names))
+	for i, n := range names {
+		if i >= len(values) {
+			break
+		}
+		attrs = append(attrs, otlpKeyValue{
+			Key:   n,
+			Value: otlpAnyValue{StringValue: values[i]},
+		})
+	}
+	return attrs
+}
+
+//------------------------------------------------------------------------------
+
+type otelKind int
+
+const (
+	otelCounter otelKind = iota
+	otelGauge
+	otelTiming
+)
+
+// otelSeries holds the cumulative state of a single series.
+type otelSeries struct {
+	kind  otelKind
+	name  string
+	attrs []otlpKeyValue
+
+	value int64
+
+	buckets       []uint64
+	count         uint64
+	sum, min, max float64
+}
+
+// OpenTelemetryStat is a representation of a single metric stat. Interactions
+// with this stat are thread safe.
+type OpenTelemetryStat struct {
+	s *otelSeries
+	o *OpenTelemetry
+}
+
+// Incr increments a metric by an amount.
+func (s *OpenTelemetryStat) Incr(count int64) error {
+	s.o.seriesMut.Lock()
+	s.s.value += count
+	s.o.seriesMut.Unlock()
+	return nil
+}
+
+// Decr decrements a metric by an amount.
+func (s *OpenTelemetryStat) Decr(count int64) error {
+	s.o.seriesMut.Lock()
+	s.s.value -= count
+	s.o.seriesMut.Unlock()
+	return nil
+}
+
+// Timing sets a timing metric, the value is given in nanoseconds and recorded
+// in seconds.
+func (s *OpenTelemetryStat) Timing(delta int64) error {
+	v := float64(delta) / 1e9
+
+	s.o.seriesMut.Lock()
+	i := sort.SearchFloat64s(s.o.buckets, v)
+	s.s.buckets[i]++
+	if s.s.count == 0 || v < s.s.min {
+		s.s.min = v
+	}
+	if s.s.count == 0 || v > s.s.max {
+		s.s.max = v
+	}
+	s.s.sum += v
+	s.s.count++
+	s.o.seriesMut.Unlock()
+	return nil
+}
+
+// Set sets a gauge metric.
+func (s *OpenTelemetryStat) Set(value int64) error {
+	s.o.seriesMut.Lock()
+	s.s.value = value
+	s.o.seriesMut.Unlock()
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+type otelCounterVec struct {
+	path   string
+	labels []string
+	o      *OpenTelemetry
+}
+
+func (v *otelCounterVec) With(labelValues ...string) StatCounter {
+	return v.o.getStat(otelCounter, v.path, v.labels, labelValues)
+}
+
+type otelTimerVec struct {
+	path   string
+	labels []string
+	o      *OpenTelemetry
+}
+
+func (v *otelTimerVec) With(labelValues ...string) StatTimer {
+	return v.o.getStat(otelTiming, v.path, v.labels, labelValues)
+}
+
+type otelGaugeVec struct {
+	path   string
+	labels []string
+	o      *OpenTelemetry
+}
+
+func (v *otelGaugeVec) With(labelValues ...string) StatGauge {
+	return v.o.getStat(otelGauge, v.path, v.labels, labelValues)
+}
+
+//------------------------------------------------------------------------------
+
+// OpenTelemetry is a stats object that periodically exports metrics to an
+// OpenTelemetry collector.
+type OpenTelemetry struct {
+	conf        OpenTelemetryConfig
+	prefix      string
+	client      http.Client
+	flushPeriod time.Duration
+	startTime   string
+	buckets     []float64
+	resource    otlpResource
+
+	series    map[string]*otelSeries
+	seriesMut sync.Mutex
+
+	log log.Modular
+
+	closeOnce  sync.Once
+	closeChan  chan struct{}
+	closedChan chan struct{}
+}
+
+// NewOpenTelemetry creates and returns a new OpenTelemetry object.
+func NewOpenTelemetry(config Config, opts ...func(Type)) (Type, error) {
+	if len(config.OpenTelemetry.URL) == 0 {
+		return nil, errors.New("a url must be specified")
+	}
+	flushPeriod, err := time.ParseDuration(config.OpenTelemetry.FlushPeriod)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse flush period: %s", err)
+	}
+	if flushPeriod <= 0 {
+		return nil, fmt.Errorf("flush period must be greater than zero: %v", flushPeriod)
+	}
+	timeout, err := time.ParseDuration(config.OpenTelemetry.Timeout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse timeout: %s", err)
+	}
+	for i, b := range config.OpenTelemetry.HistogramBuckets {
+		if i > 0 && b <= config.OpenTelemetry.HistogramBuckets[i-1] {
+			return nil, errors.New("histogram buckets must be in increasing order")
+		}
+	}
+
+	prefix := config.Prefix
+	if len(prefix) > 0 && prefix[len(prefix)-1] != '.' {
+		prefix = prefix + "."
+	}
+
+	o := &OpenTelemetry{
+		conf:        config.OpenTelemetry,
+		prefix:      prefix,
+		client:      http.Client{Timeout: timeout},
+		flushPeriod: flushPeriod,
+		startTime:   strconv.FormatInt(time.Now().UnixNano(), 10),
+		buckets:     config.OpenTelemetry.HistogramBuckets,
+		series:      map[string]*otelSeries{},
+		log:         log.New(ioutil.Discard, log.Config{LogLevel: "OFF"}),
+		closeChan:   make(chan struct{}),
+		closedChan:  make(chan struct{}),
+	}
+
+	resAttrs := map[string]string{"service.name": "benthos"}
+	for k, v := range config.OpenTelemetry.ResourceAttributes {
+		resAttrs[k] = v
+	}
+	var keys, values []string
+	for k := range resAttrs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		values = append(values, resAttrs[k])
+	}
+	o.resource.Attributes = otlpAttributes(keys, values)
+
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	go o.loop()
+	return o, nil
+}
+
+//------------------------------------------------------------------------------
+
+// getStat returns a stat for a path and set of labels, registering a series
+// for it if one does not already exist.
+func (o *OpenTelemetry) getStat(kind otelKind, path string, labelNames, labelValues []string) *OpenTelemetryStat {
+	name := o.prefix + path
+	attrs := otlpAttributes(labelNames, labelValues)
+
+	keyParts := []string{name}
+	for _, a := range attrs {
+		keyParts = append(keyParts, a.Key+"="+a.Value.StringValue)
+	}
+	key := strings.Join(keyParts, "\x00")
+
+	o.seriesMut.Lock()
+	s, exists := o.series[key]
+	if !exists {
+		s = &otelSeries{
+			kind:  kind,
+			name:  name,
+			attrs: attrs,
+		}
+		if kind == otelTiming {
+			s.buckets = make([]uint64, len(o.buckets)+1)
+		}
+		o.series[key] = s
+	}
+	o.seriesMut.Unlock()
+
+	return &OpenTelemetryStat{
+		s: s,
+		o: o,
+	}
+}
+
+// collect returns an export request containing the current state of all
+// series.
+func (o *OpenTelemetry) collect() otlpExportRequest {
+	now := strconv.FormatInt(time.Now().UnixNano(), 10)
+
+	o.seriesMut.Lock()
+	defer o.seriesMut.Unlock()
+
+	metrics := map[string]*otlpMetric{}
+	for _, s := range o.series {
+		m, exists := metrics[s.name]
+		if !exists {
+			m = &otlpMetric{Name: s.name}
+			switch s.kind {
+			case otelCounter:
+				m.Sum = &otlpSum{
+					AggregationTemporality: otlpCumulative,
+					IsMonotonic:            true,
+				}
+			case otelGauge:
+				m.Gauge = &otlpGauge{}
+			case otelTiming:
+				m.Unit = "s"
+				m.Histogram = &otlpHistogram{
+					AggregationTemporality: otlpCumulative,
+				}
+			}
+			metrics[s.name] = m
+		}
+		switch {
+		case m.Sum != nil:
+			m.Sum.DataPoints = append(m.Sum.DataPoints, otlpNumberDataPoint{
+				Attributes:        s.attrs,
+				StartTimeUnixNano: o.startTime,
+				TimeUnixNano:      now,
+				AsInt:             strconv.FormatInt(s.value, 10),
+			})
+		case m.Gauge != nil:
+			m.Gauge.DataPoints = append(m.Gauge.DataPoints, otlpNumberDataPoint{
+				Attributes:   s.attrs,
+				TimeUnixNano: now,
+				AsInt:        strconv.FormatInt(s.value, 10),
+			})
+		case m.Histogram != nil:
+			if s.count == 0 {
+				continue
+			}
+			counts := make([]string, len(s.buckets))
+			for i, c := range s.buckets {
+				counts[i] = strconv.FormatUint(c, 10)
+			}
+			m.Histogram.DataPoints = append(m.Histogram.DataPoints, otlpHistogramDataPoint{
+				Attributes:        s.attrs,
+				StartTimeUnixNano: o.startTime,
+				TimeUnixNano:      now,
+				Count:             strconv.FormatUint(s.count, 10),
+				Sum:               s.sum,
+				BucketCounts:      counts,
+				ExplicitBounds:    o.buckets,
+				Min:               s.min,
+				Max:               s.max,
+			})
+		}
+	}
+
+	names := make([]string, 0, len(metrics))
+	for name := range metrics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	scope := otlpScopeMetrics{
+		Scope:   otlpScope{Name: "benthos"},
+		Metrics: []otlpMetric{},
+	}
+	for _, name := range names {
+		m := metrics[name]
+		if m.Histogram != nil && len(m.Histogram.DataPoints) == 0 {
+			continue
+		}
+		scope.Metrics = append(scope.Metrics, *m)
+	}
+
+	return otlpExportRequest{
+		ResourceMetrics: []otlpResourceMetrics{{
+			Resource:     o.resource,
+			ScopeMetrics: []otlpScopeMetrics{scope},
+		}},
+	}
+}
+
+// flush exports the current state of all metrics.
+func (o *OpenTelemetry) flush() {
+	exportReq := o.collect()
+	if len(exportReq.ResourceMetrics[0].ScopeMetrics[0].Metrics) == 0 {
+		return
+	}
+
+	body, err := json.Marshal(exportReq)
+	if err != nil {
+		o.log.Errorf("Failed to encode metrics: %v\n", err)
+		return
+	}
+
+	req, err := http.NewRequest("POST", o.conf.URL, bytes.NewReader(body))
+	if err != nil {
+		o.log.Errorf("Failed to create request: %v\n", err)
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range o.conf.Headers {
+		req.Header.Set(k, v)
+	}
+
+	res, err := o.client.Do(req)
+	if err != nil {
+		o.log.Errorf("Failed to export metrics: %v\n", err)
+		return
+	}
+	defer res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		resBody, _ := ioutil.ReadAll(res.Body)
+		o.log.Errorf("Failed to export metrics, status %v: %s\n", res.StatusCode, resBody)
+	}
+}
+
+func (o *OpenTelemetry) loop() {
+	defer close(o.closedChan)
+
+	ticker := time.NewTicker(o.flushPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			o.flush()
+		case <-o.closeChan:
+			o.flush()
+			return
+		}
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// GetCounter returns a stat counter object for a path.
+func (o *OpenTelemetry) GetCounter(path string) StatCounter {
+	return o.getStat(otelCounter, path, nil, nil)
+}
+
+// GetCounterVec returns a stat counter object for a path with the labels
+// provided as attributes.
+func (o *OpenTelemetry) GetCounterVec(path string, n []string) StatCounterVec {
+	return &otelCounterVec{
+		path:   path,
+		labels: n,
+		o:      o,
+	}
+}
+
+// GetTimer returns a stat timer object for a path.
+func (o *OpenTelemetry) GetTimer(path string) StatTimer {
+	return o.getStat(otelTiming, path, nil, nil)
+}
+
+// GetTimerVec returns a stat timer object for a path with the labels provided
+// as attributes.
+func (o *OpenTelemetry) GetTimerVec(path string, n []string) StatTimerVec {
+	return &otelTimerVec{
+		path:   path,
+		labels: n,
+		o:      o,
+	}
+}
+
+// GetGauge returns a stat gauge object for a path.
+func (o *OpenTelemetry) GetGauge(path string) StatGauge {
+	return o.getStat(otelGauge, path, nil, nil)
+}
+
+// GetGaugeVec returns a stat gauge object for a path with the labels provided
+// as attributes.
+func (o *OpenTelemetry) GetGaugeVec(path string, n []string) StatGaugeVec {
+	return &otelGaugeVec{
+		path:   path,
+		labels: n,
+		o:      o,
+	}
+}
+
+// SetLogger sets the logger used to print connection errors.
+func (o *OpenTelemetry) SetLogger(log log.Modular) {
+	o.log = log.NewModule(".open_telemetry")
+}
+
+// Close stops the OpenTelemetry object from aggregating metrics, exports any
+// remaining metrics and cleans up resources.
+func (o *OpenTelemetry) Close() error {
+	o.closeOnce.Do(func() {
+		close(o.closeChan)
+	})
+	<-o.closedChan
+	return nil
+}
+
+//------------------------------------------------------------------------------
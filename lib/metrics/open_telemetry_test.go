@@ -0,0 +1,150 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package metrics
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestOpenTelemetryInterface(t *testing.T) {
+	o := &OpenTelemetry{}
+	if Type(o) == nil {
+		t.Errorf("Type does not satisfy Type interface.")
+	}
+}
+
+func TestOpenTelemetryExport(t *testing.T) {
+	reqChan := make(chan otlpExportRequest, 10)
+	headerChan := make(chan http.Header, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			t.Error(err)
+		}
+		var exportReq otlpExportRequest
+		if err = json.Unmarshal(body, &exportReq); err != nil {
+			t.Error(err)
+		}
+		headerChan <- r.Header
+		reqChan <- exportReq
+	}))
+	defer srv.Close()
+
+	conf := NewConfig()
+	conf.OpenTelemetry.URL = srv.URL + "/v1/metrics"
+	conf.OpenTelemetry.FlushPeriod = "1h"
+	conf.OpenTelemetry.HistogramBuckets = []float64{0.1, 1}
+	conf.OpenTelemetry.Headers = map[string]string{"X-Api-Key": "foo"}
+	conf.OpenTelemetry.ResourceAttributes = map[string]string{"deployment.environment": "prod"}
+
+	o, err := NewOpenTelemetry(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	o.GetCounter("foo.count").Incr(2)
+	o.GetCounterVec("foo.count", []string{"status"}).With("ok").Incr(3)
+	o.GetGauge("foo.gauge").Set(10)
+	o.GetTimer("foo.latency").Timing(int64(time.Millisecond * 500))
+	o.GetTimer("foo.latency").Timing(int64(time.Second * 2))
+	o.GetTimer("foo.unused")
+	if err = o.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if exp, act := "foo", (<-headerChan).Get("X-Api-Key"); exp != act {
+		t.Errorf("Wrong header: %v != %v", act, exp)
+	}
+	exportReq := <-reqChan
+
+	if exp, act := []otlpKeyValue{
+		{Key: "deployment.environment", Value: otlpAnyValue{StringValue: "prod"}},
+		{Key: "service.name", Value: otlpAnyValue{StringValue: "benthos"}},
+	}, exportReq.ResourceMetrics[0].Resource.Attributes; !reflect.DeepEqual(exp, act) {
+		t.Errorf("Wrong resource attributes: %v != %v", act, exp)
+	}
+
+	metrics := exportReq.ResourceMetrics[0].ScopeMetrics[0].Metrics
+	if exp, act := 3, len(metrics); exp != act {
+		t.Fatalf("Wrong count of metrics: %v != %v", act, exp)
+	}
+
+	if exp, act := "benthos.foo.count", metrics[0].Name; exp != act {
+		t.Errorf("Wrong name: %v != %v", act, exp)
+	}
+	if metrics[0].Sum == nil || !metrics[0].Sum.IsMonotonic {
+		t.Fatal("Expected monotonic sum")
+	}
+	values := map[string]string{}
+	for _, dp := range metrics[0].Sum.DataPoints {
+		status := ""
+		if len(dp.Attributes) > 0 {
+			status = dp.Attributes[0].Value.StringValue
+		}
+		values[status] = dp.AsInt
+	}
+	if exp, act := map[string]string{"": "2", "ok": "3"}, values; !reflect.DeepEqual(exp, act) {
+		t.Errorf("Wrong sum values: %v != %v", act, exp)
+	}
+
+	if exp, act := "benthos.foo.gauge", metrics[1].Name; exp != act {
+		t.Errorf("Wrong name: %v != %v", act, exp)
+	}
+	if metrics[1].Gauge == nil || metrics[1].Gauge.DataPoints[0].AsInt != "10" {
+		t.Errorf("Wrong gauge: %+v", metrics[1].Gauge)
+	}
+
+	if exp, act := "benthos.foo.latency", metrics[2].Name; exp != act {
+		t.Errorf("Wrong name: %v != %v", act, exp)
+	}
+	if metrics[2].Histogram == nil {
+		t.Fatal("Expected histogram")
+	}
+	dp := metrics[2].Histogram.DataPoints[0]
+	if exp, act := []string{"0", "1", "1"}, dp.BucketCounts; !reflect.DeepEqual(exp, act) {
+		t.Errorf("Wrong bucket counts: %v != %v", act, exp)
+	}
+	if exp, act := "2", dp.Count; exp != act {
+		t.Errorf("Wrong count: %v != %v", act, exp)
+	}
+	if exp, act := 2.5, dp.Sum; exp != act {
+		t.Errorf("Wrong sum: %v != %v", act, exp)
+	}
+}
+
+func TestOpenTelemetryBadConfig(t *testing.T) {
+	conf := NewConfig()
+	conf.OpenTelemetry.URL = ""
+	if _, err := NewOpenTelemetry(conf); err == nil {
+		t.Error("Expected error from missing url")
+	}
+
+	conf = NewConfig()
+	conf.OpenTelemetry.HistogramBuckets = []float64{1, 1}
+	if _, err := NewOpenTelemetry(conf); err == nil {
+		t.Error("Expected error from bad buckets")
+	}
+}
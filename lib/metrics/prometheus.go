@@ -21,7 +21,12 @@
 package metrics
 
 import (
+	"errors"
+	"fmt"
+	"io/ioutil"
 	"net/http"
+	"os"
+	"regexp"
 	"strings"
 	"sync"
 
@@ -35,19 +40,71 @@ import (
 func init() {
 	constructors[TypePrometheus] = typeSpec{
 		constructor: NewPrometheus,
-		description: `Host endpoints for Prometheus scraping.`,
+		description: `
+Host endpoints for Prometheus scraping.
+
+Timing metrics are exposed as histograms measured in seconds, where the upper
+bounds of the buckets can be configured with the field
+` + "`histogram_buckets`" + `. Histograms of unitless values, such as batch
+sizes, have buckets with upper bounds of powers of two from 1 to 2048.
+
+When running in streams mode the ID of each stream is added to its metrics as
+the label ` + "`stream`" + `.
+
+By default metric names are the full dot separated path of the metric, which
+means dimensions such as the name of a resource are flattened into the name.
+The field ` + "`path_mapping`" + ` is a list of rules that can extract these
+dimensions as labels instead. Each rule has a regular expression
+` + "`pattern`" + ` that is matched against the path of a metric, and the first
+matching rule is used. The values of named capture groups become labels of the
+metric, and the metric name becomes the field ` + "`name`" + `, which can
+reference capture groups by index with ` + "`$1`" + `, ` + "`$2`" + `, etc. For
+example, the following would move the name of each resource into the label
+` + "`resource`" + `:
+
+` + "``` yaml" + `
+metrics:
+  type: prometheus
+  prometheus:
+    path_mapping:
+    - pattern: ^resource\.(?P<resource>[^.]+)\.(.*)$
+      name: resource.$2
+` + "```" + `
+
+All paths that map to the same metric name must result in the same label
+names, otherwise the original path is used as the name instead.
+
+When ` + "`runtime_collectors`" + ` is true metrics of the Go runtime and the
+Benthos process, such as memory usage and open file descriptors, are also
+exposed.`,
 	}
 }
 
 //------------------------------------------------------------------------------
 
+// PrometheusPathMapping is a rule for mapping a metric path into a Prometheus
+// metric name and labels.
+type PrometheusPathMapping struct {
+	Pattern string `json:"pattern" yaml:"pattern"`
+	Name    string `json:"name" yaml:"name"`
+}
+
 // PrometheusConfig is config for the Prometheus metrics type.
 type PrometheusConfig struct {
+	HistogramBuckets  []float64               `json:"histogram_buckets" yaml:"histogram_buckets"`
+	PathMapping       []PrometheusPathMapping `json:"path_mapping" yaml:"path_mapping"`
+	RuntimeCollectors bool                    `json:"runtime_collectors" yaml:"runtime_collectors"`
 }
 
 // NewPrometheusConfig creates an PrometheusConfig struct with default values.
 func NewPrometheusConfig() PrometheusConfig {
-	return PrometheusConfig{}
+	buckets := make([]float64, len(prometheus.DefBuckets))
+	copy(buckets, prometheus.DefBuckets)
+	return PrometheusConfig{
+		HistogramBuckets:  buckets,
+		PathMapping:       []PrometheusPathMapping{},
+		RuntimeCollectors: true,
+	}
 }
 
 //------------------------------------------------------------------------------
@@ -91,12 +148,25 @@ func (p *PromCounter) Incr(count int64) error {
 // PromTiming is a representation of a single metric stat. Interactions with
 // this stat are thread safe.
 type PromTiming struct {
-	sum prometheus.Summary
+	obs prometheus.Histogram
 }
 
-// Timing sets a timing metric.
+// Timing sets a timing metric, the value is given in nanoseconds and recorded
+// in seconds.
 func (p *PromTiming) Timing(val int64) error {
-	p.sum.Observe(float64(val))
+	p.obs.Observe(float64(val) / 1e9)
+	return nil
+}
+
+// PromHistogram is a representation of a single histogram metric stat.
+// Interactions with this stat are thread safe.
+type PromHistogram struct {
+	obs prometheus.Histogram
+}
+
+// Observe records a value in the histogram.
+func (p *PromHistogram) Observe(val int64) error {
+	p.obs.Observe(float64(val))
 	return nil
 }
 
@@ -104,38 +174,85 @@ func (p *PromTiming) Timing(val int64) error {
 
 // PromCounterVec creates StatCounters with dynamic labels.
 type PromCounterVec struct {
-	ctr *prometheus.CounterVec
+	ctr    *prometheus.CounterVec
+	values []string
 }
 
 // With returns a StatCounter with a set of label values.
 func (p *PromCounterVec) With(labelValues ...string) StatCounter {
 	return &PromCounter{
-		ctr: p.ctr.WithLabelValues(labelValues...),
+		ctr: p.ctr.WithLabelValues(joinLabels(p.values, labelValues)...),
 	}
 }
 
 // PromTimingVec creates StatTimers with dynamic labels.
 type PromTimingVec struct {
-	sum *prometheus.SummaryVec
+	hist   *prometheus.HistogramVec
+	values []string
 }
 
 // With returns a StatTimer with a set of label values.
 func (p *PromTimingVec) With(labelValues ...string) StatTimer {
 	return &PromTiming{
-		sum: p.sum.WithLabelValues(labelValues...),
+		obs: p.hist.WithLabelValues(joinLabels(p.values, labelValues)...),
 	}
 }
 
 // PromGaugeVec creates StatGauges with dynamic labels.
 type PromGaugeVec struct {
-	ctr *prometheus.GaugeVec
+	ctr    *prometheus.GaugeVec
+	values []string
 }
 
 // With returns a StatGauge with a set of label values.
 func (p *PromGaugeVec) With(labelValues ...string) StatGauge {
 	return &PromGauge{
-		ctr: p.ctr.WithLabelValues(labelValues...),
+		ctr: p.ctr.WithLabelValues(joinLabels(p.values, labelValues)...),
+	}
+}
+
+// PromHistogramVec creates StatHistograms with dynamic labels.
+type PromHistogramVec struct {
+	hist   *prometheus.HistogramVec
+	values []string
+}
+
+// With returns a StatHistogram with a set of label values.
+func (p *PromHistogramVec) With(labelValues ...string) StatHistogram {
+	return &PromHistogram{
+		obs: p.hist.WithLabelValues(joinLabels(p.values, labelValues)...),
+	}
+}
+
+func joinLabels(a, b []string) []string {
+	joined := make([]string, 0, len(a)+len(b))
+	joined = append(joined, a...)
+	return append(joined, b...)
+}
+
+//------------------------------------------------------------------------------
+
+type promPathMapping struct {
+	re   *regexp.Regexp
+	name string
+}
+
+var promLabelNameRegexp = regexp.MustCompile("^[a-zA-Z_][a-zA-Z0-9_]*$")
+
+func newPromPathMapping(conf PrometheusPathMapping) (*promPathMapping, error) {
+	re, err := regexp.Compile(conf.Pattern)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile path mapping pattern '%v': %v", conf.Pattern, err)
 	}
+	for _, name := range re.SubexpNames() {
+		if len(name) > 0 && !promLabelNameRegexp.MatchString(name) {
+			return nil, fmt.Errorf("path mapping capture group '%v' is not a valid label name", name)
+		}
+	}
+	return &promPathMapping{
+		re:   re,
+		name: conf.Name,
+	}, nil
 }
 
 //------------------------------------------------------------------------------
@@ -143,12 +260,17 @@ func (p *PromGaugeVec) With(labelValues ...string) StatGauge {
 // Prometheus is a stats object with capability to hold internal stats as a JSON
 // endpoint.
 type Prometheus struct {
-	config Config
-	prefix string
+	config   Config
+	prefix   string
+	log      log.Modular
+	registry *prometheus.Registry
+	mappings []*promPathMapping
 
+	labels   map[string][]string
 	counters map[string]*prometheus.CounterVec
 	gauges   map[string]*prometheus.GaugeVec
-	timers   map[string]*prometheus.SummaryVec
+	timers   map[string]*prometheus.HistogramVec
+	histos   map[string]*prometheus.HistogramVec
 
 	sync.Mutex
 }
@@ -158,9 +280,32 @@ func NewPrometheus(config Config, opts ...func(Type)) (Type, error) {
 	p := &Prometheus{
 		config:   config,
 		prefix:   toPromName(config.Prefix),
+		log:      log.New(ioutil.Discard, log.Config{LogLevel: "OFF"}),
+		registry: prometheus.NewRegistry(),
+		labels:   map[string][]string{},
 		counters: map[string]*prometheus.CounterVec{},
 		gauges:   map[string]*prometheus.GaugeVec{},
-		timers:   map[string]*prometheus.SummaryVec{},
+		timers:   map[string]*prometheus.HistogramVec{},
+		histos:   map[string]*prometheus.HistogramVec{},
+	}
+
+	for _, mConf := range config.Prometheus.PathMapping {
+		mapping, err := newPromPathMapping(mConf)
+		if err != nil {
+			return nil, err
+		}
+		p.mappings = append(p.mappings, mapping)
+	}
+
+	for i, b := range config.Prometheus.HistogramBuckets {
+		if i > 0 && b <= config.Prometheus.HistogramBuckets[i-1] {
+			return nil, errors.New("histogram buckets must be in increasing order")
+		}
+	}
+
+	if config.Prometheus.RuntimeCollectors {
+		p.registry.MustRegister(prometheus.NewProcessCollector(os.Getpid(), ""))
+		p.registry.MustRegister(prometheus.NewGoCollector())
 	}
 
 	for _, opt := range opts {
@@ -174,8 +319,9 @@ func NewPrometheus(config Config, opts ...func(Type)) (Type, error) {
 
 // HandlerFunc returns an http.HandlerFunc for scraping metrics.
 func (p *Prometheus) HandlerFunc() http.HandlerFunc {
+	h := promhttp.HandlerFor(p.registry, promhttp.HandlerOpts{})
 	return func(w http.ResponseWriter, r *http.Request) {
-		promhttp.Handler().ServeHTTP(w, r)
+		h.ServeHTTP(w, r)
 	}
 }
 
@@ -187,75 +333,190 @@ func toPromName(dotSepName string) string {
 	return strings.Replace(dotSepName, ".", "_", -1)
 }
 
-// GetCounter returns a stat counter object for a path.
-func (p *Prometheus) GetCounter(path string) StatCounter {
-	stat := toPromName(path)
+// mapPath converts a dot separated path into a metric name and a set of static
+// labels according to the first matching path mapping.
+func (p *Prometheus) mapPath(path string) (string, []string, []string) {
+	for _, m := range p.mappings {
+		match := m.re.FindStringSubmatchIndex(path)
+		if match == nil {
+			continue
+		}
+		name := path
+		if len(m.name) > 0 {
+			name = string(m.re.ExpandString(nil, m.name, path, match))
+		}
+		var names, values []string
+		for i, label := range m.re.SubexpNames() {
+			if len(label) == 0 {
+				continue
+			}
+			value := ""
+			if match[2*i] >= 0 {
+				value = path[match[2*i]:match[2*i+1]]
+			}
+			names = append(names, label)
+			values = append(values, value)
+		}
+		return toPromName(name), names, values
+	}
+	return toPromName(path), nil, nil
+}
 
-	var ctr *prometheus.CounterVec
+// labelsMatch checks that a metric name is either new or was previously
+// registered with the same label names. Must be called with the lock held.
+func (p *Prometheus) labelsMatch(stat string, labelNames []string) bool {
+	existing, exists := p.labels[stat]
+	if !exists {
+		p.labels[stat] = labelNames
+		return true
+	}
+	if len(existing) != len(labelNames) {
+		return false
+	}
+	for i, n := range existing {
+		if labelNames[i] != n {
+			return false
+		}
+	}
+	return true
+}
 
+// resolve returns the metric name, label names and static label values of a
+// path. If the mapped metric conflicts with the labels of an existing metric
+// then the unmapped path is used instead. Must be called with the lock held.
+func (p *Prometheus) resolve(path string, labelNames []string) (string, []string, []string, bool) {
+	stat, names, values := p.mapPath(path)
+	names = joinLabels(names, labelNames)
+	if p.labelsMatch(stat, names) {
+		return stat, names, values, true
+	}
+	p.log.Warnf("Metric path '%v' maps to '%v' with mismatched labels, falling back to the original path\n", path, stat)
+	stat = toPromName(path)
+	if p.labelsMatch(stat, labelNames) {
+		return stat, labelNames, nil, true
+	}
+	p.log.Errorf("Metric '%v' was registered with mismatched labels\n", stat)
+	return "", nil, nil, false
+}
+
+func (p *Prometheus) getCounterVec(path string, labelNames []string) (*prometheus.CounterVec, []string) {
 	p.Lock()
-	var exists bool
-	if ctr, exists = p.counters[stat]; !exists {
+	defer p.Unlock()
+
+	stat, names, values, ok := p.resolve(path, labelNames)
+	if !ok {
+		return nil, nil
+	}
+	ctr, exists := p.counters[stat]
+	if !exists {
 		ctr = prometheus.NewCounterVec(prometheus.CounterOpts{
 			Namespace: p.prefix,
 			Name:      stat,
 			Help:      "Benthos Counter metric",
-		}, nil)
-		prometheus.MustRegister(ctr)
+		}, names)
+		p.registry.MustRegister(ctr)
 		p.counters[stat] = ctr
 	}
-	p.Unlock()
-
-	return &PromCounter{
-		ctr: ctr.WithLabelValues(),
-	}
+	return ctr, values
 }
 
-// GetTimer returns a stat timer object for a path.
-func (p *Prometheus) GetTimer(path string) StatTimer {
-	stat := toPromName(path)
-
-	var tmr *prometheus.SummaryVec
-
+func (p *Prometheus) getTimerVec(path string, labelNames []string) (*prometheus.HistogramVec, []string) {
 	p.Lock()
-	var exists bool
-	if tmr, exists = p.timers[stat]; !exists {
-		tmr = prometheus.NewSummaryVec(prometheus.SummaryOpts{
+	defer p.Unlock()
+
+	stat, names, values, ok := p.resolve(path, labelNames)
+	if !ok {
+		return nil, nil
+	}
+	tmr, exists := p.timers[stat]
+	if !exists {
+		tmr = prometheus.NewHistogramVec(prometheus.HistogramOpts{
 			Namespace: p.prefix,
 			Name:      stat,
 			Help:      "Benthos Timing metric",
-		}, nil)
-		prometheus.MustRegister(tmr)
+			Buckets:   p.config.Prometheus.HistogramBuckets,
+		}, names)
+		p.registry.MustRegister(tmr)
 		p.timers[stat] = tmr
 	}
-	p.Unlock()
-
-	return &PromTiming{
-		sum: tmr.WithLabelValues(),
-	}
+	return tmr, values
 }
 
-// GetGauge returns a stat gauge object for a path.
-func (p *Prometheus) GetGauge(path string) StatGauge {
-	stat := toPromName(path)
+func (p *Prometheus) getHistogramVec(path string, labelNames []string) (*prometheus.HistogramVec, []string) {
+	p.Lock()
+	defer p.Unlock()
 
-	var ctr *prometheus.GaugeVec
+	stat, names, values, ok := p.resolve(path, labelNames)
+	if !ok {
+		return nil, nil
+	}
+	hist, exists := p.histos[stat]
+	if !exists {
+		hist = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: p.prefix,
+			Name:      stat,
+			Help:      "Benthos Histogram metric",
+			Buckets:   prometheus.ExponentialBuckets(1, 2, 12),
+		}, names)
+		p.registry.MustRegister(hist)
+		p.histos[stat] = hist
+	}
+	return hist, values
+}
 
+func (p *Prometheus) getGaugeVec(path string, labelNames []string) (*prometheus.GaugeVec, []string) {
 	p.Lock()
-	var exists bool
-	if ctr, exists = p.gauges[stat]; !exists {
+	defer p.Unlock()
+
+	stat, names, values, ok := p.resolve(path, labelNames)
+	if !ok {
+		return nil, nil
+	}
+	ctr, exists := p.gauges[stat]
+	if !exists {
 		ctr = prometheus.NewGaugeVec(prometheus.GaugeOpts{
 			Namespace: p.prefix,
 			Name:      stat,
 			Help:      "Benthos Gauge metric",
-		}, nil)
-		prometheus.MustRegister(ctr)
+		}, names)
+		p.registry.MustRegister(ctr)
 		p.gauges[stat] = ctr
 	}
-	p.Unlock()
+	return ctr, values
+}
+
+//------------------------------------------------------------------------------
+
+// GetCounter returns a stat counter object for a path.
+func (p *Prometheus) GetCounter(path string) StatCounter {
+	ctr, values := p.getCounterVec(path, nil)
+	if ctr == nil {
+		return DudStat{}
+	}
+	return &PromCounter{
+		ctr: ctr.WithLabelValues(values...),
+	}
+}
 
+// GetTimer returns a stat timer object for a path.
+func (p *Prometheus) GetTimer(path string) StatTimer {
+	tmr, values := p.getTimerVec(path, nil)
+	if tmr == nil {
+		return DudStat{}
+	}
+	return &PromTiming{
+		obs: tmr.WithLabelValues(values...),
+	}
+}
+
+// GetGauge returns a stat gauge object for a path.
+func (p *Prometheus) GetGauge(path string) StatGauge {
+	ctr, values := p.getGaugeVec(path, nil)
+	if ctr == nil {
+		return DudStat{}
+	}
 	return &PromGauge{
-		ctr: ctr.WithLabelValues(),
+		ctr: ctr.WithLabelValues(values...),
 	}
 }
 
@@ -263,25 +524,13 @@ func (p *Prometheus) GetGauge(path string) StatGauge {
 // these labels must be consistent with any other metrics registered on the same
 // path.
 func (p *Prometheus) GetCounterVec(path string, labelNames []string) StatCounterVec {
-	stat := toPromName(path)
-
-	var ctr *prometheus.CounterVec
-
-	p.Lock()
-	var exists bool
-	if ctr, exists = p.counters[stat]; !exists {
-		ctr = prometheus.NewCounterVec(prometheus.CounterOpts{
-			Namespace: p.prefix,
-			Name:      stat,
-			Help:      "Benthos Counter metric",
-		}, labelNames)
-		prometheus.MustRegister(ctr)
-		p.counters[stat] = ctr
+	ctr, values := p.getCounterVec(path, labelNames)
+	if ctr == nil {
+		return DudType{}.GetCounterVec(path, labelNames)
 	}
-	p.Unlock()
-
 	return &PromCounterVec{
-		ctr: ctr,
+		ctr:    ctr,
+		values: values,
 	}
 }
 
@@ -289,25 +538,13 @@ func (p *Prometheus) GetCounterVec(path string, labelNames []string) StatCounter
 // these labels must be consistent with any other metrics registered on the same
 // path.
 func (p *Prometheus) GetTimerVec(path string, labelNames []string) StatTimerVec {
-	stat := toPromName(path)
-
-	var tmr *prometheus.SummaryVec
-
-	p.Lock()
-	var exists bool
-	if tmr, exists = p.timers[stat]; !exists {
-		tmr = prometheus.NewSummaryVec(prometheus.SummaryOpts{
-			Namespace: p.prefix,
-			Name:      stat,
-			Help:      "Benthos Timing metric",
-		}, labelNames)
-		prometheus.MustRegister(tmr)
-		p.timers[stat] = tmr
+	tmr, values := p.getTimerVec(path, labelNames)
+	if tmr == nil {
+		return DudType{}.GetTimerVec(path, labelNames)
 	}
-	p.Unlock()
-
 	return &PromTimingVec{
-		sum: tmr,
+		hist:   tmr,
+		values: values,
 	}
 }
 
@@ -315,30 +552,38 @@ func (p *Prometheus) GetTimerVec(path string, labelNames []string) StatTimerVec
 // these labels must be consistent with any other metrics registered on the same
 // path.
 func (p *Prometheus) GetGaugeVec(path string, labelNames []string) StatGaugeVec {
-	stat := toPromName(path)
-
-	var ctr *prometheus.GaugeVec
-
-	p.Lock()
-	var exists bool
-	if ctr, exists = p.gauges[stat]; !exists {
-		ctr = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-			Namespace: p.prefix,
-			Name:      stat,
-			Help:      "Benthos Gauge metric",
-		}, labelNames)
-		prometheus.MustRegister(ctr)
-		p.gauges[stat] = ctr
+	ctr, values := p.getGaugeVec(path, labelNames)
+	if ctr == nil {
+		return DudType{}.GetGaugeVec(path, labelNames)
 	}
-	p.Unlock()
-
 	return &PromGaugeVec{
-		ctr: ctr,
+		ctr:    ctr,
+		values: values,
+	}
+}
+
+// GetHistogramVec returns an editable histogram stat for a given path with
+// labels, these labels must be consistent with any other metrics registered on
+// the same path.
+func (p *Prometheus) GetHistogramVec(path string, labelNames []string) StatHistogramVec {
+	hist, values := p.getHistogramVec(path, labelNames)
+	if hist == nil {
+		return DudType{}.GetHistogramVec(path, labelNames)
 	}
+	return &PromHistogramVec{
+		hist:   hist,
+		values: values,
+	}
+}
+
+// SupportsLabels returns true as labels are exported as Prometheus labels.
+func (p *Prometheus) SupportsLabels() bool {
+	return true
 }
 
-// SetLogger does nothing.
+// SetLogger sets the logger used for reporting metric registration issues.
 func (p *Prometheus) SetLogger(log log.Modular) {
+	p.log = log.NewModule(".prometheus")
 }
 
 // Close stops the Prometheus object from aggregating metrics and cleans up
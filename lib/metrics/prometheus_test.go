@@ -0,0 +1,215 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package metrics
+
+import (
+	"sort"
+	"testing"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+)
+
+func TestPrometheusInterface(t *testing.T) {
+	o := &Prometheus{}
+	if Type(o) == nil {
+		t.Errorf("Type does not satisfy Type interface.")
+	}
+}
+
+func gatherPrometheus(t *testing.T, p *Prometheus) map[string]*dto.MetricFamily {
+	t.Helper()
+	families, err := p.registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := map[string]*dto.MetricFamily{}
+	for _, f := range families {
+		m[f.GetName()] = f
+	}
+	return m
+}
+
+func promLabels(m *dto.Metric) map[string]string {
+	labels := map[string]string{}
+	for _, l := range m.GetLabel() {
+		labels[l.GetName()] = l.GetValue()
+	}
+	return labels
+}
+
+func TestPrometheusPathMapping(t *testing.T) {
+	conf := NewConfig()
+	conf.Prometheus.RuntimeCollectors = false
+	conf.Prometheus.PathMapping = []PrometheusPathMapping{
+		{
+			Pattern: `^(?P<stream>[^.]+)\.((input|output)\..*)$`,
+			Name:    "$2",
+		},
+	}
+
+	pType, err := NewPrometheus(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := pType.(*Prometheus)
+
+	p.GetCounter("foo.input.count").Incr(1)
+	p.GetCounter("bar.input.count").Incr(2)
+	p.GetCounter("buffer.count").Incr(3)
+	p.GetCounterVec("baz.output.count", []string{"status"}).With("ok").Incr(4)
+
+	families := gatherPrometheus(t, p)
+
+	inFam, exists := families["benthos_input_count"]
+	if !exists {
+		t.Fatalf("Missing mapped metric: %v", families)
+	}
+	values := map[string]float64{}
+	for _, m := range inFam.GetMetric() {
+		values[promLabels(m)["stream"]] = m.GetCounter().GetValue()
+	}
+	if exp, act := map[string]float64{"foo": 1, "bar": 2}, values; len(act) != 2 || act["foo"] != exp["foo"] || act["bar"] != exp["bar"] {
+		t.Errorf("Wrong values: %v != %v", act, exp)
+	}
+
+	if _, exists = families["benthos_buffer_count"]; !exists {
+		t.Error("Missing unmapped metric")
+	}
+
+	outFam, exists := families["benthos_output_count"]
+	if !exists {
+		t.Fatal("Missing mapped vec metric")
+	}
+	if exp, act := map[string]string{"stream": "baz", "status": "ok"}, promLabels(outFam.GetMetric()[0]); len(act) != 2 || act["stream"] != exp["stream"] || act["status"] != exp["status"] {
+		t.Errorf("Wrong labels: %v != %v", act, exp)
+	}
+
+	// Mismatched labels fall back to the original path.
+	p.GetCounterVec("qux.input.count", []string{"status"}).With("ok").Incr(1)
+	families = gatherPrometheus(t, p)
+	if _, exists = families["benthos_qux_input_count"]; !exists {
+		t.Error("Missing fallback metric")
+	}
+}
+
+func TestPrometheusHistograms(t *testing.T) {
+	conf := NewConfig()
+	conf.Prometheus.RuntimeCollectors = false
+	conf.Prometheus.HistogramBuckets = []float64{0.1, 1}
+
+	pType, err := NewPrometheus(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := pType.(*Prometheus)
+
+	p.GetTimer("foo.latency").Timing(int64(time.Millisecond * 500))
+	p.GetTimer("foo.latency").Timing(int64(time.Second * 2))
+
+	fam, exists := gatherPrometheus(t, p)["benthos_foo_latency"]
+	if !exists {
+		t.Fatal("Missing timing metric")
+	}
+	hist := fam.GetMetric()[0].GetHistogram()
+	if exp, act := uint64(2), hist.GetSampleCount(); exp != act {
+		t.Errorf("Wrong sample count: %v != %v", act, exp)
+	}
+	if exp, act := 2.5, hist.GetSampleSum(); exp != act {
+		t.Errorf("Wrong sample sum: %v != %v", act, exp)
+	}
+	counts := []uint64{}
+	for _, b := range hist.GetBucket() {
+		counts = append(counts, b.GetCumulativeCount())
+	}
+	if exp, act := []uint64{0, 1}, counts; len(act) != 2 || act[0] != exp[0] || act[1] != exp[1] {
+		t.Errorf("Wrong bucket counts: %v != %v", act, exp)
+	}
+}
+
+func TestPrometheusUnitlessHistograms(t *testing.T) {
+	conf := NewConfig()
+	conf.Prometheus.RuntimeCollectors = false
+
+	pType, err := NewPrometheus(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := pType.(*Prometheus)
+
+	hist := GetHistogram(Namespaced(p, "foo"), "batch.size")
+	hist.Observe(3)
+	hist.Observe(100)
+
+	fam, exists := gatherPrometheus(t, p)["benthos_foo_batch_size"]
+	if !exists {
+		t.Fatal("Missing histogram metric")
+	}
+	h := fam.GetMetric()[0].GetHistogram()
+	if exp, act := uint64(2), h.GetSampleCount(); exp != act {
+		t.Errorf("Wrong sample count: %v != %v", act, exp)
+	}
+	if exp, act := 103.0, h.GetSampleSum(); exp != act {
+		t.Errorf("Wrong sample sum: %v != %v", act, exp)
+	}
+	if exp, act := 12, len(h.GetBucket()); exp != act {
+		t.Errorf("Wrong count of buckets: %v != %v", act, exp)
+	}
+}
+
+func TestPrometheusRuntimeCollectors(t *testing.T) {
+	conf := NewConfig()
+
+	pType, err := NewPrometheus(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, exists := gatherPrometheus(t, pType.(*Prometheus))["go_goroutines"]; !exists {
+		t.Error("Missing runtime metrics")
+	}
+
+	conf.Prometheus.RuntimeCollectors = false
+	if pType, err = NewPrometheus(conf); err != nil {
+		t.Fatal(err)
+	}
+	names := []string{}
+	for name := range gatherPrometheus(t, pType.(*Prometheus)) {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	if len(names) > 0 {
+		t.Errorf("Unexpected metrics: %v", names)
+	}
+}
+
+func TestPrometheusBadConfig(t *testing.T) {
+	conf := NewConfig()
+	conf.Prometheus.PathMapping = []PrometheusPathMapping{{Pattern: "(?P<bad-name>.*)"}}
+	if _, err := NewPrometheus(conf); err == nil {
+		t.Error("Expected error from bad label name")
+	}
+
+	conf = NewConfig()
+	conf.Prometheus.HistogramBuckets = []float64{1, 0.5}
+	if _, err := NewPrometheus(conf); err == nil {
+		t.Error("Expected error from bad buckets")
+	}
+}
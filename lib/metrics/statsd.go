@@ -21,11 +21,13 @@
 package metrics
 
 import (
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"time"
 
 	"github.com/Jeffail/benthos/lib/log"
+	btls "github.com/Jeffail/benthos/lib/util/tls"
 	"github.com/quipo/statsd"
 )
 
@@ -34,7 +36,30 @@ import (
 func init() {
 	constructors[TypeStatsd] = typeSpec{
 		constructor: NewStatsd,
-		description: `Use the statsd protocol.`,
+		description: `
+Use the statsd protocol.
+
+The field ` + "`tag_format`" + ` can be used to send the labels of metrics,
+along with the global tags of the field ` + "`tags`" + `, as statsd tags. The
+format ` + "`datadog`" + ` writes tags in the DogStatsD format
+(` + "`name:1|c|#foo:bar`" + `) and the format ` + "`influxdb`" + ` writes tags
+in the InfluxDB statsd format (` + "`name,foo=bar:1|c`" + `). When set to
+` + "`none`" + ` labels are discarded and tags cannot be used.
+
+Global tags can be set from environment variables with config interpolation:
+
+` + "``` yaml" + `
+metrics:
+  type: statsd
+  statsd:
+    address: localhost:8125
+    tag_format: datadog
+    tags:
+      env: ${ENVIRONMENT:dev}
+` + "```" + `
+
+Connections can be secured with TLS by enabling the ` + "`tls`" + ` section, which
+requires the ` + "`tcp`" + ` network.`,
 	}
 }
 
@@ -52,9 +77,12 @@ func (w *wrappedLogger) Println(v ...interface{}) {
 
 // StatsdConfig is config for the Statsd metrics type.
 type StatsdConfig struct {
-	Address     string `json:"address" yaml:"address"`
-	FlushPeriod string `json:"flush_period" yaml:"flush_period"`
-	Network     string `json:"network" yaml:"network"`
+	Address     string            `json:"address" yaml:"address"`
+	FlushPeriod string            `json:"flush_period" yaml:"flush_period"`
+	Network     string            `json:"network" yaml:"network"`
+	TagFormat   string            `json:"tag_format" yaml:"tag_format"`
+	Tags        map[string]string `json:"tags" yaml:"tags"`
+	TLS         btls.Config       `json:"tls" yaml:"tls"`
 }
 
 // NewStatsdConfig creates an StatsdConfig struct with default values.
@@ -63,6 +91,9 @@ func NewStatsdConfig() StatsdConfig {
 		Address:     "localhost:4040",
 		FlushPeriod: "100ms",
 		Network:     "udp",
+		TagFormat:   "none",
+		Tags:        map[string]string{},
+		TLS:         btls.NewConfig(),
 	}
 }
 
@@ -106,6 +137,7 @@ func (s *StatsdStat) Set(value int64) error {
 type Statsd struct {
 	config Config
 	s      statsd.Statsd
+	tagged *statsdTaggedClient
 	log    log.Modular
 }
 
@@ -128,6 +160,30 @@ func NewStatsd(config Config, opts ...func(Type)) (Type, error) {
 		prefix = prefix + "."
 	}
 
+	tagged := false
+	switch config.Statsd.TagFormat {
+	case "datadog", "influxdb":
+		tagged = true
+	case "", "none":
+		if len(config.Statsd.Tags) > 0 {
+			return nil, errors.New("tags cannot be used without a tag_format")
+		}
+	default:
+		return nil, fmt.Errorf("tag format not recognised: %v", config.Statsd.TagFormat)
+	}
+	if config.Statsd.TLS.Enabled && config.Statsd.Network == "udp" {
+		return nil, errors.New("tls cannot be used with the udp network")
+	}
+
+	// The statsd library supports neither tags nor TLS, and so our own client
+	// is used when either is needed.
+	if tagged || config.Statsd.TLS.Enabled {
+		if s.tagged, err = newStatsdTaggedClient(config.Statsd, prefix, flushPeriod, s.log); err != nil {
+			return nil, err
+		}
+		return s, nil
+	}
+
 	statsdclient := statsd.NewStatsdBuffer(
 		flushPeriod,
 		statsd.NewStatsdClient(config.Statsd.Address, prefix),
@@ -150,6 +206,9 @@ func NewStatsd(config Config, opts ...func(Type)) (Type, error) {
 
 // GetCounter returns a stat counter object for a path.
 func (h *Statsd) GetCounter(path string) StatCounter {
+	if h.tagged != nil {
+		return h.tagged.getStat(path, nil, nil)
+	}
 	return &StatsdStat{
 		path: path,
 		s:    h.s,
@@ -157,8 +216,15 @@ func (h *Statsd) GetCounter(path string) StatCounter {
 }
 
 // GetCounterVec returns a stat counter object for a path with the labels
-// discarded.
+// sent as tags, or discarded when no tag format is set.
 func (h *Statsd) GetCounterVec(path string, n []string) StatCounterVec {
+	if h.tagged != nil {
+		return &statsdTaggedCounterVec{
+			path:   path,
+			labels: n,
+			c:      h.tagged,
+		}
+	}
 	return fakeCounterVec(func() StatCounter {
 		return &StatsdStat{
 			path: path,
@@ -169,6 +235,9 @@ func (h *Statsd) GetCounterVec(path string, n []string) StatCounterVec {
 
 // GetTimer returns a stat timer object for a path.
 func (h *Statsd) GetTimer(path string) StatTimer {
+	if h.tagged != nil {
+		return h.tagged.getStat(path, nil, nil)
+	}
 	return &StatsdStat{
 		path: path,
 		s:    h.s,
@@ -176,8 +245,15 @@ func (h *Statsd) GetTimer(path string) StatTimer {
 }
 
 // GetTimerVec returns a stat timer object for a path with the labels
-// discarded.
+// sent as tags, or discarded when no tag format is set.
 func (h *Statsd) GetTimerVec(path string, n []string) StatTimerVec {
+	if h.tagged != nil {
+		return &statsdTaggedTimerVec{
+			path:   path,
+			labels: n,
+			c:      h.tagged,
+		}
+	}
 	return fakeTimerVec(func() StatTimer {
 		return &StatsdStat{
 			path: path,
@@ -188,15 +264,25 @@ func (h *Statsd) GetTimerVec(path string, n []string) StatTimerVec {
 
 // GetGauge returns a stat gauge object for a path.
 func (h *Statsd) GetGauge(path string) StatGauge {
+	if h.tagged != nil {
+		return h.tagged.getStat(path, nil, nil)
+	}
 	return &StatsdStat{
 		path: path,
 		s:    h.s,
 	}
 }
 
-// GetGaugeVec returns a stat timer object for a path with the labels
-// discarded.
+// GetGaugeVec returns a stat gauge object for a path with the labels
+// sent as tags, or discarded when no tag format is set.
 func (h *Statsd) GetGaugeVec(path string, n []string) StatGaugeVec {
+	if h.tagged != nil {
+		return &statsdTaggedGaugeVec{
+			path:   path,
+			labels: n,
+			c:      h.tagged,
+		}
+	}
 	return fakeGaugeVec(func() StatGauge {
 		return &StatsdStat{
 			path: path,
@@ -213,6 +299,9 @@ func (h *Statsd) SetLogger(log log.Modular) {
 // Close stops the Statsd object from aggregating metrics and cleans up
 // resources.
 func (h *Statsd) Close() error {
+	if h.tagged != nil {
+		return h.tagged.Close()
+	}
 	h.s.Close()
 	return nil
 }
@@ -0,0 +1,276 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package metrics
+
+import (
+	"bytes"
+	"crypto/tls"
+	"net"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/log"
+)
+
+//------------------------------------------------------------------------------
+
+// statsdMaxPacketSize is the largest payload written to a UDP socket in one
+// go, chosen to fit within a typical MTU.
+const statsdMaxPacketSize = 1432
+
+// statsdTag is a single key/value pair attached to a tagged statsd metric.
+type statsdTag struct {
+	k, v string
+}
+
+// formatStatsdTags returns the tags of a metric encoded in a given format.
+func formatStatsdTags(format string, tags []statsdTag) string {
+	if len(tags) == 0 {
+		return ""
+	}
+	var buf bytes.Buffer
+	switch format {
+	case "datadog":
+		buf.WriteString("|#")
+		for i, t := range tags {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			buf.WriteString(t.k)
+			buf.WriteByte(':')
+			buf.WriteString(t.v)
+		}
+	case "influxdb":
+		for _, t := range tags {
+			buf.WriteByte(',')
+			buf.WriteString(t.k)
+			buf.WriteByte('=')
+			buf.WriteString(t.v)
+		}
+	}
+	return buf.String()
+}
+
+//------------------------------------------------------------------------------
+
+// statsdTaggedStat is a stat of a statsdTaggedClient with a fixed set of tags.
+type statsdTaggedStat struct {
+	name string
+	tags string
+	c    *statsdTaggedClient
+}
+
+func (s *statsdTaggedStat) Incr(count int64) error {
+	s.c.send(s.name, s.tags, strconv.FormatInt(count, 10), "c")
+	return nil
+}
+
+func (s *statsdTaggedStat) Decr(count int64) error {
+	s.c.send(s.name, s.tags, strconv.FormatInt(-count, 10), "c")
+	return nil
+}
+
+func (s *statsdTaggedStat) Timing(delta int64) error {
+	s.c.send(s.name, s.tags, strconv.FormatInt(delta, 10), "ms")
+	return nil
+}
+
+func (s *statsdTaggedStat) Set(value int64) error {
+	s.c.send(s.name, s.tags, strconv.FormatInt(value, 10), "g")
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+// statsdTaggedClient writes statsd metrics with tags, which are not supported
+// by the underlying statsd library. Lines are buffered and written either when
+// a packet is full or each flush period.
+type statsdTaggedClient struct {
+	format string
+	prefix string
+	tags   []statsdTag
+
+	conn net.Conn
+	buf  bytes.Buffer
+	mut  sync.Mutex
+
+	log log.Modular
+
+	closeOnce  sync.Once
+	closeChan  chan struct{}
+	closedChan chan struct{}
+}
+
+func newStatsdTaggedClient(
+	conf StatsdConfig,
+	prefix string,
+	flushPeriod time.Duration,
+	log log.Modular,
+) (*statsdTaggedClient, error) {
+	network := "udp"
+	if conf.Network != "udp" {
+		network = "tcp"
+	}
+	var conn net.Conn
+	if conf.TLS.Enabled {
+		tlsConf, err := conf.TLS.Get()
+		if err != nil {
+			return nil, err
+		}
+		if conn, err = tls.Dial(network, conf.Address, tlsConf); err != nil {
+			return nil, err
+		}
+	} else {
+		var err error
+		if conn, err = net.Dial(network, conf.Address); err != nil {
+			return nil, err
+		}
+	}
+
+	c := &statsdTaggedClient{
+		format:     conf.TagFormat,
+		prefix:     prefix,
+		conn:       conn,
+		log:        log,
+		closeChan:  make(chan struct{}),
+		closedChan: make(chan struct{}),
+	}
+	for k, v := range conf.Tags {
+		c.tags = append(c.tags, statsdTag{k: k, v: v})
+	}
+	sort.Slice(c.tags, func(i, j int) bool {
+		return c.tags[i].k < c.tags[j].k
+	})
+
+	go c.loop(flushPeriod)
+	return c, nil
+}
+
+// getStat returns a stat for a path with the global tags and a set of labels.
+func (c *statsdTaggedClient) getStat(path string, labelNames, labelValues []string) *statsdTaggedStat {
+	tags := make([]statsdTag, 0, len(c.tags)+len(labelNames))
+	tags = append(tags, c.tags...)
+	for i, n := range labelNames {
+		if i < len(labelValues) {
+			tags = append(tags, statsdTag{k: n, v: labelValues[i]})
+		}
+	}
+	return &statsdTaggedStat{
+		name: c.prefix + path,
+		tags: formatStatsdTags(c.format, tags),
+		c:    c,
+	}
+}
+
+func (c *statsdTaggedClient) send(name, tags, value, kind string) {
+	var line string
+	if c.format == "influxdb" {
+		line = name + tags + ":" + value + "|" + kind
+	} else {
+		line = name + ":" + value + "|" + kind + tags
+	}
+
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	if c.buf.Len() > 0 && c.buf.Len()+len(line)+1 > statsdMaxPacketSize {
+		c.flush()
+	}
+	c.buf.WriteString(line)
+	c.buf.WriteByte('\n')
+}
+
+// flush writes all buffered lines. Must be called with the lock held.
+func (c *statsdTaggedClient) flush() {
+	if c.buf.Len() == 0 {
+		return
+	}
+	if _, err := c.conn.Write(c.buf.Bytes()); err != nil {
+		c.log.Errorf("Failed to send metrics: %v\n", err)
+	}
+	c.buf.Reset()
+}
+
+func (c *statsdTaggedClient) loop(flushPeriod time.Duration) {
+	defer close(c.closedChan)
+
+	ticker := time.NewTicker(flushPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-c.closeChan:
+			c.mut.Lock()
+			c.flush()
+			c.mut.Unlock()
+			c.conn.Close()
+			return
+		}
+		c.mut.Lock()
+		c.flush()
+		c.mut.Unlock()
+	}
+}
+
+func (c *statsdTaggedClient) Close() error {
+	c.closeOnce.Do(func() {
+		close(c.closeChan)
+	})
+	<-c.closedChan
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+type statsdTaggedCounterVec struct {
+	path   string
+	labels []string
+	c      *statsdTaggedClient
+}
+
+func (v *statsdTaggedCounterVec) With(labelValues ...string) StatCounter {
+	return v.c.getStat(v.path, v.labels, labelValues)
+}
+
+type statsdTaggedTimerVec struct {
+	path   string
+	labels []string
+	c      *statsdTaggedClient
+}
+
+func (v *statsdTaggedTimerVec) With(labelValues ...string) StatTimer {
+	return v.c.getStat(v.path, v.labels, labelValues)
+}
+
+type statsdTaggedGaugeVec struct {
+	path   string
+	labels []string
+	c      *statsdTaggedClient
+}
+
+func (v *statsdTaggedGaugeVec) With(labelValues ...string) StatGauge {
+	return v.c.getStat(v.path, v.labels, labelValues)
+}
+
+//------------------------------------------------------------------------------
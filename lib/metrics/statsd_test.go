@@ -0,0 +1,235 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package metrics
+
+import (
+	"bufio"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStatsdInterface(t *testing.T) {
+	s := &Statsd{}
+	if Type(s) == nil {
+		t.Errorf("Type does not satisfy Type interface.")
+	}
+}
+
+func readStatsdLines(t *testing.T, conn net.PacketConn, n int) []string {
+	t.Helper()
+
+	lines := []string{}
+	buf := make([]byte, 2048)
+	for len(lines) < n {
+		conn.SetReadDeadline(time.Now().Add(time.Second * 5))
+		l, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, line := range strings.Split(strings.TrimSpace(string(buf[:l])), "\n") {
+			lines = append(lines, line)
+		}
+	}
+	sort.Strings(lines)
+	return lines
+}
+
+func TestStatsdTagFormats(t *testing.T) {
+	tests := map[string][]string{
+		"datadog": {
+			"benthos.foo.count:3|c|#env:prod",
+			"benthos.foo.gauge:5|g|#env:prod",
+			"benthos.foo.latency:10|ms|#env:prod",
+			"benthos.foo.vec:1|c|#env:prod,status:ok",
+		},
+		"influxdb": {
+			"benthos.foo.count,env=prod:3|c",
+			"benthos.foo.gauge,env=prod:5|g",
+			"benthos.foo.latency,env=prod:10|ms",
+			"benthos.foo.vec,env=prod,status=ok:1|c",
+		},
+	}
+
+	for format, exp := range tests {
+		conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		conf := NewConfig()
+		conf.Statsd.Address = conn.LocalAddr().String()
+		conf.Statsd.FlushPeriod = "1h"
+		conf.Statsd.TagFormat = format
+		conf.Statsd.Tags = map[string]string{"env": "prod"}
+
+		s, err := NewStatsd(conf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		s.GetCounter("foo.count").Incr(3)
+		s.GetGauge("foo.gauge").Set(5)
+		s.GetTimer("foo.latency").Timing(10)
+		s.GetCounterVec("foo.vec", []string{"status"}).With("ok").Incr(1)
+		if err = s.Close(); err != nil {
+			t.Error(err)
+		}
+
+		if act := readStatsdLines(t, conn, len(exp)); strings.Join(exp, "\n") != strings.Join(act, "\n") {
+			t.Errorf("Wrong lines for %v: %v != %v", format, act, exp)
+		}
+		conn.Close()
+	}
+}
+
+func TestStatsdTaggedPacketSize(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	conf := NewConfig()
+	conf.Statsd.Address = conn.LocalAddr().String()
+	conf.Statsd.FlushPeriod = "1h"
+	conf.Statsd.TagFormat = "datadog"
+
+	s, err := NewStatsd(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctr := s.GetCounter(strings.Repeat("a", 100))
+	for i := 0; i < 50; i++ {
+		ctr.Incr(1)
+	}
+
+	conn.SetReadDeadline(time.Now().Add(time.Second * 5))
+	buf := make([]byte, 4096)
+	l, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l > statsdMaxPacketSize {
+		t.Errorf("Packet exceeded max size: %v", l)
+	}
+	s.Close()
+}
+
+func TestStatsdBadTags(t *testing.T) {
+	conf := NewConfig()
+	conf.Statsd.Tags = map[string]string{"foo": "bar"}
+	if _, err := NewStatsd(conf); err == nil {
+		t.Error("Expected error from tags without format")
+	}
+
+	conf = NewConfig()
+	conf.Statsd.TagFormat = "nope"
+	if _, err := NewStatsd(conf); err == nil {
+		t.Error("Expected error from bad tag format")
+	}
+}
+
+func TestStatsdTLS(t *testing.T) {
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "benthos test"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &tmpl, &tmpl, &priv.PublicKey, priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{
+		Certificates: []tls.Certificate{{
+			Certificate: [][]byte{der},
+			PrivateKey:  priv,
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	linesChan := make(chan []string, 1)
+	go func() {
+		conn, aerr := ln.Accept()
+		if aerr != nil {
+			linesChan <- nil
+			return
+		}
+		defer conn.Close()
+		var lines []string
+		scanner := bufio.NewScanner(conn)
+		for scanner.Scan() {
+			lines = append(lines, scanner.Text())
+		}
+		linesChan <- lines
+	}()
+
+	conf := NewConfig()
+	conf.Statsd.Address = ln.Addr().String()
+	conf.Statsd.FlushPeriod = "1h"
+	conf.Statsd.Network = "tcp"
+	conf.Statsd.TLS.Enabled = true
+	conf.Statsd.TLS.RootCAs = string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+
+	s, err := NewStatsd(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.GetCounter("foo.count").Incr(3)
+	if err = s.Close(); err != nil {
+		t.Error(err)
+	}
+
+	select {
+	case lines := <-linesChan:
+		if exp, act := "benthos.foo.count:3|c", strings.Join(lines, "\n"); exp != act {
+			t.Errorf("Wrong lines: %v != %v", act, exp)
+		}
+	case <-time.After(time.Second * 5):
+		t.Fatal("timed out")
+	}
+
+	conf.Statsd.Network = "udp"
+	if _, err = NewStatsd(conf); err == nil {
+		t.Error("Expected error from tls with udp")
+	}
+}
@@ -119,4 +119,11 @@ type WithHandlerFunc interface {
 	HandlerFunc() http.HandlerFunc
 }
 
+// WithLabels is an interface for metrics types that can report whether labels
+// are preserved by their target. Types that do not implement WithLabels are
+// assumed to discard labels.
+type WithLabels interface {
+	SupportsLabels() bool
+}
+
 //------------------------------------------------------------------------------
@@ -69,3 +69,19 @@ func fakeGaugeVec(f func() StatGauge) StatGaugeVec {
 }
 
 //------------------------------------------------------------------------------
+
+type fHistogramVec struct {
+	f func() StatHistogram
+}
+
+func (f *fHistogramVec) With(labels ...string) StatHistogram {
+	return f.f()
+}
+
+func fakeHistogramVec(f func() StatHistogram) StatHistogramVec {
+	return &fHistogramVec{
+		f: f,
+	}
+}
+
+//------------------------------------------------------------------------------
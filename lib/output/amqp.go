@@ -41,13 +41,26 @@ It's possible for this output type to create the target exchange by setting
 ` + "`exchange_declare.enabled` to `true`" + `, if the exchange already exists
 then the declaration passively verifies that the settings match.
 
-Exchange type options are: direct|fanout|topic|x-custom
+Exchange type options are: direct|fanout|topic|headers|x-custom
 
 TLS is automatic when connecting to an ` + "`amqps`" + ` URL, but custom
 settings can be enabled in the ` + "`tls`" + ` section.
 
-The field 'key' can be dynamically set using function interpolations described
-[here](../config_interpolation.md#functions).`,
+The fields 'key', 'priority' and 'expiration' can be dynamically set using
+function interpolations described
+[here](../config_interpolation.md#functions). When sending batched messages
+these interpolations are performed per message part. The priority must resolve
+to an integer between 0 and 255, and the expiration to a TTL in milliseconds.
+
+Each message is published with publisher confirms enabled and the output waits
+for the broker to acknowledge it. The field ` + "`confirm_timeout`" + ` can be set
+to a duration string in order to limit this wait, after which the connection is
+reset and the message is retried. When the ` + "`mandatory` or `immediate`" + `
+flags are set messages returned by the broker are also treated as failures.
+
+Which metadata keys are sent as headers, and the names they are given, can be
+configured within the ` + "`metadata`" + ` section as described
+[here](../metadata.md).`,
 	}
 }
 
@@ -0,0 +1,211 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package output
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/log"
+	"github.com/Jeffail/benthos/lib/message/batch"
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/response"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/throttle"
+)
+
+//------------------------------------------------------------------------------
+
+// Batcher wraps an output with a batching policy. Messages are accumulated
+// until the policy triggers, at which point they are sent to the child output
+// as a single batch.
+//
+// Messages added to a pending batch are responded to with an Unack, which
+// instructs the source to continue sending messages without acknowledging them
+// yet. Since those messages can no longer be resent by the source a flushed
+// batch is retried until it succeeds, and only then is the message that
+// completed it acknowledged.
+type Batcher struct {
+	running int32
+
+	stats metrics.Type
+	log   log.Modular
+
+	child  Type
+	policy *batch.Policy
+
+	errThrottle *throttle.Type
+
+	messagesIn  <-chan types.Transaction
+	messagesOut chan types.Transaction
+
+	closeChan  chan struct{}
+	closedChan chan struct{}
+}
+
+// NewBatcher creates an output that groups messages into batches according to
+// a policy before writing them to a child output.
+func NewBatcher(
+	policy *batch.Policy,
+	child Type,
+	log log.Modular,
+	stats metrics.Type,
+) Type {
+	m := &Batcher{
+		running:     1,
+		stats:       stats,
+		log:         log,
+		child:       child,
+		policy:      policy,
+		messagesOut: make(chan types.Transaction),
+		closeChan:   make(chan struct{}),
+		closedChan:  make(chan struct{}),
+	}
+	m.errThrottle = throttle.New(throttle.OptCloseChan(m.closeChan))
+	return m
+}
+
+//------------------------------------------------------------------------------
+
+func (m *Batcher) loop() {
+	var (
+		mSent      = m.stats.GetCounter("output.batch.sent")
+		mSentParts = m.stats.GetCounter("output.batch.parts.sent")
+		mSendErr   = m.stats.GetCounter("output.batch.send.error")
+	)
+
+	defer func() {
+		close(m.messagesOut)
+		m.child.CloseAsync()
+		err := m.child.WaitForClose(time.Second)
+		for ; err != nil; err = m.child.WaitForClose(time.Second) {
+		}
+		close(m.closedChan)
+	}()
+
+	resChan := make(chan types.Response)
+
+	// send writes the current batch to the child output until it succeeds and
+	// returns the response, or nil if the batcher was closed.
+	send := func() types.Response {
+		msg := m.policy.Flush()
+		if msg == nil {
+			return response.NewAck()
+		}
+		for {
+			select {
+			case m.messagesOut <- types.NewTransaction(msg, resChan):
+			case <-m.closeChan:
+				return nil
+			}
+			var res types.Response
+			select {
+			case res = <-resChan:
+			case <-m.closeChan:
+				return nil
+			}
+			if res.Error() == nil {
+				mSent.Incr(1)
+				mSentParts.Incr(int64(msg.Len()))
+				m.errThrottle.Reset()
+				return res
+			}
+			mSendErr.Incr(1)
+			m.log.Errorf("Failed to send batch: %v\n", res.Error())
+			if !m.errThrottle.Retry() {
+				return nil
+			}
+		}
+	}
+
+	var nextTimedBatchChan <-chan time.Time
+	for atomic.LoadInt32(&m.running) == 1 {
+		if nextTimedBatchChan == nil {
+			if tNext := m.policy.UntilNext(); tNext >= 0 {
+				nextTimedBatchChan = time.After(tNext)
+			}
+		}
+
+		var tran types.Transaction
+		var open bool
+		select {
+		case tran, open = <-m.messagesIn:
+			if !open {
+				send()
+				return
+			}
+		case <-nextTimedBatchChan:
+			nextTimedBatchChan = nil
+			if send() == nil {
+				return
+			}
+			continue
+		case <-m.closeChan:
+			return
+		}
+
+		var res types.Response = response.NewUnack()
+		if m.policy.Add(tran.Payload) {
+			nextTimedBatchChan = nil
+			if res = send(); res == nil {
+				return
+			}
+		}
+
+		select {
+		case tran.ResponseChan <- res:
+		case <-m.closeChan:
+			return
+		}
+	}
+}
+
+// Consume assigns a messages channel for the output to read.
+func (m *Batcher) Consume(msgs <-chan types.Transaction) error {
+	if m.messagesIn != nil {
+		return types.ErrAlreadyStarted
+	}
+	if err := m.child.Consume(m.messagesOut); err != nil {
+		return err
+	}
+	m.messagesIn = msgs
+	go m.loop()
+	return nil
+}
+
+// CloseAsync shuts down the Batcher and stops processing messages.
+func (m *Batcher) CloseAsync() {
+	if atomic.CompareAndSwapInt32(&m.running, 1, 0) {
+		close(m.closeChan)
+	}
+}
+
+// WaitForClose blocks until the Batcher output has closed down.
+func (m *Batcher) WaitForClose(timeout time.Duration) error {
+	select {
+	case <-m.closedChan:
+	case <-time.After(timeout):
+		return types.ErrTimeout
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,210 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package output
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/log"
+	"github.com/Jeffail/benthos/lib/message"
+	"github.com/Jeffail/benthos/lib/message/batch"
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/response"
+	"github.com/Jeffail/benthos/lib/types"
+)
+
+func newTestBatcher(t *testing.T, conf batch.PolicyConfig) (*Batcher, *mockOutput, chan types.Transaction) {
+	t.Helper()
+
+	policy, err := batch.NewPolicy(conf, types.NoopMgr(), log.Noop(), metrics.Noop())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mOut := &mockOutput{}
+	b := NewBatcher(policy, mOut, log.Noop(), metrics.Noop()).(*Batcher)
+
+	tChan := make(chan types.Transaction)
+	if err = b.Consume(tChan); err != nil {
+		t.Fatal(err)
+	}
+	return b, mOut, tChan
+}
+
+func TestBatcherCount(t *testing.T) {
+	conf := batch.NewPolicyConfig()
+	conf.Count = 3
+
+	b, mOut, tChan := newTestBatcher(t, conf)
+	resChan := make(chan types.Response)
+
+	for i, content := range []string{"foo", "bar"} {
+		select {
+		case tChan <- types.NewTransaction(message.New([][]byte{[]byte(content)}), resChan):
+		case <-time.After(time.Second):
+			t.Fatal("timed out")
+		}
+		select {
+		case res := <-resChan:
+			if !res.SkipAck() {
+				t.Errorf("Expected unack response for message %v", i)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("timed out")
+		}
+	}
+
+	go func() {
+		select {
+		case tChan <- types.NewTransaction(message.New([][]byte{[]byte("baz")}), resChan):
+		case <-time.After(time.Second):
+			t.Error("timed out")
+		}
+	}()
+
+	// The earlier messages of the batch have already been responded to, and so
+	// a failed batch must be retried rather than returned to the message that
+	// completed it.
+	for _, res := range []types.Response{
+		response.NewError(errors.New("test err")),
+		response.NewAck(),
+	} {
+		var tran types.Transaction
+		select {
+		case tran = <-mOut.ts:
+		case <-time.After(time.Second * 5):
+			t.Fatal("timed out")
+		}
+		if exp, act := [][]byte{[]byte("foo"), []byte("bar"), []byte("baz")}, message.GetAllBytes(tran.Payload); !reflect.DeepEqual(exp, act) {
+			t.Errorf("Wrong batch: %s != %s", act, exp)
+		}
+		select {
+		case tran.ResponseChan <- res:
+		case <-time.After(time.Second):
+			t.Fatal("timed out")
+		}
+	}
+
+	select {
+	case res := <-resChan:
+		if res.Error() != nil {
+			t.Errorf("Unexpected error response: %v", res.Error())
+		}
+		if res.SkipAck() {
+			t.Error("Expected ack response")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out")
+	}
+
+	b.CloseAsync()
+	if err := b.WaitForClose(time.Second); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestBatcherPeriod(t *testing.T) {
+	conf := batch.NewPolicyConfig()
+	conf.PeriodMS = 50
+
+	b, mOut, tChan := newTestBatcher(t, conf)
+	resChan := make(chan types.Response)
+
+	select {
+	case tChan <- types.NewTransaction(message.New([][]byte{[]byte("foo")}), resChan):
+	case <-time.After(time.Second):
+		t.Fatal("timed out")
+	}
+	select {
+	case <-resChan:
+	case <-time.After(time.Second):
+		t.Fatal("timed out")
+	}
+
+	// The batch should be sent without any further messages, and retried on
+	// failure.
+	for _, res := range []types.Response{
+		response.NewError(errors.New("test err")),
+		response.NewAck(),
+	} {
+		var tran types.Transaction
+		select {
+		case tran = <-mOut.ts:
+		case <-time.After(time.Second * 5):
+			t.Fatal("timed out")
+		}
+		if exp, act := "foo", string(tran.Payload.Get(0).Get()); exp != act {
+			t.Errorf("Wrong message: %v != %v", act, exp)
+		}
+		select {
+		case tran.ResponseChan <- res:
+		case <-time.After(time.Second):
+			t.Fatal("timed out")
+		}
+	}
+
+	b.CloseAsync()
+	if err := b.WaitForClose(time.Second); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestBatcherFlushOnClose(t *testing.T) {
+	conf := batch.NewPolicyConfig()
+	conf.Count = 10
+
+	b, mOut, tChan := newTestBatcher(t, conf)
+	resChan := make(chan types.Response)
+
+	select {
+	case tChan <- types.NewTransaction(message.New([][]byte{[]byte("foo")}), resChan):
+	case <-time.After(time.Second):
+		t.Fatal("timed out")
+	}
+	select {
+	case <-resChan:
+	case <-time.After(time.Second):
+		t.Fatal("timed out")
+	}
+	close(tChan)
+
+	var tran types.Transaction
+	select {
+	case tran = <-mOut.ts:
+	case <-time.After(time.Second):
+		t.Fatal("timed out")
+	}
+	if exp, act := "foo", string(tran.Payload.Get(0).Get()); exp != act {
+		t.Errorf("Wrong message: %v != %v", act, exp)
+	}
+	select {
+	case tran.ResponseChan <- response.NewAck():
+	case <-time.After(time.Second):
+		t.Fatal("timed out")
+	}
+
+	if err := b.WaitForClose(time.Second); err != nil {
+		t.Error(err)
+	}
+}
@@ -23,11 +23,15 @@ package output
 import (
 	"errors"
 	"fmt"
+	"net"
+	"net/http"
+	"time"
 
 	"github.com/Jeffail/benthos/lib/broker"
 	"github.com/Jeffail/benthos/lib/log"
 	"github.com/Jeffail/benthos/lib/metrics"
 	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/text"
 )
 
 //------------------------------------------------------------------------------
@@ -106,6 +110,87 @@ targets have broken. For example, if you had an output type ` + "`http_client`"
 but wished to reroute messages whenever the endpoint becomes unreachable you
 could use a try broker.
 
+#### ` + "`weighted`" + `
+
+The weighted pattern sends each message to a single output, where the
+proportion of messages sent to each output is determined by the field
+` + "`weights`" + `, which must contain a weight for each output in the list.
+For example, weights of ` + "`[ 95, 5 ]`" + ` would send approximately 5% of
+messages to the second output, which is useful for canarying a new consumer:
+
+` + "``` yaml" + `
+output:
+  type: broker
+  broker:
+    pattern: weighted
+    weights: [ 95, 5 ]
+    key: ${!json_field:user.id}
+    outputs:
+    - type: kafka
+      kafka:
+        addresses: [ old-cluster:9092 ]
+        topic: foo
+    - type: kafka
+      kafka:
+        addresses: [ new-cluster:9092 ]
+        topic: foo
+` + "```" + `
+
+If the field ` + "`key`" + ` is empty outputs are chosen at random. Otherwise
+the key is resolved for each message using
+[interpolation functions](../config_interpolation.md#functions) and hashed,
+so that all messages with the same key are sent to the same output. When the
+weights are changed gradually only the keys of the outputs that lose weight are
+moved, which allows traffic to be migrated between outputs in stages.
+
+#### ` + "`failover`" + `
+
+The failover pattern sends all messages to the first healthy output in the
+list, which allows a primary output to be configured with one or more standbys,
+such as the same service hosted in a different region:
+
+` + "``` yaml" + `
+output:
+  type: broker
+  broker:
+    pattern: failover
+    failover:
+      interval_ms: 5000
+      timeout_ms: 2000
+      unhealthy_threshold: 3
+      healthy_threshold: 3
+      probes:
+      - type: http
+        url: http://primary.example.com/ready
+      - type: tcp
+        address: secondary.example.com:443
+    outputs:
+    - type: http_client
+      http_client:
+        url: http://primary.example.com/post
+    - type: http_client
+      http_client:
+        url: http://secondary.example.com/post
+` + "```" + `
+
+The health of each output is determined by periodically running the probe at
+the same index within the field ` + "`probes`" + `, which must either be empty
+or contain a probe for each output. A probe of type ` + "`http`" + ` performs a
+GET request to ` + "`url`" + ` and succeeds when a 2XX status code is returned,
+a probe of type ` + "`tcp`" + ` succeeds when a connection can be opened to
+` + "`address`" + `, and a probe of type ` + "`none`" + ` always succeeds.
+
+An output is marked unhealthy once its probe has failed
+` + "`unhealthy_threshold`" + ` times in a row, and is marked healthy again once
+its probe has succeeded ` + "`healthy_threshold`" + ` times in a row, which
+prevents messages from flapping between outputs. If all outputs are unhealthy
+the first output is used.
+
+Regardless of probes, if an output fails to send a message then the message is
+attempted with the remaining outputs in order, similar to the ` + "`try`" + `
+pattern. The index of the active output is exposed with the gauge metric
+` + "`broker.failover.active`" + `.
+
 ### Utilising More Outputs
 
 When using brokered outputs with patterns such as round robin or greedy it is
@@ -130,30 +215,74 @@ nodes processors.`,
 				}
 				outSlice = append(outSlice, sanOutput)
 			}
-			return map[string]interface{}{
+			sanit := map[string]interface{}{
 				"copies":  conf.Broker.Copies,
 				"pattern": conf.Broker.Pattern,
 				"outputs": outSlice,
-			}, nil
+			}
+			if conf.Broker.Pattern == "weighted" {
+				sanit["weights"] = conf.Broker.Weights
+				sanit["key"] = conf.Broker.Key
+			}
+			if conf.Broker.Pattern == "failover" {
+				sanit["failover"] = conf.Broker.Failover
+			}
+			return sanit, nil
 		},
 	}
 }
 
 //------------------------------------------------------------------------------
 
+// BrokerProbeConfig contains configuration fields for a health probe of the
+// failover broker pattern.
+type BrokerProbeConfig struct {
+	Type    string `json:"type" yaml:"type"`
+	URL     string `json:"url" yaml:"url"`
+	Address string `json:"address" yaml:"address"`
+}
+
+// BrokerFailoverConfig contains configuration fields for the failover broker
+// pattern.
+type BrokerFailoverConfig struct {
+	IntervalMS         int                 `json:"interval_ms" yaml:"interval_ms"`
+	TimeoutMS          int                 `json:"timeout_ms" yaml:"timeout_ms"`
+	UnhealthyThreshold int                 `json:"unhealthy_threshold" yaml:"unhealthy_threshold"`
+	HealthyThreshold   int                 `json:"healthy_threshold" yaml:"healthy_threshold"`
+	Probes             []BrokerProbeConfig `json:"probes" yaml:"probes"`
+}
+
+// NewBrokerFailoverConfig creates a new BrokerFailoverConfig with default
+// values.
+func NewBrokerFailoverConfig() BrokerFailoverConfig {
+	return BrokerFailoverConfig{
+		IntervalMS:         5000,
+		TimeoutMS:          2000,
+		UnhealthyThreshold: 3,
+		HealthyThreshold:   3,
+		Probes:             []BrokerProbeConfig{},
+	}
+}
+
 // BrokerConfig contains configuration fields for the Broker output type.
 type BrokerConfig struct {
-	Copies  int              `json:"copies" yaml:"copies"`
-	Pattern string           `json:"pattern" yaml:"pattern"`
-	Outputs brokerOutputList `json:"outputs" yaml:"outputs"`
+	Copies   int                  `json:"copies" yaml:"copies"`
+	Pattern  string               `json:"pattern" yaml:"pattern"`
+	Weights  []int                `json:"weights" yaml:"weights"`
+	Key      string               `json:"key" yaml:"key"`
+	Failover BrokerFailoverConfig `json:"failover" yaml:"failover"`
+	Outputs  brokerOutputList     `json:"outputs" yaml:"outputs"`
 }
 
 // NewBrokerConfig creates a new BrokerConfig with default values.
 func NewBrokerConfig() BrokerConfig {
 	return BrokerConfig{
-		Copies:  1,
-		Pattern: "fan_out",
-		Outputs: brokerOutputList{},
+		Copies:   1,
+		Pattern:  "fan_out",
+		Weights:  []int{},
+		Key:      "",
+		Failover: NewBrokerFailoverConfig(),
+		Outputs:  brokerOutputList{},
 	}
 }
 
@@ -179,6 +308,33 @@ func NewBroker(
 		return New(outputConfs[0], mgr, log, stats, pipelines...)
 	}
 
+	if conf.Broker.Pattern == "weighted" && len(conf.Broker.Weights) != len(outputConfs) {
+		return nil, fmt.Errorf(
+			"number of weights (%v) does not match number of outputs (%v)",
+			len(conf.Broker.Weights), len(outputConfs),
+		)
+	}
+
+	var probes []func() error
+	if conf.Broker.Pattern == "failover" && len(conf.Broker.Failover.Probes) > 0 {
+		if len(conf.Broker.Failover.Probes) != len(outputConfs) {
+			return nil, fmt.Errorf(
+				"number of probes (%v) does not match number of outputs (%v)",
+				len(conf.Broker.Failover.Probes), len(outputConfs),
+			)
+		}
+		timeout := time.Duration(conf.Broker.Failover.TimeoutMS) * time.Millisecond
+		for j := 0; j < conf.Broker.Copies; j++ {
+			for _, pConf := range conf.Broker.Failover.Probes {
+				probe, err := newBrokerProbe(pConf, timeout)
+				if err != nil {
+					return nil, err
+				}
+				probes = append(probes, probe)
+			}
+		}
+	}
+
 	outputs := make([]types.Output, lOutputs)
 
 	var err error
@@ -200,9 +356,77 @@ func NewBroker(
 		return broker.NewGreedy(outputs)
 	case "try":
 		return broker.NewTry(outputs, stats)
+	case "weighted":
+		weights := make([]int, 0, lOutputs)
+		for j := 0; j < conf.Broker.Copies; j++ {
+			weights = append(weights, conf.Broker.Weights...)
+		}
+		var keyFn func(types.Message) []byte
+		if len(conf.Broker.Key) > 0 {
+			keyBytes := []byte(conf.Broker.Key)
+			interpolate := text.ContainsFunctionVariables(keyBytes)
+			keyFn = func(msg types.Message) []byte {
+				if interpolate {
+					return text.ReplaceFunctionVariables(msg, keyBytes)
+				}
+				return keyBytes
+			}
+		}
+		return broker.NewWeighted(outputs, weights, keyFn, stats)
+	case "failover":
+		return broker.NewFailover(
+			outputs, log, stats,
+			broker.OptFailoverSetProbes(probes),
+			broker.OptFailoverSetProbeInterval(
+				time.Duration(conf.Broker.Failover.IntervalMS)*time.Millisecond,
+			),
+			broker.OptFailoverSetThresholds(
+				conf.Broker.Failover.UnhealthyThreshold,
+				conf.Broker.Failover.HealthyThreshold,
+			),
+		)
 	}
 
 	return nil, fmt.Errorf("broker pattern was not recognised: %v", conf.Broker.Pattern)
 }
 
 //------------------------------------------------------------------------------
+
+// newBrokerProbe creates a health probe function from a config, a nil function
+// is returned for probes that always succeed.
+func newBrokerProbe(conf BrokerProbeConfig, timeout time.Duration) (func() error, error) {
+	switch conf.Type {
+	case "", "none":
+		return nil, nil
+	case "http":
+		if len(conf.URL) == 0 {
+			return nil, errors.New("http probe requires a url")
+		}
+		client := http.Client{Timeout: timeout}
+		return func() error {
+			res, err := client.Get(conf.URL)
+			if err != nil {
+				return err
+			}
+			res.Body.Close()
+			if res.StatusCode < 200 || res.StatusCode > 299 {
+				return fmt.Errorf("unexpected status code: %v", res.StatusCode)
+			}
+			return nil
+		}, nil
+	case "tcp":
+		if len(conf.Address) == 0 {
+			return nil, errors.New("tcp probe requires an address")
+		}
+		return func() error {
+			conn, err := net.DialTimeout("tcp", conf.Address, timeout)
+			if err != nil {
+				return err
+			}
+			return conn.Close()
+		}, nil
+	}
+	return nil, fmt.Errorf("probe type was not recognised: %v", conf.Type)
+}
+
+//------------------------------------------------------------------------------
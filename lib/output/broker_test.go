@@ -238,3 +238,73 @@ func TestRoundRobinWithNanomsg(t *testing.T) {
 		}
 	}
 }
+
+func TestBrokerWeightedBadWeights(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = TypeBroker
+	conf.Broker.Pattern = "weighted"
+	conf.Broker.Weights = []int{1}
+
+	oConf := NewConfig()
+	oConf.Type = TypeInproc
+	oConf.Inproc.Name = "foo"
+	conf.Broker.Outputs = append(conf.Broker.Outputs, oConf, oConf)
+
+	if _, err := New(conf, types.NoopMgr(), log.Noop(), metrics.Noop()); err == nil {
+		t.Error("Expected error from mismatched weights")
+	}
+
+	conf.Broker.Weights = []int{1, 1}
+	conf.Broker.Copies = 2
+	conf.Broker.Key = "${!metadata:foo}"
+
+	o, err := New(conf, types.NoopMgr(), log.Noop(), metrics.Noop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = o.Consume(make(chan types.Transaction)); err != nil {
+		t.Fatal(err)
+	}
+	o.CloseAsync()
+	if err = o.WaitForClose(time.Second); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestBrokerFailoverBadProbes(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = TypeBroker
+	conf.Broker.Pattern = "failover"
+	conf.Broker.Failover.Probes = []BrokerProbeConfig{{Type: "none"}}
+
+	oConf := NewConfig()
+	oConf.Type = TypeInproc
+	oConf.Inproc.Name = "foo"
+	conf.Broker.Outputs = append(conf.Broker.Outputs, oConf, oConf)
+
+	if _, err := New(conf, types.NoopMgr(), log.Noop(), metrics.Noop()); err == nil {
+		t.Error("Expected error from mismatched probes")
+	}
+
+	conf.Broker.Failover.Probes = []BrokerProbeConfig{{Type: "none"}, {Type: "nope"}}
+	if _, err := New(conf, types.NoopMgr(), log.Noop(), metrics.Noop()); err == nil {
+		t.Error("Expected error from bad probe type")
+	}
+
+	conf.Broker.Failover.Probes = []BrokerProbeConfig{
+		{Type: "none"}, {Type: "tcp", Address: "localhost:1"},
+	}
+	conf.Broker.Copies = 2
+
+	o, err := New(conf, types.NoopMgr(), log.Noop(), metrics.Noop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = o.Consume(make(chan types.Transaction)); err != nil {
+		t.Fatal(err)
+	}
+	o.CloseAsync()
+	if err = o.WaitForClose(time.Second); err != nil {
+		t.Error(err)
+	}
+}
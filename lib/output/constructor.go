@@ -23,15 +23,18 @@ package output
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"sort"
 	"strings"
 
 	"github.com/Jeffail/benthos/lib/log"
+	"github.com/Jeffail/benthos/lib/message/batch"
 	"github.com/Jeffail/benthos/lib/metrics"
 	"github.com/Jeffail/benthos/lib/output/writer"
 	"github.com/Jeffail/benthos/lib/pipeline"
 	"github.com/Jeffail/benthos/lib/processor"
+	"github.com/Jeffail/benthos/lib/processor/condition"
 	"github.com/Jeffail/benthos/lib/types"
 	"github.com/Jeffail/benthos/lib/util/config"
 	yaml "gopkg.in/yaml.v2"
@@ -53,6 +56,11 @@ type TypeSpec struct {
 	sanitiseConfigFunc func(conf Config) (interface{}, error)
 }
 
+// Description returns the markdown description of the type.
+func (t TypeSpec) Description() string {
+	return t.description
+}
+
 // Constructors is a map of all output types with their specs.
 var Constructors = map[string]TypeSpec{}
 
@@ -72,6 +80,7 @@ const (
 	TypeInproc        = "inproc"
 	TypeKafka         = "kafka"
 	TypeKinesis       = "kinesis"
+	TypeMock          = "mock"
 	TypeMQTT          = "mqtt"
 	TypeNanomsg       = "nanomsg"
 	TypeNATS          = "nats"
@@ -89,6 +98,10 @@ const (
 	TypeZMQ4          = "zmq4"
 )
 
+// errZMQ4Unavailable is returned when a zmq4 output is configured but Benthos was
+// built without the ZMQ4 tag, which requires cgo and libzmq.
+var errZMQ4Unavailable = errors.New("output type zmq4 is not available in this build, Benthos must be compiled with libzmq installed and the build tag ZMQ4")
+
 //------------------------------------------------------------------------------
 
 // Config is the all encompassing configuration struct for all output types.
@@ -106,6 +119,7 @@ type Config struct {
 	Inproc        InprocConfig               `json:"inproc" yaml:"inproc"`
 	Kafka         writer.KafkaConfig         `json:"kafka" yaml:"kafka"`
 	Kinesis       writer.KinesisConfig       `json:"kinesis" yaml:"kinesis"`
+	Mock          writer.MockConfig          `json:"mock" yaml:"mock"`
 	MQTT          writer.MQTTConfig          `json:"mqtt" yaml:"mqtt"`
 	Nanomsg       writer.NanomsgConfig       `json:"nanomsg" yaml:"nanomsg"`
 	NATS          writer.NATSConfig          `json:"nats" yaml:"nats"`
@@ -122,6 +136,7 @@ type Config struct {
 	Switch        SwitchConfig               `json:"switch" yaml:"switch"`
 	Websocket     writer.WebsocketConfig     `json:"websocket" yaml:"websocket"`
 	ZMQ4          *writer.ZMQ4Config         `json:"zmq4,omitempty" yaml:"zmq4,omitempty"`
+	Batching      batch.PolicyConfig         `json:"batching" yaml:"batching"`
 	Processors    []processor.Config         `json:"processors" yaml:"processors"`
 }
 
@@ -141,6 +156,7 @@ func NewConfig() Config {
 		Inproc:        NewInprocConfig(),
 		Kafka:         writer.NewKafkaConfig(),
 		Kinesis:       writer.NewKinesisConfig(),
+		Mock:          writer.NewMockConfig(),
 		MQTT:          writer.NewMQTTConfig(),
 		Nanomsg:       writer.NewNanomsgConfig(),
 		NATS:          writer.NewNATSConfig(),
@@ -157,6 +173,7 @@ func NewConfig() Config {
 		Switch:        NewSwitchConfig(),
 		Websocket:     writer.NewWebsocketConfig(),
 		ZMQ4:          writer.NewZMQ4Config(),
+		Batching:      batch.NewPolicyConfig(),
 		Processors:    []processor.Config{},
 	}
 }
@@ -197,6 +214,19 @@ func SanitiseConfig(conf Config) (interface{}, error) {
 		}
 	}
 
+	if !conf.Batching.IsNoop() {
+		condSanit, err := condition.SanitiseConfig(conf.Batching.Condition)
+		if err != nil {
+			return nil, err
+		}
+		outputMap["batching"] = map[string]interface{}{
+			"byte_size": conf.Batching.ByteSize,
+			"count":     conf.Batching.Count,
+			"condition": condSanit,
+			"period_ms": conf.Batching.PeriodMS,
+		}
+	}
+
 	if len(conf.Processors) == 0 {
 		return outputMap, nil
 	}
@@ -296,6 +326,37 @@ are content aware logical operators that can be combined using boolean logic.
 For more information regarding conditions, including a full list of available
 conditions please [read the docs here](../conditions/README.md)
 
+### Batching
+
+All outputs support a ` + "`batching`" + ` field, which groups messages into
+batches before they are written to the output. The fields of a batching policy
+are the same as the [` + "`batch`" + ` processor](../processors/README.md#batch)
+and a batch is sent when either:
+
+- The ` + "`byte_size`" + ` field is non-zero and the total size of the batch in
+  bytes matches or exceeds it.
+- The ` + "`count`" + ` field is non-zero and the total number of messages in the
+  batch matches or exceeds it.
+- A message added to the batch causes the ` + "`condition`" + ` to resolve
+  ` + "`true`" + `.
+- The ` + "`period_ms`" + ` field is non-zero and the time since the last batch
+  exceeds its value. Unlike the ` + "`batch`" + ` processor a pending batch is
+  sent once the period is reached even if no further messages arrive.
+
+Batches are formed after the ` + "`processors`" + ` of an output have been
+applied, and therefore the grouping is preserved up to the output.
+
+` + "``` yaml" + `
+output:
+  type: kafka
+  kafka:
+    addresses: [ localhost:9092 ]
+    topic: foo
+  batching:
+    count: 100
+    period_ms: 1000
+` + "```" + `
+
 ### Dead Letter Queues
 
 It's possible to create fallback outputs for when an output target fails using
@@ -373,12 +434,20 @@ func New(
 	}
 	if c, ok := Constructors[conf.Type]; ok {
 		if c.brokerConstructor != nil {
-			return c.brokerConstructor(conf, mgr, log, stats, pipelines...)
+			output, err := c.brokerConstructor(conf, mgr, log, stats, pipelines...)
+			if err != nil {
+				return nil, err
+			}
+			return wrapWithBatcher(conf, output, mgr, log, stats)
 		}
 		output, err := c.constructor(conf, mgr, log, stats)
 		if err != nil {
 			return nil, fmt.Errorf("failed to create output '%v': %v", conf.Type, err)
 		}
+		registerConnector(mgr, conf.Type, output)
+		if output, err = wrapWithBatcher(conf, output, mgr, log, stats); err != nil {
+			return nil, err
+		}
 		return WrapWithPipelines(output, pipelines...)
 	}
 	if c, ok := pluginSpecs[conf.Type]; ok {
@@ -386,9 +455,50 @@ func New(
 		if err != nil {
 			return nil, err
 		}
+		registerConnector(mgr, conf.Type, output)
+		if output, err = wrapWithBatcher(conf, output, mgr, log, stats); err != nil {
+			return nil, err
+		}
 		return WrapWithPipelines(output, pipelines...)
 	}
+	if conf.Type == TypeZMQ4 {
+		return nil, errZMQ4Unavailable
+	}
 	return nil, types.ErrInvalidOutputType
 }
 
+// wrapWithBatcher wraps an output with a batcher if the config contains a
+// batching policy.
+func wrapWithBatcher(
+	conf Config,
+	output Type,
+	mgr types.Manager,
+	log log.Modular,
+	stats metrics.Type,
+) (Type, error) {
+	if conf.Batching.IsNoop() {
+		return output, nil
+	}
+	bLog := log.NewModule(".output.batcher")
+	policy, err := batch.NewPolicy(conf.Batching, mgr, bLog, metrics.Namespaced(stats, "output"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create batch policy: %v", err)
+	}
+	return NewBatcher(policy, output, bLog, stats), nil
+}
+
+//------------------------------------------------------------------------------
+
+// registerConnector registers an output with the manager when the output
+// reports its connection state and the manager tracks it.
+func registerConnector(mgr types.Manager, typeStr string, output Type) {
+	c, ok := output.(types.Connector)
+	if !ok {
+		return
+	}
+	if reg, ok := mgr.(types.ConnectionRegistry); ok {
+		reg.RegisterConnector("output."+typeStr, c)
+	}
+}
+
 //------------------------------------------------------------------------------
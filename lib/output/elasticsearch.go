@@ -37,7 +37,16 @@ Publishes messages into an Elasticsearch index. This output currently does not
 support creating the target index.
 
 Both the ` + "`id` and `index`" + ` fields can be dynamically set using function
-interpolations described [here](../config_interpolation.md#functions).`,
+interpolations described [here](../config_interpolation.md#functions). When
+sending batched messages these interpolations are performed per message part.
+
+The field ` + "`max_in_flight`" + ` sets the maximum number of messages that
+can be written in parallel, which can greatly improve throughput when each
+write has a high latency. Each message is acknowledged once its own write has
+completed, but this means messages can be written out of order.
+
+Custom TLS settings for ` + "`https`" + ` URLs can be set in the ` + "`tls`" + `
+section as described [here](../tls.md).`,
 	}
 }
 
@@ -49,8 +58,8 @@ func NewElasticsearch(conf Config, mgr types.Manager, log log.Modular, stats met
 	if err != nil {
 		return nil, err
 	}
-	return NewWriter(
-		"elasticsearch", elasticWriter, log, stats,
+	return NewAsyncWriter(
+		"elasticsearch", conf.Elasticsearch.MaxInFlight, elasticWriter, log, stats,
 	)
 }
 
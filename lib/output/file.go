@@ -21,11 +21,17 @@
 package output
 
 import (
+	"fmt"
 	"os"
+	"path/filepath"
+	"sync"
+	"time"
 
 	"github.com/Jeffail/benthos/lib/log"
 	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/output/writer"
 	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/text"
 )
 
 //------------------------------------------------------------------------------
@@ -42,7 +48,34 @@ would be written as:
 
 foo\n
 bar\n
-baz\n\n`,
+baz\n\n
+
+The ` + "`codec`" + ` field can be used in order to change the format messages
+are written in, the options are:
+
+- ` + "`lines`" + `: Each message part is written followed by the delimiter as
+  described above.
+- ` + "`json_array`" + `: Each message part must be valid JSON and is written as
+  an element of a JSON array. The array is terminated whenever the file is
+  closed, and appending to an existing file adds elements to its array.
+- ` + "`length_prefixed`" + `: Each message part is written prefixed with its
+  length in bytes as a 32 bit big endian unsigned integer.
+
+The ` + "`path`" + ` field can be dynamically set using function interpolations
+described [here](../config_interpolation.md#functions), which are resolved for
+each message. The current file is closed and a new one opened whenever a message
+resolves to a different path, which makes it possible to partition files by
+time with paths such as ` + "`./logs/${!timestamp:2006-01-02}.log`" + `.
+
+### Rotation
+
+A file can be rotated once it reaches a size in bytes by setting
+` + "`rotate_size`" + `, or once it has been open for a period of time by setting
+` + "`rotate_period`" + ` to a duration string. When rotated the file is renamed
+with a timestamp suffix, e.g. ` + "`foo.log`" + ` becomes
+` + "`foo.log.20190102T150405.000000000`" + `, and a new file is opened at the
+original path. Rotation is checked as messages are written, and so a file may
+exceed the size limit by at most one message.`,
 	}
 }
 
@@ -50,15 +83,21 @@ baz\n\n`,
 
 // FileConfig contains configuration fields for the file based output type.
 type FileConfig struct {
-	Path  string `json:"path" yaml:"path"`
-	Delim string `json:"delimiter" yaml:"delimiter"`
+	Path         string `json:"path" yaml:"path"`
+	Codec        string `json:"codec" yaml:"codec"`
+	Delim        string `json:"delimiter" yaml:"delimiter"`
+	RotateSize   int64  `json:"rotate_size" yaml:"rotate_size"`
+	RotatePeriod string `json:"rotate_period" yaml:"rotate_period"`
 }
 
 // NewFileConfig creates a new FileConfig with default values.
 func NewFileConfig() FileConfig {
 	return FileConfig{
-		Path:  "",
-		Delim: "",
+		Path:         "",
+		Codec:        "lines",
+		Delim:        "",
+		RotateSize:   0,
+		RotatePeriod: "",
 	}
 }
 
@@ -66,11 +105,157 @@ func NewFileConfig() FileConfig {
 
 // NewFile creates a new File output type.
 func NewFile(conf Config, mgr types.Manager, log log.Modular, stats metrics.Type) (Type, error) {
-	file, err := os.OpenFile(conf.File.Path, os.O_CREATE|os.O_RDWR|os.O_APPEND, os.FileMode(0666))
+	f, err := newFileWriter(conf.File, log, stats)
 	if err != nil {
 		return nil, err
 	}
-	return NewLineWriter(file, true, []byte(conf.File.Delim), "file", log, stats)
+	return NewWriter("file", f, log, stats)
+}
+
+//------------------------------------------------------------------------------
+
+// fileWriter is a writer.Type that appends messages to a file with a codec,
+// rotating the file when configured to.
+type fileWriter struct {
+	conf         FileConfig
+	path         *text.InterpolatedString
+	rotatePeriod time.Duration
+
+	log     log.Modular
+	mRotate metrics.StatCounter
+
+	mut        sync.Mutex
+	handle     *os.File
+	handlePath string
+	encoder    writer.Encoder
+	openedAt   time.Time
+}
+
+func newFileWriter(conf FileConfig, log log.Modular, stats metrics.Type) (*fileWriter, error) {
+	f := &fileWriter{
+		conf:    conf,
+		path:    text.NewInterpolatedString(conf.Path),
+		log:     log.NewModule(".output.file"),
+		mRotate: stats.GetCounter("output.file.rotate"),
+	}
+	if _, err := writer.NewEncoder(conf.Codec, nil, nil); err != nil {
+		return nil, err
+	}
+	if len(conf.RotatePeriod) > 0 {
+		var err error
+		if f.rotatePeriod, err = time.ParseDuration(conf.RotatePeriod); err != nil {
+			return nil, fmt.Errorf("failed to parse rotate period: %v", err)
+		}
+	}
+	return f, nil
+}
+
+// Connect opens the target file when its path is static, otherwise files are
+// opened as messages are written.
+func (f *fileWriter) Connect() error {
+	f.mut.Lock()
+	defer f.mut.Unlock()
+	if f.handle != nil || text.ContainsFunctionVariables([]byte(f.conf.Path)) {
+		return nil
+	}
+	return f.open(f.conf.Path)
+}
+
+func (f *fileWriter) open(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), os.FileMode(0777)); err != nil {
+		return err
+	}
+	handle, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_APPEND, os.FileMode(0666))
+	if err != nil {
+		return err
+	}
+	encoder, err := writer.NewFileEncoder(f.conf.Codec, []byte(f.conf.Delim), handle)
+	if err != nil {
+		handle.Close()
+		return fmt.Errorf("failed to open file '%v': %v", path, err)
+	}
+	f.handle, f.handlePath, f.encoder = handle, path, encoder
+	f.openedAt = time.Now()
+	return nil
+}
+
+func (f *fileWriter) close() error {
+	if f.handle == nil {
+		return nil
+	}
+	err := f.encoder.Close()
+	if cErr := f.handle.Close(); err == nil {
+		err = cErr
+	}
+	f.handle, f.encoder = nil, nil
+	return err
+}
+
+// rotate closes the current file and renames it with a timestamp suffix.
+func (f *fileWriter) rotate() error {
+	path := f.handlePath
+	if err := f.close(); err != nil {
+		return err
+	}
+	f.mRotate.Incr(1)
+	return os.Rename(path, path+"."+time.Now().Format("20060102T150405.000000000"))
+}
+
+func (f *fileWriter) shouldRotate() (bool, error) {
+	if f.rotatePeriod > 0 && time.Since(f.openedAt) >= f.rotatePeriod {
+		return true, nil
+	}
+	if f.conf.RotateSize > 0 {
+		info, err := f.handle.Stat()
+		if err != nil {
+			return false, err
+		}
+		return info.Size() >= f.conf.RotateSize, nil
+	}
+	return false, nil
+}
+
+// Write appends a message to the file its path resolves to.
+func (f *fileWriter) Write(msg types.Message) error {
+	path := f.path.Get(msg)
+
+	f.mut.Lock()
+	defer f.mut.Unlock()
+
+	if f.handle != nil && f.handlePath != path {
+		if err := f.close(); err != nil {
+			f.log.Errorf("Failed to close file '%v': %v\n", f.handlePath, err)
+		}
+	}
+	if f.handle != nil {
+		rotate, err := f.shouldRotate()
+		if err == nil && rotate {
+			err = f.rotate()
+		}
+		if err != nil {
+			f.log.Errorf("Failed to rotate file '%v': %v\n", f.handlePath, err)
+		}
+	}
+	if f.handle == nil {
+		if err := f.open(path); err != nil {
+			return err
+		}
+	}
+	return f.encoder.Encode(msg)
+}
+
+// CloseAsync terminates and closes the current file.
+func (f *fileWriter) CloseAsync() {
+	f.mut.Lock()
+	if err := f.close(); err != nil {
+		f.log.Errorf("Failed to close file '%v': %v\n", f.handlePath, err)
+	}
+	f.mut.Unlock()
+}
+
+// WaitForClose is a noop.
+func (f *fileWriter) WaitForClose(timeout time.Duration) error {
+	return nil
 }
 
 //------------------------------------------------------------------------------
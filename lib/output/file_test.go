@@ -0,0 +1,139 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package output
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/Jeffail/benthos/lib/log"
+	"github.com/Jeffail/benthos/lib/message"
+	"github.com/Jeffail/benthos/lib/metrics"
+)
+
+func TestFileWriterInterpolatedPath(t *testing.T) {
+	dir, err := ioutil.TempDir("", "benthos_file_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	conf := NewFileConfig()
+	conf.Path = filepath.Join(dir, "${!metadata:key}", "out.txt")
+
+	f, err := newFileWriter(conf, log.Noop(), metrics.Noop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = f.Connect(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, kv := range [][2]string{{"a", "foo"}, {"b", "bar"}, {"a", "baz"}} {
+		msg := message.New([][]byte{[]byte(kv[1])})
+		msg.Get(0).Metadata().Set("key", kv[0])
+		if err = f.Write(msg); err != nil {
+			t.Fatal(err)
+		}
+	}
+	f.CloseAsync()
+
+	for k, exp := range map[string]string{
+		"a": "foo\nbaz\n",
+		"b": "bar\n",
+	} {
+		act, err := ioutil.ReadFile(filepath.Join(dir, k, "out.txt"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if exp != string(act) {
+			t.Errorf("Wrong contents for %v: %q != %q", k, act, exp)
+		}
+	}
+}
+
+func TestFileWriterRotateSize(t *testing.T) {
+	dir, err := ioutil.TempDir("", "benthos_file_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	conf := NewFileConfig()
+	conf.Path = filepath.Join(dir, "out.json")
+	conf.Codec = "json_array"
+	conf.RotateSize = 10
+
+	f, err := newFileWriter(conf, log.Noop(), metrics.Noop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = f.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	for _, doc := range []string{`{"a":"foo"}`, `{"b":"bar"}`, `{"c":"baz"}`} {
+		if err = f.Write(message.New([][]byte{[]byte(doc)})); err != nil {
+			t.Fatal(err)
+		}
+	}
+	f.CloseAsync()
+
+	files, err := filepath.Glob(filepath.Join(dir, "out.json*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(files)
+	if exp, act := 3, len(files); exp != act {
+		t.Fatalf("Wrong count of files: %v != %v", act, exp)
+	}
+
+	exp := []string{
+		"[\n{\"c\":\"baz\"}\n]\n",
+		"[\n{\"a\":\"foo\"}\n]\n",
+		"[\n{\"b\":\"bar\"}\n]\n",
+	}
+	for i, path := range files {
+		act, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if exp[i] != string(act) {
+			t.Errorf("Wrong contents for %v: %q != %q", path, act, exp[i])
+		}
+	}
+}
+
+func TestFileWriterBadConfig(t *testing.T) {
+	conf := NewFileConfig()
+	conf.Codec = "not_a_codec"
+	if _, err := newFileWriter(conf, log.Noop(), metrics.Noop()); err == nil {
+		t.Error("Expected error from bad codec")
+	}
+
+	conf = NewFileConfig()
+	conf.RotatePeriod = "not a duration"
+	if _, err := newFileWriter(conf, log.Noop(), metrics.Noop()); err == nil {
+		t.Error("Expected error from bad rotate period")
+	}
+}
@@ -25,6 +25,7 @@ import (
 	"github.com/Jeffail/benthos/lib/metrics"
 	"github.com/Jeffail/benthos/lib/output/writer"
 	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/kerberos"
 )
 
 //------------------------------------------------------------------------------
@@ -37,7 +38,13 @@ Sends message parts as files to a HDFS directory. Each file is written
 with the path specified with the 'path' field, in order to have a different path
 for each object you should use function interpolations described
 [here](../config_interpolation.md#functions). When sending batched messages the
-interpolations are performed per message part.`,
+interpolations are performed per message part.
+
+Kerberos authentication is enabled by setting a principal in the
+` + "`kerberos`" + ` section, where ` + "`service_name`" + ` is the service
+principal of the namenode.
+
+` + kerberos.Documentation + ``,
 	}
 }
 
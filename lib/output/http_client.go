@@ -42,6 +42,11 @@ The period of time between retries is linear by default. Response codes that are
 within the ` + "`backoff_on`" + ` list will instead apply exponential backoff
 between retry attempts.
 
+Response codes within the ` + "`fail_on`" + ` list are treated as permanent
+failures and the message is rejected immediately without any retry attempts. In
+order to send these messages to a dead letter queue you can place this output
+within a ` + "[`broker`](#broker)" + ` using the ` + "`try`" + ` pattern.
+
 When the number of retries expires the output will reject the message, the
 behaviour after this will depend on the pipeline but usually this simply means
 the send is attempted again until successful whilst applying back pressure.
@@ -51,7 +56,30 @@ interpolations described [here](../config_interpolation.md#functions).
 
 The body of the HTTP request is the raw contents of the message payload. If the
 message has multiple parts the request will be sent according to
-[RFC1341](https://www.w3.org/Protocols/rfc1341/7_2_Multipart.html)`,
+[RFC1341](https://www.w3.org/Protocols/rfc1341/7_2_Multipart.html)
+
+The field ` + "`max_in_flight`" + ` sets the maximum number of messages that
+can be written in parallel, which can greatly improve throughput when each
+write has a high latency. Each message is acknowledged once its own write has
+completed, but this means messages can be written out of order.
+
+If you are sending batches and wish to avoid multipart requests then you can set
+the ` + "`parallel`" + ` flag to ` + "`true`" + ` and the messages of a batch
+will be sent as individual requests in parallel. The max number of parallel
+requests per batch can be capped with ` + "`max_parallel`" + `. If any of these
+requests fail then the whole batch is rejected and, depending on the pipeline,
+will be sent again.
+
+Which metadata keys are sent as headers, and the names they are given, can be
+configured within the ` + "`metadata`" + ` section as described
+[here](../metadata.md).
+
+### OAuth2
+
+When ` + "`oauth2.enabled`" + ` is set the client obtains an access token from
+` + "`oauth2.token_url`" + ` using the client credentials grant and sets it as a
+bearer token on each request. Tokens are cached and refreshed automatically
+shortly before they expire.`,
 	}
 }
 
@@ -61,7 +89,7 @@ func NewHTTPClient(conf Config, mgr types.Manager, log log.Modular, stats metric
 	if err != nil {
 		return nil, err
 	}
-	return NewWriter("http_client", h, log, stats)
+	return NewAsyncWriter("http_client", conf.HTTPClient.MaxInFlight, h, log, stats)
 }
 
 //------------------------------------------------------------------------------
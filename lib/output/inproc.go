@@ -21,12 +21,16 @@
 package output
 
 import (
+	"encoding/json"
+	"fmt"
 	"sync/atomic"
 	"time"
 
 	"github.com/Jeffail/benthos/lib/log"
 	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/response"
 	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/throttle"
 )
 
 //------------------------------------------------------------------------------
@@ -41,29 +45,115 @@ you to hook up isolated streams whilst running Benthos in
 that you connect the inputs of a stream with an output of the same stream, as
 feedback loops can lead to deadlocks in your message flow.
 
-It is possible to connect multiple inputs to the same inproc ID, but only one
-output can connect to an inproc ID, and will replace existing outputs if a
-collision occurs.`,
+Only one output can connect to an inproc ID, and will replace existing outputs
+if a collision occurs. For backwards compatibility the config of this output can
+also be a string, in which case it is used as the ` + "`name`" + ` field.
+
+It is possible to connect multiple inputs to the same inproc ID, and the way in
+which messages are distributed amongst them is determined by the ` + "`pattern`" + `
+field:
+
+#### ` + "`shared`" + `
+
+Each message is consumed by only one of the connected inputs, whichever is
+ready first.
+
+#### ` + "`fan_out`" + `
+
+Each connected input receives its own copy of every message. A message is only
+acknowledged once every connected input has acknowledged it, and a message that
+an input fails to process is sent to that input again. When there are no inputs
+connected messages are held until one connects.
+
+### Buffering
+
+When ` + "`buffer_size`" + ` is greater than zero up to that number of messages
+are acknowledged as soon as they reach this output and are held until they are
+delivered, allowing the sending stream to continue whilst the receiving streams
+are slow or restarting. When the buffer is full back pressure is applied.
+Messages held in the buffer are lost if this output is closed before they are
+delivered.
+
+When ` + "`buffer_size`" + ` is zero (the default) acknowledgements are
+propagated from the receiving streams back to the sending stream.
+
+### Restarts
+
+When this output is closed the inputs connected to its ID remain, and continue
+to receive messages as soon as an output with the same ID reconnects. When an
+input in a ` + "`fan_out`" + ` pattern is closed it is no longer sent messages,
+including any it had not yet acknowledged.`,
 	}
 }
 
 //------------------------------------------------------------------------------
 
 // InprocConfig contains configuration fields for the Inproc output type.
-type InprocConfig string
+type InprocConfig struct {
+	Name       string `json:"name" yaml:"name"`
+	Pattern    string `json:"pattern" yaml:"pattern"`
+	BufferSize int    `json:"buffer_size" yaml:"buffer_size"`
+}
 
 // NewInprocConfig creates a new InprocConfig with default values.
 func NewInprocConfig() InprocConfig {
-	return InprocConfig("")
+	return InprocConfig{
+		Name:       "",
+		Pattern:    "shared",
+		BufferSize: 0,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type inprocConfigAlias InprocConfig
+
+// UnmarshalJSON allows the config to be a string, in which case it is used as
+// the name of the pipe.
+func (c *InprocConfig) UnmarshalJSON(bytes []byte) error {
+	var name string
+	if err := json.Unmarshal(bytes, &name); err == nil {
+		c.Name = name
+		return nil
+	}
+	aliased := inprocConfigAlias(*c)
+	if err := json.Unmarshal(bytes, &aliased); err != nil {
+		return err
+	}
+	*c = InprocConfig(aliased)
+	return nil
+}
+
+// UnmarshalYAML allows the config to be a string, in which case it is used as
+// the name of the pipe.
+func (c *InprocConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var name string
+	if err := unmarshal(&name); err == nil {
+		c.Name = name
+		return nil
+	}
+	aliased := inprocConfigAlias(*c)
+	if err := unmarshal(&aliased); err != nil {
+		return err
+	}
+	*c = InprocConfig(aliased)
+	return nil
 }
 
 //------------------------------------------------------------------------------
 
+// inprocSubscriberPeriod is the period with which the subscribers of a fan out
+// pipe are checked whilst waiting on them.
+var inprocSubscriberPeriod = time.Millisecond * 100
+
 // Inproc is an output type that serves Inproc messages.
 type Inproc struct {
 	running int32
 
-	pipe  string
+	pipe       string
+	fanOut     bool
+	bufferSize int
+
 	mgr   types.Manager
 	log   log.Modular
 	stats metrics.Type
@@ -71,6 +161,9 @@ type Inproc struct {
 	transactionsOut chan types.Transaction
 	transactionsIn  <-chan types.Transaction
 
+	mSendErr  metrics.StatCounter
+	mSendErrF metrics.StatCounter
+
 	closedChan chan struct{}
 	closeChan  chan struct{}
 }
@@ -79,7 +172,8 @@ type Inproc struct {
 func NewInproc(conf Config, mgr types.Manager, log log.Modular, stats metrics.Type) (Type, error) {
 	i := &Inproc{
 		running:         1,
-		pipe:            string(conf.Inproc),
+		pipe:            conf.Inproc.Name,
+		bufferSize:      conf.Inproc.BufferSize,
 		mgr:             mgr,
 		log:             log.NewModule(".output.inproc"),
 		stats:           stats,
@@ -87,6 +181,18 @@ func NewInproc(conf Config, mgr types.Manager, log log.Modular, stats metrics.Ty
 		closedChan:      make(chan struct{}),
 		closeChan:       make(chan struct{}),
 	}
+	switch conf.Inproc.Pattern {
+	case "shared":
+	case "fan_out":
+		i.fanOut = true
+	default:
+		return nil, fmt.Errorf("inproc pattern was not recognised: %v", conf.Inproc.Pattern)
+	}
+	if i.bufferSize < 0 {
+		return nil, fmt.Errorf("invalid buffer_size: %v", i.bufferSize)
+	}
+	i.mSendErr = stats.GetCounter("output.inproc." + i.pipe + ".send.error")
+	i.mSendErrF = stats.GetCounter("output.send.error")
 	return i, nil
 }
 
@@ -99,11 +205,24 @@ func (i *Inproc) loop() {
 		mRunningF = i.stats.GetGauge("output.running")
 		mCount    = i.stats.GetCounter("output.inproc." + i.pipe + ".count")
 		mCountF   = i.stats.GetCounter("output.count")
+		mBuffered = i.stats.GetGauge("output.inproc." + i.pipe + ".buffer.count")
 	)
 
+	var buffer chan types.Message
+	deliveredChan := make(chan struct{})
+
 	defer func() {
 		mRunning.Decr(1)
 		mRunningF.Decr(1)
+		if buffer != nil {
+			// Attempt to deliver the remaining buffered messages, this can be
+			// interrupted by closing the output.
+			close(buffer)
+			<-deliveredChan
+			if lost := len(buffer); lost > 0 {
+				i.log.Warnf("Dropping %v buffered messages due to shut down\n", lost)
+			}
+		}
 		atomic.StoreInt32(&i.running, 0)
 		i.mgr.UnsetPipe(i.pipe, i.transactionsOut)
 		close(i.transactionsOut)
@@ -115,6 +234,19 @@ func (i *Inproc) loop() {
 	i.mgr.SetPipe(i.pipe, i.transactionsOut)
 	i.log.Infof("Sending inproc messages to ID: %s\n", i.pipe)
 
+	if i.bufferSize > 0 {
+		buffer = make(chan types.Message, i.bufferSize)
+		go func() {
+			defer close(deliveredChan)
+			for msg := range buffer {
+				mBuffered.Set(int64(len(buffer)))
+				if !i.deliver(msg) {
+					return
+				}
+			}
+		}()
+	}
+
 	var open bool
 	for atomic.LoadInt32(&i.running) == 1 {
 		var ts types.Transaction
@@ -129,14 +261,156 @@ func (i *Inproc) loop() {
 
 		mCount.Incr(1)
 		mCountF.Incr(1)
+
+		if buffer != nil {
+			select {
+			case buffer <- ts.Payload:
+			case <-i.closeChan:
+				return
+			}
+			mBuffered.Set(int64(len(buffer)))
+			select {
+			case ts.ResponseChan <- response.NewAck():
+			case <-i.closeChan:
+				return
+			}
+			continue
+		}
+
+		if !i.fanOut {
+			select {
+			case i.transactionsOut <- ts:
+			case <-i.closeChan:
+				return
+			}
+			continue
+		}
+
+		if !i.sendFanOut(ts.Payload) {
+			return
+		}
 		select {
-		case i.transactionsOut <- ts:
+		case ts.ResponseChan <- response.NewAck():
 		case <-i.closeChan:
 			return
 		}
 	}
 }
 
+// deliver attempts to send a buffered message until it is successfully
+// delivered. Returns false if the output was closed before then.
+func (i *Inproc) deliver(msg types.Message) bool {
+	if i.fanOut {
+		return i.sendFanOut(msg)
+	}
+
+	throt := throttle.New(throttle.OptCloseChan(i.closeChan))
+	resChan := make(chan types.Response)
+	for {
+		select {
+		case i.transactionsOut <- types.NewTransaction(msg, resChan):
+		case <-i.closeChan:
+			return false
+		}
+		var res types.Response
+		select {
+		case res = <-resChan:
+		case <-i.closeChan:
+			return false
+		}
+		if res.Error() == nil {
+			return true
+		}
+		i.mSendErr.Incr(1)
+		i.mSendErrF.Incr(1)
+		if !throt.Retry() {
+			return false
+		}
+	}
+}
+
+// isSubscribed returns whether a transaction chan is still subscribed to the
+// pipe of this output.
+func (i *Inproc) isSubscribed(sub chan<- types.Transaction) bool {
+	for _, s := range i.mgr.GetPipeSubscribers(i.pipe) {
+		if s == sub {
+			return true
+		}
+	}
+	return false
+}
+
+// sendFanOut sends a copy of a message to each subscriber of the pipe, and
+// blocks until each subscriber has either acknowledged the message or
+// unsubscribed. Returns false if the output was closed before then.
+func (i *Inproc) sendFanOut(msg types.Message) bool {
+	var pending []chan<- types.Transaction
+	for {
+		if pending = i.mgr.GetPipeSubscribers(i.pipe); len(pending) > 0 {
+			break
+		}
+		select {
+		case <-time.After(inprocSubscriberPeriod):
+		case <-i.closeChan:
+			return false
+		}
+	}
+
+	type inFlight struct {
+		sub     chan<- types.Transaction
+		resChan chan types.Response
+	}
+
+	throt := throttle.New(throttle.OptCloseChan(i.closeChan))
+	for len(pending) > 0 {
+		sent := make([]inFlight, 0, len(pending))
+	sendLoop:
+		for _, sub := range pending {
+			resChan := make(chan types.Response, 1)
+			for {
+				select {
+				case sub <- types.NewTransaction(msg.Copy(), resChan):
+					sent = append(sent, inFlight{sub: sub, resChan: resChan})
+					continue sendLoop
+				case <-time.After(inprocSubscriberPeriod):
+					if !i.isSubscribed(sub) {
+						continue sendLoop
+					}
+				case <-i.closeChan:
+					return false
+				}
+			}
+		}
+
+		pending = pending[:0]
+	resLoop:
+		for _, f := range sent {
+			for {
+				select {
+				case res := <-f.resChan:
+					if res.Error() != nil {
+						i.mSendErr.Incr(1)
+						i.mSendErrF.Incr(1)
+						pending = append(pending, f.sub)
+					}
+					continue resLoop
+				case <-time.After(inprocSubscriberPeriod):
+					if !i.isSubscribed(f.sub) {
+						continue resLoop
+					}
+				case <-i.closeChan:
+					return false
+				}
+			}
+		}
+
+		if len(pending) > 0 && !throt.Retry() {
+			return false
+		}
+	}
+	return true
+}
+
 // Consume assigns a messages channel for the output to read.
 func (i *Inproc) Consume(ts <-chan types.Transaction) error {
 	if i.transactionsIn != nil {
@@ -21,12 +21,18 @@
 package output
 
 import (
+	"encoding/json"
+	"errors"
 	"testing"
 	"time"
 
+	yaml "gopkg.in/yaml.v2"
+
 	"github.com/Jeffail/benthos/lib/log"
 	"github.com/Jeffail/benthos/lib/manager"
+	"github.com/Jeffail/benthos/lib/message"
 	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/response"
 	"github.com/Jeffail/benthos/lib/types"
 )
 
@@ -39,7 +45,7 @@ func TestInproc(t *testing.T) {
 	}
 
 	conf := NewConfig()
-	conf.Inproc = "foo"
+	conf.Inproc.Name = "foo"
 
 	var ip Type
 	if ip, err = NewInproc(conf, mgr, log.Noop(), metrics.Noop()); err != nil {
@@ -90,4 +96,242 @@ func TestInproc(t *testing.T) {
 	}
 }
 
+func TestInprocConfigString(t *testing.T) {
+	conf := NewConfig()
+	if err := json.Unmarshal([]byte(`{"type":"inproc","inproc":"foo"}`), &conf); err != nil {
+		t.Fatal(err)
+	}
+	if exp, act := "foo", conf.Inproc.Name; exp != act {
+		t.Errorf("Wrong name: %v != %v", act, exp)
+	}
+	if exp, act := "shared", conf.Inproc.Pattern; exp != act {
+		t.Errorf("Wrong pattern: %v != %v", act, exp)
+	}
+
+	conf = NewConfig()
+	if err := yaml.Unmarshal([]byte(`
+type: inproc
+inproc:
+  name: bar
+  buffer_size: 10
+`), &conf); err != nil {
+		t.Fatal(err)
+	}
+	if exp, act := "bar", conf.Inproc.Name; exp != act {
+		t.Errorf("Wrong name: %v != %v", act, exp)
+	}
+	if exp, act := "shared", conf.Inproc.Pattern; exp != act {
+		t.Errorf("Wrong pattern: %v != %v", act, exp)
+	}
+	if exp, act := 10, conf.Inproc.BufferSize; exp != act {
+		t.Errorf("Wrong buffer size: %v != %v", act, exp)
+	}
+
+	conf = NewConfig()
+	if err := yaml.Unmarshal([]byte(`
+type: inproc
+inproc: baz
+`), &conf); err != nil {
+		t.Fatal(err)
+	}
+	if exp, act := "baz", conf.Inproc.Name; exp != act {
+		t.Errorf("Wrong name: %v != %v", act, exp)
+	}
+}
+
+func TestInprocBadPattern(t *testing.T) {
+	conf := NewConfig()
+	conf.Inproc.Name = "foo"
+	conf.Inproc.Pattern = "nope"
+
+	if _, err := NewInproc(conf, types.NoopMgr(), log.Noop(), metrics.Noop()); err == nil {
+		t.Error("Expected error from bad pattern")
+	}
+}
+
+func TestInprocFanOut(t *testing.T) {
+	mgr, err := manager.New(manager.NewConfig(), nil, log.Noop(), metrics.Noop())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	conf := NewConfig()
+	conf.Inproc.Name = "foo"
+	conf.Inproc.Pattern = "fan_out"
+
+	var ip Type
+	if ip, err = NewInproc(conf, mgr, log.Noop(), metrics.Noop()); err != nil {
+		t.Fatal(err)
+	}
+
+	sub1, sub2 := make(chan types.Transaction), make(chan types.Transaction)
+	mgr.SubscribePipe("foo", sub1)
+	mgr.SubscribePipe("foo", sub2)
+
+	tinchan := make(chan types.Transaction)
+	resChan := make(chan types.Response)
+	if err = ip.Consume(tinchan); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case tinchan <- types.NewTransaction(message.New([][]byte{[]byte("hello")}), resChan):
+	case <-time.After(time.Second):
+		t.Fatal("Timed out")
+	}
+
+	for _, sub := range []chan types.Transaction{sub1, sub2} {
+		select {
+		case tran := <-sub:
+			if act := string(tran.Payload.Get(0).Get()); act != "hello" {
+				t.Errorf("Wrong message: %v", act)
+			}
+			tran.ResponseChan <- response.NewAck()
+		case <-time.After(time.Second):
+			t.Fatal("Timed out")
+		}
+	}
+
+	select {
+	case res := <-resChan:
+		if res.Error() != nil {
+			t.Error(res.Error())
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Timed out")
+	}
+
+	// A failed subscriber should receive the message again.
+	select {
+	case tinchan <- types.NewTransaction(message.New([][]byte{[]byte("world")}), resChan):
+	case <-time.After(time.Second):
+		t.Fatal("Timed out")
+	}
+
+	for _, sub := range []chan types.Transaction{sub1, sub2} {
+		select {
+		case tran := <-sub:
+			if sub == sub1 {
+				tran.ResponseChan <- response.NewError(errors.New("nope"))
+			} else {
+				tran.ResponseChan <- response.NewAck()
+			}
+		case <-time.After(time.Second):
+			t.Fatal("Timed out")
+		}
+	}
+
+	select {
+	case tran := <-sub1:
+		if act := string(tran.Payload.Get(0).Get()); act != "world" {
+			t.Errorf("Wrong message: %v", act)
+		}
+		tran.ResponseChan <- response.NewAck()
+	case <-sub2:
+		t.Fatal("Received duplicate message")
+	case <-time.After(time.Second):
+		t.Fatal("Timed out")
+	}
+
+	select {
+	case res := <-resChan:
+		if res.Error() != nil {
+			t.Error(res.Error())
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Timed out")
+	}
+
+	// An unsubscribed subscriber should be skipped.
+	mgr.UnsubscribePipe("foo", sub2)
+
+	select {
+	case tinchan <- types.NewTransaction(message.New([][]byte{[]byte("bar")}), resChan):
+	case <-time.After(time.Second):
+		t.Fatal("Timed out")
+	}
+
+	select {
+	case tran := <-sub1:
+		tran.ResponseChan <- response.NewAck()
+	case <-time.After(time.Second):
+		t.Fatal("Timed out")
+	}
+
+	select {
+	case res := <-resChan:
+		if res.Error() != nil {
+			t.Error(res.Error())
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Timed out")
+	}
+
+	ip.CloseAsync()
+	if err = ip.WaitForClose(time.Second); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestInprocBuffered(t *testing.T) {
+	mgr, err := manager.New(manager.NewConfig(), nil, log.Noop(), metrics.Noop())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	conf := NewConfig()
+	conf.Inproc.Name = "foo"
+	conf.Inproc.BufferSize = 5
+
+	var ip Type
+	if ip, err = NewInproc(conf, mgr, log.Noop(), metrics.Noop()); err != nil {
+		t.Fatal(err)
+	}
+
+	tinchan := make(chan types.Transaction)
+	resChan := make(chan types.Response)
+	if err = ip.Consume(tinchan); err != nil {
+		t.Fatal(err)
+	}
+
+	// Messages should be acknowledged without a connected input.
+	for i := 0; i < 5; i++ {
+		select {
+		case tinchan <- types.NewTransaction(message.New([][]byte{[]byte("hello")}), resChan):
+		case <-time.After(time.Second):
+			t.Fatal("Timed out")
+		}
+		select {
+		case res := <-resChan:
+			if res.Error() != nil {
+				t.Error(res.Error())
+			}
+		case <-time.After(time.Second):
+			t.Fatal("Timed out")
+		}
+	}
+
+	var toutchan <-chan types.Transaction
+	if toutchan, err = mgr.GetPipe("foo"); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 5; i++ {
+		select {
+		case tran := <-toutchan:
+			if act := string(tran.Payload.Get(0).Get()); act != "hello" {
+				t.Errorf("Wrong message: %v", act)
+			}
+			tran.ResponseChan <- response.NewAck()
+		case <-time.After(time.Second):
+			t.Fatal("Timed out")
+		}
+	}
+
+	ip.CloseAsync()
+	if err = ip.WaitForClose(time.Second); err != nil {
+		t.Error(err)
+	}
+}
+
 //------------------------------------------------------------------------------
@@ -25,6 +25,7 @@ import (
 	"github.com/Jeffail/benthos/lib/metrics"
 	"github.com/Jeffail/benthos/lib/output/writer"
 	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/kafka/sasl"
 	"github.com/Jeffail/benthos/lib/util/tls"
 )
 
@@ -40,22 +41,54 @@ acknowledged, which is propagated back to the input. The config field
 replicas or just a single broker.
 
 It is possible to specify a compression codec to use out of the following
-options: none, snappy, lz4 and gzip.
+options: none, snappy, lz4, gzip and zstd. The zstd codec requires a
+` + "`target_version`" + ` of at least 2.1.0.
+
+Setting ` + "`idempotent_write`" + ` enables idempotent producing, which prevents
+duplicate messages being written by producer retries. This requires a
+` + "`target_version`" + ` of at least 0.11.0 and implies
+` + "`ack_replicas`" + `.
+
+Parts of a batch sent to the same partition are grouped into a single produce
+request. The fields ` + "`linger_ms` and `batch_bytes`" + ` allow the producer
+to wait for a period of time or until a number of bytes is buffered before
+flushing a request, which can improve throughput when messages are written in
+parallel.
 
 If the field ` + "`key`" + ` is not empty then each message will be given its
 contents as a key.
 
-Both the ` + "`key` and `topic`" + ` fields can be dynamically set using
-function interpolations described [here](../config_interpolation.md#functions).
-When sending batched messages these interpolations are performed per message
-part.
+The ` + "`key`, `topic`, `partition` and `timestamp`" + ` fields can be
+dynamically set using function interpolations described
+[here](../config_interpolation.md#functions). When sending batched messages
+these interpolations are performed per message part.
+
+The ` + "`partitioner`" + ` field determines how partitions are selected, and
+can be one of ` + "`fnv1a_hash`, `random`, `round_robin` or `manual`" + `. By
+default the partitioner will select partitions based on an FNV-1a hash of the
+key value, and if the key is empty then a partition is chosen at random. The
+` + "`manual`" + ` partitioner writes each message to the partition specified by
+the ` + "`partition`" + ` field, and message parts where the partition does not
+resolve to a number are logged and dropped.
+
+When the ` + "`timestamp`" + ` field is not empty it sets the timestamp of each
+record, which is useful for replaying messages whilst preserving their original
+event time. The resolved value can either be a unix timestamp in seconds, which
+may be fractional, or an RFC 3339 formatted date. For example, a replay from a
+Kafka input can use ` + "`${!metadata:kafka_timestamp_unix}`" + `, and a JSON
+document can use ` + "`${!json_field:event.time}`" + `. Record timestamps
+require a ` + "`target_version`" + ` of at least 0.10.0.
+
+The field ` + "`round_robin_partitions`" + ` is deprecated and, when set,
+overrides the ` + "`partitioner`" + ` with ` + "`round_robin`" + `.
+
+Which metadata keys are sent as headers, and the names they are given, can be
+configured within the ` + "`metadata`" + ` section as described
+[here](../metadata.md).
 
-By default the paritioner will select partitions based on a hash of the key
-value. If the key is empty then a partition is chosen at random. You can
-alternatively force the partitioner to round-robin partitions with the field
-` + "`round_robin_partitions`" + `.
+` + tls.Documentation + `
 
-` + tls.Documentation + ``,
+` + sasl.Documentation + ``,
 	}
 }
 
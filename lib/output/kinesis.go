@@ -38,7 +38,14 @@ Sends messages to a Kinesis stream.
 Both the ` + "`partition_key`" + `(required) and ` + "`hash_key`" + ` (optional)
 fields can be dynamically set using function interpolations described
 [here](../config_interpolation.md#functions). When sending batched messages the
-interpolations are performed per message part.`,
+interpolations are performed per message part.
+
+### Credentials
+
+By default Benthos will use a shared credentials file when connecting to AWS
+services. It's also possible to set them explicitly at the component level,
+allowing you to transfer data across accounts. You can find out more
+[in this document](../aws.md).`,
 	}
 }
 
@@ -21,15 +21,13 @@
 package output
 
 import (
-	"bytes"
-	"fmt"
 	"io"
 	"sync/atomic"
 	"time"
 
 	"github.com/Jeffail/benthos/lib/log"
-	"github.com/Jeffail/benthos/lib/message"
 	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/output/writer"
 	"github.com/Jeffail/benthos/lib/response"
 	"github.com/Jeffail/benthos/lib/types"
 )
@@ -45,7 +43,7 @@ type LineWriter struct {
 	log     log.Modular
 	stats   metrics.Type
 
-	customDelim []byte
+	encoder writer.Encoder
 
 	transactions <-chan types.Transaction
 
@@ -65,12 +63,31 @@ func NewLineWriter(
 	log log.Modular,
 	stats metrics.Type,
 ) (Type, error) {
+	return NewCodecWriter(handle, closeOnExit, "lines", customDelimiter, typeStr, log, stats)
+}
+
+// NewCodecWriter creates a new LineWriter output type that writes messages in
+// the format of a codec, which can be one of lines, json_array or
+// length_prefixed.
+func NewCodecWriter(
+	handle io.WriteCloser,
+	closeOnExit bool,
+	codec string,
+	customDelimiter []byte,
+	typeStr string,
+	log log.Modular,
+	stats metrics.Type,
+) (Type, error) {
+	encoder, err := writer.NewEncoder(codec, customDelimiter, handle)
+	if err != nil {
+		return nil, err
+	}
 	return &LineWriter{
 		running:     1,
 		typeStr:     typeStr,
 		log:         log.NewModule(".output." + typeStr),
 		stats:       stats,
-		customDelim: customDelimiter,
+		encoder:     encoder,
 		handle:      handle,
 		closeOnExit: closeOnExit,
 		closeChan:   make(chan struct{}),
@@ -95,6 +112,9 @@ func (w *LineWriter) loop() {
 	)
 
 	defer func() {
+		if err := w.encoder.Close(); err != nil {
+			w.log.Errorf("Failed to terminate output stream: %v\n", err)
+		}
 		if w.closeOnExit {
 			w.handle.Close()
 		}
@@ -106,11 +126,6 @@ func (w *LineWriter) loop() {
 	mRunning.Incr(1)
 	mRunningF.Incr(1)
 
-	delim := []byte("\n")
-	if len(w.customDelim) > 0 {
-		delim = w.customDelim
-	}
-
 	for atomic.LoadInt32(&w.running) == 1 {
 		var ts types.Transaction
 		var open bool
@@ -124,12 +139,7 @@ func (w *LineWriter) loop() {
 		case <-w.closeChan:
 			return
 		}
-		var err error
-		if ts.Payload.Len() == 1 {
-			_, err = fmt.Fprintf(w.handle, "%s%s", ts.Payload.Get(0).Get(), delim)
-		} else {
-			_, err = fmt.Fprintf(w.handle, "%s%s%s", bytes.Join(message.GetAllBytes(ts.Payload), delim), delim, delim)
-		}
+		err := w.encoder.Encode(ts.Payload)
 		if err != nil {
 			mError.Incr(1)
 			mErrorF.Incr(1)
@@ -0,0 +1,90 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package output
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/Jeffail/benthos/lib/log"
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/output/writer"
+	"github.com/Jeffail/benthos/lib/types"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors[TypeMock] = TypeSpec{
+		constructor: NewMock,
+		description: `
+Records the messages it receives so that the results of a config can be
+checked in CI without any external infrastructure.
+
+Recorded messages can be fetched with a GET request to the endpoint at
+` + "`path`" + ` on the Benthos HTTP server, which responds with a JSON array
+of messages, where each message is an array of parts with their content and
+metadata:
+
+` + "``` json" + `
+[
+  [
+    {"content": "{\"id\":\"foo\"}", "metadata": {"key": "value"}}
+  ]
+]
+` + "```" + `
+
+When ` + "`file`" + ` is set each message is also appended to the file as a
+line in the same format, which can be inspected after Benthos shuts down.`,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// NewMock creates a new Mock output type.
+func NewMock(conf Config, mgr types.Manager, log log.Modular, stats metrics.Type) (Type, error) {
+	m := writer.NewMock(conf.Mock, log, stats)
+	if len(conf.Mock.Path) > 0 {
+		mgr.RegisterEndpoint(
+			conf.Mock.Path, "Returns the messages recorded by a mock output.",
+			mockHandler(m),
+		)
+	}
+	return NewWriter("mock", m, log, stats)
+}
+
+func mockHandler(m *writer.Mock) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		resBytes, err := json.Marshal(m.Messages())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(resBytes)
+	}
+}
+
+//------------------------------------------------------------------------------
@@ -33,7 +33,14 @@ func init() {
 	Constructors[TypeMQTT] = TypeSpec{
 		constructor: NewMQTT,
 		description: `
-Pushes messages to an MQTT broker.`,
+Pushes messages to an MQTT broker.
+
+The field ` + "`topic`" + ` can be dynamically set using function interpolations
+described [here](../config_interpolation.md#functions). When sending batched
+messages these interpolations are performed per message part.
+
+Custom TLS settings for ` + "`ssl`" + ` URLs can be set in the ` + "`tls`" + `
+section as described [here](../tls.md).`,
 	}
 }
 
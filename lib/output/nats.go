@@ -34,7 +34,17 @@ func init() {
 		constructor: NewNATS,
 		description: `
 Publish to an NATS subject. NATS is at-most-once, so delivery is not guaranteed.
-For at-least-once behaviour with NATS look at NATS Stream.`,
+For at-least-once behaviour with NATS look at NATS Stream.
+
+The field ` + "`subject`" + ` can be dynamically set using function
+interpolations described [here](../config_interpolation.md#functions). When
+sending batched messages these interpolations are performed per message part.
+
+Message metadata is not sent as NATS headers, since headers were introduced in
+NATS server v2.2 and are not supported by the client used by this output.
+
+TLS can be customised in the ` + "`tls`" + ` section as described
+[here](../tls.md).`,
 	}
 }
 
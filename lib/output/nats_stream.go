@@ -33,7 +33,14 @@ func init() {
 	Constructors[TypeNATSStream] = TypeSpec{
 		constructor: NewNATSStream,
 		description: `
-Publish to a NATS Stream subject.`,
+Publish to a NATS Stream subject.
+
+The field ` + "`subject`" + ` can be dynamically set using function
+interpolations described [here](../config_interpolation.md#functions). When
+sending batched messages these interpolations are performed per message part.
+
+TLS can be customised in the ` + "`tls`" + ` section as described
+[here](../tls.md).`,
 	}
 }
 
@@ -37,7 +37,10 @@ func init() {
 Publish to an NSQ topic. The ` + "`topic`" + ` field can be dynamically set
 using function interpolations described
 [here](../config_interpolation.md#functions). When sending batched messages
-these interpolations are performed per message part.`,
+these interpolations are performed per message part.
+
+TLS can be customised in the ` + "`tls`" + ` section as described
+[here](../tls.md).`,
 	}
 }
 
@@ -76,8 +76,6 @@ var pluginSpecs = map[string]pluginSpec{}
 // RegisterPlugin registers a plugin by a unique name so that it can be
 // constucted similar to regular outputs. A constructor for both the plugin
 // itself as well as its configuration struct must be provided.
-//
-// WARNING: This API is experimental and could (is likely) to change.
 func RegisterPlugin(
 	typeString string,
 	configConstructor PluginConfigConstructor,
@@ -102,6 +100,12 @@ func DocumentPlugin(
 	pluginSpecs[typeString] = spec
 }
 
+// PluginExists returns true if a plugin of the given type has been registered.
+func PluginExists(typeString string) bool {
+	_, exists := pluginSpecs[typeString]
+	return exists
+}
+
 //------------------------------------------------------------------------------
 
 var pluginHeader = `This document has been generated, do not edit it directly.
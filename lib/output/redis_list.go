@@ -34,7 +34,14 @@ func init() {
 		constructor: NewRedisList,
 		description: `
 Pushes messages onto the end of a Redis list (which is created if it doesn't
-already exist) using the RPUSH command.`,
+already exist) using the RPUSH command.
+
+The field ` + "`key`" + ` can be dynamically set using function interpolations
+described [here](../config_interpolation.md#functions). When sending batched
+messages these interpolations are performed per message part.
+
+TLS can be customised in the ` + "`tls`" + ` section as described
+[here](../tls.md).`,
 	}
 }
 
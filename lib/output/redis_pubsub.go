@@ -34,7 +34,14 @@ func init() {
 		constructor: NewRedisPubSub,
 		description: `
 Publishes messages through the Redis PubSub model. It is not possible to
-guarantee that messages have been received.`,
+guarantee that messages have been received.
+
+The field ` + "`channel`" + ` can be dynamically set using function
+interpolations described [here](../config_interpolation.md#functions). When
+sending batched messages these interpolations are performed per message part.
+
+TLS can be customised in the ` + "`tls`" + ` section as described
+[here](../tls.md).`,
 	}
 }
 
@@ -42,7 +42,14 @@ efficiency.
 Redis stream entries are key/value pairs, as such it is necessary to specify the
 key to be set to the body of the message. All metadata fields of the message
 will also be set as key/value pairs, if there is a key collision between
-a metadata item and the body then the body takes precedence.`,
+a metadata item and the body then the body takes precedence.
+
+The field ` + "`stream`" + ` can be dynamically set using function
+interpolations described [here](../config_interpolation.md#functions). When
+sending batched messages these interpolations are performed per message part.
+
+TLS can be customised in the ` + "`tls`" + ` section as described
+[here](../tls.md).`,
 	}
 }
 
@@ -36,7 +36,24 @@ func init() {
 Sends message parts as objects to an Amazon S3 bucket. Each object is uploaded
 with the path specified with the 'path' field, in order to have a different path
 for each object you should use function interpolations described
-[here](../config_interpolation.md#functions).`,
+[here](../config_interpolation.md#functions). When sending batched messages
+these interpolations are performed per message part.
+
+The field ` + "`force_path_style_urls`" + ` addresses objects with path style
+URLs (` + "`endpoint/bucket/key`" + `) rather than virtual hosted URLs, which is
+required by some S3 compatible services such as Minio.
+
+The field ` + "`max_in_flight`" + ` sets the maximum number of messages that
+can be written in parallel, which can greatly improve throughput when each
+write has a high latency. Each message is acknowledged once its own write has
+completed, but this means messages can be written out of order.
+
+### Credentials
+
+By default Benthos will use a shared credentials file when connecting to AWS
+services. It's also possible to set them explicitly at the component level,
+allowing you to transfer data across accounts. You can find out more
+[in this document](../aws.md).`,
 	}
 }
 
@@ -44,8 +61,8 @@ for each object you should use function interpolations described
 
 // NewAmazonS3 creates a new AmazonS3 output type.
 func NewAmazonS3(conf Config, mgr types.Manager, log log.Modular, stats metrics.Type) (Type, error) {
-	return NewWriter(
-		"s3", writer.NewAmazonS3(conf.S3, log, stats), log, stats,
+	return NewAsyncWriter(
+		"s3", conf.S3.MaxInFlight, writer.NewAmazonS3(conf.S3, log, stats), log, stats,
 	)
 }
 
@@ -33,7 +33,43 @@ func init() {
 	Constructors[TypeSQS] = TypeSpec{
 		constructor: NewAmazonSQS,
 		description: `
-Sends messages to an SQS queue.`,
+Sends messages to an SQS queue.
+
+The field ` + "`max_in_flight`" + ` sets the maximum number of messages that
+can be written in parallel, which can greatly improve throughput when each
+write has a high latency. Each message is acknowledged once its own write has
+completed, but this means messages can be written out of order.
+
+Metadata keys are sent as message attributes according to the rules of the
+` + "`metadata`" + ` section described [here](../metadata.md), which by default
+sends none.
+
+### FIFO Queues
+
+When sending to a FIFO queue the field ` + "`message_group_id`" + ` must be
+set, and the field ` + "`message_deduplication_id`" + ` must be set unless
+content based deduplication is enabled for the queue. Both fields support
+[function interpolations](../config_interpolation.md#functions), which are
+resolved individually for each message of a batch:
+
+` + "``` yaml" + `
+type: sqs
+sqs:
+  url: https://sqs.eu-west-1.amazonaws.com/123456789012/foo.fifo
+  message_group_id: ${!metadata:tenant}
+  message_deduplication_id: ${!json_field:id}
+` + "```" + `
+
+Messages of the same group are delivered in the order they are sent, and
+therefore ` + "`max_in_flight`" + ` should be left at one when the order of
+messages matters.
+
+### Credentials
+
+By default Benthos will use a shared credentials file when connecting to AWS
+services. It's also possible to set them explicitly at the component level,
+allowing you to transfer data across accounts. You can find out more
+[in this document](../aws.md).`,
 	}
 }
 
@@ -41,8 +77,8 @@ Sends messages to an SQS queue.`,
 
 // NewAmazonSQS creates a new AmazonSQS output type.
 func NewAmazonSQS(conf Config, mgr types.Manager, log log.Modular, stats metrics.Type) (Type, error) {
-	return NewWriter(
-		"sqs", writer.NewAmazonSQS(conf.SQS, log, stats), log, stats,
+	return NewAsyncWriter(
+		"sqs", conf.SQS.MaxInFlight, writer.NewAmazonSQS(conf.SQS, log, stats), log, stats,
 	)
 }
 
@@ -42,7 +42,17 @@ as:
 
 foo\n
 bar\n
-baz\n\n`,
+baz\n\n
+
+The ` + "`codec`" + ` field can be used in order to change the format messages
+are written in, the options are:
+
+- ` + "`lines`" + `: Each message part is written followed by the delimiter as
+  described above.
+- ` + "`json_array`" + `: Each message part must be valid JSON and is written as
+  an element of a JSON array, which is terminated when Benthos shuts down.
+- ` + "`length_prefixed`" + `: Each message part is written prefixed with its
+  length in bytes as a 32 bit big endian unsigned integer.`,
 	}
 }
 
@@ -50,12 +60,14 @@ baz\n\n`,
 
 // STDOUTConfig contains configuration fields for the stdout based output type.
 type STDOUTConfig struct {
+	Codec string `json:"codec" yaml:"codec"`
 	Delim string `json:"delimiter" yaml:"delimiter"`
 }
 
 // NewSTDOUTConfig creates a new STDOUTConfig with default values.
 func NewSTDOUTConfig() STDOUTConfig {
 	return STDOUTConfig{
+		Codec: "lines",
 		Delim: "",
 	}
 }
@@ -64,7 +76,7 @@ func NewSTDOUTConfig() STDOUTConfig {
 
 // NewSTDOUT creates a new STDOUT output type.
 func NewSTDOUT(conf Config, mgr types.Manager, log log.Modular, stats metrics.Type) (Type, error) {
-	return NewLineWriter(os.Stdout, false, []byte(conf.STDOUT.Delim), "stdout", log, stats)
+	return NewCodecWriter(os.Stdout, false, conf.STDOUT.Codec, []byte(conf.STDOUT.Delim), "stdout", log, stats)
 }
 
 //------------------------------------------------------------------------------
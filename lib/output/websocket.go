@@ -33,7 +33,10 @@ func init() {
 	Constructors[TypeWebsocket] = TypeSpec{
 		constructor: NewWebsocket,
 		description: `
-Sends messages to an HTTP server via a websocket connection.`,
+Sends messages to an HTTP server via a websocket connection.
+
+Custom TLS settings for ` + "`wss`" + ` URLs can be set in the ` + "`tls`" + `
+section as described [here](../tls.md).`,
 	}
 }
 
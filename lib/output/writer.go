@@ -21,6 +21,8 @@
 package output
 
 import (
+	"errors"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -28,6 +30,7 @@ import (
 	"github.com/Jeffail/benthos/lib/metrics"
 	"github.com/Jeffail/benthos/lib/output/writer"
 	"github.com/Jeffail/benthos/lib/response"
+	"github.com/Jeffail/benthos/lib/tracing"
 	"github.com/Jeffail/benthos/lib/types"
 	"github.com/Jeffail/benthos/lib/util/throttle"
 )
@@ -36,10 +39,13 @@ import (
 
 // Writer is an output type that writes messages to a writer.Type.
 type Writer struct {
-	running int32
+	running   int32
+	connected int32
 
-	typeStr string
-	writer  writer.Type
+	typeStr     string
+	writer      writer.Type
+	maxInFlight int
+	connMut     sync.Mutex
 
 	log   log.Modular
 	stats metrics.Type
@@ -57,10 +63,28 @@ func NewWriter(
 	log log.Modular,
 	stats metrics.Type,
 ) (Type, error) {
+	return NewAsyncWriter(typeStr, 1, w, log, stats)
+}
+
+// NewAsyncWriter creates a new Writer output type that writes up to
+// maxInFlight messages concurrently, the writer.Type must therefore be safe
+// for concurrent calls to Write. Each message is acknowledged individually once
+// its write has completed.
+func NewAsyncWriter(
+	typeStr string,
+	maxInFlight int,
+	w writer.Type,
+	log log.Modular,
+	stats metrics.Type,
+) (Type, error) {
+	if maxInFlight < 1 {
+		return nil, errors.New("max_in_flight must be at least 1")
+	}
 	return &Writer{
 		running:      1,
 		typeStr:      typeStr,
 		writer:       w,
+		maxInFlight:  maxInFlight,
 		log:          log.NewModule(".output." + typeStr),
 		stats:        stats,
 		transactions: nil,
@@ -71,26 +95,16 @@ func NewWriter(
 
 //------------------------------------------------------------------------------
 
-// loop is an internal loop that brokers incoming messages to output pipe.
+// loop is an internal loop that connects the writer and then brokers incoming
+// messages to it with a number of parallel workers.
 func (w *Writer) loop() {
-	// Metrics paths
 	var (
-		mRunning       = w.stats.GetGauge("output.running")
-		mRunningF      = w.stats.GetGauge("output." + w.typeStr + ".running")
-		mCount         = w.stats.GetCounter("output.count")
-		mCountF        = w.stats.GetCounter("output." + w.typeStr + ".count")
-		mSuccess       = w.stats.GetCounter("output.send.success")
-		mSuccessF      = w.stats.GetCounter("output." + w.typeStr + ".send.success")
-		mPartsSuccess  = w.stats.GetCounter("output.parts.send.success")
-		mPartsSuccessF = w.stats.GetCounter("output." + w.typeStr + ".parts.send.success")
-		mError         = w.stats.GetCounter("output.send.error")
-		mErrorF        = w.stats.GetCounter("output." + w.typeStr + ".send.error")
-		mConn          = w.stats.GetCounter("output.connection.up")
-		mConnF         = w.stats.GetCounter("output." + w.typeStr + ".connection.up")
-		mFailedConn    = w.stats.GetCounter("output.connection.failed")
-		mFailedConnF   = w.stats.GetCounter("output." + w.typeStr + ".connection.failed")
-		mLostConn      = w.stats.GetCounter("output.connection.lost")
-		mLostConnF     = w.stats.GetCounter("output." + w.typeStr + ".connection.lost")
+		mRunning     = w.stats.GetGauge("output.running")
+		mRunningF    = w.stats.GetGauge("output." + w.typeStr + ".running")
+		mConn        = w.stats.GetCounter("output.connection.up")
+		mConnF       = w.stats.GetCounter("output." + w.typeStr + ".connection.up")
+		mFailedConn  = w.stats.GetCounter("output.connection.failed")
+		mFailedConnF = w.stats.GetCounter("output." + w.typeStr + ".connection.failed")
 	)
 
 	defer func() {
@@ -125,6 +139,90 @@ func (w *Writer) loop() {
 	}
 	mConn.Incr(1)
 	mConnF.Incr(1)
+	atomic.StoreInt32(&w.connected, 1)
+
+	wg := sync.WaitGroup{}
+	wg.Add(w.maxInFlight)
+	for i := 0; i < w.maxInFlight; i++ {
+		go func() {
+			defer wg.Done()
+			w.worker()
+		}()
+	}
+	wg.Wait()
+}
+
+// reconnect attempts to reconnect the writer and write a message that failed
+// due to a lost connection. Only one worker at a time reconnects, and workers
+// that were waiting attempt the write again before reconnecting themselves.
+// Returns false if the writer should stop, and otherwise the result of the
+// write.
+func (w *Writer) reconnect(msg types.Message, throt *throttle.Type) (bool, error) {
+	var (
+		mConn        = w.stats.GetCounter("output.connection.up")
+		mConnF       = w.stats.GetCounter("output." + w.typeStr + ".connection.up")
+		mFailedConn  = w.stats.GetCounter("output.connection.failed")
+		mFailedConnF = w.stats.GetCounter("output." + w.typeStr + ".connection.failed")
+	)
+
+	w.connMut.Lock()
+	defer w.connMut.Unlock()
+
+	if w.maxInFlight > 1 {
+		if err := w.writer.Write(msg); err != types.ErrNotConnected {
+			return true, err
+		}
+	}
+
+	// Continue to try to reconnect while still active.
+	for atomic.LoadInt32(&w.running) == 1 {
+		err := w.writer.Connect()
+		if err != nil {
+			// Close immediately if our writer is closed.
+			if err == types.ErrTypeClosed {
+				return false, err
+			}
+
+			w.log.Errorf("Failed to reconnect to %v: %v\n", w.typeStr, err)
+			mFailedConn.Incr(1)
+			mFailedConnF.Incr(1)
+			if !throt.Retry() {
+				return false, err
+			}
+		} else if err = w.writer.Write(msg); err != types.ErrNotConnected {
+			mConn.Incr(1)
+			mConnF.Incr(1)
+			atomic.StoreInt32(&w.connected, 1)
+			return true, err
+		} else if !throt.Retry() {
+			return false, err
+		}
+	}
+	return false, types.ErrTypeClosed
+}
+
+// worker writes messages from the transactions channel until the channel is
+// closed or the writer is closed.
+func (w *Writer) worker() {
+	// Metrics paths
+	var (
+		mCount         = w.stats.GetCounter("output.count")
+		mCountF        = w.stats.GetCounter("output." + w.typeStr + ".count")
+		mSuccess       = w.stats.GetCounter("output.send.success")
+		mSuccessF      = w.stats.GetCounter("output." + w.typeStr + ".send.success")
+		mPartsSuccess  = w.stats.GetCounter("output.parts.send.success")
+		mPartsSuccessF = w.stats.GetCounter("output." + w.typeStr + ".parts.send.success")
+		mError         = w.stats.GetCounter("output.send.error")
+		mErrorF        = w.stats.GetCounter("output." + w.typeStr + ".send.error")
+		mLostConn      = w.stats.GetCounter("output.connection.lost")
+		mLostConnF     = w.stats.GetCounter("output." + w.typeStr + ".connection.lost")
+		mSendLatency   = w.stats.GetTimer("output.send.latency")
+		mSendLatencyF  = w.stats.GetTimer("output." + w.typeStr + ".send.latency")
+		mLatency       = w.stats.GetTimer("output.latency")
+		mLatencyF      = w.stats.GetTimer("output." + w.typeStr + ".latency")
+	)
+
+	throt := throttle.New(throttle.OptCloseChan(w.closeChan))
 
 	for atomic.LoadInt32(&w.running) == 1 {
 		var ts types.Transaction
@@ -140,36 +238,30 @@ func (w *Writer) loop() {
 			return
 		}
 
-		err := w.writer.Write(ts.Payload)
+		// The payload may be shared with other outputs, and therefore the
+		// span contexts of this output are set within a copy.
+		payload := ts.Payload
+		var spans []*tracing.Span
+		if tracing.Enabled() {
+			payload = payload.Copy()
+			spans = tracing.InitSpans("output."+w.typeStr, payload)
+		}
+
+		started := time.Now()
+		err := w.writer.Write(payload)
 
 		// If our writer says it is not connected.
 		if err == types.ErrNotConnected {
 			mLostConn.Incr(1)
 			mLostConnF.Incr(1)
+			atomic.StoreInt32(&w.connected, 0)
 
-			// Continue to try to reconnect while still active.
-			for atomic.LoadInt32(&w.running) == 1 {
-				if err = w.writer.Connect(); err != nil {
-					// Close immediately if our writer is closed.
-					if err == types.ErrTypeClosed {
-						return
-					}
-
-					w.log.Errorf("Failed to reconnect to %v: %v\n", w.typeStr, err)
-					mFailedConn.Incr(1)
-					mFailedConnF.Incr(1)
-					if !throt.Retry() {
-						return
-					}
-				} else if err = w.writer.Write(ts.Payload); err != types.ErrNotConnected {
-					mConn.Incr(1)
-					mConnF.Incr(1)
-					break
-				} else if !throt.Retry() {
-					return
-				}
+			var ok bool
+			if ok, err = w.reconnect(payload, throt); !ok {
+				return
 			}
 		}
+		tracing.FinishSpans(spans, err)
 
 		// Close immediately if our writer is closed.
 		if err == types.ErrTypeClosed {
@@ -188,6 +280,14 @@ func (w *Writer) loop() {
 			mSuccessF.Incr(1)
 			mPartsSuccess.Incr(int64(ts.Payload.Len()))
 			mPartsSuccessF.Incr(int64(ts.Payload.Len()))
+
+			sendLatency := time.Since(started).Nanoseconds()
+			mSendLatency.Timing(sendLatency)
+			mSendLatencyF.Timing(sendLatency)
+
+			latency := time.Since(ts.Payload.CreatedAt()).Nanoseconds()
+			mLatency.Timing(latency)
+			mLatencyF.Timing(latency)
 			throt.Reset()
 		}
 		select {
@@ -216,6 +316,21 @@ func (w *Writer) CloseAsync() {
 	}
 }
 
+// ConnectionStatus returns nil if the Writer is currently connected,
+// types.ErrNotConnected if it is not, and types.ErrTypeClosed once it has
+// closed.
+func (w *Writer) ConnectionStatus() error {
+	select {
+	case <-w.closedChan:
+		return types.ErrTypeClosed
+	default:
+	}
+	if atomic.LoadInt32(&w.connected) == 1 {
+		return nil
+	}
+	return types.ErrNotConnected
+}
+
 // WaitForClose blocks until the File output has closed down.
 func (w *Writer) WaitForClose(timeout time.Duration) error {
 	select {
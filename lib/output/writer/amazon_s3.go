@@ -25,12 +25,12 @@ import (
 	"time"
 
 	"github.com/Jeffail/benthos/lib/log"
+	"github.com/Jeffail/benthos/lib/message"
 	"github.com/Jeffail/benthos/lib/metrics"
 	"github.com/Jeffail/benthos/lib/types"
+	sess "github.com/Jeffail/benthos/lib/util/aws/session"
 	"github.com/Jeffail/benthos/lib/util/text"
 	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/credentials"
-	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/s3/s3manager"
 )
@@ -38,34 +38,35 @@ import (
 //------------------------------------------------------------------------------
 
 // AmazonAWSCredentialsConfig contains configuration params for AWS credentials.
-type AmazonAWSCredentialsConfig struct {
-	ID     string `json:"id" yaml:"id"`
-	Secret string `json:"secret" yaml:"secret"`
-	Token  string `json:"token" yaml:"token"`
-	Role   string `json:"role" yaml:"role"`
+type AmazonAWSCredentialsConfig = sess.CredentialsConfig
+
+// sessionConfig wraps the shared AWS session config so that it can be embedded
+// alongside other embedded config structs.
+type sessionConfig struct {
+	sess.Config `json:",inline" yaml:",inline"`
 }
 
 // AmazonS3Config contains configuration fields for the AmazonS3 output type.
 type AmazonS3Config struct {
-	Region      string                     `json:"region" yaml:"region"`
-	Bucket      string                     `json:"bucket" yaml:"bucket"`
-	Path        string                     `json:"path" yaml:"path"`
-	Credentials AmazonAWSCredentialsConfig `json:"credentials" yaml:"credentials"`
-	TimeoutS    int64                      `json:"timeout_s" yaml:"timeout_s"`
+	sessionConfig      `json:",inline" yaml:",inline"`
+	Bucket             string `json:"bucket" yaml:"bucket"`
+	ForcePathStyleURLs bool   `json:"force_path_style_urls" yaml:"force_path_style_urls"`
+	Path               string `json:"path" yaml:"path"`
+	TimeoutS           int64  `json:"timeout_s" yaml:"timeout_s"`
+	MaxInFlight        int    `json:"max_in_flight" yaml:"max_in_flight"`
 }
 
 // NewAmazonS3Config creates a new Config with default values.
 func NewAmazonS3Config() AmazonS3Config {
 	return AmazonS3Config{
-		Region: "eu-west-1",
-		Bucket: "",
-		Path:   "${!count:files}-${!timestamp_unix_nano}.txt",
-		Credentials: AmazonAWSCredentialsConfig{
-			ID:     "",
-			Secret: "",
-			Token:  "",
+		sessionConfig: sessionConfig{
+			Config: sess.NewConfig(),
 		},
-		TimeoutS: 5,
+		Bucket:             "",
+		ForcePathStyleURLs: false,
+		Path:               "${!count:files}-${!timestamp_unix_nano}.txt",
+		TimeoutS:           5,
+		MaxInFlight:        1,
 	}
 }
 
@@ -76,8 +77,7 @@ func NewAmazonS3Config() AmazonS3Config {
 type AmazonS3 struct {
 	conf AmazonS3Config
 
-	pathBytes       []byte
-	interpolatePath bool
+	path *text.InterpolatedString
 
 	session  *session.Session
 	uploader *s3manager.Uploader
@@ -92,14 +92,11 @@ func NewAmazonS3(
 	log log.Modular,
 	stats metrics.Type,
 ) *AmazonS3 {
-	pathBytes := []byte(conf.Path)
-	interpolatePath := text.ContainsFunctionVariables(pathBytes)
 	return &AmazonS3{
-		conf:            conf,
-		pathBytes:       pathBytes,
-		interpolatePath: interpolatePath,
-		log:             log.NewModule(".output.amazon_s3"),
-		stats:           stats,
+		conf:  conf,
+		path:  text.NewInterpolatedString(conf.Path),
+		log:   log.NewModule(".output.amazon_s3"),
+		stats: stats,
 	}
 }
 
@@ -109,29 +106,13 @@ func (a *AmazonS3) Connect() error {
 		return nil
 	}
 
-	awsConf := aws.NewConfig()
-	if len(a.conf.Region) > 0 {
-		awsConf = awsConf.WithRegion(a.conf.Region)
-	}
-	if len(a.conf.Credentials.ID) > 0 {
-		awsConf = awsConf.WithCredentials(credentials.NewStaticCredentials(
-			a.conf.Credentials.ID,
-			a.conf.Credentials.Secret,
-			a.conf.Credentials.Token,
-		))
-	}
-
-	sess, err := session.NewSession(awsConf)
+	sess, err := a.conf.GetSession(func(c *aws.Config) {
+		c.S3ForcePathStyle = aws.Bool(a.conf.ForcePathStyleURLs)
+	})
 	if err != nil {
 		return err
 	}
 
-	if len(a.conf.Credentials.Role) > 0 {
-		sess.Config = sess.Config.WithCredentials(
-			stscreds.NewCredentials(sess, a.conf.Credentials.Role),
-		)
-	}
-
 	a.session = sess
 	a.uploader = s3manager.NewUploader(sess)
 
@@ -146,15 +127,10 @@ func (a *AmazonS3) Write(msg types.Message) error {
 	}
 
 	return msg.Iter(func(i int, p types.Part) error {
-		path := a.conf.Path
-		if a.interpolatePath {
-			path = string(text.ReplaceFunctionVariables(msg, a.pathBytes))
-		}
-
 		if _, err := a.uploader.Upload(&s3manager.UploadInput{
 			Body:   bytes.NewReader(p.Get()),
 			Bucket: aws.String(a.conf.Bucket),
-			Key:    aws.String(path),
+			Key:    aws.String(a.path.Get(message.Lock(msg, i))),
 		}); err != nil {
 			return err
 		}
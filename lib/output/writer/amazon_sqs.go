@@ -24,34 +24,41 @@ import (
 	"time"
 
 	"github.com/Jeffail/benthos/lib/log"
+	"github.com/Jeffail/benthos/lib/message"
+	"github.com/Jeffail/benthos/lib/message/metadata"
 	"github.com/Jeffail/benthos/lib/metrics"
 	"github.com/Jeffail/benthos/lib/types"
+	sess "github.com/Jeffail/benthos/lib/util/aws/session"
+	"github.com/Jeffail/benthos/lib/util/text"
 	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/credentials"
-	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/aws/aws-sdk-go/service/sqs/sqsiface"
 )
 
 //------------------------------------------------------------------------------
 
 // AmazonSQSConfig contains configuration fields for the output AmazonSQS type.
 type AmazonSQSConfig struct {
-	Region      string                     `json:"region" yaml:"region"`
-	URL         string                     `json:"url" yaml:"url"`
-	Credentials AmazonAWSCredentialsConfig `json:"credentials" yaml:"credentials"`
+	sessionConfig          `json:",inline" yaml:",inline"`
+	URL                    string                 `json:"url" yaml:"url"`
+	MessageGroupID         string                 `json:"message_group_id" yaml:"message_group_id"`
+	MessageDeduplicationID string                 `json:"message_deduplication_id" yaml:"message_deduplication_id"`
+	MaxInFlight            int                    `json:"max_in_flight" yaml:"max_in_flight"`
+	Metadata               metadata.MappingConfig `json:"metadata" yaml:"metadata"`
 }
 
 // NewAmazonSQSConfig creates a new Config with default values.
 func NewAmazonSQSConfig() AmazonSQSConfig {
 	return AmazonSQSConfig{
-		Region: "eu-west-1",
-		URL:    "",
-		Credentials: AmazonAWSCredentialsConfig{
-			ID:     "",
-			Secret: "",
-			Token:  "",
+		sessionConfig: sessionConfig{
+			Config: sess.NewConfig(),
 		},
+		URL:                    "",
+		MessageGroupID:         "",
+		MessageDeduplicationID: "",
+		MaxInFlight:            1,
+		Metadata:               metadata.NewMappingConfig(),
 	}
 }
 
@@ -60,10 +67,13 @@ func NewAmazonSQSConfig() AmazonSQSConfig {
 // AmazonSQS is a benthos writer.Type implementation that writes messages to an
 // Amazon SQS queue.
 type AmazonSQS struct {
-	conf AmazonSQSConfig
+	conf    AmazonSQSConfig
+	meta    *metadata.Mapping
+	groupID *text.InterpolatedString
+	dedupID *text.InterpolatedString
 
 	session *session.Session
-	sqs     *sqs.SQS
+	sqs     sqsiface.SQSAPI
 
 	log   log.Modular
 	stats metrics.Type
@@ -75,11 +85,19 @@ func NewAmazonSQS(
 	log log.Modular,
 	stats metrics.Type,
 ) *AmazonSQS {
-	return &AmazonSQS{
+	a := &AmazonSQS{
 		conf:  conf,
+		meta:  metadata.NewMapping(conf.Metadata),
 		log:   log.NewModule(".output.sqs"),
 		stats: stats,
 	}
+	if len(conf.MessageGroupID) > 0 {
+		a.groupID = text.NewInterpolatedString(conf.MessageGroupID)
+	}
+	if len(conf.MessageDeduplicationID) > 0 {
+		a.dedupID = text.NewInterpolatedString(conf.MessageDeduplicationID)
+	}
+	return a
 }
 
 // Connect attempts to establish a connection to the target SQS queue.
@@ -88,29 +106,11 @@ func (a *AmazonSQS) Connect() error {
 		return nil
 	}
 
-	awsConf := aws.NewConfig()
-	if len(a.conf.Region) > 0 {
-		awsConf = awsConf.WithRegion(a.conf.Region)
-	}
-	if len(a.conf.Credentials.ID) > 0 {
-		awsConf = awsConf.WithCredentials(credentials.NewStaticCredentials(
-			a.conf.Credentials.ID,
-			a.conf.Credentials.Secret,
-			a.conf.Credentials.Token,
-		))
-	}
-
-	sess, err := session.NewSession(awsConf)
+	sess, err := a.conf.GetSession()
 	if err != nil {
 		return err
 	}
 
-	if len(a.conf.Credentials.Role) > 0 {
-		sess.Config = sess.Config.WithCredentials(
-			stscreds.NewCredentials(sess, a.conf.Credentials.Role),
-		)
-	}
-
 	a.session = sess
 	a.sqs = sqs.New(sess)
 
@@ -145,10 +145,29 @@ func (a *AmazonSQS) Write(msg types.Message) error {
 	*/
 
 	return msg.Iter(func(i int, p types.Part) error {
-		if _, err := a.sqs.SendMessage(&sqs.SendMessageInput{
-			QueueUrl:    aws.String(a.conf.URL),
-			MessageBody: aws.String(string(p.Get())),
-		}); err != nil {
+		var attributes map[string]*sqs.MessageAttributeValue
+		a.meta.Iter(p.Metadata(), func(k, v string) error {
+			if attributes == nil {
+				attributes = map[string]*sqs.MessageAttributeValue{}
+			}
+			attributes[k] = &sqs.MessageAttributeValue{
+				DataType:    aws.String("String"),
+				StringValue: aws.String(v),
+			}
+			return nil
+		})
+		input := &sqs.SendMessageInput{
+			QueueUrl:          aws.String(a.conf.URL),
+			MessageBody:       aws.String(string(p.Get())),
+			MessageAttributes: attributes,
+		}
+		if a.groupID != nil {
+			input.MessageGroupId = aws.String(a.groupID.Get(message.Lock(msg, i)))
+		}
+		if a.dedupID != nil {
+			input.MessageDeduplicationId = aws.String(a.dedupID.Get(message.Lock(msg, i)))
+		}
+		if _, err := a.sqs.SendMessage(input); err != nil {
 			return err
 		}
 		return nil
@@ -0,0 +1,110 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package writer
+
+import (
+	"testing"
+
+	"github.com/Jeffail/benthos/lib/log"
+	"github.com/Jeffail/benthos/lib/message"
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/aws/aws-sdk-go/service/sqs/sqsiface"
+)
+
+type mockSQS struct {
+	sqsiface.SQSAPI
+	inputs []*sqs.SendMessageInput
+}
+
+func (m *mockSQS) SendMessage(input *sqs.SendMessageInput) (*sqs.SendMessageOutput, error) {
+	m.inputs = append(m.inputs, input)
+	return &sqs.SendMessageOutput{}, nil
+}
+
+func TestAmazonSQSWriteFIFO(t *testing.T) {
+	conf := NewAmazonSQSConfig()
+	conf.URL = "http://foo/bar.fifo"
+	conf.MessageGroupID = "${!metadata:group}"
+	conf.MessageDeduplicationID = "${!json_field:id}"
+	conf.Metadata.IncludePrefixes = []string{"group"}
+
+	mock := &mockSQS{}
+	a := NewAmazonSQS(conf, log.Noop(), metrics.Noop())
+	a.session = session.Must(session.NewSession(&aws.Config{}))
+	a.sqs = mock
+
+	msg := message.New([][]byte{
+		[]byte(`{"id":"foo"}`),
+		[]byte(`{"id":"bar"}`),
+	})
+	msg.Get(0).Metadata().Set("group", "a")
+	msg.Get(1).Metadata().Set("group", "b")
+
+	if err := a.Write(msg); err != nil {
+		t.Fatal(err)
+	}
+	if exp, act := 2, len(mock.inputs); exp != act {
+		t.Fatalf("Wrong count of sent messages: %v != %v", act, exp)
+	}
+	for i, exp := range []struct {
+		body, group, dedup string
+	}{
+		{`{"id":"foo"}`, "a", "foo"},
+		{`{"id":"bar"}`, "b", "bar"},
+	} {
+		input := mock.inputs[i]
+		if act := *input.MessageBody; exp.body != act {
+			t.Errorf("Wrong body %v: %v != %v", i, act, exp.body)
+		}
+		if act := *input.MessageGroupId; exp.group != act {
+			t.Errorf("Wrong group ID %v: %v != %v", i, act, exp.group)
+		}
+		if act := *input.MessageDeduplicationId; exp.dedup != act {
+			t.Errorf("Wrong deduplication ID %v: %v != %v", i, act, exp.dedup)
+		}
+		if act := *input.MessageAttributes["group"].StringValue; exp.group != act {
+			t.Errorf("Wrong group attribute %v: %v != %v", i, act, exp.group)
+		}
+	}
+}
+
+func TestAmazonSQSWriteStandard(t *testing.T) {
+	mock := &mockSQS{}
+	a := NewAmazonSQS(NewAmazonSQSConfig(), log.Noop(), metrics.Noop())
+	a.session = session.Must(session.NewSession(&aws.Config{}))
+	a.sqs = mock
+
+	if err := a.Write(message.New([][]byte{[]byte("foo")})); err != nil {
+		t.Fatal(err)
+	}
+	if exp, act := 1, len(mock.inputs); exp != act {
+		t.Fatalf("Wrong count of sent messages: %v != %v", act, exp)
+	}
+	if mock.inputs[0].MessageGroupId != nil {
+		t.Error("Unexpected group ID")
+	}
+	if mock.inputs[0].MessageDeduplicationId != nil {
+		t.Error("Unexpected deduplication ID")
+	}
+}
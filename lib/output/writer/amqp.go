@@ -23,12 +23,16 @@ package writer
 import (
 	"crypto/tls"
 	"fmt"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/Jeffail/benthos/lib/log"
+	"github.com/Jeffail/benthos/lib/message"
+	"github.com/Jeffail/benthos/lib/message/metadata"
 	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/tracing"
 	"github.com/Jeffail/benthos/lib/types"
 	"github.com/Jeffail/benthos/lib/util/text"
 	btls "github.com/Jeffail/benthos/lib/util/tls"
@@ -55,7 +59,11 @@ type AMQPConfig struct {
 	Persistent      bool                      `json:"persistent" yaml:"persistent"`
 	Mandatory       bool                      `json:"mandatory" yaml:"mandatory"`
 	Immediate       bool                      `json:"immediate" yaml:"immediate"`
+	ConfirmTimeout  string                    `json:"confirm_timeout" yaml:"confirm_timeout"`
+	Priority        string                    `json:"priority" yaml:"priority"`
+	Expiration      string                    `json:"expiration" yaml:"expiration"`
 	TLS             btls.Config               `json:"tls" yaml:"tls"`
+	Metadata        metadata.MappingConfig    `json:"metadata" yaml:"metadata"`
 }
 
 // NewAMQPConfig creates a new AMQPConfig with default values.
@@ -68,11 +76,15 @@ func NewAMQPConfig() AMQPConfig {
 			Type:    "direct",
 			Durable: true,
 		},
-		BindingKey: "benthos-key",
-		Persistent: false,
-		Mandatory:  false,
-		Immediate:  false,
-		TLS:        btls.NewConfig(),
+		BindingKey:     "benthos-key",
+		Persistent:     false,
+		Mandatory:      false,
+		Immediate:      false,
+		ConfirmTimeout: "",
+		Priority:       "",
+		Expiration:     "",
+		TLS:            btls.NewConfig(),
+		Metadata:       metadata.NewMappingConfigAll(),
 	}
 }
 
@@ -80,7 +92,12 @@ func NewAMQPConfig() AMQPConfig {
 
 // AMQP is an output type that serves AMQP messages.
 type AMQP struct {
-	key *text.InterpolatedString
+	key        *text.InterpolatedString
+	priority   *text.InterpolatedString
+	expiration *text.InterpolatedString
+	meta       *metadata.Mapping
+
+	confirmTimeout time.Duration
 
 	log   log.Modular
 	stats metrics.Type
@@ -102,6 +119,9 @@ type AMQP struct {
 func NewAMQP(conf AMQPConfig, log log.Modular, stats metrics.Type) (*AMQP, error) {
 	a := AMQP{
 		key:          text.NewInterpolatedString(conf.BindingKey),
+		priority:     text.NewInterpolatedString(conf.Priority),
+		expiration:   text.NewInterpolatedString(conf.Expiration),
+		meta:         metadata.NewMapping(conf.Metadata),
 		log:          log.NewModule(".output.amqp"),
 		stats:        stats,
 		conf:         conf,
@@ -110,8 +130,13 @@ func NewAMQP(conf AMQPConfig, log log.Modular, stats metrics.Type) (*AMQP, error
 	if conf.Persistent {
 		a.deliveryMode = amqp.Persistent
 	}
+	var err error
+	if len(conf.ConfirmTimeout) > 0 {
+		if a.confirmTimeout, err = time.ParseDuration(conf.ConfirmTimeout); err != nil {
+			return nil, fmt.Errorf("failed to parse confirm timeout duration string: %v", err)
+		}
+	}
 	if conf.TLS.Enabled {
-		var err error
 		if a.tlsConf, err = conf.TLS.Get(); err != nil {
 			return nil, err
 		}
@@ -211,14 +236,42 @@ func (a *AMQP) Write(msg types.Message) error {
 		return types.ErrNotConnected
 	}
 
-	bindingKey := strings.Replace(a.key.Get(msg), "/", ".", -1)
-
 	return msg.Iter(func(i int, p types.Part) error {
+		bindingKey := strings.Replace(a.key.Get(message.Lock(msg, i)), "/", ".", -1)
+
 		headers := amqp.Table{}
 		p.Metadata().Iter(func(k, v string) error {
-			headers[strings.Replace(k, "_", "-", -1)] = v
+			// The span context is added below using the configured formats.
+			if k == tracing.MetadataKey {
+				return nil
+			}
+			hk, ok := a.meta.Key(k)
+			if !ok {
+				return nil
+			}
+			if !a.meta.Renamed(k) {
+				hk = strings.Replace(hk, "_", "-", -1)
+			}
+			headers[hk] = v
 			return nil
 		})
+		for k, v := range tracing.InjectHeaders("amqp", p.Metadata()) {
+			headers[k] = v
+		}
+
+		var priority uint8
+		if len(a.conf.Priority) > 0 {
+			pStr := a.priority.Get(message.Lock(msg, i))
+			pInt, err := strconv.ParseUint(pStr, 10, 8)
+			if err != nil {
+				return fmt.Errorf("failed to parse priority '%v': %v", pStr, err)
+			}
+			priority = uint8(pInt)
+		}
+		var expiration string
+		if len(a.conf.Expiration) > 0 {
+			expiration = a.expiration.Get(message.Lock(msg, i))
+		}
 		err := amqpChan.Publish(
 			a.conf.Exchange,  // publish to an exchange
 			bindingKey,       // routing to 0 or more queues
@@ -230,7 +283,8 @@ func (a *AMQP) Write(msg types.Message) error {
 				ContentEncoding: "",
 				Body:            p.Get(),
 				DeliveryMode:    a.deliveryMode, // 1=non-persistent, 2=persistent
-				Priority:        0,              // 0-9
+				Priority:        priority,       // 0-9
+				Expiration:      expiration,     // milliseconds
 				// a bunch of application/implementation-specific fields
 			},
 		)
@@ -239,7 +293,17 @@ func (a *AMQP) Write(msg types.Message) error {
 			a.log.Errorf("Failed to send message: %v\n", err)
 			return types.ErrNotConnected
 		}
+		var timeoutChan <-chan time.Time
+		if a.confirmTimeout > 0 {
+			timer := time.NewTimer(a.confirmTimeout)
+			defer timer.Stop()
+			timeoutChan = timer.C
+		}
 		select {
+		case <-timeoutChan:
+			a.log.Errorln("Timed out waiting for message acknowledgement.")
+			a.disconnect()
+			return types.ErrTimeout
 		case confirm, open := <-confirmChan:
 			if !open {
 				a.log.Errorln("Failed to send message, ensure your target exchange exists.")
@@ -0,0 +1,207 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package writer
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/Jeffail/benthos/lib/message"
+	"github.com/Jeffail/benthos/lib/types"
+)
+
+//------------------------------------------------------------------------------
+
+// Encoder writes messages to a stream in the format of a codec.
+type Encoder interface {
+	// Encode writes a message to the stream.
+	Encode(msg types.Message) error
+
+	// Close writes anything required in order to terminate the stream, the
+	// underlying writer is not closed.
+	Close() error
+}
+
+// NewEncoder creates an Encoder that writes messages to w with a codec, which
+// can be one of lines, json_array or length_prefixed. The delimiter is only
+// used by the lines codec, and defaults to a newline when empty.
+func NewEncoder(codec string, delim []byte, w io.Writer) (Encoder, error) {
+	switch codec {
+	case "", "lines":
+		if len(delim) == 0 {
+			delim = []byte("\n")
+		}
+		return &linesEncoder{w: w, delim: delim}, nil
+	case "json_array":
+		return &jsonArrayEncoder{w: w}, nil
+	case "length_prefixed":
+		return &lengthPrefixedEncoder{w: w}, nil
+	}
+	return nil, fmt.Errorf("codec not recognised: %v", codec)
+}
+
+// NewFileEncoder creates an Encoder that appends messages to a file, which may
+// already contain data previously written with the same codec. When the codec
+// is json_array the closing bracket of an existing array is removed so that
+// new elements are added to it.
+func NewFileEncoder(codec string, delim []byte, f *os.File) (Encoder, error) {
+	if codec != "json_array" {
+		return NewEncoder(codec, delim, f)
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	size := info.Size()
+	if size == 0 {
+		return &jsonArrayEncoder{w: f}, nil
+	}
+
+	tailLen := int64(4096)
+	if size < tailLen {
+		tailLen = size
+	}
+	tail := make([]byte, tailLen)
+	if _, err = f.ReadAt(tail, size-tailLen); err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	const whitespace = " \t\r\n"
+	trimmed := bytes.TrimRight(tail, whitespace)
+	if len(trimmed) == 0 && tailLen == size {
+		return &jsonArrayEncoder{w: f}, nil
+	}
+	if len(trimmed) == 0 || trimmed[len(trimmed)-1] != ']' {
+		return nil, errors.New("existing file does not end with a JSON array")
+	}
+
+	prev := bytes.TrimRight(trimmed[:len(trimmed)-1], whitespace)
+	empty := len(prev) > 0 && prev[len(prev)-1] == '['
+
+	if err = f.Truncate(size - tailLen + int64(len(prev))); err != nil {
+		return nil, err
+	}
+	return &jsonArrayEncoder{w: f, opened: true, elements: !empty}, nil
+}
+
+//------------------------------------------------------------------------------
+
+// linesEncoder writes each message part followed by a delimiter, with an
+// additional delimiter written after the final part of multiple part messages.
+type linesEncoder struct {
+	w     io.Writer
+	delim []byte
+}
+
+func (l *linesEncoder) Encode(msg types.Message) error {
+	var err error
+	if msg.Len() == 1 {
+		_, err = fmt.Fprintf(l.w, "%s%s", msg.Get(0).Get(), l.delim)
+	} else {
+		_, err = fmt.Fprintf(l.w, "%s%s%s", bytes.Join(message.GetAllBytes(msg), l.delim), l.delim, l.delim)
+	}
+	return err
+}
+
+func (l *linesEncoder) Close() error {
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+// jsonArrayEncoder writes each message part as an element of a JSON array,
+// which is terminated when the encoder is closed.
+type jsonArrayEncoder struct {
+	w        io.Writer
+	opened   bool
+	elements bool
+}
+
+func (j *jsonArrayEncoder) Encode(msg types.Message) error {
+	if err := msg.Iter(func(i int, p types.Part) error {
+		if !json.Valid(p.Get()) {
+			return fmt.Errorf("message part %v is not valid JSON", i)
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	buf := bytes.Buffer{}
+	msg.Iter(func(i int, p types.Part) error {
+		if !j.opened {
+			buf.WriteByte('[')
+			j.opened = true
+		} else if j.elements {
+			buf.WriteByte(',')
+		}
+		buf.WriteByte('\n')
+		buf.Write(p.Get())
+		j.elements = true
+		return nil
+	})
+	_, err := j.w.Write(buf.Bytes())
+	return err
+}
+
+func (j *jsonArrayEncoder) Close() error {
+	var err error
+	if !j.opened {
+		_, err = j.w.Write([]byte("[]\n"))
+	} else {
+		_, err = j.w.Write([]byte("\n]\n"))
+	}
+	j.opened, j.elements = false, false
+	return err
+}
+
+//------------------------------------------------------------------------------
+
+// lengthPrefixedEncoder writes each message part prefixed with its length as a
+// 32 bit big endian unsigned integer.
+type lengthPrefixedEncoder struct {
+	w io.Writer
+}
+
+func (l *lengthPrefixedEncoder) Encode(msg types.Message) error {
+	buf := bytes.Buffer{}
+	msg.Iter(func(i int, p types.Part) error {
+		var lenBytes [4]byte
+		binary.BigEndian.PutUint32(lenBytes[:], uint32(len(p.Get())))
+		buf.Write(lenBytes[:])
+		buf.Write(p.Get())
+		return nil
+	})
+	_, err := l.w.Write(buf.Bytes())
+	return err
+}
+
+func (l *lengthPrefixedEncoder) Close() error {
+	return nil
+}
+
+//------------------------------------------------------------------------------
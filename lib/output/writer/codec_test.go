@@ -0,0 +1,171 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package writer
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Jeffail/benthos/lib/message"
+)
+
+func TestEncoderCodecs(t *testing.T) {
+	tests := []struct {
+		codec    string
+		delim    string
+		input    [][][]byte
+		expected string
+	}{
+		{
+			codec: "lines",
+			input: [][][]byte{
+				{[]byte("foo")},
+				{[]byte("bar"), []byte("baz")},
+			},
+			expected: "foo\nbar\nbaz\n\n",
+		},
+		{
+			codec: "lines",
+			delim: "|",
+			input: [][][]byte{
+				{[]byte("foo")},
+				{[]byte("bar")},
+			},
+			expected: "foo|bar|",
+		},
+		{
+			codec: "json_array",
+			input: [][][]byte{
+				{[]byte(`{"a":1}`)},
+				{[]byte(`{"b":2}`), []byte(`3`)},
+			},
+			expected: "[\n{\"a\":1},\n{\"b\":2},\n3\n]\n",
+		},
+		{
+			codec:    "json_array",
+			expected: "[]\n",
+		},
+		{
+			codec: "length_prefixed",
+			input: [][][]byte{
+				{[]byte("foo")},
+				{[]byte("ba")},
+			},
+			expected: "\x00\x00\x00\x03foo\x00\x00\x00\x02ba",
+		},
+	}
+
+	for _, test := range tests {
+		buf := bytes.Buffer{}
+		enc, err := NewEncoder(test.codec, []byte(test.delim), &buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, parts := range test.input {
+			if err = enc.Encode(message.New(parts)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err = enc.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if exp, act := test.expected, buf.String(); exp != act {
+			t.Errorf("Wrong result for codec %v: %q != %q", test.codec, act, exp)
+		}
+	}
+}
+
+func TestEncoderBadCodec(t *testing.T) {
+	if _, err := NewEncoder("not_a_codec", nil, ioutil.Discard); err == nil {
+		t.Error("Expected error from bad codec")
+	}
+}
+
+func TestEncoderJSONArrayInvalid(t *testing.T) {
+	buf := bytes.Buffer{}
+	enc, err := NewEncoder("json_array", nil, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = enc.Encode(message.New([][]byte{[]byte("not json")})); err == nil {
+		t.Error("Expected error from invalid JSON")
+	}
+	if buf.Len() > 0 {
+		t.Errorf("Unexpected data written: %q", buf.String())
+	}
+}
+
+func TestFileEncoderJSONArrayResume(t *testing.T) {
+	dir, err := ioutil.TempDir("", "benthos_codec_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "out.json")
+	writeAll := func(docs ...string) {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0666)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+
+		enc, err := NewFileEncoder("json_array", nil, f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, d := range docs {
+			if err = enc.Encode(message.New([][]byte{[]byte(d)})); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err = enc.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	writeAll()
+	writeAll(`{"a":1}`)
+	writeAll(`{"b":2}`, `{"c":3}`)
+
+	act, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp := "[\n{\"a\":1},\n{\"b\":2},\n{\"c\":3}\n]\n"; exp != string(act) {
+		t.Errorf("Wrong result: %q != %q", act, exp)
+	}
+
+	if err = ioutil.WriteFile(path, []byte("not an array"), 0666); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_APPEND, 0666)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err = NewFileEncoder("json_array", nil, f); err == nil {
+		t.Error("Expected error from file without JSON array")
+	}
+}
@@ -22,16 +22,19 @@ package writer
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"net/http"
 	"strings"
 	"time"
 
 	"github.com/Jeffail/benthos/lib/log"
+	"github.com/Jeffail/benthos/lib/message"
 	"github.com/Jeffail/benthos/lib/metrics"
 	"github.com/Jeffail/benthos/lib/types"
 	"github.com/Jeffail/benthos/lib/util/http/auth"
 	"github.com/Jeffail/benthos/lib/util/text"
+	btls "github.com/Jeffail/benthos/lib/util/tls"
 	"github.com/olivere/elastic"
 )
 
@@ -40,23 +43,27 @@ import (
 // ElasticsearchConfig contains configuration fields for the Elasticsearch
 // output type.
 type ElasticsearchConfig struct {
-	URLs      []string             `json:"urls" yaml:"urls"`
-	ID        string               `json:"id" yaml:"id"`
-	Index     string               `json:"index" yaml:"index"`
-	Type      string               `json:"type" yaml:"type"`
-	TimeoutMS int                  `json:"timeout_ms" yaml:"timeout_ms"`
-	Auth      auth.BasicAuthConfig `json:"basic_auth" yaml:"basic_auth"`
+	URLs        []string             `json:"urls" yaml:"urls"`
+	ID          string               `json:"id" yaml:"id"`
+	Index       string               `json:"index" yaml:"index"`
+	Type        string               `json:"type" yaml:"type"`
+	TimeoutMS   int                  `json:"timeout_ms" yaml:"timeout_ms"`
+	Auth        auth.BasicAuthConfig `json:"basic_auth" yaml:"basic_auth"`
+	MaxInFlight int                  `json:"max_in_flight" yaml:"max_in_flight"`
+	TLS         btls.Config          `json:"tls" yaml:"tls"`
 }
 
 // NewElasticsearchConfig creates a new ElasticsearchConfig with default values.
 func NewElasticsearchConfig() ElasticsearchConfig {
 	return ElasticsearchConfig{
-		URLs:      []string{"http://localhost:9200"},
-		ID:        "${!count:elastic_ids}-${!timestamp_unix}",
-		Index:     "benthos_index",
-		Type:      "doc",
-		TimeoutMS: 5000,
-		Auth:      auth.NewBasicAuthConfig(),
+		URLs:        []string{"http://localhost:9200"},
+		ID:          "${!count:elastic_ids}-${!timestamp_unix}",
+		Index:       "benthos_index",
+		Type:        "doc",
+		TimeoutMS:   5000,
+		Auth:        auth.NewBasicAuthConfig(),
+		MaxInFlight: 1,
+		TLS:         btls.NewConfig(),
 	}
 }
 
@@ -67,8 +74,9 @@ type Elasticsearch struct {
 	log   log.Modular
 	stats metrics.Type
 
-	urls []string
-	conf ElasticsearchConfig
+	urls    []string
+	conf    ElasticsearchConfig
+	tlsConf *tls.Config
 
 	idStr             *text.InterpolatedString
 	indexStr          *text.InterpolatedString
@@ -96,6 +104,13 @@ func NewElasticsearch(conf ElasticsearchConfig, log log.Modular, stats metrics.T
 		}
 	}
 
+	if conf.TLS.Enabled {
+		var err error
+		if e.tlsConf, err = conf.TLS.Get(); err != nil {
+			return nil, err
+		}
+	}
+
 	return &e, nil
 }
 
@@ -107,11 +122,18 @@ func (e *Elasticsearch) Connect() error {
 		return nil
 	}
 
+	httpClient := &http.Client{
+		Timeout: time.Duration(e.conf.TimeoutMS) * time.Millisecond,
+	}
+	if e.tlsConf != nil {
+		httpClient.Transport = &http.Transport{
+			TLSClientConfig: e.tlsConf,
+		}
+	}
+
 	opts := []elastic.ClientOptionFunc{
 		elastic.SetURL(e.urls...),
-		elastic.SetHttpClient(&http.Client{
-			Timeout: time.Duration(e.conf.TimeoutMS) * time.Millisecond,
-		}),
+		elastic.SetHttpClient(httpClient),
 	}
 
 	if e.conf.Auth.Enabled {
@@ -147,10 +169,11 @@ func (e *Elasticsearch) Write(msg types.Message) error {
 	}
 
 	return msg.Iter(func(i int, part types.Part) error {
+		lMsg := message.Lock(msg, i)
 		_, err := e.client.Index().
-			Index(e.indexStr.Get(msg)).
+			Index(e.indexStr.Get(lMsg)).
 			Type(e.conf.Type).
-			Id(e.idStr.Get(msg)).
+			Id(e.idStr.Get(lMsg)).
 			BodyString(string(part.Get())).
 			Do(context.Background())
 
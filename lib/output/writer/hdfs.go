@@ -28,18 +28,20 @@ import (
 	"github.com/Jeffail/benthos/lib/message"
 	"github.com/Jeffail/benthos/lib/metrics"
 	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/kerberos"
 	"github.com/Jeffail/benthos/lib/util/text"
-	"github.com/colinmarc/hdfs"
+	"github.com/colinmarc/hdfs/v2"
 )
 
 //------------------------------------------------------------------------------
 
 // HDFSConfig contains configuration fields for the HDFS output type.
 type HDFSConfig struct {
-	Hosts     []string `json:"hosts" yaml:"hosts"`
-	User      string   `json:"user" yaml:"user"`
-	Directory string   `json:"directory" yaml:"directory"`
-	Path      string   `json:"path" yaml:"path"`
+	Hosts     []string        `json:"hosts" yaml:"hosts"`
+	User      string          `json:"user" yaml:"user"`
+	Directory string          `json:"directory" yaml:"directory"`
+	Path      string          `json:"path" yaml:"path"`
+	Kerberos  kerberos.Config `json:"kerberos" yaml:"kerberos"`
 }
 
 // NewHDFSConfig creates a new Config with default values.
@@ -49,9 +51,18 @@ func NewHDFSConfig() HDFSConfig {
 		User:      "benthos_hdfs",
 		Directory: "",
 		Path:      "${!count:files}-${!timestamp_unix_nano}.txt",
+		Kerberos:  newHDFSKerberosConfig(),
 	}
 }
 
+// newHDFSKerberosConfig returns Kerberos defaults for HDFS, where the service
+// principal of the namenode is resolved from its host name.
+func newHDFSKerberosConfig() kerberos.Config {
+	conf := kerberos.NewConfig()
+	conf.ServiceName = "nn/_HOST"
+	return conf
+}
+
 //------------------------------------------------------------------------------
 
 // HDFS is a benthos writer.Type implementation that writes messages to a
@@ -91,10 +102,20 @@ func (h *HDFS) Connect() error {
 		return nil
 	}
 
-	client, err := hdfs.NewClient(hdfs.ClientOptions{
+	opts := hdfs.ClientOptions{
 		Addresses: h.conf.Hosts,
 		User:      h.conf.User,
-	})
+	}
+	if len(h.conf.Kerberos.Principal) > 0 {
+		krbClient, err := h.conf.Kerberos.Client()
+		if err != nil {
+			return err
+		}
+		opts.KerberosClient = krbClient
+		opts.KerberosServicePrincipleName = h.conf.Kerberos.ServiceName
+	}
+
+	client, err := hdfs.NewClient(opts)
 	if err != nil {
 		return err
 	}
@@ -24,6 +24,7 @@ import (
 	"time"
 
 	"github.com/Jeffail/benthos/lib/log"
+	"github.com/Jeffail/benthos/lib/message"
 	"github.com/Jeffail/benthos/lib/metrics"
 	"github.com/Jeffail/benthos/lib/types"
 	"github.com/Jeffail/benthos/lib/util/http/client"
@@ -35,12 +36,18 @@ import (
 // type.
 type HTTPClientConfig struct {
 	client.Config `json:",inline" yaml:",inline"`
+	MaxInFlight   int  `json:"max_in_flight" yaml:"max_in_flight"`
+	Parallel      bool `json:"parallel" yaml:"parallel"`
+	MaxParallel   int  `json:"max_parallel" yaml:"max_parallel"`
 }
 
 // NewHTTPClientConfig creates a new HTTPClientConfig with default values.
 func NewHTTPClientConfig() HTTPClientConfig {
 	return HTTPClientConfig{
-		Config: client.NewConfig(),
+		Config:      client.NewConfig(),
+		MaxInFlight: 1,
+		Parallel:    false,
+		MaxParallel: 0,
 	}
 }
 
@@ -95,7 +102,39 @@ func (h *HTTPClient) Connect() error {
 // Write attempts to send a message to an HTTP server, this attempt may include
 // retries, and if all retries fail an error is returned.
 func (h *HTTPClient) Write(msg types.Message) error {
-	_, err := h.client.Send(msg)
+	if !h.conf.Parallel || msg.Len() == 1 {
+		_, err := h.client.Send(msg)
+		return err
+	}
+
+	max := h.conf.MaxParallel
+	if max <= 0 || msg.Len() < max {
+		max = msg.Len()
+	}
+
+	reqChan, resChan := make(chan int), make(chan error)
+	for i := 0; i < max; i++ {
+		go func() {
+			for index := range reqChan {
+				_, err := h.client.Send(message.Lock(msg, index))
+				resChan <- err
+			}
+		}()
+	}
+	go func() {
+		for i := 0; i < msg.Len(); i++ {
+			reqChan <- i
+		}
+		close(reqChan)
+	}()
+
+	var err error
+	for i := 0; i < msg.Len(); i++ {
+		if rErr := <-resChan; rErr != nil {
+			h.log.Errorf("HTTP parallel request to '%v' failed: %v\n", h.conf.URL, rErr)
+			err = rErr
+		}
+	}
 	return err
 }
 
@@ -29,6 +29,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -221,4 +222,97 @@ func TestHTTPClientMultipart(t *testing.T) {
 	}
 }
 
+func TestHTTPClientParallel(t *testing.T) {
+	var reqs, maxReqs int64
+	var mut sync.Mutex
+	received := map[string]struct{}{}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req := atomic.AddInt64(&reqs, 1)
+		defer atomic.AddInt64(&reqs, -1)
+
+		b, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			t.Error(err)
+		}
+		mut.Lock()
+		received[string(b)] = struct{}{}
+		if req > maxReqs {
+			maxReqs = req
+		}
+		mut.Unlock()
+
+		<-time.After(time.Millisecond * 10)
+	}))
+	defer ts.Close()
+
+	conf := NewHTTPClientConfig()
+	conf.URL = ts.URL + "/testpost"
+	conf.Parallel = true
+	conf.MaxParallel = 3
+
+	h, err := NewHTTPClient(conf, types.NoopMgr(), log.Noop(), metrics.Noop())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parts := [][]byte{}
+	for i := 0; i < 10; i++ {
+		parts = append(parts, []byte(fmt.Sprintf("part%v", i)))
+	}
+	if err = h.Write(message.New(parts)); err != nil {
+		t.Fatal(err)
+	}
+
+	mut.Lock()
+	if exp, act := len(parts), len(received); exp != act {
+		t.Errorf("Wrong count of requests: %v != %v", act, exp)
+	}
+	for _, p := range parts {
+		if _, exists := received[string(p)]; !exists {
+			t.Errorf("Part not received: %s", p)
+		}
+	}
+	if maxReqs > 3 {
+		t.Errorf("Beyond parallelism cap: %v", maxReqs)
+	}
+	mut.Unlock()
+
+	h.CloseAsync()
+	if err = h.WaitForClose(time.Second); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestHTTPClientParallelError(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		if string(b) == "bar" {
+			http.Error(w, "test error", http.StatusForbidden)
+		}
+	}))
+	defer ts.Close()
+
+	conf := NewHTTPClientConfig()
+	conf.URL = ts.URL + "/testpost"
+	conf.Parallel = true
+	conf.NumRetries = 0
+
+	h, err := NewHTTPClient(conf, types.NoopMgr(), log.Noop(), metrics.Noop())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = h.Write(message.New([][]byte{
+		[]byte("foo"), []byte("bar"), []byte("baz"),
+	})); err == nil {
+		t.Error("Expected error from failed part")
+	}
+
+	h.CloseAsync()
+	if err = h.WaitForClose(time.Second); err != nil {
+		t.Error(err)
+	}
+}
+
 //------------------------------------------------------------------------------
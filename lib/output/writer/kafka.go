@@ -22,15 +22,20 @@ package writer
 
 import (
 	"crypto/tls"
+	"errors"
 	"fmt"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/Jeffail/benthos/lib/log"
 	"github.com/Jeffail/benthos/lib/message"
+	"github.com/Jeffail/benthos/lib/message/metadata"
 	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/tracing"
 	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/kafka/sasl"
 	"github.com/Jeffail/benthos/lib/util/text"
 	btls "github.com/Jeffail/benthos/lib/util/tls"
 	"github.com/Shopify/sarama"
@@ -40,17 +45,25 @@ import (
 
 // KafkaConfig contains configuration fields for the Kafka output type.
 type KafkaConfig struct {
-	Addresses            []string    `json:"addresses" yaml:"addresses"`
-	ClientID             string      `json:"client_id" yaml:"client_id"`
-	Key                  string      `json:"key" yaml:"key"`
-	RoundRobinPartitions bool        `json:"round_robin_partitions" yaml:"round_robin_partitions"`
-	Topic                string      `json:"topic" yaml:"topic"`
-	Compression          string      `json:"compression" yaml:"compression"`
-	MaxMsgBytes          int         `json:"max_msg_bytes" yaml:"max_msg_bytes"`
-	TimeoutMS            int         `json:"timeout_ms" yaml:"timeout_ms"`
-	AckReplicas          bool        `json:"ack_replicas" yaml:"ack_replicas"`
-	TargetVersion        string      `json:"target_version" yaml:"target_version"`
-	TLS                  btls.Config `json:"tls" yaml:"tls"`
+	Addresses            []string               `json:"addresses" yaml:"addresses"`
+	ClientID             string                 `json:"client_id" yaml:"client_id"`
+	Key                  string                 `json:"key" yaml:"key"`
+	RoundRobinPartitions bool                   `json:"round_robin_partitions" yaml:"round_robin_partitions"`
+	Partitioner          string                 `json:"partitioner" yaml:"partitioner"`
+	Partition            string                 `json:"partition" yaml:"partition"`
+	Topic                string                 `json:"topic" yaml:"topic"`
+	Timestamp            string                 `json:"timestamp" yaml:"timestamp"`
+	Compression          string                 `json:"compression" yaml:"compression"`
+	MaxMsgBytes          int                    `json:"max_msg_bytes" yaml:"max_msg_bytes"`
+	LingerMS             int                    `json:"linger_ms" yaml:"linger_ms"`
+	BatchBytes           int                    `json:"batch_bytes" yaml:"batch_bytes"`
+	TimeoutMS            int                    `json:"timeout_ms" yaml:"timeout_ms"`
+	AckReplicas          bool                   `json:"ack_replicas" yaml:"ack_replicas"`
+	IdempotentWrite      bool                   `json:"idempotent_write" yaml:"idempotent_write"`
+	TargetVersion        string                 `json:"target_version" yaml:"target_version"`
+	TLS                  btls.Config            `json:"tls" yaml:"tls"`
+	SASL                 sasl.Config            `json:"sasl" yaml:"sasl"`
+	Metadata             metadata.MappingConfig `json:"metadata" yaml:"metadata"`
 }
 
 // NewKafkaConfig creates a new KafkaConfig with default values.
@@ -60,13 +73,21 @@ func NewKafkaConfig() KafkaConfig {
 		ClientID:             "benthos_kafka_output",
 		Key:                  "",
 		RoundRobinPartitions: false,
+		Partitioner:          "fnv1a_hash",
+		Partition:            "",
 		Topic:                "benthos_stream",
+		Timestamp:            "",
 		Compression:          "none",
 		MaxMsgBytes:          1000000,
+		LingerMS:             0,
+		BatchBytes:           0,
 		TimeoutMS:            5000,
 		AckReplicas:          false,
+		IdempotentWrite:      false,
 		TargetVersion:        sarama.V1_0_0_0.String(),
 		TLS:                  btls.NewConfig(),
+		SASL:                 sasl.NewConfig(),
+		Metadata:             metadata.NewMappingConfig(),
 	}
 }
 
@@ -83,13 +104,20 @@ type Kafka struct {
 	version   sarama.KafkaVersion
 	conf      KafkaConfig
 
-	mDroppedMaxBytes metrics.StatCounter
+	mDroppedMaxBytes  metrics.StatCounter
+	mDroppedPartition metrics.StatCounter
 
-	key   *text.InterpolatedBytes
-	topic *text.InterpolatedString
+	key       *text.InterpolatedBytes
+	topic     *text.InterpolatedString
+	partition *text.InterpolatedString
+	timestamp *text.InterpolatedString
+	meta      *metadata.Mapping
 
 	producer    sarama.SyncProducer
 	compression sarama.CompressionCodec
+	partitioner sarama.PartitionerConstructor
+
+	manualPartition bool
 
 	connMut sync.RWMutex
 }
@@ -101,15 +129,36 @@ func NewKafka(conf KafkaConfig, log log.Modular, stats metrics.Type) (*Kafka, er
 		return nil, err
 	}
 
+	partitionerName := conf.Partitioner
+	if conf.RoundRobinPartitions {
+		partitionerName = "round_robin"
+	}
+	partitioner, err := strToPartitioner(partitionerName)
+	if err != nil {
+		return nil, err
+	}
+	if partitionerName == "manual" && len(conf.Partition) == 0 {
+		return nil, errors.New("partition field required for manual partitioner")
+	}
+
 	k := Kafka{
-		log:              log.NewModule(".output.kafka"),
-		stats:            stats,
-		mDroppedMaxBytes: stats.GetCounter("output.kafka.send.dropped.max_msg_bytes"),
+		log:               log.NewModule(".output.kafka"),
+		stats:             stats,
+		mDroppedMaxBytes:  stats.GetCounter("output.kafka.send.dropped.max_msg_bytes"),
+		mDroppedPartition: stats.GetCounter("output.kafka.send.dropped.invalid_partition"),
 
 		conf:        conf,
 		key:         text.NewInterpolatedBytes([]byte(conf.Key)),
 		topic:       text.NewInterpolatedString(conf.Topic),
+		partition:   text.NewInterpolatedString(conf.Partition),
+		meta:        metadata.NewMapping(conf.Metadata),
 		compression: compression,
+		partitioner: partitioner,
+
+		manualPartition: partitionerName == "manual",
+	}
+	if len(conf.Timestamp) > 0 {
+		k.timestamp = text.NewInterpolatedString(conf.Timestamp)
 	}
 
 	if conf.TLS.Enabled {
@@ -122,6 +171,12 @@ func NewKafka(conf KafkaConfig, log log.Modular, stats metrics.Type) (*Kafka, er
 	if k.version, err = sarama.ParseKafkaVersion(conf.TargetVersion); err != nil {
 		return nil, err
 	}
+	if compression == sarama.CompressionZSTD && !k.version.IsAtLeast(sarama.V2_1_0_0) {
+		return nil, fmt.Errorf(
+			"zstd compression requires a target_version of at least %v, got %v",
+			sarama.V2_1_0_0, k.version,
+		)
+	}
 
 	for _, addr := range conf.Addresses {
 		for _, splitAddr := range strings.Split(addr, ",") {
@@ -146,10 +201,36 @@ func strToCompressionCodec(str string) (sarama.CompressionCodec, error) {
 		return sarama.CompressionLZ4, nil
 	case "gzip":
 		return sarama.CompressionGZIP, nil
+	case "zstd":
+		return sarama.CompressionZSTD, nil
 	}
 	return sarama.CompressionNone, fmt.Errorf("compression codec not recognised: %v", str)
 }
 
+func strToPartitioner(str string) (sarama.PartitionerConstructor, error) {
+	switch str {
+	case "fnv1a_hash":
+		return sarama.NewHashPartitioner, nil
+	case "random":
+		return sarama.NewRandomPartitioner, nil
+	case "round_robin":
+		return sarama.NewRoundRobinPartitioner, nil
+	case "manual":
+		return sarama.NewManualPartitioner, nil
+	}
+	return nil, fmt.Errorf("partitioner not recognised: %v", str)
+}
+
+// parseTimestamp parses a record timestamp that is either a unix timestamp in
+// seconds, which may be fractional, or an RFC 3339 formatted date.
+func parseTimestamp(str string) (time.Time, error) {
+	if secs, err := strconv.ParseFloat(str, 64); err == nil {
+		whole := int64(secs)
+		return time.Unix(whole, int64((secs-float64(whole))*1e9)).Round(time.Millisecond), nil
+	}
+	return time.Parse(time.RFC3339Nano, str)
+}
+
 //------------------------------------------------------------------------------
 
 // Connect attempts to establish a connection to a Kafka broker.
@@ -169,18 +250,24 @@ func (k *Kafka) Connect() error {
 	config.Producer.Compression = k.compression
 	config.Producer.MaxMessageBytes = k.conf.MaxMsgBytes
 	config.Producer.Timeout = time.Duration(k.conf.TimeoutMS) * time.Millisecond
+	config.Producer.Flush.Frequency = time.Duration(k.conf.LingerMS) * time.Millisecond
+	config.Producer.Flush.Bytes = k.conf.BatchBytes
+	config.Producer.Partitioner = k.partitioner
 	config.Producer.Return.Errors = true
 	config.Producer.Return.Successes = true
 	config.Net.TLS.Enable = k.conf.TLS.Enabled
 	if k.conf.TLS.Enabled {
 		config.Net.TLS.Config = k.tlsConf
 	}
-
-	if k.conf.RoundRobinPartitions {
-		config.Producer.Partitioner = sarama.NewRoundRobinPartitioner
+	if err := k.conf.SASL.Apply(config); err != nil {
+		return err
 	}
 
-	if k.conf.AckReplicas {
+	if k.conf.IdempotentWrite {
+		config.Producer.Idempotent = true
+		config.Producer.RequiredAcks = sarama.WaitForAll
+		config.Net.MaxOpenRequests = 1
+	} else if k.conf.AckReplicas {
 		config.Producer.RequiredAcks = sarama.WaitForAll
 	} else {
 		config.Producer.RequiredAcks = sarama.WaitForLocal
@@ -207,7 +294,7 @@ func (k *Kafka) Write(msg types.Message) error {
 	}
 
 	msgs := []*sarama.ProducerMessage{}
-	msg.Iter(func(i int, p types.Part) error {
+	if err := msg.Iter(func(i int, p types.Part) error {
 		if len(p.Get()) > k.conf.MaxMsgBytes {
 			k.mDroppedMaxBytes.Incr(1)
 			return nil
@@ -223,9 +310,45 @@ func (k *Kafka) Write(msg types.Message) error {
 		if len(key) > 0 {
 			nextMsg.Key = sarama.ByteEncoder(key)
 		}
+		if k.manualPartition {
+			partitionStr := k.partition.Get(lMsg)
+			partition, err := strconv.ParseInt(partitionStr, 10, 32)
+			if err != nil {
+				k.log.Errorf("Dropping message part with invalid partition '%v': %v\n", partitionStr, err)
+				k.mDroppedPartition.Incr(1)
+				return nil
+			}
+			nextMsg.Partition = int32(partition)
+		}
+		if k.timestamp != nil {
+			if tsStr := k.timestamp.Get(lMsg); len(tsStr) > 0 {
+				ts, err := parseTimestamp(tsStr)
+				if err != nil {
+					return fmt.Errorf("failed to parse timestamp '%v': %v", tsStr, err)
+				}
+				nextMsg.Timestamp = ts
+			}
+		}
+		if k.version.IsAtLeast(sarama.V0_11_0_0) {
+			k.meta.Iter(p.Metadata(), func(hk, hv string) error {
+				nextMsg.Headers = append(nextMsg.Headers, sarama.RecordHeader{
+					Key:   []byte(hk),
+					Value: []byte(hv),
+				})
+				return nil
+			})
+			for hk, hv := range tracing.InjectHeaders("kafka", p.Metadata()) {
+				nextMsg.Headers = append(nextMsg.Headers, sarama.RecordHeader{
+					Key:   []byte(hk),
+					Value: []byte(hv),
+				})
+			}
+		}
 		msgs = append(msgs, nextMsg)
 		return nil
-	})
+	}); err != nil {
+		return err
+	}
 
 	err := producer.SendMessages(msgs)
 	if err != nil {
@@ -0,0 +1,176 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package writer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/log"
+	"github.com/Jeffail/benthos/lib/message"
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Shopify/sarama"
+)
+
+func TestKafkaBadConfigs(t *testing.T) {
+	tests := map[string]func(*KafkaConfig){
+		"bad compression": func(c *KafkaConfig) {
+			c.Compression = "nope"
+		},
+		"bad partitioner": func(c *KafkaConfig) {
+			c.Partitioner = "nope"
+		},
+		"manual without partition": func(c *KafkaConfig) {
+			c.Partitioner = "manual"
+		},
+		"bad version": func(c *KafkaConfig) {
+			c.TargetVersion = "nope"
+		},
+		"zstd with old version": func(c *KafkaConfig) {
+			c.Compression = "zstd"
+			c.TargetVersion = "2.0.0"
+		},
+	}
+
+	for name, fn := range tests {
+		conf := NewKafkaConfig()
+		fn(&conf)
+		if _, err := NewKafka(conf, log.Noop(), metrics.Noop()); err == nil {
+			t.Errorf("%v: Expected error", name)
+		}
+	}
+}
+
+func TestKafkaPartitioner(t *testing.T) {
+	conf := NewKafkaConfig()
+	conf.Compression = "zstd"
+	conf.TargetVersion = "2.1.0"
+	conf.Partitioner = "manual"
+	conf.Partition = "${!metadata:partition}"
+
+	k, err := NewKafka(conf, log.Noop(), metrics.Noop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp, act := sarama.CompressionZSTD, k.compression; exp != act {
+		t.Errorf("Wrong compression codec: %v != %v", act, exp)
+	}
+	if !k.manualPartition {
+		t.Error("Expected manual partitioning")
+	}
+
+	conf.RoundRobinPartitions = true
+	if k, err = NewKafka(conf, log.Noop(), metrics.Noop()); err != nil {
+		t.Fatal(err)
+	}
+	if k.manualPartition {
+		t.Error("Expected round robin partitioning to override manual")
+	}
+}
+
+type mockSyncProducer struct {
+	sarama.SyncProducer
+	msgs []*sarama.ProducerMessage
+}
+
+func (m *mockSyncProducer) SendMessages(msgs []*sarama.ProducerMessage) error {
+	m.msgs = append(m.msgs, msgs...)
+	return nil
+}
+
+func TestKafkaBadPartition(t *testing.T) {
+	conf := NewKafkaConfig()
+	conf.Partitioner = "manual"
+	conf.Partition = "${!metadata:partition}"
+
+	k, err := NewKafka(conf, log.Noop(), metrics.Noop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	mock := &mockSyncProducer{}
+	k.producer = mock
+
+	msg := message.New([][]byte{[]byte("foo"), []byte("bar")})
+	msg.Get(0).Metadata().Set("partition", "nope")
+	msg.Get(1).Metadata().Set("partition", "1")
+	if err = k.Write(msg); err != nil {
+		t.Fatal(err)
+	}
+
+	if exp, act := 1, len(mock.msgs); exp != act {
+		t.Fatalf("Wrong count of messages: %v != %v", act, exp)
+	}
+	if exp, act := int32(1), mock.msgs[0].Partition; exp != act {
+		t.Errorf("Wrong partition: %v != %v", act, exp)
+	}
+}
+
+func TestKafkaPartitionAndTimestamp(t *testing.T) {
+	conf := NewKafkaConfig()
+	conf.Partitioner = "manual"
+	conf.Partition = "${!metadata:partition}"
+	conf.Timestamp = "${!json_field:ts}"
+
+	k, err := NewKafka(conf, log.Noop(), metrics.Noop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	mock := &mockSyncProducer{}
+	k.producer = mock
+
+	msg := message.New([][]byte{
+		[]byte(`{"ts":1546300800}`),
+		[]byte(`{"ts":"2019-01-01T00:00:00.5Z"}`),
+		[]byte(`{"ts":1546300800.25}`),
+		[]byte(`{"ts":""}`),
+	})
+	for i, p := range []string{"0", "1", "2", "3"} {
+		msg.Get(i).Metadata().Set("partition", p)
+	}
+	if err = k.Write(msg); err != nil {
+		t.Fatal(err)
+	}
+
+	base := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	exp := []time.Time{
+		base,
+		base.Add(time.Millisecond * 500),
+		base.Add(time.Millisecond * 250),
+		{},
+	}
+	if len(mock.msgs) != len(exp) {
+		t.Fatalf("Wrong count of messages: %v != %v", len(mock.msgs), len(exp))
+	}
+	for i, m := range mock.msgs {
+		if act := m.Partition; int32(i) != act {
+			t.Errorf("Wrong partition for message %v: %v != %v", i, act, i)
+		}
+		if act := m.Timestamp; !exp[i].Equal(act) {
+			t.Errorf("Wrong timestamp for message %v: %v != %v", i, act, exp[i])
+		}
+	}
+
+	msg = message.New([][]byte{[]byte(`{"ts":"nope"}`)})
+	msg.Get(0).Metadata().Set("partition", "0")
+	if err = k.Write(msg); err == nil {
+		t.Error("Expected error from bad timestamp")
+	}
+}
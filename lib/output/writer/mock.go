@@ -0,0 +1,168 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package writer
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/log"
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+)
+
+//------------------------------------------------------------------------------
+
+// MockConfig contains configuration fields for the mock output type.
+type MockConfig struct {
+	Path string `json:"path" yaml:"path"`
+	File string `json:"file" yaml:"file"`
+}
+
+// NewMockConfig creates a new Config with default values.
+func NewMockConfig() MockConfig {
+	return MockConfig{
+		Path: "/mock",
+		File: "",
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// MockPart is a message part recorded by a mock output.
+type MockPart struct {
+	Content  string            `json:"content"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// Mock is a benthos writer.Type implementation that records the messages it
+// receives in memory, and optionally appends them to a file as lines of JSON.
+type Mock struct {
+	conf MockConfig
+
+	mut      sync.Mutex
+	messages [][]MockPart
+	file     *os.File
+
+	log   log.Modular
+	stats metrics.Type
+}
+
+// NewMock creates a new mock writer.Type.
+func NewMock(
+	conf MockConfig,
+	log log.Modular,
+	stats metrics.Type,
+) *Mock {
+	return &Mock{
+		conf:  conf,
+		log:   log.NewModule(".output.mock"),
+		stats: stats,
+	}
+}
+
+// Connect opens the file that messages are recorded to, if one is configured.
+func (m *Mock) Connect() error {
+	m.mut.Lock()
+	defer m.mut.Unlock()
+
+	if m.file != nil || len(m.conf.File) == 0 {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(m.conf.File), os.FileMode(0777)); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(m.conf.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, os.FileMode(0666))
+	if err != nil {
+		return err
+	}
+	m.file = file
+
+	m.log.Infof("Recording messages to file: %v\n", m.conf.File)
+	return nil
+}
+
+// Write records a message.
+func (m *Mock) Write(msg types.Message) error {
+	parts := make([]MockPart, 0, msg.Len())
+	msg.Iter(func(i int, p types.Part) error {
+		part := MockPart{
+			Content: string(p.Get()),
+		}
+		p.Metadata().Iter(func(k, v string) error {
+			if part.Metadata == nil {
+				part.Metadata = map[string]string{}
+			}
+			part.Metadata[k] = v
+			return nil
+		})
+		parts = append(parts, part)
+		return nil
+	})
+
+	m.mut.Lock()
+	defer m.mut.Unlock()
+
+	if m.file != nil {
+		line, err := json.Marshal(parts)
+		if err != nil {
+			return err
+		}
+		if _, err = m.file.Write(append(line, '\n')); err != nil {
+			return err
+		}
+	}
+
+	m.messages = append(m.messages, parts)
+	return nil
+}
+
+// Messages returns all messages recorded so far.
+func (m *Mock) Messages() [][]MockPart {
+	m.mut.Lock()
+	defer m.mut.Unlock()
+
+	messages := make([][]MockPart, len(m.messages))
+	copy(messages, m.messages)
+	return messages
+}
+
+// CloseAsync begins cleaning up resources used by this writer asynchronously.
+func (m *Mock) CloseAsync() {
+	m.mut.Lock()
+	if m.file != nil {
+		m.file.Close()
+		m.file = nil
+	}
+	m.mut.Unlock()
+}
+
+// WaitForClose will block until either the writer is closed or a specified
+// timeout occurs.
+func (m *Mock) WaitForClose(time.Duration) error {
+	return nil
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,88 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package writer
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/log"
+	"github.com/Jeffail/benthos/lib/message"
+	"github.com/Jeffail/benthos/lib/metrics"
+)
+
+func TestMockWriter(t *testing.T) {
+	dir, err := ioutil.TempDir("", "benthos_mock_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	conf := NewMockConfig()
+	conf.File = filepath.Join(dir, "out", "messages.jsonl")
+
+	m := NewMock(conf, log.Noop(), metrics.Noop())
+	if err = m.Connect(); err != nil {
+		t.Fatal(err)
+	}
+
+	msg := message.New([][]byte{[]byte("foo"), []byte("bar")})
+	msg.Get(1).Metadata().Set("baz", "qux")
+	if err = m.Write(msg); err != nil {
+		t.Fatal(err)
+	}
+	if err = m.Write(message.New([][]byte{[]byte("quz")})); err != nil {
+		t.Fatal(err)
+	}
+
+	exp := [][]MockPart{
+		{
+			{Content: "foo"},
+			{Content: "bar", Metadata: map[string]string{"baz": "qux"}},
+		},
+		{
+			{Content: "quz"},
+		},
+	}
+	if act := m.Messages(); !reflect.DeepEqual(exp, act) {
+		t.Errorf("Wrong recorded messages: %v != %v", act, exp)
+	}
+
+	m.CloseAsync()
+	if err = m.WaitForClose(time.Second); err != nil {
+		t.Error(err)
+	}
+
+	fileBytes, err := ioutil.ReadFile(conf.File)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expFile := `[{"content":"foo"},{"content":"bar","metadata":{"baz":"qux"}}]
+[{"content":"quz"}]
+`
+	if act := string(fileBytes); expFile != act {
+		t.Errorf("Wrong file contents: %v != %v", act, expFile)
+	}
+}
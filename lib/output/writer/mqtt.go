@@ -21,13 +21,17 @@
 package writer
 
 import (
+	"crypto/tls"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/Jeffail/benthos/lib/log"
+	"github.com/Jeffail/benthos/lib/message"
 	"github.com/Jeffail/benthos/lib/metrics"
 	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/text"
+	btls "github.com/Jeffail/benthos/lib/util/tls"
 	mqtt "github.com/eclipse/paho.mqtt.golang"
 )
 
@@ -35,10 +39,11 @@ import (
 
 // MQTTConfig contains configuration fields for the MQTT output type.
 type MQTTConfig struct {
-	URLs     []string `json:"urls" yaml:"urls"`
-	QoS      uint8    `json:"qos" yaml:"qos"`
-	Topic    string   `json:"topic" yaml:"topic"`
-	ClientID string   `json:"client_id" yaml:"client_id"`
+	URLs     []string    `json:"urls" yaml:"urls"`
+	QoS      uint8       `json:"qos" yaml:"qos"`
+	Topic    string      `json:"topic" yaml:"topic"`
+	ClientID string      `json:"client_id" yaml:"client_id"`
+	TLS      btls.Config `json:"tls" yaml:"tls"`
 }
 
 // NewMQTTConfig creates a new MQTTConfig with default values.
@@ -48,6 +53,7 @@ func NewMQTTConfig() MQTTConfig {
 		QoS:      1,
 		Topic:    "benthos_topic",
 		ClientID: "benthos_output",
+		TLS:      btls.NewConfig(),
 	}
 }
 
@@ -58,8 +64,10 @@ type MQTT struct {
 	log   log.Modular
 	stats metrics.Type
 
-	urls []string
-	conf MQTTConfig
+	urls    []string
+	tlsConf *tls.Config
+	conf    MQTTConfig
+	topic   *text.InterpolatedString
 
 	client  mqtt.Client
 	connMut sync.RWMutex
@@ -75,6 +83,7 @@ func NewMQTT(
 		log:   log.NewModule(".output.mqtt"),
 		stats: stats,
 		conf:  conf,
+		topic: text.NewInterpolatedString(conf.Topic),
 	}
 
 	for _, u := range conf.URLs {
@@ -85,6 +94,13 @@ func NewMQTT(
 		}
 	}
 
+	if conf.TLS.Enabled {
+		var err error
+		if m.tlsConf, err = conf.TLS.Get(); err != nil {
+			return nil, err
+		}
+	}
+
 	return m, nil
 }
 
@@ -105,6 +121,10 @@ func (m *MQTT) Connect() error {
 		SetWriteTimeout(time.Second).
 		SetClientID(m.conf.ClientID)
 
+	if m.tlsConf != nil {
+		conf = conf.SetTLSConfig(m.tlsConf)
+	}
+
 	for _, u := range m.urls {
 		conf = conf.AddBroker(u)
 	}
@@ -134,7 +154,7 @@ func (m *MQTT) Write(msg types.Message) error {
 	}
 
 	return msg.Iter(func(i int, p types.Part) error {
-		mtok := client.Publish(m.conf.Topic, byte(m.conf.QoS), false, p.Get())
+		mtok := client.Publish(m.topic.Get(message.Lock(msg, i)), byte(m.conf.QoS), false, p.Get())
 		mtok.Wait()
 		return mtok.Error()
 	})
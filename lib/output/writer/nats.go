@@ -21,13 +21,17 @@
 package writer
 
 import (
+	"crypto/tls"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/Jeffail/benthos/lib/log"
+	"github.com/Jeffail/benthos/lib/message"
 	"github.com/Jeffail/benthos/lib/metrics"
 	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/text"
+	btls "github.com/Jeffail/benthos/lib/util/tls"
 	nats "github.com/nats-io/go-nats"
 )
 
@@ -35,8 +39,9 @@ import (
 
 // NATSConfig contains configuration fields for the NATS output type.
 type NATSConfig struct {
-	URLs    []string `json:"urls" yaml:"urls"`
-	Subject string   `json:"subject" yaml:"subject"`
+	URLs    []string    `json:"urls" yaml:"urls"`
+	Subject string      `json:"subject" yaml:"subject"`
+	TLS     btls.Config `json:"tls" yaml:"tls"`
 }
 
 // NewNATSConfig creates a new NATSConfig with default values.
@@ -44,6 +49,7 @@ func NewNATSConfig() NATSConfig {
 	return NATSConfig{
 		URLs:    []string{nats.DefaultURL},
 		Subject: "benthos_messages",
+		TLS:     btls.NewConfig(),
 	}
 }
 
@@ -56,17 +62,26 @@ type NATS struct {
 	natsConn *nats.Conn
 	connMut  sync.RWMutex
 
-	urls string
-	conf NATSConfig
+	urls    string
+	tlsConf *tls.Config
+	conf    NATSConfig
+	subject *text.InterpolatedString
 }
 
 // NewNATS creates a new NATS output type.
 func NewNATS(conf NATSConfig, log log.Modular, stats metrics.Type) (Type, error) {
 	n := NATS{
-		log:  log.NewModule(".output.nats"),
-		conf: conf,
+		log:     log.NewModule(".output.nats"),
+		conf:    conf,
+		subject: text.NewInterpolatedString(conf.Subject),
 	}
 	n.urls = strings.Join(conf.URLs, ",")
+	if conf.TLS.Enabled {
+		var err error
+		if n.tlsConf, err = conf.TLS.Get(); err != nil {
+			return nil, err
+		}
+	}
 
 	return &n, nil
 }
@@ -82,8 +97,13 @@ func (n *NATS) Connect() error {
 		return nil
 	}
 
+	var opts []nats.Option
+	if n.tlsConf != nil {
+		opts = append(opts, nats.Secure(n.tlsConf))
+	}
+
 	var err error
-	n.natsConn, err = nats.Connect(n.urls)
+	n.natsConn, err = nats.Connect(n.urls, opts...)
 	if err == nil {
 		n.log.Infof("Sending NATS messages to URLs: %s\n", n.urls)
 	}
@@ -101,7 +121,7 @@ func (n *NATS) Write(msg types.Message) error {
 	}
 
 	return msg.Iter(func(i int, p types.Part) error {
-		return conn.Publish(n.conf.Subject, p.Get())
+		return conn.Publish(n.subject.Get(message.Lock(msg, i)), p.Get())
 	})
 }
 
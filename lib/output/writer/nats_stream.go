@@ -21,6 +21,7 @@
 package writer
 
 import (
+	"crypto/tls"
 	"fmt"
 	"math/rand"
 	"strings"
@@ -28,8 +29,12 @@ import (
 	"time"
 
 	"github.com/Jeffail/benthos/lib/log"
+	"github.com/Jeffail/benthos/lib/message"
 	"github.com/Jeffail/benthos/lib/metrics"
 	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/text"
+	btls "github.com/Jeffail/benthos/lib/util/tls"
+	nats "github.com/nats-io/go-nats"
 	"github.com/nats-io/go-nats-streaming"
 )
 
@@ -38,10 +43,11 @@ import (
 // NATSStreamConfig contains configuration fields for the NATSStream output
 // type.
 type NATSStreamConfig struct {
-	URLs      []string `json:"urls" yaml:"urls"`
-	ClusterID string   `json:"cluster_id" yaml:"cluster_id"`
-	ClientID  string   `json:"client_id" yaml:"client_id"`
-	Subject   string   `json:"subject" yaml:"subject"`
+	URLs      []string    `json:"urls" yaml:"urls"`
+	ClusterID string      `json:"cluster_id" yaml:"cluster_id"`
+	ClientID  string      `json:"client_id" yaml:"client_id"`
+	Subject   string      `json:"subject" yaml:"subject"`
+	TLS       btls.Config `json:"tls" yaml:"tls"`
 }
 
 // NewNATSStreamConfig creates a new NATSStreamConfig with default values.
@@ -51,6 +57,7 @@ func NewNATSStreamConfig() NATSStreamConfig {
 		ClusterID: "test-cluster",
 		ClientID:  "benthos_client",
 		Subject:   "benthos_messages",
+		TLS:       btls.NewConfig(),
 	}
 }
 
@@ -61,10 +68,13 @@ type NATSStream struct {
 	log log.Modular
 
 	natsConn stan.Conn
+	tlsConn  *nats.Conn
 	connMut  sync.RWMutex
 
-	urls string
-	conf NATSStreamConfig
+	urls    string
+	tlsConf *tls.Config
+	conf    NATSStreamConfig
+	subject *text.InterpolatedString
 }
 
 // NewNATSStream creates a new NATS Stream output type.
@@ -79,10 +89,17 @@ func NewNATSStream(conf NATSStreamConfig, log log.Modular, stats metrics.Type) (
 	}
 
 	n := NATSStream{
-		log:  log.NewModule(".output.nats_stream"),
-		conf: conf,
+		log:     log.NewModule(".output.nats_stream"),
+		conf:    conf,
+		subject: text.NewInterpolatedString(conf.Subject),
 	}
 	n.urls = strings.Join(conf.URLs, ",")
+	if conf.TLS.Enabled {
+		var err error
+		if n.tlsConf, err = conf.TLS.Get(); err != nil {
+			return nil, err
+		}
+	}
 
 	return &n, nil
 }
@@ -98,16 +115,30 @@ func (n *NATSStream) Connect() error {
 		return nil
 	}
 
+	opts := []stan.Option{stan.NatsURL(n.urls)}
+
 	var err error
+	if n.tlsConf != nil {
+		if n.tlsConn, err = nats.Connect(n.urls, nats.Secure(n.tlsConf)); err != nil {
+			return err
+		}
+		opts = append(opts, stan.NatsConn(n.tlsConn))
+	}
+
 	n.natsConn, err = stan.Connect(
 		n.conf.ClusterID,
 		n.conf.ClientID,
-		stan.NatsURL(n.urls),
+		opts...,
 	)
-	if err == nil {
-		n.log.Infof("Sending NATS messages to URLs: %s\n", n.urls)
+	if err != nil {
+		if n.tlsConn != nil {
+			n.tlsConn.Close()
+			n.tlsConn = nil
+		}
+		return err
 	}
-	return err
+	n.log.Infof("Sending NATS messages to URLs: %s\n", n.urls)
+	return nil
 }
 
 // Write attempts to write a message.
@@ -121,7 +152,7 @@ func (n *NATSStream) Write(msg types.Message) error {
 	}
 
 	return msg.Iter(func(i int, p types.Part) error {
-		return conn.Publish(n.conf.Subject, p.Get())
+		return conn.Publish(n.subject.Get(message.Lock(msg, i)), p.Get())
 	})
 }
 
@@ -132,6 +163,10 @@ func (n *NATSStream) CloseAsync() {
 		n.natsConn.Close()
 		n.natsConn = nil
 	}
+	if n.tlsConn != nil {
+		n.tlsConn.Close()
+		n.tlsConn = nil
+	}
 	n.connMut.Unlock()
 }
 
@@ -21,6 +21,7 @@
 package writer
 
 import (
+	"crypto/tls"
 	"io/ioutil"
 	llog "log"
 	"sync"
@@ -31,6 +32,7 @@ import (
 	"github.com/Jeffail/benthos/lib/metrics"
 	"github.com/Jeffail/benthos/lib/types"
 	"github.com/Jeffail/benthos/lib/util/text"
+	btls "github.com/Jeffail/benthos/lib/util/tls"
 	nsq "github.com/nsqio/go-nsq"
 )
 
@@ -38,9 +40,10 @@ import (
 
 // NSQConfig contains configuration fields for the NSQ output type.
 type NSQConfig struct {
-	Address   string `json:"nsqd_tcp_address" yaml:"nsqd_tcp_address"`
-	Topic     string `json:"topic" yaml:"topic"`
-	UserAgent string `json:"user_agent" yaml:"user_agent"`
+	Address   string      `json:"nsqd_tcp_address" yaml:"nsqd_tcp_address"`
+	Topic     string      `json:"topic" yaml:"topic"`
+	UserAgent string      `json:"user_agent" yaml:"user_agent"`
+	TLS       btls.Config `json:"tls" yaml:"tls"`
 }
 
 // NewNSQConfig creates a new NSQConfig with default values.
@@ -49,6 +52,7 @@ func NewNSQConfig() NSQConfig {
 		Address:   "localhost:4150",
 		Topic:     "benthos_messages",
 		UserAgent: "benthos_producer",
+		TLS:       btls.NewConfig(),
 	}
 }
 
@@ -63,7 +67,8 @@ type NSQ struct {
 	connMut  sync.RWMutex
 	producer *nsq.Producer
 
-	conf NSQConfig
+	tlsConf *tls.Config
+	conf    NSQConfig
 }
 
 // NewNSQ creates a new NSQ output type.
@@ -73,6 +78,12 @@ func NewNSQ(conf NSQConfig, log log.Modular, stats metrics.Type) (*NSQ, error) {
 		conf:     conf,
 		topicStr: text.NewInterpolatedString(conf.Topic),
 	}
+	if conf.TLS.Enabled {
+		var err error
+		if n.tlsConf, err = conf.TLS.Get(); err != nil {
+			return nil, err
+		}
+	}
 	return &n, nil
 }
 
@@ -85,6 +96,10 @@ func (n *NSQ) Connect() error {
 
 	cfg := nsq.NewConfig()
 	cfg.UserAgent = n.conf.UserAgent
+	if n.tlsConf != nil {
+		cfg.TlsV1 = true
+		cfg.TlsConfig = n.tlsConf
+	}
 
 	producer, err := nsq.NewProducer(n.conf.Address, cfg)
 	if err != nil {
@@ -21,13 +21,17 @@
 package writer
 
 import (
+	"crypto/tls"
 	"net/url"
 	"sync"
 	"time"
 
 	"github.com/Jeffail/benthos/lib/log"
+	"github.com/Jeffail/benthos/lib/message"
 	"github.com/Jeffail/benthos/lib/metrics"
 	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/text"
+	btls "github.com/Jeffail/benthos/lib/util/tls"
 	"github.com/go-redis/redis"
 )
 
@@ -35,8 +39,9 @@ import (
 
 // RedisListConfig contains configuration fields for the RedisList output type.
 type RedisListConfig struct {
-	URL string `json:"url" yaml:"url"`
-	Key string `json:"key" yaml:"key"`
+	URL string      `json:"url" yaml:"url"`
+	Key string      `json:"key" yaml:"key"`
+	TLS btls.Config `json:"tls" yaml:"tls"`
 }
 
 // NewRedisListConfig creates a new RedisListConfig with default values.
@@ -44,6 +49,7 @@ func NewRedisListConfig() RedisListConfig {
 	return RedisListConfig{
 		URL: "tcp://localhost:6379",
 		Key: "benthos_list",
+		TLS: btls.NewConfig(),
 	}
 }
 
@@ -54,8 +60,10 @@ type RedisList struct {
 	log   log.Modular
 	stats metrics.Type
 
-	url  *url.URL
-	conf RedisListConfig
+	url     *url.URL
+	tlsConf *tls.Config
+	conf    RedisListConfig
+	key     *text.InterpolatedString
 
 	client  *redis.Client
 	connMut sync.RWMutex
@@ -72,6 +80,7 @@ func NewRedisList(
 		log:   log.NewModule(".output.redis_list"),
 		stats: stats,
 		conf:  conf,
+		key:   text.NewInterpolatedString(conf.Key),
 	}
 
 	var err error
@@ -80,6 +89,12 @@ func NewRedisList(
 		return nil, err
 	}
 
+	if conf.TLS.Enabled {
+		if r.tlsConf, err = conf.TLS.Get(); err != nil {
+			return nil, err
+		}
+	}
+
 	return r, nil
 }
 
@@ -95,9 +110,10 @@ func (r *RedisList) Connect() error {
 		pass, _ = r.url.User.Password()
 	}
 	client := redis.NewClient(&redis.Options{
-		Addr:     r.url.Host,
-		Network:  r.url.Scheme,
-		Password: pass,
+		Addr:      r.url.Host,
+		Network:   r.url.Scheme,
+		Password:  pass,
+		TLSConfig: r.tlsConf,
 	})
 
 	if _, err := client.Ping().Result(); err != nil {
@@ -123,7 +139,7 @@ func (r *RedisList) Write(msg types.Message) error {
 	}
 
 	return msg.Iter(func(i int, p types.Part) error {
-		if err := client.RPush(r.conf.Key, p.Get()).Err(); err != nil {
+		if err := client.RPush(r.key.Get(message.Lock(msg, i)), p.Get()).Err(); err != nil {
 			r.disconnect()
 			r.log.Errorf("Error from redis: %v\n", err)
 			return types.ErrNotConnected
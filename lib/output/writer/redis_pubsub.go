@@ -21,13 +21,17 @@
 package writer
 
 import (
+	"crypto/tls"
 	"net/url"
 	"sync"
 	"time"
 
 	"github.com/Jeffail/benthos/lib/log"
+	"github.com/Jeffail/benthos/lib/message"
 	"github.com/Jeffail/benthos/lib/metrics"
 	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/text"
+	btls "github.com/Jeffail/benthos/lib/util/tls"
 	"github.com/go-redis/redis"
 )
 
@@ -36,8 +40,9 @@ import (
 // RedisPubSubConfig contains configuration fields for the RedisPubSub output
 // type.
 type RedisPubSubConfig struct {
-	URL     string `json:"url" yaml:"url"`
-	Channel string `json:"channel" yaml:"channel"`
+	URL     string      `json:"url" yaml:"url"`
+	Channel string      `json:"channel" yaml:"channel"`
+	TLS     btls.Config `json:"tls" yaml:"tls"`
 }
 
 // NewRedisPubSubConfig creates a new RedisPubSubConfig with default values.
@@ -45,6 +50,7 @@ func NewRedisPubSubConfig() RedisPubSubConfig {
 	return RedisPubSubConfig{
 		URL:     "tcp://localhost:6379",
 		Channel: "benthos_chan",
+		TLS:     btls.NewConfig(),
 	}
 }
 
@@ -55,8 +61,10 @@ type RedisPubSub struct {
 	log   log.Modular
 	stats metrics.Type
 
-	url  *url.URL
-	conf RedisPubSubConfig
+	url     *url.URL
+	tlsConf *tls.Config
+	conf    RedisPubSubConfig
+	channel *text.InterpolatedString
 
 	client  *redis.Client
 	connMut sync.RWMutex
@@ -70,9 +78,10 @@ func NewRedisPubSub(
 ) (*RedisPubSub, error) {
 
 	r := &RedisPubSub{
-		log:   log.NewModule(".output.redis_pubsub"),
-		stats: stats,
-		conf:  conf,
+		log:     log.NewModule(".output.redis_pubsub"),
+		stats:   stats,
+		conf:    conf,
+		channel: text.NewInterpolatedString(conf.Channel),
 	}
 
 	var err error
@@ -81,6 +90,12 @@ func NewRedisPubSub(
 		return nil, err
 	}
 
+	if conf.TLS.Enabled {
+		if r.tlsConf, err = conf.TLS.Get(); err != nil {
+			return nil, err
+		}
+	}
+
 	return r, nil
 }
 
@@ -96,9 +111,10 @@ func (r *RedisPubSub) Connect() error {
 		pass, _ = r.url.User.Password()
 	}
 	client := redis.NewClient(&redis.Options{
-		Addr:     r.url.Host,
-		Network:  r.url.Scheme,
-		Password: pass,
+		Addr:      r.url.Host,
+		Network:   r.url.Scheme,
+		Password:  pass,
+		TLSConfig: r.tlsConf,
 	})
 
 	if _, err := client.Ping().Result(); err != nil {
@@ -124,7 +140,7 @@ func (r *RedisPubSub) Write(msg types.Message) error {
 	}
 
 	return msg.Iter(func(i int, p types.Part) error {
-		if err := client.Publish(r.conf.Channel, p.Get()).Err(); err != nil {
+		if err := client.Publish(r.channel.Get(message.Lock(msg, i)), p.Get()).Err(); err != nil {
 			r.disconnect()
 			r.log.Errorf("Error from redis: %v\n", err)
 			return types.ErrNotConnected
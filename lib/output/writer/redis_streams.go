@@ -21,13 +21,17 @@
 package writer
 
 import (
+	"crypto/tls"
 	"net/url"
 	"sync"
 	"time"
 
 	"github.com/Jeffail/benthos/lib/log"
+	"github.com/Jeffail/benthos/lib/message"
 	"github.com/Jeffail/benthos/lib/metrics"
 	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/text"
+	btls "github.com/Jeffail/benthos/lib/util/tls"
 	"github.com/go-redis/redis"
 )
 
@@ -35,10 +39,11 @@ import (
 
 // RedisStreamsConfig contains configuration fields for the RedisStreams output type.
 type RedisStreamsConfig struct {
-	URL          string `json:"url" yaml:"url"`
-	Stream       string `json:"stream" yaml:"stream"`
-	BodyKey      string `json:"body_key" yaml:"body_key"`
-	MaxLenApprox int64  `json:"max_length" yaml:"max_length"`
+	URL          string      `json:"url" yaml:"url"`
+	Stream       string      `json:"stream" yaml:"stream"`
+	BodyKey      string      `json:"body_key" yaml:"body_key"`
+	MaxLenApprox int64       `json:"max_length" yaml:"max_length"`
+	TLS          btls.Config `json:"tls" yaml:"tls"`
 }
 
 // NewRedisStreamsConfig creates a new RedisStreamsConfig with default values.
@@ -48,6 +53,7 @@ func NewRedisStreamsConfig() RedisStreamsConfig {
 		Stream:       "benthos_stream",
 		BodyKey:      "body",
 		MaxLenApprox: 0,
+		TLS:          btls.NewConfig(),
 	}
 }
 
@@ -58,8 +64,10 @@ type RedisStreams struct {
 	log   log.Modular
 	stats metrics.Type
 
-	url  *url.URL
-	conf RedisStreamsConfig
+	url     *url.URL
+	tlsConf *tls.Config
+	conf    RedisStreamsConfig
+	stream  *text.InterpolatedString
 
 	client  *redis.Client
 	connMut sync.RWMutex
@@ -73,9 +81,10 @@ func NewRedisStreams(
 ) (*RedisStreams, error) {
 
 	r := &RedisStreams{
-		log:   log.NewModule(".output.redis_streams"),
-		stats: stats,
-		conf:  conf,
+		log:    log.NewModule(".output.redis_streams"),
+		stats:  stats,
+		conf:   conf,
+		stream: text.NewInterpolatedString(conf.Stream),
 	}
 
 	var err error
@@ -84,6 +93,12 @@ func NewRedisStreams(
 		return nil, err
 	}
 
+	if conf.TLS.Enabled {
+		if r.tlsConf, err = conf.TLS.Get(); err != nil {
+			return nil, err
+		}
+	}
+
 	return r, nil
 }
 
@@ -99,9 +114,10 @@ func (r *RedisStreams) Connect() error {
 		pass, _ = r.url.User.Password()
 	}
 	client := redis.NewClient(&redis.Options{
-		Addr:     r.url.Host,
-		Network:  r.url.Scheme,
-		Password: pass,
+		Addr:      r.url.Host,
+		Network:   r.url.Scheme,
+		Password:  pass,
+		TLSConfig: r.tlsConf,
 	})
 
 	if _, err := client.Ping().Result(); err != nil {
@@ -135,7 +151,7 @@ func (r *RedisStreams) Write(msg types.Message) error {
 		values[r.conf.BodyKey] = p.Get()
 		if err := client.XAdd(&redis.XAddArgs{
 			ID:           "*",
-			Stream:       r.conf.Stream,
+			Stream:       r.stream.Get(message.Lock(msg, i)),
 			MaxLenApprox: r.conf.MaxLenApprox,
 			Values:       values,
 		}).Err(); err != nil {
@@ -21,6 +21,7 @@
 package writer
 
 import (
+	"crypto/tls"
 	"net/http"
 	"net/url"
 	"sync"
@@ -30,6 +31,7 @@ import (
 	"github.com/Jeffail/benthos/lib/metrics"
 	"github.com/Jeffail/benthos/lib/types"
 	"github.com/Jeffail/benthos/lib/util/http/auth"
+	btls "github.com/Jeffail/benthos/lib/util/tls"
 	"github.com/gorilla/websocket"
 )
 
@@ -37,7 +39,8 @@ import (
 
 // WebsocketConfig contains configuration fields for the Websocket output type.
 type WebsocketConfig struct {
-	URL         string `json:"url" yaml:"url"`
+	URL         string      `json:"url" yaml:"url"`
+	TLS         btls.Config `json:"tls" yaml:"tls"`
 	auth.Config `json:",inline" yaml:",inline"`
 }
 
@@ -45,6 +48,7 @@ type WebsocketConfig struct {
 func NewWebsocketConfig() WebsocketConfig {
 	return WebsocketConfig{
 		URL:    "ws://localhost:4195/post/ws",
+		TLS:    btls.NewConfig(),
 		Config: auth.NewConfig(),
 	}
 }
@@ -58,8 +62,9 @@ type Websocket struct {
 
 	lock *sync.Mutex
 
-	conf   WebsocketConfig
-	client *websocket.Conn
+	conf    WebsocketConfig
+	tlsConf *tls.Config
+	client  *websocket.Conn
 }
 
 // NewWebsocket creates a new Websocket output type.
@@ -74,6 +79,12 @@ func NewWebsocket(
 		lock:  &sync.Mutex{},
 		conf:  conf,
 	}
+	if conf.TLS.Enabled {
+		var err error
+		if ws.tlsConf, err = conf.TLS.Get(); err != nil {
+			return nil, err
+		}
+	}
 	return ws, nil
 }
 
@@ -112,7 +123,9 @@ func (w *Websocket) Connect() error {
 	}
 
 	var client *websocket.Conn
-	if client, _, err = websocket.DefaultDialer.Dial(w.conf.URL, headers); err != nil {
+	dialer := *websocket.DefaultDialer
+	dialer.TLSClientConfig = w.tlsConf
+	if client, _, err = dialer.Dial(w.conf.URL, headers); err != nil {
 		return err
 	}
 
@@ -30,6 +30,7 @@ import (
 	"github.com/Jeffail/benthos/lib/log"
 	"github.com/Jeffail/benthos/lib/message"
 	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/tracing"
 	"github.com/Jeffail/benthos/lib/types"
 )
 
@@ -579,3 +580,167 @@ func TestWriterSadPath(t *testing.T) {
 }
 
 //------------------------------------------------------------------------------
+
+//------------------------------------------------------------------------------
+
+type writerBlocking struct {
+	writing chan struct{}
+	release chan error
+}
+
+func (w *writerBlocking) Connect() error { return nil }
+func (w *writerBlocking) Write(msg types.Message) error {
+	w.writing <- struct{}{}
+	return <-w.release
+}
+func (w *writerBlocking) CloseAsync() {}
+func (w *writerBlocking) WaitForClose(time.Duration) error {
+	return nil
+}
+
+func TestAsyncWriterBadMaxInFlight(t *testing.T) {
+	if _, err := NewAsyncWriter("foo", 0, &writerBlocking{}, log.Noop(), metrics.Noop()); err == nil {
+		t.Error("Expected error from zero max_in_flight")
+	}
+}
+
+func TestAsyncWriterInFlight(t *testing.T) {
+	t.Parallel()
+
+	writerImpl := &writerBlocking{
+		writing: make(chan struct{}),
+		release: make(chan error),
+	}
+
+	w, err := NewAsyncWriter("foo", 3, writerImpl, log.Noop(), metrics.Noop())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msgChan := make(chan types.Transaction)
+	if err = w.Consume(msgChan); err != nil {
+		t.Fatal(err)
+	}
+
+	resChans := make([]chan types.Response, 3)
+	for i := range resChans {
+		resChans[i] = make(chan types.Response, 1)
+		select {
+		case msgChan <- types.NewTransaction(message.New([][]byte{[]byte("foo")}), resChans[i]):
+		case <-time.After(time.Second):
+			t.Fatal("Timed out")
+		}
+		select {
+		case <-writerImpl.writing:
+		case <-time.After(time.Second):
+			t.Fatal("Timed out")
+		}
+	}
+
+	// All three writes are now in flight, so a fourth message should not be
+	// consumed.
+	select {
+	case msgChan <- types.NewTransaction(message.New([][]byte{[]byte("bar")}), make(chan types.Response)):
+		t.Fatal("Expected writer to be at capacity")
+	case <-time.After(time.Millisecond * 50):
+	}
+
+	expErr := errors.New("test err")
+	for _, exp := range []error{nil, expErr, nil} {
+		select {
+		case writerImpl.release <- exp:
+		case <-time.After(time.Second):
+			t.Fatal("Timed out")
+		}
+	}
+
+	var errs int
+	for _, resChan := range resChans {
+		select {
+		case res := <-resChan:
+			if res.Error() != nil {
+				errs++
+			}
+		case <-time.After(time.Second):
+			t.Fatal("Timed out")
+		}
+	}
+	if errs != 1 {
+		t.Errorf("Wrong count of errored responses: %v != %v", errs, 1)
+	}
+
+	w.CloseAsync()
+	if err = w.WaitForClose(time.Second); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestWriterTracing(t *testing.T) {
+	tConf := tracing.NewConfig()
+	tConf.Type = tracing.TypeZipkin
+	tConf.Zipkin.URL = "http://localhost:1"
+	tConf.Zipkin.FlushPeriod = "1h"
+	tracer, err := tracing.New(tConf, log.Noop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	tracing.SetGlobal(tracer)
+	defer func() {
+		tracing.SetGlobal(tracing.Noop{})
+		tracer.Close()
+	}()
+
+	writerImpl := newMockWriter()
+
+	w, err := NewWriter(
+		"foo", writerImpl,
+		log.New(os.Stdout, logConfig), metrics.DudType{},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msgChan := make(chan types.Transaction)
+	resChan := make(chan types.Response)
+
+	if err = w.Consume(msgChan); err != nil {
+		t.Fatal(err)
+	}
+
+	msg := message.New([][]byte{[]byte("foo")})
+	go func() {
+		select {
+		case msgChan <- types.NewTransaction(msg, resChan):
+		case <-time.After(time.Second):
+			t.Error("Timed out")
+		}
+	}()
+
+	select {
+	case writerImpl.connChan <- nil:
+	case <-time.After(time.Second):
+		t.Fatal("Timed out")
+	}
+	select {
+	case writerImpl.writeChan <- nil:
+	case <-time.After(time.Second):
+		t.Fatal("Timed out")
+	}
+	select {
+	case <-resChan:
+	case <-time.After(time.Second):
+		t.Fatal("Timed out")
+	}
+
+	w.CloseAsync()
+	if err = w.WaitForClose(time.Second); err != nil {
+		t.Error(err)
+	}
+
+	if _, exists := tracing.Extract(writerImpl.msgRcvd.Get(0).Metadata()); !exists {
+		t.Error("Expected span context within written message")
+	}
+	if v := msg.Get(0).Metadata().Get(tracing.MetadataKey); len(v) > 0 {
+		t.Errorf("Expected original message to be unchanged: %v", v)
+	}
+}
@@ -21,6 +21,8 @@
 package pipeline
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 
@@ -28,6 +30,7 @@ import (
 	"github.com/Jeffail/benthos/lib/metrics"
 	"github.com/Jeffail/benthos/lib/processor"
 	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/lineage"
 )
 
 //------------------------------------------------------------------------------
@@ -40,15 +43,40 @@ import (
 // In order to fully utilise each processing thread you must either have a
 // number of parallel inputs that matches or surpasses the number of pipeline
 // threads, or use a memory buffer.
+//
+// When there are multiple threads the order of messages is not preserved
+// unless Ordered is set, in which case the results of each message are held
+// until all preceding messages have been processed.
 type Config struct {
 	Threads    int                `json:"threads" yaml:"threads"`
+	Ordered    bool               `json:"ordered" yaml:"ordered"`
+	Lineage    LineageConfig      `json:"lineage" yaml:"lineage"`
 	Processors []processor.Config `json:"processors" yaml:"processors"`
 }
 
+// LineageConfig contains configuration fields for stamping lineage metadata
+// onto messages once they have been processed by a pipeline.
+type LineageConfig struct {
+	Enabled bool   `json:"enabled" yaml:"enabled"`
+	Prefix  string `json:"prefix" yaml:"prefix"`
+	Version string `json:"version" yaml:"version"`
+}
+
+// NewLineageConfig returns a LineageConfig with default values.
+func NewLineageConfig() LineageConfig {
+	return LineageConfig{
+		Enabled: false,
+		Prefix:  lineage.NewConfig().Prefix,
+		Version: "",
+	}
+}
+
 // NewConfig returns a configuration struct fully populated with default values.
 func NewConfig() Config {
 	return Config{
 		Threads:    1,
+		Ordered:    false,
+		Lineage:    NewLineageConfig(),
 		Processors: []processor.Config{},
 	}
 }
@@ -76,10 +104,43 @@ func SanitiseConfig(conf Config) (interface{}, error) {
 		procSlice = append(procSlice, procSanitised)
 	}
 	hashMap["processors"] = procSlice
+	if !conf.Lineage.Enabled {
+		delete(hashMap, "lineage")
+	}
 
 	return hashMap, nil
 }
 
+// NewLineageStamp creates a processor that stamps lineage metadata onto
+// messages, consisting of a hash of the processors of a pipeline config, the
+// version of the pipeline when set, the time at which messages were processed
+// and the instance ID.
+func NewLineageStamp(conf Config) (types.Processor, error) {
+	procSlice := []interface{}{}
+	for _, proc := range conf.Processors {
+		procSanitised, err := processor.SanitiseConfig(proc)
+		if err != nil {
+			return nil, err
+		}
+		procSlice = append(procSlice, procSanitised)
+	}
+	procBytes, err := json.Marshal(procSlice)
+	if err != nil {
+		return nil, err
+	}
+	procHash := sha256.Sum256(procBytes)
+
+	prefix := conf.Lineage.Prefix
+	fields := map[string]string{
+		prefix + "processors_hash": hex.EncodeToString(procHash[:]),
+		prefix + "instance_id":     lineage.InstanceID(),
+	}
+	if len(conf.Lineage.Version) > 0 {
+		fields[prefix+"processors_version"] = conf.Lineage.Version
+	}
+	return lineage.NewStamp(fields, prefix+"processed_at", true), nil
+}
+
 //------------------------------------------------------------------------------
 
 // New creates an input type based on an input configuration.
@@ -106,11 +167,21 @@ func New(
 				return nil, fmt.Errorf("failed to create processor: %v", err)
 			}
 		}
+		if conf.Lineage.Enabled {
+			stamp, err := NewLineageStamp(conf)
+			if err != nil {
+				return nil, fmt.Errorf("failed to create lineage stamp: %v", err)
+			}
+			processors = append(processors, stamp)
+		}
 		return NewProcessor(log, stats, processors...), nil
 	}
 	if conf.Threads <= 1 {
 		return procCtor()
 	}
+	if conf.Ordered {
+		return NewOrderedPool(procCtor, conf.Threads, log, stats)
+	}
 	return NewPool(procCtor, conf.Threads, log, stats)
 }
 
@@ -41,6 +41,7 @@ func TestSanitise(t *testing.T) {
 	var err error
 
 	exp := `{` +
+		`"ordered":false,` +
 		`"processors":[],` +
 		`"threads":10` +
 		`}`
@@ -60,6 +61,7 @@ func TestSanitise(t *testing.T) {
 	}
 
 	exp = `{` +
+		`"ordered":false,` +
 		`"processors":[` +
 		`{` +
 		`"type":"combine",` +
@@ -179,3 +181,46 @@ func TestProcCtor(t *testing.T) {
 		t.Error(err)
 	}
 }
+
+func TestLineage(t *testing.T) {
+	conf := NewConfig()
+	conf.Lineage.Enabled = true
+	conf.Lineage.Version = "v1"
+
+	procConf := processor.NewConfig()
+	procConf.Type = processor.TypeNoop
+	conf.Processors = append(conf.Processors, procConf)
+
+	stampA, err := NewLineageStamp(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msgs, res := stampA.ProcessMessage(message.New([][]byte{[]byte("foo")}))
+	if res != nil {
+		t.Fatal(res.Error())
+	}
+	meta := msgs[0].Get(0).Metadata()
+	if exp, act := "v1", meta.Get("lineage_processors_version"); exp != act {
+		t.Errorf("Wrong version: %v != %v", act, exp)
+	}
+	if _, err = time.Parse(time.RFC3339Nano, meta.Get("lineage_processed_at")); err != nil {
+		t.Errorf("Failed to parse processed_at: %v", err)
+	}
+	hashA := meta.Get("lineage_processors_hash")
+	if exp, act := 64, len(hashA); exp != act {
+		t.Errorf("Wrong hash length: %v != %v", act, exp)
+	}
+
+	procConf = processor.NewConfig()
+	procConf.Type = processor.TypeSplit
+	conf.Processors = append(conf.Processors, procConf)
+
+	stampB, err := NewLineageStamp(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msgs, _ = stampB.ProcessMessage(message.New([][]byte{[]byte("foo")}))
+	if hashB := msgs[0].Get(0).Metadata().Get("lineage_processors_hash"); hashA == hashB {
+		t.Errorf("Hash did not change with processors: %v", hashB)
+	}
+}
@@ -0,0 +1,297 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package pipeline
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/log"
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+)
+
+//------------------------------------------------------------------------------
+
+// NewOrderedPool returns a new pipeline pool that utilises multiple processor
+// threads, where the resulting messages are sent on in the same order as the
+// messages they were derived from were received.
+//
+// Each message received is given a sequence number, and the results of
+// processing a message are held back until all messages with a lower sequence
+// number have finished being processed and their results sent on. This means a
+// slow message will delay the results of messages that follow it.
+func NewOrderedPool(
+	constructor types.PipelineConstructorFunc,
+	threads int,
+	log log.Modular,
+	stats metrics.Type,
+) (*Pool, error) {
+	p, err := NewPool(constructor, threads, log, stats)
+	if err != nil {
+		return nil, err
+	}
+	p.ordered = true
+	return p, nil
+}
+
+//------------------------------------------------------------------------------
+
+// sequencedTransaction is a transaction tagged with its sequence number.
+type sequencedTransaction struct {
+	seq  uint64
+	tran types.Transaction
+}
+
+// orderedEvent is sent to the ordering loop whenever a worker produces a
+// result for a sequence number, or when a sequence number is known to have no
+// further results.
+type orderedEvent struct {
+	seq  uint64
+	tran *types.Transaction
+}
+
+// orderedLoop is the processing loop of an ordered pipeline pool.
+func (p *Pool) orderedLoop() {
+	var (
+		mHeld     = p.stats.GetGauge("pipeline.ordered.held")
+		mReleased = p.stats.GetCounter("pipeline.ordered.released")
+	)
+
+	defer func() {
+		atomic.StoreUint32(&p.running, 0)
+
+		for _, worker := range p.workers {
+			worker.CloseAsync()
+		}
+		for _, worker := range p.workers {
+			err := worker.WaitForClose(time.Second)
+			for err != nil {
+				err = worker.WaitForClose(time.Second)
+			}
+		}
+
+		close(p.messagesOut)
+		close(p.closed)
+	}()
+
+	sequenced := make(chan sequencedTransaction)
+	events := make(chan orderedEvent)
+
+	// The events channel is closed once all workers have stopped and all
+	// pending responses have been received.
+	workersWG := sync.WaitGroup{}
+	pendingWG := sync.WaitGroup{}
+
+	sendEvent := func(e orderedEvent) bool {
+		select {
+		case events <- e:
+			return true
+		case <-p.closeChan:
+			return false
+		}
+	}
+
+	workersIn := make([]chan types.Transaction, 0, len(p.workers))
+	workers := make([]types.Pipeline, 0, len(p.workers))
+	for _, worker := range p.workers {
+		workerIn := make(chan types.Transaction)
+		if err := worker.Consume(workerIn); err != nil {
+			p.log.Errorf("Failed to start pipeline worker: %v\n", err)
+			continue
+		}
+		workersIn = append(workersIn, workerIn)
+		workers = append(workers, worker)
+	}
+	if len(workers) == 0 {
+		return
+	}
+
+	// Assign a sequence number to each incoming transaction, and intercept
+	// its response so that we know when it has been fully processed.
+	go func() {
+		defer close(sequenced)
+
+		var seq uint64
+		for {
+			var t types.Transaction
+			var open bool
+			select {
+			case t, open = <-p.messagesIn:
+				if !open {
+					return
+				}
+			case <-p.closeChan:
+				return
+			}
+
+			resChan := make(chan types.Response)
+			pendingWG.Add(1)
+			go func(seq uint64, resChanIn <-chan types.Response, resChanOut chan<- types.Response) {
+				defer pendingWG.Done()
+
+				var res types.Response
+				select {
+				case res = <-resChanIn:
+				case <-p.closeChan:
+					return
+				}
+				select {
+				case resChanOut <- res:
+				case <-p.closeChan:
+					return
+				}
+				sendEvent(orderedEvent{seq: seq})
+			}(seq, resChan, t.ResponseChan)
+
+			select {
+			case sequenced <- sequencedTransaction{
+				seq:  seq,
+				tran: types.NewTransaction(t.Payload, resChan),
+			}:
+			case <-p.closeChan:
+				return
+			}
+			seq++
+		}
+	}()
+
+	// Each worker is fed by a goroutine that both sends it transactions and
+	// reads its results, which means results can be attributed to the sequence
+	// number of the transaction that the worker is currently processing. Once
+	// a worker accepts a new transaction it has produced all results of the
+	// previous one.
+	workersWG.Add(len(workers))
+	for i, worker := range workers {
+		go func(w types.Pipeline, workerIn chan types.Transaction) {
+			defer workersWG.Done()
+
+			var cur uint64
+			var hasCur bool
+			var next *sequencedTransaction
+
+			sequencedChan := (<-chan sequencedTransaction)(sequenced)
+			for {
+				var inChan chan<- types.Transaction
+				var nextTran types.Transaction
+				var fetchChan <-chan sequencedTransaction
+				if next != nil {
+					inChan, nextTran = workerIn, next.tran
+				} else {
+					fetchChan = sequencedChan
+				}
+
+				select {
+				case st, open := <-fetchChan:
+					if !open {
+						close(workerIn)
+						sequencedChan = nil
+						continue
+					}
+					next = &st
+				case inChan <- nextTran:
+					if hasCur && !sendEvent(orderedEvent{seq: cur}) {
+						return
+					}
+					cur, hasCur, next = next.seq, true, nil
+				case t, open := <-w.TransactionChan():
+					if !open {
+						return
+					}
+					if !sendEvent(orderedEvent{seq: cur, tran: &t}) {
+						return
+					}
+				case <-p.closeChan:
+					return
+				}
+			}
+		}(worker, workersIn[i])
+	}
+	go func() {
+		workersWG.Wait()
+		pendingWG.Wait()
+		close(events)
+	}()
+
+	var head uint64
+	held := map[uint64][]types.Transaction{}
+	done := map[uint64]struct{}{}
+	heldCount := 0
+
+	release := func(t types.Transaction) bool {
+		select {
+		case p.messagesOut <- t:
+		case <-p.closeChan:
+			return false
+		}
+		mReleased.Incr(1)
+		return true
+	}
+
+	for {
+		var e orderedEvent
+		var open bool
+		select {
+		case e, open = <-events:
+			if !open {
+				return
+			}
+		case <-p.closeChan:
+			return
+		}
+
+		if e.seq < head {
+			continue
+		}
+		if e.tran != nil {
+			if e.seq == head {
+				if !release(*e.tran) {
+					return
+				}
+			} else {
+				held[e.seq] = append(held[e.seq], *e.tran)
+				heldCount++
+				mHeld.Set(int64(heldCount))
+			}
+			continue
+		}
+
+		done[e.seq] = struct{}{}
+		for {
+			if _, exists := done[head]; !exists {
+				break
+			}
+			delete(done, head)
+			head++
+			for _, t := range held[head] {
+				if !release(t) {
+					return
+				}
+				heldCount--
+			}
+			delete(held, head)
+			mHeld.Set(int64(heldCount))
+		}
+	}
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,211 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package pipeline
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/log"
+	"github.com/Jeffail/benthos/lib/message"
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/response"
+	"github.com/Jeffail/benthos/lib/types"
+)
+
+// mockSlowProcessor delays each message by a random period, drops messages
+// containing "drop", and splits messages containing "split".
+type mockSlowProcessor struct{}
+
+func (m mockSlowProcessor) ProcessMessage(msg types.Message) ([]types.Message, types.Response) {
+	<-time.After(time.Duration(rand.Intn(5)) * time.Millisecond)
+	content := string(msg.Get(0).Get())
+	switch content {
+	case "drop":
+		return nil, response.NewAck()
+	case "split":
+		return []types.Message{
+			message.New([][]byte{[]byte("split")}),
+			message.New([][]byte{[]byte("split")}),
+		}, nil
+	}
+	return []types.Message{msg}, nil
+}
+
+func TestOrderedPool(t *testing.T) {
+	constr := func() (types.Pipeline, error) {
+		return NewProcessor(log.Noop(), metrics.Noop(), mockSlowProcessor{}), nil
+	}
+
+	proc, err := NewOrderedPool(constr, 8, log.Noop(), metrics.Noop())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tChan := make(chan types.Transaction)
+	if err = proc.Consume(tChan); err != nil {
+		t.Fatal(err)
+	}
+
+	var inputs []string
+	for i := 0; i < 200; i++ {
+		switch {
+		case i%17 == 0:
+			inputs = append(inputs, "drop")
+		case i%23 == 0:
+			inputs = append(inputs, "split")
+		default:
+			inputs = append(inputs, strconv.Itoa(i))
+		}
+	}
+
+	resChan := make(chan types.Response, len(inputs))
+	go func() {
+		for _, in := range inputs {
+			select {
+			case tChan <- types.NewTransaction(message.New([][]byte{[]byte(in)}), resChan):
+			case <-time.After(time.Second * 5):
+				t.Error("Timed out")
+				return
+			}
+		}
+		close(tChan)
+	}()
+
+	var expected []string
+	for _, in := range inputs {
+		switch in {
+		case "drop":
+		case "split":
+			expected = append(expected, in, in)
+		default:
+			expected = append(expected, in)
+		}
+	}
+
+	for i, exp := range expected {
+		select {
+		case tran, open := <-proc.TransactionChan():
+			if !open {
+				t.Fatal("Closed early")
+			}
+			if act := string(tran.Payload.Get(0).Get()); act != exp {
+				t.Fatalf("Wrong message at index %v: %v != %v", i, act, exp)
+			}
+			go func(resChan chan<- types.Response) {
+				resChan <- response.NewAck()
+			}(tran.ResponseChan)
+		case <-time.After(time.Second * 5):
+			t.Fatal("Timed out")
+		}
+	}
+
+	for range inputs {
+		select {
+		case res := <-resChan:
+			if res.Error() != nil {
+				t.Error(res.Error())
+			}
+		case <-time.After(time.Second * 5):
+			t.Fatal("Timed out")
+		}
+	}
+
+	select {
+	case _, open := <-proc.TransactionChan():
+		if open {
+			t.Error("Expected pool to close")
+		}
+	case <-time.After(time.Second * 5):
+		t.Fatal("Timed out")
+	}
+	if err = proc.WaitForClose(time.Second * 5); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestOrderedPoolErrors(t *testing.T) {
+	constr := func() (types.Pipeline, error) {
+		return NewProcessor(log.Noop(), metrics.Noop(), mockSlowProcessor{}), nil
+	}
+
+	proc, err := NewOrderedPool(constr, 4, log.Noop(), metrics.Noop())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tChan := make(chan types.Transaction)
+	if err = proc.Consume(tChan); err != nil {
+		t.Fatal(err)
+	}
+
+	resChans := make([]chan types.Response, 3)
+	for i := range resChans {
+		resChans[i] = make(chan types.Response, 1)
+		select {
+		case tChan <- types.NewTransaction(message.New([][]byte{[]byte(fmt.Sprintf("foo%v", i))}), resChans[i]):
+		case <-time.After(time.Second * 5):
+			t.Fatal("Timed out")
+		}
+	}
+
+	// An error response for one message should only be returned to the source
+	// of that message.
+	expErr := errors.New("test err")
+	for i := range resChans {
+		select {
+		case tran := <-proc.TransactionChan():
+			if exp, act := fmt.Sprintf("foo%v", i), string(tran.Payload.Get(0).Get()); exp != act {
+				t.Errorf("Wrong message: %v != %v", act, exp)
+			}
+			var res types.Response = response.NewAck()
+			if i == 1 {
+				res = response.NewError(expErr)
+			}
+			tran.ResponseChan <- res
+		case <-time.After(time.Second * 5):
+			t.Fatal("Timed out")
+		}
+	}
+
+	for i, resChan := range resChans {
+		select {
+		case res := <-resChan:
+			if i == 1 && res.Error() != expErr {
+				t.Errorf("Wrong response: %v != %v", res.Error(), expErr)
+			}
+			if i != 1 && res.Error() != nil {
+				t.Errorf("Unexpected error: %v", res.Error())
+			}
+		case <-time.After(time.Second * 5):
+			t.Fatal("Timed out")
+		}
+	}
+
+	proc.CloseAsync()
+	if err = proc.WaitForClose(time.Second * 5); err != nil {
+		t.Error(err)
+	}
+}
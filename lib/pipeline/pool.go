@@ -16,6 +16,7 @@ import (
 // response channel in the transaction.
 type Pool struct {
 	running uint32
+	ordered bool
 
 	workers []types.Pipeline
 
@@ -141,7 +142,11 @@ func (p *Pool) Consume(msgs <-chan types.Transaction) error {
 		return types.ErrAlreadyStarted
 	}
 	p.messagesIn = msgs
-	go p.loop()
+	if p.ordered {
+		go p.orderedLoop()
+	} else {
+		go p.loop()
+	}
 	return nil
 }
 
@@ -44,6 +44,7 @@ type Processor struct {
 	stats metrics.Type
 
 	msgProcessors []types.Processor
+	observers     []types.ResponseObserver
 
 	messagesOut chan types.Transaction
 	responsesIn chan types.Response
@@ -63,9 +64,16 @@ func NewProcessor(
 	stats metrics.Type,
 	msgProcessors ...types.Processor,
 ) *Processor {
+	var observers []types.ResponseObserver
+	for _, proc := range msgProcessors {
+		if o, ok := proc.(types.ResponseObserver); ok {
+			observers = append(observers, o)
+		}
+	}
 	return &Processor{
 		running:       1,
 		msgProcessors: msgProcessors,
+		observers:     observers,
 		log:           log.NewModule(".pipeline.processor"),
 		stats:         stats,
 		mSndSucc:      stats.GetCounter("pipeline.processor.send.success"),
@@ -89,32 +97,60 @@ func (p *Processor) loop() {
 	var (
 		mProcCount   = p.stats.GetCounter("pipeline.processor.count")
 		mProcDropped = p.stats.GetCounter("pipeline.processor.dropped")
+		mProcLatency = p.stats.GetTimer("pipeline.processor.latency")
 	)
 
+	// Processors that buffer messages are flushed by a timer once their period
+	// has passed, as otherwise they are only checked when messages arrive.
+	var flushTimer *time.Timer
+	var flushChan <-chan time.Time
+	resetFlush := func() {
+		if flushTimer != nil && !flushTimer.Stop() {
+			select {
+			case <-flushTimer.C:
+			default:
+			}
+		}
+		flushChan = nil
+		if tNext := p.untilFlush(); tNext >= 0 {
+			if flushTimer == nil {
+				flushTimer = time.NewTimer(tNext)
+			} else {
+				flushTimer.Reset(tNext)
+			}
+			flushChan = flushTimer.C
+		}
+	}
+	defer func() {
+		if flushTimer != nil {
+			flushTimer.Stop()
+		}
+	}()
+
 	var open bool
 	for atomic.LoadInt32(&p.running) == 1 {
+		resetFlush()
+
 		var tran types.Transaction
 		select {
 		case tran, open = <-p.messagesIn:
 			if !open {
 				return
 			}
+		case <-flushChan:
+			flushChan = nil
+			if flushed := p.flush(); len(flushed) > 0 {
+				p.dispatchMessages(flushed, make(chan types.Response, 1))
+			}
+			continue
 		case <-p.closeChan:
 			return
 		}
 		mProcCount.Incr(1)
 
-		resultMsgs := []types.Message{tran.Payload}
-		var resultRes types.Response
-		for i := 0; len(resultMsgs) > 0 && i < len(p.msgProcessors); i++ {
-			var nextResultMsgs []types.Message
-			for _, m := range resultMsgs {
-				var rMsgs []types.Message
-				rMsgs, resultRes = p.msgProcessors[i].ProcessMessage(m)
-				nextResultMsgs = append(nextResultMsgs, rMsgs...)
-			}
-			resultMsgs = nextResultMsgs
-		}
+		started := time.Now()
+		resultMsgs, resultRes := p.process([]types.Message{tran.Payload}, 0)
+		mProcLatency.Timing(time.Since(started).Nanoseconds())
 
 		if len(resultMsgs) == 0 {
 			mProcDropped.Incr(1)
@@ -129,8 +165,12 @@ func (p *Processor) loop() {
 		if len(resultMsgs) > 1 {
 			p.dispatchMessages(resultMsgs, tran.ResponseChan)
 		} else {
+			resChan := tran.ResponseChan
+			if len(p.observers) > 0 {
+				resChan = p.observe(resChan)
+			}
 			select {
-			case p.messagesOut <- types.NewTransaction(resultMsgs[0], tran.ResponseChan):
+			case p.messagesOut <- types.NewTransaction(resultMsgs[0], resChan):
 			case <-p.closeChan:
 				return
 			}
@@ -138,6 +178,86 @@ func (p *Processor) loop() {
 	}
 }
 
+// process applies the processors of the pipeline, starting from an index, to a
+// slice of messages.
+func (p *Processor) process(msgs []types.Message, from int) ([]types.Message, types.Response) {
+	var res types.Response
+	for i := from; len(msgs) > 0 && i < len(p.msgProcessors); i++ {
+		var nextMsgs []types.Message
+		for _, m := range msgs {
+			var rMsgs []types.Message
+			rMsgs, res = p.msgProcessors[i].ProcessMessage(m)
+			nextMsgs = append(nextMsgs, rMsgs...)
+		}
+		msgs = nextMsgs
+	}
+	return msgs, res
+}
+
+// untilFlush returns the shortest duration until a processor of the pipeline
+// should be flushed, or a negative duration if none need flushing.
+func (p *Processor) untilFlush() time.Duration {
+	next := time.Duration(-1)
+	for _, proc := range p.msgProcessors {
+		f, ok := proc.(types.Flusher)
+		if !ok {
+			continue
+		}
+		if tNext := f.UntilFlush(); tNext >= 0 && (next < 0 || tNext < next) {
+			next = tNext
+		}
+	}
+	return next
+}
+
+// flush collects the buffered messages of any processors that are due to be
+// flushed and applies the remaining processors of the pipeline to them.
+func (p *Processor) flush() []types.Message {
+	var flushed []types.Message
+	for i, proc := range p.msgProcessors {
+		f, ok := proc.(types.Flusher)
+		if !ok {
+			continue
+		}
+		if tNext := f.UntilFlush(); tNext < 0 || tNext > 0 {
+			continue
+		}
+		msgs, _ := p.process(f.Flush(), i+1)
+		flushed = append(flushed, msgs...)
+	}
+	return flushed
+}
+
+// observe returns a response channel that passes responses to the processors
+// of the pipeline that observe them before forwarding them to a destination
+// channel.
+func (p *Processor) observe(resChan chan<- types.Response) chan<- types.Response {
+	obsChan := make(chan types.Response, 1)
+	started := time.Now()
+	go func() {
+		var res types.Response
+		select {
+		case res = <-obsChan:
+		case <-p.closeChan:
+			return
+		}
+		p.notifyObservers(res, time.Since(started))
+		select {
+		case resChan <- res:
+		case <-p.closeChan:
+		}
+	}()
+	return obsChan
+}
+
+// notifyObservers passes a response to the processors of the pipeline that
+// observe them.
+func (p *Processor) notifyObservers(res types.Response, latency time.Duration) {
+	for _, o := range p.observers {
+		o.ObserveResponse(res, latency)
+	}
+}
+
 // dispatchMessages attempts to send a multiple messages results of processors
 // over the shared messages channel. This send is retried until success.
 func (p *Processor) dispatchMessages(msgs []types.Message, ogResChan chan<- types.Response) {
@@ -149,6 +269,7 @@ func (p *Processor) dispatchMessages(msgs []types.Message, ogResChan chan<- type
 		transac := types.NewTransaction(m, resChan)
 
 		for {
+			started := time.Now()
 			select {
 			case p.messagesOut <- transac:
 			case <-p.closeChan:
@@ -165,6 +286,7 @@ func (p *Processor) dispatchMessages(msgs []types.Message, ogResChan chan<- type
 			case <-p.closeChan:
 				return
 			}
+			p.notifyObservers(res, time.Since(started))
 
 			if skipAck := res.SkipAck(); res.Error() == nil || skipAck {
 				if skipAck {
@@ -369,3 +369,168 @@ func TestProcessorMultiMsgsOddSync(t *testing.T) {
 		t.Error(err)
 	}
 }
+
+type mockFlushProcessor struct {
+	period  time.Duration
+	added   time.Time
+	pending []types.Part
+}
+
+func (m *mockFlushProcessor) ProcessMessage(msg types.Message) ([]types.Message, types.Response) {
+	if len(m.pending) == 0 {
+		m.added = time.Now()
+	}
+	msg.Iter(func(i int, p types.Part) error {
+		m.pending = append(m.pending, p.Copy())
+		return nil
+	})
+	return nil, response.NewUnack()
+}
+
+func (m *mockFlushProcessor) UntilFlush() time.Duration {
+	if len(m.pending) == 0 {
+		return -1
+	}
+	if tNext := time.Until(m.added.Add(m.period)); tNext > 0 {
+		return tNext
+	}
+	return 0
+}
+
+func (m *mockFlushProcessor) Flush() []types.Message {
+	newMsg := message.New(nil)
+	newMsg.Append(m.pending...)
+	m.pending = nil
+	return []types.Message{newMsg}
+}
+
+type mockAppendProcessor struct{}
+
+func (m mockAppendProcessor) ProcessMessage(msg types.Message) ([]types.Message, types.Response) {
+	newMsg := msg.Copy()
+	newMsg.Append(message.NewPart([]byte("appended")))
+	return []types.Message{newMsg}, nil
+}
+
+func TestProcessorPipelineFlush(t *testing.T) {
+	proc := NewProcessor(
+		log.Noop(), metrics.Noop(),
+		&mockFlushProcessor{period: time.Millisecond * 50},
+		mockAppendProcessor{},
+	)
+
+	tChan, resChan := make(chan types.Transaction), make(chan types.Response)
+	if err := proc.Consume(tChan); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, p := range []string{"foo", "bar"} {
+		select {
+		case tChan <- types.NewTransaction(message.New([][]byte{[]byte(p)}), resChan):
+		case <-time.After(time.Second):
+			t.Fatal("Timed out")
+		}
+		select {
+		case res := <-resChan:
+			if !res.SkipAck() {
+				t.Error("Expected unack response")
+			}
+		case <-time.After(time.Second):
+			t.Fatal("Timed out")
+		}
+	}
+
+	// The batch should be flushed without any further messages.
+	var procT types.Transaction
+	select {
+	case procT = <-proc.TransactionChan():
+	case <-time.After(time.Second):
+		t.Fatal("Timed out waiting for flushed batch")
+	}
+
+	exp := [][]byte{[]byte("foo"), []byte("bar"), []byte("appended")}
+	if act := message.GetAllBytes(procT.Payload); !reflect.DeepEqual(exp, act) {
+		t.Errorf("Wrong flushed batch: %s != %s", act, exp)
+	}
+
+	select {
+	case procT.ResponseChan <- response.NewAck():
+	case <-time.After(time.Second):
+		t.Fatal("Timed out")
+	}
+
+	select {
+	case <-proc.TransactionChan():
+		t.Error("Unexpected second flush")
+	case <-time.After(time.Millisecond * 100):
+	}
+
+	proc.CloseAsync()
+	if err := proc.WaitForClose(time.Second); err != nil {
+		t.Error(err)
+	}
+}
+
+type mockObserverProcessor struct {
+	observed chan error
+}
+
+func (m *mockObserverProcessor) ProcessMessage(msg types.Message) ([]types.Message, types.Response) {
+	return []types.Message{msg}, nil
+}
+
+func (m *mockObserverProcessor) ObserveResponse(res types.Response, latency time.Duration) {
+	m.observed <- res.Error()
+}
+
+func TestProcessorPipelineObserver(t *testing.T) {
+	obs := &mockObserverProcessor{observed: make(chan error, 1)}
+	proc := NewProcessor(log.Noop(), metrics.Noop(), obs)
+
+	tChan, resChan := make(chan types.Transaction), make(chan types.Response)
+	if err := proc.Consume(tChan); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case tChan <- types.NewTransaction(message.New([][]byte{[]byte("foo")}), resChan):
+	case <-time.After(time.Second):
+		t.Fatal("Timed out")
+	}
+
+	var procT types.Transaction
+	select {
+	case procT = <-proc.TransactionChan():
+	case <-time.After(time.Second):
+		t.Fatal("Timed out")
+	}
+
+	select {
+	case procT.ResponseChan <- response.NewError(errMockProc):
+	case <-time.After(time.Second):
+		t.Fatal("Timed out")
+	}
+
+	select {
+	case err := <-obs.observed:
+		if err != errMockProc {
+			t.Errorf("Wrong observed response: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Timed out waiting for observed response")
+	}
+
+	select {
+	case res := <-resChan:
+		if res.Error() != errMockProc {
+			t.Errorf("Wrong forwarded response: %v", res.Error())
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Timed out waiting for forwarded response")
+	}
+
+	proc.CloseAsync()
+	if err := proc.WaitForClose(time.Second); err != nil {
+		t.Error(err)
+	}
+}
@@ -33,6 +33,7 @@ import (
 	"github.com/Jeffail/benthos/lib/metrics"
 	"github.com/Jeffail/benthos/lib/response"
 	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/pool"
 	"github.com/Jeffail/benthos/lib/util/text"
 )
 
@@ -79,7 +80,7 @@ type archiveFunc func(hFunc headerFunc, msg types.Message) (types.Part, error)
 type headerFunc func(body types.Part) os.FileInfo
 
 func tarArchive(hFunc headerFunc, msg types.Message) (types.Part, error) {
-	buf := &bytes.Buffer{}
+	buf := pool.GetBuffer()
 	tw := tar.NewWriter(buf)
 
 	// Iterate through the parts of the message.
@@ -99,14 +100,15 @@ func tarArchive(hFunc headerFunc, msg types.Message) (types.Part, error) {
 	tw.Close()
 
 	if err != nil {
+		pool.PutBuffer(buf)
 		return nil, err
 	}
-	return message.NewPart(buf.Bytes()).
+	return message.NewPart(pool.Detach(buf)).
 		SetMetadata(msg.Get(0).Metadata().Copy()), nil
 }
 
 func zipArchive(hFunc headerFunc, msg types.Message) (types.Part, error) {
-	buf := &bytes.Buffer{}
+	buf := pool.GetBuffer()
 	zw := zip.NewWriter(buf)
 
 	// Iterate through the parts of the message.
@@ -129,9 +131,10 @@ func zipArchive(hFunc headerFunc, msg types.Message) (types.Part, error) {
 	zw.Close()
 
 	if err != nil {
+		pool.PutBuffer(buf)
 		return nil, err
 	}
-	return message.NewPart(buf.Bytes()).
+	return message.NewPart(pool.Detach(buf)).
 		SetMetadata(msg.Get(0).Metadata().Copy()), nil
 }
 
@@ -177,7 +180,7 @@ type Archive struct {
 
 	mCount   metrics.StatCounter
 	mSkipped metrics.StatCounter
-	mErr     metrics.StatCounter
+	mErr     *errorStats
 	mSucc    metrics.StatCounter
 	mSent    metrics.StatCounter
 
@@ -207,7 +210,7 @@ func NewArchive(
 
 		mCount:   stats.GetCounter("processor.archive.count"),
 		mSkipped: stats.GetCounter("processor.archive.skipped"),
-		mErr:     stats.GetCounter("processor.archive.error"),
+		mErr:     newErrorStats("archive", stats),
 		mSucc:    stats.GetCounter("processor.archive.success"),
 		mSent:    stats.GetCounter("processor.archive.sent"),
 	}, nil
@@ -269,7 +272,7 @@ func (d *Archive) ProcessMessage(msg types.Message) ([]types.Message, types.Resp
 	newPart, err := d.archive(d.createHeaderFunc(msg), msg)
 	if err != nil {
 		d.log.Errorf("Failed to create archive: %v\n", err)
-		d.mErr.Incr(1)
+		d.mErr.IncrErr(err)
 		return nil, response.NewAck()
 	}
 
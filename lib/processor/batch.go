@@ -24,7 +24,7 @@ import (
 	"time"
 
 	"github.com/Jeffail/benthos/lib/log"
-	"github.com/Jeffail/benthos/lib/message"
+	"github.com/Jeffail/benthos/lib/message/batch"
 	"github.com/Jeffail/benthos/lib/metrics"
 	"github.com/Jeffail/benthos/lib/processor/condition"
 	"github.com/Jeffail/benthos/lib/response"
@@ -55,9 +55,16 @@ preserving at-least-once delivery guarantees.
 
 The ` + "`period_ms`" + ` field - when greater than zero - defines a period in
 milliseconds whereby a batch is sent even if the ` + "`byte_size`" + ` has not
-yet been reached. Batch parameters are only triggered when a message is added,
-meaning a pending batch can last beyond this period if no messages are added
-since the period was reached.
+yet been reached. When the batch processor is one of the processors of a
+pipeline, or of an input or output, a pending batch is sent once this period
+has passed even if no further messages are received. The messages of a batch
+sent this way are acknowledged along with the next message received. Batch
+processors nested within other processors, such as
+` + "[`process_batch`](#process_batch)" + `, only check the period when a
+message is added.
+
+The ` + "`condition`" + ` field can be used to send a batch as soon as a message
+matching it is added, for example a message marking the end of a transaction.
 
 When a batch is sent to an output the behaviour will differ depending on the
 protocol. If the output type supports multipart messages then the batch is sent
@@ -87,24 +94,11 @@ unexpected behaviour and message ordering.`,
 //------------------------------------------------------------------------------
 
 // BatchConfig contains configuration fields for the Batch processor.
-type BatchConfig struct {
-	ByteSize  int              `json:"byte_size" yaml:"byte_size"`
-	Count     int              `json:"count" yaml:"count"`
-	Condition condition.Config `json:"condition" yaml:"condition"`
-	PeriodMS  int              `json:"period_ms" yaml:"period_ms"`
-}
+type BatchConfig batch.PolicyConfig
 
 // NewBatchConfig returns a BatchConfig with default values.
 func NewBatchConfig() BatchConfig {
-	cond := condition.NewConfig()
-	cond.Type = "static"
-	cond.Static = false
-	return BatchConfig{
-		ByteSize:  0,
-		Count:     0,
-		Condition: cond,
-		PeriodMS:  0,
-	}
+	return BatchConfig(batch.NewPolicyConfig())
 }
 
 //------------------------------------------------------------------------------
@@ -122,23 +116,13 @@ type Batch struct {
 	log   log.Modular
 	stats metrics.Type
 
-	byteSize  int
-	count     int
-	period    time.Duration
-	cond      condition.Type
-	sizeTally int
-	parts     []types.Part
-
-	lastBatch time.Time
-
-	mCount       metrics.StatCounter
-	mSent        metrics.StatCounter
-	mSentParts   metrics.StatCounter
-	mSizeBatch   metrics.StatCounter
-	mCountBatch  metrics.StatCounter
-	mPeriodBatch metrics.StatCounter
-	mCondBatch   metrics.StatCounter
-	mDropped     metrics.StatCounter
+	policy *batch.Policy
+	period time.Duration
+
+	mCount     metrics.StatCounter
+	mSent      metrics.StatCounter
+	mSentParts metrics.StatCounter
+	mDropped   metrics.StatCounter
 }
 
 // NewBatch returns a Batch processor.
@@ -146,7 +130,9 @@ func NewBatch(
 	conf Config, mgr types.Manager, log log.Modular, stats metrics.Type,
 ) (Type, error) {
 	logger := log.NewModule(".processor.batch")
-	cond, err := condition.New(conf.Batch.Condition, mgr, logger, metrics.Namespaced(stats, "processor.batch"))
+	policy, err := batch.NewPolicy(
+		batch.PolicyConfig(conf.Batch), mgr, logger, metrics.Namespaced(stats, "processor"),
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -157,23 +143,15 @@ func NewBatch(
 			" period_ms cap. It's possible that this batch will never resolve.")
 	}
 	return &Batch{
-		log:      logger,
-		stats:    stats,
-		byteSize: conf.Batch.ByteSize,
-		count:    conf.Batch.Count,
-		period:   time.Duration(conf.Batch.PeriodMS) * time.Millisecond,
-		cond:     cond,
-
-		lastBatch: time.Now(),
-
-		mCount:       stats.GetCounter("processor.batch.count"),
-		mSizeBatch:   stats.GetCounter("processor.batch.on_size"),
-		mCountBatch:  stats.GetCounter("processor.batch.on_count"),
-		mPeriodBatch: stats.GetCounter("processor.batch.on_period"),
-		mCondBatch:   stats.GetCounter("processor.batch.on_condition"),
-		mSent:        stats.GetCounter("processor.batch.sent"),
-		mSentParts:   stats.GetCounter("processor.batch.parts.sent"),
-		mDropped:     stats.GetCounter("processor.batch.dropped"),
+		log:    logger,
+		stats:  stats,
+		policy: policy,
+		period: time.Duration(conf.Batch.PeriodMS) * time.Millisecond,
+
+		mCount:     stats.GetCounter("processor.batch.count"),
+		mSent:      stats.GetCounter("processor.batch.sent"),
+		mSentParts: stats.GetCounter("processor.batch.parts.sent"),
+		mDropped:   stats.GetCounter("processor.batch.dropped"),
 	}, nil
 }
 
@@ -184,44 +162,8 @@ func NewBatch(
 func (c *Batch) ProcessMessage(msg types.Message) ([]types.Message, types.Response) {
 	c.mCount.Incr(1)
 
-	// Add new parts to the buffer.
-	msg.Iter(func(i int, b types.Part) error {
-		c.sizeTally += len(b.Get())
-		c.parts = append(c.parts, b.Copy())
-		return nil
-	})
-
-	batch := false
-	if !batch && c.count > 0 && len(c.parts) >= c.count {
-		batch = true
-		c.mCountBatch.Incr(1)
-		c.log.Traceln("Batching based on count")
-	}
-	if !batch && c.byteSize > 0 && c.sizeTally >= c.byteSize {
-		batch = true
-		c.mSizeBatch.Incr(1)
-		c.log.Traceln("Batching based on byte_size")
-	}
-	if !batch && c.period > 0 && time.Since(c.lastBatch) > c.period {
-		batch = true
-		c.mPeriodBatch.Incr(1)
-		c.log.Traceln("Batching based on period_ms")
-	}
-	if !batch && c.cond.Check(msg) {
-		batch = true
-		c.mCondBatch.Incr(1)
-		c.log.Traceln("Batching based on condition")
-	}
-
-	// If we have reached our target count of parts in the buffer.
-	if batch {
-		newMsg := message.New(nil)
-		newMsg.Append(c.parts...)
-
-		c.parts = nil
-		c.sizeTally = 0
-		c.lastBatch = time.Now()
-
+	if c.policy.Add(msg) {
+		newMsg := c.policy.Flush()
 		c.mSentParts.Incr(int64(newMsg.Len()))
 		c.mSent.Incr(1)
 		msgs := [1]types.Message{newMsg}
@@ -233,4 +175,29 @@ func (c *Batch) ProcessMessage(msg types.Message) ([]types.Message, types.Respon
 	return nil, response.NewUnack()
 }
 
+// UntilFlush returns the duration until a pending batch should be sent due to
+// the configured period, or a negative duration if there is nothing to send or
+// a period has not been set.
+func (c *Batch) UntilFlush() time.Duration {
+	if c.period <= 0 || c.policy.Count() == 0 {
+		return -1
+	}
+	if tNext := c.policy.UntilNext(); tNext > 0 {
+		return tNext
+	}
+	return 0
+}
+
+// Flush returns the pending batch, if any.
+func (c *Batch) Flush() []types.Message {
+	newMsg := c.policy.Flush()
+	if newMsg == nil {
+		return nil
+	}
+	c.log.Traceln("Flushing pending batch")
+	c.mSentParts.Incr(int64(newMsg.Len()))
+	c.mSent.Incr(1)
+	return []types.Message{newMsg}
+}
+
 //------------------------------------------------------------------------------
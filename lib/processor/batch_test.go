@@ -24,11 +24,13 @@ import (
 	"os"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/Jeffail/benthos/lib/log"
 	"github.com/Jeffail/benthos/lib/message"
 	"github.com/Jeffail/benthos/lib/metrics"
 	"github.com/Jeffail/benthos/lib/processor/condition"
+	"github.com/Jeffail/benthos/lib/types"
 )
 
 func TestBatchBasic(t *testing.T) {
@@ -283,3 +285,53 @@ func TestBatchCondition(t *testing.T) {
 		t.Errorf("Wrong batch contents: %s != %s", act, exp)
 	}
 }
+
+func TestBatchFlush(t *testing.T) {
+	conf := NewConfig()
+	conf.Batch.Count = 10
+	conf.Batch.PeriodMS = 50
+
+	proc, err := NewBatch(conf, nil, log.Noop(), metrics.Noop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	flusher, ok := proc.(types.Flusher)
+	if !ok {
+		t.Fatal("Batch processor does not implement Flusher")
+	}
+
+	if tNext := flusher.UntilFlush(); tNext >= 0 {
+		t.Errorf("Expected no flush when empty: %v", tNext)
+	}
+
+	<-time.After(time.Millisecond * 60)
+	if tNext := flusher.UntilFlush(); tNext >= 0 {
+		t.Errorf("Expected no flush when empty after period: %v", tNext)
+	}
+	if msgs := flusher.Flush(); len(msgs) != 0 {
+		t.Errorf("Unexpected flushed messages: %v", len(msgs))
+	}
+
+	if msgs, _ := proc.ProcessMessage(message.New([][]byte{[]byte("foo")})); len(msgs) != 0 {
+		t.Fatal("Unexpected batch")
+	}
+	if tNext := flusher.UntilFlush(); tNext <= 0 || tNext > time.Millisecond*50 {
+		t.Errorf("Wrong flush duration: %v", tNext)
+	}
+
+	<-time.After(time.Millisecond * 60)
+	if tNext := flusher.UntilFlush(); tNext != 0 {
+		t.Errorf("Expected overdue flush: %v", tNext)
+	}
+
+	msgs := flusher.Flush()
+	if len(msgs) != 1 {
+		t.Fatalf("Wrong count of flushed messages: %v", len(msgs))
+	}
+	if exp, act := [][]byte{[]byte("foo")}, message.GetAllBytes(msgs[0]); !reflect.DeepEqual(exp, act) {
+		t.Errorf("Wrong flushed batch: %s != %s", act, exp)
+	}
+	if tNext := flusher.UntilFlush(); tNext >= 0 {
+		t.Errorf("Expected no flush after flushing: %v", tNext)
+	}
+}
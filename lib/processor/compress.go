@@ -21,16 +21,18 @@
 package processor
 
 import (
-	"bytes"
 	"compress/flate"
 	"compress/gzip"
 	"compress/zlib"
 	"fmt"
+	"io"
+	"sync"
 
 	"github.com/Jeffail/benthos/lib/log"
 	"github.com/Jeffail/benthos/lib/metrics"
 	"github.com/Jeffail/benthos/lib/response"
 	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/pool"
 )
 
 //------------------------------------------------------------------------------
@@ -68,46 +70,74 @@ func NewCompressConfig() CompressConfig {
 
 type compressFunc func(level int, bytes []byte) ([]byte, error)
 
-func gzipCompress(level int, b []byte) ([]byte, error) {
-	buf := &bytes.Buffer{}
-	zw, err := gzip.NewWriterLevel(buf, level)
-	if err != nil {
-		return nil, err
-	}
+// resetWriter is a compression writer that can be reused with a new underlying
+// writer.
+type resetWriter interface {
+	io.WriteCloser
+	Reset(w io.Writer)
+}
 
-	if _, err = zw.Write(b); err != nil {
-		return nil, err
+// writerPools holds a pool of compression writers for each compression level,
+// as writers allocate large amounts of internal state.
+type writerPools [flate.BestCompression - flate.HuffmanOnly + 1]sync.Pool
+
+var (
+	gzipWriters  writerPools
+	zlibWriters  writerPools
+	flateWriters writerPools
+)
+
+func pooledCompress(
+	pools *writerPools,
+	ctor func(w io.Writer, level int) (resetWriter, error),
+	level int,
+	b []byte,
+) ([]byte, error) {
+	if level < flate.HuffmanOnly || level > flate.BestCompression {
+		return nil, fmt.Errorf("invalid compression level: %v", level)
+	}
+	p := &pools[level-flate.HuffmanOnly]
+
+	buf := pool.GetBuffer()
+	zw, _ := p.Get().(resetWriter)
+	if zw == nil {
+		var err error
+		if zw, err = ctor(buf, level); err != nil {
+			pool.PutBuffer(buf)
+			return nil, err
+		}
+	} else {
+		zw.Reset(buf)
 	}
-	zw.Close()
-	return buf.Bytes(), nil
-}
 
-func zlibCompress(level int, b []byte) ([]byte, error) {
-	buf := &bytes.Buffer{}
-	zw, err := zlib.NewWriterLevel(buf, level)
-	if err != nil {
+	if _, err := zw.Write(b); err != nil {
+		pool.PutBuffer(buf)
 		return nil, err
 	}
-
-	if _, err = zw.Write(b); err != nil {
+	if err := zw.Close(); err != nil {
+		pool.PutBuffer(buf)
 		return nil, err
 	}
-	zw.Close()
-	return buf.Bytes(), nil
+	p.Put(zw)
+	return pool.Detach(buf), nil
 }
 
-func flateCompress(level int, b []byte) ([]byte, error) {
-	buf := &bytes.Buffer{}
-	zw, err := flate.NewWriter(buf, level)
-	if err != nil {
-		return nil, err
-	}
+func gzipCompress(level int, b []byte) ([]byte, error) {
+	return pooledCompress(&gzipWriters, func(w io.Writer, level int) (resetWriter, error) {
+		return gzip.NewWriterLevel(w, level)
+	}, level, b)
+}
 
-	if _, err = zw.Write(b); err != nil {
-		return nil, err
-	}
-	zw.Close()
-	return buf.Bytes(), nil
+func zlibCompress(level int, b []byte) ([]byte, error) {
+	return pooledCompress(&zlibWriters, func(w io.Writer, level int) (resetWriter, error) {
+		return zlib.NewWriterLevel(w, level)
+	}, level, b)
+}
+
+func flateCompress(level int, b []byte) ([]byte, error) {
+	return pooledCompress(&flateWriters, func(w io.Writer, level int) (resetWriter, error) {
+		return flate.NewWriter(w, level)
+	}, level, b)
 }
 
 func strToCompressor(str string) (compressFunc, error) {
@@ -135,7 +165,7 @@ type Compress struct {
 
 	mCount     metrics.StatCounter
 	mSucc      metrics.StatCounter
-	mErr       metrics.StatCounter
+	mErr       *errorStats
 	mSkipped   metrics.StatCounter
 	mSent      metrics.StatCounter
 	mSentParts metrics.StatCounter
@@ -157,7 +187,7 @@ func NewCompress(
 
 		mCount:     stats.GetCounter("processor.compress.count"),
 		mSucc:      stats.GetCounter("processor.compress.success"),
-		mErr:       stats.GetCounter("processor.compress.error"),
+		mErr:       newErrorStats("compress", stats),
 		mSkipped:   stats.GetCounter("processor.compress.skipped"),
 		mSent:      stats.GetCounter("processor.compress.sent"),
 		mSentParts: stats.GetCounter("processor.compress.parts.sent"),
@@ -189,7 +219,7 @@ func (c *Compress) ProcessMessage(msg types.Message) ([]types.Message, types.Res
 			newMsg.Get(index).Set(newPart)
 		} else {
 			c.log.Errorf("Failed to compress message part: %v\n", err)
-			c.mErr.Incr(1)
+			c.mErr.IncrErr(err)
 		}
 	}
 
@@ -263,3 +263,23 @@ func TestCompressEmpty(t *testing.T) {
 		t.Error("Expected failure with zero part message")
 	}
 }
+
+func BenchmarkCompressGzip(b *testing.B) {
+	conf := NewConfig()
+	conf.Compress.Algorithm = "gzip"
+
+	proc, err := NewCompress(conf, nil, log.Noop(), metrics.Noop())
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	input := message.New([][]byte{
+		bytes.Repeat([]byte(`{"hello":"world","foo":"bar"}`), 100),
+	})
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		proc.ProcessMessage(input)
+	}
+}
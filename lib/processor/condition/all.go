@@ -0,0 +1,166 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package condition
+
+import (
+	"encoding/json"
+
+	"github.com/Jeffail/benthos/lib/log"
+	"github.com/Jeffail/benthos/lib/message"
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors[TypeAll] = TypeSpec{
+		constructor: NewAll,
+		description: `
+All is a condition that tests a child condition against each message of a batch
+individually. If all messages pass the child condition then this condition also
+passes. An empty batch will not pass.
+
+For example, if we wanted to check that all messages of a batch contain the word
+'foo' we could use this config:
+
+` + "``` yaml" + `
+type: all
+all:
+  type: text
+  text:
+    operator: contains
+    arg: foo
+` + "```",
+		sanitiseConfigFunc: func(conf Config) (interface{}, error) {
+			if conf.All.Config == nil {
+				return struct{}{}, nil
+			}
+			return SanitiseConfig(*conf.All.Config)
+		},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// AllConfig is a configuration struct containing fields for the All condition.
+type AllConfig struct {
+	*Config
+}
+
+// NewAllConfig returns a AllConfig with default values.
+func NewAllConfig() AllConfig {
+	return AllConfig{
+		Config: nil,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// MarshalJSON prints an empty object instead of nil.
+func (m AllConfig) MarshalJSON() ([]byte, error) {
+	if m.Config != nil {
+		return json.Marshal(m.Config)
+	}
+	return json.Marshal(struct{}{})
+}
+
+// MarshalYAML prints an empty object instead of nil.
+func (m AllConfig) MarshalYAML() (interface{}, error) {
+	if m.Config != nil {
+		return *m.Config, nil
+	}
+	return struct{}{}, nil
+}
+
+//------------------------------------------------------------------------------
+
+// UnmarshalJSON ensures that when parsing child config it is initialised.
+func (m *AllConfig) UnmarshalJSON(bytes []byte) error {
+	if m.Config == nil {
+		nConf := NewConfig()
+		m.Config = &nConf
+	}
+
+	return json.Unmarshal(bytes, m.Config)
+}
+
+// UnmarshalYAML ensures that when parsing child config it is initialised.
+func (m *AllConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	if m.Config == nil {
+		nConf := NewConfig()
+		m.Config = &nConf
+	}
+
+	return unmarshal(m.Config)
+}
+
+//------------------------------------------------------------------------------
+
+// All is a condition that returns the logical AND of a child condition applied
+// to each message of a batch individually.
+type All struct {
+	child Type
+}
+
+// NewAll returns an All condition.
+func NewAll(
+	conf Config, mgr types.Manager, log log.Modular, stats metrics.Type,
+) (Type, error) {
+	childConf := conf.All.Config
+	if childConf == nil {
+		newConf := NewConfig()
+		childConf = &newConf
+	}
+	child, err := New(*childConf, mgr, log, stats)
+	if err != nil {
+		return nil, err
+	}
+	return &All{
+		child: child,
+	}, nil
+}
+
+//------------------------------------------------------------------------------
+
+// Check attempts to check a message against a configured condition.
+func (c *All) Check(msg types.Message) bool {
+	if msg.Len() == 0 {
+		return false
+	}
+	for i := 0; i < msg.Len(); i++ {
+		if !message.CheckPart(c.child, msg, i) {
+			return false
+		}
+	}
+	return true
+}
+
+// CheckPart attempts to check a single part of a batch against a configured
+// condition as if it were a message of its own.
+func (c *All) CheckPart(msg types.Message, index int) bool {
+	if msg.Len() == 0 {
+		return false
+	}
+	return message.CheckPart(c.child, msg, index)
+}
+
+//------------------------------------------------------------------------------
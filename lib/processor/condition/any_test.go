@@ -0,0 +1,133 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package condition
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/Jeffail/benthos/lib/log"
+	"github.com/Jeffail/benthos/lib/message"
+	"github.com/Jeffail/benthos/lib/metrics"
+	yaml "gopkg.in/yaml.v2"
+)
+
+func TestAnyCheck(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = "any"
+
+	childConf := NewConfig()
+	childConf.Type = "text"
+	childConf.Text.Operator = "contains"
+	childConf.Text.Arg = "foo"
+	conf.Any.Config = &childConf
+
+	c, err := New(conf, nil, log.Noop(), metrics.Noop())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		input [][]byte
+		exp   bool
+	}{
+		{
+			input: [][]byte{
+				[]byte("bar"),
+				[]byte("baz"),
+			},
+			exp: false,
+		},
+		{
+			input: [][]byte{
+				[]byte("bar"),
+				[]byte("foo"),
+			},
+			exp: true,
+		},
+		{
+			input: [][]byte{
+				[]byte("foo"),
+				[]byte("foo bar"),
+			},
+			exp: true,
+		},
+		{
+			input: [][]byte{
+				[]byte("foo"),
+				[]byte("bar"),
+			},
+			exp: true,
+		},
+		{
+			input: [][]byte{},
+			exp:   false,
+		},
+	}
+
+	for i, test := range tests {
+		if act := c.Check(message.New(test.input)); act != test.exp {
+			t.Errorf("Wrong result for test %v: %v != %v", i, act, test.exp)
+		}
+	}
+}
+
+func TestAnyConfigUnmarshal(t *testing.T) {
+	jsonConf := []byte(`{
+	"type": "any",
+	"any": {
+		"type": "text",
+		"text": {
+			"arg": "foo"
+		}
+	}
+}`)
+	yamlConf := []byte(`
+type: any
+any:
+  type: text
+  text:
+    arg: foo
+`)
+
+	jConf := NewConfig()
+	if err := json.Unmarshal(jsonConf, &jConf); err != nil {
+		t.Fatal(err)
+	}
+	yConf := NewConfig()
+	if err := yaml.Unmarshal(yamlConf, &yConf); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, conf := range []Config{jConf, yConf} {
+		if conf.Any.Config == nil {
+			t.Fatal("Expected child config")
+		}
+		if exp, act := "text", conf.Any.Type; exp != act {
+			t.Errorf("Wrong child type: %v != %v", act, exp)
+		}
+		if exp, act := "foo", conf.Any.Text.Arg; exp != act {
+			t.Errorf("Wrong child arg: %v != %v", act, exp)
+		}
+		if exp, act := "equals_cs", conf.Any.Text.Operator; exp != act {
+			t.Errorf("Wrong child default operator: %v != %v", act, exp)
+		}
+	}
+}
@@ -34,7 +34,14 @@ func init() {
 	Constructors[TypeBoundsCheck] = TypeSpec{
 		constructor: NewBoundsCheck,
 		description: `
-Checks a message against a set of bounds.`,
+Checks a message against a set of bounds, rejecting it if any are breached. The
+bounds include the number of parts of a batch, the size in bytes of each
+individual part and the total size in bytes of all parts of the batch.
+
+The fields ` + "`min_size` and `max_size`" + ` refer to the total size of the
+batch and are ignored when set to zero, which is the default. This condition
+can be used to filter or route oversized batches before they reach an output
+with hard limits.`,
 	}
 }
 
@@ -47,6 +54,8 @@ type BoundsCheckConfig struct {
 	MinParts    int `json:"min_parts" yaml:"min_parts"`
 	MaxPartSize int `json:"max_part_size" yaml:"max_part_size"`
 	MinPartSize int `json:"min_part_size" yaml:"min_part_size"`
+	MaxSize     int `json:"max_size" yaml:"max_size"`
+	MinSize     int `json:"min_size" yaml:"min_size"`
 }
 
 // NewBoundsCheckConfig returns a BoundsCheckConfig with default values.
@@ -56,6 +65,8 @@ func NewBoundsCheckConfig() BoundsCheckConfig {
 		MinParts:    1,
 		MaxPartSize: 1 * 1024 * 1024 * 1024, // 1GB
 		MinPartSize: 1,
+		MaxSize:     0,
+		MinSize:     0,
 	}
 }
 
@@ -71,12 +82,15 @@ type BoundsCheck struct {
 	maxPartSize int
 	minParts    int
 	minPartSize int
+	maxSize     int
+	minSize     int
 
 	mApplied         metrics.StatCounter
 	mSkipped         metrics.StatCounter
 	mSkippedEmpty    metrics.StatCounter
 	mSkippedNumParts metrics.StatCounter
 	mSkippedPartSize metrics.StatCounter
+	mSkippedSize     metrics.StatCounter
 }
 
 // NewBoundsCheck returns a BoundsCheck condition.
@@ -90,11 +104,14 @@ func NewBoundsCheck(
 		maxPartSize:      conf.BoundsCheck.MaxPartSize,
 		minParts:         conf.BoundsCheck.MinParts,
 		minPartSize:      conf.BoundsCheck.MinPartSize,
+		maxSize:          conf.BoundsCheck.MaxSize,
+		minSize:          conf.BoundsCheck.MinSize,
 		mApplied:         stats.GetCounter("condition.bounds_check.applied"),
 		mSkipped:         stats.GetCounter("condition.bounds_check.skipped"),
 		mSkippedEmpty:    stats.GetCounter("condition.bounds_check.skipped.empty_message"),
 		mSkippedNumParts: stats.GetCounter("condition.bounds_check.skipped.num_parts"),
 		mSkippedPartSize: stats.GetCounter("condition.bounds_check.skipped.part_size"),
+		mSkippedSize:     stats.GetCounter("condition.bounds_check.skipped.size"),
 	}, nil
 }
 
@@ -127,8 +144,10 @@ func (c *BoundsCheck) Check(msg types.Message) bool {
 	}
 
 	var reject bool
+	var totalSize int
 	msg.Iter(func(i int, p types.Part) error {
-		if size := len(p.Get()); size > c.maxPartSize || size < c.minPartSize {
+		size := len(p.Get())
+		if size > c.maxPartSize || size < c.minPartSize {
 			c.log.Debugf(
 				"Rejecting message due to message part size (%v -> %v): %v\n",
 				c.minPartSize, c.maxPartSize, size,
@@ -136,6 +155,7 @@ func (c *BoundsCheck) Check(msg types.Message) bool {
 			reject = true
 			return errors.New("bounds_check part error")
 		}
+		totalSize += size
 		return nil
 	})
 
@@ -145,6 +165,16 @@ func (c *BoundsCheck) Check(msg types.Message) bool {
 		return false
 	}
 
+	if (c.maxSize > 0 && totalSize > c.maxSize) || totalSize < c.minSize {
+		c.log.Debugf(
+			"Rejecting message due to total size (%v -> %v): %v\n",
+			c.minSize, c.maxSize, totalSize,
+		)
+		c.mSkipped.Incr(1)
+		c.mSkippedSize.Incr(1)
+		return false
+	}
+
 	c.mApplied.Incr(1)
 	return true
 }
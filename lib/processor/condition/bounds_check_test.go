@@ -14,6 +14,8 @@ func TestBoundsCheck(t *testing.T) {
 		maxPartSize int
 		minParts    int
 		minPartSize int
+		maxSize     int
+		minSize     int
 	}
 	tests := []struct {
 		name   string
@@ -102,6 +104,51 @@ func TestBoundsCheck(t *testing.T) {
 			},
 			want: false,
 		},
+		{
+			name: "bounds_check maxSize pos 1",
+			fields: fields{
+				maxParts:    5,
+				maxPartSize: 1.073741824e+09,
+				minParts:    1,
+				minPartSize: 1,
+				maxSize:     10,
+			},
+			arg: [][]byte{
+				[]byte("hello"),
+				[]byte("world"),
+			},
+			want: true,
+		},
+		{
+			name: "bounds_check maxSize neg 1",
+			fields: fields{
+				maxParts:    5,
+				maxPartSize: 1.073741824e+09,
+				minParts:    1,
+				minPartSize: 1,
+				maxSize:     9,
+			},
+			arg: [][]byte{
+				[]byte("hello"),
+				[]byte("world"),
+			},
+			want: false,
+		},
+		{
+			name: "bounds_check minSize neg 1",
+			fields: fields{
+				maxParts:    5,
+				maxPartSize: 1.073741824e+09,
+				minParts:    1,
+				minPartSize: 1,
+				minSize:     11,
+			},
+			arg: [][]byte{
+				[]byte("hello"),
+				[]byte("world"),
+			},
+			want: false,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -111,6 +158,8 @@ func TestBoundsCheck(t *testing.T) {
 			conf.BoundsCheck.MaxPartSize = tt.fields.maxPartSize
 			conf.BoundsCheck.MinParts = tt.fields.minParts
 			conf.BoundsCheck.MinPartSize = tt.fields.minPartSize
+			conf.BoundsCheck.MaxSize = tt.fields.maxSize
+			conf.BoundsCheck.MinSize = tt.fields.minSize
 
 			c, err := NewBoundsCheck(conf, nil, log.Noop(), metrics.Noop())
 			if err != nil {
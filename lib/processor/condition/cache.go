@@ -0,0 +1,142 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package condition
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Jeffail/benthos/lib/log"
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/text"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors[TypeCache] = TypeSpec{
+		constructor: NewCache,
+		description: `
+Checks whether a key exists within a cache resource, returning true if it does.
+The ` + "`key`" + ` field supports
+[function interpolations](../config_interpolation.md#functions), which are
+resolved against the whole message batch.
+
+This condition makes it possible to route or filter messages based on an
+allowlist or blocklist that is maintained externally, for example by another
+stream writing keys to a shared Redis cache:
+
+` + "``` yaml" + `
+type: cache
+cache:
+  cache: blocklist
+  key: ${!metadata:user_id}
+` + "```" + `
+
+If the cache returns an error other than the key not existing the condition
+returns false and the error is logged.
+
+Caches should be configured as a resource, for more information check out the
+[documentation here](../caches).`,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// CacheConfig is a configuration struct containing fields for the Cache
+// condition.
+type CacheConfig struct {
+	Cache string `json:"cache" yaml:"cache"`
+	Key   string `json:"key" yaml:"key"`
+}
+
+// NewCacheConfig returns a CacheConfig with default values.
+func NewCacheConfig() CacheConfig {
+	return CacheConfig{
+		Cache: "",
+		Key:   "",
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// Cache is a condition that checks whether an interpolated key exists within a
+// cache resource.
+type Cache struct {
+	log   log.Modular
+	stats metrics.Type
+
+	key   *text.InterpolatedString
+	cache types.Cache
+
+	mApplied  metrics.StatCounter
+	mExists   metrics.StatCounter
+	mNotFound metrics.StatCounter
+	mErr      metrics.StatCounter
+}
+
+// NewCache returns a Cache condition.
+func NewCache(
+	conf Config, mgr types.Manager, log log.Modular, stats metrics.Type,
+) (Type, error) {
+	if len(conf.Cache.Key) == 0 {
+		return nil, errors.New("a key must be specified")
+	}
+	c, err := mgr.GetCache(conf.Cache.Cache)
+	if err != nil {
+		return nil, fmt.Errorf("failed to obtain cache resource '%v': %v", conf.Cache.Cache, err)
+	}
+	return &Cache{
+		log:   log.NewModule(".condition.cache"),
+		stats: stats,
+
+		key:   text.NewInterpolatedString(conf.Cache.Key),
+		cache: c,
+
+		mApplied:  stats.GetCounter("condition.cache.applied"),
+		mExists:   stats.GetCounter("condition.cache.exists"),
+		mNotFound: stats.GetCounter("condition.cache.not_found"),
+		mErr:      stats.GetCounter("condition.cache.error"),
+	}, nil
+}
+
+//------------------------------------------------------------------------------
+
+// Check attempts to check a message against a configured condition.
+func (c *Cache) Check(msg types.Message) bool {
+	c.mApplied.Incr(1)
+
+	key := c.key.Get(msg)
+	if _, err := c.cache.Get(key); err != nil {
+		if err == types.ErrKeyNotFound {
+			c.mNotFound.Incr(1)
+		} else {
+			c.mErr.Incr(1)
+			c.log.Errorf("Failed to check key '%v': %v\n", key, err)
+		}
+		return false
+	}
+
+	c.mExists.Incr(1)
+	return true
+}
+
+//------------------------------------------------------------------------------
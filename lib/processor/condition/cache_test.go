@@ -0,0 +1,94 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package condition
+
+import (
+	"testing"
+
+	"github.com/Jeffail/benthos/lib/cache"
+	"github.com/Jeffail/benthos/lib/log"
+	"github.com/Jeffail/benthos/lib/message"
+	"github.com/Jeffail/benthos/lib/message/metadata"
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+)
+
+func TestCacheCheck(t *testing.T) {
+	memCache, err := cache.NewMemory(cache.NewConfig(), nil, log.Noop(), metrics.Noop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = memCache.Set("foo", []byte("1")); err != nil {
+		t.Fatal(err)
+	}
+	if err = memCache.Set("bar", []byte("1")); err != nil {
+		t.Fatal(err)
+	}
+
+	mgr := &fakeMgr{
+		caches: map[string]types.Cache{
+			"foocache": memCache,
+		},
+	}
+
+	conf := NewConfig()
+	conf.Type = TypeCache
+	conf.Cache.Cache = "foocache"
+	conf.Cache.Key = "${!metadata:id}"
+
+	c, err := New(conf, mgr, log.Noop(), metrics.Noop())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]bool{
+		"foo": true,
+		"bar": true,
+		"baz": false,
+		"":    false,
+	}
+
+	for k, exp := range tests {
+		msg := message.New(nil)
+		msg.Append(message.NewPart([]byte("hello world")).SetMetadata(metadata.New(map[string]string{
+			"id": k,
+		})))
+		if act := c.Check(msg); act != exp {
+			t.Errorf("Wrong result for key '%v': %v != %v", k, act, exp)
+		}
+	}
+}
+
+func TestCacheBadConfig(t *testing.T) {
+	mgr := &fakeMgr{}
+
+	conf := NewConfig()
+	conf.Type = TypeCache
+	conf.Cache.Cache = "foocache"
+
+	if _, err := New(conf, mgr, log.Noop(), metrics.Noop()); err == nil {
+		t.Error("Expected error from empty key")
+	}
+
+	conf.Cache.Key = "foo"
+	if _, err := New(conf, mgr, log.Noop(), metrics.Noop()); err == nil {
+		t.Error("Expected error from missing cache")
+	}
+}
@@ -0,0 +1,304 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package condition
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/log"
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/text"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors[TypeCached] = TypeSpec{
+		constructor: NewCached,
+		description: `
+Cached is a condition that stores the result of a child condition within a
+cache resource, and for messages that resolve to the same key returns the stored
+result until it expires rather than checking the child condition again. This is
+useful for expensive child conditions, such as those that call out to slow
+external services, on high volume streams.
+
+The ` + "`key`" + ` field supports
+[function interpolations](../config_interpolation.md#functions), which are
+resolved against the whole message batch, and should resolve to a value that
+uniquely identifies the result of the child condition:
+
+` + "``` yaml" + `
+type: cached
+cached:
+  cache: user_checks
+  key: ${!metadata:user_id}
+  ttl: 5m
+  condition:
+    type: resource
+    resource: user_is_active
+` + "```" + `
+
+Results expire after the duration ` + "`ttl`" + `, after which the child
+condition is checked again. Caches that support a TTL per key also have entries
+removed after this duration.
+
+If the cache returns an error other than the key not existing the child
+condition is checked and the error is logged.
+
+Caches should be configured as a resource, for more information check out the
+[documentation here](../caches).`,
+		sanitiseConfigFunc: func(conf Config) (interface{}, error) {
+			var cond interface{} = struct{}{}
+			if conf.Cached.Condition != nil {
+				var err error
+				if cond, err = SanitiseConfig(*conf.Cached.Condition); err != nil {
+					return nil, err
+				}
+			}
+			return map[string]interface{}{
+				"cache":     conf.Cached.Cache,
+				"key":       conf.Cached.Key,
+				"ttl":       conf.Cached.TTL,
+				"condition": cond,
+			}, nil
+		},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// CachedConfig is a configuration struct containing fields for the Cached
+// condition.
+type CachedConfig struct {
+	Cache     string  `json:"cache" yaml:"cache"`
+	Key       string  `json:"key" yaml:"key"`
+	TTL       string  `json:"ttl" yaml:"ttl"`
+	Condition *Config `json:"condition" yaml:"condition"`
+}
+
+// NewCachedConfig returns a CachedConfig with default values.
+func NewCachedConfig() CachedConfig {
+	return CachedConfig{
+		Cache:     "",
+		Key:       "",
+		TTL:       "60s",
+		Condition: nil,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type cachedConfigPrinted struct {
+	Cache     string      `json:"cache" yaml:"cache"`
+	Key       string      `json:"key" yaml:"key"`
+	TTL       string      `json:"ttl" yaml:"ttl"`
+	Condition interface{} `json:"condition" yaml:"condition"`
+}
+
+func (m CachedConfig) printed() cachedConfigPrinted {
+	var cond interface{} = struct{}{}
+	if m.Condition != nil {
+		cond = *m.Condition
+	}
+	return cachedConfigPrinted{
+		Cache:     m.Cache,
+		Key:       m.Key,
+		TTL:       m.TTL,
+		Condition: cond,
+	}
+}
+
+// MarshalJSON prints an empty object instead of a nil child condition.
+func (m CachedConfig) MarshalJSON() ([]byte, error) {
+	return json.Marshal(m.printed())
+}
+
+// MarshalYAML prints an empty object instead of a nil child condition.
+func (m CachedConfig) MarshalYAML() (interface{}, error) {
+	return m.printed(), nil
+}
+
+//------------------------------------------------------------------------------
+
+// UnmarshalJSON ensures that when parsing child config it is initialised.
+func (m *CachedConfig) UnmarshalJSON(bytes []byte) error {
+	type confAlias CachedConfig
+	aliased := confAlias(*m)
+	if aliased.Condition == nil {
+		nConf := NewConfig()
+		aliased.Condition = &nConf
+	}
+
+	if err := json.Unmarshal(bytes, &aliased); err != nil {
+		return err
+	}
+	*m = CachedConfig(aliased)
+	return nil
+}
+
+// UnmarshalYAML ensures that when parsing child config it is initialised.
+func (m *CachedConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type confAlias CachedConfig
+	aliased := confAlias(*m)
+	if aliased.Condition == nil {
+		nConf := NewConfig()
+		aliased.Condition = &nConf
+	}
+
+	if err := unmarshal(&aliased); err != nil {
+		return err
+	}
+	*m = CachedConfig(aliased)
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+// Cached is a condition that stores the results of a child condition within a
+// cache resource under an interpolated key.
+type Cached struct {
+	log   log.Modular
+	stats metrics.Type
+
+	key   *text.InterpolatedString
+	ttl   time.Duration
+	cache types.Cache
+	child Type
+
+	mApplied metrics.StatCounter
+	mHit     metrics.StatCounter
+	mMiss    metrics.StatCounter
+	mErr     metrics.StatCounter
+}
+
+// NewCached returns a Cached condition.
+func NewCached(
+	conf Config, mgr types.Manager, log log.Modular, stats metrics.Type,
+) (Type, error) {
+	if len(conf.Cached.Key) == 0 {
+		return nil, errors.New("a key must be specified")
+	}
+	ttl, err := time.ParseDuration(conf.Cached.TTL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse ttl: %v", err)
+	}
+	if ttl <= 0 {
+		return nil, errors.New("ttl must be greater than zero")
+	}
+	c, err := mgr.GetCache(conf.Cached.Cache)
+	if err != nil {
+		return nil, fmt.Errorf("failed to obtain cache resource '%v': %v", conf.Cached.Cache, err)
+	}
+	childConf := conf.Cached.Condition
+	if childConf == nil {
+		newConf := NewConfig()
+		childConf = &newConf
+	}
+	child, err := New(*childConf, mgr, log, stats)
+	if err != nil {
+		return nil, err
+	}
+	return &Cached{
+		log:   log.NewModule(".condition.cached"),
+		stats: stats,
+
+		key:   text.NewInterpolatedString(conf.Cached.Key),
+		ttl:   ttl,
+		cache: c,
+		child: child,
+
+		mApplied: stats.GetCounter("condition.cached.applied"),
+		mHit:     stats.GetCounter("condition.cached.hit"),
+		mMiss:    stats.GetCounter("condition.cached.miss"),
+		mErr:     stats.GetCounter("condition.cached.error"),
+	}, nil
+}
+
+//------------------------------------------------------------------------------
+
+// encodeCachedResult encodes the result of a condition along with the time at
+// which it expires.
+func encodeCachedResult(result bool, expires time.Time) []byte {
+	return []byte(strconv.FormatBool(result) + ":" + strconv.FormatInt(expires.UnixNano(), 10))
+}
+
+// decodeCachedResult decodes the result of a condition and the time at which it
+// expires.
+func decodeCachedResult(value []byte) (bool, time.Time, error) {
+	parts := strings.SplitN(string(value), ":", 2)
+	if len(parts) != 2 {
+		return false, time.Time{}, errors.New("unexpected format")
+	}
+	result, err := strconv.ParseBool(parts[0])
+	if err != nil {
+		return false, time.Time{}, err
+	}
+	expires, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return false, time.Time{}, err
+	}
+	return result, time.Unix(0, expires), nil
+}
+
+// Check attempts to check a message against a configured condition.
+func (c *Cached) Check(msg types.Message) bool {
+	c.mApplied.Incr(1)
+
+	key := c.key.Get(msg)
+	value, err := c.cache.Get(key)
+	if err == nil {
+		result, expires, derr := decodeCachedResult(value)
+		if derr == nil && time.Now().Before(expires) {
+			c.mHit.Incr(1)
+			return result
+		}
+		if derr != nil {
+			c.mErr.Incr(1)
+			c.log.Errorf("Failed to decode cached result of key '%v': %v\n", key, derr)
+		}
+	} else if err != types.ErrKeyNotFound {
+		c.mErr.Incr(1)
+		c.log.Errorf("Failed to get cached result of key '%v': %v\n", key, err)
+	}
+
+	c.mMiss.Incr(1)
+	result := c.child.Check(msg)
+
+	value = encodeCachedResult(result, time.Now().Add(c.ttl))
+	if ttlCache, ok := c.cache.(types.CacheWithTTL); ok {
+		err = ttlCache.SetWithTTL(key, value, c.ttl)
+	} else {
+		err = c.cache.Set(key, value)
+	}
+	if err != nil {
+		c.mErr.Incr(1)
+		c.log.Errorf("Failed to set cached result of key '%v': %v\n", key, err)
+	}
+	return result
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,219 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package condition
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/cache"
+	"github.com/Jeffail/benthos/lib/log"
+	"github.com/Jeffail/benthos/lib/message"
+	"github.com/Jeffail/benthos/lib/message/metadata"
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	yaml "gopkg.in/yaml.v2"
+)
+
+type countingCond struct {
+	checked map[string]int
+}
+
+func (c *countingCond) Check(msg types.Message) bool {
+	id := msg.Get(0).Metadata().Get("id")
+	c.checked[id]++
+	return id == "foo"
+}
+
+func cachedMsg(id string) types.Message {
+	msg := message.New(nil)
+	msg.Append(message.NewPart([]byte("hello world")).SetMetadata(metadata.New(map[string]string{
+		"id": id,
+	})))
+	return msg
+}
+
+func TestCachedCheck(t *testing.T) {
+	memCache, err := cache.NewMemory(cache.NewConfig(), nil, log.Noop(), metrics.Noop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	child := &countingCond{checked: map[string]int{}}
+	mgr := &fakeMgr{
+		caches: map[string]types.Cache{
+			"foocache": memCache,
+		},
+		conds: map[string]Type{
+			"foocond": child,
+		},
+	}
+
+	conf := NewConfig()
+	conf.Type = TypeCached
+	conf.Cached.Cache = "foocache"
+	conf.Cached.Key = "${!metadata:id}"
+	conf.Cached.TTL = "50ms"
+	childConf := NewConfig()
+	childConf.Type = TypeResource
+	childConf.Resource = "foocond"
+	conf.Cached.Condition = &childConf
+
+	c, err := New(conf, mgr, log.Noop(), metrics.Noop())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 3; i++ {
+		if !c.Check(cachedMsg("foo")) {
+			t.Error("Expected foo to pass")
+		}
+		if c.Check(cachedMsg("bar")) {
+			t.Error("Expected bar to fail")
+		}
+	}
+	if exp, act := 1, child.checked["foo"]; exp != act {
+		t.Errorf("Wrong count of foo checks: %v != %v", act, exp)
+	}
+	if exp, act := 1, child.checked["bar"]; exp != act {
+		t.Errorf("Wrong count of bar checks: %v != %v", act, exp)
+	}
+
+	<-time.After(time.Millisecond * 60)
+
+	if !c.Check(cachedMsg("foo")) {
+		t.Error("Expected foo to pass")
+	}
+	if exp, act := 2, child.checked["foo"]; exp != act {
+		t.Errorf("Wrong count of foo checks after expiry: %v != %v", act, exp)
+	}
+}
+
+func TestCachedCorruptValue(t *testing.T) {
+	memCache, err := cache.NewMemory(cache.NewConfig(), nil, log.Noop(), metrics.Noop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = memCache.Set("foo", []byte("not a result")); err != nil {
+		t.Fatal(err)
+	}
+	child := &countingCond{checked: map[string]int{}}
+	mgr := &fakeMgr{
+		caches: map[string]types.Cache{
+			"foocache": memCache,
+		},
+		conds: map[string]Type{
+			"foocond": child,
+		},
+	}
+
+	conf := NewConfig()
+	conf.Type = TypeCached
+	conf.Cached.Cache = "foocache"
+	conf.Cached.Key = "${!metadata:id}"
+	childConf := NewConfig()
+	childConf.Type = TypeResource
+	childConf.Resource = "foocond"
+	conf.Cached.Condition = &childConf
+
+	c, err := New(conf, mgr, log.Noop(), metrics.Noop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !c.Check(cachedMsg("foo")) {
+		t.Error("Expected foo to pass")
+	}
+	if !c.Check(cachedMsg("foo")) {
+		t.Error("Expected foo to pass")
+	}
+	if exp, act := 1, child.checked["foo"]; exp != act {
+		t.Errorf("Wrong count of foo checks: %v != %v", act, exp)
+	}
+}
+
+func TestCachedBadConfig(t *testing.T) {
+	memCache, err := cache.NewMemory(cache.NewConfig(), nil, log.Noop(), metrics.Noop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	mgr := &fakeMgr{
+		caches: map[string]types.Cache{
+			"foocache": memCache,
+		},
+	}
+
+	conf := NewConfig()
+	conf.Type = TypeCached
+	conf.Cached.Cache = "foocache"
+	if _, err := New(conf, mgr, log.Noop(), metrics.Noop()); err == nil {
+		t.Error("Expected error from empty key")
+	}
+
+	conf.Cached.Key = "foo"
+	conf.Cached.TTL = "nope"
+	if _, err := New(conf, mgr, log.Noop(), metrics.Noop()); err == nil {
+		t.Error("Expected error from bad ttl")
+	}
+
+	conf.Cached.TTL = "1m"
+	conf.Cached.Cache = "barcache"
+	if _, err := New(conf, mgr, log.Noop(), metrics.Noop()); err == nil {
+		t.Error("Expected error from missing cache")
+	}
+}
+
+func TestCachedConfigParse(t *testing.T) {
+	input := `
+type: cached
+cached:
+  cache: foocache
+  key: ${!metadata:id}
+  condition:
+    type: text
+    text:
+      operator: equals
+      arg: foo
+`
+	conf := NewConfig()
+	if err := yaml.Unmarshal([]byte(input), &conf); err != nil {
+		t.Fatal(err)
+	}
+	if exp, act := "60s", conf.Cached.TTL; exp != act {
+		t.Errorf("Wrong default ttl: %v != %v", act, exp)
+	}
+	if conf.Cached.Condition == nil {
+		t.Fatal("Missing child condition")
+	}
+	if exp, act := "equals", conf.Cached.Condition.Text.Operator; exp != act {
+		t.Errorf("Wrong child operator: %v != %v", act, exp)
+	}
+	if exp, act := 0, conf.Cached.Condition.Text.Part; exp != act {
+		t.Errorf("Wrong default child part: %v != %v", act, exp)
+	}
+
+	jBytes, err := json.Marshal(NewCachedConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp, act := `{"cache":"","key":"","ttl":"60s","condition":{}}`, string(jBytes); exp != act {
+		t.Errorf("Wrong marshalled config: %v != %v", act, exp)
+	}
+}
@@ -48,6 +48,11 @@ type TypeSpec struct {
 	sanitiseConfigFunc func(conf Config) (interface{}, error)
 }
 
+// Description returns the markdown description of the type.
+func (t TypeSpec) Description() string {
+	return t.description
+}
+
 // Constructors is a map of all condition types with their specs.
 var Constructors = map[string]TypeSpec{}
 
@@ -55,14 +60,20 @@ var Constructors = map[string]TypeSpec{}
 
 // String constants representing each condition type.
 var (
+	TypeAll         = "all"
 	TypeAnd         = "and"
+	TypeAny         = "any"
 	TypeBoundsCheck = "bounds_check"
+	TypeCache       = "cache"
+	TypeCached      = "cached"
 	TypeCheckField  = "check_field"
 	TypeCount       = "count"
 	TypeJMESPath    = "jmespath"
 	TypeNot         = "not"
 	TypeMetadata    = "metadata"
 	TypeOr          = "or"
+	TypePeriodic    = "periodic"
+	TypeProcessors  = "processors"
 	TypeResource    = "resource"
 	TypeStatic      = "static"
 	TypeText        = "text"
@@ -74,15 +85,21 @@ var (
 // Config is the all encompassing configuration struct for all condition types.
 type Config struct {
 	Type        string            `json:"type" yaml:"type"`
+	All         AllConfig         `json:"all" yaml:"all"`
 	And         AndConfig         `json:"and" yaml:"and"`
+	Any         AnyConfig         `json:"any" yaml:"any"`
 	BoundsCheck BoundsCheckConfig `json:"bounds_check" yaml:"bounds_check"`
+	Cache       CacheConfig       `json:"cache" yaml:"cache"`
+	Cached      CachedConfig      `json:"cached" yaml:"cached"`
 	CheckField  CheckFieldConfig  `json:"check_field" yaml:"check_field"`
 	Count       CountConfig       `json:"count" yaml:"count"`
 	JMESPath    JMESPathConfig    `json:"jmespath" yaml:"jmespath"`
 	Not         NotConfig         `json:"not" yaml:"not"`
 	Metadata    MetadataConfig    `json:"metadata" yaml:"metadata"`
 	Or          OrConfig          `json:"or" yaml:"or"`
+	Periodic    PeriodicConfig    `json:"periodic" yaml:"periodic"`
 	Plugin      interface{}       `json:"plugin,omitempty" yaml:"plugin,omitempty"`
+	Processors  ProcessorsConfig  `json:"processors" yaml:"processors"`
 	Resource    string            `json:"resource" yaml:"resource"`
 	Static      bool              `json:"static" yaml:"static"`
 	Text        TextConfig        `json:"text" yaml:"text"`
@@ -93,15 +110,21 @@ type Config struct {
 func NewConfig() Config {
 	return Config{
 		Type:        "text",
+		All:         NewAllConfig(),
 		And:         NewAndConfig(),
+		Any:         NewAnyConfig(),
 		BoundsCheck: NewBoundsCheckConfig(),
+		Cache:       NewCacheConfig(),
+		Cached:      NewCachedConfig(),
 		CheckField:  NewCheckFieldConfig(),
 		Count:       NewCountConfig(),
 		JMESPath:    NewJMESPathConfig(),
 		Not:         NewNotConfig(),
 		Metadata:    NewMetadataConfig(),
 		Or:          NewOrConfig(),
+		Periodic:    NewPeriodicConfig(),
 		Plugin:      nil,
+		Processors:  NewProcessorsConfig(),
 		Resource:    "",
 		Static:      true,
 		Text:        NewTextConfig(),
@@ -276,6 +299,11 @@ counting backwards starting from -1. E.g. if part = -1 then the selected part
 will be the last part of the message, if part = -2 then the part before the last
 element with be selected, and so on.
 
+In order to test a condition against every part of a batch the
+` + "[`any`](#any) and [`all`](#all)" + ` conditions can be used. These apply a
+child condition to each part of a batch individually and pass if any or all of
+the parts pass respectively.
+
 ### Reusing Conditions
 
 Sometimes large chunks of logic are reused across processors, or nested multiple
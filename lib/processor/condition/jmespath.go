@@ -144,7 +144,11 @@ func (c *JMESPath) Check(msg types.Message) bool {
 		return false
 	}
 
-	jsonPart, err := msg.Get(index).JSON()
+	return c.checkPart(msg.Get(index))
+}
+
+func (c *JMESPath) checkPart(part types.Part) bool {
+	jsonPart, err := part.JSON()
 	if err != nil {
 		c.mErrJSONP.Incr(1)
 		c.mDropped.Incr(1)
@@ -165,4 +169,14 @@ func (c *JMESPath) Check(msg types.Message) bool {
 	return resultBool
 }
 
+// CheckPart attempts to check a single part of a batch against a configured
+// condition as if it were a message of its own.
+func (c *JMESPath) CheckPart(msg types.Message, index int) bool {
+	if msg.Len() == 0 || (c.part != 0 && c.part != -1) {
+		c.mSkipped.Incr(1)
+		return false
+	}
+	return c.checkPart(msg.Get(index))
+}
+
 //------------------------------------------------------------------------------
@@ -164,13 +164,13 @@ metadata:
 
 ### ` + "`regexp_exact`" + `
 
-Checks whether the contents of a metadata key exactly matches a regular expression 
-(RE2 syntax).
+Checks whether the contents of a metadata key exactly matches a regular
+expression (RE2 syntax).
 
 ` + "```yaml" + `
 type: metadata
 metadata:
-  operator: regexp_partial
+  operator: regexp_exact
   part: 0
   key: foo
   arg: "1[a-z]2"
@@ -353,7 +353,8 @@ func strToMetadataOperator(str, key string, arg interface{}) (metadataOperator,
 
 //------------------------------------------------------------------------------
 
-// Metadata is a condition that checks message text against logical operators.
+// Metadata is a condition that checks metadata keys of a message part against
+// logical operators.
 type Metadata struct {
 	stats    metrics.Type
 	operator metadataOperator
@@ -378,10 +379,10 @@ func NewMetadata(
 		operator: op,
 		part:     conf.Metadata.Part,
 
-		mSkippedEmpty: stats.GetCounter("condition.text.skipped.empty_message"),
-		mSkipped:      stats.GetCounter("condition.text.skipped"),
-		mSkippedOOB:   stats.GetCounter("condition.text.skipped.out_of_bounds"),
-		mApplied:      stats.GetCounter("condition.text.applied"),
+		mSkippedEmpty: stats.GetCounter("condition.metadata.skipped.empty_message"),
+		mSkipped:      stats.GetCounter("condition.metadata.skipped"),
+		mSkippedOOB:   stats.GetCounter("condition.metadata.skipped.out_of_bounds"),
+		mApplied:      stats.GetCounter("condition.metadata.applied"),
 	}, nil
 }
 
@@ -396,6 +397,32 @@ func (c *Metadata) Check(msg types.Message) bool {
 		c.mSkipped.Incr(1)
 		return false
 	}
+	if index < 0 {
+		index = lParts + index
+	}
+	if index < 0 || index >= lParts {
+		c.mSkippedOOB.Incr(1)
+		c.mSkipped.Incr(1)
+		return false
+	}
+
+	c.mApplied.Incr(1)
+	return c.operator(msg.Get(index).Metadata())
+}
+
+// CheckPart attempts to check a single part of a batch against a configured
+// condition as if it were a message of its own.
+func (c *Metadata) CheckPart(msg types.Message, index int) bool {
+	if msg.Len() == 0 {
+		c.mSkippedEmpty.Incr(1)
+		c.mSkipped.Incr(1)
+		return false
+	}
+	if c.part != 0 && c.part != -1 {
+		c.mSkippedOOB.Incr(1)
+		c.mSkipped.Incr(1)
+		return false
+	}
 
 	c.mApplied.Incr(1)
 	return c.operator(msg.Get(index).Metadata())
@@ -429,3 +429,36 @@ func TestMetadataBadOperator(t *testing.T) {
 		t.Error("expected error from bad operator")
 	}
 }
+
+func TestMetadataPartIndexes(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = TypeMetadata
+	conf.Metadata.Operator = "exists"
+	conf.Metadata.Key = "foo"
+
+	msg := message.New(nil)
+	msg.Append(message.NewPart(nil))
+	msg.Append(message.NewPart(nil).SetMetadata(metadata.New(map[string]string{
+		"foo": "bar",
+	})))
+
+	tests := map[int]bool{
+		-3: false,
+		-2: false,
+		-1: true,
+		0:  false,
+		1:  true,
+		2:  false,
+	}
+
+	for part, exp := range tests {
+		conf.Metadata.Part = part
+		c, err := NewMetadata(conf, nil, log.Noop(), metrics.Noop())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if act := c.Check(msg); act != exp {
+			t.Errorf("Wrong result for part %v: %v != %v", part, act, exp)
+		}
+	}
+}
@@ -24,6 +24,7 @@ import (
 	"encoding/json"
 
 	"github.com/Jeffail/benthos/lib/log"
+	"github.com/Jeffail/benthos/lib/message"
 	"github.com/Jeffail/benthos/lib/metrics"
 	"github.com/Jeffail/benthos/lib/types"
 )
@@ -158,4 +159,10 @@ func (c *Not) Check(msg types.Message) bool {
 	return !c.child.Check(msg)
 }
 
+// CheckPart attempts to check a single part of a batch against a configured
+// condition as if it were a message of its own.
+func (c *Not) CheckPart(msg types.Message, index int) bool {
+	return !message.CheckPart(c.child, msg, index)
+}
+
 //------------------------------------------------------------------------------
@@ -24,6 +24,7 @@ import (
 	"fmt"
 
 	"github.com/Jeffail/benthos/lib/log"
+	"github.com/Jeffail/benthos/lib/message"
 	"github.com/Jeffail/benthos/lib/metrics"
 	"github.com/Jeffail/benthos/lib/types"
 )
@@ -94,4 +95,15 @@ func (c *Or) Check(msg types.Message) bool {
 	return false
 }
 
+// CheckPart attempts to check a single part of a batch against a configured
+// condition as if it were a message of its own.
+func (c *Or) CheckPart(msg types.Message, index int) bool {
+	for _, child := range c.children {
+		if message.CheckPart(child, msg, index) {
+			return true
+		}
+	}
+	return false
+}
+
 //------------------------------------------------------------------------------
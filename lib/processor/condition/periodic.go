@@ -0,0 +1,195 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software or associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, or/or sell
+// copies of the Software, or to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright orice or this permission orice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT or LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE or NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package condition
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/log"
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/text"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors[TypePeriodic] = TypeSpec{
+		constructor: NewPeriodic,
+		description: `
+Periodic is a condition that passes for the first message and then
+periodically, either once every ` + "`count`" + ` messages or for the first
+message after each ` + "`interval`" + ` has elapsed. When both fields are set
+the condition passes when either is reached, and a pass resets both. This is
+useful for sampling heartbeat records from high volume streams, for example by
+routing them to a monitoring output:
+
+` + "``` yaml" + `
+type: periodic
+periodic:
+  interval: 30s
+  key: ${!metadata:kafka_topic}
+` + "```" + `
+
+The field ` + "`key`" + ` supports
+[function interpolations](../config_interpolation.md#functions), which are
+resolved against the whole message batch, and messages are counted and timed
+separately for each unique key. The state of each key is held in memory until
+the key has not been seen for longer than the ` + "`interval`" + `. Without an
+interval the state of a key is never removed, and therefore keys should have a
+bounded number of values.
+
+Like the ` + "`count`" + ` condition, parallel processors containing a periodic
+condition have their own state. It is possible to share the state across
+processor pipelines by defining the condition as a resource.`,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// PeriodicConfig is a configuration struct containing fields for the Periodic
+// condition.
+type PeriodicConfig struct {
+	Count    int    `json:"count" yaml:"count"`
+	Interval string `json:"interval" yaml:"interval"`
+	Key      string `json:"key" yaml:"key"`
+}
+
+// NewPeriodicConfig returns a PeriodicConfig with default values.
+func NewPeriodicConfig() PeriodicConfig {
+	return PeriodicConfig{
+		Count:    0,
+		Interval: "",
+		Key:      "",
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type periodicState struct {
+	count  int
+	passed time.Time
+	seen   time.Time
+}
+
+// Periodic is a condition that passes once every N messages or once per time
+// interval for each interpolated key.
+type Periodic struct {
+	count    int
+	interval time.Duration
+	key      *text.InterpolatedString
+
+	states    map[string]*periodicState
+	lastSweep time.Time
+	mut       sync.Mutex
+
+	mApplied metrics.StatCounter
+	mTrue    metrics.StatCounter
+	mFalse   metrics.StatCounter
+}
+
+// NewPeriodic returns a Periodic condition.
+func NewPeriodic(
+	conf Config, mgr types.Manager, log log.Modular, stats metrics.Type,
+) (Type, error) {
+	if conf.Periodic.Count < 0 {
+		return nil, errors.New("count must not be negative")
+	}
+	var interval time.Duration
+	if len(conf.Periodic.Interval) > 0 {
+		var err error
+		if interval, err = time.ParseDuration(conf.Periodic.Interval); err != nil {
+			return nil, fmt.Errorf("failed to parse interval: %v", err)
+		}
+		if interval <= 0 {
+			return nil, errors.New("interval must be greater than zero")
+		}
+	}
+	if conf.Periodic.Count == 0 && interval == 0 {
+		return nil, errors.New("either a count or an interval must be specified")
+	}
+	return &Periodic{
+		count:     conf.Periodic.Count,
+		interval:  interval,
+		key:       text.NewInterpolatedString(conf.Periodic.Key),
+		states:    map[string]*periodicState{},
+		lastSweep: time.Now(),
+
+		mApplied: stats.GetCounter("condition.periodic.applied"),
+		mTrue:    stats.GetCounter("condition.periodic.true"),
+		mFalse:   stats.GetCounter("condition.periodic.false"),
+	}, nil
+}
+
+//------------------------------------------------------------------------------
+
+// Check attempts to check a message against a configured condition.
+func (c *Periodic) Check(msg types.Message) bool {
+	c.mApplied.Incr(1)
+
+	key := c.key.Get(msg)
+	now := time.Now()
+
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	c.sweep(now)
+
+	state, exists := c.states[key]
+	if !exists {
+		c.states[key] = &periodicState{passed: now, seen: now}
+		c.mTrue.Incr(1)
+		return true
+	}
+
+	state.seen = now
+	state.count++
+	if (c.count > 0 && state.count >= c.count) ||
+		(c.interval > 0 && now.Sub(state.passed) >= c.interval) {
+		state.count = 0
+		state.passed = now
+		c.mTrue.Incr(1)
+		return true
+	}
+	c.mFalse.Incr(1)
+	return false
+}
+
+// sweep removes the state of keys that have not been seen for longer than the
+// interval, which is at most once per interval. The next message of a removed
+// key would have passed regardless, and therefore removing it doesn't change
+// the result. Must be called with mut held.
+func (c *Periodic) sweep(now time.Time) {
+	if c.interval == 0 || now.Sub(c.lastSweep) < c.interval {
+		return
+	}
+	c.lastSweep = now
+	for k, state := range c.states {
+		if now.Sub(state.seen) >= c.interval {
+			delete(c.states, k)
+		}
+	}
+}
+
+//------------------------------------------------------------------------------
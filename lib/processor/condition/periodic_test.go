@@ -0,0 +1,159 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package condition
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/log"
+	"github.com/Jeffail/benthos/lib/message"
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+)
+
+func TestPeriodicCount(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = TypePeriodic
+	conf.Periodic.Count = 3
+
+	c, err := New(conf, nil, log.Noop(), metrics.Noop())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exp := []bool{true, false, false, true, false, false, true}
+	for i, e := range exp {
+		if act := c.Check(message.New(nil)); act != e {
+			t.Errorf("Wrong result at message %v: %v != %v", i, act, e)
+		}
+	}
+}
+
+func TestPeriodicInterval(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = TypePeriodic
+	conf.Periodic.Interval = "50ms"
+
+	c, err := New(conf, nil, log.Noop(), metrics.Noop())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !c.Check(message.New(nil)) {
+		t.Error("Expected first message to pass")
+	}
+	if c.Check(message.New(nil)) {
+		t.Error("Expected message within interval to fail")
+	}
+	<-time.After(time.Millisecond * 60)
+	if !c.Check(message.New(nil)) {
+		t.Error("Expected message after interval to pass")
+	}
+	if c.Check(message.New(nil)) {
+		t.Error("Expected message within interval to fail")
+	}
+}
+
+func TestPeriodicKeys(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = TypePeriodic
+	conf.Periodic.Count = 2
+	conf.Periodic.Key = "${!json_field:id}"
+
+	c, err := New(conf, nil, log.Noop(), metrics.Noop())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg := func(id string) types.Message {
+		return message.New([][]byte{[]byte(`{"id":"` + id + `"}`)})
+	}
+
+	tests := []struct {
+		id  string
+		exp bool
+	}{
+		{"foo", true},
+		{"bar", true},
+		{"foo", false},
+		{"foo", true},
+		{"bar", false},
+		{"bar", true},
+	}
+	for i, test := range tests {
+		if act := c.Check(msg(test.id)); act != test.exp {
+			t.Errorf("Wrong result at message %v (%v): %v != %v", i, test.id, act, test.exp)
+		}
+	}
+}
+
+func TestPeriodicEviction(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = TypePeriodic
+	conf.Periodic.Interval = "50ms"
+	conf.Periodic.Key = "${!json_field:id}"
+
+	c, err := NewPeriodic(conf, nil, log.Noop(), metrics.Noop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := c.(*Periodic)
+
+	for _, id := range []string{"foo", "bar", "baz"} {
+		if !p.Check(message.New([][]byte{[]byte(`{"id":"` + id + `"}`)})) {
+			t.Errorf("Expected first message of %v to pass", id)
+		}
+	}
+	if exp, act := 3, len(p.states); exp != act {
+		t.Errorf("Wrong count of states: %v != %v", act, exp)
+	}
+
+	<-time.After(time.Millisecond * 60)
+	if !p.Check(message.New([][]byte{[]byte(`{"id":"foo"}`)})) {
+		t.Error("Expected message after interval to pass")
+	}
+	if exp, act := 1, len(p.states); exp != act {
+		t.Errorf("Wrong count of states after interval: %v != %v", act, exp)
+	}
+	if p.Check(message.New([][]byte{[]byte(`{"id":"foo"}`)})) {
+		t.Error("Expected message within interval to fail")
+	}
+}
+
+func TestPeriodicBadConfig(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = TypePeriodic
+	if _, err := New(conf, nil, log.Noop(), metrics.Noop()); err == nil {
+		t.Error("Expected error from empty config")
+	}
+
+	conf.Periodic.Interval = "nope"
+	if _, err := New(conf, nil, log.Noop(), metrics.Noop()); err == nil {
+		t.Error("Expected error from bad interval")
+	}
+
+	conf.Periodic.Interval = ""
+	conf.Periodic.Count = -1
+	if _, err := New(conf, nil, log.Noop(), metrics.Noop()); err == nil {
+		t.Error("Expected error from negative count")
+	}
+}
@@ -0,0 +1,228 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package condition
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/Jeffail/benthos/lib/log"
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors[TypeProcessors] = TypeSpec{
+		constructor: NewProcessors,
+		description: `
+Processors is a condition that applies a list of child processors to a copy of
+the message batch, and passes if the processors resulted in at least one message
+part with non-empty content without returning an error. The original message is
+never modified. This allows arbitrary processing logic to drive decisions such
+as routing or filtering.
+
+For example, the following passes when an HTTP service accepts the message:
+
+` + "``` yaml" + `
+type: processors
+processors:
+- type: http
+  http:
+    request:
+      url: http://localhost:8081/check
+      verb: POST
+` + "```" + `
+
+Processors that filter messages, such as ` + "`filter`" + ` or
+` + "`bounds_check`" + `, cause the condition to fail when all messages are
+dropped.`,
+		sanitiseConfigFunc: func(conf Config) (interface{}, error) {
+			provider := getProcessorProvider()
+			if provider == nil {
+				return []interface{}(conf.Processors), nil
+			}
+			return provider.SanitiseProcessors(conf.Processors)
+		},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// ProcessorsConfig is a configuration type containing the configs of child
+// processors for the Processors condition. The configs are parsed by the
+// registered ProcessorProvider.
+type ProcessorsConfig []interface{}
+
+// NewProcessorsConfig returns a ProcessorsConfig with default values.
+func NewProcessorsConfig() ProcessorsConfig {
+	return ProcessorsConfig{}
+}
+
+// UnmarshalYAML converts parsed YAML objects into a form that can also be
+// marshalled as JSON.
+func (p *ProcessorsConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var confs []interface{}
+	if err := unmarshal(&confs); err != nil {
+		return err
+	}
+	for i, c := range confs {
+		confs[i] = normaliseYAML(c)
+	}
+	*p = confs
+	return nil
+}
+
+// normaliseYAML recursively converts the maps of a parsed YAML value into maps
+// with string keys.
+func normaliseYAML(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[interface{}]interface{}:
+		newMap := make(map[string]interface{}, len(t))
+		for k, v := range t {
+			if kStr, ok := k.(string); ok {
+				newMap[kStr] = normaliseYAML(v)
+			}
+		}
+		return newMap
+	case []interface{}:
+		newSlice := make([]interface{}, len(t))
+		for i, v := range t {
+			newSlice[i] = normaliseYAML(v)
+		}
+		return newSlice
+	}
+	return v
+}
+
+//------------------------------------------------------------------------------
+
+// ProcessorProvider parses and constructs the child processors of Processors
+// conditions. Processors cannot be constructed by this package directly as the
+// processor package depends on it.
+type ProcessorProvider interface {
+	// SanitiseProcessors returns a sanitised version of processor configs.
+	SanitiseProcessors(confs []interface{}) (interface{}, error)
+
+	// NewProcessors creates processors from their configs.
+	NewProcessors(
+		confs []interface{}, mgr types.Manager, log log.Modular, stats metrics.Type,
+	) ([]types.Processor, error)
+}
+
+var (
+	processorProvider    ProcessorProvider
+	processorProviderMut sync.RWMutex
+)
+
+// RegisterProcessorProvider sets the ProcessorProvider used by Processors
+// conditions. This is called by the processor package from an init func.
+func RegisterProcessorProvider(p ProcessorProvider) {
+	processorProviderMut.Lock()
+	processorProvider = p
+	processorProviderMut.Unlock()
+}
+
+func getProcessorProvider() ProcessorProvider {
+	processorProviderMut.RLock()
+	defer processorProviderMut.RUnlock()
+	return processorProvider
+}
+
+//------------------------------------------------------------------------------
+
+// Processors is a condition that passes when a list of child processors result
+// in a non-empty message.
+type Processors struct {
+	log   log.Modular
+	procs []types.Processor
+
+	mApplied metrics.StatCounter
+	mTrue    metrics.StatCounter
+	mFalse   metrics.StatCounter
+	mErr     metrics.StatCounter
+}
+
+// NewProcessors returns a Processors condition.
+func NewProcessors(
+	conf Config, mgr types.Manager, log log.Modular, stats metrics.Type,
+) (Type, error) {
+	provider := getProcessorProvider()
+	if provider == nil {
+		return nil, errors.New("no processor provider has been registered")
+	}
+	if len(conf.Processors) == 0 {
+		return nil, errors.New("at least one processor must be specified")
+	}
+	procs, err := provider.NewProcessors(conf.Processors, mgr, log, stats)
+	if err != nil {
+		return nil, err
+	}
+	return &Processors{
+		log:   log.NewModule(".condition.processors"),
+		procs: procs,
+
+		mApplied: stats.GetCounter("condition.processors.applied"),
+		mTrue:    stats.GetCounter("condition.processors.true"),
+		mFalse:   stats.GetCounter("condition.processors.false"),
+		mErr:     stats.GetCounter("condition.processors.error"),
+	}, nil
+}
+
+//------------------------------------------------------------------------------
+
+// Check attempts to check a message against a configured condition.
+func (c *Processors) Check(msg types.Message) bool {
+	c.mApplied.Incr(1)
+
+	msgs := []types.Message{msg.DeepCopy()}
+	for _, proc := range c.procs {
+		var nextMsgs []types.Message
+		for _, m := range msgs {
+			resMsgs, res := proc.ProcessMessage(m)
+			if res != nil && res.Error() != nil {
+				c.mErr.Incr(1)
+				c.log.Debugf("Processor failed: %v\n", res.Error())
+				c.mFalse.Incr(1)
+				return false
+			}
+			nextMsgs = append(nextMsgs, resMsgs...)
+		}
+		if msgs = nextMsgs; len(msgs) == 0 {
+			c.mFalse.Incr(1)
+			return false
+		}
+	}
+
+	for _, m := range msgs {
+		for i := 0; i < m.Len(); i++ {
+			if len(m.Get(i).Get()) > 0 {
+				c.mTrue.Incr(1)
+				return true
+			}
+		}
+	}
+	c.mFalse.Incr(1)
+	return false
+}
+
+//------------------------------------------------------------------------------
@@ -24,6 +24,7 @@ import (
 	"fmt"
 
 	"github.com/Jeffail/benthos/lib/log"
+	"github.com/Jeffail/benthos/lib/message"
 	"github.com/Jeffail/benthos/lib/metrics"
 	"github.com/Jeffail/benthos/lib/types"
 )
@@ -117,4 +118,15 @@ func (c *Resource) Check(msg types.Message) bool {
 	return msg.LazyCondition(c.name, cond)
 }
 
+// CheckPart attempts to check a single part of a batch against a configured
+// condition as if it were a message of its own.
+func (c *Resource) CheckPart(msg types.Message, index int) bool {
+	cond, err := c.mgr.GetCondition(c.name)
+	if err != nil {
+		c.log.Debugf("Failed to obtain condition resource '%v': %v", c.name, err)
+		return false
+	}
+	return message.CheckPart(cond, msg, index)
+}
+
 //------------------------------------------------------------------------------
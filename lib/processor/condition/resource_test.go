@@ -32,12 +32,16 @@ import (
 )
 
 type fakeMgr struct {
-	conds map[string]Type
+	conds  map[string]Type
+	caches map[string]types.Cache
 }
 
 func (f *fakeMgr) RegisterEndpoint(path, desc string, h http.HandlerFunc) {
 }
 func (f *fakeMgr) GetCache(name string) (types.Cache, error) {
+	if c, exists := f.caches[name]; exists {
+		return c, nil
+	}
 	return nil, types.ErrCacheNotFound
 }
 func (f *fakeMgr) GetCondition(name string) (types.Condition, error) {
@@ -52,8 +56,13 @@ func (f *fakeMgr) GetRateLimit(name string) (types.RateLimit, error) {
 func (f *fakeMgr) GetPipe(name string) (<-chan types.Transaction, error) {
 	return nil, types.ErrPipeNotFound
 }
-func (f *fakeMgr) SetPipe(name string, prod <-chan types.Transaction)   {}
-func (f *fakeMgr) UnsetPipe(name string, prod <-chan types.Transaction) {}
+func (f *fakeMgr) SetPipe(name string, prod <-chan types.Transaction)      {}
+func (f *fakeMgr) UnsetPipe(name string, prod <-chan types.Transaction)    {}
+func (f *fakeMgr) SubscribePipe(name string, t chan<- types.Transaction)   {}
+func (f *fakeMgr) UnsubscribePipe(name string, t chan<- types.Transaction) {}
+func (f *fakeMgr) GetPipeSubscribers(name string) []chan<- types.Transaction {
+	return nil
+}
 
 func TestResourceCheck(t *testing.T) {
 	testLog := log.New(os.Stdout, log.Config{LogLevel: "NONE"})
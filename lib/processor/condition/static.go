@@ -59,4 +59,10 @@ func (s *Static) Check(msg types.Message) bool {
 	return s.value
 }
 
+// CheckPart attempts to check a single part of a batch against a configured
+// condition as if it were a message of its own.
+func (s *Static) CheckPart(msg types.Message, index int) bool {
+	return s.value
+}
+
 //------------------------------------------------------------------------------
@@ -272,4 +272,27 @@ func (c *Text) Check(msg types.Message) bool {
 	return c.operator(msgPart)
 }
 
+// CheckPart attempts to check a single part of a batch against a configured
+// condition as if it were a message of its own.
+func (c *Text) CheckPart(msg types.Message, index int) bool {
+	if msg.Len() == 0 {
+		c.mSkippedEmpty.Incr(1)
+		c.mSkipped.Incr(1)
+		return false
+	}
+
+	var msgPart []byte
+	if c.part == 0 || c.part == -1 {
+		msgPart = msg.Get(index).Get()
+	}
+	if msgPart == nil {
+		c.mSkippedOOB.Incr(1)
+		c.mSkipped.Incr(1)
+		return false
+	}
+
+	c.mApplied.Incr(1)
+	return c.operator(msgPart)
+}
+
 //------------------------------------------------------------------------------
@@ -0,0 +1,142 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package condition
+
+import (
+	"testing"
+
+	"github.com/Jeffail/benthos/lib/log"
+	"github.com/Jeffail/benthos/lib/message"
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	yaml "gopkg.in/yaml.v2"
+)
+
+func TestCheckPartMatchesLocked(t *testing.T) {
+	confs := map[string]string{
+		"text": `
+type: text
+text:
+  operator: contains
+  arg: foo`,
+		"text wrong part": `
+type: text
+text:
+  operator: contains
+  part: 1
+  arg: foo`,
+		"jmespath": `
+type: jmespath
+jmespath:
+  query: value == 'foo'`,
+		"jmespath wrong part": `
+type: jmespath
+jmespath:
+  part: 2
+  query: value == 'foo'`,
+		"metadata": `
+type: metadata
+metadata:
+  operator: equals
+  key: foo
+  arg: bar`,
+		"not and or": `
+type: not
+not:
+  type: and
+  and:
+  - type: or
+    or:
+    - type: static
+      static: false
+    - type: text
+      text:
+        operator: contains
+        part: -1
+        arg: foo
+  - type: xor
+    xor:
+    - type: static
+      static: false
+    - type: any
+      any:
+        type: metadata
+        metadata:
+          operator: exists
+          key: foo`,
+		"all": `
+type: all
+all:
+  type: jmespath
+  jmespath:
+    part: -1
+    query: value == 'bar'`,
+		"resource": `
+type: resource
+resource: foo`,
+		"bounds_check": `
+type: bounds_check
+bounds_check:
+  max_parts: 1
+  max_part_size: 20`,
+	}
+
+	msg := message.New([][]byte{
+		[]byte(`{"value":"foo"}`),
+		[]byte(`{"value":"bar"}`),
+		[]byte(`not json foo`),
+		[]byte(`{"value":"this is too large to pass"}`),
+	})
+	msg.Get(0).Metadata().Set("foo", "bar")
+	msg.Get(2).Metadata().Set("foo", "baz")
+
+	fooConf := NewConfig()
+	fooConf.Type = TypeText
+	fooConf.Text.Operator = "contains"
+	fooConf.Text.Arg = "foo"
+	fooCond, err := New(fooConf, nil, log.Noop(), metrics.Noop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	mgr := &fakeMgr{
+		conds: map[string]Type{"foo": fooCond},
+	}
+
+	for name, confStr := range confs {
+		conf := NewConfig()
+		if err := yaml.Unmarshal([]byte(confStr), &conf); err != nil {
+			t.Fatalf("%v: %v", name, err)
+		}
+		c, err := New(conf, mgr, log.Noop(), metrics.Noop())
+		if err != nil {
+			t.Fatalf("%v: %v", name, err)
+		}
+		if _, ok := c.(types.PartCondition); !ok && name != "bounds_check" {
+			t.Errorf("%v: Condition does not implement PartCondition", name)
+		}
+		for i := 0; i < msg.Len(); i++ {
+			exp := c.Check(message.Lock(msg, i))
+			if act := message.CheckPart(c, msg, i); act != exp {
+				t.Errorf("%v: Wrong result for part %v: %v != %v", name, i, act, exp)
+			}
+		}
+	}
+}
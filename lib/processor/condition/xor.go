@@ -24,6 +24,7 @@ import (
 	"fmt"
 
 	"github.com/Jeffail/benthos/lib/log"
+	"github.com/Jeffail/benthos/lib/message"
 	"github.com/Jeffail/benthos/lib/metrics"
 	"github.com/Jeffail/benthos/lib/types"
 )
@@ -100,4 +101,19 @@ func (c *Xor) Check(msg types.Message) bool {
 	return hadTrue
 }
 
+// CheckPart attempts to check a single part of a batch against a configured
+// condition as if it were a message of its own.
+func (c *Xor) CheckPart(msg types.Message, index int) bool {
+	hadTrue := false
+	for _, child := range c.children {
+		if message.CheckPart(child, msg, index) {
+			if hadTrue {
+				return false
+			}
+			hadTrue = true
+		}
+	}
+	return hadTrue
+}
+
 //------------------------------------------------------------------------------
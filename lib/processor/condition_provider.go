@@ -0,0 +1,91 @@
+// Copyright (c) 2017 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package processor
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/Jeffail/benthos/lib/log"
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/processor/condition"
+	"github.com/Jeffail/benthos/lib/types"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	condition.RegisterProcessorProvider(conditionProvider{})
+}
+
+// conditionProvider implements condition.ProcessorProvider in order to
+// construct the child processors of processors conditions.
+type conditionProvider struct{}
+
+func parseConditionProcessors(confs []interface{}) ([]Config, error) {
+	confBytes, err := json.Marshal(confs)
+	if err != nil {
+		return nil, err
+	}
+	procConfs := []Config{}
+	if err = json.Unmarshal(confBytes, &procConfs); err != nil {
+		return nil, fmt.Errorf("failed to parse processor configs: %v", err)
+	}
+	return procConfs, nil
+}
+
+// SanitiseProcessors returns a sanitised version of processor configs.
+func (conditionProvider) SanitiseProcessors(confs []interface{}) (interface{}, error) {
+	procConfs, err := parseConditionProcessors(confs)
+	if err != nil {
+		return nil, err
+	}
+	sanConfs := make([]interface{}, 0, len(procConfs))
+	for _, conf := range procConfs {
+		sanConf, err := SanitiseConfig(conf)
+		if err != nil {
+			return nil, err
+		}
+		sanConfs = append(sanConfs, sanConf)
+	}
+	return sanConfs, nil
+}
+
+// NewProcessors creates processors from their configs.
+func (conditionProvider) NewProcessors(
+	confs []interface{}, mgr types.Manager, log log.Modular, stats metrics.Type,
+) ([]types.Processor, error) {
+	procConfs, err := parseConditionProcessors(confs)
+	if err != nil {
+		return nil, err
+	}
+	procs := make([]types.Processor, 0, len(procConfs))
+	for _, conf := range procConfs {
+		proc, err := New(conf, mgr, log, stats)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create processor '%v': %v", conf.Type, err)
+		}
+		procs = append(procs, proc)
+	}
+	return procs, nil
+}
+
+//------------------------------------------------------------------------------
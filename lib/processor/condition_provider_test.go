@@ -0,0 +1,145 @@
+// Copyright (c) 2017 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package processor
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Jeffail/benthos/lib/log"
+	"github.com/Jeffail/benthos/lib/message"
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/processor/condition"
+	"github.com/Jeffail/benthos/lib/types"
+	yaml "gopkg.in/yaml.v2"
+)
+
+func TestProcessorsCondition(t *testing.T) {
+	input := `
+type: processors
+processors:
+- type: text
+  text:
+    operator: replace_regexp
+    arg: "^(?:foo|bar)$"
+    value: ""
+`
+	conf := condition.NewConfig()
+	if err := yaml.Unmarshal([]byte(input), &conf); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := condition.New(conf, types.NoopMgr(), log.Noop(), metrics.Noop())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]bool{
+		"foo": false,
+		"bar": false,
+		"baz": true,
+	}
+	for content, exp := range tests {
+		msg := message.New([][]byte{[]byte(content)})
+		if act := c.Check(msg); act != exp {
+			t.Errorf("Wrong result for '%v': %v != %v", content, act, exp)
+		}
+		if exp, act := content, string(msg.Get(0).Get()); exp != act {
+			t.Errorf("Original message was modified: %v != %v", act, exp)
+		}
+	}
+}
+
+func TestProcessorsConditionFiltered(t *testing.T) {
+	conf := condition.NewConfig()
+	conf.Type = condition.TypeProcessors
+	conf.Processors = condition.ProcessorsConfig{
+		map[string]interface{}{
+			"type": "bounds_check",
+			"bounds_check": map[string]interface{}{
+				"min_part_size": 5,
+			},
+		},
+	}
+
+	c, err := condition.New(conf, types.NoopMgr(), log.Noop(), metrics.Noop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Check(message.New([][]byte{[]byte("foo")})) {
+		t.Error("Expected filtered message to fail")
+	}
+	if !c.Check(message.New([][]byte{[]byte("foo bar")})) {
+		t.Error("Expected message to pass")
+	}
+}
+
+func TestProcessorsConditionSanitise(t *testing.T) {
+	input := `
+type: processors
+processors:
+- type: text
+  text:
+    operator: to_upper
+`
+	conf := condition.NewConfig()
+	if err := yaml.Unmarshal([]byte(input), &conf); err != nil {
+		t.Fatal(err)
+	}
+
+	sanit, err := condition.SanitiseConfig(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sanitBytes, err := yaml.Marshal(sanit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := `type: processors
+processors:
+- type: text
+  text:
+    arg: ""
+    operator: to_upper
+    parts: []
+    value: ""
+`
+	if act := string(sanitBytes); !reflect.DeepEqual(exp, act) {
+		t.Errorf("Wrong sanitised config: %v != %v", act, exp)
+	}
+}
+
+func TestProcessorsConditionBadConfig(t *testing.T) {
+	conf := condition.NewConfig()
+	conf.Type = condition.TypeProcessors
+	if _, err := condition.New(conf, types.NoopMgr(), log.Noop(), metrics.Noop()); err == nil {
+		t.Error("Expected error from empty processors")
+	}
+
+	conf.Processors = condition.ProcessorsConfig{
+		map[string]interface{}{
+			"type": "does_not_exist",
+		},
+	}
+	if _, err := condition.New(conf, types.NoopMgr(), log.Noop(), metrics.Noop()); err == nil {
+		t.Error("Expected error from bad processor")
+	}
+}
@@ -48,6 +48,11 @@ type TypeSpec struct {
 	sanitiseConfigFunc func(conf Config) (interface{}, error)
 }
 
+// Description returns the markdown description of the type.
+func (t TypeSpec) Description() string {
+	return t.description
+}
+
 // Constructors is a map of all processor types with their specs.
 var Constructors = map[string]TypeSpec{}
 
@@ -86,6 +91,7 @@ const (
 	TypeSplit        = "split"
 	TypeText         = "text"
 	TypeThrottle     = "throttle"
+	TypeTTL          = "ttl"
 	TypeUnarchive    = "unarchive"
 )
 
@@ -125,6 +131,7 @@ type Config struct {
 	Split        SplitConfig        `json:"split" yaml:"split"`
 	Text         TextConfig         `json:"text" yaml:"text"`
 	Throttle     ThrottleConfig     `json:"throttle" yaml:"throttle"`
+	TTL          TTLConfig          `json:"ttl" yaml:"ttl"`
 	Unarchive    UnarchiveConfig    `json:"unarchive" yaml:"unarchive"`
 }
 
@@ -163,6 +170,7 @@ func NewConfig() Config {
 		Split:        NewSplitConfig(),
 		Text:         NewTextConfig(),
 		Throttle:     NewThrottleConfig(),
+		TTL:          NewTTLConfig(),
 		Unarchive:    NewUnarchiveConfig(),
 	}
 }
@@ -358,13 +366,19 @@ func New(
 	log log.Modular,
 	stats metrics.Type,
 ) (Type, error) {
+	var proc Type
+	var err error
 	if c, ok := Constructors[conf.Type]; ok {
-		return c.constructor(conf, mgr, log, stats)
+		proc, err = c.constructor(conf, mgr, log, stats)
+	} else if c, ok := pluginSpecs[conf.Type]; ok {
+		proc, err = c.constructor(conf.Plugin, mgr, log, stats)
+	} else {
+		return nil, types.ErrInvalidProcessorType
 	}
-	if c, ok := pluginSpecs[conf.Type]; ok {
-		return c.constructor(conf.Plugin, mgr, log, stats)
+	if err != nil {
+		return nil, err
 	}
-	return nil, types.ErrInvalidProcessorType
+	return newTimed(conf.Type, proc, stats), nil
 }
 
 //------------------------------------------------------------------------------
@@ -89,7 +89,7 @@ type Decode struct {
 
 	mCount     metrics.StatCounter
 	mSucc      metrics.StatCounter
-	mErr       metrics.StatCounter
+	mErr       *errorStats
 	mSkipped   metrics.StatCounter
 	mSent      metrics.StatCounter
 	mSentParts metrics.StatCounter
@@ -111,7 +111,7 @@ func NewDecode(
 
 		mCount:     stats.GetCounter("processor.decode.count"),
 		mSucc:      stats.GetCounter("processor.decode.success"),
-		mErr:       stats.GetCounter("processor.decode.error"),
+		mErr:       newErrorStats("decode", stats),
 		mSkipped:   stats.GetCounter("processor.decode.skipped"),
 		mSent:      stats.GetCounter("processor.decode.sent"),
 		mSentParts: stats.GetCounter("processor.decode.parts.sent"),
@@ -143,7 +143,7 @@ func (c *Decode) ProcessMessage(msg types.Message) ([]types.Message, types.Respo
 			newMsg.Get(index).Set(newPart)
 		} else {
 			c.log.Errorf("Failed to decode message part: %v\n", err)
-			c.mErr.Incr(1)
+			c.mErr.Incr(ErrClassParse)
 		}
 	}
 
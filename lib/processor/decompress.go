@@ -28,11 +28,13 @@ import (
 	"compress/zlib"
 	"fmt"
 	"io"
+	"sync"
 
 	"github.com/Jeffail/benthos/lib/log"
 	"github.com/Jeffail/benthos/lib/metrics"
 	"github.com/Jeffail/benthos/lib/response"
 	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/pool"
 )
 
 //------------------------------------------------------------------------------
@@ -69,57 +71,81 @@ func NewDecompressConfig() DecompressConfig {
 
 type decompressFunc func(bytes []byte) ([]byte, error)
 
-func gzipDecompress(b []byte) ([]byte, error) {
-	buf := bytes.NewBuffer(b)
-	zr, err := gzip.NewReader(buf)
-	if err != nil {
+// Decompression readers are pooled as they allocate large amounts of internal
+// state.
+var (
+	gzipReaders  sync.Pool
+	zlibReaders  sync.Pool
+	flateReaders sync.Pool
+)
+
+// readAll reads the contents of a reader into a pooled buffer and returns a
+// copy of the result.
+func readAll(r io.Reader) ([]byte, error) {
+	outBuf := pool.GetBuffer()
+	if _, err := outBuf.ReadFrom(r); err != nil && err != io.EOF {
+		pool.PutBuffer(outBuf)
 		return nil, err
 	}
+	return pool.Detach(outBuf), nil
+}
 
-	outBuf := bytes.Buffer{}
-	if _, err = outBuf.ReadFrom(zr); err != nil && err != io.EOF {
+func gzipDecompress(b []byte) ([]byte, error) {
+	buf := bytes.NewReader(b)
+	zr, _ := gzipReaders.Get().(*gzip.Reader)
+	if zr == nil {
+		var err error
+		if zr, err = gzip.NewReader(buf); err != nil {
+			return nil, err
+		}
+	} else if err := zr.Reset(buf); err != nil {
+		gzipReaders.Put(zr)
 		return nil, err
 	}
+
+	out, err := readAll(zr)
 	zr.Close()
-	return outBuf.Bytes(), nil
+	gzipReaders.Put(zr)
+	return out, err
 }
 
 func zlibDecompress(b []byte) ([]byte, error) {
-	buf := bytes.NewBuffer(b)
-	zr, err := zlib.NewReader(buf)
-	if err != nil {
+	buf := bytes.NewReader(b)
+	zr, _ := zlibReaders.Get().(io.ReadCloser)
+	if zr == nil {
+		var err error
+		if zr, err = zlib.NewReader(buf); err != nil {
+			return nil, err
+		}
+	} else if err := zr.(zlib.Resetter).Reset(buf, nil); err != nil {
+		zlibReaders.Put(zr)
 		return nil, err
 	}
 
-	outBuf := bytes.Buffer{}
-	if _, err = outBuf.ReadFrom(zr); err != nil && err != io.EOF {
-		return nil, err
-	}
+	out, err := readAll(zr)
 	zr.Close()
-	return outBuf.Bytes(), nil
+	zlibReaders.Put(zr)
+	return out, err
 }
 
 func flateDecompress(b []byte) ([]byte, error) {
-	buf := bytes.NewBuffer(b)
-	zr := flate.NewReader(buf)
-
-	outBuf := bytes.Buffer{}
-	if _, err := outBuf.ReadFrom(zr); err != nil && err != io.EOF {
+	buf := bytes.NewReader(b)
+	zr, _ := flateReaders.Get().(io.ReadCloser)
+	if zr == nil {
+		zr = flate.NewReader(buf)
+	} else if err := zr.(flate.Resetter).Reset(buf, nil); err != nil {
+		flateReaders.Put(zr)
 		return nil, err
 	}
+
+	out, err := readAll(zr)
 	zr.Close()
-	return outBuf.Bytes(), nil
+	flateReaders.Put(zr)
+	return out, err
 }
 
 func bzip2Decompress(b []byte) ([]byte, error) {
-	buf := bytes.NewBuffer(b)
-	zr := bzip2.NewReader(buf)
-
-	outBuf := bytes.Buffer{}
-	if _, err := outBuf.ReadFrom(zr); err != nil && err != io.EOF {
-		return nil, err
-	}
-	return outBuf.Bytes(), nil
+	return readAll(bzip2.NewReader(bytes.NewReader(b)))
 }
 
 func strToDecompressor(str string) (decompressFunc, error) {
@@ -149,7 +175,7 @@ type Decompress struct {
 
 	mCount     metrics.StatCounter
 	mSucc      metrics.StatCounter
-	mErr       metrics.StatCounter
+	mErr       *errorStats
 	mSkipped   metrics.StatCounter
 	mSent      metrics.StatCounter
 	mSentParts metrics.StatCounter
@@ -171,7 +197,7 @@ func NewDecompress(
 
 		mCount:     stats.GetCounter("processor.decompress.count"),
 		mSucc:      stats.GetCounter("processor.decompress.success"),
-		mErr:       stats.GetCounter("processor.decompress.error"),
+		mErr:       newErrorStats("decompress", stats),
 		mSkipped:   stats.GetCounter("processor.decompress.skipped"),
 		mSent:      stats.GetCounter("processor.decompress.sent"),
 		mSentParts: stats.GetCounter("processor.decompress.parts.sent"),
@@ -202,7 +228,7 @@ func (d *Decompress) ProcessMessage(msg types.Message) ([]types.Message, types.R
 			d.mSucc.Incr(1)
 			newMsg.Get(index).Set(newPart)
 		} else {
-			d.mErr.Incr(1)
+			d.mErr.Incr(ErrClassParse)
 		}
 	}
 
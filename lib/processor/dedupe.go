@@ -161,6 +161,7 @@ type Dedupe struct {
 	hasherFunc hasherFunc
 
 	mCount     metrics.StatCounter
+	mErr       *errorStats
 	mErrJSON   metrics.StatCounter
 	mDropped   metrics.StatCounter
 	mErrHash   metrics.StatCounter
@@ -198,6 +199,7 @@ func NewDedupe(
 		hasherFunc: hFunc,
 
 		mCount:     stats.GetCounter("processor.dedupe.count"),
+		mErr:       newErrorStats("dedupe", stats),
 		mErrJSON:   stats.GetCounter("processor.dedupe.error.json_parse"),
 		mDropped:   stats.GetCounter("processor.dedupe.dropped"),
 		mErrHash:   stats.GetCounter("processor.dedupe.error.hash"),
@@ -230,6 +232,7 @@ func (d *Dedupe) ProcessMessage(msg types.Message) ([]types.Message, types.Respo
 			if partBytes := msg.Get(index).Get(); partBytes != nil {
 				if _, err := hasher.Write(msg.Get(index).Get()); nil != err {
 					d.mErrHash.Incr(1)
+					d.mErr.IncrErr(err)
 					d.mDropped.Incr(1)
 					d.log.Errorf("Hash error: %v\n", err)
 				} else {
@@ -247,6 +250,7 @@ func (d *Dedupe) ProcessMessage(msg types.Message) ([]types.Message, types.Respo
 	} else if err := d.cache.Add(string(hasher.Bytes()), []byte{'t'}); err != nil {
 		if err != types.ErrKeyAlreadyExists {
 			d.mErrCache.Incr(1)
+			d.mErr.IncrErr(err)
 			d.log.Errorf("Cache error: %v\n", err)
 			if d.conf.Dedupe.DropOnCacheErr {
 				d.mDropped.Incr(1)
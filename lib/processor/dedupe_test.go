@@ -65,8 +65,13 @@ func (f *fakeMgr) GetRateLimit(name string) (types.RateLimit, error) {
 func (f *fakeMgr) GetPipe(name string) (<-chan types.Transaction, error) {
 	return nil, types.ErrPipeNotFound
 }
-func (f *fakeMgr) SetPipe(name string, prod <-chan types.Transaction)   {}
-func (f *fakeMgr) UnsetPipe(name string, prod <-chan types.Transaction) {}
+func (f *fakeMgr) SetPipe(name string, prod <-chan types.Transaction)      {}
+func (f *fakeMgr) UnsetPipe(name string, prod <-chan types.Transaction)    {}
+func (f *fakeMgr) SubscribePipe(name string, t chan<- types.Transaction)   {}
+func (f *fakeMgr) UnsubscribePipe(name string, t chan<- types.Transaction) {}
+func (f *fakeMgr) GetPipeSubscribers(name string) []chan<- types.Transaction {
+	return nil
+}
 
 func TestDedupe(t *testing.T) {
 	rndText1 := randStringRunes(20)
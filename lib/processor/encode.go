@@ -93,7 +93,7 @@ type Encode struct {
 
 	mCount     metrics.StatCounter
 	mSucc      metrics.StatCounter
-	mErr       metrics.StatCounter
+	mErr       *errorStats
 	mSkipped   metrics.StatCounter
 	mSent      metrics.StatCounter
 	mSentParts metrics.StatCounter
@@ -115,7 +115,7 @@ func NewEncode(
 
 		mCount:     stats.GetCounter("processor.encode.count"),
 		mSucc:      stats.GetCounter("processor.encode.success"),
-		mErr:       stats.GetCounter("processor.encode.error"),
+		mErr:       newErrorStats("encode", stats),
 		mSkipped:   stats.GetCounter("processor.encode.skipped"),
 		mSent:      stats.GetCounter("processor.encode.sent"),
 		mSentParts: stats.GetCounter("processor.encode.parts.sent"),
@@ -147,7 +147,7 @@ func (c *Encode) ProcessMessage(msg types.Message) ([]types.Message, types.Respo
 			newMsg.Get(index).Set(newPart)
 		} else {
 			c.log.Debugf("Failed to encode message part: %v\n", err)
-			c.mErr.Incr(1)
+			c.mErr.IncrErr(err)
 		}
 	}
 
@@ -0,0 +1,109 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package processor
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net"
+	"strconv"
+
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+)
+
+//------------------------------------------------------------------------------
+
+// Error classes that processor errors are broken down by, each class is counted
+// under the metric path processor.<type>.error.<class>.
+const (
+	ErrClassParse   = "parse"
+	ErrClassTimeout = "timeout"
+	ErrClassHTTP4xx = "http_4xx"
+	ErrClassHTTP5xx = "http_5xx"
+	ErrClassOther   = "other"
+)
+
+var errClasses = []string{
+	ErrClassParse, ErrClassTimeout, ErrClassHTTP4xx, ErrClassHTTP5xx, ErrClassOther,
+}
+
+// ClassifyError returns the error class of an error returned while processing
+// a message.
+func ClassifyError(err error) string {
+	switch t := err.(type) {
+	case types.ErrUnexpectedHTTPRes:
+		if t.Code >= 500 {
+			return ErrClassHTTP5xx
+		}
+		if t.Code >= 400 {
+			return ErrClassHTTP4xx
+		}
+	case *json.SyntaxError, *json.UnmarshalTypeError, *strconv.NumError, base64.CorruptInputError:
+		return ErrClassParse
+	case net.Error:
+		if t.Timeout() {
+			return ErrClassTimeout
+		}
+	}
+	if err == types.ErrTimeout || err == context.DeadlineExceeded {
+		return ErrClassTimeout
+	}
+	return ErrClassOther
+}
+
+//------------------------------------------------------------------------------
+
+// errorStats counts the errors of a processor under the metric path
+// processor.<type>.error, as well as under a path for the class of each error.
+type errorStats struct {
+	mErr     metrics.StatCounter
+	mClasses map[string]metrics.StatCounter
+}
+
+func newErrorStats(typeStr string, stats metrics.Type) *errorStats {
+	e := &errorStats{
+		mErr:     stats.GetCounter("processor." + typeStr + ".error"),
+		mClasses: make(map[string]metrics.StatCounter, len(errClasses)),
+	}
+	for _, class := range errClasses {
+		e.mClasses[class] = stats.GetCounter("processor." + typeStr + ".error." + class)
+	}
+	return e
+}
+
+// Incr counts an error of a class.
+func (e *errorStats) Incr(class string) {
+	e.mErr.Incr(1)
+	if m, exists := e.mClasses[class]; exists {
+		m.Incr(1)
+	} else {
+		e.mClasses[ErrClassOther].Incr(1)
+	}
+}
+
+// IncrErr counts an error with the class returned by ClassifyError.
+func (e *errorStats) IncrErr(err error) {
+	e.Incr(ClassifyError(err))
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,104 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package processor
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"testing"
+
+	"github.com/Jeffail/benthos/lib/log"
+	"github.com/Jeffail/benthos/lib/message"
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+)
+
+type timeoutErr struct{}
+
+func (timeoutErr) Error() string   { return "timed out" }
+func (timeoutErr) Timeout() bool   { return true }
+func (timeoutErr) Temporary() bool { return true }
+
+func TestClassifyError(t *testing.T) {
+	_, numErr := strconv.Atoi("nope")
+	jsonErr := json.Unmarshal([]byte("{"), &struct{}{})
+
+	tests := map[string]struct {
+		err   error
+		class string
+	}{
+		"json syntax":  {err: jsonErr, class: ErrClassParse},
+		"number":       {err: numErr, class: ErrClassParse},
+		"net timeout":  {err: timeoutErr{}, class: ErrClassTimeout},
+		"timeout":      {err: types.ErrTimeout, class: ErrClassTimeout},
+		"http 404":     {err: types.ErrUnexpectedHTTPRes{Code: 404}, class: ErrClassHTTP4xx},
+		"http 503":     {err: types.ErrUnexpectedHTTPRes{Code: 503}, class: ErrClassHTTP5xx},
+		"http 302":     {err: types.ErrUnexpectedHTTPRes{Code: 302}, class: ErrClassOther},
+		"unrecognised": {err: errors.New("foo"), class: ErrClassOther},
+	}
+
+	for name, test := range tests {
+		if exp, act := test.class, ClassifyError(test.err); exp != act {
+			t.Errorf("Wrong class for %v: %v != %v", name, act, exp)
+		}
+	}
+}
+
+func TestProcessorErrorMetrics(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = TypeJSON
+	conf.JSON.Operator = "select"
+	conf.JSON.Path = "foo"
+
+	stats := metrics.NewLocal()
+	proc, err := New(conf, nil, log.Noop(), stats)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	proc.ProcessMessage(message.New([][]byte{
+		[]byte(`not json`),
+		[]byte(`{"foo":"bar"}`),
+		[]byte(`also not json`),
+	}))
+
+	counters := stats.GetCounters()
+	for path, exp := range map[string]int64{
+		"processor.json.error":            2,
+		"processor.json.error.parse":      2,
+		"processor.json.error.json_parse": 2,
+		"processor.json.error.timeout":    0,
+		"processor.json.error.other":      0,
+	} {
+		if act := counters[path]; exp != act {
+			t.Errorf("Wrong count for %v: %v != %v", path, act, exp)
+		}
+	}
+
+	timings := stats.GetTimings()
+	if exp, act := int64(3), timings["processor.json.batch.received"]; exp != act {
+		t.Errorf("Wrong batch received size: %v != %v", act, exp)
+	}
+	if exp, act := int64(3), timings["processor.json.batch.sent"]; exp != act {
+		t.Errorf("Wrong batch sent size: %v != %v", act, exp)
+	}
+}
@@ -119,7 +119,7 @@ func (c *FilterParts) ProcessMessage(msg types.Message) ([]types.Message, types.
 	newMsg := message.New(nil)
 
 	for i := 0; i < msg.Len(); i++ {
-		if c.condition.Check(message.Lock(msg, i)) {
+		if message.CheckPart(c.condition, msg, i) {
 			newMsg.Append(msg.Get(i).Copy())
 		} else {
 			c.mPartDropped.Incr(1)
@@ -105,3 +105,40 @@ func TestFilterPartsTextCheck(t *testing.T) {
 		})
 	}
 }
+
+func BenchmarkFilterPartsJMESPath(b *testing.B) {
+	conf := NewConfig()
+	conf.Type = "filter_parts"
+	conf.FilterParts.Type = "jmespath"
+	conf.FilterParts.JMESPath.Query = "value == 'foo'"
+
+	c, err := New(conf, nil, log.Noop(), metrics.Noop())
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	parts := make([][]byte, 100)
+	for i := range parts {
+		if i%2 == 0 {
+			parts[i] = []byte(`{"value":"foo"}`)
+		} else {
+			parts[i] = []byte(`{"value":"bar"}`)
+		}
+	}
+	msg := message.New(parts)
+	for i := 0; i < msg.Len(); i++ {
+		if _, err = msg.Get(i).JSON(); err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		msgs, _ := c.ProcessMessage(msg)
+		if len(msgs) != 1 || msgs[0].Len() != 50 {
+			b.Fatal("Unexpected filter result")
+		}
+	}
+}
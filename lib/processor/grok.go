@@ -83,6 +83,7 @@ type Grok struct {
 	stats metrics.Type
 
 	mCount     metrics.StatCounter
+	mErr       *errorStats
 	mErrGrok   metrics.StatCounter
 	mErrJSONS  metrics.StatCounter
 	mSucc      metrics.StatCounter
@@ -120,6 +121,7 @@ func NewGrok(
 		stats:    stats,
 
 		mCount:     stats.GetCounter("processor.grok.count"),
+		mErr:       newErrorStats("grok", stats),
 		mErrGrok:   stats.GetCounter("processor.grok.error.grok_no_matches"),
 		mErrJSONS:  stats.GetCounter("processor.grok.error.json_set"),
 		mSucc:      stats.GetCounter("processor.grok.success"),
@@ -163,12 +165,14 @@ func (g *Grok) ProcessMessage(msg types.Message) ([]types.Message, types.Respons
 
 		if len(values) == 0 {
 			g.mErrGrok.Incr(1)
+			g.mErr.Incr(ErrClassParse)
 			g.log.Debugf("No matches found for payload: %s\n", body)
 			continue
 		}
 
 		if err := newMsg.Get(index).SetJSON(values); err != nil {
 			g.mErrJSONS.Incr(1)
+			g.mErr.IncrErr(err)
 			g.log.Debugf("Failed to convert grok result into json: %v\n", err)
 		} else {
 			g.mSucc.Incr(1)
@@ -121,7 +121,7 @@ type Hash struct {
 
 	mCount     metrics.StatCounter
 	mSucc      metrics.StatCounter
-	mErr       metrics.StatCounter
+	mErr       *errorStats
 	mSkipped   metrics.StatCounter
 	mSent      metrics.StatCounter
 	mSentParts metrics.StatCounter
@@ -143,7 +143,7 @@ func NewHash(
 
 		mCount:     stats.GetCounter("processor.hash.count"),
 		mSucc:      stats.GetCounter("processor.hash.success"),
-		mErr:       stats.GetCounter("processor.hash.error"),
+		mErr:       newErrorStats("hash", stats),
 		mSkipped:   stats.GetCounter("processor.hash.skipped"),
 		mSent:      stats.GetCounter("processor.hash.sent"),
 		mSentParts: stats.GetCounter("processor.hash.parts.sent"),
@@ -167,7 +167,7 @@ func (c *Hash) ProcessMessage(msg types.Message) ([]types.Message, types.Respons
 			newMsg.Get(index).Set(newPart)
 		} else {
 			c.log.Debugf("Failed to hash message part: %v\n", err)
-			c.mErr.Incr(1)
+			c.mErr.IncrErr(err)
 		}
 	}
 
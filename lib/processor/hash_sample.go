@@ -84,6 +84,7 @@ type HashSample struct {
 	stats metrics.Type
 
 	mCount     metrics.StatCounter
+	mErr       *errorStats
 	mDropOOB   metrics.StatCounter
 	mDropped   metrics.StatCounter
 	mErrHash   metrics.StatCounter
@@ -101,6 +102,7 @@ func NewHashSample(
 		stats: stats,
 
 		mCount:     stats.GetCounter("processor.hash_sample.count"),
+		mErr:       newErrorStats("hash_sample", stats),
 		mDropOOB:   stats.GetCounter("processor.hash_sample.dropped_part_out_of_bounds"),
 		mDropped:   stats.GetCounter("processor.hash_sample.dropped"),
 		mErrHash:   stats.GetCounter("processor.hash_sample.hashing_error"),
@@ -136,6 +138,7 @@ func (s *HashSample) ProcessMessage(msg types.Message) ([]types.Message, types.R
 		// Attempt to add part to hash.
 		if _, err := hash.Write(msg.Get(index).Get()); nil != err {
 			s.mErrHash.Incr(1)
+			s.mErr.IncrErr(err)
 			s.log.Debugf("Cannot hash message part for sampling: %v\n", err)
 			return nil, response.NewAck()
 		}
@@ -59,6 +59,28 @@ parallel components service wide.
 The URL and header values of this type can be dynamically set using function
 interpolations described [here](../config_interpolation.md#functions).
 
+Requests can be authenticated with an OAuth2 client credentials exchange by
+enabling the ` + "`request.oauth2`" + ` section, in which case access tokens are
+fetched from the configured ` + "`token_url`" + ` and refreshed before they
+expire.
+
+Requests are retried whenever the response code is outside the range of 200 ->
+299 inclusive, with exponential backoff for codes within ` + "`request.backoff_on`" + `.
+Codes within ` + "`request.drop_on`" + ` are not retried and are treated as
+successful, whereas codes within ` + "`request.fail_on`" + ` are treated as
+permanent failures that are not retried.
+
+### Capturing Responses
+
+When ` + "`response_metadata`" + ` is set the original message parts are kept and
+the body of the response is added to them as a metadata field of that name
+instead. The list ` + "`response_metadata_codes`" + ` can be used in order to
+only capture the response for certain status codes, and when the response code
+is not listed the message is left unchanged. If a batch is sent as a single
+multipart request then each response part is captured into the message part of
+the same index when the counts match, otherwise the first response part is
+captured into all of them.
+
 In order to map or encode the payload to a specific request body, and map the
 response back into the original payload instead of replacing it entirely, you
 can use the ` + "[`process_map`](#process_map)" + ` or
@@ -70,17 +92,21 @@ can use the ` + "[`process_map`](#process_map)" + ` or
 
 // HTTPConfig contains configuration fields for the HTTP processor.
 type HTTPConfig struct {
-	Client      client.Config `json:"request" yaml:"request"`
-	Parallel    bool          `json:"parallel" yaml:"parallel"`
-	MaxParallel int           `json:"max_parallel" yaml:"max_parallel"`
+	Client            client.Config `json:"request" yaml:"request"`
+	Parallel          bool          `json:"parallel" yaml:"parallel"`
+	MaxParallel       int           `json:"max_parallel" yaml:"max_parallel"`
+	ResponseMeta      string        `json:"response_metadata" yaml:"response_metadata"`
+	ResponseMetaCodes []int         `json:"response_metadata_codes" yaml:"response_metadata_codes"`
 }
 
 // NewHTTPConfig returns a HTTPConfig with default values.
 func NewHTTPConfig() HTTPConfig {
 	return HTTPConfig{
-		Client:      client.NewConfig(),
-		Parallel:    false,
-		MaxParallel: 0,
+		Client:            client.NewConfig(),
+		Parallel:          false,
+		MaxParallel:       0,
+		ResponseMeta:      "",
+		ResponseMetaCodes: []int{},
 	}
 }
 
@@ -94,13 +120,15 @@ type HTTP struct {
 	parallel bool
 	max      int
 
+	responseCodes map[int]struct{}
+
 	conf  Config
 	log   log.Modular
 	stats metrics.Type
 
 	mCount     metrics.StatCounter
 	mErrHTTP   metrics.StatCounter
-	mErr       metrics.StatCounter
+	mErr       *errorStats
 	mSucc      metrics.StatCounter
 	mSent      metrics.StatCounter
 	mSentParts metrics.StatCounter
@@ -118,19 +146,25 @@ func NewHTTP(
 		parallel: conf.HTTP.Parallel,
 		max:      conf.HTTP.MaxParallel,
 
+		responseCodes: map[int]struct{}{},
+
 		mCount:     stats.GetCounter("processor.http.count"),
 		mSucc:      stats.GetCounter("processor.http.success"),
-		mErr:       stats.GetCounter("processor.http.error"),
+		mErr:       newErrorStats("http", stats),
 		mErrHTTP:   stats.GetCounter("processor.http.error.http"),
 		mSent:      stats.GetCounter("processor.http.sent"),
 		mSentParts: stats.GetCounter("processor.http.parts.sent"),
 	}
+	for _, c := range conf.HTTP.ResponseMetaCodes {
+		g.responseCodes[c] = struct{}{}
+	}
 	var err error
 	if g.client, err = client.New(
 		conf.HTTP.Client,
 		client.OptSetLogger(g.log),
 		client.OptSetStats(metrics.Namespaced(g.stats, "processor.http")),
 		client.OptSetManager(mgr),
+		client.OptSetTracingComponent("http"),
 	); err != nil {
 		return nil, err
 	}
@@ -139,6 +173,50 @@ func NewHTTP(
 
 //------------------------------------------------------------------------------
 
+// send performs a request with a message and returns the parsed response. If a
+// response metadata key is configured then the request parts are returned
+// instead, with the response body captured as metadata.
+func (h *HTTP) send(msg types.Message) (types.Message, error) {
+	key := h.conf.HTTP.ResponseMeta
+	if len(key) == 0 {
+		return h.client.Send(msg)
+	}
+
+	res, err := h.client.Do(msg)
+	if err != nil {
+		return nil, err
+	}
+	code := res.StatusCode
+	resMsg, err := h.client.ParseResponse(res)
+	if err != nil {
+		return nil, err
+	}
+
+	newMsg := message.New(nil)
+	msg.Iter(func(i int, p types.Part) error {
+		newMsg.Append(p.Copy())
+		return nil
+	})
+	if len(h.responseCodes) > 0 {
+		if _, exists := h.responseCodes[code]; !exists {
+			return newMsg, nil
+		}
+	}
+	newMsg.Iter(func(i int, p types.Part) error {
+		var body string
+		if resMsg != nil && resMsg.Len() > 0 {
+			j := 0
+			if resMsg.Len() == newMsg.Len() {
+				j = i
+			}
+			body = string(resMsg.Get(j).Get())
+		}
+		p.Metadata().Set(key, body)
+		return nil
+	})
+	return newMsg, nil
+}
+
 // ProcessMessage applies the processor to a message, either creating >0
 // resulting messages or a response to be sent back to the message source.
 func (h *HTTP) ProcessMessage(msg types.Message) ([]types.Message, types.Response) {
@@ -148,9 +226,9 @@ func (h *HTTP) ProcessMessage(msg types.Message) ([]types.Message, types.Respons
 	if !h.parallel || msg.Len() == 1 {
 		// Easy, just do a single request.
 		var err error
-		if responseMsg, err = h.client.Send(msg); err != nil {
+		if responseMsg, err = h.send(msg); err != nil {
 			if err != nil {
-				h.mErr.Incr(1)
+				h.mErr.IncrErr(err)
 				h.mErrHTTP.Incr(1)
 				return nil, response.NewError(fmt.Errorf(
 					"HTTP request '%v' failed: %v", h.conf.HTTP.Client.URL, err,
@@ -174,7 +252,7 @@ func (h *HTTP) ProcessMessage(msg types.Message) ([]types.Message, types.Respons
 		for i := 0; i < max; i++ {
 			go func() {
 				for index := range reqChan {
-					result, err := h.client.Send(message.Lock(msg, index))
+					result, err := h.send(message.Lock(msg, index))
 					if err == nil && result.Len() != 1 {
 						err = fmt.Errorf("unexpected response size: %v", result.Len())
 					}
@@ -192,6 +270,7 @@ func (h *HTTP) ProcessMessage(msg types.Message) ([]types.Message, types.Respons
 		}()
 		for i := 0; i < msg.Len(); i++ {
 			if err := <-resChan; err != nil {
+				h.mErr.IncrErr(err)
 				h.mErrHTTP.Incr(1)
 				h.log.Errorf("HTTP parallel request to '%v' failed: %v\n", h.conf.HTTP.Client.URL, err)
 			}
@@ -203,7 +282,7 @@ func (h *HTTP) ProcessMessage(msg types.Message) ([]types.Message, types.Respons
 	}
 
 	if responseMsg.Len() < 1 {
-		h.mErr.Incr(1)
+		h.mErr.Incr(ErrClassOther)
 		h.mErrHTTP.Incr(1)
 		return nil, response.NewError(fmt.Errorf(
 			"HTTP response from '%v' was empty", h.conf.HTTP.Client.URL,
@@ -62,6 +62,47 @@ func TestHTTPClientRetries(t *testing.T) {
 	}
 }
 
+func TestHTTPClientResponseMetadata(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		if string(body) == "miss" {
+			w.WriteHeader(http.StatusAccepted)
+		}
+		w.Write(append([]byte("response: "), body...))
+	}))
+	defer ts.Close()
+
+	conf := NewConfig()
+	conf.HTTP.Client.URL = ts.URL + "/testpost"
+	conf.HTTP.Parallel = true
+	conf.HTTP.ResponseMeta = "response"
+	conf.HTTP.ResponseMetaCodes = []int{200}
+
+	h, err := NewHTTP(conf, nil, log.Noop(), metrics.Noop())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msgs, res := h.ProcessMessage(message.New([][]byte{
+		[]byte("foo"), []byte("miss"), []byte("bar"),
+	}))
+	if res != nil {
+		t.Fatal(res.Error())
+	}
+	if len(msgs) != 1 {
+		t.Fatalf("Wrong count of messages: %v", len(msgs))
+	}
+	if exp, act := []string{"foo", "miss", "bar"}, message.GetAllBytes(msgs[0]); len(act) != 3 ||
+		string(act[0]) != exp[0] || string(act[1]) != exp[1] || string(act[2]) != exp[2] {
+		t.Errorf("Wrong result: %s != %s", act, exp)
+	}
+	for i, exp := range []string{"response: foo", "", "response: bar"} {
+		if act := msgs[0].Get(i).Metadata().Get("response"); exp != act {
+			t.Errorf("Wrong metadata %v: %v != %v", i, act, exp)
+		}
+	}
+}
+
 func TestHTTPClientBasic(t *testing.T) {
 	i := 0
 	expPayloads := []string{"foo", "bar", "baz"}
@@ -21,11 +21,17 @@
 package processor
 
 import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+
 	"github.com/Jeffail/benthos/lib/log"
 	"github.com/Jeffail/benthos/lib/message"
 	"github.com/Jeffail/benthos/lib/metrics"
 	"github.com/Jeffail/benthos/lib/types"
 	"github.com/Jeffail/benthos/lib/util/text"
+	"github.com/Jeffail/gabs"
 )
 
 //------------------------------------------------------------------------------
@@ -44,24 +50,180 @@ inserted before the last element, and so on. If the negative index is greater
 than the length of the existing parts it will be inserted at the beginning.
 
 This processor will interpolate functions within the 'content' field, you can
-find a list of functions [here](../config_interpolation.md#functions).`,
+find a list of functions [here](../config_interpolation.md#functions).
+
+### Aggregates
+
+The field ` + "`aggregates`" + ` is a map of names to aggregations calculated
+across all parts of the batch, which is useful for building header and footer
+records of a fixed envelope. Each aggregate extracts the value at a dot ` + "`path`" + `
+from the JSON of every part and reduces them with an ` + "`operator`" + `, which can
+be one of ` + "`count`, `sum`, `min`, `max`, `first` or `last`" + `. Parts that
+are not JSON or do not contain the path are skipped. When the path is empty the
+raw contents of every part are used instead.
+
+The result of each aggregate can be referenced within the content with the
+metadata function (` + "`${!metadata:<name>}`" + `) and is also added as metadata
+to the inserted part:
+
+` + "``` yaml" + `
+insert_part:
+  index: -1
+  content: '{"type":"footer","records":${!metadata:records},"total":${!metadata:total}}'
+  aggregates:
+    records:
+      operator: count
+    total:
+      path: order.amount
+      operator: sum
+` + "```" + ``,
 	}
 }
 
 //------------------------------------------------------------------------------
 
+// InsertPartAggregateConfig contains configuration fields for an aggregation
+// calculated across the parts of a batch.
+type InsertPartAggregateConfig struct {
+	Path     string `json:"path" yaml:"path"`
+	Operator string `json:"operator" yaml:"operator"`
+}
+
 // InsertPartConfig contains configuration fields for the InsertPart processor.
 type InsertPartConfig struct {
-	Index   int    `json:"index" yaml:"index"`
-	Content string `json:"content" yaml:"content"`
+	Index      int                                  `json:"index" yaml:"index"`
+	Content    string                               `json:"content" yaml:"content"`
+	Aggregates map[string]InsertPartAggregateConfig `json:"aggregates" yaml:"aggregates"`
 }
 
 // NewInsertPartConfig returns a InsertPartConfig with default values.
 func NewInsertPartConfig() InsertPartConfig {
 	return InsertPartConfig{
-		Index:   -1,
-		Content: "",
+		Index:      -1,
+		Content:    "",
+		Aggregates: map[string]InsertPartAggregateConfig{},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type aggregator func(values []interface{}) string
+
+func aggregateString(v interface{}) string {
+	switch t := v.(type) {
+	case string:
+		return t
+	case nil:
+		return "null"
+	}
+	b, _ := json.Marshal(v)
+	return string(b)
+}
+
+func aggregateNumbers(values []interface{}) []float64 {
+	nums := make([]float64, 0, len(values))
+	for _, v := range values {
+		switch t := v.(type) {
+		case float64:
+			nums = append(nums, t)
+		case json.Number:
+			if f, err := t.Float64(); err == nil {
+				nums = append(nums, f)
+			}
+		case string:
+			if f, err := strconv.ParseFloat(t, 64); err == nil {
+				nums = append(nums, f)
+			}
+		}
+	}
+	return nums
+}
+
+func formatAggregateNumber(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+var aggregators = map[string]aggregator{
+	"count": func(values []interface{}) string {
+		return strconv.Itoa(len(values))
+	},
+	"sum": func(values []interface{}) string {
+		var sum float64
+		for _, n := range aggregateNumbers(values) {
+			sum += n
+		}
+		return formatAggregateNumber(sum)
+	},
+	"min": func(values []interface{}) string {
+		nums := aggregateNumbers(values)
+		if len(nums) == 0 {
+			return "null"
+		}
+		sort.Float64s(nums)
+		return formatAggregateNumber(nums[0])
+	},
+	"max": func(values []interface{}) string {
+		nums := aggregateNumbers(values)
+		if len(nums) == 0 {
+			return "null"
+		}
+		sort.Float64s(nums)
+		return formatAggregateNumber(nums[len(nums)-1])
+	},
+	"first": func(values []interface{}) string {
+		if len(values) == 0 {
+			return "null"
+		}
+		return aggregateString(values[0])
+	},
+	"last": func(values []interface{}) string {
+		if len(values) == 0 {
+			return "null"
+		}
+		return aggregateString(values[len(values)-1])
+	},
+}
+
+type insertPartAggregate struct {
+	name string
+	path string
+	fn   aggregator
+}
+
+func (a insertPartAggregate) calculate(msg types.Message) string {
+	values := []interface{}{}
+	msg.Iter(func(i int, p types.Part) error {
+		if len(a.path) == 0 {
+			values = append(values, string(p.Get()))
+			return nil
+		}
+		jObj, err := p.JSON()
+		if err != nil {
+			return nil
+		}
+		gObj, _ := gabs.Consume(jObj)
+		if !gObj.ExistsP(a.path) {
+			return nil
+		}
+		values = append(values, gObj.Path(a.path).Data())
+		return nil
+	})
+	return a.fn(values)
+}
+
+// aggregateView wraps a message so that the first part, which is used by
+// interpolation functions by default, carries the results of aggregates as
+// metadata without modifying the original message.
+type aggregateView struct {
+	types.Message
+	first types.Part
+}
+
+func (a aggregateView) Get(index int) types.Part {
+	if index == 0 || (index < 0 && index+a.Message.Len() == 0) {
+		return a.first
 	}
+	return a.Message.Get(index)
 }
 
 //------------------------------------------------------------------------------
@@ -71,6 +233,7 @@ func NewInsertPartConfig() InsertPartConfig {
 type InsertPart struct {
 	interpolate bool
 	part        []byte
+	aggregates  []insertPartAggregate
 
 	conf  Config
 	log   log.Modular
@@ -87,9 +250,27 @@ func NewInsertPart(
 ) (Type, error) {
 	part := []byte(conf.InsertPart.Content)
 	interpolate := text.ContainsFunctionVariables(part)
+
+	var aggregates []insertPartAggregate
+	for name, aConf := range conf.InsertPart.Aggregates {
+		fn, exists := aggregators[aConf.Operator]
+		if !exists {
+			return nil, fmt.Errorf("aggregate '%v' operator not recognised: %v", name, aConf.Operator)
+		}
+		aggregates = append(aggregates, insertPartAggregate{
+			name: name,
+			path: aConf.Path,
+			fn:   fn,
+		})
+	}
+	sort.Slice(aggregates, func(i, j int) bool {
+		return aggregates[i].name < aggregates[j].name
+	})
+
 	return &InsertPart{
 		part:        part,
 		interpolate: interpolate,
+		aggregates:  aggregates,
 		conf:        conf,
 		log:         log.NewModule(".processor.insert_part"),
 		stats:       stats,
@@ -107,11 +288,28 @@ func NewInsertPart(
 func (p *InsertPart) ProcessMessage(msg types.Message) ([]types.Message, types.Response) {
 	p.mCount.Incr(1)
 
-	var newPart []byte
+	var interpMsg text.Message = msg
+	var aggMeta map[string]string
+	if len(p.aggregates) > 0 {
+		aggMeta = make(map[string]string, len(p.aggregates))
+		first := msg.Get(0).Copy()
+		for _, agg := range p.aggregates {
+			v := agg.calculate(msg)
+			aggMeta[agg.name] = v
+			first.Metadata().Set(agg.name, v)
+		}
+		interpMsg = aggregateView{Message: msg, first: first}
+	}
+
+	var newPartBytes []byte
 	if p.interpolate {
-		newPart = text.ReplaceFunctionVariables(msg, p.part)
+		newPartBytes = text.ReplaceFunctionVariables(interpMsg, p.part)
 	} else {
-		newPart = p.part
+		newPartBytes = p.part
+	}
+	newPart := message.NewPart(newPartBytes)
+	for k, v := range aggMeta {
+		newPart.Metadata().Set(k, v)
 	}
 
 	index := p.conf.InsertPart.Index
@@ -128,13 +326,13 @@ func (p *InsertPart) ProcessMessage(msg types.Message) ([]types.Message, types.R
 	newMsg := message.New(nil)
 	msg.Iter(func(i int, p types.Part) error {
 		if i == index {
-			newMsg.Append(message.NewPart(newPart))
+			newMsg.Append(newPart)
 		}
 		newMsg.Append(p.Copy())
 		return nil
 	})
 	if index == msg.Len() {
-		newMsg.Append(message.NewPart(newPart))
+		newMsg.Append(newPart)
 	}
 
 	p.mSent.Incr(1)
@@ -252,3 +252,62 @@ func TestInsertPartInterpolation(t *testing.T) {
 		}
 	}
 }
+
+func TestInsertPartAggregates(t *testing.T) {
+	conf := NewConfig()
+	conf.InsertPart.Index = -1
+	conf.InsertPart.Content = `{"records":${!metadata:records},"total":${!metadata:total},"min":${!metadata:min},"max":${!metadata:max},"first":"${!metadata:first}","last":"${!metadata:last}","id":"${!json_field:id}"}`
+	conf.InsertPart.Aggregates = map[string]InsertPartAggregateConfig{
+		"records": {Operator: "count"},
+		"total":   {Path: "amount", Operator: "sum"},
+		"min":     {Path: "amount", Operator: "min"},
+		"max":     {Path: "amount", Operator: "max"},
+		"first":   {Path: "id", Operator: "first"},
+		"last":    {Path: "id", Operator: "last"},
+	}
+
+	proc, err := NewInsertPart(conf, nil, log.Noop(), metrics.Noop())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	input := message.New([][]byte{
+		[]byte(`{"id":"a","amount":5}`),
+		[]byte(`{"id":"b","amount":2.5}`),
+		[]byte(`not json`),
+		[]byte(`{"id":"c","amount":"10"}`),
+	})
+	input.Get(0).Metadata().Set("foo", "bar")
+
+	msgs, res := proc.ProcessMessage(input)
+	if res != nil {
+		t.Fatal(res.Error())
+	}
+	if len(msgs) != 1 {
+		t.Fatalf("Wrong count of messages: %v", len(msgs))
+	}
+
+	exp := `{"records":4,"total":17.5,"min":2.5,"max":10,"first":"a","last":"c","id":"a"}`
+	if act := string(msgs[0].Get(-1).Get()); act != exp {
+		t.Errorf("Wrong result: %v != %v", act, exp)
+	}
+	if exp, act := "17.5", msgs[0].Get(-1).Metadata().Get("total"); exp != act {
+		t.Errorf("Wrong metadata: %v != %v", act, exp)
+	}
+	if exp, act := "", input.Get(0).Metadata().Get("total"); exp != act {
+		t.Errorf("Input metadata was modified: %v", act)
+	}
+	if exp, act := "", msgs[0].Get(0).Metadata().Get("total"); exp != act {
+		t.Errorf("Existing part metadata was modified: %v", act)
+	}
+}
+
+func TestInsertPartBadAggregate(t *testing.T) {
+	conf := NewConfig()
+	conf.InsertPart.Aggregates = map[string]InsertPartAggregateConfig{
+		"foo": {Operator: "nope"},
+	}
+	if _, err := NewInsertPart(conf, nil, log.Noop(), metrics.Noop()); err == nil {
+		t.Error("Expected error from bad operator")
+	}
+}
@@ -102,6 +102,7 @@ type JMESPath struct {
 	stats metrics.Type
 
 	mCount     metrics.StatCounter
+	mErr       *errorStats
 	mErrJSONP  metrics.StatCounter
 	mErrJMES   metrics.StatCounter
 	mErrJSONS  metrics.StatCounter
@@ -126,6 +127,7 @@ func NewJMESPath(
 		stats: stats,
 
 		mCount:     stats.GetCounter("processor.jmespath.count"),
+		mErr:       newErrorStats("jmespath", stats),
 		mErrJSONP:  stats.GetCounter("processor.jmespath.error.json_parse"),
 		mErrJMES:   stats.GetCounter("processor.jmespath.error.jmespath_search"),
 		mErrJSONS:  stats.GetCounter("processor.jmespath.error.json_set"),
@@ -166,6 +168,7 @@ func (p *JMESPath) ProcessMessage(msg types.Message) ([]types.Message, types.Res
 		jsonPart, err := newMsg.Get(index).JSON()
 		if err != nil {
 			p.mErrJSONP.Incr(1)
+			p.mErr.Incr(ErrClassParse)
 			p.log.Debugf("Failed to parse part into json: %v\n", err)
 			continue
 		}
@@ -173,12 +176,14 @@ func (p *JMESPath) ProcessMessage(msg types.Message) ([]types.Message, types.Res
 		var result interface{}
 		if result, err = safeSearch(jsonPart, p.query); err != nil {
 			p.mErrJMES.Incr(1)
+			p.mErr.IncrErr(err)
 			p.log.Debugf("Failed to search json: %v\n", err)
 			continue
 		}
 
 		if err = newMsg.Get(index).SetJSON(result); err != nil {
 			p.mErrJSONS.Incr(1)
+			p.mErr.IncrErr(err)
 			p.log.Debugf("Failed to convert jmespath result into part: %v\n", err)
 		} else {
 			p.mSucc.Incr(1)
@@ -29,6 +29,7 @@ import (
 	"github.com/Jeffail/benthos/lib/log"
 	"github.com/Jeffail/benthos/lib/metrics"
 	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/jsonlib"
 	"github.com/Jeffail/benthos/lib/util/text"
 	"github.com/Jeffail/gabs"
 )
@@ -215,7 +216,7 @@ func newSetOperator(path []string) jsonOperator {
 		}
 
 		var data interface{}
-		if err = json.Unmarshal([]byte(value), &data); err != nil {
+		if err = jsonlib.Unmarshal([]byte(value), &data); err != nil {
 			return nil, fmt.Errorf("failed to parse value: %v", err)
 		}
 
@@ -396,7 +397,7 @@ func newAppendOperator(path []string) jsonOperator {
 		var array []interface{}
 
 		var valueParsed interface{}
-		if err = json.Unmarshal(value, &valueParsed); err != nil {
+		if err = jsonlib.Unmarshal(value, &valueParsed); err != nil {
 			return nil, err
 		}
 		switch t := valueParsed.(type) {
@@ -469,7 +470,7 @@ type JSON struct {
 	mCount     metrics.StatCounter
 	mErrJSONP  metrics.StatCounter
 	mErrJSONS  metrics.StatCounter
-	mErr       metrics.StatCounter
+	mErr       *errorStats
 	mSucc      metrics.StatCounter
 	mSent      metrics.StatCounter
 	mSentParts metrics.StatCounter
@@ -490,7 +491,7 @@ func NewJSON(
 		mCount:     stats.GetCounter("processor.json.count"),
 		mErrJSONP:  stats.GetCounter("processor.json.error.json_parse"),
 		mErrJSONS:  stats.GetCounter("processor.json.error.json_set"),
-		mErr:       stats.GetCounter("processor.json.error"),
+		mErr:       newErrorStats("json", stats),
 		mSucc:      stats.GetCounter("processor.json.success"),
 		mSent:      stats.GetCounter("processor.json.sent"),
 		mSentParts: stats.GetCounter("processor.json.parts.sent"),
@@ -536,13 +537,14 @@ func (p *JSON) ProcessMessage(msg types.Message) ([]types.Message, types.Respons
 		jsonPart, err := newMsg.Get(index).JSON()
 		if err != nil {
 			p.mErrJSONP.Incr(1)
+			p.mErr.Incr(ErrClassParse)
 			p.log.Debugf("Failed to parse part into json: %v\n", err)
 			continue
 		}
 
 		var data interface{}
 		if data, err = p.operator(jsonPart, json.RawMessage(valueBytes)); err != nil {
-			p.mErr.Incr(1)
+			p.mErr.IncrErr(err)
 			p.log.Debugf("Failed to apply operator: %v\n", err)
 			continue
 		}
@@ -555,6 +557,7 @@ func (p *JSON) ProcessMessage(msg types.Message) ([]types.Message, types.Respons
 		default:
 			if err = newMsg.Get(index).SetJSON(data); err != nil {
 				p.mErrJSONS.Incr(1)
+				p.mErr.IncrErr(err)
 				p.log.Debugf("Failed to convert json into part: %v\n", err)
 			}
 		}
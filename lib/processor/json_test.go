@@ -21,12 +21,14 @@
 package processor
 
 import (
+	"fmt"
 	"os"
 	"testing"
 
 	"github.com/Jeffail/benthos/lib/log"
 	"github.com/Jeffail/benthos/lib/message"
 	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
 	yaml "gopkg.in/yaml.v2"
 )
 
@@ -950,3 +952,56 @@ func TestJSONDelete(t *testing.T) {
 		}
 	}
 }
+
+func BenchmarkJSONProcessorChain(b *testing.B) {
+	procs := []Type{}
+	for i := 0; i < 5; i++ {
+		conf := NewConfig()
+		conf.JSON.Operator = "set"
+		conf.JSON.Path = fmt.Sprintf("results.step%v", i)
+		conf.JSON.Value = []byte(`true`)
+
+		proc, err := NewJSON(conf, nil, log.Noop(), metrics.Noop())
+		if err != nil {
+			b.Fatal(err)
+		}
+		procs = append(procs, proc)
+	}
+
+	doc := `{"id":"foo","items":[`
+	for i := 0; i < 100; i++ {
+		if i > 0 {
+			doc += ","
+		}
+		doc += fmt.Sprintf(`{"index":%v,"name":"item %v","tags":["a","b","c"],"nested":{"value":%v.5}}`, i, i, i)
+	}
+	doc += `]}`
+	payload := []byte(doc)
+
+	// The cached JSON document of each part is carried along the chain, and
+	// so the payload is only parsed once.
+	b.Run("cached", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			var msg types.Message = message.New([][]byte{payload})
+			for _, proc := range procs {
+				msgs, _ := proc.ProcessMessage(msg)
+				msg = msgs[0]
+			}
+		}
+	})
+
+	// Resetting the payload drops the cached document, and so each processor
+	// parses the payload again.
+	b.Run("reparsed", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			var msg types.Message = message.New([][]byte{payload})
+			for _, proc := range procs {
+				msgs, _ := proc.ProcessMessage(msg)
+				msg = msgs[0]
+				msg.Get(0).Set(msg.Get(0).Get())
+			}
+		}
+	})
+}
@@ -70,6 +70,7 @@ type MergeJSON struct {
 	stats metrics.Type
 
 	mCount     metrics.StatCounter
+	mErr       *errorStats
 	mErrJSONP  metrics.StatCounter
 	mErrJSONS  metrics.StatCounter
 	mSucc      metrics.StatCounter
@@ -88,6 +89,7 @@ func NewMergeJSON(
 		stats:  stats,
 
 		mCount:     stats.GetCounter("processor.merge_json.count"),
+		mErr:       newErrorStats("merge_json", stats),
 		mErrJSONP:  stats.GetCounter("processor.merge_json.error.json_parse"),
 		mErrJSONS:  stats.GetCounter("processor.merge_json.error.json_set"),
 		mSucc:      stats.GetCounter("processor.merge_json.success"),
@@ -109,6 +111,7 @@ func (p *MergeJSON) ProcessMessage(msg types.Message) ([]types.Message, types.Re
 		jsonPart, err := msg.Get(index).JSON()
 		if err != nil {
 			p.mErrJSONP.Incr(1)
+			p.mErr.Incr(ErrClassParse)
 			p.log.Debugf("Failed to parse part into json: %v\n", err)
 			return
 		}
@@ -116,6 +119,7 @@ func (p *MergeJSON) ProcessMessage(msg types.Message) ([]types.Message, types.Re
 		var gPart *gabs.Container
 		if gPart, err = gabs.Consume(jsonPart); err != nil {
 			p.mErrJSONP.Incr(1)
+			p.mErr.Incr(ErrClassParse)
 			p.log.Debugf("Failed to parse part into json: %v\n", err)
 			return
 		}
@@ -161,6 +165,7 @@ func (p *MergeJSON) ProcessMessage(msg types.Message) ([]types.Message, types.Re
 	i := newMsg.Append(message.NewPart(nil))
 	if err := newMsg.Get(i).SetJSON(newPart.Data()); err != nil {
 		p.mErrJSONS.Incr(1)
+		p.mErr.IncrErr(err)
 		p.log.Debugf("Failed to marshal merged part into json: %v\n", err)
 	} else {
 		p.mSucc.Incr(1)
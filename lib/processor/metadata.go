@@ -146,7 +146,7 @@ type Metadata struct {
 	stats metrics.Type
 
 	mCount     metrics.StatCounter
-	mErr       metrics.StatCounter
+	mErr       *errorStats
 	mSucc      metrics.StatCounter
 	mSent      metrics.StatCounter
 	mSentParts metrics.StatCounter
@@ -166,7 +166,7 @@ func NewMetadata(
 		valueBytes: []byte(conf.Metadata.Value),
 
 		mCount:     stats.GetCounter("processor.metadata.count"),
-		mErr:       stats.GetCounter("processor.metadata.error"),
+		mErr:       newErrorStats("metadata", stats),
 		mSucc:      stats.GetCounter("processor.metadata.success"),
 		mSent:      stats.GetCounter("processor.metadata.sent"),
 		mSentParts: stats.GetCounter("processor.metadata.parts.sent"),
@@ -205,7 +205,7 @@ func (p *Metadata) ProcessMessage(msg types.Message) ([]types.Message, types.Res
 
 	for _, index := range targetParts {
 		if err := p.operator(newMsg.Get(index).Metadata(), valueBytes); err != nil {
-			p.mErr.Incr(1)
+			p.mErr.IncrErr(err)
 			p.log.Debugf("Failed to apply operator: %v\n", err)
 		}
 	}
@@ -142,7 +142,7 @@ type Metric struct {
 
 	mCount metrics.StatCounter
 	mSucc  metrics.StatCounter
-	mErr   metrics.StatCounter
+	mErr   *errorStats
 
 	handler func(string, types.Message) error
 }
@@ -187,7 +187,7 @@ func NewMetric(
 		stats:            stats,
 		mCount:           stats.GetCounter("processor.metric.count"),
 		mSucc:            stats.GetCounter("processor.metric.success"),
-		mErr:             stats.GetCounter("processor.metric.error"),
+		mErr:             newErrorStats("metric", stats),
 		interpolateValue: text.ContainsFunctionVariables([]byte(conf.Metric.Value)),
 	}
 
@@ -286,7 +286,7 @@ func (m *Metric) ProcessMessage(msg types.Message) ([]types.Message, types.Respo
 
 	err := m.handler(value, msg)
 	if err != nil {
-		m.mErr.Incr(1)
+		m.mErr.IncrErr(err)
 	} else {
 		m.mSucc.Incr(1)
 	}
@@ -61,6 +61,16 @@ func (d *mockMetric) Gauge(path string, value int64) error {
 
 func (d *mockMetric) Close() error { return nil }
 
+// checkLatencyMetric checks that the processor latency was recorded and then
+// removes it, since its value is not deterministic.
+func checkLatencyMetric(t *testing.T, m *mockMetric) {
+	t.Helper()
+	if _, exists := m.values["processor.metric.latency"]; !exists {
+		t.Error("Expected processor latency metric")
+	}
+	delete(m.values, "processor.metric.latency")
+}
+
 //------------------------------------------------------------------------------
 
 func TestMetricBad(t *testing.T) {
@@ -115,9 +125,11 @@ func TestMetricCounter(t *testing.T) {
 	}
 
 	expMetrics := map[string]int64{
-		"processor.metric.count":   7,
-		"processor.metric.success": 7,
-		"foo.bar":                  7,
+		"processor.metric.count":          7,
+		"processor.metric.success":        7,
+		"foo.bar":                         7,
+		"processor.metric.batch.received": 1,
+		"processor.metric.batch.sent":     1,
 	}
 
 	for _, i := range inputs {
@@ -130,6 +142,7 @@ func TestMetricCounter(t *testing.T) {
 		}
 	}
 
+	checkLatencyMetric(t, mockStats)
 	if !reflect.DeepEqual(expMetrics, mockStats.values) {
 		t.Errorf("Wrong result: %v != %v", mockStats.values, expMetrics)
 	}
@@ -171,9 +184,11 @@ func TestMetricCounterParts(t *testing.T) {
 	}
 
 	expMetrics := map[string]int64{
-		"processor.metric.count":   7,
-		"processor.metric.success": 7,
-		"foo.bar":                  5,
+		"processor.metric.count":          7,
+		"processor.metric.success":        7,
+		"foo.bar":                         5,
+		"processor.metric.batch.received": 1,
+		"processor.metric.batch.sent":     1,
 	}
 
 	for _, i := range inputs {
@@ -186,6 +201,7 @@ func TestMetricCounterParts(t *testing.T) {
 		}
 	}
 
+	checkLatencyMetric(t, mockStats)
 	if !reflect.DeepEqual(expMetrics, mockStats.values) {
 		t.Errorf("Wrong result: %v != %v", mockStats.values, expMetrics)
 	}
@@ -229,10 +245,14 @@ func TestMetricCounterBy(t *testing.T) {
 	}
 
 	expMetrics := map[string]int64{
-		"processor.metric.count":   7,
-		"processor.metric.success": 2,
-		"processor.metric.error":   5,
-		"foo.bar":                  5,
+		"processor.metric.count":          7,
+		"processor.metric.success":        2,
+		"processor.metric.error":          5,
+		"processor.metric.error.parse":    4,
+		"processor.metric.error.other":    1,
+		"foo.bar":                         5,
+		"processor.metric.batch.received": 1,
+		"processor.metric.batch.sent":     1,
 	}
 
 	for _, i := range inputs {
@@ -245,6 +265,7 @@ func TestMetricCounterBy(t *testing.T) {
 		}
 	}
 
+	checkLatencyMetric(t, mockStats)
 	if !reflect.DeepEqual(expMetrics, mockStats.values) {
 		t.Errorf("Wrong result: %v != %v", mockStats.values, expMetrics)
 	}
@@ -288,10 +309,14 @@ func TestMetricGauge(t *testing.T) {
 	}
 
 	expMetrics := map[string]int64{
-		"processor.metric.count":   7,
-		"processor.metric.success": 1,
-		"processor.metric.error":   6,
-		"foo.bar":                  5,
+		"processor.metric.count":          7,
+		"processor.metric.success":        1,
+		"processor.metric.error":          6,
+		"processor.metric.error.parse":    5,
+		"processor.metric.error.other":    1,
+		"foo.bar":                         5,
+		"processor.metric.batch.received": 1,
+		"processor.metric.batch.sent":     1,
 	}
 
 	for _, i := range inputs {
@@ -304,6 +329,7 @@ func TestMetricGauge(t *testing.T) {
 		}
 	}
 
+	checkLatencyMetric(t, mockStats)
 	if !reflect.DeepEqual(expMetrics, mockStats.values) {
 		t.Errorf("Wrong result: %v != %v", mockStats.values, expMetrics)
 	}
@@ -347,10 +373,14 @@ func TestMetricTiming(t *testing.T) {
 	}
 
 	expMetrics := map[string]int64{
-		"processor.metric.count":   7,
-		"processor.metric.success": 1,
-		"processor.metric.error":   6,
-		"foo.bar":                  5,
+		"processor.metric.count":          7,
+		"processor.metric.success":        1,
+		"processor.metric.error":          6,
+		"processor.metric.error.parse":    5,
+		"processor.metric.error.other":    1,
+		"foo.bar":                         5,
+		"processor.metric.batch.received": 1,
+		"processor.metric.batch.sent":     1,
 	}
 
 	for _, i := range inputs {
@@ -363,6 +393,7 @@ func TestMetricTiming(t *testing.T) {
 		}
 	}
 
+	checkLatencyMetric(t, mockStats)
 	if !reflect.DeepEqual(expMetrics, mockStats.values) {
 		t.Errorf("Wrong result: %v != %v", mockStats.values, expMetrics)
 	}
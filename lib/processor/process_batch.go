@@ -74,7 +74,7 @@ type ProcessBatch struct {
 	log log.Modular
 
 	mCount     metrics.StatCounter
-	mErr       metrics.StatCounter
+	mErr       *errorStats
 	mSent      metrics.StatCounter
 	mSentParts metrics.StatCounter
 	mDropped   metrics.StatCounter
@@ -100,7 +100,7 @@ func NewProcessBatch(
 		log:      nsLog,
 
 		mCount:     stats.GetCounter("processor.process_batch.count"),
-		mErr:       stats.GetCounter("processor.process_batch.error"),
+		mErr:       newErrorStats("process_batch", stats),
 		mSent:      stats.GetCounter("processor.process_batch.sent"),
 		mSentParts: stats.GetCounter("processor.process_batch.parts.sent"),
 		mDropped:   stats.GetCounter("processor.process_batch.dropped"),
@@ -92,7 +92,7 @@ type ProcessField struct {
 	log log.Modular
 
 	mCount              metrics.StatCounter
-	mErr                metrics.StatCounter
+	mErr                *errorStats
 	mErrJSONParse       metrics.StatCounter
 	mErrMisaligned      metrics.StatCounter
 	mErrMisalignedBatch metrics.StatCounter
@@ -124,7 +124,7 @@ func NewProcessField(
 		log: nsLog,
 
 		mCount:              stats.GetCounter("processor.process_field.count"),
-		mErr:                stats.GetCounter("processor.process_field.error"),
+		mErr:                newErrorStats("process_field", stats),
 		mErrJSONParse:       stats.GetCounter("processor.process_field.error.json_parse"),
 		mErrMisaligned:      stats.GetCounter("processor.process_field.error.misaligned"),
 		mErrMisalignedBatch: stats.GetCounter("processor.process_field.error.misaligned_messages"),
@@ -161,10 +161,12 @@ func (p *ProcessField) ProcessMessage(msg types.Message) (msgs []types.Message,
 		var jObj interface{}
 		if jObj, err = payload.Get(index).JSON(); err != nil {
 			p.mErrJSONParse.Incr(1)
+			p.mErr.Incr(ErrClassParse)
 			p.log.Errorf("Failed to decode part: %v\n", err)
 		}
 		if gParts[i], err = gabs.Consume(jObj); err != nil {
 			p.mErrJSONParse.Incr(1)
+			p.mErr.Incr(ErrClassParse)
 			p.log.Errorf("Failed to decode part: %v\n", err)
 		}
 		gTarget := gParts[i].S(p.path...)
@@ -198,7 +200,7 @@ func (p *ProcessField) ProcessMessage(msg types.Message) (msgs []types.Message,
 	if exp, act := len(targetParts), resMsg.Len(); exp != act {
 		p.mSent.Incr(1)
 		p.mSentParts.Incr(int64(payload.Len()))
-		p.mErr.Incr(1)
+		p.mErr.Incr(ErrClassOther)
 		p.mErrMisalignedBatch.Incr(1)
 		p.log.Errorf("Misaligned processor result batch. Expected %v messages, received %v\n", exp, act)
 		return
@@ -169,7 +169,7 @@ type ProcessMap struct {
 	mCountParts   metrics.StatCounter
 	mSkipped      metrics.StatCounter
 	mSkippedParts metrics.StatCounter
-	mErr          metrics.StatCounter
+	mErr          *errorStats
 	mErrPre       metrics.StatCounter
 	mErrProc      metrics.StatCounter
 	mErrPost      metrics.StatCounter
@@ -213,7 +213,7 @@ func NewProcessMap(
 		mCountParts:   stats.GetCounter("processor.process_map.parts.count"),
 		mSkipped:      stats.GetCounter("processor.process_map.skipped"),
 		mSkippedParts: stats.GetCounter("processor.process_map.parts.skipped"),
-		mErr:          stats.GetCounter("processor.process_map.error"),
+		mErr:          newErrorStats("process_map", stats),
 		mErrPre:       stats.GetCounter("processor.process_map.error.premap"),
 		mErrProc:      stats.GetCounter("processor.process_map.error.processors"),
 		mErrPost:      stats.GetCounter("processor.process_map.error.postmap"),
@@ -255,7 +255,7 @@ func (p *ProcessMap) ProcessMessage(msg types.Message) ([]types.Message, types.R
 
 	mappedMsg, skipped, err := p.mapper.MapRequests(mapMsg)
 	if err != nil {
-		p.mErr.Incr(1)
+		p.mErr.IncrErr(err)
 		p.mErrPre.Incr(1)
 		p.log.Errorf("Failed to map request: %v\n", err)
 		msgs := [1]types.Message{msg}
@@ -272,7 +272,7 @@ func (p *ProcessMap) ProcessMessage(msg types.Message) ([]types.Message, types.R
 	var procResults []types.Message
 	if procResults, err = processMap(mappedMsg, p.children); err != nil {
 		p.mErrProc.Incr(1)
-		p.mErr.Incr(1)
+		p.mErr.IncrErr(err)
 		p.log.Errorf("Processors failed: %v\n", err)
 		msgs := [1]types.Message{msg}
 		return msgs[:], nil
@@ -290,7 +290,7 @@ func (p *ProcessMap) ProcessMessage(msg types.Message) ([]types.Message, types.R
 	var alignedResult types.Message
 	if alignedResult, err = p.mapper.AlignResult(msg.Len(), skipped, procResults); err != nil {
 		p.mErrPost.Incr(1)
-		p.mErr.Incr(1)
+		p.mErr.IncrErr(err)
 		p.log.Errorf("Postmap failed: %v\n", err)
 		msgs := [1]types.Message{msg}
 		return msgs[:], nil
@@ -299,7 +299,7 @@ func (p *ProcessMap) ProcessMessage(msg types.Message) ([]types.Message, types.R
 	result := msg.Copy()
 	if err = p.mapper.MapResponses(result, alignedResult); err != nil {
 		p.mErrPost.Incr(1)
-		p.mErr.Incr(1)
+		p.mErr.IncrErr(err)
 		p.log.Errorf("Postmap failed: %v\n", err)
 		msgs := [1]types.Message{msg}
 		return msgs[:], nil
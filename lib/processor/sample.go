@@ -27,6 +27,8 @@ import (
 	"github.com/Jeffail/benthos/lib/metrics"
 	"github.com/Jeffail/benthos/lib/response"
 	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/text"
+	"github.com/OneOfOne/xxhash"
 )
 
 //------------------------------------------------------------------------------
@@ -37,7 +39,16 @@ func init() {
 		description: `
 Retains a randomly sampled percentage of messages (0 to 100) and drops all
 others. The random seed is static in order to sample deterministically, but can
-be set in config to allow parallel samples that are unique.`,
+be set in config to allow parallel samples that are unique.
+
+When the field ` + "`key`" + ` is set, messages are instead sampled by a hash of
+its value, meaning messages that share a key are either all retained or all
+dropped. For example, a key of ` + "`${!json_field:user_id}`" + ` keeps every
+message of the sampled users rather than a random selection of messages from
+all users. The key supports
+[function interpolations](../config_interpolation.md#functions) and the seed is
+mixed into the hash, so that samples with different seeds retain different
+keys.`,
 	}
 }
 
@@ -47,6 +58,7 @@ be set in config to allow parallel samples that are unique.`,
 type SampleConfig struct {
 	Retain     float64 `json:"retain" yaml:"retain"`
 	RandomSeed int64   `json:"seed" yaml:"seed"`
+	Key        string  `json:"key" yaml:"key"`
 }
 
 // NewSampleConfig returns a SampleConfig with default values.
@@ -54,6 +66,7 @@ func NewSampleConfig() SampleConfig {
 	return SampleConfig{
 		Retain:     10.0, // 10%
 		RandomSeed: 0,
+		Key:        "",
 	}
 }
 
@@ -67,6 +80,7 @@ type Sample struct {
 
 	retain float64
 	gen    *rand.Rand
+	key    *text.InterpolatedBytes
 
 	mCount     metrics.StatCounter
 	mDropped   metrics.StatCounter
@@ -79,12 +93,17 @@ func NewSample(
 	conf Config, mgr types.Manager, log log.Modular, stats metrics.Type,
 ) (Type, error) {
 	gen := rand.New(rand.NewSource(conf.Sample.RandomSeed))
+	var key *text.InterpolatedBytes
+	if len(conf.Sample.Key) > 0 {
+		key = text.NewInterpolatedBytes([]byte(conf.Sample.Key))
+	}
 	return &Sample{
 		conf:   conf,
 		log:    log.NewModule(".processor.sample"),
 		stats:  stats,
 		retain: conf.Sample.Retain / 100.0,
 		gen:    gen,
+		key:    key,
 
 		mCount:     stats.GetCounter("processor.sample.count"),
 		mDropped:   stats.GetCounter("processor.sample.dropped"),
@@ -99,7 +118,14 @@ func NewSample(
 // resulting messages or a response to be sent back to the message source.
 func (s *Sample) ProcessMessage(msg types.Message) ([]types.Message, types.Response) {
 	s.mCount.Incr(1)
-	if s.gen.Float64() > s.retain {
+	var rate float64
+	if s.key != nil {
+		hash := xxhash.Checksum64S(s.key.Get(msg), uint64(s.conf.Sample.RandomSeed))
+		rate = scaleNum(hash) / 100.0
+	} else {
+		rate = s.gen.Float64()
+	}
+	if rate > s.retain {
 		s.mDropped.Incr(1)
 		return nil, response.NewAck()
 	}
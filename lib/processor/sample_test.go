@@ -21,6 +21,7 @@
 package processor
 
 import (
+	"fmt"
 	"os"
 	"reflect"
 	"testing"
@@ -103,3 +104,60 @@ func TestSample24Percent(t *testing.T) {
 		t.Errorf("Sample error greater than margin: %v != %v", act, exp)
 	}
 }
+
+func TestSampleKey(t *testing.T) {
+	conf := NewConfig()
+	conf.Sample.Retain = 25.0
+	conf.Sample.Key = "${!json_field:user}"
+
+	proc, err := NewSample(conf, nil, log.Noop(), metrics.Noop())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sampled := func(proc Type, user int) bool {
+		msgs, _ := proc.ProcessMessage(message.New([][]byte{
+			[]byte(fmt.Sprintf(`{"user":"user%v"}`, user)),
+		}))
+		return len(msgs) > 0
+	}
+
+	total := 10000
+	retained := map[int]bool{}
+	for i := 0; i < total; i++ {
+		retained[i] = sampled(proc, i)
+	}
+
+	// Messages of the same key must always get the same result.
+	for j := 0; j < 3; j++ {
+		for i := 0; i < total; i++ {
+			if exp, act := retained[i], sampled(proc, i); exp != act {
+				t.Fatalf("Inconsistent sample of user%v: %v != %v", i, act, exp)
+			}
+		}
+	}
+
+	var totalSampled int
+	for _, r := range retained {
+		if r {
+			totalSampled++
+		}
+	}
+	if act := float64(totalSampled) / float64(total) * 100.0; act < 23.0 || act > 27.0 {
+		t.Errorf("Sample outside of margin: %v != %v", act, conf.Sample.Retain)
+	}
+
+	conf.Sample.RandomSeed = 10
+	if proc, err = NewSample(conf, nil, log.Noop(), metrics.Noop()); err != nil {
+		t.Fatal(err)
+	}
+	var diff int
+	for i := 0; i < total; i++ {
+		if sampled(proc, i) != retained[i] {
+			diff++
+		}
+	}
+	if diff == 0 {
+		t.Error("Expected a different seed to sample different keys")
+	}
+}
@@ -21,6 +21,9 @@
 package processor
 
 import (
+	"errors"
+	"strconv"
+
 	"github.com/Jeffail/benthos/lib/log"
 	"github.com/Jeffail/benthos/lib/message"
 	"github.com/Jeffail/benthos/lib/metrics"
@@ -43,6 +46,19 @@ remainder is also sent as a single batch. For example, if your target size was
 10, and the processor received a batch of 95 message parts, the result would be
 9 batches of 10 messages followed by a batch of 5 messages.
 
+Batches can also be limited by an approximate total size in bytes with the
+field ` + "`byte_size`" + `, where a new batch is started whenever adding a part
+to the current one would exceed the size. A part that exceeds the size by
+itself is sent as a batch of its own. When both ` + "`size` and `byte_size`" + `
+are set a batch ends as soon as either limit is reached, and setting
+` + "`size`" + ` to zero removes the limit on the number of parts.
+
+Setting ` + "`chunk_size`" + ` to a number of bytes breaks message parts larger
+than it into chunks of that size before they are batched. Each resulting part
+is given the metadata keys ` + "`chunk_index`" + ` (starting from zero) and
+` + "`chunk_count`" + `, so that the chunks of a part can be reassembled
+downstream.
+
 The split processor should *always* be positioned at the end of a list of
 processors.`,
 	}
@@ -53,13 +69,17 @@ processors.`,
 // SplitConfig is a configuration struct containing fields for the Split
 // processor, which breaks message batches down into batches of a smaller size.
 type SplitConfig struct {
-	Size int `json:"size" yaml:"size"`
+	Size      int `json:"size" yaml:"size"`
+	ByteSize  int `json:"byte_size" yaml:"byte_size"`
+	ChunkSize int `json:"chunk_size" yaml:"chunk_size"`
 }
 
 // NewSplitConfig returns a SplitConfig with default values.
 func NewSplitConfig() SplitConfig {
 	return SplitConfig{
-		Size: 1,
+		Size:      1,
+		ByteSize:  0,
+		ChunkSize: 0,
 	}
 }
 
@@ -70,7 +90,9 @@ type Split struct {
 	log   log.Modular
 	stats metrics.Type
 
-	size int
+	size      int
+	byteSize  int
+	chunkSize int
 
 	mCount     metrics.StatCounter
 	mDropped   metrics.StatCounter
@@ -82,11 +104,16 @@ type Split struct {
 func NewSplit(
 	conf Config, mgr types.Manager, log log.Modular, stats metrics.Type,
 ) (Type, error) {
+	if conf.Split.Size <= 0 && conf.Split.ByteSize <= 0 {
+		return nil, errors.New("at least one of size or byte_size must be greater than zero")
+	}
 	return &Split{
 		log:   log.NewModule(".processor.split"),
 		stats: stats,
 
-		size: conf.Split.Size,
+		size:      conf.Split.Size,
+		byteSize:  conf.Split.ByteSize,
+		chunkSize: conf.Split.ChunkSize,
 
 		mCount:     stats.GetCounter("processor.split.count"),
 		mDropped:   stats.GetCounter("processor.split.dropped"),
@@ -97,6 +124,33 @@ func NewSplit(
 
 //------------------------------------------------------------------------------
 
+// chunk breaks a message part into parts of the configured chunk size, each
+// labelled with its index and the total count of chunks.
+func (s *Split) chunk(p types.Part) []types.Part {
+	b := p.Get()
+	count := (len(b) + s.chunkSize - 1) / s.chunkSize
+	if count == 0 {
+		count = 1
+	}
+	countStr := strconv.Itoa(count)
+
+	chunks := make([]types.Part, count)
+	for i := range chunks {
+		start, end := i*s.chunkSize, (i+1)*s.chunkSize
+		if end > len(b) {
+			end = len(b)
+		}
+		chunks[i] = p.Copy()
+		if count > 1 {
+			chunks[i].Set(b[start:end])
+		}
+		chunks[i].Metadata().
+			Set("chunk_index", strconv.Itoa(i)).
+			Set("chunk_count", countStr)
+	}
+	return chunks
+}
+
 // ProcessMessage applies the processor to a message, either creating >0
 // resulting messages or a response to be sent back to the message source.
 func (s *Split) ProcessMessage(msg types.Message) ([]types.Message, types.Response) {
@@ -107,24 +161,40 @@ func (s *Split) ProcessMessage(msg types.Message) ([]types.Message, types.Respon
 		return nil, response.NewAck()
 	}
 
+	parts := make([]types.Part, 0, msg.Len())
+	msg.Iter(func(i int, p types.Part) error {
+		if s.chunkSize > 0 {
+			parts = append(parts, s.chunk(p)...)
+		} else {
+			parts = append(parts, p.Copy())
+		}
+		return nil
+	})
+
 	msgs := []types.Message{}
 
-	for i := 0; i < msg.Len(); i += s.size {
-		batchSize := s.size
-		if msg.Len() < (i + batchSize) {
-			batchSize = msg.Len() - i
-		}
-		parts := make([]types.Part, batchSize)
-		for j := range parts {
-			parts[j] = msg.Get(i + j).Copy()
-		}
+	var batch []types.Part
+	var batchBytes int
+	flush := func() {
 		newMsg := message.New(nil)
-		newMsg.SetAll(parts)
+		newMsg.SetAll(batch)
 		msgs = append(msgs, newMsg)
+		batch, batchBytes = nil, 0
+	}
+	for _, p := range parts {
+		pSize := len(p.Get())
+		if len(batch) > 0 &&
+			((s.size > 0 && len(batch) >= s.size) ||
+				(s.byteSize > 0 && batchBytes+pSize > s.byteSize)) {
+			flush()
+		}
+		batch = append(batch, p)
+		batchBytes += pSize
 	}
+	flush()
 
 	s.mSent.Incr(int64(len(msgs)))
-	s.mSentParts.Incr(int64(msg.Len()))
+	s.mSentParts.Incr(int64(len(parts)))
 	return msgs, nil
 }
 
@@ -22,6 +22,7 @@ package processor
 
 import (
 	"os"
+	"reflect"
 	"testing"
 
 	"github.com/Jeffail/benthos/lib/log"
@@ -114,3 +115,128 @@ func TestSplitToMultipleParts(t *testing.T) {
 		t.Errorf("Wrong contents: %v != %v", act, exp)
 	}
 }
+
+func TestSplitByteSize(t *testing.T) {
+	conf := NewConfig()
+	conf.Split.Size = 0
+	conf.Split.ByteSize = 10
+
+	proc, err := NewSplit(conf, nil, log.Noop(), metrics.Noop())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msgs, res := proc.ProcessMessage(message.New([][]byte{
+		[]byte("foo"),
+		[]byte("bar"),
+		[]byte("baz"),
+		[]byte("qux"),
+		[]byte("this is too large"),
+		[]byte("quz"),
+	}))
+	if res != nil {
+		t.Fatal(res.Error())
+	}
+
+	exp := [][][]byte{
+		{[]byte("foo"), []byte("bar"), []byte("baz")},
+		{[]byte("qux")},
+		{[]byte("this is too large")},
+		{[]byte("quz")},
+	}
+	if len(msgs) != len(exp) {
+		t.Fatalf("Wrong count of messages: %v != %v", len(msgs), len(exp))
+	}
+	for i, m := range msgs {
+		if act := message.GetAllBytes(m); !reflect.DeepEqual(act, exp[i]) {
+			t.Errorf("Wrong contents of batch %v: %s != %s", i, act, exp[i])
+		}
+	}
+
+	conf.Split.Size = 2
+	if proc, err = NewSplit(conf, nil, log.Noop(), metrics.Noop()); err != nil {
+		t.Fatal(err)
+	}
+	msgs, _ = proc.ProcessMessage(message.New([][]byte{
+		[]byte("foo"),
+		[]byte("bar"),
+		[]byte("baz"),
+	}))
+	if exp, act := 2, len(msgs); exp != act {
+		t.Errorf("Wrong count of messages: %v != %v", act, exp)
+	}
+}
+
+func TestSplitChunks(t *testing.T) {
+	conf := NewConfig()
+	conf.Split.Size = 0
+	conf.Split.ByteSize = 8
+	conf.Split.ChunkSize = 4
+
+	proc, err := NewSplit(conf, nil, log.Noop(), metrics.Noop())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	inMsg := message.New([][]byte{
+		[]byte("foobarbaz"),
+		[]byte("qux"),
+	})
+	inMsg.Get(0).Metadata().Set("foo", "bar")
+
+	msgs, res := proc.ProcessMessage(inMsg)
+	if res != nil {
+		t.Fatal(res.Error())
+	}
+
+	exp := [][][]byte{
+		{[]byte("foob"), []byte("arba")},
+		{[]byte("z"), []byte("qux")},
+	}
+	if len(msgs) != len(exp) {
+		t.Fatalf("Wrong count of messages: %v != %v", len(msgs), len(exp))
+	}
+	for i, m := range msgs {
+		if act := message.GetAllBytes(m); !reflect.DeepEqual(act, exp[i]) {
+			t.Errorf("Wrong contents of batch %v: %s != %s", i, act, exp[i])
+		}
+	}
+
+	expMeta := [][3]string{
+		{"0", "3", "bar"},
+		{"1", "3", "bar"},
+		{"2", "3", "bar"},
+		{"0", "1", ""},
+	}
+	var i int
+	for _, m := range msgs {
+		m.Iter(func(_ int, p types.Part) error {
+			act := [3]string{
+				p.Metadata().Get("chunk_index"),
+				p.Metadata().Get("chunk_count"),
+				p.Metadata().Get("foo"),
+			}
+			if act != expMeta[i] {
+				t.Errorf("Wrong metadata of part %v: %v != %v", i, act, expMeta[i])
+			}
+			i++
+			return nil
+		})
+	}
+
+	if exp, act := "foobarbaz", string(inMsg.Get(0).Get()); exp != act {
+		t.Errorf("Original message was modified: %v != %v", act, exp)
+	}
+	if exp, act := "", inMsg.Get(0).Metadata().Get("chunk_index"); exp != act {
+		t.Errorf("Original metadata was modified: %v != %v", act, exp)
+	}
+}
+
+func TestSplitBadConfig(t *testing.T) {
+	conf := NewConfig()
+	conf.Split.Size = 0
+
+	if _, err := NewSplit(conf, nil, log.Noop(), metrics.Noop()); err == nil {
+		t.Error("Expected error from zero size")
+	}
+}
@@ -189,7 +189,7 @@ type Text struct {
 
 	mCount     metrics.StatCounter
 	mSucc      metrics.StatCounter
-	mErr       metrics.StatCounter
+	mErr       *errorStats
 	mSent      metrics.StatCounter
 	mSentParts metrics.StatCounter
 }
@@ -208,7 +208,7 @@ func NewText(
 
 		mCount:     stats.GetCounter("processor.text.count"),
 		mSucc:      stats.GetCounter("processor.text.success"),
-		mErr:       stats.GetCounter("processor.text.error"),
+		mErr:       newErrorStats("text", stats),
 		mSent:      stats.GetCounter("processor.text.sent"),
 		mSentParts: stats.GetCounter("processor.text.parts.sent"),
 	}
@@ -248,7 +248,7 @@ func (t *Text) ProcessMessage(msg types.Message) ([]types.Message, types.Respons
 		data := newMsg.Get(index).Get()
 		var err error
 		if data, err = t.operator(data, valueBytes); err != nil {
-			t.mErr.Incr(1)
+			t.mErr.IncrErr(err)
 			t.log.Debugf("Failed to apply operator: %v\n", err)
 			continue
 		}
@@ -21,7 +21,9 @@
 package processor
 
 import (
+	"errors"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/Jeffail/benthos/lib/log"
@@ -40,28 +42,71 @@ period. This throttle is per processing pipeline, and therefore four threads
 each with a throttle would result in four times the rate specified.
 
 The period should be specified as a time duration string. For example, '1s'
-would be 1 second, '10ms' would be 10 milliseconds, etc.`,
+would be 1 second, '10ms' would be 10 milliseconds, etc.
+
+Alternatively, the field ` + "`rate`" + ` sets a target number of message
+batches per second, in which case the period is ignored. The field
+` + "`burst`" + ` sets the number of batches that can pass without delay after a
+quiet period, with the rate still holding over time.
+
+### Adaptive Throttling
+
+When ` + "`adaptive.enabled`" + ` is set the throttle adjusts its rate based on
+the responses of the components downstream of it, such as the output. When a
+message is rejected, or is acknowledged after longer than
+` + "`adaptive.max_latency`" + `, the rate is halved, at most once per second and
+no lower than ` + "`adaptive.min_rate`" + `. Each subsequent message that
+succeeds within the latency limit raises the rate gradually back towards
+` + "`rate`" + `, which must be set.
+
+Responses are only observed when the throttle is one of the processors of a
+pipeline, or of an input or output, and not when it is nested within other
+processors.`,
 	}
 }
 
 //------------------------------------------------------------------------------
 
+// ThrottleAdaptiveConfig contains configuration fields for adapting the rate of
+// a Throttle processor based on downstream responses.
+type ThrottleAdaptiveConfig struct {
+	Enabled    bool    `json:"enabled" yaml:"enabled"`
+	MaxLatency string  `json:"max_latency" yaml:"max_latency"`
+	MinRate    float64 `json:"min_rate" yaml:"min_rate"`
+}
+
+// NewThrottleAdaptiveConfig returns a ThrottleAdaptiveConfig with default
+// values.
+func NewThrottleAdaptiveConfig() ThrottleAdaptiveConfig {
+	return ThrottleAdaptiveConfig{
+		Enabled:    false,
+		MaxLatency: "1s",
+		MinRate:    1,
+	}
+}
+
 // ThrottleConfig contains configuration fields for the Throttle processor.
 type ThrottleConfig struct {
-	Period string `json:"period" yaml:"period"`
+	Period   string                 `json:"period" yaml:"period"`
+	Rate     float64                `json:"rate" yaml:"rate"`
+	Burst    int                    `json:"burst" yaml:"burst"`
+	Adaptive ThrottleAdaptiveConfig `json:"adaptive" yaml:"adaptive"`
 }
 
 // NewThrottleConfig returns a ThrottleConfig with default values.
 func NewThrottleConfig() ThrottleConfig {
 	return ThrottleConfig{
-		Period: "100us",
+		Period:   "100us",
+		Rate:     0,
+		Burst:    1,
+		Adaptive: NewThrottleAdaptiveConfig(),
 	}
 }
 
 //------------------------------------------------------------------------------
 
 // Throttle is a processor that limits the stream of a pipeline to one message
-// batch per period specified.
+// batch per period specified, or to a rate of message batches per second.
 type Throttle struct {
 	conf  Config
 	log   log.Modular
@@ -70,9 +115,23 @@ type Throttle struct {
 	duration  time.Duration
 	lastBatch time.Time
 
+	// Fields used when a rate is set, which may be modified concurrently by
+	// observed responses.
+	rateMut     sync.Mutex
+	targetRate  float64
+	rate        float64
+	burst       float64
+	tokens      float64
+	lastRefill  time.Time
+	minRate     float64
+	maxLatency  time.Duration
+	lastBackoff time.Time
+
 	mCount     metrics.StatCounter
 	mSent      metrics.StatCounter
 	mSentParts metrics.StatCounter
+	mBackoff   metrics.StatCounter
+	mRate      metrics.StatGauge
 }
 
 // NewThrottle returns a Throttle processor.
@@ -84,32 +143,87 @@ func NewThrottle(
 		log:   log.NewModule(".processor.throttle"),
 		stats: stats,
 
+		targetRate: conf.Throttle.Rate,
+		rate:       conf.Throttle.Rate,
+		burst:      float64(conf.Throttle.Burst),
+		minRate:    conf.Throttle.Adaptive.MinRate,
+
 		mCount:     stats.GetCounter("processor.throttle.count"),
 		mSent:      stats.GetCounter("processor.throttle.sent"),
 		mSentParts: stats.GetCounter("processor.throttle.parts.sent"),
+		mBackoff:   stats.GetCounter("processor.throttle.backoff"),
+		mRate:      stats.GetGauge("processor.throttle.rate"),
 	}
 
 	var err error
 	if t.duration, err = time.ParseDuration(conf.Throttle.Period); err != nil {
 		return nil, fmt.Errorf("failed to parse period: %v", err)
 	}
+	if t.targetRate < 0 {
+		return nil, errors.New("rate must not be negative")
+	}
+	if t.burst < 1 {
+		t.burst = 1
+	}
+	t.tokens = t.burst
 
-	return t, nil
+	if !conf.Throttle.Adaptive.Enabled {
+		return t, nil
+	}
+	if t.targetRate <= 0 {
+		return nil, errors.New("a rate must be set for adaptive throttling")
+	}
+	if t.maxLatency, err = time.ParseDuration(conf.Throttle.Adaptive.MaxLatency); err != nil {
+		return nil, fmt.Errorf("failed to parse max_latency: %v", err)
+	}
+	if t.minRate <= 0 || t.minRate > t.targetRate {
+		return nil, errors.New("min_rate must be greater than zero and no more than rate")
+	}
+	t.mRate.Set(int64(t.rate))
+	return &adaptiveThrottle{Throttle: t}, nil
 }
 
 //------------------------------------------------------------------------------
 
+// waitForRate blocks until a message batch is permitted by the configured
+// rate, where a bucket of tokens up to the burst size is refilled at the rate.
+func (m *Throttle) waitForRate() {
+	m.rateMut.Lock()
+	now := time.Now()
+	if !m.lastRefill.IsZero() {
+		m.tokens += now.Sub(m.lastRefill).Seconds() * m.rate
+		if m.tokens > m.burst {
+			m.tokens = m.burst
+		}
+	}
+	m.lastRefill = now
+	m.tokens--
+
+	var wait time.Duration
+	if m.tokens < 0 {
+		wait = time.Duration(-m.tokens / m.rate * float64(time.Second))
+	}
+	m.rateMut.Unlock()
+
+	if wait > 0 {
+		time.Sleep(wait)
+	}
+}
+
 // ProcessMessage applies the processor to a message, either creating >0
 // resulting messages or a response to be sent back to the message source.
 func (m *Throttle) ProcessMessage(msg types.Message) ([]types.Message, types.Response) {
 	m.mCount.Incr(1)
 
-	if since := time.Since(m.lastBatch); m.duration > since {
-		time.Sleep(m.duration - since)
+	if m.targetRate > 0 {
+		m.waitForRate()
+	} else {
+		if since := time.Since(m.lastBatch); m.duration > since {
+			time.Sleep(m.duration - since)
+		}
+		m.lastBatch = time.Now()
 	}
 
-	m.lastBatch = time.Now()
-
 	m.mSent.Incr(1)
 	m.mSentParts.Incr(int64(msg.Len()))
 	msgs := [1]types.Message{msg}
@@ -117,3 +231,40 @@ func (m *Throttle) ProcessMessage(msg types.Message) ([]types.Message, types.Res
 }
 
 //------------------------------------------------------------------------------
+
+// adaptiveThrottle is a Throttle that adapts its rate based on the responses
+// of downstream components.
+type adaptiveThrottle struct {
+	*Throttle
+}
+
+// ObserveResponse halves the rate of the throttle when a response is an error
+// or took longer than the max latency, and otherwise raises the rate towards
+// its target.
+func (m *adaptiveThrottle) ObserveResponse(res types.Response, latency time.Duration) {
+	m.rateMut.Lock()
+	defer m.rateMut.Unlock()
+
+	if res.Error() != nil || latency > m.maxLatency {
+		if time.Since(m.lastBackoff) < time.Second {
+			return
+		}
+		m.lastBackoff = time.Now()
+		if m.rate /= 2; m.rate < m.minRate {
+			m.rate = m.minRate
+		}
+		m.log.Debugf("Reducing throttle rate to %v per second\n", m.rate)
+		m.mBackoff.Incr(1)
+		m.mRate.Set(int64(m.rate))
+		return
+	}
+
+	if m.rate < m.targetRate {
+		if m.rate += m.targetRate / 100; m.rate > m.targetRate {
+			m.rate = m.targetRate
+		}
+		m.mRate.Set(int64(m.rate))
+	}
+}
+
+//------------------------------------------------------------------------------
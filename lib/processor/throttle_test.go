@@ -21,12 +21,15 @@
 package processor
 
 import (
+	"errors"
 	"testing"
 	"time"
 
 	"github.com/Jeffail/benthos/lib/log"
 	"github.com/Jeffail/benthos/lib/message"
 	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/response"
+	"github.com/Jeffail/benthos/lib/types"
 )
 
 func TestThrottle(t *testing.T) {
@@ -84,3 +87,125 @@ func TestThrottleBadPeriod(t *testing.T) {
 		t.Error("Expected error from bad duration")
 	}
 }
+
+func TestThrottleRate(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = TypeThrottle
+	conf.Throttle.Rate = 20
+
+	throt, err := New(conf, nil, log.Noop(), metrics.Noop())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tBefore := time.Now()
+	for i := 0; i < 5; i++ {
+		throt.ProcessMessage(message.New(nil))
+	}
+	if dur := time.Since(tBefore); dur < time.Millisecond*190 || dur > time.Millisecond*400 {
+		t.Errorf("Wrong duration for rate: %v", dur)
+	}
+}
+
+func TestThrottleBurst(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = TypeThrottle
+	conf.Throttle.Rate = 10
+	conf.Throttle.Burst = 5
+
+	throt, err := New(conf, nil, log.Noop(), metrics.Noop())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tBefore := time.Now()
+	for i := 0; i < 5; i++ {
+		throt.ProcessMessage(message.New(nil))
+	}
+	if dur := time.Since(tBefore); dur > time.Millisecond*50 {
+		t.Errorf("Burst took too long: %v", dur)
+	}
+
+	tBefore = time.Now()
+	throt.ProcessMessage(message.New(nil))
+	if dur := time.Since(tBefore); dur < time.Millisecond*90 {
+		t.Errorf("Message after burst didn't take long enough: %v", dur)
+	}
+}
+
+func TestThrottleAdaptive(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = TypeThrottle
+	conf.Throttle.Rate = 100
+	conf.Throttle.Adaptive.Enabled = true
+	conf.Throttle.Adaptive.MaxLatency = "100ms"
+	conf.Throttle.Adaptive.MinRate = 30
+
+	proc, err := New(conf, nil, log.Noop(), metrics.Noop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	obs, ok := proc.(types.ResponseObserver)
+	if !ok {
+		t.Fatal("Adaptive throttle does not observe responses")
+	}
+	throt := proc.(*timedObserver).proc.(*adaptiveThrottle)
+
+	obs.ObserveResponse(response.NewError(errors.New("nope")), time.Millisecond)
+	if exp, act := 50.0, throt.rate; exp != act {
+		t.Errorf("Wrong rate after error: %v != %v", act, exp)
+	}
+
+	// Backoff only happens once per second.
+	obs.ObserveResponse(response.NewAck(), time.Second)
+	if exp, act := 50.0, throt.rate; exp != act {
+		t.Errorf("Wrong rate after second backoff: %v != %v", act, exp)
+	}
+
+	obs.ObserveResponse(response.NewAck(), time.Millisecond)
+	if exp, act := 51.0, throt.rate; exp != act {
+		t.Errorf("Wrong rate after success: %v != %v", act, exp)
+	}
+
+	throt.lastBackoff = time.Time{}
+	obs.ObserveResponse(response.NewAck(), time.Second)
+	if exp, act := 30.0, throt.rate; exp != act {
+		t.Errorf("Wrong rate after slow response: %v != %v", act, exp)
+	}
+
+	for i := 0; i < 100; i++ {
+		obs.ObserveResponse(response.NewAck(), time.Millisecond)
+	}
+	if exp, act := 100.0, throt.rate; exp != act {
+		t.Errorf("Wrong rate after recovery: %v != %v", act, exp)
+	}
+}
+
+func TestThrottleBadConfigs(t *testing.T) {
+	tests := map[string]func(*ThrottleConfig){
+		"negative rate": func(c *ThrottleConfig) {
+			c.Rate = -1
+		},
+		"adaptive without rate": func(c *ThrottleConfig) {
+			c.Adaptive.Enabled = true
+		},
+		"adaptive bad latency": func(c *ThrottleConfig) {
+			c.Rate = 10
+			c.Adaptive.Enabled = true
+			c.Adaptive.MaxLatency = "nope"
+		},
+		"adaptive bad min rate": func(c *ThrottleConfig) {
+			c.Rate = 10
+			c.Adaptive.Enabled = true
+			c.Adaptive.MinRate = 20
+		},
+	}
+	for name, fn := range tests {
+		conf := NewConfig()
+		conf.Type = TypeThrottle
+		fn(&conf.Throttle)
+		if _, err := New(conf, nil, log.Noop(), metrics.Noop()); err == nil {
+			t.Errorf("%v: Expected error", name)
+		}
+	}
+}
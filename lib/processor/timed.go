@@ -0,0 +1,116 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package processor
+
+import (
+	"time"
+
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/tracing"
+	"github.com/Jeffail/benthos/lib/types"
+)
+
+//------------------------------------------------------------------------------
+
+// timed wraps a processor and records the time taken for each call to
+// ProcessMessage under the metric path processor.<type>.latency, as well as
+// creating a span for each message part when tracing is enabled. The sizes of
+// batches are recorded under the histograms processor.<type>.batch.received
+// and processor.<type>.batch.sent.
+type timed struct {
+	proc       Type
+	operation  string
+	mLatency   metrics.StatTimer
+	mBatchRcvd metrics.StatHistogram
+	mBatchSent metrics.StatHistogram
+}
+
+func newTimed(typeStr string, proc Type, stats metrics.Type) Type {
+	t := &timed{
+		proc:       proc,
+		operation:  "processor." + typeStr,
+		mLatency:   stats.GetTimer("processor." + typeStr + ".latency"),
+		mBatchRcvd: metrics.GetHistogram(stats, "processor."+typeStr+".batch.received"),
+		mBatchSent: metrics.GetHistogram(stats, "processor."+typeStr+".batch.sent"),
+	}
+	if _, ok := proc.(types.ResponseObserver); ok {
+		return &timedObserver{timed: t}
+	}
+	return t
+}
+
+// ProcessMessage applies the underlying processor to a message and records the
+// time taken.
+func (t *timed) ProcessMessage(msg types.Message) ([]types.Message, types.Response) {
+	t.mBatchRcvd.Observe(int64(msg.Len()))
+	spans := tracing.ChildSpans(t.operation, msg)
+	started := time.Now()
+	msgs, res := t.proc.ProcessMessage(msg)
+	t.mLatency.Timing(time.Since(started).Nanoseconds())
+	for _, m := range msgs {
+		t.mBatchSent.Observe(int64(m.Len()))
+	}
+	if res != nil {
+		tracing.FinishSpans(spans, res.Error())
+	} else {
+		tracing.FinishSpans(spans, nil)
+	}
+	return msgs, res
+}
+
+// UntilFlush returns the duration until the underlying processor should be
+// flushed, or a negative duration if it does not buffer messages.
+func (t *timed) UntilFlush() time.Duration {
+	if f, ok := t.proc.(types.Flusher); ok {
+		return f.UntilFlush()
+	}
+	return -1
+}
+
+// Flush returns any messages buffered by the underlying processor.
+func (t *timed) Flush() []types.Message {
+	f, ok := t.proc.(types.Flusher)
+	if !ok {
+		return nil
+	}
+	msgs := f.Flush()
+	for _, m := range msgs {
+		t.mBatchSent.Observe(int64(m.Len()))
+	}
+	return msgs
+}
+
+//------------------------------------------------------------------------------
+
+// timedObserver is a timed processor that also passes the responses observed
+// by a pipeline to the underlying processor. It is only used for processors
+// that observe responses, as pipelines only track responses when a processor
+// requires them.
+type timedObserver struct {
+	*timed
+}
+
+// ObserveResponse passes a response to the underlying processor.
+func (t *timedObserver) ObserveResponse(res types.Response, latency time.Duration) {
+	t.proc.(types.ResponseObserver).ObserveResponse(res, latency)
+}
+
+//------------------------------------------------------------------------------
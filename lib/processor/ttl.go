@@ -0,0 +1,257 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package processor
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/log"
+	"github.com/Jeffail/benthos/lib/message"
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/response"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/text"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors[TypeTTL] = TypeSpec{
+		constructor: NewTTL,
+		description: `
+Checks the age of each message part against a time to live, and either drops
+parts that have expired or marks them with a metadata key. This is useful for
+avoiding wasting downstream capacity on a stale backlog, for example whilst
+recovering from an outage.
+
+The age of a part is determined by the ` + "`timestamp`" + ` field, which
+supports [interpolation functions](../config_interpolation.md#functions)
+resolved individually for each part and is parsed according to
+` + "`timestamp_format`" + `. The format can be one of ` + "`unix`" + ` (seconds,
+with optional decimal places), ` + "`unix_ms`" + `, ` + "`unix_nano`" + `, or
+otherwise is a [Go time layout](https://golang.org/pkg/time/#Parse) such as
+` + "`2006-01-02T15:04:05Z07:00`" + `.
+
+By default the timestamp is read from the metadata key ` + "`ingest_timestamp`" + `,
+which can be recorded when a message is consumed by adding a
+` + "[`metadata`](#metadata)" + ` processor to the input:
+
+` + "``` yaml" + `
+input:
+  type: kafka
+  processors:
+  - type: metadata
+    metadata:
+      operator: set
+      key: ingest_timestamp
+      value: ${!timestamp_unix}
+pipeline:
+  processors:
+  - type: ttl
+    ttl:
+      ttl_ms: 300000
+` + "```" + `
+
+Alternatively a timestamp can be taken from the message contents with an
+interpolation function such as ` + "`${!json_field:created_at}`" + `.
+
+Parts where the timestamp cannot be parsed are not considered expired.
+
+### On Expired
+
+When ` + "`on_expired`" + ` is ` + "`drop`" + ` expired parts are removed from the
+batch, and if the resulting batch is empty it is dropped.
+
+When ` + "`on_expired`" + ` is ` + "`mark`" + ` expired parts are kept and have
+the metadata key ` + "`mark_key`" + ` set to ` + "`true`" + `. This allows
+expired messages to be routed to a dead letter queue with a
+` + "[`switch`](../outputs/README.md#switch)" + ` output and a
+` + "[`metadata`](../conditions/README.md#metadata)" + ` condition.`,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// TTLConfig contains configuration fields for the TTL processor.
+type TTLConfig struct {
+	TTLMS           int64  `json:"ttl_ms" yaml:"ttl_ms"`
+	Timestamp       string `json:"timestamp" yaml:"timestamp"`
+	TimestampFormat string `json:"timestamp_format" yaml:"timestamp_format"`
+	OnExpired       string `json:"on_expired" yaml:"on_expired"`
+	MarkKey         string `json:"mark_key" yaml:"mark_key"`
+}
+
+// NewTTLConfig returns a TTLConfig with default values.
+func NewTTLConfig() TTLConfig {
+	return TTLConfig{
+		TTLMS:           60000,
+		Timestamp:       "${!metadata:ingest_timestamp}",
+		TimestampFormat: "unix",
+		OnExpired:       "drop",
+		MarkKey:         "ttl_expired",
+	}
+}
+
+//------------------------------------------------------------------------------
+
+func parseTTLTimestamp(format, value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	switch format {
+	case "unix":
+		secs, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return time.Time{}, err
+		}
+		whole, frac := math.Modf(secs)
+		return time.Unix(int64(whole), int64(frac*1e9)), nil
+	case "unix_ms":
+		ms, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return time.Unix(0, ms*int64(time.Millisecond)), nil
+	case "unix_nano":
+		ns, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return time.Unix(0, ns), nil
+	}
+	return time.Parse(format, value)
+}
+
+//------------------------------------------------------------------------------
+
+// TTL is a processor that drops or marks message parts that are older than a
+// time to live.
+type TTL struct {
+	ttl         time.Duration
+	timestamp   []byte
+	interpolate bool
+	format      string
+	drop        bool
+	markKey     string
+
+	now func() time.Time
+
+	log   log.Modular
+	stats metrics.Type
+
+	mCount       metrics.StatCounter
+	mErr         *errorStats
+	mPartExpired metrics.StatCounter
+	mDropped     metrics.StatCounter
+	mSent        metrics.StatCounter
+	mSentParts   metrics.StatCounter
+}
+
+// NewTTL returns a TTL processor.
+func NewTTL(
+	conf Config, mgr types.Manager, log log.Modular, stats metrics.Type,
+) (Type, error) {
+	if conf.TTL.TTLMS <= 0 {
+		return nil, fmt.Errorf("ttl_ms must be greater than zero, got: %v", conf.TTL.TTLMS)
+	}
+	t := &TTL{
+		ttl:       time.Duration(conf.TTL.TTLMS) * time.Millisecond,
+		timestamp: []byte(conf.TTL.Timestamp),
+		format:    conf.TTL.TimestampFormat,
+		markKey:   conf.TTL.MarkKey,
+		now:       time.Now,
+
+		log:   log.NewModule(".processor.ttl"),
+		stats: stats,
+
+		mCount:       stats.GetCounter("processor.ttl.count"),
+		mErr:         newErrorStats("ttl", stats),
+		mPartExpired: stats.GetCounter("processor.ttl.part.expired"),
+		mDropped:     stats.GetCounter("processor.ttl.dropped"),
+		mSent:        stats.GetCounter("processor.ttl.sent"),
+		mSentParts:   stats.GetCounter("processor.ttl.parts.sent"),
+	}
+	t.interpolate = text.ContainsFunctionVariables(t.timestamp)
+
+	switch conf.TTL.OnExpired {
+	case "drop":
+		t.drop = true
+	case "mark":
+		if len(t.markKey) == 0 {
+			return nil, fmt.Errorf("mark_key must not be empty when on_expired is mark")
+		}
+	default:
+		return nil, fmt.Errorf("on_expired not recognised: %v", conf.TTL.OnExpired)
+	}
+	return t, nil
+}
+
+//------------------------------------------------------------------------------
+
+// expired returns whether a part of a message is older than the TTL.
+func (t *TTL) expired(msg types.Message, index int) bool {
+	tsBytes := t.timestamp
+	if t.interpolate {
+		tsBytes = text.ReplaceFunctionVariables(message.Lock(msg, index), tsBytes)
+	}
+	ts, err := parseTTLTimestamp(t.format, string(tsBytes))
+	if err != nil {
+		t.mErr.Incr(ErrClassParse)
+		t.log.Debugf("Failed to parse timestamp '%s': %v\n", tsBytes, err)
+		return false
+	}
+	return t.now().Sub(ts) > t.ttl
+}
+
+// ProcessMessage applies the processor to a message, either creating >0
+// resulting messages or a response to be sent back to the message source.
+func (t *TTL) ProcessMessage(msg types.Message) ([]types.Message, types.Response) {
+	t.mCount.Incr(1)
+
+	newMsg := message.New(nil)
+	for i := 0; i < msg.Len(); i++ {
+		if !t.expired(msg, i) {
+			newMsg.Append(msg.Get(i).Copy())
+			continue
+		}
+		t.mPartExpired.Incr(1)
+		if t.drop {
+			continue
+		}
+		part := msg.Get(i).Copy()
+		part.Metadata().Set(t.markKey, "true")
+		newMsg.Append(part)
+	}
+
+	if newMsg.Len() == 0 {
+		t.mDropped.Incr(1)
+		return nil, response.NewAck()
+	}
+
+	t.mSent.Incr(1)
+	t.mSentParts.Incr(int64(newMsg.Len()))
+	msgs := [1]types.Message{newMsg}
+	return msgs[:], nil
+}
+
+//------------------------------------------------------------------------------
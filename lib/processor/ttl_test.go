@@ -0,0 +1,166 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package processor
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/log"
+	"github.com/Jeffail/benthos/lib/message"
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+)
+
+//------------------------------------------------------------------------------
+
+func TestTTLBadConfig(t *testing.T) {
+	conf := NewConfig()
+	conf.TTL.OnExpired = "nope"
+	if _, err := NewTTL(conf, nil, log.Noop(), metrics.Noop()); err == nil {
+		t.Error("Expected error from bad on_expired")
+	}
+
+	conf = NewConfig()
+	conf.TTL.TTLMS = 0
+	if _, err := NewTTL(conf, nil, log.Noop(), metrics.Noop()); err == nil {
+		t.Error("Expected error from zero ttl_ms")
+	}
+}
+
+func TestTTLParseTimestamp(t *testing.T) {
+	tests := []struct {
+		format string
+		value  string
+		exp    time.Time
+	}{
+		{"unix", "1500000000", time.Unix(1500000000, 0)},
+		{"unix", "1500000000.5", time.Unix(1500000000, 500000000)},
+		{"unix_ms", "1500000000500", time.Unix(1500000000, 500000000)},
+		{"unix_nano", "1500000000000000001", time.Unix(1500000000, 1)},
+		{time.RFC3339, "2017-07-14T02:40:00Z", time.Unix(1500000000, 0)},
+	}
+
+	for _, test := range tests {
+		act, err := parseTTLTimestamp(test.format, test.value)
+		if err != nil {
+			t.Errorf("Failed to parse '%v': %v", test.value, err)
+			continue
+		}
+		if !act.Equal(test.exp) {
+			t.Errorf("Wrong result for '%v': %v != %v", test.value, act, test.exp)
+		}
+	}
+
+	if _, err := parseTTLTimestamp("unix", "not a number"); err == nil {
+		t.Error("Expected error from bad timestamp")
+	}
+}
+
+func TestTTLDrop(t *testing.T) {
+	conf := NewConfig()
+	conf.TTL.TTLMS = 1000
+
+	proc, err := NewTTL(conf, nil, log.Noop(), metrics.Noop())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Unix(1000, 0)
+	proc.(*TTL).now = func() time.Time { return now }
+
+	newPart := func(content string, ts int64) types.Part {
+		p := message.NewPart([]byte(content))
+		p.Metadata().Set("ingest_timestamp", strconv.FormatInt(ts, 10))
+		return p
+	}
+
+	msg := message.New(nil)
+	msg.Append(newPart("foo", 999))
+	msg.Append(newPart("bar", 998))
+	msg.Append(newPart("baz", 1000))
+	msg.Append(message.NewPart([]byte("no timestamp")))
+
+	msgs, res := proc.ProcessMessage(msg)
+	if res != nil {
+		t.Fatal(res.Error())
+	}
+	if len(msgs) != 1 {
+		t.Fatalf("Wrong count of messages: %v", len(msgs))
+	}
+	exp := [][]byte{[]byte("foo"), []byte("baz"), []byte("no timestamp")}
+	if act := message.GetAllBytes(msgs[0]); !reflect.DeepEqual(exp, act) {
+		t.Errorf("Wrong result: %s != %s", act, exp)
+	}
+
+	msg = message.New(nil)
+	msg.Append(newPart("foo", 500))
+
+	msgs, res = proc.ProcessMessage(msg)
+	if len(msgs) != 0 {
+		t.Errorf("Expected message to be dropped: %s", message.GetAllBytes(msgs[0]))
+	}
+	if res == nil || res.Error() != nil {
+		t.Errorf("Expected ack response: %v", res)
+	}
+}
+
+func TestTTLMarkJSON(t *testing.T) {
+	conf := NewConfig()
+	conf.TTL.TTLMS = 60000
+	conf.TTL.Timestamp = "${!json_field:created_at}"
+	conf.TTL.TimestampFormat = time.RFC3339
+	conf.TTL.OnExpired = "mark"
+
+	proc, err := NewTTL(conf, nil, log.Noop(), metrics.Noop())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now, _ := time.Parse(time.RFC3339, "2018-01-01T12:00:00Z")
+	proc.(*TTL).now = func() time.Time { return now }
+
+	msg := message.New([][]byte{
+		[]byte(`{"created_at":"2018-01-01T11:59:30Z"}`),
+		[]byte(`{"created_at":"2018-01-01T11:58:30Z"}`),
+	})
+
+	msgs, res := proc.ProcessMessage(msg)
+	if res != nil {
+		t.Fatal(res.Error())
+	}
+	if len(msgs) != 1 || msgs[0].Len() != 2 {
+		t.Fatalf("Wrong result: %v", msgs)
+	}
+	if act := msgs[0].Get(0).Metadata().Get("ttl_expired"); act != "" {
+		t.Errorf("Unexpected mark on first part: %v", act)
+	}
+	if act := msgs[0].Get(1).Metadata().Get("ttl_expired"); act != "true" {
+		t.Errorf("Expected mark on second part: %v", act)
+	}
+	if act := msg.Get(1).Metadata().Get("ttl_expired"); act != "" {
+		t.Errorf("Original message was modified: %v", act)
+	}
+}
+
+//------------------------------------------------------------------------------
@@ -32,6 +32,7 @@ import (
 	"github.com/Jeffail/benthos/lib/metrics"
 	"github.com/Jeffail/benthos/lib/response"
 	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/pool"
 )
 
 //------------------------------------------------------------------------------
@@ -92,13 +93,15 @@ func tarUnarchive(part types.Part) ([]types.Part, error) {
 			return nil, err
 		}
 
-		newPartBuf := bytes.Buffer{}
-		if _, err = newPartBuf.ReadFrom(tr); err != nil {
+		newPartBuf := pool.GetBuffer()
+		_, err = newPartBuf.ReadFrom(tr)
+		if err != nil {
+			pool.PutBuffer(newPartBuf)
 			return nil, err
 		}
 
 		newParts = append(newParts,
-			message.NewPart(newPartBuf.Bytes()).
+			message.NewPart(pool.Detach(newPartBuf)).
 				SetMetadata(part.Metadata().Copy().Set("archive_filename", h.Name)))
 	}
 
@@ -121,13 +124,16 @@ func zipUnarchive(part types.Part) ([]types.Part, error) {
 			return nil, err
 		}
 
-		newPartBuf := bytes.Buffer{}
-		if _, err = newPartBuf.ReadFrom(fr); err != nil {
+		newPartBuf := pool.GetBuffer()
+		_, err = newPartBuf.ReadFrom(fr)
+		fr.Close()
+		if err != nil {
+			pool.PutBuffer(newPartBuf)
 			return nil, err
 		}
 
 		newParts = append(newParts,
-			message.NewPart(newPartBuf.Bytes()).
+			message.NewPart(pool.Detach(newPartBuf)).
 				SetMetadata(part.Metadata().Copy().Set("archive_filename", f.Name)))
 	}
 
@@ -184,7 +190,7 @@ type Unarchive struct {
 
 	mCount     metrics.StatCounter
 	mSucc      metrics.StatCounter
-	mErr       metrics.StatCounter
+	mErr       *errorStats
 	mSkipped   metrics.StatCounter
 	mDropped   metrics.StatCounter
 	mSent      metrics.StatCounter
@@ -207,7 +213,7 @@ func NewUnarchive(
 
 		mCount:     stats.GetCounter("processor.unarchive.count"),
 		mSucc:      stats.GetCounter("processor.unarchive.success"),
-		mErr:       stats.GetCounter("processor.unarchive.error"),
+		mErr:       newErrorStats("unarchive", stats),
 		mSkipped:   stats.GetCounter("processor.unarchive.skipped"),
 		mDropped:   stats.GetCounter("processor.unarchive.dropped"),
 		mSent:      stats.GetCounter("processor.unarchive.sent"),
@@ -246,7 +252,7 @@ func (d *Unarchive) ProcessMessage(msg types.Message) ([]types.Message, types.Re
 			d.mSucc.Incr(1)
 			newMsg.Append(newParts...)
 		} else {
-			d.mErr.Incr(1)
+			d.mErr.Incr(ErrClassParse)
 		}
 		return nil
 	})
@@ -0,0 +1,293 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package processor
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/Jeffail/benthos/lib/log"
+	"github.com/Jeffail/benthos/lib/message/metadata"
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+)
+
+//------------------------------------------------------------------------------
+
+// wasmDescription documents the wasm processor plugin.
+var wasmDescription = `
+Executes a [WebAssembly](https://webassembly.org/) module on message parts,
+replacing the contents and metadata of each part with the result. This allows
+custom transformations to be written in languages such as Rust or TinyGo and
+executed sandboxed within the pipeline.
+
+This processor is only available in custom builds of Benthos that register a
+WebAssembly runtime with ` + "`processor.RegisterWASMRuntime`" + `, for more
+information read the [plugins documentation](../plugins.md).
+
+### ABI
+
+The module must export its ` + "`memory`" + ` along with the functions:
+
+- ` + "`allocate(size: i32) -> i32`" + `, which reserves ` + "`size`" + ` bytes of
+  memory and returns a pointer to them.
+- ` + "`process(ptr: i32, len: i32) -> i64`" + `, which processes the input at
+  ` + "`ptr`" + ` of length ` + "`len`" + ` and returns the pointer of its output
+  in the upper 32 bits and the length in the lower 32 bits.
+
+The input given to ` + "`process`" + ` is a JSON object containing the contents
+of the part encoded as base64 and its metadata:
+
+` + "``` json" + `
+{"content":"aGVsbG8gd29ybGQ=","metadata":{"kafka_key":"foo"}}
+` + "```" + `
+
+And the output must be an object of the same format, which replaces the
+contents and metadata of the part. In order to signal that the part could not
+be processed the output can instead contain an error, in which case the part
+is left unchanged:
+
+` + "``` json" + `
+{"error":"something went wrong"}
+` + "```" + `
+
+A pool of ` + "`pool_size`" + ` instances of the module is created when the
+processor starts, and each part is processed by one instance at a time. An
+instance that fails to execute is replaced with a new one.`
+
+//------------------------------------------------------------------------------
+
+// WASMConfig contains configuration fields for the WASM processor.
+type WASMConfig struct {
+	Parts    []int  `json:"parts" yaml:"parts"`
+	Path     string `json:"path" yaml:"path"`
+	PoolSize int    `json:"pool_size" yaml:"pool_size"`
+}
+
+// NewWASMConfig returns a WASMConfig with default values.
+func NewWASMConfig() WASMConfig {
+	return WASMConfig{
+		Parts:    []int{},
+		Path:     "",
+		PoolSize: 1,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// WASMRuntime compiles WebAssembly modules for the wasm processor.
+type WASMRuntime interface {
+	// Compile parses and validates the code of a WebAssembly module.
+	Compile(code []byte) (WASMModule, error)
+}
+
+// WASMModule is a compiled WebAssembly module.
+type WASMModule interface {
+	// Instantiate creates a new instance of the module with its own memory.
+	Instantiate() (WASMInstance, error)
+}
+
+// WASMInstance is an instance of a WebAssembly module that implements the ABI
+// of the wasm processor.
+type WASMInstance interface {
+	// Call copies the input into the memory of the instance with its exported
+	// allocate function, executes its exported process function and returns a
+	// copy of the output. Call is never called concurrently on an instance.
+	Call(input []byte) ([]byte, error)
+}
+
+// RegisterWASMRuntime registers the wasm processor as a plugin that executes
+// modules with a WebAssembly runtime. This should be called before any configs
+// are parsed, usually from an init func.
+func RegisterWASMRuntime(r WASMRuntime) {
+	RegisterPlugin(
+		"wasm",
+		func() interface{} {
+			conf := NewWASMConfig()
+			return &conf
+		},
+		func(
+			conf interface{}, mgr types.Manager, log log.Modular, stats metrics.Type,
+		) (types.Processor, error) {
+			return NewWASM(r, *conf.(*WASMConfig), log, stats)
+		},
+	)
+	DocumentPlugin("wasm", wasmDescription, nil)
+}
+
+//------------------------------------------------------------------------------
+
+// wasmFrame is the JSON format of the inputs and outputs of WASM instances.
+type wasmFrame struct {
+	Content  []byte            `json:"content"`
+	Metadata map[string]string `json:"metadata"`
+	Error    string            `json:"error,omitempty"`
+}
+
+//------------------------------------------------------------------------------
+
+// WASM is a processor that executes a WebAssembly module on message parts.
+type WASM struct {
+	parts  []int
+	module WASMModule
+	pool   chan WASMInstance
+
+	log   log.Modular
+	stats metrics.Type
+
+	mCount       metrics.StatCounter
+	mErr         *errorStats
+	mErrProcess  metrics.StatCounter
+	mErrInstance metrics.StatCounter
+	mSucc        metrics.StatCounter
+	mSent        metrics.StatCounter
+	mSentParts   metrics.StatCounter
+}
+
+// NewWASM returns a WASM processor that executes modules with a runtime.
+func NewWASM(
+	runtime WASMRuntime, conf WASMConfig, log log.Modular, stats metrics.Type,
+) (*WASM, error) {
+	if conf.PoolSize < 1 {
+		return nil, fmt.Errorf("pool_size must be at least 1, got %v", conf.PoolSize)
+	}
+
+	code, err := ioutil.ReadFile(conf.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read module: %v", err)
+	}
+	module, err := runtime.Compile(code)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile module: %v", err)
+	}
+
+	pool := make(chan WASMInstance, conf.PoolSize)
+	for i := 0; i < conf.PoolSize; i++ {
+		inst, err := module.Instantiate()
+		if err != nil {
+			return nil, fmt.Errorf("failed to instantiate module: %v", err)
+		}
+		pool <- inst
+	}
+
+	return &WASM{
+		parts:  conf.Parts,
+		module: module,
+		pool:   pool,
+		log:    log.NewModule(".processor.wasm"),
+		stats:  stats,
+
+		mCount:       stats.GetCounter("processor.wasm.count"),
+		mErr:         newErrorStats("wasm", stats),
+		mErrProcess:  stats.GetCounter("processor.wasm.error.process"),
+		mErrInstance: stats.GetCounter("processor.wasm.error.instantiate"),
+		mSucc:        stats.GetCounter("processor.wasm.success"),
+		mSent:        stats.GetCounter("processor.wasm.sent"),
+		mSentParts:   stats.GetCounter("processor.wasm.parts.sent"),
+	}, nil
+}
+
+//------------------------------------------------------------------------------
+
+// call executes a pooled instance with an input, replacing the instance with a
+// new one when the execution fails as its state can no longer be trusted.
+func (w *WASM) call(input []byte) ([]byte, error) {
+	inst := <-w.pool
+	output, err := inst.Call(input)
+	if err != nil {
+		if newInst, ierr := w.module.Instantiate(); ierr != nil {
+			w.mErrInstance.Incr(1)
+			w.log.Errorf("Failed to replace module instance: %v\n", ierr)
+		} else {
+			inst = newInst
+		}
+	}
+	w.pool <- inst
+	return output, err
+}
+
+func (w *WASM) processPart(part types.Part) error {
+	input := wasmFrame{
+		Content:  part.Get(),
+		Metadata: map[string]string{},
+	}
+	part.Metadata().Iter(func(k, v string) error {
+		input.Metadata[k] = v
+		return nil
+	})
+
+	inputBytes, err := json.Marshal(input)
+	if err != nil {
+		return fmt.Errorf("failed to encode input: %v", err)
+	}
+	outputBytes, err := w.call(inputBytes)
+	if err != nil {
+		return fmt.Errorf("failed to execute module: %v", err)
+	}
+
+	var output wasmFrame
+	if err = json.Unmarshal(outputBytes, &output); err != nil {
+		return fmt.Errorf("failed to decode output: %v", err)
+	}
+	if len(output.Error) > 0 {
+		return errors.New(output.Error)
+	}
+
+	part.Set(output.Content)
+	part.SetMetadata(metadata.New(output.Metadata))
+	return nil
+}
+
+// ProcessMessage applies the processor to a message, either creating >0
+// resulting messages or a response to be sent back to the message source.
+func (w *WASM) ProcessMessage(msg types.Message) ([]types.Message, types.Response) {
+	w.mCount.Incr(1)
+
+	newMsg := msg.Copy()
+
+	targetParts := w.parts
+	if len(targetParts) == 0 {
+		targetParts = make([]int, newMsg.Len())
+		for i := range targetParts {
+			targetParts[i] = i
+		}
+	}
+
+	for _, index := range targetParts {
+		if err := w.processPart(newMsg.Get(index)); err != nil {
+			w.mErrProcess.Incr(1)
+			w.mErr.IncrErr(err)
+			w.log.Debugf("Failed to process part: %v\n", err)
+			continue
+		}
+		w.mSucc.Incr(1)
+	}
+
+	msgs := [1]types.Message{newMsg}
+
+	w.mSent.Incr(1)
+	w.mSentParts.Incr(int64(newMsg.Len()))
+	return msgs[:], nil
+}
+
+//------------------------------------------------------------------------------
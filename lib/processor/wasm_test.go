@@ -0,0 +1,243 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package processor
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/Jeffail/benthos/lib/log"
+	"github.com/Jeffail/benthos/lib/message"
+	"github.com/Jeffail/benthos/lib/metrics"
+)
+
+//------------------------------------------------------------------------------
+
+// fakeWASMRuntime emulates a module that uppercases the content of parts and
+// tags them with the ID of the instance that processed them.
+type fakeWASMRuntime struct {
+	sync.Mutex
+	instances int
+}
+
+func (r *fakeWASMRuntime) Compile(code []byte) (WASMModule, error) {
+	if string(code) != "upper" {
+		return nil, errors.New("invalid module")
+	}
+	return r, nil
+}
+
+func (r *fakeWASMRuntime) Instantiate() (WASMInstance, error) {
+	r.Lock()
+	defer r.Unlock()
+	r.instances++
+	return fakeWASMInstance(r.instances), nil
+}
+
+type fakeWASMInstance int
+
+func (i fakeWASMInstance) Call(input []byte) ([]byte, error) {
+	var frame wasmFrame
+	if err := json.Unmarshal(input, &frame); err != nil {
+		return nil, err
+	}
+	switch string(frame.Content) {
+	case "trap":
+		return nil, errors.New("unreachable executed")
+	case "fail":
+		return json.Marshal(wasmFrame{Error: "refusing to process"})
+	}
+	frame.Content = bytes.ToUpper(frame.Content)
+	frame.Metadata["instance"] = strconv.Itoa(int(i))
+	return json.Marshal(frame)
+}
+
+func writeWASMModule(t *testing.T, code string) (string, func()) {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "benthos_wasm_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "module.wasm")
+	if err = ioutil.WriteFile(path, []byte(code), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path, func() { os.RemoveAll(dir) }
+}
+
+//------------------------------------------------------------------------------
+
+func TestWASMPlugin(t *testing.T) {
+	if PluginExists("wasm") {
+		t.Fatal("Expected wasm plugin to be absent without a runtime")
+	}
+
+	RegisterWASMRuntime(&fakeWASMRuntime{})
+	defer delete(pluginSpecs, "wasm")
+
+	path, cleanup := writeWASMModule(t, "upper")
+	defer cleanup()
+
+	wConf := NewWASMConfig()
+	wConf.Path = path
+
+	conf := NewConfig()
+	conf.Type = "wasm"
+	conf.Plugin = &wConf
+
+	testLog := log.New(os.Stdout, log.Config{LogLevel: "NONE"})
+	proc, err := New(conf, nil, testLog, metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msgs, res := proc.ProcessMessage(message.New([][]byte{[]byte("foo")}))
+	if res != nil {
+		t.Fatal(res.Error())
+	}
+	if exp, act := "FOO", string(msgs[0].Get(0).Get()); exp != act {
+		t.Errorf("Wrong result: %v != %v", act, exp)
+	}
+}
+
+func TestWASMBadConfig(t *testing.T) {
+	runtime := &fakeWASMRuntime{}
+
+	path, cleanup := writeWASMModule(t, "not a module")
+	defer cleanup()
+
+	testLog := log.New(os.Stdout, log.Config{LogLevel: "NONE"})
+
+	conf := NewWASMConfig()
+	conf.Path = path
+	if _, err := NewWASM(runtime, conf, testLog, metrics.DudType{}); err == nil {
+		t.Error("Expected error from bad module")
+	}
+
+	conf.Path = path + ".missing"
+	if _, err := NewWASM(runtime, conf, testLog, metrics.DudType{}); err == nil {
+		t.Error("Expected error from missing module")
+	}
+
+	conf.Path = path
+	conf.PoolSize = 0
+	if _, err := NewWASM(runtime, conf, testLog, metrics.DudType{}); err == nil {
+		t.Error("Expected error from zero pool size")
+	}
+}
+
+func TestWASMBasic(t *testing.T) {
+	runtime := &fakeWASMRuntime{}
+
+	path, cleanup := writeWASMModule(t, "upper")
+	defer cleanup()
+
+	conf := NewWASMConfig()
+	conf.Path = path
+	conf.PoolSize = 2
+
+	testLog := log.New(os.Stdout, log.Config{LogLevel: "NONE"})
+	proc, err := NewWASM(runtime, conf, testLog, metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp, act := 2, runtime.instances; exp != act {
+		t.Errorf("Wrong count of instances: %v != %v", act, exp)
+	}
+
+	msgIn := message.New([][]byte{
+		[]byte("hello world"),
+		[]byte("fail"),
+	})
+	msgIn.Get(0).Metadata().Set("foo", "bar")
+	msgIn.Get(1).Metadata().Set("foo", "baz")
+
+	msgs, res := proc.ProcessMessage(msgIn)
+	if res != nil {
+		t.Fatal(res.Error())
+	}
+	if len(msgs) != 1 {
+		t.Fatalf("Wrong count of messages: %v", len(msgs))
+	}
+
+	if exp, act := "HELLO WORLD", string(msgs[0].Get(0).Get()); exp != act {
+		t.Errorf("Wrong result: %v != %v", act, exp)
+	}
+	if exp, act := "bar", msgs[0].Get(0).Metadata().Get("foo"); exp != act {
+		t.Errorf("Wrong metadata: %v != %v", act, exp)
+	}
+	if act := msgs[0].Get(0).Metadata().Get("instance"); len(act) == 0 {
+		t.Error("Expected metadata from module")
+	}
+
+	if exp, act := "fail", string(msgs[0].Get(1).Get()); exp != act {
+		t.Errorf("Wrong result: %v != %v", act, exp)
+	}
+	if exp, act := "baz", msgs[0].Get(1).Metadata().Get("foo"); exp != act {
+		t.Errorf("Wrong metadata: %v != %v", act, exp)
+	}
+	if act := msgs[0].Get(1).Metadata().Get("instance"); len(act) > 0 {
+		t.Errorf("Unexpected metadata from failed part: %v", act)
+	}
+
+	if exp, act := "hello world", string(msgIn.Get(0).Get()); exp != act {
+		t.Errorf("Input message was modified: %v != %v", act, exp)
+	}
+}
+
+func TestWASMTrapReplacesInstance(t *testing.T) {
+	runtime := &fakeWASMRuntime{}
+
+	path, cleanup := writeWASMModule(t, "upper")
+	defer cleanup()
+
+	conf := NewWASMConfig()
+	conf.Path = path
+
+	testLog := log.New(os.Stdout, log.Config{LogLevel: "NONE"})
+	proc, err := NewWASM(runtime, conf, testLog, metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msgs, _ := proc.ProcessMessage(message.New([][]byte{
+		[]byte("trap"),
+		[]byte("foo"),
+	}))
+	if exp, act := "trap", string(msgs[0].Get(0).Get()); exp != act {
+		t.Errorf("Wrong result: %v != %v", act, exp)
+	}
+	if exp, act := "FOO", string(msgs[0].Get(1).Get()); exp != act {
+		t.Errorf("Wrong result: %v != %v", act, exp)
+	}
+	if exp, act := "2", msgs[0].Get(1).Metadata().Get("instance"); exp != act {
+		t.Errorf("Trapped instance was not replaced: %v != %v", act, exp)
+	}
+}
+
+//------------------------------------------------------------------------------
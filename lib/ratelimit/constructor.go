@@ -42,6 +42,11 @@ type TypeSpec struct {
 	description string
 }
 
+// Description returns the markdown description of the type.
+func (t TypeSpec) Description() string {
+	return t.description
+}
+
 // Constructors is a map of all cache types with their specs.
 var Constructors = map[string]TypeSpec{}
 
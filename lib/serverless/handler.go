@@ -0,0 +1,215 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package serverless
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/log"
+	"github.com/Jeffail/benthos/lib/manager"
+	"github.com/Jeffail/benthos/lib/message"
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/output"
+	"github.com/Jeffail/benthos/lib/pipeline"
+	"github.com/Jeffail/benthos/lib/processor"
+	"github.com/Jeffail/benthos/lib/types"
+)
+
+//------------------------------------------------------------------------------
+
+// Config is the configuration of a serverless handler. It is a subset of a
+// regular Benthos config, and therefore regular configs can be used, where
+// the input, buffer and HTTP sections are ignored.
+//
+// The output is optional. When it is not set the processed messages are
+// returned as the response of each invocation, otherwise they are delivered
+// to the output.
+type Config struct {
+	Pipeline pipeline.Config `json:"pipeline" yaml:"pipeline"`
+	Output   *output.Config  `json:"output,omitempty" yaml:"output,omitempty"`
+	Manager  manager.Config  `json:"resources" yaml:"resources"`
+	Logger   log.Config      `json:"logger" yaml:"logger"`
+	Metrics  metrics.Config  `json:"metrics" yaml:"metrics"`
+}
+
+// NewConfig returns a new configuration with default values.
+func NewConfig() Config {
+	return Config{
+		Pipeline: pipeline.NewConfig(),
+		Manager:  manager.NewConfig(),
+		Logger:   log.NewConfig(),
+		Metrics:  metrics.NewConfig(),
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// Handler processes the payload of each invocation with the processors of a
+// config, and either returns the results or delivers them to an output.
+type Handler struct {
+	log   log.Modular
+	stats metrics.Type
+
+	procs     []processor.Type
+	output    output.Type
+	transChan chan types.Transaction
+}
+
+// NewHandler creates a new serverless handler from a config.
+func NewHandler(conf Config, log log.Modular, stats metrics.Type) (*Handler, error) {
+	mgr, err := manager.New(conf.Manager, noopAPIReg{}, log, stats)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create resources: %v", err)
+	}
+
+	h := &Handler{
+		log:   log,
+		stats: stats,
+	}
+	for i, pConf := range conf.Pipeline.Processors {
+		proc, err := processor.New(pConf, mgr, log, stats)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create processor %v: %v", i, err)
+		}
+		h.procs = append(h.procs, proc)
+	}
+	if conf.Pipeline.Lineage.Enabled {
+		stamp, err := pipeline.NewLineageStamp(conf.Pipeline)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create lineage stamp: %v", err)
+		}
+		h.procs = append(h.procs, stamp)
+	}
+
+	if conf.Output != nil {
+		if h.output, err = output.New(*conf.Output, mgr, log, stats); err != nil {
+			return nil, fmt.Errorf("failed to create output: %v", err)
+		}
+		h.transChan = make(chan types.Transaction)
+		if err = h.output.Consume(h.transChan); err != nil {
+			return nil, fmt.Errorf("failed to start output: %v", err)
+		}
+	}
+	return h, nil
+}
+
+//------------------------------------------------------------------------------
+
+// Handle processes the payload of an invocation as a single message. When the
+// handler has an output the resulting messages are delivered to it and the
+// response is null, otherwise the resulting messages are returned as the
+// response.
+//
+// A response of one message with one part is the part, which is written as is
+// when it is valid JSON and as a JSON string otherwise. Messages of multiple
+// parts are arrays of parts, and multiple messages are an array of messages.
+// When all messages are filtered the response is null.
+func (h *Handler) Handle(ctx context.Context, payload []byte) ([]byte, error) {
+	msgs := []types.Message{message.New([][]byte{payload})}
+	for i := 0; len(msgs) > 0 && i < len(h.procs); i++ {
+		var nextMsgs []types.Message
+		for _, m := range msgs {
+			rMsgs, res := h.procs[i].ProcessMessage(m)
+			if len(rMsgs) == 0 && res != nil && res.Error() != nil {
+				return nil, res.Error()
+			}
+			nextMsgs = append(nextMsgs, rMsgs...)
+		}
+		msgs = nextMsgs
+	}
+
+	if h.output == nil {
+		return responseOf(msgs)
+	}
+
+	resChan := make(chan types.Response)
+	for _, m := range msgs {
+		select {
+		case h.transChan <- types.NewTransaction(m, resChan):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		select {
+		case res := <-resChan:
+			if err := res.Error(); err != nil {
+				return nil, err
+			}
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	return []byte("null"), nil
+}
+
+func responseOf(msgs []types.Message) ([]byte, error) {
+	if len(msgs) == 0 {
+		return []byte("null"), nil
+	}
+
+	partValue := func(b []byte) json.RawMessage {
+		if json.Valid(b) {
+			return json.RawMessage(b)
+		}
+		strBytes, _ := json.Marshal(string(b))
+		return json.RawMessage(strBytes)
+	}
+	msgValue := func(msg types.Message) interface{} {
+		if msg.Len() == 1 {
+			return partValue(msg.Get(0).Get())
+		}
+		parts := make([]json.RawMessage, 0, msg.Len())
+		msg.Iter(func(i int, p types.Part) error {
+			parts = append(parts, partValue(p.Get()))
+			return nil
+		})
+		return parts
+	}
+
+	if len(msgs) == 1 {
+		return json.Marshal(msgValue(msgs[0]))
+	}
+	values := make([]interface{}, 0, len(msgs))
+	for _, m := range msgs {
+		values = append(values, msgValue(m))
+	}
+	return json.Marshal(values)
+}
+
+// Close shuts down the output of the handler, if there is one.
+func (h *Handler) Close(timeout time.Duration) error {
+	if h.output == nil {
+		return nil
+	}
+	close(h.transChan)
+	return h.output.WaitForClose(timeout)
+}
+
+//------------------------------------------------------------------------------
+
+type noopAPIReg struct{}
+
+func (noopAPIReg) RegisterEndpoint(path, desc string, h http.HandlerFunc) {}
+
+//------------------------------------------------------------------------------
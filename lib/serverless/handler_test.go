@@ -0,0 +1,146 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package serverless
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/log"
+	"github.com/Jeffail/benthos/lib/metrics"
+	yaml "gopkg.in/yaml.v2"
+)
+
+func newTestHandler(t *testing.T, confStr string) *Handler {
+	t.Helper()
+	conf := NewConfig()
+	if err := yaml.Unmarshal([]byte(confStr), &conf); err != nil {
+		t.Fatal(err)
+	}
+	h, err := NewHandler(conf, log.Noop(), metrics.Noop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return h
+}
+
+func TestHandlerResponse(t *testing.T) {
+	h := newTestHandler(t, `
+input:
+  type: stdin
+pipeline:
+  processors:
+  - type: filter
+    filter:
+      type: text
+      text:
+        operator: contains
+        arg: foo
+  - type: jmespath
+    jmespath:
+      query: value
+`)
+	defer h.Close(time.Second)
+
+	tests := []struct {
+		payload string
+		res     string
+	}{
+		{payload: `{"value":"foo"}`, res: `"foo"`},
+		{payload: `{"value":{"foo":[1,2]}}`, res: `{"foo":[1,2]}`},
+		{payload: `{"value":"bar"}`, res: `null`},
+	}
+	for _, test := range tests {
+		res, err := h.Handle(context.Background(), []byte(test.payload))
+		if err != nil {
+			t.Errorf("Payload %v: %v", test.payload, err)
+			continue
+		}
+		if exp, act := test.res, string(res); exp != act {
+			t.Errorf("Wrong response to %v: %v != %v", test.payload, act, exp)
+		}
+	}
+}
+
+func TestHandlerMultipleResponse(t *testing.T) {
+	h := newTestHandler(t, `
+pipeline:
+  processors:
+  - type: unarchive
+    unarchive:
+      format: lines
+`)
+	defer h.Close(time.Second)
+
+	res, err := h.Handle(context.Background(), []byte("foo\n{\"bar\":1}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp, act := `["foo",{"bar":1}]`, string(res); exp != act {
+		t.Errorf("Wrong response: %v != %v", act, exp)
+	}
+}
+
+func TestHandlerOutput(t *testing.T) {
+	dir, err := ioutil.TempDir("", "benthos_serverless_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	outPath := filepath.Join(dir, "out.txt")
+
+	h := newTestHandler(t, `
+pipeline:
+  processors:
+  - type: jmespath
+    jmespath:
+      query: value
+output:
+  type: file
+  file:
+    path: `+outPath+`
+`)
+
+	for _, payload := range []string{`{"value":"foo"}`, `{"value":"bar"}`} {
+		res, err := h.Handle(context.Background(), []byte(payload))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if exp, act := "null", string(res); exp != act {
+			t.Errorf("Wrong response: %v != %v", act, exp)
+		}
+	}
+	if err = h.Close(time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	outBytes, err := ioutil.ReadFile(outPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp, act := "\"foo\"\n\"bar\"\n", string(outBytes); exp != act {
+		t.Errorf("Wrong output: %v != %v", act, exp)
+	}
+}
@@ -0,0 +1,142 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package serverless
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+//------------------------------------------------------------------------------
+
+// lambdaAPIVersion is the version of the AWS Lambda runtime API.
+const lambdaAPIVersion = "2018-06-01"
+
+// lambdaError is the body of an error reported to the runtime API.
+type lambdaError struct {
+	ErrorMessage string `json:"errorMessage"`
+	ErrorType    string `json:"errorType"`
+}
+
+// lambdaRuntime is a client of the AWS Lambda runtime API.
+type lambdaRuntime struct {
+	client  *http.Client
+	baseURL string
+}
+
+func newLambdaRuntime(address string) *lambdaRuntime {
+	return &lambdaRuntime{
+		// Requests for the next invocation block until one arrives.
+		client:  &http.Client{},
+		baseURL: "http://" + address + "/" + lambdaAPIVersion + "/runtime",
+	}
+}
+
+func (l *lambdaRuntime) post(path string, body []byte) error {
+	res, err := l.client.Post(l.baseURL+path, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("runtime API %v returned status: %v", path, res.StatusCode)
+	}
+	return nil
+}
+
+func (l *lambdaRuntime) postError(path string, err error) error {
+	errBytes, _ := json.Marshal(lambdaError{
+		ErrorMessage: err.Error(),
+		ErrorType:    "BenthosError",
+	})
+	return l.post(path, errBytes)
+}
+
+// next blocks until the next invocation and returns its ID, deadline and
+// payload.
+func (l *lambdaRuntime) next() (string, time.Time, []byte, error) {
+	res, err := l.client.Get(l.baseURL + "/invocation/next")
+	if err != nil {
+		return "", time.Time{}, nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return "", time.Time{}, nil, fmt.Errorf("runtime API returned status: %v", res.StatusCode)
+	}
+
+	payload, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return "", time.Time{}, nil, err
+	}
+
+	var deadline time.Time
+	if deadlineMS, err := strconv.ParseInt(res.Header.Get("Lambda-Runtime-Deadline-Ms"), 10, 64); err == nil {
+		deadline = time.Unix(0, deadlineMS*int64(time.Millisecond))
+	}
+	return res.Header.Get("Lambda-Runtime-Aws-Request-Id"), deadline, payload, nil
+}
+
+//------------------------------------------------------------------------------
+
+// RunLambda serves invocations from the AWS Lambda runtime API at an address,
+// which is given to functions by the environment variable
+// AWS_LAMBDA_RUNTIME_API, with a handler. Each invocation is handled in turn,
+// and this call only returns when communicating with the runtime API fails.
+func RunLambda(address string, h *Handler) error {
+	runtime := newLambdaRuntime(address)
+	for {
+		id, deadline, payload, err := runtime.next()
+		if err != nil {
+			return fmt.Errorf("failed to obtain next invocation: %v", err)
+		}
+
+		ctx, done := context.Background(), func() {}
+		if !deadline.IsZero() {
+			ctx, done = context.WithDeadline(ctx, deadline)
+		}
+		resBytes, err := h.Handle(ctx, payload)
+		done()
+
+		if err != nil {
+			h.log.Errorf("Failed to handle invocation %v: %v\n", id, err)
+			err = runtime.postError("/invocation/"+id+"/error", err)
+		} else {
+			err = runtime.post("/invocation/"+id+"/response", resBytes)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to respond to invocation %v: %v", id, err)
+		}
+	}
+}
+
+// ReportLambdaInitError reports an error that prevents a function from being
+// initialised to the AWS Lambda runtime API at an address.
+func ReportLambdaInitError(address string, err error) error {
+	return newLambdaRuntime(address).postError("/init/error", err)
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,114 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package serverless
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRunLambda(t *testing.T) {
+	h := newTestHandler(t, `
+pipeline:
+  processors:
+  - type: jmespath
+    jmespath:
+      query: value
+`)
+	defer h.Close(time.Second)
+
+	events := []string{`{"value":"foo"}`, `not json`}
+	var eventsMut sync.Mutex
+	results := map[string]string{}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		eventsMut.Lock()
+		defer eventsMut.Unlock()
+
+		const prefix = "/2018-06-01/runtime/invocation/"
+		if !strings.HasPrefix(r.URL.Path, prefix) {
+			t.Errorf("Unexpected path: %v", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		path := strings.TrimPrefix(r.URL.Path, prefix)
+		if path == "next" {
+			if len(events) == 0 {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			w.Header().Set("Lambda-Runtime-Aws-Request-Id", "req"+strconv.Itoa(len(events)))
+			w.Header().Set("Lambda-Runtime-Deadline-Ms", "9999999999999")
+			w.Write([]byte(events[0]))
+			events = events[1:]
+			return
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		results[path] = string(body)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	err := RunLambda(strings.TrimPrefix(server.URL, "http://"), h)
+	if err == nil || !strings.Contains(err.Error(), "status: 500") {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	exp := map[string]string{
+		"req2/response": `"foo"`,
+		"req1/response": `"not json"`,
+	}
+	if len(results) != len(exp) {
+		t.Errorf("Wrong results: %v", results)
+	}
+	for k, v := range exp {
+		if act := results[k]; v != act {
+			t.Errorf("Wrong result for %v: %v != %v", k, act, v)
+		}
+	}
+}
+
+func TestReportLambdaInitError(t *testing.T) {
+	var path, body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		bodyBytes, _ := ioutil.ReadAll(r.Body)
+		path, body = r.URL.Path, string(bodyBytes)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	if err := ReportLambdaInitError(strings.TrimPrefix(server.URL, "http://"), errors.New("nope")); err != nil {
+		t.Fatal(err)
+	}
+	if exp, act := "/2018-06-01/runtime/init/error", path; exp != act {
+		t.Errorf("Wrong path: %v != %v", act, exp)
+	}
+	if exp, act := `{"errorMessage":"nope","errorType":"BenthosError"}`, body; exp != act {
+		t.Errorf("Wrong body: %v != %v", act, exp)
+	}
+}
@@ -0,0 +1,376 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package service
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math/rand"
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/log"
+	"github.com/Jeffail/benthos/lib/manager"
+	"github.com/Jeffail/benthos/lib/message"
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/output"
+	"github.com/Jeffail/benthos/lib/pipeline"
+	"github.com/Jeffail/benthos/lib/processor"
+	"github.com/Jeffail/benthos/lib/response"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/config"
+)
+
+//------------------------------------------------------------------------------
+
+// benchOptions determines the messages generated during a benchmark.
+type benchOptions struct {
+	rate     int
+	duration time.Duration
+	size     int
+	batch    int
+	payload  string
+	drop     bool
+}
+
+// benchResults summarises a benchmark run.
+type benchResults struct {
+	sent      int64
+	errors    int64
+	pending   int64
+	elapsed   time.Duration
+	latencies []time.Duration
+	mallocs   uint64
+	bytes     uint64
+	numGC     uint32
+}
+
+// percentile returns the latency at a percentile (0 -> 1) of the results, the
+// latencies must already be sorted.
+func (r benchResults) percentile(p float64) time.Duration {
+	if len(r.latencies) == 0 {
+		return 0
+	}
+	i := int(float64(len(r.latencies))*p+0.5) - 1
+	if i < 0 {
+		i = 0
+	} else if i >= len(r.latencies) {
+		i = len(r.latencies) - 1
+	}
+	return r.latencies[i]
+}
+
+// report writes a human readable summary of the results.
+func (r benchResults) report(w io.Writer) {
+	acked := int64(len(r.latencies))
+	secs := r.elapsed.Seconds()
+	fmt.Fprintf(w, "Duration:   %v\n", r.elapsed.Round(time.Millisecond))
+	fmt.Fprintf(w, "Sent:       %v messages (%.1f/s)\n", r.sent, float64(r.sent)/secs)
+	fmt.Fprintf(w, "Acked:      %v messages (%.1f/s)\n", acked, float64(acked)/secs)
+	fmt.Fprintf(w, "Errors:     %v\n", r.errors)
+	if r.pending > 0 {
+		fmt.Fprintf(w, "Pending:    %v\n", r.pending)
+	}
+	fmt.Fprintf(
+		w, "Latency:    p50 %v, p90 %v, p99 %v, max %v\n",
+		r.percentile(0.5), r.percentile(0.9), r.percentile(0.99), r.percentile(1),
+	)
+	if r.sent > 0 {
+		fmt.Fprintf(
+			w, "Allocs:     %v per message (%v bytes), %v GC cycles\n",
+			r.mallocs/uint64(r.sent), r.bytes/uint64(r.sent), r.numGC,
+		)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// benchParts creates the message parts to send during a benchmark, which are
+// either the payload given or random alphanumeric bytes.
+func benchParts(opts benchOptions) [][]byte {
+	const chars = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+	parts := make([][]byte, opts.batch)
+	for i := range parts {
+		if len(opts.payload) > 0 {
+			parts[i] = []byte(opts.payload)
+			continue
+		}
+		parts[i] = make([]byte, opts.size)
+		for j := range parts[i] {
+			parts[i][j] = chars[rand.Intn(len(chars))]
+		}
+	}
+	return parts
+}
+
+// bench drives the processors and output of a config with generated messages
+// and measures the time taken for each message to be acknowledged.
+func bench(conf Config, opts benchOptions, logger log.Modular) (benchResults, error) {
+	var res benchResults
+	stats := metrics.Noop()
+	closeTimeout := time.Millisecond * time.Duration(conf.SystemCloseTimeoutMS)
+
+	mgr, err := manager.New(conf.Manager, types.NoopMgr(), logger, stats)
+	if err != nil {
+		return res, fmt.Errorf("failed to create resources: %v", err)
+	}
+
+	// The input is replaced with generated messages, but its processors are
+	// kept.
+	pConf := conf.Pipeline
+	pConf.Processors = append(
+		append([]processor.Config{}, conf.Input.Processors...),
+		conf.Pipeline.Processors...,
+	)
+
+	tranChan := make(chan types.Transaction)
+	var nextChan <-chan types.Transaction = tranChan
+
+	var pipe pipeline.Type
+	if len(pConf.Processors) > 0 {
+		if pipe, err = pipeline.New(pConf, mgr, logger, stats); err != nil {
+			return res, fmt.Errorf("failed to create pipeline: %v", err)
+		}
+		if err = pipe.Consume(tranChan); err != nil {
+			return res, err
+		}
+		nextChan = pipe.TransactionChan()
+	}
+
+	if opts.drop {
+		go func(tChan <-chan types.Transaction) {
+			for tran := range tChan {
+				tran.ResponseChan <- response.NewAck()
+			}
+		}(nextChan)
+		defer func() {
+			close(tranChan)
+			if pipe != nil {
+				pipe.CloseAsync()
+			}
+		}()
+	} else {
+		out, err := output.New(conf.Output, mgr, logger, stats)
+		if err != nil {
+			return res, fmt.Errorf("failed to create output: %v", err)
+		}
+		if err = out.Consume(nextChan); err != nil {
+			return res, err
+		}
+		defer func() {
+			close(tranChan)
+			if pipe != nil {
+				pipe.CloseAsync()
+			}
+			out.CloseAsync()
+			if err := out.WaitForClose(closeTimeout); err != nil {
+				logger.Warnf("Failed to close output cleanly: %v\n", err)
+			}
+		}()
+	}
+
+	parts := benchParts(opts)
+
+	var interval time.Duration
+	if opts.rate > 0 {
+		interval = time.Second / time.Duration(opts.rate)
+	}
+
+	// Acknowledgements are recorded under resMut until the timedOut flag is
+	// set, after which late acknowledgements are ignored.
+	var resMut sync.Mutex
+	var latencies []time.Duration
+	var errCount int64
+	var timedOut bool
+	var wg sync.WaitGroup
+
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+
+	start := time.Now()
+	deadline := time.NewTimer(opts.duration)
+	defer deadline.Stop()
+
+genLoop:
+	for i := 0; ; i++ {
+		if interval > 0 {
+			if wait := time.Until(start.Add(time.Duration(i) * interval)); wait > 0 {
+				select {
+				case <-time.After(wait):
+				case <-deadline.C:
+					break genLoop
+				}
+			}
+		}
+
+		msg := message.New(nil)
+		for _, p := range parts {
+			msg.Append(message.NewPart(append([]byte(nil), p...)))
+		}
+
+		resChan := make(chan types.Response, 1)
+		sentAt := time.Now()
+		select {
+		case tranChan <- types.NewTransaction(msg, resChan):
+		case <-deadline.C:
+			break genLoop
+		}
+		res.sent++
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			tRes := <-resChan
+			latency := time.Since(sentAt)
+
+			resMut.Lock()
+			if !timedOut {
+				latencies = append(latencies, latency)
+				if tRes.Error() != nil {
+					errCount++
+				}
+			}
+			resMut.Unlock()
+		}()
+	}
+
+	doneChan := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(doneChan)
+	}()
+	select {
+	case <-doneChan:
+	case <-time.After(closeTimeout):
+		logger.Warnln("Timed out waiting for pending messages to be acknowledged")
+	}
+
+	resMut.Lock()
+	timedOut = true
+	res.elapsed = time.Since(start)
+	res.latencies = append([]time.Duration(nil), latencies...)
+	res.errors = errCount
+	resMut.Unlock()
+	res.pending = res.sent - int64(len(res.latencies))
+
+	runtime.ReadMemStats(&after)
+	res.mallocs = after.Mallocs - before.Mallocs
+	res.bytes = after.TotalAlloc - before.TotalAlloc
+	res.numGC = after.NumGC - before.NumGC
+
+	sort.Slice(res.latencies, func(i, j int) bool {
+		return res.latencies[i] < res.latencies[j]
+	})
+	return res, nil
+}
+
+//------------------------------------------------------------------------------
+
+// runBench executes the bench subcommand and returns the exit status to use.
+func runBench(args []string, w, errW io.Writer) int {
+	flags := flag.NewFlagSet("bench", flag.ContinueOnError)
+	flags.SetOutput(errW)
+	rate := flags.Int(
+		"rate", 0,
+		"The target number of messages to generate per second, zero for as fast as possible",
+	)
+	duration := flags.Duration(
+		"duration", time.Second*10, "The period of time to generate messages for",
+	)
+	size := flags.Int(
+		"size", 128, "The size in bytes of each randomly generated message part",
+	)
+	batch := flags.Int(
+		"batch", 1, "The number of parts within each generated message",
+	)
+	payload := flags.String(
+		"payload", "", "A payload to send as each message part instead of random bytes",
+	)
+	drop := flags.Bool(
+		"drop", false,
+		"Acknowledge messages once processed rather than sending them to the output of the config",
+	)
+	swapEnvs := flags.Bool(
+		"swap-envs", true,
+		"Swap ${FOO} patterns in config file with environment variables",
+	)
+	plugins := flags.String(
+		"plugins-dir", "/usr/lib/benthos/plugins",
+		"Specify a directory containing Go plugins (.so files) to load at startup",
+	)
+	flags.Usage = func() {
+		fmt.Fprintln(errW, "Usage: benthos bench [flags...] [config file]")
+		fmt.Fprintln(errW, "Sends generated messages through the processors and output of a config")
+		fmt.Fprintln(errW, "and reports the throughput and latency of acknowledgements.")
+		fmt.Fprintln(errW, "Flags:")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return 1
+	}
+
+	opts := benchOptions{
+		rate:     *rate,
+		duration: *duration,
+		size:     *size,
+		batch:    *batch,
+		payload:  *payload,
+		drop:     *drop,
+	}
+	if opts.duration <= 0 || opts.batch <= 0 || opts.size < 0 || opts.rate < 0 {
+		fmt.Fprintln(errW, "Bench error: duration and batch must be positive, and rate and size must not be negative")
+		return 1
+	}
+
+	if _, err := loadPlugins(*plugins); err != nil {
+		fmt.Fprintf(errW, "Plugin error: %v\n", err)
+		return 1
+	}
+
+	conf := NewConfig()
+	if err := config.Read(flags.Arg(0), *swapEnvs, &conf); err != nil {
+		fmt.Fprintf(errW, "Configuration file read error: %v\n", err)
+		return 1
+	}
+
+	res, err := bench(conf, opts, log.New(errW, conf.Logger))
+	if err == nil && len(res.latencies) == 0 {
+		err = errors.New("no messages were acknowledged")
+	}
+	if err != nil {
+		fmt.Fprintf(errW, "Bench error: %v\n", err)
+		return 1
+	}
+	res.report(w)
+	return 0
+}
+
+//------------------------------------------------------------------------------
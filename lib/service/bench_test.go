@@ -0,0 +1,150 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package service
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/log"
+	"github.com/Jeffail/benthos/lib/processor"
+)
+
+func TestBenchDrop(t *testing.T) {
+	conf := NewConfig()
+	conf.Pipeline.Processors = nil
+
+	res, err := bench(conf, benchOptions{
+		rate:     1000,
+		duration: time.Millisecond * 200,
+		size:     10,
+		batch:    2,
+		drop:     true,
+	}, log.Noop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.sent == 0 {
+		t.Fatal("Expected messages to be sent")
+	}
+	if res.sent > 250 {
+		t.Errorf("Rate was exceeded: %v", res.sent)
+	}
+	if exp, act := res.sent, int64(len(res.latencies)); exp != act {
+		t.Errorf("Wrong count of acks: %v != %v", act, exp)
+	}
+	if res.errors > 0 {
+		t.Errorf("Unexpected errors: %v", res.errors)
+	}
+	for i := 1; i < len(res.latencies); i++ {
+		if res.latencies[i] < res.latencies[i-1] {
+			t.Fatal("Latencies not sorted")
+		}
+	}
+}
+
+func TestBenchCloseTimeout(t *testing.T) {
+	conf := NewConfig()
+	conf.SystemCloseTimeoutMS = 50
+
+	procConf := processor.NewConfig()
+	procConf.Type = "throttle"
+	procConf.Throttle.Period = "200ms"
+	conf.Pipeline.Processors = []processor.Config{procConf}
+
+	res, err := bench(conf, benchOptions{
+		duration: time.Millisecond * 100,
+		size:     10,
+		batch:    1,
+		drop:     true,
+	}, log.Noop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.pending == 0 {
+		t.Error("Expected pending messages")
+	}
+	if exp, act := res.sent, int64(len(res.latencies))+res.pending; exp != act {
+		t.Errorf("Wrong count of acks and pending: %v != %v", act, exp)
+	}
+
+	// Abandoned messages are acknowledged after the results are returned.
+	<-time.After(time.Millisecond * 300)
+}
+
+func TestBenchPercentile(t *testing.T) {
+	res := benchResults{}
+	for i := 1; i <= 100; i++ {
+		res.latencies = append(res.latencies, time.Duration(i))
+	}
+	if exp, act := time.Duration(50), res.percentile(0.5); exp != act {
+		t.Errorf("Wrong p50: %v != %v", act, exp)
+	}
+	if exp, act := time.Duration(99), res.percentile(0.99); exp != act {
+		t.Errorf("Wrong p99: %v != %v", act, exp)
+	}
+	if exp, act := time.Duration(100), res.percentile(1); exp != act {
+		t.Errorf("Wrong max: %v != %v", act, exp)
+	}
+}
+
+func TestRunBench(t *testing.T) {
+	dir, err := ioutil.TempDir("", "benthos_bench_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	confPath := filepath.Join(dir, "config.yaml")
+	if err = ioutil.WriteFile(confPath, []byte(`
+logger:
+  level: NONE
+pipeline:
+  processors:
+  - type: text
+    text:
+      operator: append
+      value: " foo"
+`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var out, errOut bytes.Buffer
+	if exp, act := 0, runBench([]string{
+		"--duration", "100ms", "--drop", "--payload", "hello world", confPath,
+	}, &out, &errOut); exp != act {
+		t.Fatalf("Wrong exit status: %v != %v: %s", act, exp, errOut.Bytes())
+	}
+	for _, exp := range []string{"Sent:", "Acked:", "Latency:", "Allocs:"} {
+		if !strings.Contains(out.String(), exp) {
+			t.Errorf("Report missing %v: %s", exp, out.String())
+		}
+	}
+
+	if exp, act := 1, runBench([]string{"--batch", "0", confPath}, &out, &errOut); exp != act {
+		t.Errorf("Wrong exit status: %v != %v", act, exp)
+	}
+}
@@ -0,0 +1,224 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/Jeffail/benthos/lib/input"
+	"github.com/Jeffail/benthos/lib/output"
+	"github.com/Jeffail/benthos/lib/processor"
+	"gopkg.in/yaml.v2"
+)
+
+//------------------------------------------------------------------------------
+
+// createSectionComments are written above the root sections of a created
+// config.
+var createSectionComments = map[string]string{
+	"http":                      "The HTTP server exposes endpoints for health checks, metrics and debugging.",
+	"input":                     "The input consumes messages.",
+	"buffer":                    "The buffer optionally stores messages between the input and the pipeline.",
+	"pipeline":                  "The pipeline processes messages in parallel threads.",
+	"output":                    "The output delivers processed messages.",
+	"resources":                 "Resources are caches, conditions and rate limits that components can refer to by name.",
+	"logger":                    "The logger writes service logs to stdout, or stderr when the output is stdout.",
+	"metrics":                   "Metrics are exposed or sent to the chosen aggregator.",
+	"tracer":                    "The tracer exports spans of message flows.",
+	"shutdown_drain_timeout_ms": "The time given to deliver in flight messages when shutting down.",
+	"sys_exit_timeout_ms":       "The time after which the service exits forcefully when shutting down.",
+}
+
+var createTypeLineRegexp = regexp.MustCompile(`^(\s*)(- )?type: (\S+)$`)
+
+// parseCreateArg parses an argument of the form input/processors/output, where
+// processors are comma separated and any part can be empty.
+func parseCreateArg(arg string) (inputType string, procTypes []string, outputType string, err error) {
+	parts := strings.Split(arg, "/")
+	if len(parts) > 3 {
+		return "", nil, "", fmt.Errorf("expected an argument of the form input/processors/output, got: %v", arg)
+	}
+	for len(parts) < 3 {
+		parts = append(parts, "")
+	}
+	inputType, outputType = parts[0], parts[2]
+	if len(parts[1]) > 0 {
+		procTypes = strings.Split(parts[1], ",")
+	}
+
+	if _, exists := input.Constructors[inputType]; len(inputType) > 0 && !exists {
+		return "", nil, "", fmt.Errorf("input type not recognised: %v", inputType)
+	}
+	for _, procType := range procTypes {
+		if _, exists := processor.Constructors[procType]; !exists {
+			return "", nil, "", fmt.Errorf("processor type not recognised: %v", procType)
+		}
+	}
+	if _, exists := output.Constructors[outputType]; len(outputType) > 0 && !exists {
+		return "", nil, "", fmt.Errorf("output type not recognised: %v", outputType)
+	}
+	return inputType, procTypes, outputType, nil
+}
+
+// summary returns the first paragraph of a markdown description as a single
+// line.
+func summary(description string) string {
+	description = strings.TrimSpace(description)
+	if i := strings.Index(description, "\n\n"); i >= 0 {
+		description = description[:i]
+	}
+	return strings.Join(strings.Fields(description), " ")
+}
+
+// writeComment writes a comment wrapped to 80 columns.
+func writeComment(w io.Writer, indent, comment string) {
+	line := indent + "#"
+	for _, word := range strings.Fields(comment) {
+		if len(line)+len(word)+1 > 80 && len(line) > len(indent)+1 {
+			fmt.Fprintln(w, line)
+			line = indent + "#"
+		}
+		line += " " + word
+	}
+	fmt.Fprintln(w, line)
+}
+
+// createConfig returns a config with the chosen components, where all fields
+// of the components are present with their default values along with comments
+// describing each section and component.
+func createConfig(inputType string, procTypes []string, outputType string) ([]byte, error) {
+	conf := NewConfig()
+	if len(inputType) > 0 {
+		conf.Input.Type = inputType
+	}
+	for _, procType := range procTypes {
+		procConf := processor.NewConfig()
+		procConf.Type = procType
+		conf.Pipeline.Processors = append(conf.Pipeline.Processors, procConf)
+	}
+	if len(outputType) > 0 {
+		conf.Output.Type = outputType
+	}
+
+	sanit, err := conf.Sanitised()
+	if err != nil {
+		return nil, err
+	}
+	confBytes, err := yaml.Marshal(sanit)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	var section string
+	scanner := bufio.NewScanner(bytes.NewReader(confBytes))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if len(line) > 0 && line[0] != ' ' && line[0] != '-' {
+			section = strings.SplitN(line, ":", 2)[0]
+			if buf.Len() > 0 {
+				buf.WriteByte('\n')
+			}
+			if comment, exists := createSectionComments[section]; exists {
+				writeComment(&buf, "", comment)
+			}
+		} else if m := createTypeLineRegexp.FindStringSubmatch(line); m != nil && len(m[1]) == 2 {
+			var description string
+			switch section {
+			case "input":
+				description = input.Constructors[m[3]].Description()
+			case "pipeline":
+				description = processor.Constructors[m[3]].Description()
+			case "output":
+				description = output.Constructors[m[3]].Description()
+			}
+			if s := summary(description); len(s) > 0 {
+				writeComment(&buf, m[1], m[3]+": "+s)
+			}
+		}
+		buf.WriteString(line)
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes(), scanner.Err()
+}
+
+//------------------------------------------------------------------------------
+
+// runCreate writes a config created from the components chosen in args and
+// returns the exit status to use.
+func runCreate(args []string, w, errW io.Writer) int {
+	flags := flag.NewFlagSet("create", flag.ContinueOnError)
+	flags.SetOutput(errW)
+	flags.Usage = func() {
+		fmt.Fprintln(errW, "Usage: benthos create [input]/[processors]/[output]")
+		fmt.Fprintln(errW, "Writes a config with the chosen components, where processors are")
+		fmt.Fprintln(errW, "comma separated, for example: benthos create kafka/jmespath,compress/s3")
+		fmt.Fprintln(errW)
+
+		var names []string
+		for name := range input.Constructors {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		fmt.Fprintf(errW, "Inputs: %v\n", strings.Join(names, ", "))
+		names = nil
+		for name := range processor.Constructors {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		fmt.Fprintf(errW, "Processors: %v\n", strings.Join(names, ", "))
+		names = nil
+		for name := range output.Constructors {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		fmt.Fprintf(errW, "Outputs: %v\n", strings.Join(names, ", "))
+	}
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+	if flags.NArg() > 1 {
+		flags.Usage()
+		return 1
+	}
+
+	inputType, procTypes, outputType, err := parseCreateArg(flags.Arg(0))
+	if err != nil {
+		fmt.Fprintf(errW, "Create error: %v\n", err)
+		return 1
+	}
+	confBytes, err := createConfig(inputType, procTypes, outputType)
+	if err != nil {
+		fmt.Fprintf(errW, "Create error: %v\n", err)
+		return 1
+	}
+	w.Write(confBytes)
+	return 0
+}
+
+//------------------------------------------------------------------------------
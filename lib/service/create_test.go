@@ -0,0 +1,108 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package service
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+)
+
+func TestCreateConfig(t *testing.T) {
+	inputType, procTypes, outputType, err := parseCreateArg("kafka/jmespath,bounds_check/s3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	confBytes, err := createConfig(inputType, procTypes, outputType)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	conf := NewConfig()
+	if err = yaml.Unmarshal(confBytes, &conf); err != nil {
+		t.Fatal(err)
+	}
+	if exp, act := "kafka", conf.Input.Type; exp != act {
+		t.Errorf("Wrong input type: %v != %v", act, exp)
+	}
+	if exp, act := 2, len(conf.Pipeline.Processors); exp != act {
+		t.Fatalf("Wrong count of processors: %v != %v", act, exp)
+	}
+	if exp, act := "bounds_check", conf.Pipeline.Processors[1].Type; exp != act {
+		t.Errorf("Wrong processor type: %v != %v", act, exp)
+	}
+	if exp, act := "s3", conf.Output.Type; exp != act {
+		t.Errorf("Wrong output type: %v != %v", act, exp)
+	}
+
+	for _, exp := range []string{
+		"\n# The input consumes messages.\ninput:\n  # kafka: ",
+		"\n  # jmespath: ",
+		"\n  - type: jmespath\n",
+	} {
+		if !strings.Contains(string(confBytes), exp) {
+			t.Errorf("Expected %q in config: %s", exp, confBytes)
+		}
+	}
+
+	issues, err := lintConfigBytes(confBytes, reflect.TypeOf(Config{}), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(issues) > 0 {
+		t.Errorf("Unexpected lint issues: %v", issues)
+	}
+}
+
+func TestCreateDefaults(t *testing.T) {
+	var out, errOut bytes.Buffer
+	if exp, act := 0, runCreate([]string{"//"}, &out, &errOut); exp != act {
+		t.Fatalf("Wrong exit status: %v != %v: %s", act, exp, errOut.Bytes())
+	}
+
+	conf := NewConfig()
+	if err := yaml.Unmarshal(out.Bytes(), &conf); err != nil {
+		t.Fatal(err)
+	}
+	if exp, act := "stdin", conf.Input.Type; exp != act {
+		t.Errorf("Wrong input type: %v != %v", act, exp)
+	}
+	if exp, act := "stdout", conf.Output.Type; exp != act {
+		t.Errorf("Wrong output type: %v != %v", act, exp)
+	}
+}
+
+func TestCreateErrors(t *testing.T) {
+	for _, arg := range []string{
+		"nope/jmespath/s3",
+		"kafka/nope/s3",
+		"kafka/jmespath/nope",
+		"kafka/jmespath/s3/foo",
+	} {
+		var out, errOut bytes.Buffer
+		if exp, act := 1, runCreate([]string{arg}, &out, &errOut); exp != act {
+			t.Errorf("Wrong exit status for %v: %v != %v", arg, act, exp)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package service_test
+
+import (
+	"bytes"
+	"errors"
+	"sync"
+
+	"github.com/Jeffail/benthos/lib/cache"
+	"github.com/Jeffail/benthos/lib/log"
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/processor"
+	"github.com/Jeffail/benthos/lib/service"
+	"github.com/Jeffail/benthos/lib/types"
+)
+
+//------------------------------------------------------------------------------
+
+// UpperConfig is the config of the upper processor plugin.
+type UpperConfig struct {
+	Enabled bool `json:"enabled" yaml:"enabled"`
+}
+
+// Upper is a processor plugin that converts messages to upper case.
+type Upper struct {
+	enabled bool
+}
+
+// ProcessMessage converts each part of a message to upper case.
+func (u *Upper) ProcessMessage(msg types.Message) ([]types.Message, types.Response) {
+	if !u.enabled {
+		return []types.Message{msg}, nil
+	}
+	newMsg := msg.Copy()
+	newMsg.Iter(func(i int, p types.Part) error {
+		p.Set(bytes.ToUpper(p.Get()))
+		return nil
+	})
+	return []types.Message{newMsg}, nil
+}
+
+//------------------------------------------------------------------------------
+
+// MapCache is a cache plugin that stores items in a map.
+type MapCache struct {
+	mut   sync.Mutex
+	items map[string][]byte
+}
+
+// Get returns the value of a key.
+func (m *MapCache) Get(key string) ([]byte, error) {
+	m.mut.Lock()
+	defer m.mut.Unlock()
+	if v, exists := m.items[key]; exists {
+		return v, nil
+	}
+	return nil, types.ErrKeyNotFound
+}
+
+// Set sets the value of a key.
+func (m *MapCache) Set(key string, value []byte) error {
+	m.mut.Lock()
+	m.items[key] = value
+	m.mut.Unlock()
+	return nil
+}
+
+// Add sets the value of a key only if it does not already exist.
+func (m *MapCache) Add(key string, value []byte) error {
+	m.mut.Lock()
+	defer m.mut.Unlock()
+	if _, exists := m.items[key]; exists {
+		return types.ErrKeyAlreadyExists
+	}
+	m.items[key] = value
+	return nil
+}
+
+// Delete removes a key.
+func (m *MapCache) Delete(key string) error {
+	m.mut.Lock()
+	delete(m.items, key)
+	m.mut.Unlock()
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+// This example builds a custom Benthos binary with a processor plugin and a
+// cache plugin, which are used within configs like any other processor or
+// cache:
+//
+//	pipeline:
+//	  processors:
+//	  - type: upper
+//	    plugin:
+//	      enabled: true
+//	resources:
+//	  caches:
+//	    foo:
+//	      type: map
+func Example_plugins() {
+	processor.RegisterPlugin(
+		"upper",
+		func() interface{} {
+			return &UpperConfig{Enabled: true}
+		},
+		func(conf interface{}, mgr types.Manager, log log.Modular, stats metrics.Type) (types.Processor, error) {
+			uConf, ok := conf.(*UpperConfig)
+			if !ok {
+				return nil, errors.New("failed to cast config")
+			}
+			return &Upper{enabled: uConf.Enabled}, nil
+		},
+	)
+	processor.DocumentPlugin("upper", "Converts messages to upper case.", nil)
+
+	cache.RegisterPlugin(
+		"map",
+		func() interface{} {
+			return &struct{}{}
+		},
+		func(conf interface{}, mgr types.Manager, log log.Modular, stats metrics.Type) (types.Cache, error) {
+			return &MapCache{items: map[string][]byte{}}, nil
+		},
+	)
+	cache.DocumentPlugin("map", "Stores items in memory without expiry.", nil)
+
+	// Run the service, which parses flags and the config file given as usual.
+	service.Run()
+}
+
+//------------------------------------------------------------------------------
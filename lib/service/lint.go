@@ -0,0 +1,659 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package service
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/Jeffail/benthos/lib/buffer"
+	"github.com/Jeffail/benthos/lib/cache"
+	"github.com/Jeffail/benthos/lib/input"
+	"github.com/Jeffail/benthos/lib/log"
+	"github.com/Jeffail/benthos/lib/output"
+	"github.com/Jeffail/benthos/lib/processor"
+	"github.com/Jeffail/benthos/lib/processor/condition"
+	"github.com/Jeffail/benthos/lib/ratelimit"
+	"github.com/Jeffail/benthos/lib/util/config"
+	"github.com/Jeffail/benthos/lib/util/http/client"
+	"github.com/Jeffail/benthos/lib/util/text"
+	"gopkg.in/yaml.v2"
+)
+
+//------------------------------------------------------------------------------
+
+// lintIssue is a problem found within a config file.
+type lintIssue struct {
+	Line int
+	Path string
+	What string
+}
+
+func (l lintIssue) String() string {
+	if l.Line > 0 {
+		return fmt.Sprintf("line %v: %v: %v", l.Line, l.Path, l.What)
+	}
+	return fmt.Sprintf("%v: %v", l.Path, l.What)
+}
+
+//------------------------------------------------------------------------------
+
+// componentSpec describes a config type where the implementation is selected
+// with a `type` field.
+type componentSpec struct {
+	name        string
+	defaultType string
+	isType      func(string) bool
+	isPlugin    func(string) bool
+	allowDitto  bool
+}
+
+func constructorsCheck(m interface{}) func(string) bool {
+	v := reflect.ValueOf(m)
+	return func(t string) bool {
+		return v.MapIndex(reflect.ValueOf(t)).IsValid()
+	}
+}
+
+var componentSpecs = map[reflect.Type]componentSpec{
+	reflect.TypeOf(input.Config{}): {
+		name:        "input",
+		defaultType: input.NewConfig().Type,
+		isType:      constructorsCheck(input.Constructors),
+		isPlugin:    input.PluginExists,
+		allowDitto:  true,
+	},
+	reflect.TypeOf(output.Config{}): {
+		name:        "output",
+		defaultType: output.NewConfig().Type,
+		isType:      constructorsCheck(output.Constructors),
+		isPlugin:    output.PluginExists,
+		allowDitto:  true,
+	},
+	reflect.TypeOf(buffer.Config{}): {
+		name:        "buffer",
+		defaultType: buffer.NewConfig().Type,
+		isType:      constructorsCheck(buffer.Constructors),
+	},
+	reflect.TypeOf(processor.Config{}): {
+		name:        "processor",
+		defaultType: processor.NewConfig().Type,
+		isType:      constructorsCheck(processor.Constructors),
+		isPlugin:    processor.PluginExists,
+	},
+	reflect.TypeOf(condition.Config{}): {
+		name:        "condition",
+		defaultType: condition.NewConfig().Type,
+		isType:      constructorsCheck(condition.Constructors),
+		isPlugin:    condition.PluginExists,
+	},
+	reflect.TypeOf(cache.Config{}): {
+		name:        "cache",
+		defaultType: cache.NewConfig().Type,
+		isType:      constructorsCheck(cache.Constructors),
+		isPlugin:    cache.PluginExists,
+	},
+	reflect.TypeOf(ratelimit.Config{}): {
+		name:        "rate limit",
+		defaultType: ratelimit.NewConfig().Type,
+		isType:      constructorsCheck(ratelimit.Constructors),
+	},
+}
+
+// deprecatedTypes lists component types that are deprecated along with advice
+// on what to use instead.
+var deprecatedTypes = map[string]map[string]string{
+	"processor": {
+		"combine": "use the batch processor with the count field instead",
+	},
+}
+
+// resourceFields lists string fields of config types that refer to a resource
+// by name, and the section of resources that the name must exist in.
+var resourceFields = map[reflect.Type]map[string]string{
+	reflect.TypeOf(processor.DedupeConfig{}): {"cache": "caches"},
+	reflect.TypeOf(condition.CacheConfig{}):  {"cache": "caches"},
+	reflect.TypeOf(condition.Config{}):       {"resource": "conditions"},
+	reflect.TypeOf(client.Config{}):          {"rate_limit": "rate_limits"},
+}
+
+var (
+	logConfigType   = reflect.TypeOf(log.Config{})
+	unmarshalerType = reflect.TypeOf((*yaml.Unmarshaler)(nil)).Elem()
+)
+
+//------------------------------------------------------------------------------
+
+// lintResources contains the names of resources that are available to a
+// config, keyed by the resources section they belong to.
+type lintResources map[string]map[string]struct{}
+
+// resourcesFromNode extracts the names of resources from a parsed resources
+// section of a config.
+func resourcesFromNode(node interface{}) lintResources {
+	res := lintResources{
+		"caches":      map[string]struct{}{},
+		"conditions":  map[string]struct{}{},
+		"rate_limits": map[string]struct{}{},
+	}
+	for _, section := range nodeKeys(node) {
+		if names, exists := res[section]; exists {
+			for _, name := range nodeKeys(lookupNodeKey(node, section)) {
+				names[name] = struct{}{}
+			}
+		}
+	}
+	return res
+}
+
+// linter walks a parsed config and compares it against the structure of the
+// config type it is expected to be parsed into.
+type linter struct {
+	lines     map[string]int
+	resources lintResources
+	issues    []lintIssue
+}
+
+func (l *linter) add(path, format string, args ...interface{}) {
+	l.issues = append(l.issues, lintIssue{
+		Line: lineOfPath(l.lines, path),
+		Path: path,
+		What: fmt.Sprintf(format, args...),
+	})
+}
+
+func (l *linter) walk(path string, node interface{}, t reflect.Type) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if node == nil {
+		return
+	}
+	switch t.Kind() {
+	case reflect.Interface:
+	case reflect.Struct:
+		m, ok := node.(yaml.MapSlice)
+		if !ok {
+			// Types with custom parsing rules might accept other forms.
+			if !reflect.PtrTo(t).Implements(unmarshalerType) {
+				l.add(path, "expected an object, found %v", describeNode(node))
+			}
+			return
+		}
+		l.walkStruct(path, m, t)
+	case reflect.Map:
+		m, ok := node.(yaml.MapSlice)
+		if !ok {
+			l.add(path, "expected an object, found %v", describeNode(node))
+			return
+		}
+		for _, item := range m {
+			l.walk(joinPath(path, keyString(item.Key)), item.Value, t.Elem())
+		}
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			// Raw values can be any structure.
+			return
+		}
+		s, ok := node.([]interface{})
+		if !ok {
+			l.add(path, "expected an array, found %v", describeNode(node))
+			return
+		}
+		for i, v := range s {
+			l.walk(fmt.Sprintf("%v[%v]", path, i), v, t.Elem())
+		}
+	case reflect.String:
+		switch v := node.(type) {
+		case yaml.MapSlice, []interface{}:
+			l.add(path, "expected a string, found %v", describeNode(node))
+		case string:
+			if err := text.ValidateFunctionVariables([]byte(v)); err != nil {
+				l.add(path, "%v", err)
+			}
+		}
+	case reflect.Bool:
+		if _, ok := node.(bool); !ok {
+			l.add(path, "expected a boolean, found %v", describeNode(node))
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		switch v := node.(type) {
+		case int, int64, uint64:
+		case float64:
+			if v != math.Trunc(v) {
+				l.add(path, "expected an integer, found %v", describeNode(node))
+			}
+		default:
+			l.add(path, "expected an integer, found %v", describeNode(node))
+		}
+	case reflect.Float32, reflect.Float64:
+		switch node.(type) {
+		case int, int64, uint64, float64:
+		default:
+			l.add(path, "expected a number, found %v", describeNode(node))
+		}
+	}
+}
+
+func (l *linter) walkStruct(path string, m yaml.MapSlice, t reflect.Type) {
+	fields := map[string]reflect.Type{}
+	structTypes := collectFields(t, fields)
+
+	for _, item := range m {
+		key := keyString(item.Key)
+		ft, exists := fields[key]
+		if !exists {
+			l.add(joinPath(path, key), "field %v not recognised", key)
+			continue
+		}
+		l.walk(joinPath(path, key), item.Value, ft)
+	}
+
+	if spec, exists := componentSpecs[t]; exists {
+		l.checkComponent(path, m, spec)
+	}
+	for _, st := range structTypes {
+		for field, section := range resourceFields[st] {
+			name, _ := lookupKey(m, field).(string)
+			if len(name) == 0 || l.resources == nil {
+				continue
+			}
+			if _, exists := l.resources[section][name]; !exists {
+				l.add(joinPath(path, field), "resource %v not found in resources.%v", name, section)
+			}
+		}
+	}
+	if t == logConfigType {
+		if jsonFormat, ok := lookupKey(m, "json_format").(bool); ok && !jsonFormat {
+			l.add(joinPath(path, "json_format"), "field json_format is deprecated, use the format field instead")
+		}
+	}
+}
+
+func (l *linter) checkComponent(path string, m yaml.MapSlice, spec componentSpec) {
+	typeStr := spec.defaultType
+	if v := lookupKey(m, "type"); v != nil {
+		typeStr = fmt.Sprintf("%v", v)
+	}
+
+	typePath := joinPath(path, "type")
+	switch {
+	case spec.isType(typeStr):
+		if advice, deprecated := deprecatedTypes[spec.name][typeStr]; deprecated {
+			l.add(typePath, "%v %v is deprecated, %v", spec.name, typeStr, advice)
+		}
+	case spec.isPlugin != nil && spec.isPlugin(typeStr):
+	case spec.allowDitto && strings.HasPrefix(typeStr, "ditto"):
+	default:
+		l.add(typePath, "%v type %v not recognised", spec.name, typeStr)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// collectFields adds the YAML field names of a struct type to a map, flattening
+// inlined and embedded structs. The struct type and any types that were
+// flattened into it are returned.
+func collectFields(t reflect.Type, fields map[string]reflect.Type) []reflect.Type {
+	structTypes := []reflect.Type{t}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("yaml")
+		if tag == "-" {
+			continue
+		}
+		name := strings.Split(tag, ",")[0]
+		if strings.Contains(tag, ",inline") || (f.Anonymous && len(name) == 0) {
+			ft := f.Type
+			for ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				structTypes = append(structTypes, collectFields(ft, fields)...)
+			}
+			continue
+		}
+		if len(f.PkgPath) > 0 {
+			continue
+		}
+		if len(name) == 0 {
+			name = strings.ToLower(f.Name)
+		}
+		fields[name] = f.Type
+	}
+	return structTypes
+}
+
+// nodeKeys returns the keys of a parsed object, which is either ordered or a
+// map.
+func nodeKeys(node interface{}) []string {
+	var keys []string
+	switch t := node.(type) {
+	case yaml.MapSlice:
+		for _, item := range t {
+			keys = append(keys, keyString(item.Key))
+		}
+	case map[string]interface{}:
+		for k := range t {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+func lookupNodeKey(node interface{}, key string) interface{} {
+	switch t := node.(type) {
+	case yaml.MapSlice:
+		return lookupKey(t, key)
+	case map[string]interface{}:
+		return t[key]
+	}
+	return nil
+}
+
+func lookupKey(m yaml.MapSlice, key string) interface{} {
+	for _, item := range m {
+		if keyString(item.Key) == key {
+			return item.Value
+		}
+	}
+	return nil
+}
+
+func keyString(k interface{}) string {
+	return fmt.Sprintf("%v", k)
+}
+
+func joinPath(path, key string) string {
+	if len(path) == 0 {
+		return key
+	}
+	return path + "." + key
+}
+
+func describeNode(node interface{}) string {
+	switch node.(type) {
+	case yaml.MapSlice:
+		return "an object"
+	case []interface{}:
+		return "an array"
+	case string:
+		return "a string"
+	case bool:
+		return "a boolean"
+	case int, int64, uint64:
+		return "an integer"
+	case float64:
+		return "a number"
+	}
+	return fmt.Sprintf("%T", node)
+}
+
+//------------------------------------------------------------------------------
+
+var yamlKeyRegex = regexp.MustCompile(`^("[^"]*"|'[^']*'|[^\s#'"{\[][^#]*?)\s*:(\s|$)`)
+
+// yamlLines maps the paths of keys and array elements within a block style
+// YAML document to the line numbers they appear on. Documents written in flow
+// style, such as JSON, result in an empty map.
+func yamlLines(data []byte) map[string]int {
+	const (
+		kindKey = iota
+		kindSeq
+		kindItem
+	)
+	type frame struct {
+		indent int
+		path   string
+		kind   int
+		index  int
+	}
+
+	lines := map[string]int{}
+	var stack []*frame
+	blockIndent := -1
+
+	for i, line := range strings.Split(string(data), "\n") {
+		trimmed := strings.TrimSpace(line)
+		col := len(line) - len(strings.TrimLeft(line, " "))
+		if blockIndent >= 0 {
+			if len(trimmed) == 0 || col > blockIndent {
+				continue
+			}
+			blockIndent = -1
+		}
+		if len(trimmed) == 0 || trimmed[0] == '#' || trimmed == "---" {
+			continue
+		}
+
+		content := strings.TrimRight(line[col:], " \r")
+		for len(content) > 0 {
+			if content == "-" || strings.HasPrefix(content, "- ") {
+				for len(stack) > 0 && stack[len(stack)-1].indent > col {
+					stack = stack[:len(stack)-1]
+				}
+				var seq *frame
+				if len(stack) > 0 && stack[len(stack)-1].kind == kindSeq && stack[len(stack)-1].indent == col {
+					seq = stack[len(stack)-1]
+					seq.index++
+				} else {
+					var parent string
+					if len(stack) > 0 {
+						parent = stack[len(stack)-1].path
+					}
+					seq = &frame{indent: col, path: parent, kind: kindSeq}
+					stack = append(stack, seq)
+				}
+				itemPath := fmt.Sprintf("%v[%v]", seq.path, seq.index)
+				if _, exists := lines[itemPath]; !exists {
+					lines[itemPath] = i + 1
+				}
+				rest := strings.TrimLeft(content[1:], " ")
+				col += len(content) - len(rest)
+				stack = append(stack, &frame{indent: col, path: itemPath, kind: kindItem})
+				content = rest
+				continue
+			}
+
+			match := yamlKeyRegex.FindStringSubmatch(content)
+			if match == nil {
+				break
+			}
+			for len(stack) > 0 {
+				top := stack[len(stack)-1]
+				if top.indent > col || (top.indent == col && top.kind != kindItem) {
+					stack = stack[:len(stack)-1]
+					continue
+				}
+				break
+			}
+			var parent string
+			if len(stack) > 0 {
+				parent = stack[len(stack)-1].path
+			}
+			path := joinPath(parent, strings.Trim(match[1], `"'`))
+			if _, exists := lines[path]; !exists {
+				lines[path] = i + 1
+			}
+			stack = append(stack, &frame{indent: col, path: path, kind: kindKey})
+
+			value := strings.TrimSpace(content[len(match[0]):])
+			if strings.HasPrefix(value, "|") || strings.HasPrefix(value, ">") {
+				blockIndent = col
+			}
+			break
+		}
+	}
+	return lines
+}
+
+// lineOfPath returns the line number of a path, or of its closest ancestor
+// when the path itself was not found. Zero is returned when no line is known.
+func lineOfPath(lines map[string]int, path string) int {
+	for len(path) > 0 {
+		if line, exists := lines[path]; exists {
+			return line
+		}
+		i := strings.LastIndexAny(path, ".[")
+		if i == -1 {
+			break
+		}
+		path = path[:i]
+	}
+	return 0
+}
+
+//------------------------------------------------------------------------------
+
+// lintConfigBytes parses a config and returns any issues found when comparing
+// it against the structure of confType. When resources is nil the resources
+// section of the config itself is used for checking references to resources.
+func lintConfigBytes(data []byte, confType reflect.Type, resources lintResources) ([]lintIssue, error) {
+	var root yaml.MapSlice
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, err
+	}
+	if resources == nil {
+		resources = resourcesFromNode(lookupKey(root, "resources"))
+	}
+
+	// Included files are linted separately, and templates are linted once
+	// instantiated.
+	for i := 0; i < len(root); i++ {
+		if k := keyString(root[i].Key); k == "include" || k == "templates" {
+			root = append(root[:i:i], root[i+1:]...)
+			i--
+		}
+	}
+
+	l := linter{
+		lines:     yamlLines(data),
+		resources: resources,
+	}
+	l.walk("", root, confType)
+	sort.SliceStable(l.issues, func(i, j int) bool {
+		return l.issues[i].Line < l.issues[j].Line
+	})
+	return l.issues, nil
+}
+
+// lintConfigFile reads a config file and lints its contents. When resources is
+// nil the resources of the config, including those of any included files, are
+// used for checking references to resources.
+func lintConfigFile(path string, replaceEnvs bool, confType reflect.Type, resources lintResources) ([]lintIssue, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if replaceEnvs {
+		data = text.ReplaceEnvVariables(data)
+	}
+	if resources == nil {
+		if resources, err = lintResourcesFromFile(path, replaceEnvs); err != nil {
+			return nil, err
+		}
+	}
+
+	// Configs that use templates are linted after the templates are
+	// instantiated, and therefore without line numbers.
+	var root yaml.MapSlice
+	if err = yaml.Unmarshal(data, &root); err == nil && lookupKey(root, "templates") != nil {
+		tree, err := config.ReadTree(path, replaceEnvs)
+		if err != nil {
+			return nil, err
+		}
+		if data, err = yaml.Marshal(tree); err != nil {
+			return nil, err
+		}
+		issues, err := lintConfigBytes(data, confType, resources)
+		for i := range issues {
+			issues[i].Line = 0
+		}
+		return issues, err
+	}
+	return lintConfigBytes(data, confType, resources)
+}
+
+// lintResourcesFromFile parses the resources of a config file, including those
+// of any included files.
+func lintResourcesFromFile(path string, replaceEnvs bool) (lintResources, error) {
+	if len(path) == 0 {
+		return resourcesFromNode(nil), nil
+	}
+	tree, err := config.ReadTree(path, replaceEnvs)
+	if err != nil {
+		return nil, err
+	}
+	return resourcesFromNode(tree["resources"]), nil
+}
+
+// lintStreamsDir returns the paths of stream config files within a directory.
+func lintStreamsDir(dir string) ([]string, error) {
+	var paths []string
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		return nil, nil
+	}
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, werr error) error {
+		if werr != nil {
+			return werr
+		}
+		if !info.IsDir() &&
+			(strings.HasSuffix(info.Name(), ".yaml") ||
+				strings.HasSuffix(info.Name(), ".json")) {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	return paths, err
+}
+
+//------------------------------------------------------------------------------
+
+// lintAndReport lints a config file and writes any issues found to w,
+// returning the number of issues.
+func lintAndReport(
+	w io.Writer,
+	path string,
+	replaceEnvs bool,
+	confType reflect.Type,
+	resources lintResources,
+) int {
+	issues, err := lintConfigFile(path, replaceEnvs, confType, resources)
+	if err != nil {
+		fmt.Fprintf(w, "%v: %v\n", path, err)
+		return 1
+	}
+	for _, issue := range issues {
+		fmt.Fprintf(w, "%v: %v\n", path, issue)
+	}
+	return len(issues)
+}
+
+//------------------------------------------------------------------------------
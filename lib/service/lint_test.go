@@ -0,0 +1,178 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package service
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Jeffail/benthos/lib/stream"
+)
+
+func TestLintConfig(t *testing.T) {
+	conf := `
+input:
+  type: kafka
+  kafka:
+    adresses: [ foo ]
+    partition: nope
+  processors:
+    - type: dedupe
+      dedupe:
+        cache: foo
+        key: ${!json_feld:id}
+    - type: combine
+output:
+  type: broker
+  broker:
+    outputs:
+    - type: http_client
+      http_client:
+        rate_limit: bar
+        drop_on: 404
+    - type: ditto
+    - type: fileee
+resources:
+  caches:
+    foo:
+      type: memory
+  conditions:
+    baz:
+      type: resource
+      resource: buz
+logger:
+  json_format: false
+`
+
+	exp := []string{
+		"line 5: input.kafka.adresses: field adresses not recognised",
+		"line 6: input.kafka.partition: expected an integer, found a string",
+		"line 11: input.processors[0].dedupe.key: unrecognised function variable: ${!json_feld:id}",
+		"line 12: input.processors[1].type: processor combine is deprecated, use the batch processor with the count field instead",
+		"line 19: output.broker.outputs[0].http_client.rate_limit: resource bar not found in resources.rate_limits",
+		"line 20: output.broker.outputs[0].http_client.drop_on: expected an array, found an integer",
+		"line 22: output.broker.outputs[2].type: output type fileee not recognised",
+		"line 30: resources.conditions.baz.resource: resource buz not found in resources.conditions",
+		"line 32: logger.json_format: field json_format is deprecated, use the format field instead",
+	}
+
+	issues, err := lintConfigBytes([]byte(conf), reflect.TypeOf(Config{}), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	act := []string{}
+	for _, issue := range issues {
+		act = append(act, issue.String())
+	}
+	if !reflect.DeepEqual(exp, act) {
+		t.Errorf("Wrong issues: %v != %v", act, exp)
+	}
+}
+
+func TestLintStreamConfig(t *testing.T) {
+	conf := `{
+	"input": {
+		"type": "stdin",
+		"stdin": {
+			"delimiter": 10
+		}
+	},
+	"pipeline": {
+		"processors": [{"type": "dedupe", "dedupe": {"cache": "foo"}}]
+	},
+	"resources": {}
+}`
+
+	res := resourcesFromNode(nil)
+	res["caches"]["foo"] = struct{}{}
+
+	exp := []string{
+		"resources: field resources not recognised",
+	}
+
+	issues, err := lintConfigBytes([]byte(conf), reflect.TypeOf(stream.Config{}), res)
+	if err != nil {
+		t.Fatal(err)
+	}
+	act := []string{}
+	for _, issue := range issues {
+		act = append(act, issue.String())
+	}
+	if !reflect.DeepEqual(exp, act) {
+		t.Errorf("Wrong issues: %v != %v", act, exp)
+	}
+}
+
+func TestLintGeneratedConfig(t *testing.T) {
+	issues, err := lintConfigFile("../../config/everything.yaml", false, reflect.TypeOf(Config{}), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, issue := range issues {
+		t.Errorf("Unexpected issue: %v", issue)
+	}
+}
+
+func TestLintYAMLLines(t *testing.T) {
+	conf := `# comment
+foo:
+  bar: |
+    baz: nope
+  qux: 10
+list:
+- a
+- b: 1
+  c:
+    - d: 2
+    -
+      e: 3
+"quoted": true
+`
+
+	exp := map[string]int{
+		"foo":            2,
+		"foo.bar":        3,
+		"foo.qux":        5,
+		"list":           6,
+		"list[0]":        7,
+		"list[1]":        8,
+		"list[1].b":      8,
+		"list[1].c":      9,
+		"list[1].c[0]":   10,
+		"list[1].c[0].d": 10,
+		"list[1].c[1]":   11,
+		"list[1].c[1].e": 12,
+		"quoted":         13,
+	}
+
+	act := yamlLines([]byte(conf))
+	for k, v := range exp {
+		if act[k] != v {
+			t.Errorf("Wrong line for %v: %v != %v", k, act[k], v)
+		}
+	}
+	if _, exists := act["foo.baz"]; exists {
+		t.Error("Key within block scalar was located")
+	}
+	if line := lineOfPath(act, "list[1].c[1].e.nope"); line != 12 {
+		t.Errorf("Wrong line of missing path: %v", line)
+	}
+}
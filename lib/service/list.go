@@ -0,0 +1,363 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package service
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/Jeffail/benthos/lib/buffer"
+	"github.com/Jeffail/benthos/lib/cache"
+	"github.com/Jeffail/benthos/lib/input"
+	"github.com/Jeffail/benthos/lib/output"
+	"github.com/Jeffail/benthos/lib/processor"
+	"github.com/Jeffail/benthos/lib/processor/condition"
+	"github.com/Jeffail/benthos/lib/ratelimit"
+)
+
+//------------------------------------------------------------------------------
+
+// listKind is a kind of component that can be listed, such as inputs.
+type listKind struct {
+	name         string
+	title        string
+	descriptions func() string
+	types        func() map[string]string
+	sanitised    func(typeStr string) (interface{}, error)
+}
+
+var listKinds = []listKind{
+	{
+		name:         "inputs",
+		title:        "Inputs",
+		descriptions: input.Descriptions,
+		types: func() map[string]string {
+			m := map[string]string{}
+			for k, v := range input.Constructors {
+				m[k] = v.Description()
+			}
+			return m
+		},
+		sanitised: func(typeStr string) (interface{}, error) {
+			conf := input.NewConfig()
+			conf.Type = typeStr
+			conf.Processors = nil
+			return input.SanitiseConfig(conf)
+		},
+	},
+	{
+		name:         "buffers",
+		title:        "Buffers",
+		descriptions: buffer.Descriptions,
+		types: func() map[string]string {
+			m := map[string]string{}
+			for k, v := range buffer.Constructors {
+				m[k] = v.Description()
+			}
+			return m
+		},
+		sanitised: func(typeStr string) (interface{}, error) {
+			conf := buffer.NewConfig()
+			conf.Type = typeStr
+			return buffer.SanitiseConfig(conf)
+		},
+	},
+	{
+		name:         "processors",
+		title:        "Processors",
+		descriptions: processor.Descriptions,
+		types: func() map[string]string {
+			m := map[string]string{}
+			for k, v := range processor.Constructors {
+				m[k] = v.Description()
+			}
+			return m
+		},
+		sanitised: func(typeStr string) (interface{}, error) {
+			conf := processor.NewConfig()
+			conf.Type = typeStr
+			return processor.SanitiseConfig(conf)
+		},
+	},
+	{
+		name:         "conditions",
+		title:        "Conditions",
+		descriptions: condition.Descriptions,
+		types: func() map[string]string {
+			m := map[string]string{}
+			for k, v := range condition.Constructors {
+				m[k] = v.Description()
+			}
+			return m
+		},
+		sanitised: func(typeStr string) (interface{}, error) {
+			conf := condition.NewConfig()
+			conf.Type = typeStr
+			return condition.SanitiseConfig(conf)
+		},
+	},
+	{
+		name:         "outputs",
+		title:        "Outputs",
+		descriptions: output.Descriptions,
+		types: func() map[string]string {
+			m := map[string]string{}
+			for k, v := range output.Constructors {
+				m[k] = v.Description()
+			}
+			return m
+		},
+		sanitised: func(typeStr string) (interface{}, error) {
+			conf := output.NewConfig()
+			conf.Type = typeStr
+			conf.Processors = nil
+			return output.SanitiseConfig(conf)
+		},
+	},
+	{
+		name:         "caches",
+		title:        "Caches",
+		descriptions: cache.Descriptions,
+		types: func() map[string]string {
+			m := map[string]string{}
+			for k, v := range cache.Constructors {
+				m[k] = v.Description()
+			}
+			return m
+		},
+		sanitised: func(typeStr string) (interface{}, error) {
+			conf := cache.NewConfig()
+			conf.Type = typeStr
+			return cache.SanitiseConfig(conf)
+		},
+	},
+	{
+		name:         "rate_limits",
+		title:        "Rate Limits",
+		descriptions: ratelimit.Descriptions,
+		types: func() map[string]string {
+			m := map[string]string{}
+			for k, v := range ratelimit.Constructors {
+				m[k] = v.Description()
+			}
+			return m
+		},
+		sanitised: func(typeStr string) (interface{}, error) {
+			conf := ratelimit.NewConfig()
+			conf.Type = typeStr
+			return ratelimit.SanitiseConfig(conf)
+		},
+	},
+}
+
+//------------------------------------------------------------------------------
+
+// listComponent is the description and default config fields of a component
+// type.
+type listComponent struct {
+	Name        string      `json:"name"`
+	Description string      `json:"description"`
+	Config      interface{} `json:"config"`
+}
+
+// components returns each type of a kind ordered by name.
+func (k listKind) components() ([]listComponent, error) {
+	types := k.types()
+	names := make([]string, 0, len(types))
+	for name := range types {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	comps := make([]listComponent, 0, len(names))
+	for _, name := range names {
+		sanit, err := k.sanitised(name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to sanitise %v type '%v': %v", k.name, name, err)
+		}
+
+		// Convert to a generic structure in order to extract the fields of
+		// the type.
+		var generic map[string]interface{}
+		sanitBytes, err := json.Marshal(sanit)
+		if err != nil {
+			return nil, err
+		}
+		if err = json.Unmarshal(sanitBytes, &generic); err != nil {
+			return nil, err
+		}
+
+		comps = append(comps, listComponent{
+			Name:        name,
+			Description: types[name],
+			Config:      generic[name],
+		})
+	}
+	return comps, nil
+}
+
+// flattenFields appends a line for each field of a config, where nested
+// fields are written as dot separated paths.
+func flattenFields(lines []string, prefix string, node interface{}) []string {
+	obj, isObj := node.(map[string]interface{})
+	if len(prefix) == 0 && !isObj {
+		return lines
+	}
+	if !isObj || len(obj) == 0 {
+		valueBytes, _ := json.Marshal(node)
+		return append(lines, fmt.Sprintf("%v: %s", prefix, valueBytes))
+	}
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		path := k
+		if len(prefix) > 0 {
+			path = prefix + "." + k
+		}
+		lines = flattenFields(lines, path, obj[k])
+	}
+	return lines
+}
+
+//------------------------------------------------------------------------------
+
+// runList writes the component types of the kinds named in args, or of all
+// kinds when args is empty, in the format given with the --format flag, and
+// returns the exit status to use.
+func runList(args []string, w, errW io.Writer) int {
+	flags := flag.NewFlagSet("list", flag.ContinueOnError)
+	flags.SetOutput(errW)
+	format := flags.String(
+		"format", "text",
+		"The format to list components in: text, json or markdown",
+	)
+	flags.Usage = func() {
+		fmt.Fprintln(errW, "Usage: benthos list [flags...] [kinds...]")
+		fmt.Fprintf(errW, "Kinds: %v\n", strings.Join(listKindNames(), ", "))
+		fmt.Fprintln(errW, "Flags:")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	kinds, err := selectListKinds(flags.Args())
+	if err == nil {
+		switch *format {
+		case "text":
+			err = listText(w, kinds)
+		case "json":
+			err = listJSON(w, kinds)
+		case "markdown":
+			for i, k := range kinds {
+				if i > 0 {
+					fmt.Fprintln(w)
+				}
+				fmt.Fprintln(w, k.descriptions())
+			}
+		default:
+			err = fmt.Errorf("format not recognised: %v", *format)
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(errW, "List error: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+func listKindNames() []string {
+	names := make([]string, 0, len(listKinds))
+	for _, k := range listKinds {
+		names = append(names, k.name)
+	}
+	return names
+}
+
+func selectListKinds(names []string) ([]listKind, error) {
+	if len(names) == 0 {
+		return listKinds, nil
+	}
+	var kinds []listKind
+	for _, name := range names {
+		var found bool
+		for _, k := range listKinds {
+			if k.name == name {
+				kinds = append(kinds, k)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf(
+				"kind '%v' not recognised, expected one of: %v",
+				name, strings.Join(listKindNames(), ", "),
+			)
+		}
+	}
+	return kinds, nil
+}
+
+func listText(w io.Writer, kinds []listKind) error {
+	for i, k := range kinds {
+		comps, err := k.components()
+		if err != nil {
+			return err
+		}
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintf(w, "%v:\n", k.title)
+		for _, c := range comps {
+			fmt.Fprintf(w, "  %v\n", c.Name)
+			for _, line := range flattenFields(nil, "", c.Config) {
+				fmt.Fprintf(w, "    %v\n", line)
+			}
+		}
+	}
+	return nil
+}
+
+func listJSON(w io.Writer, kinds []listKind) error {
+	res := map[string][]listComponent{}
+	for _, k := range kinds {
+		comps, err := k.components()
+		if err != nil {
+			return err
+		}
+		res[k.name] = comps
+	}
+	resBytes, err := json.Marshal(res)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, string(resBytes))
+	return err
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,84 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestListJSON(t *testing.T) {
+	var out, errOut bytes.Buffer
+	if exp, act := 0, runList([]string{"--format", "json", "inputs", "caches"}, &out, &errOut); exp != act {
+		t.Fatalf("Wrong exit status: %v != %v: %s", act, exp, errOut.Bytes())
+	}
+
+	var res map[string][]listComponent
+	if err := json.Unmarshal(out.Bytes(), &res); err != nil {
+		t.Fatal(err)
+	}
+	if exp, act := 2, len(res); exp != act {
+		t.Errorf("Wrong count of kinds: %v != %v", act, exp)
+	}
+
+	var found bool
+	for _, c := range res["inputs"] {
+		if c.Name != "file" {
+			continue
+		}
+		found = true
+		if len(c.Description) == 0 {
+			t.Error("Expected description")
+		}
+		conf, _ := c.Config.(map[string]interface{})
+		if exp, act := float64(1000000), conf["max_buffer"]; exp != act {
+			t.Errorf("Wrong default: %v != %v", act, exp)
+		}
+	}
+	if !found {
+		t.Error("Input type file not found")
+	}
+}
+
+func TestListText(t *testing.T) {
+	var out, errOut bytes.Buffer
+	if exp, act := 0, runList([]string{"processors"}, &out, &errOut); exp != act {
+		t.Fatalf("Wrong exit status: %v != %v: %s", act, exp, errOut.Bytes())
+	}
+	if !strings.HasPrefix(out.String(), "Processors:\n") {
+		t.Errorf("Wrong output: %s", out.Bytes())
+	}
+	if !strings.Contains(out.String(), "\n  bounds_check\n    max_part_size: 1073741824\n") {
+		t.Errorf("Expected bounds_check fields: %s", out.Bytes())
+	}
+}
+
+func TestListErrors(t *testing.T) {
+	var out, errOut bytes.Buffer
+	if exp, act := 1, runList([]string{"nope"}, &out, &errOut); exp != act {
+		t.Errorf("Wrong exit status: %v != %v", act, exp)
+	}
+	if exp, act := 1, runList([]string{"--format", "nope"}, &out, &errOut); exp != act {
+		t.Errorf("Wrong exit status: %v != %v", act, exp)
+	}
+}
@@ -0,0 +1,60 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package service
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"plugin"
+)
+
+//------------------------------------------------------------------------------
+
+// loadPlugins opens each Go plugin (files with the extension .so) found within
+// a directory and its subdirectories, in lexical order. Plugins are expected to
+// register their components from init funcs, which are called when the plugin
+// is opened. Returns the paths of the plugins that were loaded.
+//
+// A directory that does not exist is not considered an error, since the
+// default directory is often absent.
+func loadPlugins(dir string) ([]string, error) {
+	if len(dir) == 0 {
+		return nil, nil
+	}
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		return nil, nil
+	}
+	var loaded []string
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() || filepath.Ext(path) != ".so" {
+			return err
+		}
+		if _, err = plugin.Open(path); err != nil {
+			return fmt.Errorf("failed to load plugin '%v': %v", path, err)
+		}
+		loaded = append(loaded, path)
+		return nil
+	})
+	return loaded, err
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,81 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package service
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadPluginsMissingDir(t *testing.T) {
+	loaded, err := loadPlugins("/does/not/exist/benthos_plugins")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded) > 0 {
+		t.Errorf("Unexpected plugins loaded: %v", loaded)
+	}
+}
+
+func TestLoadPluginsIgnoresOtherFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "benthos_plugins_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err = ioutil.WriteFile(filepath.Join(dir, "README.md"), []byte("hello"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := loadPlugins(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded) > 0 {
+		t.Errorf("Unexpected plugins loaded: %v", loaded)
+	}
+}
+
+func TestLoadPluginsBadPlugin(t *testing.T) {
+	dir, err := ioutil.TempDir("", "benthos_plugins_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	badPath := filepath.Join(dir, "nested", "bad.so")
+	if err = os.MkdirAll(filepath.Dir(badPath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err = ioutil.WriteFile(badPath, []byte("not a plugin"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = loadPlugins(dir); err == nil {
+		t.Fatal("Expected error from bad plugin")
+	} else if !strings.Contains(err.Error(), badPath) {
+		t.Errorf("Error does not reference plugin path: %v", err)
+	}
+}
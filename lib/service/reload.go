@@ -0,0 +1,216 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package service
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/stream"
+	"github.com/Jeffail/benthos/lib/util/config"
+)
+
+//------------------------------------------------------------------------------
+
+// streamConstructor creates a stream from a config, calling onClose when the
+// stream closes.
+type streamConstructor func(conf stream.Config, onClose func()) (*stream.Type, error)
+
+// reloadableStream runs a single stream that can be replaced with a new
+// version at runtime. The new version is constructed before the old version
+// is drained and stopped, therefore a config that fails to construct leaves
+// the old version running.
+type reloadableStream struct {
+	ctor        streamConstructor
+	stopTimeout time.Duration
+
+	// reloadMut prevents concurrent reloads.
+	reloadMut sync.Mutex
+
+	mut     sync.Mutex
+	conf    stream.Config
+	current *stream.Type
+	stopped bool
+
+	closeOnce  sync.Once
+	closedChan chan struct{}
+}
+
+func newReloadableStream(
+	conf stream.Config,
+	stopTimeout time.Duration,
+	ctor streamConstructor,
+) (*reloadableStream, error) {
+	r := &reloadableStream{
+		ctor:        ctor,
+		stopTimeout: stopTimeout,
+		conf:        conf,
+		closedChan:  make(chan struct{}),
+	}
+	strm, err := r.construct(conf)
+	if err != nil {
+		return nil, err
+	}
+	r.current = strm
+	return r, nil
+}
+
+// construct creates a stream, where the closure of the stream only closes the
+// reloadable stream when it has not been replaced.
+func (r *reloadableStream) construct(conf stream.Config) (*stream.Type, error) {
+	var strm *stream.Type
+	var strmMut sync.Mutex
+	strmMut.Lock()
+	defer strmMut.Unlock()
+
+	var err error
+	strm, err = r.ctor(conf, func() {
+		strmMut.Lock()
+		closed := strm
+		strmMut.Unlock()
+
+		r.mut.Lock()
+		isCurrent := r.current == closed || r.current == nil
+		r.mut.Unlock()
+		if isCurrent {
+			r.closeOnce.Do(func() {
+				close(r.closedChan)
+			})
+		}
+	})
+	return strm, err
+}
+
+// Reload replaces the running stream with a new stream created from a config,
+// returning false if the config is unchanged.
+func (r *reloadableStream) Reload(conf stream.Config) (bool, error) {
+	r.reloadMut.Lock()
+	defer r.reloadMut.Unlock()
+
+	r.mut.Lock()
+	if r.stopped {
+		r.mut.Unlock()
+		return false, errors.New("stream has been stopped")
+	}
+	if reflect.DeepEqual(r.conf, conf) {
+		r.mut.Unlock()
+		return false, nil
+	}
+	r.mut.Unlock()
+
+	newStrm, err := r.construct(conf)
+	if err != nil {
+		return false, err
+	}
+
+	r.mut.Lock()
+	if r.stopped {
+		r.mut.Unlock()
+		newStrm.Stop(r.stopTimeout)
+		return false, errors.New("stream has been stopped")
+	}
+	oldStrm := r.current
+	r.current, r.conf = newStrm, conf
+	r.mut.Unlock()
+
+	return true, oldStrm.Stop(r.stopTimeout)
+}
+
+// ClosedChan returns a channel that is closed when the running stream closes
+// without having been replaced.
+func (r *reloadableStream) ClosedChan() <-chan struct{} {
+	return r.closedChan
+}
+
+// Stop attempts to close the running stream within the specified timeout.
+func (r *reloadableStream) Stop(timeout time.Duration) error {
+	r.mut.Lock()
+	r.stopped = true
+	strm := r.current
+	r.mut.Unlock()
+	return strm.Stop(timeout)
+}
+
+//------------------------------------------------------------------------------
+
+// readConfig reads a service config from a file, also returning the duration
+// until the earliest secret resolved within the config expires.
+func readConfig(path string) (Config, time.Duration, error) {
+	conf := NewConfig()
+	expiry, err := config.ReadWithExpiry(path, *swapEnvs, &conf)
+	return conf, expiry, err
+}
+
+// secretsRetryPeriod is how long to wait before reading a config again after
+// failing to refresh its secrets.
+const secretsRetryPeriod = time.Second * 10
+
+// secretsRefreshDelay returns how long to wait before reading a config again
+// in order to refresh secrets that expire after a duration, which leaves time
+// for retries before the secrets expire.
+func secretsRefreshDelay(expiry time.Duration) time.Duration {
+	delay := expiry * 4 / 5
+	if delay < time.Second {
+		delay = time.Second
+	}
+	return delay
+}
+
+// onlyStreamChanged returns whether two service configs are identical other
+// than their stream sections (input, buffer, pipeline and output), which are
+// the only sections that can be reloaded.
+func onlyStreamChanged(before, after Config) bool {
+	before.Config, after.Config = stream.Config{}, stream.Config{}
+	return reflect.DeepEqual(before, after)
+}
+
+// watchFile polls a file at a period and calls onChange whenever its
+// modification time or size changes, until closeChan is closed.
+func watchFile(path string, period time.Duration, closeChan <-chan struct{}, onChange func()) {
+	var modTime time.Time
+	var size int64
+	if info, err := os.Stat(path); err == nil {
+		modTime, size = info.ModTime(), info.Size()
+	}
+
+	ticker := time.NewTicker(period)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-closeChan:
+			return
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		if !info.ModTime().Equal(modTime) || info.Size() != size {
+			modTime, size = info.ModTime(), info.Size()
+			onChange()
+		}
+	}
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,104 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/stream"
+)
+
+func harmlessStreamConf(path string) stream.Config {
+	conf := stream.NewConfig()
+	conf.Input.Type = "http_server"
+	conf.Input.HTTPServer.Path = path
+	conf.Output.Type = "http_server"
+	return conf
+}
+
+func TestReloadableStream(t *testing.T) {
+	ctor := func(conf stream.Config, onClose func()) (*stream.Type, error) {
+		return stream.New(conf, stream.OptOnClose(onClose))
+	}
+
+	strm, err := newReloadableStream(harmlessStreamConf("/foo"), time.Second*5, ctor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := strm.current
+
+	if changed, err := strm.Reload(harmlessStreamConf("/foo")); err != nil || changed {
+		t.Errorf("Unexpected reload result: %v, %v", changed, err)
+	}
+	if strm.current != first {
+		t.Error("Stream replaced without config changes")
+	}
+
+	if changed, err := strm.Reload(harmlessStreamConf("/bar")); err != nil || !changed {
+		t.Errorf("Unexpected reload result: %v, %v", changed, err)
+	}
+	second := strm.current
+	if second == first {
+		t.Error("Stream not replaced")
+	}
+
+	badConf := harmlessStreamConf("/baz")
+	badConf.Input.Type = "does_not_exist"
+	if _, err := strm.Reload(badConf); err == nil {
+		t.Error("Expected error from bad config")
+	}
+	if strm.current != second {
+		t.Error("Stream replaced by bad config")
+	}
+
+	select {
+	case <-strm.ClosedChan():
+		t.Fatal("Closed by replaced stream")
+	case <-time.After(time.Millisecond * 100):
+	}
+
+	if err = strm.Stop(time.Second * 5); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-strm.ClosedChan():
+	case <-time.After(time.Second * 5):
+		t.Fatal("Timed out waiting for close")
+	}
+
+	if _, err := strm.Reload(harmlessStreamConf("/qux")); err == nil {
+		t.Error("Expected error from reloading a stopped stream")
+	}
+}
+
+func TestOnlyStreamChanged(t *testing.T) {
+	before := NewConfig()
+	after := NewConfig()
+	after.Input.Type = "kafka"
+	if !onlyStreamChanged(before, after) {
+		t.Error("Expected only stream to have changed")
+	}
+	after.HTTP.Address = "localhost:1234"
+	if onlyStreamChanged(before, after) {
+		t.Error("Expected more than stream to have changed")
+	}
+}
@@ -0,0 +1,902 @@
+// Copyright (c) 2014 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"reflect"
+	"runtime/pprof"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/api"
+	"github.com/Jeffail/benthos/lib/buffer"
+	"github.com/Jeffail/benthos/lib/cache"
+	"github.com/Jeffail/benthos/lib/input"
+	"github.com/Jeffail/benthos/lib/log"
+	"github.com/Jeffail/benthos/lib/manager"
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/output"
+	"github.com/Jeffail/benthos/lib/pipeline"
+	"github.com/Jeffail/benthos/lib/processor"
+	"github.com/Jeffail/benthos/lib/processor/condition"
+	"github.com/Jeffail/benthos/lib/ratelimit"
+	"github.com/Jeffail/benthos/lib/stream"
+	strmmgr "github.com/Jeffail/benthos/lib/stream/manager"
+	"github.com/Jeffail/benthos/lib/test"
+	"github.com/Jeffail/benthos/lib/tracing"
+	"github.com/Jeffail/benthos/lib/util/config"
+	yaml "gopkg.in/yaml.v2"
+)
+
+//------------------------------------------------------------------------------
+
+// Build stamps, which are set when building with linker flags such as
+// -X github.com/Jeffail/benthos/lib/service.Version=v1.0.0
+var (
+	Version   string
+	DateBuilt string
+)
+
+//------------------------------------------------------------------------------
+
+// Config is the benthos configuration struct.
+type Config struct {
+	HTTP                   api.Config `json:"http" yaml:"http"`
+	stream.Config          `json:",inline" yaml:",inline"`
+	Manager                manager.Config `json:"resources" yaml:"resources"`
+	Logger                 log.Config     `json:"logger" yaml:"logger"`
+	Metrics                metrics.Config `json:"metrics" yaml:"metrics"`
+	Tracer                 tracing.Config `json:"tracer" yaml:"tracer"`
+	SystemCloseTimeoutMS   int            `json:"sys_exit_timeout_ms" yaml:"sys_exit_timeout_ms"`
+	ShutdownDrainTimeoutMS int            `json:"shutdown_drain_timeout_ms" yaml:"shutdown_drain_timeout_ms"`
+}
+
+// NewConfig returns a new configuration with default values.
+func NewConfig() Config {
+	metricsConf := metrics.NewConfig()
+	metricsConf.Prefix = "benthos"
+
+	return Config{
+		HTTP:                   api.NewConfig(),
+		Config:                 stream.NewConfig(),
+		Manager:                manager.NewConfig(),
+		Logger:                 log.NewConfig(),
+		Metrics:                metricsConf,
+		Tracer:                 tracing.NewConfig(),
+		SystemCloseTimeoutMS:   20000,
+		ShutdownDrainTimeoutMS: 15000,
+	}
+}
+
+// Sanitised returns a sanitised copy of the Benthos configuration, meaning
+// fields of no consequence (unused inputs, outputs, processors etc) are
+// excluded.
+func (c Config) Sanitised() (interface{}, error) {
+	inConf, err := input.SanitiseConfig(c.Input)
+	if err != nil {
+		return nil, err
+	}
+
+	var pipeConf interface{}
+	pipeConf, err = pipeline.SanitiseConfig(c.Pipeline)
+	if err != nil {
+		return nil, err
+	}
+
+	var outConf interface{}
+	outConf, err = output.SanitiseConfig(c.Output)
+	if err != nil {
+		return nil, err
+	}
+
+	var bufConf interface{}
+	bufConf, err = buffer.SanitiseConfig(c.Buffer)
+	if err != nil {
+		return nil, err
+	}
+
+	var metConf interface{}
+	metConf, err = metrics.SanitiseConfig(c.Metrics)
+	if err != nil {
+		return nil, err
+	}
+
+	var tracerConf interface{}
+	tracerConf, err = tracing.SanitiseConfig(c.Tracer)
+	if err != nil {
+		return nil, err
+	}
+
+	return struct {
+		HTTP                   interface{} `json:"http" yaml:"http"`
+		Input                  interface{} `json:"input" yaml:"input"`
+		Buffer                 interface{} `json:"buffer" yaml:"buffer"`
+		Pipeline               interface{} `json:"pipeline" yaml:"pipeline"`
+		Output                 interface{} `json:"output" yaml:"output"`
+		Manager                interface{} `json:"resources" yaml:"resources"`
+		Logger                 interface{} `json:"logger" yaml:"logger"`
+		Metrics                interface{} `json:"metrics" yaml:"metrics"`
+		Tracer                 interface{} `json:"tracer" yaml:"tracer"`
+		SystemCloseTimeoutMS   interface{} `json:"sys_exit_timeout_ms" yaml:"sys_exit_timeout_ms"`
+		ShutdownDrainTimeoutMS interface{} `json:"shutdown_drain_timeout_ms" yaml:"shutdown_drain_timeout_ms"`
+	}{
+		HTTP:                   c.HTTP,
+		Input:                  inConf,
+		Buffer:                 bufConf,
+		Pipeline:               pipeConf,
+		Output:                 outConf,
+		Manager:                c.Manager,
+		Logger:                 c.Logger,
+		Metrics:                metConf,
+		Tracer:                 tracerConf,
+		SystemCloseTimeoutMS:   c.SystemCloseTimeoutMS,
+		ShutdownDrainTimeoutMS: c.ShutdownDrainTimeoutMS,
+	}, nil
+}
+
+// Redacted returns a sanitised version of the Config where the values of
+// fields that are likely to contain credentials are redacted.
+func (c Config) Redacted() (interface{}, error) {
+	sanit, err := c.Sanitised()
+	if err != nil {
+		return nil, err
+	}
+	return config.Redact(sanit)
+}
+
+//------------------------------------------------------------------------------
+
+// Extra flags
+var (
+	showVersion = flag.Bool(
+		"version", false, "Display version info, then exit",
+	)
+	showConfigJSON = flag.Bool(
+		"print-json", false, "Print loaded configuration as JSON, then exit",
+	)
+	showConfigYAML = flag.Bool(
+		"print-yaml", false, "Print loaded configuration as YAML, then exit",
+	)
+	printConfig = flag.Bool(
+		"print-config", false,
+		"Print the config that would be run, with defaults applied, unused"+
+			" sections removed and credentials redacted, as YAML, then exit",
+	)
+	showAll = flag.Bool(
+		"all", false,
+		"Set whether all fields should be shown when printing configuration"+
+			" via --print-yaml or --print-json, otherwise only used values"+
+			" will be printed.",
+	)
+	configPath = flag.String(
+		"c", "", "Path to a configuration file",
+	)
+	swapEnvs = flag.Bool(
+		"swap-envs", true,
+		"Swap ${FOO} patterns in config file with environment variables",
+	)
+	examples = flag.String(
+		"example", "",
+		"Add specific examples when printing a configuration file with"+
+			" --print-yaml or --print-json by listing comma separated"+
+			" types. Types can be any input, buffer, processor or output. For"+
+			" example: benthos --print-yaml --example websocket,jmespath"+
+			" would print a config with a websocket input and output and a"+
+			" jmespath processor.",
+	)
+	printInputs = flag.Bool(
+		"list-inputs", false,
+		"Print a list of available input options, then exit",
+	)
+	printOutputs = flag.Bool(
+		"list-outputs", false,
+		"Print a list of available output options, then exit",
+	)
+	printBuffers = flag.Bool(
+		"list-buffers", false,
+		"Print a list of available buffer options, then exit",
+	)
+	printProcessors = flag.Bool(
+		"list-processors", false,
+		"Print a list of available processor options, then exit",
+	)
+	printConditions = flag.Bool(
+		"list-conditions", false,
+		"Print a list of available processor condition options, then exit",
+	)
+	printCaches = flag.Bool(
+		"list-caches", false,
+		"Print a list of available cache options, then exit",
+	)
+	printRateLimits = flag.Bool(
+		"list-rate-limits", false,
+		"Print a list of available rate_limit options, then exit",
+	)
+	pluginsDir = flag.String(
+		"plugins-dir", "/usr/lib/benthos/plugins",
+		"Specify a directory containing Go plugins (.so files) to load at startup",
+	)
+	printInputPlugins = flag.Bool(
+		"list-input-plugins", false,
+		"Print a list of loaded input plugins, then exit",
+	)
+	printOutputPlugins = flag.Bool(
+		"list-output-plugins", false,
+		"Print a list of loaded output plugins, then exit",
+	)
+	printProcessorPlugins = flag.Bool(
+		"list-processor-plugins", false,
+		"Print a list of loaded processor plugins, then exit",
+	)
+	printConditionPlugins = flag.Bool(
+		"list-condition-plugins", false,
+		"Print a list of loaded condition plugins, then exit",
+	)
+	printCachePlugins = flag.Bool(
+		"list-cache-plugins", false,
+		"Print a list of loaded cache plugins, then exit",
+	)
+	streamsMode = flag.Bool(
+		"streams", false,
+		"Run Benthos in streams mode, where streams can be created, updated"+
+			" and removed via REST HTTP endpoints. In streams mode the stream"+
+			" fields of a config file (input, buffer, pipeline, output) will"+
+			" be ignored. Instead, any .yaml or .json files inside the"+
+			" --streams-dir directory will be parsed as stream configs.",
+	)
+	streamsDir = flag.String(
+		"streams-dir", "/benthos/streams",
+		"When running Benthos in streams mode any files in this directory with"+
+			" a .json or .yaml extension will be parsed as a stream"+
+			" configuration (input, buffer, pipeline, output), where the"+
+			" filename less the extension will be the id of the stream.",
+	)
+	watchConfig = flag.Bool(
+		"watch", false,
+		"Watch the config file for changes and reload the stream sections"+
+			" (input, buffer, pipeline, output) when it is modified. The"+
+			" config is also reloaded when the service receives SIGHUP. This"+
+			" flag is ignored in streams mode.",
+	)
+	refreshSecrets = flag.Bool(
+		"refresh-secrets", false,
+		"Read the config file again shortly before any secrets resolved"+
+			" within it expire, reloading the stream sections (input, buffer,"+
+			" pipeline, output) with the new values. This flag is ignored in"+
+			" streams mode.",
+	)
+	lintConfig = flag.Bool(
+		"lint", false,
+		"Lint the config file, and in streams mode each stream config file,"+
+			" printing any problems found such as unrecognised fields, then"+
+			" exit. The exit status is non-zero if problems were found.",
+	)
+	streamsMaxInFlight = flag.Int(
+		"streams-max-in-flight", 0,
+		"When running Benthos in streams mode limit the number of messages of"+
+			" each stream that can be pending acknowledgement, zero disables"+
+			" the limit.",
+	)
+	streamsMaxBufferMemory = flag.Int(
+		"streams-max-buffer-memory", 0,
+		"When running Benthos in streams mode reject streams with a memory"+
+			" buffer limit greater than this number of bytes, zero disables"+
+			" the limit.",
+	)
+	streamsMaxThreads = flag.Int(
+		"streams-max-threads", 0,
+		"When running Benthos in streams mode reject streams with more"+
+			" pipeline threads than this number, zero disables the limit.",
+	)
+	streamsWatch = flag.Bool(
+		"streams-watch", false,
+		"When running Benthos in streams mode watch the --streams-dir"+
+			" directory for changes, creating, updating and removing streams"+
+			" as their config files are added, modified and removed.",
+	)
+)
+
+//------------------------------------------------------------------------------
+
+func addExamples(examples string, conf *Config) {
+	var inputType, bufferType, conditionType, outputType string
+	var processorTypes []string
+	for _, e := range strings.Split(examples, ",") {
+		if _, exists := input.Constructors[e]; exists && len(inputType) == 0 {
+			inputType = e
+		}
+		if _, exists := buffer.Constructors[e]; exists {
+			bufferType = e
+		}
+		if _, exists := processor.Constructors[e]; exists {
+			processorTypes = append(processorTypes, e)
+		}
+		if _, exists := condition.Constructors[e]; exists {
+			conditionType = e
+		}
+		if _, exists := output.Constructors[e]; exists {
+			outputType = e
+		}
+	}
+	if len(inputType) > 0 {
+		conf.Input.Type = inputType
+	}
+	if len(bufferType) > 0 {
+		conf.Buffer.Type = bufferType
+	}
+	if len(processorTypes) > 0 {
+		for _, procType := range processorTypes {
+			procConf := processor.NewConfig()
+			procConf.Type = procType
+			conf.Pipeline.Processors = append(conf.Pipeline.Processors, procConf)
+		}
+	}
+	if len(conditionType) > 0 {
+		condConf := condition.NewConfig()
+		condConf.Type = conditionType
+		procConf := processor.NewConfig()
+		procConf.Type = "filter"
+		procConf.Filter.Config = condConf
+		conf.Pipeline.Processors = append(conf.Pipeline.Processors, procConf)
+	}
+	if len(outputType) > 0 {
+		conf.Output.Type = outputType
+	}
+}
+
+// bootstrap reads cmd args and either parses and config file or prints helper
+// text and exits. The path of the config file read is returned, which is
+// empty if no file was read, along with the duration until the earliest secret
+// resolved within the config expires.
+func bootstrap() (Config, string, time.Duration) {
+	conf := NewConfig()
+
+	// A list of default config paths to check for if not explicitly defined
+	defaultPaths := []string{
+		"/benthos.yaml",
+		"/etc/benthos/config.yaml",
+		"/etc/benthos.yaml",
+	}
+
+	// Override default help printing
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: benthos [flags...]")
+		fmt.Fprintln(os.Stderr, "       benthos lint [flags...] [config files...]")
+		fmt.Fprintln(os.Stderr, "       benthos test [flags...] [paths...]")
+		fmt.Fprintln(os.Stderr, "       benthos echo [flags...]")
+		fmt.Fprintln(os.Stderr, "       benthos list [--format text|json|markdown] [kinds...]")
+		fmt.Fprintln(os.Stderr, "       benthos create [input]/[processors]/[output]")
+		fmt.Fprintln(os.Stderr, "       benthos bench [flags...] [config file]")
+		fmt.Fprintln(os.Stderr, "Flags:")
+		flag.PrintDefaults()
+		fmt.Fprintf(os.Stderr,
+			"\nFor example configs use --print-yaml or --print-json\n"+
+				"For a list of available inputs or outputs use --list-inputs or --list-outputs\n"+
+				"For a list of available buffer options use --list-buffers\n")
+	}
+
+	// The list, create and bench subcommands have their own flags.
+	if len(os.Args) > 1 && os.Args[1] == "list" {
+		os.Exit(runList(os.Args[2:], os.Stdout, os.Stderr))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "create" {
+		os.Exit(runCreate(os.Args[2:], os.Stdout, os.Stderr))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "bench" {
+		os.Exit(runBench(os.Args[2:], os.Stdout, os.Stderr))
+	}
+
+	// The lint subcommand is an alias of the --lint flag that also accepts
+	// config file paths as arguments. The test subcommand runs the tests of
+	// the config files or directories given as arguments. The echo
+	// subcommand is an alias of the --print-config flag.
+	var runTests bool
+	if len(os.Args) > 1 && (os.Args[1] == "lint" || os.Args[1] == "test" || os.Args[1] == "echo") {
+		flag.CommandLine.Parse(os.Args[2:])
+		*lintConfig = os.Args[1] == "lint"
+		runTests = os.Args[1] == "test"
+		*printConfig = *printConfig || os.Args[1] == "echo"
+	} else {
+		flag.Parse()
+	}
+
+	// If the user wants the version we print it.
+	if *showVersion {
+		fmt.Printf("Version: %v\nDate: %v\n", Version, DateBuilt)
+		os.Exit(0)
+	}
+
+	if _, err := loadPlugins(*pluginsDir); err != nil {
+		fmt.Fprintf(os.Stderr, "Plugin error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *lintConfig {
+		os.Exit(lint(defaultPaths))
+	}
+	if runTests {
+		if test.Run(os.Stdout, flag.Args(), test.DefaultSuffix) {
+			os.Exit(0)
+		}
+		os.Exit(1)
+	}
+
+	var readPath string
+	var secretsExpiry time.Duration
+	if len(*configPath) > 0 {
+		var err error
+		if secretsExpiry, err = config.ReadWithExpiry(*configPath, *swapEnvs, &conf); err != nil {
+			fmt.Fprintf(os.Stderr, "Configuration file read error: %v\n", err)
+			os.Exit(1)
+		}
+		readPath = *configPath
+	} else {
+		// Iterate default config paths
+		for _, path := range defaultPaths {
+			if _, err := os.Stat(path); err == nil {
+				fmt.Fprintf(os.Stderr, "Config file not specified, reading from %v\n", path)
+
+				if secretsExpiry, err = config.ReadWithExpiry(path, *swapEnvs, &conf); err != nil {
+					fmt.Fprintf(os.Stderr, "Configuration file read error: %v\n", err)
+					os.Exit(1)
+				}
+				readPath = path
+				break
+			}
+		}
+	}
+
+	// If the user wants the configuration to be printed we do so and then exit.
+	if *printConfig {
+		outConf, err := conf.Redacted()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Configuration sanitise error: %v\n", err)
+			os.Exit(1)
+		}
+		configYAML, err := yaml.Marshal(outConf)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Configuration marshal error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Print(string(configYAML))
+		os.Exit(0)
+	}
+	if *showConfigJSON || *showConfigYAML {
+		var outConf interface{}
+		var err error
+
+		if len(*examples) > 0 {
+			addExamples(*examples, &conf)
+		}
+
+		if !*showAll {
+			if outConf, err = conf.Sanitised(); err != nil {
+				fmt.Fprintln(os.Stderr, fmt.Sprintf("Configuration sanitise error: %v", err))
+				os.Exit(1)
+			}
+		} else {
+			if len(conf.Input.Processors) == 0 &&
+				len(conf.Pipeline.Processors) == 0 &&
+				len(conf.Output.Processors) == 0 {
+				conf.Pipeline.Processors = append(conf.Pipeline.Processors, processor.NewConfig())
+			}
+			manager.AddExamples(&conf.Manager)
+			outConf = conf
+		}
+
+		if *showConfigJSON {
+			if configJSON, err := json.Marshal(outConf); err == nil {
+				fmt.Println(string(configJSON))
+			} else {
+				fmt.Fprintln(os.Stderr, fmt.Sprintf("Configuration marshal error: %v", err))
+			}
+			os.Exit(0)
+		} else {
+			if configYAML, err := yaml.Marshal(outConf); err == nil {
+				fmt.Println(string(configYAML))
+			} else {
+				fmt.Fprintln(os.Stderr, fmt.Sprintf("Configuration marshal error: %v", err))
+			}
+			os.Exit(0)
+		}
+	}
+
+	// If we only want to print our inputs or outputs we should exit afterwards
+	if *printInputs || *printOutputs || *printBuffers || *printProcessors ||
+		*printConditions || *printCaches || *printRateLimits {
+		if *printInputs {
+			fmt.Println(input.Descriptions())
+		}
+		if *printProcessors {
+			fmt.Println(processor.Descriptions())
+		}
+		if *printConditions {
+			fmt.Println(condition.Descriptions())
+		}
+		if *printRateLimits {
+			fmt.Println(ratelimit.Descriptions())
+		}
+		if *printBuffers {
+			fmt.Println(buffer.Descriptions())
+		}
+		if *printOutputs {
+			fmt.Println(output.Descriptions())
+		}
+		if *printCaches {
+			fmt.Println(cache.Descriptions())
+		}
+		os.Exit(0)
+	}
+
+	if *printInputPlugins || *printOutputPlugins || *printProcessorPlugins ||
+		*printConditionPlugins || *printCachePlugins {
+		if *printInputPlugins {
+			fmt.Println(input.PluginDescriptions())
+		}
+		if *printOutputPlugins {
+			fmt.Println(output.PluginDescriptions())
+		}
+		if *printProcessorPlugins {
+			fmt.Println(processor.PluginDescriptions())
+		}
+		if *printConditionPlugins {
+			fmt.Println(condition.PluginDescriptions())
+		}
+		if *printCachePlugins {
+			fmt.Println(cache.PluginDescriptions())
+		}
+		os.Exit(0)
+	}
+
+	return conf, readPath, secretsExpiry
+}
+
+// lint checks the config files given as arguments, or otherwise the config
+// file that would be read, and returns the exit status to use.
+func lint(defaultPaths []string) int {
+	paths := flag.Args()
+	if len(paths) == 0 {
+		if len(*configPath) > 0 {
+			paths = append(paths, *configPath)
+		} else {
+			for _, path := range defaultPaths {
+				if _, err := os.Stat(path); err == nil {
+					paths = append(paths, path)
+					break
+				}
+			}
+		}
+	}
+
+	var streamPaths []string
+	if *streamsMode {
+		var err error
+		if streamPaths, err = lintStreamsDir(*streamsDir); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to read streams directory: %v\n", err)
+			return 1
+		}
+	}
+	if len(paths) == 0 && len(streamPaths) == 0 {
+		fmt.Fprintln(os.Stderr, "No config files to lint.")
+		return 1
+	}
+
+	issues := 0
+	for _, path := range paths {
+		issues += lintAndReport(os.Stdout, path, *swapEnvs, reflect.TypeOf(Config{}), nil)
+	}
+	if len(streamPaths) > 0 {
+		var mainPath string
+		if len(paths) > 0 {
+			mainPath = paths[0]
+		}
+		resources, err := lintResourcesFromFile(mainPath, *swapEnvs)
+		if err != nil {
+			resources = resourcesFromNode(nil)
+		}
+		for _, path := range streamPaths {
+			issues += lintAndReport(os.Stdout, path, *swapEnvs, reflect.TypeOf(stream.Config{}), resources)
+		}
+	}
+	if issues > 0 {
+		return 1
+	}
+	return 0
+}
+
+type stoppableStreams interface {
+	Stop(timeout time.Duration) error
+}
+
+// Run the Benthos service, parsing flags and subcommands from os.Args and
+// blocking until the service is shut down. Plugins registered before calling
+// Run are available to configs, which allows custom builds of Benthos to be
+// made with a main func that registers plugins and then calls Run.
+func Run() {
+	// Bootstrap by reading cmd flags and configuration file.
+	config, readPath, secretsExpiry := bootstrap()
+
+	// Logging and stats aggregation.
+	var logger log.Modular
+
+	// Note: Logs are written to a file when a path is configured, otherwise
+	// only log to Stderr if one of our outputs is stdout.
+	if len(config.Logger.File.Path) > 0 {
+		logFile, err := log.NewRotatingFile(config.Logger.File)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to open log file: %v\n", err)
+			os.Exit(1)
+		}
+		defer logFile.Close()
+		logger = log.New(logFile, config.Logger)
+	} else if config.Output.Type == "stdout" {
+		logger = log.New(os.Stderr, config.Logger)
+	} else {
+		logger = log.New(os.Stdout, config.Logger)
+	}
+
+	// Create our metrics type.
+	var stats metrics.Type
+	var err error
+	stats, err = metrics.New(config.Metrics, metrics.OptSetLogger(logger))
+	for err != nil {
+		logger.Errorf("Failed to connect to metrics aggregator: %v\n", err)
+		<-time.After(time.Second)
+		stats, err = metrics.New(config.Metrics, metrics.OptSetLogger(logger))
+	}
+	defer stats.Close()
+
+	// Create our tracer, which is used globally for creating spans.
+	tracer, err := tracing.New(config.Tracer, logger)
+	if err != nil {
+		logger.Errorf("Failed to create tracer: %v\n", err)
+		os.Exit(1)
+	}
+	tracing.SetGlobal(tracer)
+	defer tracer.Close()
+
+	propagator, err := tracing.NewPropagator(config.Tracer.Propagation)
+	if err != nil {
+		logger.Errorf("Failed to create trace propagator: %v\n", err)
+		os.Exit(1)
+	}
+	tracing.SetPropagator(propagator)
+
+	// Create HTTP API with a sanitised service config.
+	sanConf, err := config.Redacted()
+	if err != nil {
+		logger.Warnf("Failed to generate sanitised config: %v\n", err)
+	}
+	httpServer := api.New(Version, DateBuilt, config.HTTP, sanConf, logger, stats)
+
+	// Create resource manager.
+	manager, err := manager.New(config.Manager, httpServer, logger, stats)
+	if err != nil {
+		logger.Errorf("Failed to create resource: %v\n", err)
+		os.Exit(1)
+	}
+
+	drainTimeout := time.Millisecond * time.Duration(config.ShutdownDrainTimeoutMS)
+	if config.ShutdownDrainTimeoutMS >= config.SystemCloseTimeoutMS {
+		logger.Warnf(
+			"Shutdown drain timeout (%vms) must be less than the exit timeout (%vms) and will be ignored.\n",
+			config.ShutdownDrainTimeoutMS, config.SystemCloseTimeoutMS,
+		)
+	}
+
+	var dataStream stoppableStreams
+	var dataStreamClosedChan <-chan struct{} = make(chan struct{})
+	reloadChan := make(chan struct{}, 1)
+
+	// Create data streams.
+	if *streamsMode {
+		streamMgr := strmmgr.New(
+			strmmgr.OptSetAPITimeout(time.Duration(config.HTTP.ReadTimeoutMS)*time.Millisecond),
+			strmmgr.OptSetLogger(logger),
+			strmmgr.OptSetManager(manager),
+			strmmgr.OptSetStats(stats),
+			strmmgr.OptSetDrainTimeout(drainTimeout),
+			strmmgr.OptSetQuotas(strmmgr.Quotas{
+				MaxInFlight:        *streamsMaxInFlight,
+				MaxBufferMemory:    *streamsMaxBufferMemory,
+				MaxPipelineThreads: *streamsMaxThreads,
+			}),
+		)
+		var streamConfs map[string]stream.Config
+		if streamConfs, err = strmmgr.LoadStreamConfigsFromDirectory(true, *streamsDir); err != nil {
+			logger.Errorf("Failed to load stream configs: %v\n", err)
+			os.Exit(1)
+		}
+		dataStream = streamMgr
+		for id, conf := range streamConfs {
+			if err = streamMgr.Create(id, conf); err != nil {
+				logger.Errorf("Failed to create stream (%v): %v\n", id, err)
+				os.Exit(1)
+			}
+		}
+		logger.Infoln("Launching benthos in streams mode, use CTRL+C to close.")
+		if lStreams := len(streamConfs); lStreams > 0 {
+			logger.Infof("Created %v streams from directory: %v\n", lStreams, *streamsDir)
+		}
+		if *streamsWatch {
+			streamMgr.WatchDirectory(true, *streamsDir, time.Second)
+			logger.Infof("Watching directory for stream config changes: %v\n", *streamsDir)
+		}
+	} else {
+		strm, err := newReloadableStream(
+			config.Config,
+			time.Millisecond*time.Duration(config.SystemCloseTimeoutMS),
+			func(conf stream.Config, onClose func()) (*stream.Type, error) {
+				return stream.New(
+					conf,
+					stream.OptSetLogger(logger),
+					stream.OptSetStats(stats),
+					stream.OptSetManager(manager),
+					stream.OptSetDrainTimeout(drainTimeout),
+					stream.OptOnClose(onClose),
+				)
+			},
+		)
+		if err != nil {
+			logger.Errorf("Service closing due to: %v\n", err)
+			os.Exit(1)
+		}
+		dataStream = strm
+		dataStreamClosedChan = strm.ClosedChan()
+
+		if len(readPath) > 0 {
+			triggerReload := func() {
+				select {
+				case reloadChan <- struct{}{}:
+				default:
+				}
+			}
+			secretsTimer := time.AfterFunc(time.Hour, triggerReload)
+			secretsTimer.Stop()
+			scheduleSecretsRefresh := func(expiry time.Duration) {
+				if *refreshSecrets && expiry > 0 {
+					delay := secretsRefreshDelay(expiry)
+					logger.Infof("Config secrets will be refreshed in %v\n", delay)
+					secretsTimer.Reset(delay)
+				}
+			}
+			scheduleSecretsRefresh(secretsExpiry)
+
+			go func() {
+				for range reloadChan {
+					newConfig, expiry, err := readConfig(readPath)
+					if err != nil {
+						logger.Errorf("Failed to reload config: %v\n", err)
+						if secretsExpiry > 0 {
+							scheduleSecretsRefresh(secretsRetryPeriod)
+						}
+						continue
+					}
+					secretsExpiry = expiry
+					scheduleSecretsRefresh(expiry)
+					if !onlyStreamChanged(config, newConfig) {
+						logger.Warnln("Changes to sections other than input, buffer, pipeline and output require a restart and have been ignored.")
+					}
+					if changed, err := strm.Reload(newConfig.Config); err != nil {
+						logger.Errorf("Failed to reload stream: %v\n", err)
+					} else if changed {
+						logger.Infof("Reloaded stream from config: %v\n", readPath)
+						runningConf := config
+						runningConf.Config = newConfig.Config
+						if sanConf, err := runningConf.Redacted(); err == nil {
+							httpServer.SetConfig(sanConf)
+						}
+					}
+				}
+			}()
+			if *watchConfig {
+				go watchFile(readPath, time.Second, strm.ClosedChan(), triggerReload)
+			}
+		}
+		logger.Infoln("Launching a benthos instance, use CTRL+C to close.")
+	}
+
+	// Start HTTP server.
+	httpServerClosedChan := make(chan struct{})
+	go func() {
+		scheme := "http://"
+		if config.HTTP.TLS.Enabled {
+			scheme = "https://"
+		}
+		logger.Infof(
+			"Listening for HTTP requests at: %v\n",
+			scheme+config.HTTP.Address,
+		)
+		httpErr := httpServer.ListenAndServe()
+		if httpErr != nil && httpErr != http.ErrServerClosed {
+			logger.Errorf("HTTP Server error: %v\n", httpErr)
+		}
+		close(httpServerClosedChan)
+	}()
+
+	// Defer clean up.
+	defer func() {
+		tout := time.Millisecond * time.Duration(config.SystemCloseTimeoutMS)
+
+		go func() {
+			httpServer.Shutdown(context.Background())
+			select {
+			case <-httpServerClosedChan:
+			case <-time.After(tout / 2):
+				logger.Warnln("Service failed to close HTTP server gracefully in time.")
+			}
+		}()
+
+		go func() {
+			<-time.After(tout + time.Second)
+			logger.Warnln(
+				"Service failed to close cleanly within allocated time." +
+					" Exiting forcefully and dumping stack trace to stderr.",
+			)
+			pprof.Lookup("goroutine").WriteTo(os.Stderr, 1)
+			os.Exit(1)
+		}()
+
+		if err := dataStream.Stop(tout); err != nil {
+			os.Exit(1)
+		}
+	}()
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+
+	hupChan := make(chan os.Signal, 1)
+	signal.Notify(hupChan, syscall.SIGHUP)
+
+	// Wait for termination signal
+	for {
+		select {
+		case <-hupChan:
+			if *streamsMode || len(readPath) == 0 {
+				logger.Warnln("Received SIGHUP, but there is no config to reload.")
+				continue
+			}
+			logger.Infoln("Received SIGHUP, reloading config.")
+			select {
+			case reloadChan <- struct{}{}:
+			default:
+			}
+			continue
+		case <-sigChan:
+			logger.Infoln("Received SIGTERM, the service is closing.")
+		case <-dataStreamClosedChan:
+			logger.Infoln("Pipeline has terminated. Shutting down the service.")
+		case <-httpServerClosedChan:
+			logger.Infoln("HTTP Server has terminated. Shutting down the service.")
+		}
+		return
+	}
+}
+
+//------------------------------------------------------------------------------
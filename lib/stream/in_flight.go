@@ -0,0 +1,124 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package stream
+
+import (
+	"sync/atomic"
+
+	"github.com/Jeffail/benthos/lib/types"
+)
+
+//------------------------------------------------------------------------------
+
+// inFlightTracker sits between two layers of a stream and keeps count of the
+// transactions that have passed through it and are yet to receive a response,
+// and of those that have been successfully acknowledged.
+type inFlightTracker struct {
+	pending int64
+	acked   int64
+
+	// slots limits the number of pending transactions when not nil.
+	slots chan struct{}
+
+	closeChan <-chan struct{}
+}
+
+// newInFlightTracker creates a tracker that reads transactions from a channel
+// and returns a channel of the same transactions with their responses tracked.
+// When maxPending is greater than zero no further transactions are read once
+// that many are pending. Responses are abandoned once the close chan is closed.
+func newInFlightTracker(
+	in <-chan types.Transaction, maxPending int, closeChan <-chan struct{},
+) (*inFlightTracker, <-chan types.Transaction) {
+	t := &inFlightTracker{
+		closeChan: closeChan,
+	}
+	if maxPending > 0 {
+		t.slots = make(chan struct{}, maxPending)
+	}
+	out := make(chan types.Transaction)
+	go t.loop(in, out)
+	return t, out
+}
+
+//------------------------------------------------------------------------------
+
+func (t *inFlightTracker) loop(in <-chan types.Transaction, out chan<- types.Transaction) {
+	defer close(out)
+	for {
+		if t.slots != nil {
+			select {
+			case t.slots <- struct{}{}:
+			case <-t.closeChan:
+				return
+			}
+		}
+		var ts types.Transaction
+		var open bool
+		select {
+		case ts, open = <-in:
+			if !open {
+				return
+			}
+		case <-t.closeChan:
+			return
+		}
+		resChan := make(chan types.Response)
+		atomic.AddInt64(&t.pending, 1)
+		select {
+		case out <- types.NewTransaction(ts.Payload, resChan):
+		case <-t.closeChan:
+			return
+		}
+		go func(resChanOut chan<- types.Response) {
+			var res types.Response
+			select {
+			case res = <-resChan:
+			case <-t.closeChan:
+				return
+			}
+			atomic.AddInt64(&t.pending, -1)
+			if t.slots != nil {
+				<-t.slots
+			}
+			if res.Error() == nil {
+				atomic.AddInt64(&t.acked, 1)
+			}
+			select {
+			case resChanOut <- res:
+			case <-t.closeChan:
+			}
+		}(ts.ResponseChan)
+	}
+}
+
+// Pending returns the number of transactions yet to receive a response.
+func (t *inFlightTracker) Pending() int64 {
+	return atomic.LoadInt64(&t.pending)
+}
+
+// Acked returns the number of transactions that have been successfully
+// acknowledged.
+func (t *inFlightTracker) Acked() int64 {
+	return atomic.LoadInt64(&t.acked)
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,150 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package stream
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/message"
+	"github.com/Jeffail/benthos/lib/response"
+	"github.com/Jeffail/benthos/lib/types"
+)
+
+//------------------------------------------------------------------------------
+
+func TestInFlightTracker(t *testing.T) {
+	inChan := make(chan types.Transaction)
+	closeChan := make(chan struct{})
+
+	tracker, outChan := newInFlightTracker(inChan, 0, closeChan)
+
+	resChans := []chan types.Response{}
+	outTrans := []types.Transaction{}
+	for i := 0; i < 3; i++ {
+		resChan := make(chan types.Response)
+		resChans = append(resChans, resChan)
+		select {
+		case inChan <- types.NewTransaction(message.New([][]byte{[]byte("foo")}), resChan):
+		case <-time.After(time.Second):
+			t.Fatal("Timed out")
+		}
+		select {
+		case tran := <-outChan:
+			outTrans = append(outTrans, tran)
+		case <-time.After(time.Second):
+			t.Fatal("Timed out")
+		}
+	}
+
+	if exp, act := int64(3), tracker.Pending(); exp != act {
+		t.Errorf("Wrong pending count: %v != %v", act, exp)
+	}
+
+	outTrans[0].ResponseChan <- response.NewAck()
+	outTrans[1].ResponseChan <- response.NewError(errors.New("nope"))
+
+	for i, exp := range []error{nil, errors.New("nope")} {
+		select {
+		case res := <-resChans[i]:
+			if (res.Error() == nil) != (exp == nil) {
+				t.Errorf("Wrong response %v: %v", i, res.Error())
+			}
+		case <-time.After(time.Second):
+			t.Fatal("Timed out")
+		}
+	}
+
+	if exp, act := int64(1), tracker.Pending(); exp != act {
+		t.Errorf("Wrong pending count: %v != %v", act, exp)
+	}
+	if exp, act := int64(1), tracker.Acked(); exp != act {
+		t.Errorf("Wrong acked count: %v != %v", act, exp)
+	}
+
+	close(inChan)
+	select {
+	case _, open := <-outChan:
+		if open {
+			t.Error("Expected out chan to be closed")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Timed out")
+	}
+	close(closeChan)
+}
+
+func TestInFlightTrackerMaxPending(t *testing.T) {
+	inChan := make(chan types.Transaction)
+	closeChan := make(chan struct{})
+	defer close(closeChan)
+
+	tracker, outChan := newInFlightTracker(inChan, 2, closeChan)
+
+	resChan := make(chan types.Response)
+	outTrans := []types.Transaction{}
+	for i := 0; i < 2; i++ {
+		select {
+		case inChan <- types.NewTransaction(message.New([][]byte{[]byte("foo")}), resChan):
+		case <-time.After(time.Second):
+			t.Fatal("Timed out")
+		}
+		select {
+		case tran := <-outChan:
+			outTrans = append(outTrans, tran)
+		case <-time.After(time.Second):
+			t.Fatal("Timed out")
+		}
+	}
+
+	select {
+	case inChan <- types.NewTransaction(message.New([][]byte{[]byte("bar")}), resChan):
+		t.Fatal("Expected transaction to be blocked by max pending")
+	case <-time.After(time.Millisecond * 50):
+	}
+	if exp, act := int64(2), tracker.Pending(); exp != act {
+		t.Errorf("Wrong pending count: %v != %v", act, exp)
+	}
+
+	outTrans[0].ResponseChan <- response.NewAck()
+	select {
+	case <-resChan:
+	case <-time.After(time.Second):
+		t.Fatal("Timed out")
+	}
+
+	select {
+	case inChan <- types.NewTransaction(message.New([][]byte{[]byte("bar")}), resChan):
+	case <-time.After(time.Second):
+		t.Fatal("Timed out")
+	}
+	select {
+	case tran := <-outChan:
+		if exp, act := "bar", string(tran.Payload.Get(0).Get()); exp != act {
+			t.Errorf("Wrong payload: %v != %v", act, exp)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Timed out")
+	}
+}
+
+//------------------------------------------------------------------------------
@@ -85,6 +85,7 @@ func (m *Type) HandleStreamsCRUD(w http.ResponseWriter, r *http.Request) {
 
 	type confInfo struct {
 		Active    bool    `json:"active"`
+		Connected bool    `json:"connected"`
 		Uptime    float64 `json:"uptime"`
 		UptimeStr string  `json:"uptime_str"`
 	}
@@ -94,6 +95,7 @@ func (m *Type) HandleStreamsCRUD(w http.ResponseWriter, r *http.Request) {
 	for id, strInfo := range m.streams {
 		infos[id] = confInfo{
 			Active:    strInfo.IsRunning(),
+			Connected: strInfo.IsConnected(),
 			Uptime:    strInfo.Uptime().Seconds(),
 			UptimeStr: strInfo.Uptime().String(),
 		}
@@ -206,22 +208,23 @@ func (m *Type) HandleStreamsCRUD(w http.ResponseWriter, r *http.Request) {
 // HandleStreamCRUD is an http.HandleFunc for performing CRUD operations on
 // individual streams.
 func (m *Type) HandleStreamCRUD(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
 	var serverErr, requestErr error
 	defer func() {
 		if r.Body != nil {
 			r.Body.Close()
 		}
 		if serverErr != nil {
-			m.logger.Errorf("Streams CRUD Error: %v\n", serverErr)
+			m.streamLogger(id).Errorf("Streams CRUD Error: %v\n", serverErr)
 			http.Error(w, fmt.Sprintf("Error: %v", serverErr), http.StatusBadGateway)
 		}
 		if requestErr != nil {
-			m.logger.Debugf("Streams request CRUD Error: %v\n", requestErr)
+			m.streamLogger(id).Debugf("Streams request CRUD Error: %v\n", requestErr)
 			http.Error(w, fmt.Sprintf("Error: %v", requestErr), http.StatusBadRequest)
 		}
 	}()
 
-	id := mux.Vars(r)["id"]
 	if len(id) == 0 {
 		http.Error(w, "Var `id` must be set", http.StatusBadRequest)
 		return
@@ -259,7 +262,13 @@ func (m *Type) HandleStreamCRUD(w http.ResponseWriter, r *http.Request) {
 			Pipeline: aliasedPipe(confIn.Pipeline),
 			Output:   aliasedOut(confIn.Output),
 		}
-		if err = json.Unmarshal(patchBytes, &aliasedConf); err != nil {
+		// JSON bodies are parsed as such since YAML does not permit tabs.
+		if json.Valid(patchBytes) {
+			err = json.Unmarshal(patchBytes, &aliasedConf)
+		} else {
+			err = yaml.Unmarshal(patchBytes, &aliasedConf)
+		}
+		if err != nil {
 			return
 		}
 		confOut = stream.Config{
@@ -288,17 +297,27 @@ func (m *Type) HandleStreamCRUD(w http.ResponseWriter, r *http.Request) {
 		if info, serverErr = m.Read(id); serverErr == nil {
 			sanit, _ := info.Config().Sanitised()
 
+			connections := info.Connections()
+			connected := true
+			for _, c := range connections {
+				connected = connected && c.Connected
+			}
+
 			var bodyBytes []byte
 			if bodyBytes, serverErr = json.Marshal(struct {
-				Active    bool        `json:"active"`
-				Uptime    float64     `json:"uptime"`
-				UptimeStr string      `json:"uptime_str"`
-				Config    interface{} `json:"config"`
+				Active      bool               `json:"active"`
+				Connected   bool               `json:"connected"`
+				Connections []ConnectionStatus `json:"connections"`
+				Uptime      float64            `json:"uptime"`
+				UptimeStr   string             `json:"uptime_str"`
+				Config      interface{}        `json:"config"`
 			}{
-				Active:    info.IsRunning(),
-				Uptime:    info.Uptime().Seconds(),
-				UptimeStr: info.Uptime().String(),
-				Config:    sanit,
+				Active:      info.IsRunning(),
+				Connected:   connected,
+				Connections: connections,
+				Uptime:      info.Uptime().Seconds(),
+				UptimeStr:   info.Uptime().String(),
+				Config:      sanit,
 			}); serverErr != nil {
 				return
 			}
@@ -332,26 +351,30 @@ func (m *Type) HandleStreamCRUD(w http.ResponseWriter, r *http.Request) {
 		serverErr = nil
 		http.Error(w, "Stream already exists", http.StatusBadRequest)
 	}
+	if _, ok := serverErr.(ErrQuotaExceeded); ok {
+		requestErr, serverErr = serverErr, nil
+	}
 }
 
 // HandleStreamStats is an http.HandleFunc for obtaining metrics for a stream.
 func (m *Type) HandleStreamStats(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
 	var serverErr, requestErr error
 	defer func() {
 		if r.Body != nil {
 			r.Body.Close()
 		}
 		if serverErr != nil {
-			m.logger.Errorf("Stream stats Error: %v\n", serverErr)
+			m.streamLogger(id).Errorf("Stream stats Error: %v\n", serverErr)
 			http.Error(w, fmt.Sprintf("Error: %v", serverErr), http.StatusBadGateway)
 		}
 		if requestErr != nil {
-			m.logger.Debugf("Stream request stats Error: %v\n", requestErr)
+			m.streamLogger(id).Debugf("Stream request stats Error: %v\n", requestErr)
 			http.Error(w, fmt.Sprintf("Error: %v", requestErr), http.StatusBadRequest)
 		}
 	}()
 
-	id := mux.Vars(r)["id"]
 	if len(id) == 0 {
 		http.Error(w, "Var `id` must be set", http.StatusBadRequest)
 		return
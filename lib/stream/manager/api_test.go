@@ -88,6 +88,7 @@ func genYAMLRequest(verb, url string, payload interface{}) *http.Request {
 
 type listItemBody struct {
 	Active    bool    `json:"active"`
+	Connected bool    `json:"connected"`
 	Uptime    float64 `json:"uptime"`
 	UptimeStr string  `json:"uptime_str"`
 }
@@ -103,10 +104,12 @@ func parseListBody(data *bytes.Buffer) listBody {
 }
 
 type getBody struct {
-	Active    bool          `json:"active"`
-	Uptime    float64       `json:"uptime"`
-	UptimeStr string        `json:"uptime_str"`
-	Config    stream.Config `json:"config"`
+	Active      bool               `json:"active"`
+	Connected   bool               `json:"connected"`
+	Connections []ConnectionStatus `json:"connections"`
+	Uptime      float64            `json:"uptime"`
+	UptimeStr   string             `json:"uptime_str"`
+	Config      stream.Config      `json:"config"`
 }
 
 func parseGetBody(data *bytes.Buffer) getBody {
@@ -604,3 +607,124 @@ func TestTypeAPIGetStats(t *testing.T) {
 		t.Logf("Metrics: %v", stats)
 	}
 }
+
+func TestTypeAPIPatchYAML(t *testing.T) {
+	mgr := New(
+		OptSetLogger(log.Noop()),
+		OptSetStats(metrics.DudType{}),
+		OptSetManager(types.DudMgr{}),
+		OptSetAPITimeout(time.Millisecond*100),
+	)
+
+	r := router(mgr)
+	conf := harmlessConf()
+
+	request := genYAMLRequest("POST", "/streams/foo", conf)
+	response := httptest.NewRecorder()
+	r.ServeHTTP(response, request)
+	if exp, act := http.StatusOK, response.Code; exp != act {
+		t.Errorf("Unexpected result: %v != %v", act, exp)
+	}
+
+	request, err := http.NewRequest("PATCH", "/streams/foo", bytes.NewReader([]byte(`
+input:
+  http_server:
+    path: /foobarbaz
+`)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	response = httptest.NewRecorder()
+	r.ServeHTTP(response, request)
+	if exp, act := http.StatusOK, response.Code; exp != act {
+		t.Errorf("Unexpected result: %v != %v: %v", act, exp, response.Body.String())
+	}
+
+	request = genRequest("GET", "/streams/foo", nil)
+	response = httptest.NewRecorder()
+	r.ServeHTTP(response, request)
+	if exp, act := http.StatusOK, response.Code; exp != act {
+		t.Errorf("Unexpected result: %v != %v", act, exp)
+	}
+	info := parseGetBody(response.Body)
+	if act, exp := info.Config.Input.HTTPServer.Path, "/foobarbaz"; exp != act {
+		t.Errorf("Unexpected config: %v != %v", act, exp)
+	}
+	if act, exp := info.Config.Input.Type, conf.Input.Type; exp != act {
+		t.Errorf("Unexpected config: %v != %v", act, exp)
+	}
+}
+
+type fakeConnector struct {
+	err error
+}
+
+func (f *fakeConnector) ConnectionStatus() error {
+	return f.err
+}
+
+func TestTypeAPIConnections(t *testing.T) {
+	mgr := New(
+		OptSetLogger(log.Noop()),
+		OptSetStats(metrics.DudType{}),
+		OptSetManager(types.DudMgr{}),
+		OptSetAPITimeout(time.Millisecond*100),
+	)
+
+	r := router(mgr)
+
+	request := genRequest("POST", "/streams/foo", harmlessConf())
+	response := httptest.NewRecorder()
+	r.ServeHTTP(response, request)
+	if exp, act := http.StatusOK, response.Code; exp != act {
+		t.Fatalf("Unexpected result: %v != %v", act, exp)
+	}
+
+	in := &fakeConnector{err: types.ErrNotConnected}
+	out := &fakeConnector{err: nil}
+	mgr.streams["foo"].connectors.RegisterConnector("input.foo", in)
+	mgr.streams["foo"].connectors.RegisterConnector("output.bar", out)
+
+	request = genRequest("GET", "/streams", nil)
+	response = httptest.NewRecorder()
+	r.ServeHTTP(response, request)
+	if act := parseListBody(response.Body)["foo"]; act.Connected || !act.Active {
+		t.Errorf("Unexpected status: %+v", act)
+	}
+
+	request = genRequest("GET", "/streams/foo", nil)
+	response = httptest.NewRecorder()
+	r.ServeHTTP(response, request)
+	info := parseGetBody(response.Body)
+	if info.Connected {
+		t.Error("Expected stream not to be connected")
+	}
+	exp := []ConnectionStatus{
+		{Name: "input.foo", Connected: false},
+		{Name: "output.bar", Connected: true},
+	}
+	if !reflect.DeepEqual(exp, info.Connections) {
+		t.Errorf("Unexpected connections: %v != %v", info.Connections, exp)
+	}
+
+	in.err = nil
+	out.err = types.ErrTypeClosed
+
+	request = genRequest("GET", "/streams", nil)
+	response = httptest.NewRecorder()
+	r.ServeHTTP(response, request)
+	if act := parseListBody(response.Body)["foo"]; !act.Connected {
+		t.Errorf("Unexpected status: %+v", act)
+	}
+
+	request = genRequest("GET", "/streams/foo", nil)
+	response = httptest.NewRecorder()
+	r.ServeHTTP(response, request)
+	info = parseGetBody(response.Body)
+	exp = []ConnectionStatus{
+		{Name: "input.foo", Connected: true},
+	}
+	if !info.Connected || !reflect.DeepEqual(exp, info.Connections) {
+		t.Errorf("Unexpected connections: %v != %v", info.Connections, exp)
+	}
+}
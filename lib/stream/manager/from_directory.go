@@ -22,20 +22,19 @@ package manager
 
 import (
 	"fmt"
-	"io/ioutil"
 	"os"
 	"path/filepath"
 	"strings"
 
 	"github.com/Jeffail/benthos/lib/stream"
-	"github.com/Jeffail/benthos/lib/util/text"
-	yaml "gopkg.in/yaml.v2"
+	"github.com/Jeffail/benthos/lib/util/config"
 )
 
 //------------------------------------------------------------------------------
 
 // LoadStreamConfigsFromDirectory reads a map of stream ids to configurations
-// by walking a directory of .json and .yaml files.
+// by walking a directory of .json and .yaml files. Each file is read with
+// config.Read, and can therefore include other files and use templates.
 func LoadStreamConfigsFromDirectory(replaceEnvVars bool, dir string) (map[string]stream.Config, error) {
 	streamMap := map[string]stream.Config{}
 
@@ -74,23 +73,9 @@ func LoadStreamConfigsFromDirectory(replaceEnvVars bool, dir string) (map[string
 			return fmt.Errorf("stream id (%v) collision from file: %v", id, path)
 		}
 
-		file, openerr := os.Open(path)
-		if openerr != nil {
-			return fmt.Errorf("failed to read stream file '%v': %v", path, openerr)
-		}
-		defer file.Close()
-
-		streamBytes, readerr := ioutil.ReadAll(file)
-		if readerr != nil {
-			return readerr
-		}
-		if replaceEnvVars {
-			streamBytes = text.ReplaceEnvVariables(streamBytes)
-		}
-
 		conf := stream.NewConfig()
-		if readerr = yaml.Unmarshal(streamBytes, &conf); readerr != nil {
-			return readerr
+		if readerr := config.Read(path, replaceEnvVars, &conf); readerr != nil {
+			return fmt.Errorf("failed to read stream file '%v': %v", path, readerr)
 		}
 
 		streamMap[id] = conf
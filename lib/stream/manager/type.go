@@ -31,6 +31,7 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/Jeffail/benthos/lib/buffer"
 	"github.com/Jeffail/benthos/lib/log"
 	"github.com/Jeffail/benthos/lib/metrics"
 	"github.com/Jeffail/benthos/lib/stream"
@@ -47,6 +48,8 @@ type StreamStatus struct {
 	logger       log.Modular
 	metrics      *metrics.Local
 	createdAt    time.Time
+
+	connectors *nsMgr
 }
 
 // NewStreamStatus creates a new StreamStatus.
@@ -89,6 +92,33 @@ func (s *StreamStatus) Metrics() *metrics.Local {
 	return s.metrics
 }
 
+// ConnectionStatus describes whether a component of a stream is currently
+// connected.
+type ConnectionStatus struct {
+	Name      string `json:"name"`
+	Connected bool   `json:"connected"`
+}
+
+// Connections returns the connection state of each input and output of the
+// stream that connects to an external service.
+func (s *StreamStatus) Connections() []ConnectionStatus {
+	if s.connectors == nil {
+		return []ConnectionStatus{}
+	}
+	return s.connectors.connections()
+}
+
+// IsConnected returns a boolean indicating whether all inputs and outputs of
+// the stream are currently connected.
+func (s *StreamStatus) IsConnected() bool {
+	for _, c := range s.Connections() {
+		if !c.Connected {
+			return false
+		}
+	}
+	return true
+}
+
 // Logger returns the logger of the stream.
 func (s *StreamStatus) Logger() log.Modular {
 	return s.logger
@@ -102,12 +132,22 @@ func (s *StreamStatus) setClosed() {
 //------------------------------------------------------------------------------
 
 type nsMgr struct {
+	id  string
 	ns  string
 	mgr types.Manager
+
+	connMut    sync.Mutex
+	connectors []namedConnector
+}
+
+type namedConnector struct {
+	name string
+	c    types.Connector
 }
 
 func namespacedMgr(ns string, mgr types.Manager) *nsMgr {
 	return &nsMgr{
+		id:  ns,
 		ns:  "/" + ns,
 		mgr: mgr,
 	}
@@ -151,6 +191,55 @@ func (n *nsMgr) UnsetPipe(name string, t <-chan types.Transaction) {
 	n.mgr.UnsetPipe(name, t)
 }
 
+// SubscribePipe subscribes to a named pipe.
+func (n *nsMgr) SubscribePipe(name string, t chan<- types.Transaction) {
+	// Pipes are always absolute.
+	n.mgr.SubscribePipe(name, t)
+}
+
+// UnsubscribePipe unsubscribes from a named pipe.
+func (n *nsMgr) UnsubscribePipe(name string, t chan<- types.Transaction) {
+	// Pipes are always absolute.
+	n.mgr.UnsubscribePipe(name, t)
+}
+
+// GetPipeSubscribers returns the subscribers of a named pipe.
+func (n *nsMgr) GetPipeSubscribers(name string) []chan<- types.Transaction {
+	// Pipes are always absolute.
+	return n.mgr.GetPipeSubscribers(name)
+}
+
+// RegisterConnector registers a component of the stream, and registers it with
+// the service manager under a name prefixed with the stream ID.
+func (n *nsMgr) RegisterConnector(name string, c types.Connector) {
+	n.connMut.Lock()
+	n.connectors = append(n.connectors, namedConnector{name: name, c: c})
+	n.connMut.Unlock()
+	if reg, ok := n.mgr.(types.ConnectionRegistry); ok {
+		reg.RegisterConnector(n.id+"."+name, c)
+	}
+}
+
+// connections returns the connection state of each registered component that
+// has not closed.
+func (n *nsMgr) connections() []ConnectionStatus {
+	n.connMut.Lock()
+	defer n.connMut.Unlock()
+
+	statuses := []ConnectionStatus{}
+	for _, nc := range n.connectors {
+		err := nc.c.ConnectionStatus()
+		if err == types.ErrTypeClosed {
+			continue
+		}
+		statuses = append(statuses, ConnectionStatus{
+			Name:      nc.name,
+			Connected: err == nil,
+		})
+	}
+	return statuses
+}
+
 //------------------------------------------------------------------------------
 
 // StreamProcConstructorFunc is a closure type that constructs a processor type
@@ -174,11 +263,15 @@ type Type struct {
 	logger     log.Modular
 	apiTimeout time.Duration
 
+	drainTimeout time.Duration
+	quotas       Quotas
+
 	inputPipeCtors    []StreamPipeConstructorFunc
 	pipelineProcCtors []StreamProcConstructorFunc
 	outputPipeCtors   []StreamPipeConstructorFunc
 
-	lock sync.Mutex
+	closeChan chan struct{}
+	lock      sync.Mutex
 }
 
 // New creates a new stream manager.Type.
@@ -189,6 +282,7 @@ func New(opts ...func(*Type)) *Type {
 		stats:      metrics.DudType{},
 		apiTimeout: time.Second * 5,
 		logger:     log.New(os.Stdout, log.Config{LogLevel: "NONE"}),
+		closeChan:  make(chan struct{}),
 	}
 	for _, opt := range opts {
 		opt(t)
@@ -230,6 +324,21 @@ func OptSetAPITimeout(tout time.Duration) func(*Type) {
 	}
 }
 
+// OptSetDrainTimeout sets the period of time that each stream is given to
+// deliver its in-flight and buffered messages when it is stopped.
+func OptSetDrainTimeout(tout time.Duration) func(*Type) {
+	return func(t *Type) {
+		t.drainTimeout = tout
+	}
+}
+
+// OptSetQuotas sets limits that are enforced on each stream of the manager.
+func OptSetQuotas(q Quotas) func(*Type) {
+	return func(t *Type) {
+		t.quotas = q
+	}
+}
+
 // OptAddInputPipelines adds pipeline constructors that will be called for every
 // new stream and attached to the input component. The constructor is given the
 // name of the stream as an argument.
@@ -265,8 +374,69 @@ var (
 	ErrStreamDoesNotExist = errors.New("stream does not exist")
 )
 
+// ErrQuotaExceeded is returned when a stream config exceeds a quota of the
+// stream manager.
+type ErrQuotaExceeded struct {
+	Quota string
+	Value int
+	Limit int
+}
+
+// Error returns a description of the exceeded quota.
+func (e ErrQuotaExceeded) Error() string {
+	return fmt.Sprintf("stream exceeds the %v quota: %v > %v", e.Quota, e.Value, e.Limit)
+}
+
+//------------------------------------------------------------------------------
+
+// Quotas describes limits that are enforced on each stream of a manager, so that
+// a single stream is unable to starve the others of resources. A limit of zero
+// is disabled.
+type Quotas struct {
+	// MaxInFlight is the maximum number of messages of a stream that can be
+	// pending acknowledgement, once reached the stream stops reading until
+	// pending messages are acknowledged.
+	MaxInFlight int
+
+	// MaxBufferMemory is the maximum size in bytes of a memory buffer, streams
+	// with a larger buffer are rejected.
+	MaxBufferMemory int
+
+	// MaxPipelineThreads is the maximum number of processing threads of a
+	// pipeline, streams with more threads are rejected.
+	MaxPipelineThreads int
+}
+
+// check returns an ErrQuotaExceeded if a stream config exceeds the quotas.
+func (q Quotas) check(conf stream.Config) error {
+	if q.MaxBufferMemory > 0 && conf.Buffer.Type == buffer.TypeMemory &&
+		conf.Buffer.Memory.Limit > q.MaxBufferMemory {
+		return ErrQuotaExceeded{
+			Quota: "buffer memory",
+			Value: conf.Buffer.Memory.Limit,
+			Limit: q.MaxBufferMemory,
+		}
+	}
+	if q.MaxPipelineThreads > 0 && conf.Pipeline.Threads > q.MaxPipelineThreads {
+		return ErrQuotaExceeded{
+			Quota: "pipeline threads",
+			Value: conf.Pipeline.Threads,
+			Limit: q.MaxPipelineThreads,
+		}
+	}
+	return nil
+}
+
 //------------------------------------------------------------------------------
 
+// streamLogger returns the logger of the manager with the field `stream` set to
+// the ID of a stream, for logs that concern that stream.
+func (m *Type) streamLogger(id string) log.Modular {
+	return m.logger.WithFields(map[string]string{
+		"stream": id,
+	})
+}
+
 // Create attempts to construct and run a new stream under a unique ID. If the
 // ID already exists an error is returned.
 func (m *Type) Create(id string, conf stream.Config) error {
@@ -281,6 +451,10 @@ func (m *Type) Create(id string, conf stream.Config) error {
 		return ErrStreamExists
 	}
 
+	if err := m.quotas.check(conf); err != nil {
+		return err
+	}
+
 	var inputPipeCtors []types.PipelineConstructorFunc
 	var procCtors []types.ProcessorConstructorFunc
 	var outputPipeCtors []types.PipelineConstructorFunc
@@ -307,9 +481,11 @@ func (m *Type) Create(id string, conf stream.Config) error {
 		}(ctor)
 	}
 
-	strmLogger := m.logger.NewModule("." + id)
+	strmLogger := m.streamLogger(id).NewModule("." + id)
 	strmFlatMetrics := metrics.NewLocal()
 
+	strmMgr := namespacedMgr(id, m.manager)
+
 	var wrapper *StreamStatus
 	strm, err := stream.New(
 		conf,
@@ -317,8 +493,10 @@ func (m *Type) Create(id string, conf stream.Config) error {
 		stream.OptAddProcessors(procCtors...),
 		stream.OptAddOutputPipelines(outputPipeCtors...),
 		stream.OptSetLogger(strmLogger),
-		stream.OptSetStats(metrics.Combine(metrics.Namespaced(m.stats, id), strmFlatMetrics)),
-		stream.OptSetManager(namespacedMgr(id, m.manager)),
+		stream.OptSetStats(metrics.Combine(metrics.Labelled(m.stats, "stream", id), strmFlatMetrics)),
+		stream.OptSetManager(strmMgr),
+		stream.OptSetDrainTimeout(m.drainTimeout),
+		stream.OptSetMaxInFlight(m.quotas.MaxInFlight),
 		stream.OptOnClose(func() {
 			wrapper.setClosed()
 		}),
@@ -328,6 +506,7 @@ func (m *Type) Create(id string, conf stream.Config) error {
 	}
 
 	wrapper = NewStreamStatus(conf, strm, strmLogger, strmFlatMetrics)
+	wrapper.connectors = strmMgr
 	m.streams[id] = wrapper
 	return nil
 }
@@ -369,6 +548,11 @@ func (m *Type) Update(id string, conf stream.Config, timeout time.Duration) erro
 		return nil
 	}
 
+	// Check quotas before the existing stream is removed.
+	if err := m.quotas.check(conf); err != nil {
+		return err
+	}
+
 	if err := m.Delete(id, timeout); err != nil {
 		return err
 	}
@@ -430,6 +614,9 @@ func (m *Type) Stop(timeout time.Duration) error {
 	}
 
 	m.streams = map[string]*StreamStatus{}
+	if !m.closed {
+		close(m.closeChan)
+	}
 	m.closed = true
 
 	if len(failedStreams) > 0 {
@@ -181,6 +181,51 @@ func TestTypeBasicOperations(t *testing.T) {
 	}
 }
 
+func TestTypeQuotas(t *testing.T) {
+	mgr := New(
+		OptSetLogger(log.New(os.Stdout, log.Config{LogLevel: "NONE"})),
+		OptSetStats(metrics.DudType{}),
+		OptSetManager(types.DudMgr{}),
+		OptSetQuotas(Quotas{
+			MaxInFlight:        10,
+			MaxBufferMemory:    1000,
+			MaxPipelineThreads: 2,
+		}),
+	)
+	defer mgr.Stop(time.Second)
+
+	conf := harmlessConf()
+	conf.Pipeline.Threads = 3
+	err := mgr.Create("foo", conf)
+	if exp, act := (ErrQuotaExceeded{Quota: "pipeline threads", Value: 3, Limit: 2}), err; exp != act {
+		t.Errorf("Unexpected error: %v != %v", act, exp)
+	}
+
+	conf = harmlessConf()
+	conf.Buffer.Type = "memory"
+	conf.Buffer.Memory.Limit = 2000
+	err = mgr.Create("foo", conf)
+	if exp, act := (ErrQuotaExceeded{Quota: "buffer memory", Value: 2000, Limit: 1000}), err; exp != act {
+		t.Errorf("Unexpected error: %v != %v", act, exp)
+	}
+
+	conf = harmlessConf()
+	conf.Pipeline.Threads = 2
+	if err = mgr.Create("foo", conf); err != nil {
+		t.Fatal(err)
+	}
+
+	conf.Pipeline.Threads = 4
+	if err = mgr.Update("foo", conf, time.Second); err == nil {
+		t.Error("Expected error from update exceeding quota")
+	}
+	if info, err := mgr.Read("foo"); err != nil {
+		t.Error(err)
+	} else if !info.IsRunning() {
+		t.Error("Stream was removed by rejected update")
+	}
+}
+
 func TestTypeBasicClose(t *testing.T) {
 	mgr := New(
 		OptSetLogger(log.New(os.Stdout, log.Config{LogLevel: "NONE"})),
@@ -0,0 +1,135 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package manager
+
+import (
+	"reflect"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/stream"
+)
+
+//------------------------------------------------------------------------------
+
+// dirWatcher periodically reloads a directory of stream configs and applies
+// any changes to the streams of a manager.
+type dirWatcher struct {
+	m              *Type
+	dir            string
+	replaceEnvVars bool
+	period         time.Duration
+
+	// applied contains the last config read for each stream owned by the
+	// directory, regardless of whether applying it succeeded.
+	applied map[string]stream.Config
+	lastErr string
+}
+
+// WatchDirectory starts watching a directory of stream configs, polling it at
+// the given period. Streams are created when a file is added, updated when
+// the contents of a file change and deleted when a file is removed.
+//
+// Only streams that were loaded from the directory are removed, and streams
+// created via the REST API are left untouched unless a file of the same ID is
+// added. A stream that was modified via the REST API is not reverted until
+// its file changes. The watcher stops once the manager is stopped.
+func (m *Type) WatchDirectory(replaceEnvVars bool, dir string, period time.Duration) {
+	w := &dirWatcher{
+		m:              m,
+		dir:            dir,
+		replaceEnvVars: replaceEnvVars,
+		period:         period,
+		applied:        map[string]stream.Config{},
+	}
+
+	// Streams that already exist and have a file are considered owned by the
+	// directory with their current config.
+	if confs, err := LoadStreamConfigsFromDirectory(replaceEnvVars, dir); err == nil {
+		for id := range confs {
+			if info, err := m.Read(id); err == nil {
+				w.applied[id] = info.Config()
+			}
+		}
+	}
+
+	go w.loop()
+}
+
+func (w *dirWatcher) loop() {
+	ticker := time.NewTicker(w.period)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			w.sync()
+		case <-w.m.closeChan:
+			return
+		}
+	}
+}
+
+// sync reloads the directory and applies the differences since the last sync.
+func (w *dirWatcher) sync() {
+	confs, err := LoadStreamConfigsFromDirectory(w.replaceEnvVars, w.dir)
+	if err != nil {
+		// Files may be read while partially written, so errors are only
+		// logged when they change and the next sync tries again.
+		if errStr := err.Error(); errStr != w.lastErr {
+			w.m.logger.Errorf("Failed to reload stream configs: %v\n", err)
+			w.lastErr = errStr
+		}
+		return
+	}
+	w.lastErr = ""
+
+	for id := range w.applied {
+		if _, exists := confs[id]; exists {
+			continue
+		}
+		delete(w.applied, id)
+		if err = w.m.Delete(id, w.m.apiTimeout); err != nil && err != ErrStreamDoesNotExist {
+			w.m.streamLogger(id).Errorf("Failed to delete stream (%v): %v\n", id, err)
+		} else {
+			w.m.streamLogger(id).Infof("Deleted stream (%v) after its config was removed\n", id)
+		}
+	}
+
+	for id, conf := range confs {
+		if prev, exists := w.applied[id]; exists && reflect.DeepEqual(prev, conf) {
+			continue
+		}
+		w.applied[id] = conf
+
+		if err = w.m.Update(id, conf, w.m.apiTimeout); err == ErrStreamDoesNotExist {
+			if err = w.m.Create(id, conf); err != nil {
+				w.m.streamLogger(id).Errorf("Failed to create stream (%v): %v\n", id, err)
+			} else {
+				w.m.streamLogger(id).Infof("Created stream (%v) from a new config\n", id)
+			}
+		} else if err != nil {
+			w.m.streamLogger(id).Errorf("Failed to update stream (%v): %v\n", id, err)
+		} else {
+			w.m.streamLogger(id).Infof("Updated stream (%v) after its config changed\n", id)
+		}
+	}
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,140 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package manager
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/log"
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+)
+
+//------------------------------------------------------------------------------
+
+func waitForStreams(t *testing.T, mgr *Type, exp map[string]string) {
+	t.Helper()
+	var act map[string]string
+	for deadline := time.Now().Add(time.Second * 5); time.Now().Before(deadline); {
+		act = map[string]string{}
+		mgr.lock.Lock()
+		for id, info := range mgr.streams {
+			act[id] = info.Config().Input.HTTPServer.Path
+		}
+		mgr.lock.Unlock()
+		if len(act) == len(exp) {
+			matched := true
+			for k, v := range exp {
+				if act[k] != v {
+					matched = false
+				}
+			}
+			if matched {
+				return
+			}
+		}
+		<-time.After(time.Millisecond * 10)
+	}
+	t.Fatalf("Wrong streams: %v != %v", act, exp)
+}
+
+func TestWatchDirectory(t *testing.T) {
+	testDir, err := ioutil.TempDir("", "streams_watch_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(testDir)
+
+	writeConf := func(name, path string) {
+		conf := []byte(`
+input:
+  type: http_server
+  http_server:
+    path: ` + path + `
+output:
+  type: http_server
+`)
+		if err := ioutil.WriteFile(filepath.Join(testDir, name), conf, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	mgr := New(
+		OptSetLogger(log.Noop()),
+		OptSetStats(metrics.DudType{}),
+		OptSetManager(types.DudMgr{}),
+		OptSetAPITimeout(time.Second),
+	)
+
+	writeConf("foo.yaml", "/foo")
+	confs, err := LoadStreamConfigsFromDirectory(false, testDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = mgr.Create("foo", confs["foo"]); err != nil {
+		t.Fatal(err)
+	}
+	if err = mgr.Create("api", harmlessConf()); err != nil {
+		t.Fatal(err)
+	}
+
+	mgr.WatchDirectory(false, testDir, time.Millisecond*10)
+
+	writeConf("bar.yaml", "/bar")
+	waitForStreams(t, mgr, map[string]string{
+		"foo": "/foo",
+		"bar": "/bar",
+		"api": "/post",
+	})
+
+	writeConf("foo.yaml", "/foo2")
+	waitForStreams(t, mgr, map[string]string{
+		"foo": "/foo2",
+		"bar": "/bar",
+		"api": "/post",
+	})
+
+	if err = ioutil.WriteFile(filepath.Join(testDir, "baz.yaml"), []byte("not: [valid"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	<-time.After(time.Millisecond * 50)
+	if err = os.Remove(filepath.Join(testDir, "baz.yaml")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = os.Remove(filepath.Join(testDir, "foo.yaml")); err != nil {
+		t.Fatal(err)
+	}
+	waitForStreams(t, mgr, map[string]string{
+		"bar": "/bar",
+		"api": "/post",
+	})
+
+	if err = mgr.Stop(time.Second * 5); err != nil {
+		t.Fatal(err)
+	}
+}
+
+//------------------------------------------------------------------------------
@@ -23,6 +23,7 @@ package stream
 import (
 	"bytes"
 	"runtime/pprof"
+	"sync"
 	"time"
 
 	"github.com/Jeffail/benthos/lib/buffer"
@@ -53,6 +54,14 @@ type Type struct {
 	stats   metrics.Type
 	logger  log.Modular
 
+	drainTimeout time.Duration
+	maxInFlight  int
+
+	inputTracker  *inFlightTracker
+	bufferTracker *inFlightTracker
+	trackerClose  chan struct{}
+	trackerOnce   sync.Once
+
 	onClose func()
 }
 
@@ -64,6 +73,8 @@ func New(conf Config, opts ...func(*Type)) (*Type, error) {
 		logger:  log.Noop(),
 		manager: types.NoopMgr(),
 		onClose: func() {},
+
+		trackerClose: make(chan struct{}),
 	}
 	for _, opt := range opts {
 		opt(t)
@@ -133,6 +144,27 @@ func OptSetManager(mgr types.Manager) func(*Type) {
 	}
 }
 
+// OptSetDrainTimeout sets the period of time that the stream is given to
+// deliver its in-flight and buffered messages when it is stopped, after which
+// the remaining messages are abandoned. If the period is zero or exceeds the
+// timeout given to Stop then three quarters of that timeout is used instead.
+func OptSetDrainTimeout(tout time.Duration) func(*Type) {
+	return func(t *Type) {
+		t.drainTimeout = tout
+	}
+}
+
+// OptSetMaxInFlight sets the maximum number of messages that can be read from
+// the input of the stream, and from its buffer when one is configured, without
+// yet having been acknowledged. Once the limit is reached no further messages
+// are read until pending messages are acknowledged. A value of zero or less
+// disables the limit.
+func OptSetMaxInFlight(n int) func(*Type) {
+	return func(t *Type) {
+		t.maxInFlight = n
+	}
+}
+
 // OptOnClose sets a closure to be called when the stream closes.
 func OptOnClose(onClose func()) func(*Type) {
 	return func(t *Type) {
@@ -151,12 +183,12 @@ func (t *Type) start() (err error) {
 	}
 	if t.conf.Buffer.Type != "none" {
 		if t.bufferLayer, err = buffer.New(
-			t.conf.Buffer, t.logger, t.stats,
+			t.conf.Buffer, t.manager, t.logger, t.stats,
 		); err != nil {
 			return
 		}
 	}
-	if tLen := len(t.complementaryProcs) + len(t.conf.Pipeline.Processors); tLen > 0 {
+	if tLen := len(t.complementaryProcs) + len(t.conf.Pipeline.Processors); tLen > 0 || t.conf.Pipeline.Lineage.Enabled {
 		if t.pipelineLayer, err = pipeline.New(
 			t.conf.Pipeline, t.manager, t.logger, t.stats, t.complementaryProcs...,
 		); err != nil {
@@ -172,12 +204,16 @@ func (t *Type) start() (err error) {
 	// Start chaining components
 	var nextTranChan <-chan types.Transaction
 
-	nextTranChan = t.inputLayer.TransactionChan()
+	t.inputTracker, nextTranChan = newInFlightTracker(
+		t.inputLayer.TransactionChan(), t.maxInFlight, t.trackerClose,
+	)
 	if t.bufferLayer != nil {
 		if err = t.bufferLayer.Consume(nextTranChan); err != nil {
 			return
 		}
-		nextTranChan = t.bufferLayer.TransactionChan()
+		t.bufferTracker, nextTranChan = newInFlightTracker(
+			t.bufferLayer.TransactionChan(), t.maxInFlight, t.trackerClose,
+		)
 	}
 	if t.pipelineLayer != nil {
 		if err = t.pipelineLayer.Consume(nextTranChan); err != nil {
@@ -353,8 +389,13 @@ func (t *Type) stopUnordered(timeout time.Duration) (err error) {
 // Initially the attempt is graceful, but as the timeout draws close the attempt
 // becomes progressively less graceful.
 func (t *Type) Stop(timeout time.Duration) error {
-	tOutUnordered := timeout / 4
-	tOutGraceful := timeout - tOutUnordered
+	defer t.reportAbandoned()
+
+	tOutGraceful := timeout - timeout/4
+	if t.drainTimeout > 0 && t.drainTimeout < timeout {
+		tOutGraceful = t.drainTimeout
+	}
+	tOutUnordered := timeout - tOutGraceful
 
 	err := t.stopGracefully(tOutGraceful)
 	if err == nil {
@@ -384,4 +425,42 @@ func (t *Type) Stop(timeout time.Duration) error {
 	return err
 }
 
+// abandoned returns the number of messages that were consumed from the input
+// but have not been acknowledged, and the number of messages that were stored
+// in the buffer but have not been delivered.
+func (t *Type) abandoned() (inFlight, buffered int64) {
+	if t.inputTracker == nil {
+		return
+	}
+	inFlight = t.inputTracker.Pending()
+	if t.bufferTracker != nil {
+		inFlight += t.bufferTracker.Pending()
+		buffered = t.inputTracker.Acked() - t.bufferTracker.Acked() - t.bufferTracker.Pending()
+		if buffered < 0 {
+			buffered = 0
+		}
+	}
+	return
+}
+
+// reportAbandoned logs the number of messages abandoned by the stream once it
+// has stopped, and then ceases to track responses.
+func (t *Type) reportAbandoned() {
+	t.trackerOnce.Do(func() {
+		inFlight, buffered := t.abandoned()
+		close(t.trackerClose)
+
+		t.stats.GetCounter("stream.shutdown.abandoned.in_flight").Incr(inFlight)
+		t.stats.GetCounter("stream.shutdown.abandoned.buffered").Incr(buffered)
+		if inFlight > 0 || buffered > 0 {
+			t.logger.Warnf(
+				"Stream stopped with %v in-flight messages unacknowledged and %v buffered messages undelivered.\n",
+				inFlight, buffered,
+			)
+		} else {
+			t.logger.Infoln("Stream stopped with all messages delivered.")
+		}
+	})
+}
+
 //------------------------------------------------------------------------------
@@ -0,0 +1,252 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package test
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/Jeffail/benthos/lib/log"
+	"github.com/Jeffail/benthos/lib/manager"
+	"github.com/Jeffail/benthos/lib/message"
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/processor"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/text"
+	"gopkg.in/yaml.v2"
+)
+
+//------------------------------------------------------------------------------
+
+var (
+	logger = log.Noop()
+	stats  = metrics.Noop()
+)
+
+// InputPart is a message part of an input batch.
+type InputPart struct {
+	Content  string            `json:"content" yaml:"content"`
+	Metadata map[string]string `json:"metadata" yaml:"metadata"`
+}
+
+// Case is a single test, where an input batch is sent through the processors
+// of a config and the resulting batches are checked against conditions.
+type Case struct {
+	Name             string              `json:"name" yaml:"name"`
+	Environment      map[string]string   `json:"environment" yaml:"environment"`
+	TargetProcessors string              `json:"target_processors" yaml:"target_processors"`
+	InputBatch       []InputPart         `json:"input_batch" yaml:"input_batch"`
+	OutputBatches    [][]OutputCondition `json:"output_batches" yaml:"output_batches"`
+}
+
+// NewCase returns a test case with default values.
+func NewCase() Case {
+	return Case{
+		Environment:      map[string]string{},
+		TargetProcessors: "/pipeline/processors",
+		InputBatch:       []InputPart{},
+		OutputBatches:    [][]OutputCondition{},
+	}
+}
+
+// UnmarshalYAML ensures that when parsing test cases that are in a slice the
+// default values are still applied.
+func (c *Case) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type caseAlias Case
+	aliased := caseAlias(NewCase())
+
+	if err := unmarshal(&aliased); err != nil {
+		return err
+	}
+
+	*c = Case(aliased)
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+// Execute runs the test case against the config file at confPath and returns
+// a description of each failure. An error is returned if the test could not be
+// run.
+func (c Case) Execute(confPath string) ([]string, error) {
+	restoreEnv := setEnvironment(c.Environment)
+	defer restoreEnv()
+
+	confBytes, err := ioutil.ReadFile(confPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %v", err)
+	}
+	confBytes = text.ReplaceEnvVariables(confBytes)
+
+	var root interface{}
+	if err = yaml.Unmarshal(confBytes, &root); err != nil {
+		return nil, fmt.Errorf("failed to parse config: %v", err)
+	}
+
+	mgrConf := manager.NewConfig()
+	if err = resolveInto(root, "/resources", &mgrConf); err != nil && err != errPathNotFound {
+		return nil, fmt.Errorf("failed to parse resources: %v", err)
+	}
+	mgr, err := manager.New(mgrConf, noopAPIReg{}, logger, stats)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create resources: %v", err)
+	}
+
+	var procConfs []processor.Config
+	if err = resolveInto(root, c.TargetProcessors, &procConfs); err != nil {
+		return nil, fmt.Errorf("failed to parse target processors '%v': %v", c.TargetProcessors, err)
+	}
+	procs := make([]processor.Type, 0, len(procConfs))
+	for i, conf := range procConfs {
+		proc, err := processor.New(conf, mgr, logger, stats)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create processor %v: %v", i, err)
+		}
+		procs = append(procs, proc)
+	}
+
+	checkers := make([][]*outputChecker, len(c.OutputBatches))
+	for i, batch := range c.OutputBatches {
+		for j, conf := range batch {
+			checker, err := newOutputChecker(conf, mgr)
+			if err != nil {
+				return nil, fmt.Errorf("output batch %v message %v: %v", i, j, err)
+			}
+			checkers[i] = append(checkers[i], checker)
+		}
+	}
+
+	msg := message.New(nil)
+	for _, input := range c.InputBatch {
+		part := message.NewPart([]byte(input.Content))
+		for k, v := range input.Metadata {
+			part.Metadata().Set(k, v)
+		}
+		msg.Append(part)
+	}
+
+	results := []types.Message{msg}
+	for i := 0; len(results) > 0 && i < len(procs); i++ {
+		var nextResults []types.Message
+		for _, m := range results {
+			rMsgs, _ := procs[i].ProcessMessage(m)
+			nextResults = append(nextResults, rMsgs...)
+		}
+		results = nextResults
+	}
+
+	var failures []string
+	if exp, act := len(checkers), len(results); exp != act {
+		failures = append(failures, fmt.Sprintf(
+			"wrong batch count: expected %v, received %v", exp, act,
+		))
+	}
+	for i, batchCheckers := range checkers {
+		if i >= len(results) {
+			break
+		}
+		if exp, act := len(batchCheckers), results[i].Len(); exp != act {
+			failures = append(failures, fmt.Sprintf(
+				"batch %v: wrong message count: expected %v, received %v", i, exp, act,
+			))
+		}
+		for j, checker := range batchCheckers {
+			if j >= results[i].Len() {
+				break
+			}
+			for _, f := range checker.Check(results[i].Get(j)) {
+				failures = append(failures, fmt.Sprintf("batch %v message %v: %v", i, j, f))
+			}
+		}
+	}
+	return failures, nil
+}
+
+//------------------------------------------------------------------------------
+
+type noopAPIReg struct{}
+
+func (noopAPIReg) RegisterEndpoint(path, desc string, h http.HandlerFunc) {}
+
+// setEnvironment sets environment variables and returns a func that restores
+// their previous values.
+func setEnvironment(env map[string]string) func() {
+	type prevValue struct {
+		value  string
+		exists bool
+	}
+	prev := map[string]prevValue{}
+	for k, v := range env {
+		value, exists := os.LookupEnv(k)
+		prev[k] = prevValue{value, exists}
+		os.Setenv(k, v)
+	}
+	return func() {
+		for k, v := range prev {
+			if v.exists {
+				os.Setenv(k, v.value)
+			} else {
+				os.Unsetenv(k)
+			}
+		}
+	}
+}
+
+var errPathNotFound = errors.New("path not found")
+
+// resolveInto finds the node of a parsed config at a path, which is a JSON
+// pointer such as `/pipeline/processors`, and parses it into target.
+func resolveInto(root interface{}, path string, target interface{}) error {
+	node := root
+	for _, seg := range strings.Split(strings.Trim(path, "/"), "/") {
+		if len(seg) == 0 {
+			continue
+		}
+		seg = strings.Replace(strings.Replace(seg, "~1", "/", -1), "~0", "~", -1)
+		switch t := node.(type) {
+		case map[interface{}]interface{}:
+			var exists bool
+			if node, exists = t[seg]; !exists {
+				return errPathNotFound
+			}
+		case []interface{}:
+			i, err := strconv.Atoi(seg)
+			if err != nil || i < 0 || i >= len(t) {
+				return errPathNotFound
+			}
+			node = t[i]
+		default:
+			return errPathNotFound
+		}
+	}
+	nodeBytes, err := yaml.Marshal(node)
+	if err != nil {
+		return err
+	}
+	return yaml.Unmarshal(nodeBytes, target)
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,142 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+func writeTestFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestCaseExecute(t *testing.T) {
+	dir, err := ioutil.TempDir("", "benthos_test_case")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	confPath := writeTestFile(t, dir, "config.yaml", `
+input:
+  processors:
+  - type: text
+    text:
+      operator: prepend
+      value: "input "
+pipeline:
+  processors:
+  - type: text
+    text:
+      operator: append
+      value: " ${FOO_SUFFIX:default}"
+  - type: dedupe
+    dedupe:
+      cache: foocache
+      key: ${!json_field:id}
+  - type: split
+resources:
+  caches:
+    foocache:
+      type: memory
+`)
+
+	caseConf := `
+name: foo
+environment:
+  FOO_SUFFIX: bar
+input_batch:
+- content: '{"id":"a"}'
+  metadata:
+    foo: bar
+- content: '{"id":"b"}'
+output_batches:
+- - content_equals: '{"id":"a"} bar'
+    metadata_equals:
+      foo: bar
+- - content_matches: '^\{"id":"c"\}'
+    metadata_equals:
+      foo: baz
+    condition:
+      type: text
+      text:
+        operator: contains
+        arg: nope
+- - content_equals: 'nope'
+`
+
+	var c Case
+	if err = yaml.Unmarshal([]byte(caseConf), &c); err != nil {
+		t.Fatal(err)
+	}
+	if exp, act := "/pipeline/processors", c.TargetProcessors; exp != act {
+		t.Errorf("Wrong default target: %v != %v", act, exp)
+	}
+
+	failures, err := c.Execute(confPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exp := []string{
+		"wrong batch count: expected 3, received 2",
+		`batch 1 message 0: content_matches: expected to match "^\\{\"id\":\"c\"\\}", received "{\"id\":\"b\"} bar"`,
+		`batch 1 message 0: metadata_equals: expected key foo to be "baz", received ""`,
+		"batch 1 message 0: condition: not met",
+	}
+	if !reflect.DeepEqual(exp, failures) {
+		t.Errorf("Wrong failures: %q != %q", failures, exp)
+	}
+
+	if _, exists := os.LookupEnv("FOO_SUFFIX"); exists {
+		t.Error("Environment variable was not restored")
+	}
+
+	c.TargetProcessors = "/input/processors"
+	c.OutputBatches = [][]OutputCondition{{{
+		JSONEquals: map[interface{}]interface{}{"id": "a"},
+	}, {}}}
+	if failures, err = c.Execute(confPath); err != nil {
+		t.Fatal(err)
+	}
+	exp = []string{
+		`batch 0 message 0: json_equals: failed to parse message as JSON: invalid character 'i' looking for beginning of value`,
+	}
+	if !reflect.DeepEqual(exp, failures) {
+		t.Errorf("Wrong failures: %q != %q", failures, exp)
+	}
+
+	c.TargetProcessors = "/output/processors"
+	if _, err = c.Execute(confPath); err == nil {
+		t.Error("Expected error from missing target processors")
+	}
+}
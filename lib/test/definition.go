@@ -0,0 +1,187 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package test
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+//------------------------------------------------------------------------------
+
+// DefaultSuffix is the suffix added to the name of a config file, less its
+// extension, in order to find the test definition of the config. For example,
+// the tests of `foo.yaml` are defined in `foo_benthos_test.yaml`.
+const DefaultSuffix = "_benthos_test"
+
+// Definition is a set of test cases for a config file.
+type Definition struct {
+	Cases []Case `json:"tests" yaml:"tests"`
+}
+
+// ReadDefinition parses a test definition file.
+func ReadDefinition(path string) (Definition, error) {
+	var def Definition
+	defBytes, err := ioutil.ReadFile(path)
+	if err != nil {
+		return def, err
+	}
+	if err = yaml.UnmarshalStrict(defBytes, &def); err != nil {
+		return def, err
+	}
+	return def, nil
+}
+
+// Execute runs each test case of the definition against a config file and
+// returns a description of each failure prefixed with the case it belongs to.
+func (d Definition) Execute(confPath string) []string {
+	var failures []string
+	for i, c := range d.Cases {
+		name := fmt.Sprintf("%v [%v]", c.Name, i)
+		caseFailures, err := c.Execute(confPath)
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("%v: %v", name, err))
+			continue
+		}
+		for _, f := range caseFailures {
+			failures = append(failures, fmt.Sprintf("%v: %v", name, f))
+		}
+	}
+	return failures
+}
+
+//------------------------------------------------------------------------------
+
+// resolveTestPaths returns a map of test definition paths to the config paths
+// they test. Each path can be a config file, a test definition file, or a
+// directory that is walked for test definition files.
+func resolveTestPaths(paths []string, suffix string) (map[string]string, error) {
+	tests := map[string]string{}
+	for _, path := range paths {
+		path = filepath.Clean(strings.TrimSuffix(path, "/..."))
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			if confPath, isDef := configOfDefinition(path, suffix); isDef {
+				tests[path] = confPath
+			} else {
+				tests[definitionOfConfig(path, suffix)] = path
+			}
+			continue
+		}
+		err = filepath.Walk(path, func(p string, info os.FileInfo, werr error) error {
+			if werr != nil {
+				return werr
+			}
+			if info.IsDir() {
+				return nil
+			}
+			if confPath, isDef := configOfDefinition(p, suffix); isDef {
+				tests[p] = confPath
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return tests, nil
+}
+
+// definitionOfConfig returns the path of the test definition for a config.
+func definitionOfConfig(confPath, suffix string) string {
+	ext := filepath.Ext(confPath)
+	return strings.TrimSuffix(confPath, ext) + suffix + ".yaml"
+}
+
+// configOfDefinition returns the path of the config that a test definition
+// tests, and false if the path is not a test definition.
+func configOfDefinition(defPath, suffix string) (string, bool) {
+	ext := filepath.Ext(defPath)
+	if ext != ".yaml" && ext != ".yml" {
+		return "", false
+	}
+	base := strings.TrimSuffix(defPath, ext)
+	if !strings.HasSuffix(base, suffix) {
+		return "", false
+	}
+	base = strings.TrimSuffix(base, suffix)
+	for _, confExt := range []string{".yaml", ".yml", ".json"} {
+		if _, err := os.Stat(base + confExt); err == nil {
+			return base + confExt, true
+		}
+	}
+	return base + ".yaml", true
+}
+
+// Run executes the tests for each path, which can be a config file, a test
+// definition file, or a directory that is walked for test definition files. A
+// report of the results is written to w, and true is returned if all tests
+// passed.
+func Run(w io.Writer, paths []string, suffix string) bool {
+	tests, err := resolveTestPaths(paths, suffix)
+	if err != nil {
+		fmt.Fprintf(w, "Failed to find tests: %v\n", err)
+		return false
+	}
+	if len(tests) == 0 {
+		fmt.Fprintln(w, "No tests were found.")
+		return false
+	}
+
+	defPaths := make([]string, 0, len(tests))
+	for defPath := range tests {
+		defPaths = append(defPaths, defPath)
+	}
+	sort.Strings(defPaths)
+
+	passed := true
+	for _, defPath := range defPaths {
+		def, err := ReadDefinition(defPath)
+		if err != nil {
+			fmt.Fprintf(w, "FAIL: %v\n  Failed to read test definition: %v\n", defPath, err)
+			passed = false
+			continue
+		}
+		failures := def.Execute(tests[defPath])
+		if len(failures) == 0 {
+			fmt.Fprintf(w, "PASS: %v\n", defPath)
+			continue
+		}
+		passed = false
+		fmt.Fprintf(w, "FAIL: %v\n", defPath)
+		for _, f := range failures {
+			fmt.Fprintf(w, "  %v\n", f)
+		}
+	}
+	return passed
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,104 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	dir, err := ioutil.TempDir("", "benthos_test_run")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err = os.Mkdir(filepath.Join(dir, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	conf := `
+pipeline:
+  processors:
+  - type: text
+    text:
+      operator: trim_space
+`
+	writeTestFile(t, dir, "foo.yaml", conf)
+	writeTestFile(t, dir, "foo_benthos_test.yaml", `
+tests:
+- name: trims
+  input_batch:
+  - content: '  foo  '
+  output_batches:
+  - - content_equals: foo
+`)
+	barConf := writeTestFile(t, dir, "sub/bar.json", `{"pipeline":{"processors":[{"type":"noop"}]}}`)
+	barDef := writeTestFile(t, dir, "sub/bar_benthos_test.yaml", `
+tests:
+- name: fails
+  input_batch:
+  - content: bar
+  output_batches:
+  - - content_equals: baz
+`)
+
+	buf := bytes.Buffer{}
+	if !Run(&buf, []string{filepath.Join(dir, "foo.yaml")}, DefaultSuffix) {
+		t.Errorf("Expected tests to pass: %s", buf.Bytes())
+	}
+
+	tests, err := resolveTestPaths([]string{dir + "/..."}, DefaultSuffix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp, act := 2, len(tests); exp != act {
+		t.Errorf("Wrong count of tests: %v != %v", act, exp)
+	}
+	if exp, act := barConf, tests[barDef]; exp != act {
+		t.Errorf("Wrong config path: %v != %v", act, exp)
+	}
+
+	buf.Reset()
+	if Run(&buf, []string{dir}, DefaultSuffix) {
+		t.Error("Expected tests to fail")
+	}
+	exp := "PASS: " + filepath.Join(dir, "foo_benthos_test.yaml") + "\n" +
+		"FAIL: " + barDef + "\n" +
+		"  fails [0]: batch 0 message 0: content_equals: expected \"baz\", received \"bar\"\n"
+	if act := buf.String(); exp != act {
+		t.Errorf("Wrong report: %v != %v", act, exp)
+	}
+
+	writeTestFile(t, dir, "sub/bar_benthos_test.yaml", `
+tests:
+- name: typo
+  input_bath: []
+`)
+	buf.Reset()
+	if Run(&buf, []string{barDef}, DefaultSuffix) {
+		t.Error("Expected tests to fail")
+	}
+}
@@ -0,0 +1,299 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package integration
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/input/reader"
+	"github.com/Jeffail/benthos/lib/message"
+	"github.com/Jeffail/benthos/lib/output/writer"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/ory/dockertest"
+)
+
+// This file contains helpers for testing pairs of components against a real
+// service. A typical integration test looks like this:
+//
+//	func TestFooIntegration(t *testing.T) {
+//		pool := newDockerPool(t)
+//		resource, purge := runDockerResource(t, pool, &dockertest.RunOptions{
+//			Repository: "foo",
+//			Tag:        "latest",
+//		}, func(r *dockertest.Resource) error {
+//			return pingFoo(r.GetPort("1234/tcp"))
+//		})
+//		defer purge()
+//
+//		runRoundTripTests(t, roundTripSpec{
+//			newOutput: func(id string) (writer.Type, error) { ... },
+//			newInput:  func(id string) (reader.Type, error) { ... },
+//		})
+//	}
+
+//------------------------------------------------------------------------------
+
+// newDockerPool skips the test when running in short mode or when docker is not
+// available, otherwise it marks the test as parallel and returns a pool for
+// running docker resources.
+func newDockerPool(t *testing.T) *dockertest.Pool {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	t.Parallel()
+
+	pool, err := dockertest.NewPool("")
+	if err != nil {
+		t.Skipf("Could not connect to docker: %s", err)
+	}
+	pool.MaxWait = time.Second * 30
+	return pool
+}
+
+// runDockerResource starts a docker resource and retries ready until it returns
+// nil. The returned function purges the resource and should be deferred.
+func runDockerResource(
+	t *testing.T,
+	pool *dockertest.Pool,
+	opts *dockertest.RunOptions,
+	ready func(r *dockertest.Resource) error,
+) (*dockertest.Resource, func()) {
+	resource, err := pool.RunWithOptions(opts)
+	if err != nil {
+		t.Fatalf("Could not start resource %v: %s", opts.Repository, err)
+	}
+
+	purge := func() {
+		if err := pool.Purge(resource); err != nil {
+			t.Logf("Failed to clean up docker resource: %v", err)
+		}
+	}
+
+	if err = pool.Retry(func() error {
+		return ready(resource)
+	}); err != nil {
+		purge()
+		t.Fatalf("Could not connect to docker resource %v: %s", opts.Repository, err)
+	}
+	return resource, purge
+}
+
+// containerName returns the name of the container of a resource, which can be
+// used for linking other resources to it.
+func containerName(r *dockertest.Resource) string {
+	return strings.TrimPrefix(r.Container.Name, "/")
+}
+
+//------------------------------------------------------------------------------
+
+var roundTripIDs int64
+
+// nextRoundTripID returns an identifier that is unique to this test run and is
+// valid as a topic, key, stream or bucket name for all tested services.
+func nextRoundTripID() string {
+	return fmt.Sprintf("benthos-test-%v-%v", time.Now().Unix(), atomic.AddInt64(&roundTripIDs, 1))
+}
+
+// roundTripSpec describes an output and input pair that are tested by writing
+// messages to the output and reading them back from the input.
+type roundTripSpec struct {
+	// newOutput creates an output that writes to the target identified by id,
+	// which is unique to each test and can be used to isolate topics, keys,
+	// buckets, etc.
+	newOutput func(id string) (writer.Type, error)
+
+	// newInput creates an input that reads from the target identified by id.
+	newInput func(id string) (reader.Type, error)
+
+	// writeFirst creates the input only after all messages have been written,
+	// for inputs that only consume data that exists when they connect.
+	writeFirst bool
+
+	// metadata indicates that metadata is expected to survive the round trip.
+	metadata bool
+
+	// timeout is the maximum time to wait for all messages to be read back,
+	// defaults to thirty seconds.
+	timeout time.Duration
+}
+
+// runRoundTripTests runs each of the round trip tests against a spec.
+func runRoundTripTests(t *testing.T, spec roundTripSpec) {
+	t.Run("SinglePart", func(te *testing.T) {
+		testRoundTrip(te, spec, 1)
+	})
+	t.Run("MultiPart", func(te *testing.T) {
+		testRoundTrip(te, spec, 3)
+	})
+	t.Run("Close", func(te *testing.T) {
+		testRoundTripClose(te, spec)
+	})
+}
+
+func connectOutput(t *testing.T, spec roundTripSpec, id string) writer.Type {
+	out, err := spec.newOutput(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = out.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+func connectInput(t *testing.T, spec roundTripSpec, id string) reader.Type {
+	in, err := spec.newInput(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = in.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	return in
+}
+
+func closeComponent(t *testing.T, c types.Closable) {
+	c.CloseAsync()
+	if err := c.WaitForClose(time.Second * 5); err != nil {
+		t.Error(err)
+	}
+}
+
+// testRoundTrip writes ten messages each of a number of parts to the output and
+// checks that every part is read back from the input, in any order and in any
+// grouping. When the spec expects metadata each part is also checked for the
+// metadata it was written with.
+func testRoundTrip(t *testing.T, spec roundTripSpec, parts int) {
+	id := nextRoundTripID()
+
+	out := connectOutput(t, spec, id)
+	defer closeComponent(t, out)
+
+	var in reader.Type
+	if !spec.writeFirst {
+		in = connectInput(t, spec, id)
+		defer closeComponent(t, in)
+	}
+
+	N := 10
+
+	expected := map[string]struct{}{}
+	for i := 0; i < N; i++ {
+		msg := message.New(nil)
+		for j := 0; j < parts; j++ {
+			content := fmt.Sprintf("hello world: %v part %v", i, j)
+			expected[content] = struct{}{}
+
+			part := message.NewPart([]byte(content))
+			part.Metadata().Set("benthos_test", content)
+			msg.Append(part)
+		}
+		if err := out.Write(msg); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if spec.writeFirst {
+		in = connectInput(t, spec, id)
+		defer closeComponent(t, in)
+	}
+
+	timeout := spec.timeout
+	if timeout == 0 {
+		timeout = time.Second * 30
+	}
+	deadline := time.Now().Add(timeout)
+
+	for len(expected) > 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out waiting for %v remaining parts", len(expected))
+		}
+
+		msg, err := in.Read()
+		if err == types.ErrTimeout {
+			continue
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		msg.Iter(func(i int, p types.Part) error {
+			act := string(p.Get())
+			if _, exists := expected[act]; !exists {
+				t.Errorf("Unexpected part: %v", act)
+			}
+			delete(expected, act)
+			if spec.metadata {
+				if meta := p.Metadata().Get("benthos_test"); meta != act {
+					t.Errorf("Wrong metadata returned: %v != %v", meta, act)
+				}
+			}
+			return nil
+		})
+
+		if err = in.Acknowledge(nil); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+// testRoundTripClose checks that connected components close in a timely manner
+// whilst the input is blocked on a read.
+func testRoundTripClose(t *testing.T, spec roundTripSpec) {
+	id := nextRoundTripID()
+
+	out := connectOutput(t, spec, id)
+	in := connectInput(t, spec, id)
+
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			if _, err := in.Read(); err != nil && err != types.ErrTimeout {
+				return
+			}
+		}
+	}()
+
+	<-time.After(time.Millisecond * 100)
+	closeComponent(t, in)
+	closeComponent(t, out)
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second * 30):
+		t.Error("Timed out waiting for blocked read to return")
+	}
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,93 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package integration
+
+import (
+	"testing"
+
+	"github.com/Jeffail/benthos/lib/input/reader"
+	"github.com/Jeffail/benthos/lib/log"
+	"github.com/Jeffail/benthos/lib/message/metadata"
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/output/writer"
+	"github.com/Shopify/sarama"
+	"github.com/ory/dockertest"
+	"github.com/ory/dockertest/docker"
+)
+
+func TestKafkaIntegration(t *testing.T) {
+	pool := newDockerPool(t)
+
+	zookeeper, purgeZK := runDockerResource(t, pool, &dockertest.RunOptions{
+		Repository: "wurstmeister/zookeeper",
+		Tag:        "latest",
+	}, func(r *dockertest.Resource) error {
+		return nil
+	})
+	defer purgeZK()
+
+	// Kafka advertises its own address to clients and so the host port must
+	// match the advertised listener.
+	addresses := []string{"localhost:9092"}
+	_, purgeKafka := runDockerResource(t, pool, &dockertest.RunOptions{
+		Repository:   "wurstmeister/kafka",
+		Tag:          "latest",
+		Links:        []string{containerName(zookeeper) + ":zookeeper"},
+		ExposedPorts: []string{"9092"},
+		PortBindings: map[docker.Port][]docker.PortBinding{
+			"9092/tcp": {{HostIP: "", HostPort: "9092"}},
+		},
+		Env: []string{
+			"KAFKA_ZOOKEEPER_CONNECT=zookeeper:2181",
+			"KAFKA_LISTENERS=PLAINTEXT://0.0.0.0:9092",
+			"KAFKA_ADVERTISED_LISTENERS=PLAINTEXT://localhost:9092",
+			"KAFKA_AUTO_CREATE_TOPICS_ENABLE=true",
+		},
+	}, func(r *dockertest.Resource) error {
+		client, err := sarama.NewClient(addresses, sarama.NewConfig())
+		if err != nil {
+			return err
+		}
+		defer client.Close()
+		_, err = client.Partitions("benthos_ready")
+		return err
+	})
+	defer purgeKafka()
+
+	runRoundTripTests(t, roundTripSpec{
+		newOutput: func(id string) (writer.Type, error) {
+			conf := writer.NewKafkaConfig()
+			conf.Addresses = addresses
+			conf.Topic = id
+			conf.Metadata = metadata.NewMappingConfigAll()
+			return writer.NewKafka(conf, log.Noop(), metrics.Noop())
+		},
+		newInput: func(id string) (reader.Type, error) {
+			conf := reader.NewKafkaConfig()
+			conf.Addresses = addresses
+			conf.Topic = id
+			conf.ConsumerGroup = id
+			conf.StartOffset = "oldest"
+			return reader.NewKafka(conf, log.Noop(), metrics.Noop())
+		},
+		metadata: true,
+	})
+}
@@ -20,4 +20,8 @@
 
 // Package integration implements integration tests using docker. You can skip
 // these tests with the `--short` flag.
+//
+// Output and input pairs can be tested against each other with the round trip
+// helpers found in harness_test.go, which write messages to a real service and
+// check that they are read back intact.
 package integration
@@ -0,0 +1,104 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package integration
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/Jeffail/benthos/lib/input/reader"
+	"github.com/Jeffail/benthos/lib/log"
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/output/writer"
+	"github.com/go-redis/redis"
+	"github.com/ory/dockertest"
+)
+
+func TestRedisIntegration(t *testing.T) {
+	pool := newDockerPool(t)
+
+	var url string
+	_, purge := runDockerResource(t, pool, &dockertest.RunOptions{
+		Repository: "redis",
+		Tag:        "latest",
+	}, func(r *dockertest.Resource) error {
+		url = fmt.Sprintf("tcp://localhost:%v", r.GetPort("6379/tcp"))
+		client := redis.NewClient(&redis.Options{
+			Addr: fmt.Sprintf("localhost:%v", r.GetPort("6379/tcp")),
+		})
+		defer client.Close()
+		return client.Ping().Err()
+	})
+	defer purge()
+
+	t.Run("List", func(te *testing.T) {
+		runRoundTripTests(te, roundTripSpec{
+			newOutput: func(id string) (writer.Type, error) {
+				conf := writer.NewRedisListConfig()
+				conf.URL = url
+				conf.Key = id
+				return writer.NewRedisList(conf, log.Noop(), metrics.Noop())
+			},
+			newInput: func(id string) (reader.Type, error) {
+				conf := reader.NewRedisListConfig()
+				conf.URL = url
+				conf.Key = id
+				return reader.NewRedisList(conf, log.Noop(), metrics.Noop())
+			},
+		})
+	})
+
+	t.Run("PubSub", func(te *testing.T) {
+		runRoundTripTests(te, roundTripSpec{
+			newOutput: func(id string) (writer.Type, error) {
+				conf := writer.NewRedisPubSubConfig()
+				conf.URL = url
+				conf.Channel = id
+				return writer.NewRedisPubSub(conf, log.Noop(), metrics.Noop())
+			},
+			newInput: func(id string) (reader.Type, error) {
+				conf := reader.NewRedisPubSubConfig()
+				conf.URL = url
+				conf.Channels = []string{id}
+				return reader.NewRedisPubSub(conf, log.Noop(), metrics.Noop())
+			},
+		})
+	})
+
+	t.Run("Streams", func(te *testing.T) {
+		runRoundTripTests(te, roundTripSpec{
+			newOutput: func(id string) (writer.Type, error) {
+				conf := writer.NewRedisStreamsConfig()
+				conf.URL = url
+				conf.Stream = id
+				return writer.NewRedisStreams(conf, log.Noop(), metrics.Noop())
+			},
+			newInput: func(id string) (reader.Type, error) {
+				conf := reader.NewRedisStreamsConfig()
+				conf.URL = url
+				conf.Streams = []string{id}
+				conf.ConsumerGroup = id
+				return reader.NewRedisStreams(conf, log.Noop(), metrics.Noop())
+			},
+			metadata: true,
+		})
+	})
+}
@@ -0,0 +1,96 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package integration
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/Jeffail/benthos/lib/input/reader"
+	"github.com/Jeffail/benthos/lib/log"
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/output/writer"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/ory/dockertest"
+)
+
+func TestMinioIntegration(t *testing.T) {
+	pool := newDockerPool(t)
+
+	id, secret := "benthos_id", "benthos_secret"
+
+	var endpoint string
+	var client *s3.S3
+	_, purge := runDockerResource(t, pool, &dockertest.RunOptions{
+		Repository: "minio/minio",
+		Tag:        "latest",
+		Cmd:        []string{"server", "/data"},
+		Env: []string{
+			"MINIO_ACCESS_KEY=" + id,
+			"MINIO_SECRET_KEY=" + secret,
+		},
+	}, func(r *dockertest.Resource) error {
+		endpoint = fmt.Sprintf("http://localhost:%v", r.GetPort("9000/tcp"))
+		sess, err := session.NewSession(&aws.Config{
+			Region:           aws.String("eu-west-1"),
+			Endpoint:         aws.String(endpoint),
+			Credentials:      credentials.NewStaticCredentials(id, secret, ""),
+			S3ForcePathStyle: aws.Bool(true),
+		})
+		if err != nil {
+			return err
+		}
+		client = s3.New(sess)
+		_, err = client.ListBuckets(&s3.ListBucketsInput{})
+		return err
+	})
+	defer purge()
+
+	runRoundTripTests(t, roundTripSpec{
+		newOutput: func(bucket string) (writer.Type, error) {
+			if _, err := client.CreateBucket(&s3.CreateBucketInput{
+				Bucket: aws.String(bucket),
+			}); err != nil {
+				return nil, err
+			}
+			conf := writer.NewAmazonS3Config()
+			conf.Endpoint = endpoint
+			conf.Credentials.ID = id
+			conf.Credentials.Secret = secret
+			conf.Bucket = bucket
+			conf.ForcePathStyleURLs = true
+			return writer.NewAmazonS3(conf, log.Noop(), metrics.Noop()), nil
+		},
+		newInput: func(bucket string) (reader.Type, error) {
+			conf := reader.NewAmazonS3Config()
+			conf.Endpoint = endpoint
+			conf.Credentials.ID = id
+			conf.Credentials.Secret = secret
+			conf.Bucket = bucket
+			conf.ForcePathStyleURLs = true
+			return reader.NewAmazonS3(conf, log.Noop(), metrics.Noop()), nil
+		},
+		writeFirst: true,
+	})
+}
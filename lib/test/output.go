@@ -0,0 +1,163 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package test
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+
+	"github.com/Jeffail/benthos/lib/message"
+	"github.com/Jeffail/benthos/lib/processor/condition"
+	"github.com/Jeffail/benthos/lib/types"
+)
+
+//------------------------------------------------------------------------------
+
+// OutputCondition describes the conditions that a message part of an output
+// batch must meet. Only the conditions that are set are checked.
+type OutputCondition struct {
+	ContentEquals  *string           `json:"content_equals" yaml:"content_equals"`
+	ContentMatches *string           `json:"content_matches" yaml:"content_matches"`
+	JSONEquals     interface{}       `json:"json_equals" yaml:"json_equals"`
+	MetadataEquals map[string]string `json:"metadata_equals" yaml:"metadata_equals"`
+	Condition      *condition.Config `json:"condition" yaml:"condition"`
+}
+
+// outputChecker checks message parts against an OutputCondition.
+type outputChecker struct {
+	conf    OutputCondition
+	matches *regexp.Regexp
+	cond    types.Condition
+}
+
+func newOutputChecker(conf OutputCondition, mgr types.Manager) (*outputChecker, error) {
+	c := &outputChecker{conf: conf}
+	if conf.ContentMatches != nil {
+		var err error
+		if c.matches, err = regexp.Compile(*conf.ContentMatches); err != nil {
+			return nil, fmt.Errorf("failed to compile content_matches: %v", err)
+		}
+	}
+	if conf.Condition != nil {
+		var err error
+		if c.cond, err = condition.New(*conf.Condition, mgr, logger, stats); err != nil {
+			return nil, fmt.Errorf("failed to create condition: %v", err)
+		}
+	}
+	return c, nil
+}
+
+// Check returns a description of each condition that the part fails to meet.
+func (c *outputChecker) Check(part types.Part) []string {
+	var failures []string
+
+	content := part.Get()
+	if c.conf.ContentEquals != nil && string(content) != *c.conf.ContentEquals {
+		failures = append(failures, fmt.Sprintf(
+			"content_equals: expected %q, received %q",
+			*c.conf.ContentEquals, content,
+		))
+	}
+	if c.matches != nil && !c.matches.Match(content) {
+		failures = append(failures, fmt.Sprintf(
+			"content_matches: expected to match %q, received %q",
+			*c.conf.ContentMatches, content,
+		))
+	}
+	if c.conf.JSONEquals != nil {
+		if err := checkJSONEquals(c.conf.JSONEquals, part); err != nil {
+			failures = append(failures, fmt.Sprintf("json_equals: %v", err))
+		}
+	}
+	if len(c.conf.MetadataEquals) > 0 {
+		keys := make([]string, 0, len(c.conf.MetadataEquals))
+		for k := range c.conf.MetadataEquals {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		meta := part.Metadata()
+		for _, k := range keys {
+			if exp, act := c.conf.MetadataEquals[k], meta.Get(k); exp != act {
+				failures = append(failures, fmt.Sprintf(
+					"metadata_equals: expected key %v to be %q, received %q",
+					k, exp, act,
+				))
+			}
+		}
+	}
+	if c.cond != nil {
+		msg := message.New(nil)
+		msg.Append(part.Copy())
+		if !c.cond.Check(msg) {
+			failures = append(failures, "condition: not met")
+		}
+	}
+	return failures
+}
+
+func checkJSONEquals(exp interface{}, part types.Part) error {
+	expBytes, err := json.Marshal(jsonCompatible(exp))
+	if err != nil {
+		return fmt.Errorf("failed to marshal expected value: %v", err)
+	}
+	act, err := part.JSON()
+	if err != nil {
+		return fmt.Errorf("failed to parse message as JSON: %v", err)
+	}
+	actBytes, err := json.Marshal(act)
+	if err != nil {
+		return err
+	}
+	if string(expBytes) != string(actBytes) {
+		return fmt.Errorf("expected %s, received %s", expBytes, actBytes)
+	}
+	return nil
+}
+
+// jsonCompatible converts maps parsed from YAML into maps that can be
+// marshalled as JSON.
+func jsonCompatible(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(t))
+		for k, v := range t {
+			m[fmt.Sprintf("%v", k)] = jsonCompatible(v)
+		}
+		return m
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(t))
+		for k, v := range t {
+			m[k] = jsonCompatible(v)
+		}
+		return m
+	case []interface{}:
+		s := make([]interface{}, len(t))
+		for i, v := range t {
+			s[i] = jsonCompatible(v)
+		}
+		return s
+	}
+	return v
+}
+
+//------------------------------------------------------------------------------
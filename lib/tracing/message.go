@@ -0,0 +1,134 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package tracing
+
+import (
+	"github.com/Jeffail/benthos/lib/types"
+)
+
+//------------------------------------------------------------------------------
+
+// MetadataKey is the metadata key of message parts that span contexts are
+// propagated under.
+const MetadataKey = "traceparent"
+
+// Extract attempts to obtain the span context that is carried within the
+// metadata of a message part as it passes through Benthos. Keys set from HTTP
+// headers are canonicalised and are therefore also checked.
+func Extract(meta types.Metadata) (SpanContext, bool) {
+	v := meta.Get(MetadataKey)
+	if len(v) == 0 {
+		v = meta.Get("Traceparent")
+	}
+	if len(v) == 0 {
+		return SpanContext{}, false
+	}
+	ctx, err := ParseTraceParent(v)
+	if err != nil {
+		return SpanContext{}, false
+	}
+	return ctx, true
+}
+
+// Inject sets the span context carried within the metadata of a message part.
+func Inject(ctx SpanContext, meta types.Metadata) {
+	meta.Delete("Traceparent")
+	meta.Set(MetadataKey, ctx.TraceParent())
+}
+
+//------------------------------------------------------------------------------
+
+func startSpans(operation string, msg types.Message, inject bool) []*Span {
+	tracer := Global()
+	if _, isNoop := tracer.(Noop); isNoop || msg == nil || msg.Len() == 0 {
+		return nil
+	}
+	spans := make([]*Span, msg.Len())
+	msg.Iter(func(i int, p types.Part) error {
+		meta := p.Metadata()
+		var span *Span
+		if parent, exists := Extract(meta); exists {
+			span = tracer.StartSpan(operation, &parent)
+		} else {
+			span = tracer.StartSpan(operation, nil)
+		}
+		if inject && span != nil {
+			Inject(span.Context(), meta)
+		}
+		spans[i] = span
+		return nil
+	})
+	return spans
+}
+
+// InitInputSpans creates a span for each part of a message consumed by an input
+// component, continuing any trace found within the metadata of a part using
+// the propagation formats configured for the component. The metadata keys of
+// all propagation formats are then replaced with the context of the new span.
+// Returns nil when tracing is disabled.
+func InitInputSpans(component string, msg types.Message) []*Span {
+	tracer := Global()
+	if _, isNoop := tracer.(Noop); isNoop || msg == nil || msg.Len() == 0 {
+		return nil
+	}
+	prop := getPropagator()
+	spans := make([]*Span, msg.Len())
+	msg.Iter(func(i int, p types.Part) error {
+		meta := p.Metadata()
+		var span *Span
+		if parent, exists := prop.Extract(component, meta); exists {
+			span = tracer.StartSpan("input."+component, &parent)
+		} else {
+			span = tracer.StartSpan("input."+component, nil)
+		}
+		prop.Strip(meta)
+		if span != nil {
+			Inject(span.Context(), meta)
+		}
+		spans[i] = span
+		return nil
+	})
+	return spans
+}
+
+// InitSpans creates a span for each part of a message, continuing any trace
+// found within the metadata of a part, and sets the context of the new span
+// within the metadata of the part so that subsequent spans become its
+// children. Returns nil when tracing is disabled.
+func InitSpans(operation string, msg types.Message) []*Span {
+	return startSpans(operation, msg, true)
+}
+
+// ChildSpans creates a span for each part of a message that is a child of the
+// span found within the metadata of the part, without modifying the metadata.
+// Returns nil when tracing is disabled.
+func ChildSpans(operation string, msg types.Message) []*Span {
+	return startSpans(operation, msg, false)
+}
+
+// FinishSpans finishes a slice of spans, marking each as failed if err is not
+// nil.
+func FinishSpans(spans []*Span, err error) {
+	for _, s := range spans {
+		s.SetError(err).Finish()
+	}
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,120 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package tracing
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/Jeffail/benthos/lib/message"
+)
+
+type testTracer struct {
+	spanStarter
+
+	mut      sync.Mutex
+	finished []*Span
+}
+
+func newTestTracer() *testTracer {
+	t := &testTracer{}
+	t.spanStarter = spanStarter{
+		sampling: NewSamplingConfig(),
+		onFinish: func(s *Span) {
+			t.mut.Lock()
+			t.finished = append(t.finished, s)
+			t.mut.Unlock()
+		},
+	}
+	return t
+}
+
+func (t *testTracer) Close() error {
+	return nil
+}
+
+func TestMessageSpansDisabled(t *testing.T) {
+	msg := message.New([][]byte{[]byte("foo")})
+	if spans := InitSpans("foo", msg); spans != nil {
+		t.Errorf("Expected nil spans, received: %v", spans)
+	}
+	if v := msg.Get(0).Metadata().Get(MetadataKey); len(v) > 0 {
+		t.Errorf("Expected empty metadata, received: %v", v)
+	}
+}
+
+func TestMessageSpans(t *testing.T) {
+	tracer := newTestTracer()
+	SetGlobal(tracer)
+	defer SetGlobal(Noop{})
+
+	upstream := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+	msg := message.New([][]byte{[]byte("foo"), []byte("bar")})
+	msg.Get(1).Metadata().Set("Traceparent", upstream)
+
+	inputSpans := InitSpans("input", msg)
+	if len(inputSpans) != 2 {
+		t.Fatalf("Wrong count of spans: %v", len(inputSpans))
+	}
+	if inputSpans[0].hasParent {
+		t.Error("Expected first span to begin a new trace")
+	}
+	if exp, act := "4bf92f3577b34da6a3ce929d0e0e4736", inputSpans[1].Context().TraceID.String(); exp != act {
+		t.Errorf("Wrong trace ID: %v != %v", act, exp)
+	}
+	if exp, act := "00f067aa0ba902b7", inputSpans[1].parentID.String(); exp != act {
+		t.Errorf("Wrong parent ID: %v != %v", act, exp)
+	}
+	if v := msg.Get(1).Metadata().Get("Traceparent"); len(v) > 0 {
+		t.Errorf("Expected canonical key to be removed, received: %v", v)
+	}
+	for i, s := range inputSpans {
+		if exp, act := s.Context().TraceParent(), msg.Get(i).Metadata().Get(MetadataKey); exp != act {
+			t.Errorf("Wrong injected context: %v != %v", act, exp)
+		}
+	}
+
+	procSpans := ChildSpans("processor", msg)
+	for i, s := range procSpans {
+		if exp, act := inputSpans[i].Context().SpanID, s.parentID; exp != act {
+			t.Errorf("Wrong parent of processor span: %v != %v", act, exp)
+		}
+		if exp, act := inputSpans[i].Context().TraceParent(), msg.Get(i).Metadata().Get(MetadataKey); exp != act {
+			t.Errorf("Expected metadata to be unchanged: %v != %v", act, exp)
+		}
+	}
+
+	FinishSpans(procSpans, errors.New("nope"))
+	FinishSpans(inputSpans, nil)
+
+	tracer.mut.Lock()
+	defer tracer.mut.Unlock()
+	if exp, act := 4, len(tracer.finished); exp != act {
+		t.Fatalf("Wrong count of finished spans: %v != %v", act, exp)
+	}
+	if exp, act := "nope", tracer.finished[0].tags["error"]; exp != act {
+		t.Errorf("Wrong error tag: %v != %v", act, exp)
+	}
+	if _, exists := tracer.finished[2].tags["error"]; exists {
+		t.Error("Expected no error tag")
+	}
+}
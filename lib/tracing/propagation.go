@@ -0,0 +1,340 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package tracing
+
+import (
+	"encoding/hex"
+	"fmt"
+	"net/textproto"
+	"strings"
+	"sync"
+
+	"github.com/Jeffail/benthos/lib/types"
+)
+
+//------------------------------------------------------------------------------
+
+// String constants representing each propagation format.
+const (
+	// FormatW3C is the W3C Trace Context format, carried under the key
+	// traceparent.
+	FormatW3C = "w3c"
+
+	// FormatB3 is the single value B3 format, carried under the key b3.
+	FormatB3 = "b3"
+
+	// FormatB3Multi is the multiple value B3 format, carried under the keys
+	// X-B3-TraceId, X-B3-SpanId and X-B3-Sampled.
+	FormatB3Multi = "b3_multi"
+)
+
+// Keys used by each propagation format.
+const (
+	keyB3        = "b3"
+	keyB3TraceID = "X-B3-TraceId"
+	keyB3SpanID  = "X-B3-SpanId"
+	keyB3Parent  = "X-B3-ParentSpanId"
+	keyB3Sampled = "X-B3-Sampled"
+	keyB3Flags   = "X-B3-Flags"
+)
+
+var formatKeys = map[string][]string{
+	FormatW3C:     {MetadataKey},
+	FormatB3:      {keyB3},
+	FormatB3Multi: {keyB3TraceID, keyB3SpanID, keyB3Parent, keyB3Sampled, keyB3Flags},
+}
+
+//------------------------------------------------------------------------------
+
+// PropagationFormatsConfig lists the formats used for extracting span contexts
+// from messages as they are consumed, and for injecting them into messages as
+// they are produced.
+type PropagationFormatsConfig struct {
+	Extract []string `json:"extract" yaml:"extract"`
+	Inject  []string `json:"inject" yaml:"inject"`
+}
+
+// PropagationConfig contains configuration for how span contexts are
+// propagated across services. The formats of each component type can be
+// overridden by adding them to the components map.
+type PropagationConfig struct {
+	PropagationFormatsConfig `json:",inline" yaml:",inline"`
+	Components               map[string]PropagationFormatsConfig `json:"components" yaml:"components"`
+}
+
+// NewPropagationConfig creates a PropagationConfig struct with default values.
+func NewPropagationConfig() PropagationConfig {
+	return PropagationConfig{
+		PropagationFormatsConfig: PropagationFormatsConfig{
+			Extract: []string{FormatW3C, FormatB3, FormatB3Multi},
+			Inject:  []string{FormatW3C},
+		},
+		Components: map[string]PropagationFormatsConfig{},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// Propagator extracts and injects span contexts using the formats configured
+// for each component type.
+type Propagator struct {
+	formats    PropagationFormatsConfig
+	components map[string]PropagationFormatsConfig
+}
+
+func checkFormats(formats []string) error {
+	for _, f := range formats {
+		if _, exists := formatKeys[f]; !exists {
+			return fmt.Errorf("propagation format not recognised: %v", f)
+		}
+	}
+	return nil
+}
+
+// NewPropagator creates a new propagator from a configuration.
+func NewPropagator(conf PropagationConfig) (*Propagator, error) {
+	if err := checkFormats(conf.Extract); err != nil {
+		return nil, err
+	}
+	if err := checkFormats(conf.Inject); err != nil {
+		return nil, err
+	}
+	p := &Propagator{
+		formats:    conf.PropagationFormatsConfig,
+		components: map[string]PropagationFormatsConfig{},
+	}
+	for name, c := range conf.Components {
+		if err := checkFormats(c.Extract); err != nil {
+			return nil, fmt.Errorf("component '%v': %v", name, err)
+		}
+		if err := checkFormats(c.Inject); err != nil {
+			return nil, fmt.Errorf("component '%v': %v", name, err)
+		}
+		// Formats that are not set are inherited, an explicitly empty list
+		// disables propagation for the component.
+		if c.Extract == nil {
+			c.Extract = p.formats.Extract
+		}
+		if c.Inject == nil {
+			c.Inject = p.formats.Inject
+		}
+		p.components[name] = c
+	}
+	return p, nil
+}
+
+func (p *Propagator) formatsFor(component string) PropagationFormatsConfig {
+	if c, exists := p.components[component]; exists {
+		return c
+	}
+	return p.formats
+}
+
+//------------------------------------------------------------------------------
+
+// getKey returns the value of a metadata key, falling back to the canonical
+// header and lower case variants of the key.
+func getKey(meta types.Metadata, key string) string {
+	if v := meta.Get(key); len(v) > 0 {
+		return v
+	}
+	if v := meta.Get(textproto.CanonicalMIMEHeaderKey(key)); len(v) > 0 {
+		return v
+	}
+	return meta.Get(strings.ToLower(key))
+}
+
+func deleteKey(meta types.Metadata, key string) {
+	meta.Delete(key)
+	meta.Delete(textproto.CanonicalMIMEHeaderKey(key))
+	meta.Delete(strings.ToLower(key))
+}
+
+func parseB3TraceID(v string) (id TraceID, ok bool) {
+	if len(v) == 16 {
+		v = strings.Repeat("0", 16) + v
+	}
+	if len(v) != 32 {
+		return id, false
+	}
+	if _, err := hex.Decode(id[:], []byte(v)); err != nil {
+		return id, false
+	}
+	return id, id != (TraceID{})
+}
+
+func parseB3SpanID(v string) (id SpanID, ok bool) {
+	if len(v) != 16 {
+		return id, false
+	}
+	if _, err := hex.Decode(id[:], []byte(v)); err != nil {
+		return id, false
+	}
+	return id, id != (SpanID{})
+}
+
+// parseB3Sampled sets the sampling decision of a span context from a B3
+// sampling state, where an empty state defers the decision.
+func parseB3Sampled(ctx *SpanContext, v string) bool {
+	switch v {
+	case "":
+		ctx.deferred = true
+	case "1", "d", "true":
+		ctx.Sampled = true
+	case "0", "false":
+		ctx.Sampled = false
+	default:
+		return false
+	}
+	return true
+}
+
+func extractFormat(format string, meta types.Metadata) (SpanContext, bool) {
+	var ctx SpanContext
+	var ok bool
+
+	switch format {
+	case FormatW3C:
+		v := getKey(meta, MetadataKey)
+		if len(v) == 0 {
+			return ctx, false
+		}
+		var err error
+		ctx, err = ParseTraceParent(v)
+		return ctx, err == nil
+	case FormatB3:
+		parts := strings.Split(getKey(meta, keyB3), "-")
+		if len(parts) < 2 {
+			return ctx, false
+		}
+		if ctx.TraceID, ok = parseB3TraceID(parts[0]); !ok {
+			return ctx, false
+		}
+		if ctx.SpanID, ok = parseB3SpanID(parts[1]); !ok {
+			return ctx, false
+		}
+		sampled := ""
+		if len(parts) > 2 {
+			sampled = parts[2]
+		}
+		return ctx, parseB3Sampled(&ctx, sampled)
+	case FormatB3Multi:
+		if ctx.TraceID, ok = parseB3TraceID(getKey(meta, keyB3TraceID)); !ok {
+			return ctx, false
+		}
+		if ctx.SpanID, ok = parseB3SpanID(getKey(meta, keyB3SpanID)); !ok {
+			return ctx, false
+		}
+		if getKey(meta, keyB3Flags) == "1" {
+			ctx.Sampled = true
+			return ctx, true
+		}
+		return ctx, parseB3Sampled(&ctx, getKey(meta, keyB3Sampled))
+	}
+	return ctx, false
+}
+
+// Extract attempts to obtain a span context from the metadata of a message
+// part consumed by a component, using the first of its configured formats that
+// is present.
+func (p *Propagator) Extract(component string, meta types.Metadata) (SpanContext, bool) {
+	for _, f := range p.formatsFor(component).Extract {
+		if ctx, ok := extractFormat(f, meta); ok {
+			return ctx, true
+		}
+	}
+	return SpanContext{}, false
+}
+
+// Strip removes the keys of all propagation formats from metadata.
+func (p *Propagator) Strip(meta types.Metadata) {
+	for _, keys := range formatKeys {
+		for _, k := range keys {
+			deleteKey(meta, k)
+		}
+	}
+}
+
+// Headers returns the key/value pairs that carry a span context when it is
+// produced by a component, using each of its configured formats.
+func (p *Propagator) Headers(component string, ctx SpanContext) map[string]string {
+	formats := p.formatsFor(component).Inject
+	if len(formats) == 0 {
+		return nil
+	}
+	sampled := "0"
+	if ctx.Sampled {
+		sampled = "1"
+	}
+	headers := map[string]string{}
+	for _, f := range formats {
+		switch f {
+		case FormatW3C:
+			headers[MetadataKey] = ctx.TraceParent()
+		case FormatB3:
+			headers[keyB3] = ctx.TraceID.String() + "-" + ctx.SpanID.String() + "-" + sampled
+		case FormatB3Multi:
+			headers[keyB3TraceID] = ctx.TraceID.String()
+			headers[keyB3SpanID] = ctx.SpanID.String()
+			headers[keyB3Sampled] = sampled
+		}
+	}
+	return headers
+}
+
+//------------------------------------------------------------------------------
+
+var (
+	propMut    sync.RWMutex
+	propagator *Propagator
+)
+
+func init() {
+	propagator, _ = NewPropagator(NewPropagationConfig())
+}
+
+// SetPropagator sets the propagator used for extracting and injecting span
+// contexts throughout Benthos.
+func SetPropagator(p *Propagator) {
+	propMut.Lock()
+	propagator = p
+	propMut.Unlock()
+}
+
+func getPropagator() *Propagator {
+	propMut.RLock()
+	p := propagator
+	propMut.RUnlock()
+	return p
+}
+
+// InjectHeaders returns the key/value pairs that should be added to the
+// headers of a message produced by a component in order to propagate the span
+// context found within the metadata of a message part. Returns nil if the
+// metadata does not contain a span context.
+func InjectHeaders(component string, meta types.Metadata) map[string]string {
+	ctx, exists := Extract(meta)
+	if !exists {
+		return nil
+	}
+	return getPropagator().Headers(component, ctx)
+}
+
+//------------------------------------------------------------------------------
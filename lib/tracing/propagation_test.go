@@ -0,0 +1,241 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package tracing
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Jeffail/benthos/lib/message"
+	"github.com/Jeffail/benthos/lib/message/metadata"
+)
+
+func TestPropagatorExtract(t *testing.T) {
+	prop, err := NewPropagator(NewPropagationConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	traceID := "4bf92f3577b34da6a3ce929d0e0e4736"
+	spanID := "00f067aa0ba902b7"
+
+	tests := []struct {
+		name    string
+		meta    map[string]string
+		exists  bool
+		sampled bool
+		trace   string
+	}{
+		{
+			name:    "w3c",
+			meta:    map[string]string{"traceparent": "00-" + traceID + "-" + spanID + "-01"},
+			exists:  true,
+			sampled: true,
+			trace:   traceID,
+		},
+		{
+			name:    "b3 single",
+			meta:    map[string]string{"b3": traceID + "-" + spanID + "-0"},
+			exists:  true,
+			sampled: false,
+			trace:   traceID,
+		},
+		{
+			name:    "b3 single short trace",
+			meta:    map[string]string{"B3": "a3ce929d0e0e4736-" + spanID + "-1"},
+			exists:  true,
+			sampled: true,
+			trace:   "0000000000000000a3ce929d0e0e4736",
+		},
+		{
+			name: "b3 multi canonical",
+			meta: map[string]string{
+				"X-B3-Traceid": traceID,
+				"X-B3-Spanid":  spanID,
+				"X-B3-Sampled": "1",
+			},
+			exists:  true,
+			sampled: true,
+			trace:   traceID,
+		},
+		{
+			name: "b3 multi lower case debug",
+			meta: map[string]string{
+				"x-b3-traceid": traceID,
+				"x-b3-spanid":  spanID,
+				"x-b3-flags":   "1",
+			},
+			exists:  true,
+			sampled: true,
+			trace:   traceID,
+		},
+		{
+			name:   "b3 bad sampled",
+			meta:   map[string]string{"b3": traceID + "-" + spanID + "-x"},
+			exists: false,
+		},
+		{
+			name:   "b3 sampling only",
+			meta:   map[string]string{"b3": "0"},
+			exists: false,
+		},
+		{
+			name:   "none",
+			meta:   map[string]string{"foo": "bar"},
+			exists: false,
+		},
+	}
+
+	for _, test := range tests {
+		ctx, exists := prop.Extract("foo", metadata.New(test.meta))
+		if exists != test.exists {
+			t.Errorf("%v: Wrong exists result: %v != %v", test.name, exists, test.exists)
+			continue
+		}
+		if !exists {
+			continue
+		}
+		if ctx.Sampled != test.sampled {
+			t.Errorf("%v: Wrong sampled result: %v != %v", test.name, ctx.Sampled, test.sampled)
+		}
+		if act := ctx.TraceID.String(); act != test.trace {
+			t.Errorf("%v: Wrong trace ID: %v != %v", test.name, act, test.trace)
+		}
+		if act := ctx.SpanID.String(); act != spanID {
+			t.Errorf("%v: Wrong span ID: %v != %v", test.name, act, spanID)
+		}
+	}
+}
+
+func TestPropagatorComponents(t *testing.T) {
+	conf := NewPropagationConfig()
+	conf.Components["kafka"] = PropagationFormatsConfig{
+		Extract: []string{FormatB3Multi},
+		Inject:  []string{FormatB3Multi, FormatB3},
+	}
+	conf.Components["amqp"] = PropagationFormatsConfig{
+		Inject: []string{},
+	}
+	prop, err := NewPropagator(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	meta := metadata.New(map[string]string{
+		"traceparent": "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+	})
+	if _, exists := prop.Extract("kafka", meta); exists {
+		t.Error("Expected kafka component to ignore w3c")
+	}
+	ctx, exists := prop.Extract("amqp", meta)
+	if !exists {
+		t.Fatal("Expected amqp component to inherit extract formats")
+	}
+
+	exp := map[string]string{
+		"X-B3-TraceId": "4bf92f3577b34da6a3ce929d0e0e4736",
+		"X-B3-SpanId":  "00f067aa0ba902b7",
+		"X-B3-Sampled": "1",
+		"b3":           "4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-1",
+	}
+	if act := prop.Headers("kafka", ctx); !reflect.DeepEqual(exp, act) {
+		t.Errorf("Wrong kafka headers: %v != %v", act, exp)
+	}
+	if act := prop.Headers("amqp", ctx); len(act) > 0 {
+		t.Errorf("Expected no amqp headers: %v", act)
+	}
+	exp = map[string]string{
+		"traceparent": "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+	}
+	if act := prop.Headers("http_client", ctx); !reflect.DeepEqual(exp, act) {
+		t.Errorf("Wrong default headers: %v != %v", act, exp)
+	}
+}
+
+func TestPropagatorBadFormats(t *testing.T) {
+	conf := NewPropagationConfig()
+	conf.Inject = []string{"nope"}
+	if _, err := NewPropagator(conf); err == nil {
+		t.Error("Expected error from bad inject format")
+	}
+
+	conf = NewPropagationConfig()
+	conf.Components["foo"] = PropagationFormatsConfig{
+		Extract: []string{"nope"},
+	}
+	if _, err := NewPropagator(conf); err == nil {
+		t.Error("Expected error from bad component format")
+	}
+}
+
+func TestInitInputSpansStrips(t *testing.T) {
+	tracer := newTestTracer()
+	SetGlobal(tracer)
+	defer SetGlobal(Noop{})
+
+	conf := NewPropagationConfig()
+	conf.Components["foo"] = PropagationFormatsConfig{
+		Extract: []string{FormatB3},
+	}
+	prop, err := NewPropagator(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	SetPropagator(prop)
+	defer func() {
+		prop, _ = NewPropagator(NewPropagationConfig())
+		SetPropagator(prop)
+	}()
+
+	msg := message.New([][]byte{[]byte("foo")})
+	msg.Get(0).Metadata().
+		Set("b3", "4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-1").
+		Set("X-B3-Sampled", "0").
+		Set("bar", "baz")
+
+	spans := InitInputSpans("foo", msg)
+	if len(spans) != 1 {
+		t.Fatalf("Wrong count of spans: %v", len(spans))
+	}
+	if exp, act := "4bf92f3577b34da6a3ce929d0e0e4736", spans[0].Context().TraceID.String(); exp != act {
+		t.Errorf("Wrong trace ID: %v != %v", act, exp)
+	}
+	if exp, act := "input.foo", spans[0].name; exp != act {
+		t.Errorf("Wrong span name: %v != %v", act, exp)
+	}
+
+	exp := map[string]string{
+		"bar":         "baz",
+		"traceparent": spans[0].Context().TraceParent(),
+	}
+	act := map[string]string{}
+	msg.Get(0).Metadata().Iter(func(k, v string) error {
+		act[k] = v
+		return nil
+	})
+	if !reflect.DeepEqual(exp, act) {
+		t.Errorf("Wrong metadata: %v != %v", act, exp)
+	}
+
+	headers := InjectHeaders("bar", msg.Get(0).Metadata())
+	if exp, act := spans[0].Context().TraceParent(), headers["traceparent"]; exp != act {
+		t.Errorf("Wrong injected header: %v != %v", act, exp)
+	}
+}
@@ -0,0 +1,195 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package tracing
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+)
+
+//------------------------------------------------------------------------------
+
+// TraceID is a 128 bit identifier of a trace.
+type TraceID [16]byte
+
+// String returns the lower case hex representation of the ID.
+func (t TraceID) String() string {
+	return hex.EncodeToString(t[:])
+}
+
+// SpanID is a 64 bit identifier of a span.
+type SpanID [8]byte
+
+// String returns the lower case hex representation of the ID.
+func (s SpanID) String() string {
+	return hex.EncodeToString(s[:])
+}
+
+//------------------------------------------------------------------------------
+
+// ErrInvalidTraceParent is returned when a traceparent string cannot be
+// parsed.
+var ErrInvalidTraceParent = errors.New("invalid traceparent")
+
+// SpanContext contains the identifiers of a span that are propagated across
+// process boundaries.
+type SpanContext struct {
+	TraceID TraceID
+	SpanID  SpanID
+	Sampled bool
+
+	// deferred is set when the sampling decision was left to the receiver.
+	deferred bool
+}
+
+// TraceParent returns the span context formatted as a W3C Trace Context
+// traceparent value.
+func (s SpanContext) TraceParent() string {
+	flags := "00"
+	if s.Sampled {
+		flags = "01"
+	}
+	return "00-" + s.TraceID.String() + "-" + s.SpanID.String() + "-" + flags
+}
+
+// ParseTraceParent attempts to parse a W3C Trace Context traceparent value
+// into a span context.
+func ParseTraceParent(v string) (SpanContext, error) {
+	var ctx SpanContext
+
+	parts := strings.Split(strings.TrimSpace(v), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" {
+		return ctx, ErrInvalidTraceParent
+	}
+	if parts[0] == "00" && len(parts) != 4 {
+		return ctx, ErrInvalidTraceParent
+	}
+	if len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return ctx, ErrInvalidTraceParent
+	}
+	if _, err := hex.Decode(ctx.TraceID[:], []byte(parts[1])); err != nil {
+		return ctx, ErrInvalidTraceParent
+	}
+	if _, err := hex.Decode(ctx.SpanID[:], []byte(parts[2])); err != nil {
+		return ctx, ErrInvalidTraceParent
+	}
+	if ctx.TraceID == (TraceID{}) || ctx.SpanID == (SpanID{}) {
+		return ctx, ErrInvalidTraceParent
+	}
+	var flags [1]byte
+	if _, err := hex.Decode(flags[:], []byte(parts[3])); err != nil {
+		return ctx, ErrInvalidTraceParent
+	}
+	ctx.Sampled = flags[0]&0x01 == 0x01
+	return ctx, nil
+}
+
+//------------------------------------------------------------------------------
+
+// Span represents a single operation within a trace. All methods of a span are
+// safe to call on a nil span, which is what is returned by a tracer that does
+// not record spans.
+type Span struct {
+	ctx       SpanContext
+	parentID  SpanID
+	hasParent bool
+	name      string
+	start     time.Time
+	duration  time.Duration
+	tags      map[string]string
+
+	mut      sync.Mutex
+	finished bool
+	onFinish func(s *Span)
+}
+
+// Context returns the span context of the span.
+func (s *Span) Context() SpanContext {
+	if s == nil {
+		return SpanContext{}
+	}
+	return s.ctx
+}
+
+// SetTag sets a tag of the span to a value.
+func (s *Span) SetTag(key, value string) *Span {
+	if s == nil {
+		return s
+	}
+	s.mut.Lock()
+	if s.tags == nil {
+		s.tags = map[string]string{}
+	}
+	s.tags[key] = value
+	s.mut.Unlock()
+	return s
+}
+
+// SetError marks the span as having failed with an error.
+func (s *Span) SetError(err error) *Span {
+	if s == nil || err == nil {
+		return s
+	}
+	return s.SetTag("error", err.Error())
+}
+
+// Finish marks the end of the operation of the span. Calls after the first
+// have no effect.
+func (s *Span) Finish() {
+	if s == nil {
+		return
+	}
+	s.mut.Lock()
+	if s.finished {
+		s.mut.Unlock()
+		return
+	}
+	s.finished = true
+	s.duration = time.Since(s.start)
+	s.mut.Unlock()
+
+	if s.ctx.Sampled && s.onFinish != nil {
+		s.onFinish(s)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+func randomBytes(b []byte) {
+	// An error here would mean the system has no source of randomness, in
+	// which case an ID of zeroes is as good as anything.
+	rand.Read(b)
+}
+
+func newTraceID() (id TraceID) {
+	randomBytes(id[:])
+	return
+}
+
+func newSpanID() (id SpanID) {
+	randomBytes(id[:])
+	return
+}
+
+//------------------------------------------------------------------------------
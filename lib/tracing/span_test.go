@@ -0,0 +1,152 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package tracing
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestTraceParentRoundTrip(t *testing.T) {
+	exp := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	ctx, err := ParseTraceParent(exp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ctx.Sampled {
+		t.Error("Expected span context to be sampled")
+	}
+	if act := ctx.TraceID.String(); act != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("Wrong trace ID: %v", act)
+	}
+	if act := ctx.SpanID.String(); act != "00f067aa0ba902b7" {
+		t.Errorf("Wrong span ID: %v", act)
+	}
+	if act := ctx.TraceParent(); act != exp {
+		t.Errorf("Wrong traceparent: %v != %v", act, exp)
+	}
+
+	if ctx, err = ParseTraceParent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00"); err != nil {
+		t.Fatal(err)
+	}
+	if ctx.Sampled {
+		t.Error("Expected span context to not be sampled")
+	}
+}
+
+func TestTraceParentInvalid(t *testing.T) {
+	tests := []string{
+		"",
+		"foo",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e473x-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-0x",
+	}
+	for _, test := range tests {
+		if _, err := ParseTraceParent(test); err != ErrInvalidTraceParent {
+			t.Errorf("Expected error for '%v', received: %v", test, err)
+		}
+	}
+}
+
+func TestSpanNilSafe(t *testing.T) {
+	var s *Span
+	s.SetTag("foo", "bar").SetError(errors.New("nope")).Finish()
+	if s.Context() != (SpanContext{}) {
+		t.Error("Expected empty span context")
+	}
+}
+
+func TestSpanStarterSampling(t *testing.T) {
+	var finished []*Span
+	starter := &spanStarter{
+		sampling: SamplingConfig{
+			Ratio:       0,
+			ParentBased: true,
+		},
+		onFinish: func(s *Span) {
+			finished = append(finished, s)
+		},
+	}
+
+	root := starter.StartSpan("foo", nil)
+	if root.Context().Sampled {
+		t.Error("Expected root span to not be sampled")
+	}
+	root.Finish()
+	if len(finished) != 0 {
+		t.Errorf("Expected no recorded spans, received %v", len(finished))
+	}
+
+	parent := SpanContext{
+		TraceID: TraceID{1},
+		SpanID:  SpanID{2},
+		Sampled: true,
+	}
+	child := starter.StartSpan("bar", &parent)
+	if !child.Context().Sampled {
+		t.Error("Expected child span to inherit sampling decision")
+	}
+	if child.Context().TraceID != parent.TraceID {
+		t.Error("Expected child span to inherit trace ID")
+	}
+	if child.parentID != parent.SpanID {
+		t.Error("Expected child span to reference parent")
+	}
+	child.Finish()
+	child.Finish()
+	if len(finished) != 1 {
+		t.Errorf("Expected one recorded span, received %v", len(finished))
+	}
+}
+
+func TestSpanStarterNotParentBased(t *testing.T) {
+	starter, err := newSpanStarter(SamplingConfig{
+		Ratio:       0,
+		ParentBased: false,
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parent := SpanContext{
+		TraceID: TraceID{1},
+		SpanID:  SpanID{2},
+		Sampled: true,
+	}
+	if starter.StartSpan("foo", &parent).Context().Sampled {
+		t.Error("Expected span to not be sampled")
+	}
+
+	starter.sampling = SamplingConfig{Ratio: 1, ParentBased: true}
+	parent.Sampled = false
+	parent.deferred = true
+	if !starter.StartSpan("foo", &parent).Context().Sampled {
+		t.Error("Expected deferred sampling decision to use ratio")
+	}
+
+	if _, err = newSpanStarter(SamplingConfig{Ratio: -1}, nil); err == nil {
+		t.Error("Expected error from bad ratio")
+	}
+}
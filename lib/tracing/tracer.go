@@ -0,0 +1,238 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package tracing
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/log"
+)
+
+//------------------------------------------------------------------------------
+
+// Errors for the tracing package.
+var (
+	ErrInvalidTracerType = errors.New("invalid tracer type")
+)
+
+//------------------------------------------------------------------------------
+
+// Type is an interface implemented by all tracers.
+type Type interface {
+	// StartSpan creates a new span for an operation. If a parent span context
+	// is provided then the span is a child of it, otherwise the span begins a
+	// new trace. The returned span may be nil, which is safe to use.
+	StartSpan(operation string, parent *SpanContext) *Span
+
+	// Close stops the tracer, exports any remaining spans and cleans up
+	// resources.
+	Close() error
+}
+
+//------------------------------------------------------------------------------
+
+// typeSpec is a constructor for each tracer type.
+type typeSpec struct {
+	constructor func(conf Config, log log.Modular) (Type, error)
+}
+
+var constructors = map[string]typeSpec{}
+
+//------------------------------------------------------------------------------
+
+// String constants representing each tracer type.
+const (
+	TypeNone   = "none"
+	TypeZipkin = "zipkin"
+)
+
+//------------------------------------------------------------------------------
+
+// Config is the all encompassing configuration struct for all tracer types.
+type Config struct {
+	Type        string            `json:"type" yaml:"type"`
+	Propagation PropagationConfig `json:"propagation" yaml:"propagation"`
+	Sampling    SamplingConfig    `json:"sampling" yaml:"sampling"`
+	Zipkin      ZipkinConfig      `json:"zipkin" yaml:"zipkin"`
+}
+
+// NewConfig returns a configuration struct fully populated with default values.
+func NewConfig() Config {
+	return Config{
+		Type:        TypeNone,
+		Propagation: NewPropagationConfig(),
+		Sampling:    NewSamplingConfig(),
+		Zipkin:      NewZipkinConfig(),
+	}
+}
+
+// SanitiseConfig returns a sanitised version of the Config, meaning sections
+// that aren't relevant to behaviour are removed.
+func SanitiseConfig(conf Config) (interface{}, error) {
+	cBytes, err := json.Marshal(conf)
+	if err != nil {
+		return nil, err
+	}
+
+	hashMap := map[string]interface{}{}
+	if err = json.Unmarshal(cBytes, &hashMap); err != nil {
+		return nil, err
+	}
+
+	outputMap := map[string]interface{}{}
+	outputMap["type"] = hashMap["type"]
+	outputMap["propagation"] = hashMap["propagation"]
+	outputMap["sampling"] = hashMap["sampling"]
+	if sec, exists := hashMap[conf.Type]; exists {
+		outputMap[conf.Type] = sec
+	}
+	return outputMap, nil
+}
+
+//------------------------------------------------------------------------------
+
+// New creates a tracer type based on a configuration.
+func New(conf Config, log log.Modular) (Type, error) {
+	if conf.Type == TypeNone || len(conf.Type) == 0 {
+		return Noop{}, nil
+	}
+	c, ok := constructors[conf.Type]
+	if !ok {
+		return nil, ErrInvalidTracerType
+	}
+	return c.constructor(conf, log)
+}
+
+//------------------------------------------------------------------------------
+
+// Noop is a tracer that does not create spans.
+type Noop struct{}
+
+// StartSpan returns a nil span.
+func (n Noop) StartSpan(operation string, parent *SpanContext) *Span {
+	return nil
+}
+
+// Close does nothing.
+func (n Noop) Close() error {
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+// SamplingConfig contains configuration for deciding which traces are
+// recorded.
+type SamplingConfig struct {
+	Ratio       float64 `json:"ratio" yaml:"ratio"`
+	ParentBased bool    `json:"parent_based" yaml:"parent_based"`
+}
+
+// NewSamplingConfig creates a SamplingConfig struct with default values.
+func NewSamplingConfig() SamplingConfig {
+	return SamplingConfig{
+		Ratio:       1,
+		ParentBased: true,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// spanStarter creates spans and passes those that are sampled to a function
+// once they are finished.
+type spanStarter struct {
+	sampling SamplingConfig
+	onFinish func(s *Span)
+}
+
+func newSpanStarter(conf SamplingConfig, onFinish func(s *Span)) (spanStarter, error) {
+	if conf.Ratio < 0 || conf.Ratio > 1 {
+		return spanStarter{}, fmt.Errorf("sampling ratio must be between 0 and 1: %v", conf.Ratio)
+	}
+	return spanStarter{
+		sampling: conf,
+		onFinish: onFinish,
+	}, nil
+}
+
+func (t *spanStarter) sample() bool {
+	return t.sampling.Ratio >= 1 || rand.Float64() < t.sampling.Ratio
+}
+
+// StartSpan creates a new span for an operation. When sampling is parent based
+// spans that continue a trace inherit the sampling decision of their parent,
+// otherwise each trace is sampled at the configured ratio.
+func (t *spanStarter) StartSpan(operation string, parent *SpanContext) *Span {
+	s := &Span{
+		name:     operation,
+		start:    time.Now(),
+		onFinish: t.onFinish,
+	}
+	s.ctx.SpanID = newSpanID()
+	if parent != nil {
+		s.ctx.TraceID = parent.TraceID
+		s.parentID = parent.SpanID
+		s.hasParent = true
+		if t.sampling.ParentBased && !parent.deferred {
+			s.ctx.Sampled = parent.Sampled
+		} else {
+			s.ctx.Sampled = t.sample()
+		}
+	} else {
+		s.ctx.TraceID = newTraceID()
+		s.ctx.Sampled = t.sample()
+	}
+	return s
+}
+
+//------------------------------------------------------------------------------
+
+var (
+	globalMut sync.RWMutex
+	global    Type = Noop{}
+)
+
+// SetGlobal sets the tracer used for creating spans throughout Benthos. By
+// default no spans are created.
+func SetGlobal(t Type) {
+	globalMut.Lock()
+	global = t
+	globalMut.Unlock()
+}
+
+// Global returns the tracer used for creating spans throughout Benthos.
+func Global() Type {
+	globalMut.RLock()
+	t := global
+	globalMut.RUnlock()
+	return t
+}
+
+// Enabled returns true if the global tracer creates spans.
+func Enabled() bool {
+	_, isNoop := Global().(Noop)
+	return !isNoop
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,242 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package tracing
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/log"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	constructors[TypeZipkin] = typeSpec{
+		constructor: NewZipkin,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// ZipkinConfig is config for the Zipkin tracer type.
+type ZipkinConfig struct {
+	URL           string            `json:"url" yaml:"url"`
+	ServiceName   string            `json:"service_name" yaml:"service_name"`
+	Headers       map[string]string `json:"headers" yaml:"headers"`
+	FlushPeriod   string            `json:"flush_period" yaml:"flush_period"`
+	Timeout       string            `json:"timeout" yaml:"timeout"`
+	MaxBufferSize int               `json:"max_buffer_size" yaml:"max_buffer_size"`
+}
+
+// NewZipkinConfig creates a ZipkinConfig struct with default values.
+func NewZipkinConfig() ZipkinConfig {
+	return ZipkinConfig{
+		URL:           "http://localhost:9411/api/v2/spans",
+		ServiceName:   "benthos",
+		Headers:       map[string]string{},
+		FlushPeriod:   "1s",
+		Timeout:       "5s",
+		MaxBufferSize: 10000,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type zipkinEndpoint struct {
+	ServiceName string `json:"serviceName"`
+}
+
+// zipkinSpan is the Zipkin v2 JSON representation of a span, where timestamps
+// and durations are measured in microseconds.
+type zipkinSpan struct {
+	TraceID       string            `json:"traceId"`
+	ID            string            `json:"id"`
+	ParentID      string            `json:"parentId,omitempty"`
+	Name          string            `json:"name"`
+	Timestamp     int64             `json:"timestamp"`
+	Duration      int64             `json:"duration"`
+	LocalEndpoint zipkinEndpoint    `json:"localEndpoint"`
+	Tags          map[string]string `json:"tags,omitempty"`
+}
+
+//------------------------------------------------------------------------------
+
+// Zipkin is a tracer that exports spans to a Zipkin compatible collector using
+// the v2 JSON format over HTTP.
+type Zipkin struct {
+	spanStarter
+
+	conf     ZipkinConfig
+	client   http.Client
+	endpoint zipkinEndpoint
+	log      log.Modular
+
+	bufMut  sync.Mutex
+	buffer  []zipkinSpan
+	dropped int
+
+	closeOnce  sync.Once
+	closeChan  chan struct{}
+	closedChan chan struct{}
+}
+
+// NewZipkin creates and returns a new Zipkin tracer.
+func NewZipkin(conf Config, log log.Modular) (Type, error) {
+	if len(conf.Zipkin.URL) == 0 {
+		return nil, errors.New("a url must be specified")
+	}
+	flushPeriod, err := time.ParseDuration(conf.Zipkin.FlushPeriod)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse flush period: %s", err)
+	}
+	if flushPeriod <= 0 {
+		return nil, fmt.Errorf("flush period must be greater than zero: %v", flushPeriod)
+	}
+	timeout, err := time.ParseDuration(conf.Zipkin.Timeout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse timeout: %s", err)
+	}
+
+	z := &Zipkin{
+		conf:       conf.Zipkin,
+		client:     http.Client{Timeout: timeout},
+		endpoint:   zipkinEndpoint{ServiceName: conf.Zipkin.ServiceName},
+		log:        log.NewModule(".tracer.zipkin"),
+		closeChan:  make(chan struct{}),
+		closedChan: make(chan struct{}),
+	}
+	if z.spanStarter, err = newSpanStarter(conf.Sampling, z.record); err != nil {
+		return nil, err
+	}
+
+	go z.loop(flushPeriod)
+	return z, nil
+}
+
+//------------------------------------------------------------------------------
+
+// record adds a finished span to the buffer of spans to be exported.
+func (z *Zipkin) record(s *Span) {
+	zSpan := zipkinSpan{
+		TraceID:       s.ctx.TraceID.String(),
+		ID:            s.ctx.SpanID.String(),
+		Name:          s.name,
+		Timestamp:     s.start.UnixNano() / int64(time.Microsecond),
+		Duration:      int64(s.duration / time.Microsecond),
+		LocalEndpoint: z.endpoint,
+	}
+	if s.hasParent {
+		zSpan.ParentID = s.parentID.String()
+	}
+	s.mut.Lock()
+	if len(s.tags) > 0 {
+		zSpan.Tags = make(map[string]string, len(s.tags))
+		for k, v := range s.tags {
+			zSpan.Tags[k] = v
+		}
+	}
+	s.mut.Unlock()
+
+	z.bufMut.Lock()
+	if z.conf.MaxBufferSize > 0 && len(z.buffer) >= z.conf.MaxBufferSize {
+		z.dropped++
+	} else {
+		z.buffer = append(z.buffer, zSpan)
+	}
+	z.bufMut.Unlock()
+}
+
+// flush exports all buffered spans.
+func (z *Zipkin) flush() {
+	z.bufMut.Lock()
+	spans, dropped := z.buffer, z.dropped
+	z.buffer, z.dropped = nil, 0
+	z.bufMut.Unlock()
+
+	if dropped > 0 {
+		z.log.Warnf("Dropped %v spans due to a full buffer\n", dropped)
+	}
+	if len(spans) == 0 {
+		return
+	}
+
+	body, err := json.Marshal(spans)
+	if err != nil {
+		z.log.Errorf("Failed to encode spans: %v\n", err)
+		return
+	}
+
+	req, err := http.NewRequest("POST", z.conf.URL, bytes.NewReader(body))
+	if err != nil {
+		z.log.Errorf("Failed to create request: %v\n", err)
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range z.conf.Headers {
+		req.Header.Set(k, v)
+	}
+
+	res, err := z.client.Do(req)
+	if err != nil {
+		z.log.Errorf("Failed to export spans: %v\n", err)
+		return
+	}
+	res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		z.log.Errorf("Failed to export spans: unexpected status code: %v\n", res.StatusCode)
+	}
+}
+
+func (z *Zipkin) loop(flushPeriod time.Duration) {
+	defer close(z.closedChan)
+
+	ticker := time.NewTicker(flushPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			z.flush()
+		case <-z.closeChan:
+			z.flush()
+			return
+		}
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// Close stops the Zipkin tracer, exports any remaining spans and cleans up
+// resources.
+func (z *Zipkin) Close() error {
+	z.closeOnce.Do(func() {
+		close(z.closeChan)
+	})
+	<-z.closedChan
+	return nil
+}
+
+//------------------------------------------------------------------------------
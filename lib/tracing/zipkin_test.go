@@ -0,0 +1,165 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package tracing
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/Jeffail/benthos/lib/log"
+)
+
+func TestZipkinBadConfig(t *testing.T) {
+	tests := map[string]func(c *Config){
+		"no url":            func(c *Config) { c.Zipkin.URL = "" },
+		"bad sample ratio":  func(c *Config) { c.Sampling.Ratio = 2 },
+		"bad flush period":  func(c *Config) { c.Zipkin.FlushPeriod = "nope" },
+		"zero flush period": func(c *Config) { c.Zipkin.FlushPeriod = "0s" },
+		"bad timeout":       func(c *Config) { c.Zipkin.Timeout = "nope" },
+	}
+	for name, f := range tests {
+		conf := NewConfig()
+		conf.Type = TypeZipkin
+		f(&conf)
+		if _, err := New(conf, log.Noop()); err == nil {
+			t.Errorf("%v: Expected error", name)
+		}
+	}
+
+	conf := NewConfig()
+	conf.Type = "nope"
+	if _, err := New(conf, log.Noop()); err != ErrInvalidTracerType {
+		t.Errorf("Wrong error: %v", err)
+	}
+}
+
+func TestZipkinExport(t *testing.T) {
+	var mut sync.Mutex
+	var received []zipkinSpan
+	var headers http.Header
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		var spans []zipkinSpan
+		if err = json.Unmarshal(body, &spans); err != nil {
+			t.Error(err)
+			return
+		}
+		mut.Lock()
+		received = append(received, spans...)
+		headers = r.Header
+		mut.Unlock()
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	conf := NewConfig()
+	conf.Type = TypeZipkin
+	conf.Zipkin.URL = server.URL
+	conf.Zipkin.ServiceName = "foo"
+	conf.Zipkin.FlushPeriod = "1h"
+	conf.Zipkin.Headers["X-Foo"] = "bar"
+
+	tracer, err := New(conf, log.Noop())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parent := tracer.StartSpan("input", nil)
+	child := parent.Context()
+	tracer.StartSpan("output", &child).SetTag("baz", "buz").Finish()
+	parent.Finish()
+
+	if err = tracer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	mut.Lock()
+	defer mut.Unlock()
+
+	if exp, act := "bar", headers.Get("X-Foo"); exp != act {
+		t.Errorf("Wrong header: %v != %v", act, exp)
+	}
+	if exp, act := "application/json", headers.Get("Content-Type"); exp != act {
+		t.Errorf("Wrong content type: %v != %v", act, exp)
+	}
+	if len(received) != 2 {
+		t.Fatalf("Wrong count of spans: %v", len(received))
+	}
+
+	out, in := received[0], received[1]
+	if exp, act := "output", out.Name; exp != act {
+		t.Errorf("Wrong span name: %v != %v", act, exp)
+	}
+	if exp, act := in.ID, out.ParentID; exp != act {
+		t.Errorf("Wrong parent ID: %v != %v", act, exp)
+	}
+	if exp, act := in.TraceID, out.TraceID; exp != act {
+		t.Errorf("Wrong trace ID: %v != %v", act, exp)
+	}
+	if len(in.ParentID) > 0 {
+		t.Errorf("Expected root span to have no parent: %v", in.ParentID)
+	}
+	if exp, act := "buz", out.Tags["baz"]; exp != act {
+		t.Errorf("Wrong tag: %v != %v", act, exp)
+	}
+	if exp, act := "foo", in.LocalEndpoint.ServiceName; exp != act {
+		t.Errorf("Wrong service name: %v != %v", act, exp)
+	}
+	if in.Timestamp == 0 {
+		t.Error("Expected a timestamp")
+	}
+}
+
+func TestZipkinMaxBuffer(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = TypeZipkin
+	conf.Zipkin.URL = "http://localhost:1"
+	conf.Zipkin.FlushPeriod = "1h"
+	conf.Zipkin.MaxBufferSize = 2
+
+	tracer, err := New(conf, log.Noop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tracer.Close()
+	z := tracer.(*Zipkin)
+
+	for i := 0; i < 5; i++ {
+		z.StartSpan("foo", nil).Finish()
+	}
+
+	z.bufMut.Lock()
+	if exp, act := 2, len(z.buffer); exp != act {
+		t.Errorf("Wrong buffer size: %v != %v", act, exp)
+	}
+	if exp, act := 3, z.dropped; exp != act {
+		t.Errorf("Wrong dropped count: %v != %v", act, exp)
+	}
+	z.bufMut.Unlock()
+}
@@ -47,6 +47,15 @@ type Cache interface {
 	Delete(key string) error
 }
 
+// CacheWithTTL is a Cache that also supports setting a TTL on individual keys.
+type CacheWithTTL interface {
+	Cache
+
+	// SetWithTTL attempts to set the value of a key with a TTL that overrides
+	// the default of the cache, returns an error if the command fails.
+	SetWithTTL(key string, value []byte, ttl time.Duration) error
+}
+
 //------------------------------------------------------------------------------
 
 // RateLimit is a strategy for limiting access to a shared resource, this
@@ -67,6 +76,17 @@ type Condition interface {
 	Check(msg Message) bool
 }
 
+// PartCondition is an optional extension of Condition implemented by
+// conditions that are able to test a single part of a batch directly, rather
+// than requiring the part to be isolated into a message of its own.
+type PartCondition interface {
+	// CheckPart tests the message part at an index of a batch against a
+	// configured condition. The result must be equal to calling Check with the
+	// part isolated into a message of its own. The message must not be
+	// modified.
+	CheckPart(msg Message, index int) bool
+}
+
 //------------------------------------------------------------------------------
 
 // Processor reads a message, performs some form of data processing to the
@@ -80,6 +100,28 @@ type Processor interface {
 	ProcessMessage(Message) ([]Message, Response)
 }
 
+// Flusher is an optional extension of Processor implemented by processors
+// that buffer messages, allowing a pipeline to flush those messages once a
+// period has passed even when no further messages are received.
+type Flusher interface {
+	// UntilFlush returns the duration until buffered messages should be
+	// flushed, or a negative duration if there is nothing to flush.
+	UntilFlush() time.Duration
+
+	// Flush returns any buffered messages and clears them from the processor.
+	Flush() []Message
+}
+
+// ResponseObserver is an optional extension of Processor implemented by
+// processors that adapt their behaviour based on the responses of the
+// components downstream of a pipeline.
+type ResponseObserver interface {
+	// ObserveResponse is called with the response to each message sent from
+	// a pipeline and the time taken for it to be received. It may be called
+	// concurrently with ProcessMessage.
+	ObserveResponse(res Response, latency time.Duration)
+}
+
 //------------------------------------------------------------------------------
 
 // Manager is an interface expected by Benthos components that allows them to
@@ -106,6 +148,35 @@ type Manager interface {
 
 	// UnsetPipe removes a named transaction chan.
 	UnsetPipe(name string, t <-chan Transaction)
+
+	// SubscribePipe registers a transaction chan as a subscriber of a named
+	// pipe, which is given its own copy of transactions from pipes that fan
+	// out.
+	SubscribePipe(name string, t chan<- Transaction)
+
+	// UnsubscribePipe removes a subscribed transaction chan from a named pipe.
+	UnsubscribePipe(name string, t chan<- Transaction)
+
+	// GetPipeSubscribers returns all transaction chans subscribed to a named
+	// pipe.
+	GetPipeSubscribers(name string) []chan<- Transaction
+}
+
+// Connector is implemented by components that connect to an external service
+// and are able to report the state of that connection.
+type Connector interface {
+	// ConnectionStatus returns nil if the component is currently connected,
+	// ErrNotConnected if it is not, and ErrTypeClosed once it has closed.
+	ConnectionStatus() error
+}
+
+// ConnectionRegistry is an optional interface implemented by a Manager that
+// tracks the connection state of components, allowing it to report whether
+// the service as a whole is ready.
+type ConnectionRegistry interface {
+	// RegisterConnector registers a component under a name, which is
+	// forgotten once the component reports that it has closed.
+	RegisterConnector(name string, c Connector)
 }
 
 //------------------------------------------------------------------------------
@@ -59,6 +59,17 @@ func (f DudMgr) SetPipe(name string, t <-chan Transaction) {}
 // UnsetPipe removes a named pipe.
 func (f DudMgr) UnsetPipe(name string, t <-chan Transaction) {}
 
+// SubscribePipe registers a subscriber to a named pipe.
+func (f DudMgr) SubscribePipe(name string, t chan<- Transaction) {}
+
+// UnsubscribePipe removes a subscriber from a named pipe.
+func (f DudMgr) UnsubscribePipe(name string, t chan<- Transaction) {}
+
+// GetPipeSubscribers always returns an empty slice.
+func (f DudMgr) GetPipeSubscribers(name string) []chan<- Transaction {
+	return nil
+}
+
 // NoopMgr returns a Manager implementation that does nothing.
 func NoopMgr() Manager {
 	return DudMgr{}
@@ -0,0 +1,141 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package session
+
+import (
+	"errors"
+	"os"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/sts"
+)
+
+//------------------------------------------------------------------------------
+
+// expiryWindow is how long before temporary credentials expire that they are
+// refreshed.
+const expiryWindow = time.Minute
+
+// CredentialsConfig contains configuration params for AWS credentials.
+type CredentialsConfig struct {
+	ID                   string `json:"id" yaml:"id"`
+	Secret               string `json:"secret" yaml:"secret"`
+	Token                string `json:"token" yaml:"token"`
+	Role                 string `json:"role" yaml:"role"`
+	RoleExternalID       string `json:"role_external_id" yaml:"role_external_id"`
+	WebIdentityTokenFile string `json:"web_identity_token_file" yaml:"web_identity_token_file"`
+}
+
+// Config contains configuration fields for creating an AWS session.
+type Config struct {
+	Region      string            `json:"region" yaml:"region"`
+	Endpoint    string            `json:"endpoint" yaml:"endpoint"`
+	Credentials CredentialsConfig `json:"credentials" yaml:"credentials"`
+}
+
+// NewConfig returns a Config with default values.
+func NewConfig() Config {
+	return Config{
+		Region:   "eu-west-1",
+		Endpoint: "",
+		Credentials: CredentialsConfig{
+			ID:                   "",
+			Secret:               "",
+			Token:                "",
+			Role:                 "",
+			RoleExternalID:       "",
+			WebIdentityTokenFile: "",
+		},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// GetSession creates an AWS session from the Config. Optional functions can be
+// provided in order to modify the AWS config before the session is created.
+//
+// Static credentials are used when an ID is set. When a web identity token
+// file is set the role is assumed with the token, otherwise when a role is set
+// it is assumed with the static or default credentials. When none of these are
+// set and the environment variables AWS_ROLE_ARN and
+// AWS_WEB_IDENTITY_TOKEN_FILE are present, as is the case for EKS pods with IAM
+// roles for service accounts, the role is assumed with the web identity token.
+// Temporary credentials are refreshed automatically before they expire.
+func (c Config) GetSession(opts ...func(*aws.Config)) (*session.Session, error) {
+	awsConf := aws.NewConfig()
+	if len(c.Region) > 0 {
+		awsConf = awsConf.WithRegion(c.Region)
+	}
+	if len(c.Endpoint) > 0 {
+		awsConf = awsConf.WithEndpoint(c.Endpoint)
+	}
+	if len(c.Credentials.ID) > 0 {
+		awsConf = awsConf.WithCredentials(credentials.NewStaticCredentials(
+			c.Credentials.ID,
+			c.Credentials.Secret,
+			c.Credentials.Token,
+		))
+	}
+	for _, opt := range opts {
+		opt(awsConf)
+	}
+
+	sess, err := session.NewSession(awsConf)
+	if err != nil {
+		return nil, err
+	}
+
+	role, tokenFile := c.Credentials.Role, c.Credentials.WebIdentityTokenFile
+	if len(c.Credentials.ID) == 0 && len(role) == 0 && len(tokenFile) == 0 {
+		envRole, envFile := os.Getenv("AWS_ROLE_ARN"), os.Getenv("AWS_WEB_IDENTITY_TOKEN_FILE")
+		if len(envRole) > 0 && len(envFile) > 0 {
+			role, tokenFile = envRole, envFile
+		}
+	}
+
+	if len(tokenFile) > 0 {
+		if len(role) == 0 {
+			return nil, errors.New("a role must be specified in order to use a web identity token file")
+		}
+		// The token authenticates the request, and so the STS client must not
+		// sign it with any other credentials.
+		client := sts.New(sess, aws.NewConfig().WithCredentials(credentials.AnonymousCredentials))
+		sess.Config = sess.Config.WithCredentials(credentials.NewCredentials(
+			newWebIdentityProvider(client, role, tokenFile),
+		))
+	} else if len(role) > 0 {
+		sess.Config = sess.Config.WithCredentials(stscreds.NewCredentials(
+			sess, role, func(p *stscreds.AssumeRoleProvider) {
+				if len(c.Credentials.RoleExternalID) > 0 {
+					p.ExternalID = aws.String(c.Credentials.RoleExternalID)
+				}
+				p.ExpiryWindow = expiryWindow
+			},
+		))
+	}
+	return sess, nil
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,131 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package session
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sts"
+)
+
+type fakeWebIdentityClient struct {
+	inputs []*sts.AssumeRoleWithWebIdentityInput
+	expiry time.Time
+	err    error
+}
+
+func (f *fakeWebIdentityClient) AssumeRoleWithWebIdentity(
+	in *sts.AssumeRoleWithWebIdentityInput,
+) (*sts.AssumeRoleWithWebIdentityOutput, error) {
+	f.inputs = append(f.inputs, in)
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &sts.AssumeRoleWithWebIdentityOutput{
+		Credentials: &sts.Credentials{
+			AccessKeyId:     aws.String("foo"),
+			SecretAccessKey: aws.String("bar"),
+			SessionToken:    aws.String("baz"),
+			Expiration:      aws.Time(f.expiry),
+		},
+	}, nil
+}
+
+func TestWebIdentityProvider(t *testing.T) {
+	dir, err := ioutil.TempDir("", "benthos_aws_session_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	tokenFile := filepath.Join(dir, "token")
+	if err = ioutil.WriteFile(tokenFile, []byte("first\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Unix(1000, 0)
+	client := &fakeWebIdentityClient{expiry: now.Add(time.Hour)}
+	p := newWebIdentityProvider(client, "arn:aws:iam::123:role/foo", tokenFile)
+	p.CurrentTime = func() time.Time { return now }
+
+	if !p.IsExpired() {
+		t.Error("Expected expired before first retrieval")
+	}
+
+	val, err := p.Retrieve()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp, act := "foo", val.AccessKeyID; exp != act {
+		t.Errorf("Wrong access key: %v != %v", act, exp)
+	}
+	if exp, act := "baz", val.SessionToken; exp != act {
+		t.Errorf("Wrong session token: %v != %v", act, exp)
+	}
+	if exp, act := "first", aws.StringValue(client.inputs[0].WebIdentityToken); exp != act {
+		t.Errorf("Wrong token: %v != %v", act, exp)
+	}
+	if exp, act := "arn:aws:iam::123:role/foo", aws.StringValue(client.inputs[0].RoleArn); exp != act {
+		t.Errorf("Wrong role: %v != %v", act, exp)
+	}
+	if p.IsExpired() {
+		t.Error("Expected not expired")
+	}
+
+	now = now.Add(time.Hour - expiryWindow/2)
+	if !p.IsExpired() {
+		t.Error("Expected expired within expiry window")
+	}
+
+	if err = ioutil.WriteFile(tokenFile, []byte("second"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = p.Retrieve(); err != nil {
+		t.Fatal(err)
+	}
+	if exp, act := "second", aws.StringValue(client.inputs[1].WebIdentityToken); exp != act {
+		t.Errorf("Wrong rotated token: %v != %v", act, exp)
+	}
+
+	client.err = errors.New("nope")
+	if _, err = p.Retrieve(); err == nil {
+		t.Error("Expected error from client")
+	}
+
+	p = newWebIdentityProvider(client, "foo", filepath.Join(dir, "missing"))
+	if _, err = p.Retrieve(); err == nil {
+		t.Error("Expected error from missing token file")
+	}
+}
+
+func TestGetSessionWebIdentityNoRole(t *testing.T) {
+	conf := NewConfig()
+	conf.Credentials.WebIdentityTokenFile = "/tmp/foo"
+	if _, err := conf.GetSession(); err == nil {
+		t.Error("Expected error from token file without role")
+	}
+}
@@ -0,0 +1,91 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package session
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/service/sts"
+)
+
+//------------------------------------------------------------------------------
+
+// webIdentityClient is the subset of the STS API used by webIdentityProvider.
+type webIdentityClient interface {
+	AssumeRoleWithWebIdentity(*sts.AssumeRoleWithWebIdentityInput) (*sts.AssumeRoleWithWebIdentityOutput, error)
+}
+
+// webIdentityProvider retrieves temporary credentials by assuming a role with a
+// web identity token read from a file. The file is read for each retrieval as
+// tokens such as those of Kubernetes service accounts are rotated.
+type webIdentityProvider struct {
+	credentials.Expiry
+
+	client      webIdentityClient
+	role        string
+	sessionName string
+	tokenFile   string
+}
+
+func newWebIdentityProvider(client webIdentityClient, role, tokenFile string) *webIdentityProvider {
+	return &webIdentityProvider{
+		client:      client,
+		role:        role,
+		sessionName: fmt.Sprintf("benthos-%v", time.Now().UnixNano()),
+		tokenFile:   tokenFile,
+	}
+}
+
+// Retrieve assumes the role and returns the resulting credentials.
+func (w *webIdentityProvider) Retrieve() (credentials.Value, error) {
+	token, err := ioutil.ReadFile(w.tokenFile)
+	if err != nil {
+		return credentials.Value{}, fmt.Errorf("failed to read web identity token: %v", err)
+	}
+
+	out, err := w.client.AssumeRoleWithWebIdentity(&sts.AssumeRoleWithWebIdentityInput{
+		RoleArn:          aws.String(w.role),
+		RoleSessionName:  aws.String(w.sessionName),
+		WebIdentityToken: aws.String(strings.TrimSpace(string(token))),
+	})
+	if err != nil {
+		return credentials.Value{}, fmt.Errorf("failed to assume role with web identity: %v", err)
+	}
+	if out.Credentials == nil {
+		return credentials.Value{}, errors.New("assume role with web identity returned no credentials")
+	}
+
+	w.SetExpiration(aws.TimeValue(out.Credentials.Expiration), expiryWindow)
+	return credentials.Value{
+		AccessKeyID:     aws.StringValue(out.Credentials.AccessKeyId),
+		SecretAccessKey: aws.StringValue(out.Credentials.SecretAccessKey),
+		SessionToken:    aws.StringValue(out.Credentials.SessionToken),
+		ProviderName:    "WebIdentityCredentials",
+	}, nil
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,86 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package checkpoint
+
+import (
+	"fmt"
+
+	"github.com/Jeffail/benthos/lib/types"
+)
+
+//------------------------------------------------------------------------------
+
+// Store is a place where inputs persist checkpoints, which are opaque values
+// stored per key.
+type Store interface {
+	// Get returns the last checkpoint stored for a key, or nil if no
+	// checkpoint exists.
+	Get(key string) ([]byte, error)
+
+	// Set stores the checkpoint for a key.
+	Set(key string, value []byte) error
+}
+
+//------------------------------------------------------------------------------
+
+// Cache is a Store that persists checkpoints to a cache resource. Keys are
+// prefixed with a namespace so that a single cache can be shared by multiple
+// inputs.
+type Cache struct {
+	cache     types.Cache
+	namespace string
+}
+
+// NewCache creates a Store backed by a cache resource of a manager.
+func NewCache(name, namespace string, mgr types.Manager) (*Cache, error) {
+	c, err := mgr.GetCache(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to obtain checkpoint cache '%v': %v", name, err)
+	}
+	return &Cache{
+		cache:     c,
+		namespace: namespace,
+	}, nil
+}
+
+func (c *Cache) key(key string) string {
+	if len(c.namespace) == 0 {
+		return key
+	}
+	return c.namespace + "/" + key
+}
+
+// Get returns the last checkpoint stored for a key, or nil if no checkpoint
+// exists.
+func (c *Cache) Get(key string) ([]byte, error) {
+	value, err := c.cache.Get(c.key(key))
+	if err == types.ErrKeyNotFound {
+		return nil, nil
+	}
+	return value, err
+}
+
+// Set stores the checkpoint for a key.
+func (c *Cache) Set(key string, value []byte) error {
+	return c.cache.Set(c.key(key), value)
+}
+
+//------------------------------------------------------------------------------
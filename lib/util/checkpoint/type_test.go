@@ -0,0 +1,96 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package checkpoint
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Jeffail/benthos/lib/cache"
+	"github.com/Jeffail/benthos/lib/log"
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+)
+
+//------------------------------------------------------------------------------
+
+type fakeMgr struct {
+	types.DudMgr
+	caches map[string]types.Cache
+}
+
+func (f fakeMgr) GetCache(name string) (types.Cache, error) {
+	if c, exists := f.caches[name]; exists {
+		return c, nil
+	}
+	return nil, types.ErrCacheNotFound
+}
+
+func TestCacheStore(t *testing.T) {
+	memCache, err := cache.NewMemory(cache.NewConfig(), nil, log.Noop(), metrics.Noop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	mgr := fakeMgr{caches: map[string]types.Cache{"foo": memCache}}
+
+	if _, err = NewCache("bar", "", mgr); err == nil {
+		t.Error("Expected error from missing cache")
+	}
+
+	storeA, err := NewCache("foo", "a", mgr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	storeB, err := NewCache("foo", "b", mgr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var value []byte
+	if value, err = storeA.Get("key"); err != nil {
+		t.Fatal(err)
+	}
+	if value != nil {
+		t.Errorf("Expected nil checkpoint, received: %s", value)
+	}
+
+	if err = storeA.Set("key", []byte("first")); err != nil {
+		t.Fatal(err)
+	}
+	if err = storeB.Set("key", []byte("second")); err != nil {
+		t.Fatal(err)
+	}
+
+	if value, err = storeA.Get("key"); err != nil {
+		t.Fatal(err)
+	}
+	if exp, act := []byte("first"), value; !reflect.DeepEqual(exp, act) {
+		t.Errorf("Wrong checkpoint: %s != %s", act, exp)
+	}
+	if value, err = memCache.Get("b/key"); err != nil {
+		t.Fatal(err)
+	}
+	if exp, act := []byte("second"), value; !reflect.DeepEqual(exp, act) {
+		t.Errorf("Wrong checkpoint: %s != %s", act, exp)
+	}
+}
+
+//------------------------------------------------------------------------------
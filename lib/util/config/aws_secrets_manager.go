@@ -0,0 +1,83 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+)
+
+//------------------------------------------------------------------------------
+
+// awsSecretsManagerResolver resolves secret URIs of the form
+// `aws-sm://<name>?region=<region>#<key>` by reading the secret from AWS
+// Secrets Manager, where the region and key are optional. Credentials are
+// obtained from the default AWS credentials chain. When a key is specified the
+// secret must be a JSON object.
+type awsSecretsManagerResolver struct{}
+
+func (awsSecretsManagerResolver) ResolveSecret(ref string) (string, time.Duration, error) {
+	name, query, key, err := parseSecretRef(ref)
+	if err != nil {
+		return "", 0, err
+	}
+
+	awsConf := aws.NewConfig()
+	if region := query.Get("region"); len(region) > 0 {
+		awsConf = awsConf.WithRegion(region)
+	}
+	sess, err := session.NewSession(awsConf)
+	if err != nil {
+		return "", 0, err
+	}
+
+	out, err := secretsmanager.New(sess).GetSecretValue(&secretsmanager.GetSecretValueInput{
+		SecretId: aws.String(name),
+	})
+	if err != nil {
+		return "", 0, err
+	}
+
+	var value string
+	if out.SecretString != nil {
+		value = *out.SecretString
+	} else {
+		value = string(out.SecretBinary)
+	}
+	if len(key) == 0 {
+		return value, 0, nil
+	}
+
+	var data map[string]interface{}
+	if err = json.Unmarshal([]byte(strings.TrimSpace(value)), &data); err != nil {
+		return "", 0, errors.New("a key was specified but the secret is not a JSON object")
+	}
+	value, err = secretField(data, key)
+	return value, 0, err
+}
+
+//------------------------------------------------------------------------------
@@ -22,37 +22,62 @@ package config
 
 import (
 	"encoding/json"
-	"io/ioutil"
-	"path/filepath"
+	"time"
 
-	"github.com/Jeffail/benthos/lib/util/text"
 	"gopkg.in/yaml.v2"
 )
 
 //------------------------------------------------------------------------------
 
-// Read will attempt to read a configuration file path into a structure.
+// Read will attempt to read a configuration file path into a structure. Any
+// files listed within the include field of the config are merged into it, and
+// templates are instantiated, as described by ReadTree. String values that are secret URIs, such as
+// `vault://secret/data/foo#bar`, are replaced with the value of the secret.
 func Read(path string, replaceEnvs bool, config interface{}) error {
-	configBytes, err := ioutil.ReadFile(path)
+	_, err := ReadWithExpiry(path, replaceEnvs, config)
+	return err
+}
+
+// ReadWithExpiry reads a configuration file path into a structure in the same
+// way as Read, and also returns the duration until the earliest resolved
+// secret expires, which is zero if no secrets expire.
+func ReadWithExpiry(path string, replaceEnvs bool, config interface{}) (time.Duration, error) {
+	configBytes, err := readConfigBytes(path, replaceEnvs)
 	if err != nil {
-		return err
+		return 0, err
 	}
 
-	if replaceEnvs {
-		configBytes = text.ReplaceEnvVariables(configBytes)
+	isJSON := isJSONPath(path)
+
+	secrets := newSecretsReplacer()
+	if mightInclude(configBytes) || secrets.mightContainSecrets(configBytes) {
+		root, err := ReadTree(path, replaceEnvs)
+		if err != nil {
+			return 0, err
+		}
+		if _, err = secrets.walk(root); err != nil {
+			return 0, err
+		}
+		if isJSON {
+			configBytes, err = json.Marshal(root)
+		} else {
+			configBytes, err = yaml.Marshal(root)
+		}
+		if err != nil {
+			return 0, err
+		}
 	}
 
-	ext := filepath.Ext(path)
-	if ".js" == ext || ".json" == ext {
+	if isJSON {
 		if err = json.Unmarshal(configBytes, config); err != nil {
-			return err
+			return 0, err
 		}
 	} else { // if ".yml" == ext || ".yaml" == ext {
 		if err = yaml.Unmarshal(configBytes, config); err != nil {
-			return err
+			return 0, err
 		}
 	}
-	return nil
+	return secrets.expiry, nil
 }
 
 //------------------------------------------------------------------------------
@@ -0,0 +1,239 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/Jeffail/benthos/lib/util/text"
+	"gopkg.in/yaml.v2"
+)
+
+//------------------------------------------------------------------------------
+
+// includeKey is the field of a config that lists other config files to be
+// merged into it.
+const includeKey = "include"
+
+func isJSONPath(path string) bool {
+	ext := filepath.Ext(path)
+	return ".js" == ext || ".json" == ext
+}
+
+func readConfigBytes(path string, replaceEnvs bool) ([]byte, error) {
+	configBytes, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if replaceEnvs {
+		configBytes = text.ReplaceEnvVariables(configBytes)
+	}
+	return configBytes, nil
+}
+
+// mightInclude returns true if the raw bytes of a config might contain an
+// include or templates field.
+func mightInclude(configBytes []byte) bool {
+	return bytes.Contains(configBytes, []byte(includeKey)) ||
+		bytes.Contains(configBytes, []byte(templatesKey))
+}
+
+//------------------------------------------------------------------------------
+
+// ReadTree reads a configuration file into a generic structure, where the
+// files listed in its include field are read and merged in order, followed by
+// the contents of the file itself. Objects are merged recursively, and all
+// other values, including arrays, replace the values of earlier files.
+//
+// Components of the tree with the name of a template listed in a templates
+// field of any of the files as their type are replaced with the instantiated
+// template.
+func ReadTree(path string, replaceEnvs bool) (map[string]interface{}, error) {
+	tree, err := readTree(path, replaceEnvs, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err = expandTemplates(tree, replaceEnvs); err != nil {
+		return nil, err
+	}
+	return tree, nil
+}
+
+func readTree(path string, replaceEnvs bool, stack []string) (map[string]interface{}, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range stack {
+		if p == absPath {
+			return nil, fmt.Errorf("include cycle: %v", strings.Join(append(stack, absPath), " -> "))
+		}
+	}
+	stack = append(stack, absPath)
+
+	configBytes, err := readConfigBytes(path, replaceEnvs)
+	if err != nil {
+		return nil, err
+	}
+	root, err := parseTree(configBytes, isJSONPath(path))
+	if err != nil {
+		return nil, err
+	}
+
+	includes, err := includePaths(path, root[includeKey])
+	if err != nil {
+		return nil, err
+	}
+	delete(root, includeKey)
+
+	templates, err := includePaths(path, root[templatesKey])
+	if err != nil {
+		return nil, err
+	}
+	delete(root, templatesKey)
+
+	// Template paths are collected from all files rather than merged.
+	var templatePaths []interface{}
+
+	merged := map[string]interface{}{}
+	for _, incPath := range includes {
+		incTree, err := readTree(incPath, replaceEnvs, stack)
+		if err != nil {
+			return nil, fmt.Errorf("failed to include '%v': %v", incPath, err)
+		}
+		if incTemplates, ok := incTree[templatesKey].([]interface{}); ok {
+			templatePaths = append(templatePaths, incTemplates...)
+			delete(incTree, templatesKey)
+		}
+		mergeTrees(merged, incTree)
+	}
+	mergeTrees(merged, root)
+
+	for _, p := range templates {
+		templatePaths = append(templatePaths, p)
+	}
+	if len(templatePaths) > 0 {
+		merged[templatesKey] = templatePaths
+	}
+	return merged, nil
+}
+
+// parseTree parses a config into a generic structure where all objects have
+// string keys.
+func parseTree(configBytes []byte, isJSON bool) (map[string]interface{}, error) {
+	var root interface{}
+	if isJSON {
+		if err := json.Unmarshal(configBytes, &root); err != nil {
+			return nil, err
+		}
+	} else if err := yaml.Unmarshal(configBytes, &root); err != nil {
+		return nil, err
+	}
+	if root == nil {
+		return map[string]interface{}{}, nil
+	}
+	obj, ok := normaliseTree(root).(map[string]interface{})
+	if !ok {
+		return nil, errors.New("config root must be an object")
+	}
+	return obj, nil
+}
+
+func normaliseTree(node interface{}) interface{} {
+	switch t := node.(type) {
+	case map[interface{}]interface{}:
+		obj := make(map[string]interface{}, len(t))
+		for k, v := range t {
+			obj[fmt.Sprintf("%v", k)] = normaliseTree(v)
+		}
+		return obj
+	case map[string]interface{}:
+		for k, v := range t {
+			t[k] = normaliseTree(v)
+		}
+	case []interface{}:
+		for i, v := range t {
+			t[i] = normaliseTree(v)
+		}
+	}
+	return node
+}
+
+// mergeTrees merges src into dst, where objects are merged recursively and all
+// other values of src replace those of dst.
+func mergeTrees(dst, src map[string]interface{}) {
+	for k, v := range src {
+		srcObj, srcIsObj := v.(map[string]interface{})
+		dstObj, dstIsObj := dst[k].(map[string]interface{})
+		if srcIsObj && dstIsObj {
+			mergeTrees(dstObj, srcObj)
+			continue
+		}
+		dst[k] = v
+	}
+}
+
+// includePaths returns the paths listed by an include field, which can be a
+// string or an array of strings. Paths are relative to the directory of the
+// including file and can be glob patterns.
+func includePaths(fromPath string, node interface{}) ([]string, error) {
+	var patterns []string
+	switch t := node.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		patterns = append(patterns, t)
+	case []interface{}:
+		for _, v := range t {
+			str, ok := v.(string)
+			if !ok {
+				return nil, fmt.Errorf("expected include path to be a string, found: %v", v)
+			}
+			patterns = append(patterns, str)
+		}
+	default:
+		return nil, fmt.Errorf("expected include to be a string or an array of strings, found: %v", t)
+	}
+
+	var paths []string
+	for _, pattern := range patterns {
+		if !filepath.IsAbs(pattern) {
+			pattern = filepath.Join(filepath.Dir(fromPath), pattern)
+		}
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, err
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("no files found to include for path: %v", pattern)
+		}
+		paths = append(paths, matches...)
+	}
+	return paths, nil
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,188 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func writeIncludeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "benthos_include_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err = os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err = ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestReadTreeMergeOrder(t *testing.T) {
+	dir := writeIncludeFiles(t, map[string]string{
+		"base.yaml": `
+http:
+  address: 0.0.0.0:4195
+  debug_endpoints: true
+pipeline:
+  processors:
+  - type: noop
+`,
+		"override.yaml": `
+http:
+  address: 0.0.0.0:5000
+`,
+		"root.yaml": `
+include:
+  - ./base.yaml
+  - ./override.yaml
+pipeline:
+  processors:
+  - type: bounds_check
+`,
+	})
+	defer os.RemoveAll(dir)
+
+	tree, err := ReadTree(filepath.Join(dir, "root.yaml"), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exp := map[string]interface{}{
+		"http": map[string]interface{}{
+			"address":         "0.0.0.0:5000",
+			"debug_endpoints": true,
+		},
+		"pipeline": map[string]interface{}{
+			"processors": []interface{}{
+				map[string]interface{}{"type": "bounds_check"},
+			},
+		},
+	}
+	if !reflect.DeepEqual(exp, tree) {
+		t.Errorf("Wrong result: %v != %v", tree, exp)
+	}
+}
+
+func TestReadTreeNestedAndGlob(t *testing.T) {
+	dir := writeIncludeFiles(t, map[string]string{
+		"parts/a.yaml": `
+include: ../common/logger.yaml
+input:
+  type: stdin
+`,
+		"parts/b.yaml": `
+output:
+  type: stdout
+`,
+		"common/logger.yaml": `
+logger:
+  level: DEBUG
+`,
+		"root.json": `{"include":"parts/*.yaml","logger":{"prefix":"foo"}}`,
+	})
+	defer os.RemoveAll(dir)
+
+	tree, err := ReadTree(filepath.Join(dir, "root.json"), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exp := map[string]interface{}{
+		"input":  map[string]interface{}{"type": "stdin"},
+		"output": map[string]interface{}{"type": "stdout"},
+		"logger": map[string]interface{}{
+			"level":  "DEBUG",
+			"prefix": "foo",
+		},
+	}
+	if !reflect.DeepEqual(exp, tree) {
+		t.Errorf("Wrong result: %v != %v", tree, exp)
+	}
+}
+
+func TestReadTreeErrors(t *testing.T) {
+	dir := writeIncludeFiles(t, map[string]string{
+		"a.yaml":       "include: b.yaml",
+		"b.yaml":       "include: a.yaml",
+		"missing.yaml": "include: nope/*.yaml",
+		"bad.yaml":     "include: 10",
+	})
+	defer os.RemoveAll(dir)
+
+	tests := map[string]string{
+		"a.yaml":       "include cycle",
+		"missing.yaml": "no files found to include",
+		"bad.yaml":     "expected include to be a string",
+	}
+	for name, exp := range tests {
+		_, err := ReadTree(filepath.Join(dir, name), false)
+		if err == nil {
+			t.Errorf("Expected error from %v", name)
+		} else if !strings.Contains(err.Error(), exp) {
+			t.Errorf("Wrong error from %v: %v", name, err)
+		}
+	}
+}
+
+func TestReadWithIncludes(t *testing.T) {
+	dir := writeIncludeFiles(t, map[string]string{
+		"base.yaml": `
+http:
+  address: 0.0.0.0:5000
+`,
+		"root.yaml": `
+include: base.yaml
+logger:
+  level: WARN
+`,
+	})
+	defer os.RemoveAll(dir)
+
+	conf := struct {
+		HTTP struct {
+			Address string `yaml:"address"`
+		} `yaml:"http"`
+		Logger struct {
+			Level string `yaml:"level"`
+		} `yaml:"logger"`
+	}{}
+	if err := Read(filepath.Join(dir, "root.yaml"), false, &conf); err != nil {
+		t.Fatal(err)
+	}
+	if exp, act := "0.0.0.0:5000", conf.HTTP.Address; exp != act {
+		t.Errorf("Wrong address: %v != %v", act, exp)
+	}
+	if exp, act := "WARN", conf.Logger.Level; exp != act {
+		t.Errorf("Wrong log level: %v != %v", act, exp)
+	}
+}
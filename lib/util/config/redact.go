@@ -0,0 +1,191 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+//------------------------------------------------------------------------------
+
+// RedactedValue replaces the values of config fields that are likely to
+// contain credentials.
+const RedactedValue = "!!!SECRET_SCRUBBED!!!"
+
+// secretFieldNames are the names of config fields that contain credentials.
+var secretFieldNames = map[string]struct{}{
+	"password":            {},
+	"secret":              {},
+	"token":               {},
+	"access_token":        {},
+	"access_token_secret": {},
+	"consumer_secret":     {},
+	"client_secret":       {},
+	"bearer_token":        {},
+	"encryption_key":      {},
+}
+
+// Redacted is a config structure where the values of fields that are likely to
+// contain credentials have been replaced with RedactedValue. It marshals into
+// both YAML and JSON with the field order of the original structure.
+type Redacted yaml.MapSlice
+
+// Redact returns a copy of a config structure that is safe to expose, where
+// the values of fields such as passwords and tokens, the private keys of TLS
+// certificates and the passwords of URLs are replaced with RedactedValue.
+// Empty values are not replaced.
+func Redact(conf interface{}) (Redacted, error) {
+	confBytes, err := yaml.Marshal(conf)
+	if err != nil {
+		return nil, err
+	}
+	var root yaml.MapSlice
+	if err = yaml.Unmarshal(confBytes, &root); err != nil {
+		return nil, err
+	}
+	return Redacted(redactObj(root)), nil
+}
+
+func redactObj(obj yaml.MapSlice) yaml.MapSlice {
+	var hasCert bool
+	for _, item := range obj {
+		if item.Key == "cert" {
+			hasCert = true
+		}
+	}
+	for i, item := range obj {
+		key, _ := item.Key.(string)
+		str, isStr := item.Value.(string)
+		if !isStr {
+			obj[i].Value = redactNode(item.Value)
+			continue
+		}
+		if len(str) == 0 {
+			continue
+		}
+		if _, exists := secretFieldNames[key]; exists || (hasCert && key == "key") {
+			obj[i].Value = RedactedValue
+			continue
+		}
+		obj[i].Value = redactURL(str)
+	}
+	return obj
+}
+
+func redactNode(node interface{}) interface{} {
+	switch t := node.(type) {
+	case yaml.MapSlice:
+		return redactObj(t)
+	case []interface{}:
+		for i, v := range t {
+			if str, isStr := v.(string); isStr {
+				t[i] = redactURL(str)
+			} else {
+				t[i] = redactNode(v)
+			}
+		}
+	}
+	return node
+}
+
+// redactURL replaces the password of a string that is a URL.
+func redactURL(str string) string {
+	if !strings.Contains(str, "@") {
+		return str
+	}
+	u, err := url.Parse(str)
+	if err != nil || u.User == nil {
+		return str
+	}
+	if _, hasPass := u.User.Password(); !hasPass {
+		return str
+	}
+	u.User = url.UserPassword(u.User.Username(), RedactedValue)
+
+	// Keep the replacement readable rather than escaped.
+	escaped := url.UserPassword("", RedactedValue).String()
+	return strings.Replace(u.String(), escaped+"@", ":"+RedactedValue+"@", 1)
+}
+
+//------------------------------------------------------------------------------
+
+// MarshalYAML returns the config as YAML.
+func (r Redacted) MarshalYAML() (interface{}, error) {
+	return yaml.MapSlice(r), nil
+}
+
+// MarshalJSON returns the config as a JSON object.
+func (r Redacted) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := writeOrderedJSON(&buf, yaml.MapSlice(r)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func writeOrderedJSON(buf *bytes.Buffer, node interface{}) error {
+	switch t := node.(type) {
+	case yaml.MapSlice:
+		buf.WriteByte('{')
+		for i, item := range t {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			keyBytes, err := json.Marshal(fmt.Sprintf("%v", item.Key))
+			if err != nil {
+				return err
+			}
+			buf.Write(keyBytes)
+			buf.WriteByte(':')
+			if err = writeOrderedJSON(buf, item.Value); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+		return nil
+	case []interface{}:
+		buf.WriteByte('[')
+		for i, v := range t {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := writeOrderedJSON(buf, v); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+		return nil
+	}
+	valBytes, err := json.Marshal(node)
+	if err != nil {
+		return err
+	}
+	buf.Write(valBytes)
+	return nil
+}
+
+//------------------------------------------------------------------------------
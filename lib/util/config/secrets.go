@@ -0,0 +1,190 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+//------------------------------------------------------------------------------
+
+// SecretResolver obtains the value of a secret referenced by a URI within a
+// config.
+type SecretResolver interface {
+	// ResolveSecret returns the value of a secret along with the duration
+	// that the value is valid for, which is zero if it does not expire. The
+	// reference is the contents of the URI following the scheme and `://`.
+	ResolveSecret(ref string) (string, time.Duration, error)
+}
+
+var (
+	secretResolvers    = map[string]SecretResolver{}
+	secretResolversMut sync.RWMutex
+)
+
+// RegisterSecretResolver sets the resolver used for secret URIs with a scheme.
+// Config string values that are a URI with a registered scheme are replaced
+// with the value of the secret when a config is read.
+func RegisterSecretResolver(scheme string, r SecretResolver) {
+	secretResolversMut.Lock()
+	secretResolvers[scheme] = r
+	secretResolversMut.Unlock()
+}
+
+func init() {
+	RegisterSecretResolver("vault", vaultResolver{})
+	RegisterSecretResolver("aws-sm", awsSecretsManagerResolver{})
+}
+
+//------------------------------------------------------------------------------
+
+// parseSecretRef splits a secret reference of the form `<path>?<query>#<key>`
+// into its parts.
+func parseSecretRef(ref string) (path string, query url.Values, key string, err error) {
+	if i := strings.IndexByte(ref, '#'); i != -1 {
+		ref, key = ref[:i], ref[i+1:]
+	}
+	if i := strings.IndexByte(ref, '?'); i != -1 {
+		if query, err = url.ParseQuery(ref[i+1:]); err != nil {
+			return
+		}
+		ref = ref[:i]
+	} else {
+		query = url.Values{}
+	}
+	path = strings.Trim(ref, "/")
+	if len(path) == 0 {
+		err = errors.New("secret path is empty")
+	}
+	return
+}
+
+// secretField extracts a field from the structured contents of a secret,
+// where the key can be omitted if the secret has only one field.
+func secretField(data map[string]interface{}, key string) (string, error) {
+	if len(key) == 0 {
+		if len(data) != 1 {
+			return "", errors.New("the secret has multiple fields, a key must be specified after a '#'")
+		}
+		for k := range data {
+			key = k
+		}
+	}
+	v, exists := data[key]
+	if !exists {
+		return "", fmt.Errorf("key '%v' not found in secret", key)
+	}
+	if str, ok := v.(string); ok {
+		return str, nil
+	}
+	vBytes, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(vBytes), nil
+}
+
+//------------------------------------------------------------------------------
+
+// secretsReplacer replaces secret URIs within a parsed config with the value
+// of the secret, tracking the earliest expiry of any resolved secret.
+type secretsReplacer struct {
+	resolvers map[string]SecretResolver
+	expiry    time.Duration
+}
+
+func newSecretsReplacer() *secretsReplacer {
+	secretResolversMut.RLock()
+	resolvers := make(map[string]SecretResolver, len(secretResolvers))
+	for k, v := range secretResolvers {
+		resolvers[k] = v
+	}
+	secretResolversMut.RUnlock()
+	return &secretsReplacer{resolvers: resolvers}
+}
+
+// mightContainSecrets returns true if the raw bytes of a config contain a URI
+// with a registered scheme.
+func (s *secretsReplacer) mightContainSecrets(configBytes []byte) bool {
+	for scheme := range s.resolvers {
+		if bytes.Contains(configBytes, []byte(scheme+"://")) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *secretsReplacer) resolve(str string) (string, error) {
+	i := strings.Index(str, "://")
+	if i == -1 {
+		return str, nil
+	}
+	resolver, exists := s.resolvers[str[:i]]
+	if !exists {
+		return str, nil
+	}
+	value, ttl, err := resolver.ResolveSecret(str[i+3:])
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve secret '%v': %v", str, err)
+	}
+	if ttl > 0 && (s.expiry == 0 || ttl < s.expiry) {
+		s.expiry = ttl
+	}
+	return value, nil
+}
+
+// walk replaces each string value of a parsed YAML or JSON structure that is a
+// secret URI.
+func (s *secretsReplacer) walk(node interface{}) (interface{}, error) {
+	var err error
+	switch t := node.(type) {
+	case string:
+		return s.resolve(t)
+	case map[interface{}]interface{}:
+		for k, v := range t {
+			if t[k], err = s.walk(v); err != nil {
+				return nil, err
+			}
+		}
+	case map[string]interface{}:
+		for k, v := range t {
+			if t[k], err = s.walk(v); err != nil {
+				return nil, err
+			}
+		}
+	case []interface{}:
+		for i, v := range t {
+			if t[i], err = s.walk(v); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return node, nil
+}
+
+//------------------------------------------------------------------------------
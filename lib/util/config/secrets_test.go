@@ -0,0 +1,191 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package config
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type fakeResolver map[string]string
+
+func (f fakeResolver) ResolveSecret(ref string) (string, time.Duration, error) {
+	if v, exists := f[ref]; exists {
+		return v, time.Minute, nil
+	}
+	return "", 0, errors.New("nope")
+}
+
+type testConf struct {
+	User     string            `json:"user" yaml:"user"`
+	Password string            `json:"password" yaml:"password"`
+	Headers  map[string]string `json:"headers" yaml:"headers"`
+	Args     []string          `json:"args" yaml:"args"`
+}
+
+func TestReadWithSecrets(t *testing.T) {
+	RegisterSecretResolver("fake", fakeResolver{
+		"foo#bar": "secret1",
+		"baz":     "secret2",
+	})
+	defer func() {
+		secretResolversMut.Lock()
+		delete(secretResolvers, "fake")
+		secretResolversMut.Unlock()
+	}()
+
+	dir, err := ioutil.TempDir("", "benthos_secrets_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"conf.yaml": `
+user: not fake://foo#bar
+password: fake://foo#bar
+headers:
+  token: fake://baz
+args: [ "http://foo", fake://baz ]
+`,
+		"conf.json": `{
+	"user": "not fake://foo#bar",
+	"password": "fake://foo#bar",
+	"headers": {"token": "fake://baz"},
+	"args": ["http://foo", "fake://baz"]
+}`,
+	}
+
+	exp := testConf{
+		User:     "not fake://foo#bar",
+		Password: "secret1",
+		Headers:  map[string]string{"token": "secret2"},
+		Args:     []string{"http://foo", "secret2"},
+	}
+
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err = ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		var conf testConf
+		expiry, err := ReadWithExpiry(path, false, &conf)
+		if err != nil {
+			t.Errorf("%v: %v", name, err)
+			continue
+		}
+		if !reflect.DeepEqual(exp, conf) {
+			t.Errorf("%v: Wrong config: %+v != %+v", name, conf, exp)
+		}
+		if expiry != time.Minute {
+			t.Errorf("%v: Wrong expiry: %v", name, expiry)
+		}
+	}
+
+	path := filepath.Join(dir, "bad.yaml")
+	if err = ioutil.WriteFile(path, []byte("password: fake://nope"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	var conf testConf
+	if err = Read(path, false, &conf); err == nil {
+		t.Error("Expected error from unresolvable secret")
+	}
+}
+
+func TestVaultResolver(t *testing.T) {
+	var reqPath, reqToken string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reqPath, reqToken = r.URL.Path, r.Header.Get("X-Vault-Token")
+		switch r.URL.Path {
+		case "/v1/secret/data/foo":
+			w.Write([]byte(`{"lease_duration":0,"data":{"data":{"user":"foo","password":"bar"},"metadata":{"version":1}}}`))
+		case "/v1/kv/foo":
+			w.Write([]byte(`{"lease_duration":3600,"data":{"password":"baz"}}`))
+		case "/v1/database/creds/foo":
+			w.Write([]byte(`{"lease_duration":60,"data":{"username":"u","password":"p","port":5432}}`))
+		default:
+			http.Error(w, "nope", http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	os.Setenv("VAULT_ADDR", ts.URL)
+	os.Setenv("VAULT_TOKEN", "footoken")
+	defer os.Unsetenv("VAULT_ADDR")
+	defer os.Unsetenv("VAULT_TOKEN")
+
+	type result struct {
+		value  string
+		expiry time.Duration
+		err    bool
+	}
+	tests := map[string]result{
+		"secret/data/foo#password": {value: "bar"},
+		"secret/data/foo#nope":     {err: true},
+		"secret/data/foo":          {err: true},
+		"kv/foo":                   {value: "baz", expiry: time.Hour},
+		"/database/creds/foo#port": {value: "5432", expiry: time.Minute},
+		"missing#foo":              {err: true},
+		"":                         {err: true},
+	}
+
+	for ref, exp := range tests {
+		value, expiry, err := vaultResolver{}.ResolveSecret(ref)
+		if exp.err {
+			if err == nil {
+				t.Errorf("%v: expected error", ref)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%v: %v", ref, err)
+			continue
+		}
+		if value != exp.value || expiry != exp.expiry {
+			t.Errorf("%v: Wrong result: %v, %v != %v, %v", ref, value, expiry, exp.value, exp.expiry)
+		}
+	}
+
+	vaultResolver{}.ResolveSecret("kv/foo")
+	if exp, act := "/v1/kv/foo", reqPath; exp != act {
+		t.Errorf("Wrong request path: %v != %v", act, exp)
+	}
+	if exp, act := "footoken", reqToken; exp != act {
+		t.Errorf("Wrong request token: %v != %v", act, exp)
+	}
+}
+
+func TestParseSecretRef(t *testing.T) {
+	path, query, key, err := parseSecretRef("/foo/bar?region=eu-west-1#baz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path != "foo/bar" || query.Get("region") != "eu-west-1" || key != "baz" {
+		t.Errorf("Wrong result: %v, %v, %v", path, query, key)
+	}
+}
@@ -0,0 +1,208 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+
+	"gopkg.in/yaml.v2"
+)
+
+//------------------------------------------------------------------------------
+
+// templatesKey is the field of a config that lists template files to be used
+// within it.
+const templatesKey = "templates"
+
+// templateParam describes a parameter of a config template.
+type templateParam struct {
+	Description string      `yaml:"description"`
+	Default     interface{} `yaml:"default"`
+}
+
+// configTemplate is a component config that is defined once and instantiated
+// with arguments wherever its name is used as a component type.
+type configTemplate struct {
+	Name        string                   `yaml:"name"`
+	Description string                   `yaml:"description"`
+	Parameters  map[string]templateParam `yaml:"parameters"`
+	Template    string                   `yaml:"template"`
+
+	tmpl *template.Template
+}
+
+var templateFuncs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+}
+
+func readTemplate(path string, replaceEnvs bool) (*configTemplate, error) {
+	templateBytes, err := readConfigBytes(path, replaceEnvs)
+	if err != nil {
+		return nil, err
+	}
+	t := &configTemplate{}
+	if err = yaml.UnmarshalStrict(templateBytes, t); err != nil {
+		return nil, err
+	}
+	if len(t.Name) == 0 {
+		return nil, errors.New("template name must not be empty")
+	}
+	if t.tmpl, err = template.New(t.Name).
+		Option("missingkey=error").
+		Funcs(templateFuncs).
+		Parse(t.Template); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// instantiate renders the template with arguments and parses the result.
+func (t *configTemplate) instantiate(argsNode interface{}) (map[string]interface{}, error) {
+	var args map[string]interface{}
+	switch a := argsNode.(type) {
+	case nil:
+		args = map[string]interface{}{}
+	case map[string]interface{}:
+		args = make(map[string]interface{}, len(a))
+		for k, v := range a {
+			if _, exists := t.Parameters[k]; !exists {
+				return nil, fmt.Errorf("parameter '%v' not recognised", k)
+			}
+			args[k] = v
+		}
+	default:
+		return nil, fmt.Errorf("expected arguments to be an object, found: %v", a)
+	}
+
+	var missing []string
+	for k, p := range t.Parameters {
+		if _, exists := args[k]; exists {
+			continue
+		}
+		if p.Default == nil {
+			missing = append(missing, k)
+			continue
+		}
+		args[k] = normaliseTree(p.Default)
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return nil, fmt.Errorf("missing required parameters: %v", strings.Join(missing, ", "))
+	}
+
+	var buf bytes.Buffer
+	if err := t.tmpl.Execute(&buf, args); err != nil {
+		return nil, err
+	}
+	return parseTree(buf.Bytes(), false)
+}
+
+//------------------------------------------------------------------------------
+
+// expandTemplates removes the templates field from a config tree, reads the
+// templates it lists, and replaces each component within the tree that has a
+// template name as its type with the instantiated template.
+func expandTemplates(tree map[string]interface{}, replaceEnvs bool) error {
+	pathsNode, exists := tree[templatesKey]
+	if !exists {
+		return nil
+	}
+	delete(tree, templatesKey)
+
+	paths, _ := pathsNode.([]interface{})
+	seen := map[string]struct{}{}
+	templates := map[string]*configTemplate{}
+	for _, p := range paths {
+		path, _ := p.(string)
+		if absPath, err := filepath.Abs(path); err == nil {
+			if _, exists := seen[absPath]; exists {
+				continue
+			}
+			seen[absPath] = struct{}{}
+		}
+		t, err := readTemplate(path, replaceEnvs)
+		if err != nil {
+			return fmt.Errorf("failed to read template '%v': %v", path, err)
+		}
+		if _, exists := templates[t.Name]; exists {
+			return fmt.Errorf("template name '%v' from file '%v' already in use", t.Name, path)
+		}
+		templates[t.Name] = t
+	}
+
+	for k, v := range tree {
+		expanded, err := walkTemplates(templates, v, nil)
+		if err != nil {
+			return err
+		}
+		tree[k] = expanded
+	}
+	return nil
+}
+
+func walkTemplates(templates map[string]*configTemplate, node interface{}, stack []string) (interface{}, error) {
+	var err error
+	switch t := node.(type) {
+	case map[string]interface{}:
+		typeStr, _ := t["type"].(string)
+		if tmpl, exists := templates[typeStr]; exists {
+			for _, name := range stack {
+				if name == typeStr {
+					return nil, fmt.Errorf("template cycle: %v", strings.Join(append(stack, typeStr), " -> "))
+				}
+			}
+			for k := range t {
+				if k != "type" && k != typeStr {
+					return nil, fmt.Errorf("template '%v': field '%v' not recognised", typeStr, k)
+				}
+			}
+			var expanded map[string]interface{}
+			if expanded, err = tmpl.instantiate(t[typeStr]); err != nil {
+				return nil, fmt.Errorf("template '%v': %v", typeStr, err)
+			}
+			return walkTemplates(templates, expanded, append(stack, typeStr))
+		}
+		for k, v := range t {
+			if t[k], err = walkTemplates(templates, v, stack); err != nil {
+				return nil, err
+			}
+		}
+	case []interface{}:
+		for i, v := range t {
+			if t[i], err = walkTemplates(templates, v, stack); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return node, nil
+}
+
+//------------------------------------------------------------------------------
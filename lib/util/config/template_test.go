@@ -0,0 +1,189 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testKafkaTemplate = `
+name: standard_kafka
+description: Our standard Kafka input.
+parameters:
+  topics:
+    description: The topics to consume from.
+  group:
+    default: benthos
+template: |
+  type: kafka_balanced
+  kafka_balanced:
+    addresses: [ "localhost:9092" ]
+    topics: {{ json .topics }}
+    consumer_group: {{ .group }}
+`
+
+func TestReadTreeTemplates(t *testing.T) {
+	dir := writeIncludeFiles(t, map[string]string{
+		"templates/kafka.yaml": testKafkaTemplate,
+		"templates/filter.yaml": `
+name: only_foo
+template: |
+  type: filter
+  filter:
+    type: text
+    text:
+      operator: contains
+      arg: foo
+`,
+		"root.yaml": `
+templates: ./templates/*.yaml
+input:
+  type: standard_kafka
+  standard_kafka:
+    topics: [ foo, bar ]
+pipeline:
+  processors:
+  - type: only_foo
+  - type: noop
+`,
+	})
+	defer os.RemoveAll(dir)
+
+	tree, err := ReadTree(filepath.Join(dir, "root.yaml"), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exp := map[string]interface{}{
+		"input": map[string]interface{}{
+			"type": "kafka_balanced",
+			"kafka_balanced": map[string]interface{}{
+				"addresses":      []interface{}{"localhost:9092"},
+				"topics":         []interface{}{"foo", "bar"},
+				"consumer_group": "benthos",
+			},
+		},
+		"pipeline": map[string]interface{}{
+			"processors": []interface{}{
+				map[string]interface{}{
+					"type": "filter",
+					"filter": map[string]interface{}{
+						"type": "text",
+						"text": map[string]interface{}{
+							"operator": "contains",
+							"arg":      "foo",
+						},
+					},
+				},
+				map[string]interface{}{"type": "noop"},
+			},
+		},
+	}
+	if !reflect.DeepEqual(exp, tree) {
+		t.Errorf("Wrong result: %v != %v", tree, exp)
+	}
+}
+
+func TestReadTreeTemplatesFromInclude(t *testing.T) {
+	dir := writeIncludeFiles(t, map[string]string{
+		"common/kafka.yaml":  testKafkaTemplate,
+		"common/common.yaml": "templates: ./kafka.yaml",
+		"streams/foo.yaml": `
+include: ../common/common.yaml
+input:
+  type: standard_kafka
+  standard_kafka:
+    topics: [ foo ]
+    group: foos
+`,
+	})
+	defer os.RemoveAll(dir)
+
+	tree, err := ReadTree(filepath.Join(dir, "streams", "foo.yaml"), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exp := map[string]interface{}{
+		"input": map[string]interface{}{
+			"type": "kafka_balanced",
+			"kafka_balanced": map[string]interface{}{
+				"addresses":      []interface{}{"localhost:9092"},
+				"topics":         []interface{}{"foo"},
+				"consumer_group": "foos",
+			},
+		},
+	}
+	if !reflect.DeepEqual(exp, tree) {
+		t.Errorf("Wrong result: %v != %v", tree, exp)
+	}
+}
+
+func TestReadTreeTemplateErrors(t *testing.T) {
+	dir := writeIncludeFiles(t, map[string]string{
+		"kafka.yaml": testKafkaTemplate,
+		"a.yaml": `
+name: a
+template: "type: b"
+`,
+		"b.yaml": `
+name: b
+template: "type: a"
+`,
+		"missing.yaml": `
+templates: kafka.yaml
+input:
+  type: standard_kafka
+`,
+		"unknown.yaml": `
+templates: kafka.yaml
+input:
+  type: standard_kafka
+  standard_kafka:
+    topics: [ foo ]
+    nope: bar
+`,
+		"cycle.yaml": `
+templates: [ a.yaml, b.yaml ]
+input:
+  type: a
+`,
+	})
+	defer os.RemoveAll(dir)
+
+	tests := map[string]string{
+		"missing.yaml": "missing required parameters: topics",
+		"unknown.yaml": "parameter 'nope' not recognised",
+		"cycle.yaml":   "template cycle: a -> b -> a",
+	}
+	for name, exp := range tests {
+		_, err := ReadTree(filepath.Join(dir, name), false)
+		if err == nil {
+			t.Errorf("Expected error from %v", name)
+		} else if !strings.Contains(err.Error(), exp) {
+			t.Errorf("Wrong error from %v: %v", name, err)
+		}
+	}
+}
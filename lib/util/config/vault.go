@@ -0,0 +1,100 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+//------------------------------------------------------------------------------
+
+var vaultClient = &http.Client{
+	Timeout: time.Second * 10,
+}
+
+// vaultResolver resolves secret URIs of the form `vault://<path>#<key>` by
+// reading the path from the HashiCorp Vault HTTP API. The address and token of
+// Vault are read from the environment variables VAULT_ADDR and VAULT_TOKEN.
+type vaultResolver struct{}
+
+func (vaultResolver) ResolveSecret(ref string) (string, time.Duration, error) {
+	path, _, key, err := parseSecretRef(ref)
+	if err != nil {
+		return "", 0, err
+	}
+
+	addr := os.Getenv("VAULT_ADDR")
+	if len(addr) == 0 {
+		addr = "https://127.0.0.1:8200"
+	}
+	req, err := http.NewRequest("GET", strings.TrimSuffix(addr, "/")+"/v1/"+path, nil)
+	if err != nil {
+		return "", 0, err
+	}
+	if token := os.Getenv("VAULT_TOKEN"); len(token) > 0 {
+		req.Header.Set("X-Vault-Token", token)
+	}
+
+	res, err := vaultClient.Do(req)
+	if err != nil {
+		return "", 0, err
+	}
+	defer res.Body.Close()
+
+	switch res.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return "", 0, errors.New("secret not found")
+	default:
+		return "", 0, fmt.Errorf("unexpected status code from vault: %v", res.StatusCode)
+	}
+
+	var body struct {
+		LeaseDuration int64                  `json:"lease_duration"`
+		Data          map[string]interface{} `json:"data"`
+	}
+	if err = json.NewDecoder(res.Body).Decode(&body); err != nil {
+		return "", 0, fmt.Errorf("failed to parse vault response: %v", err)
+	}
+
+	// Secrets of version 2 of the key/value engine are nested along with
+	// metadata.
+	data := body.Data
+	if nested, ok := data["data"].(map[string]interface{}); ok {
+		if _, hasMeta := data["metadata"]; hasMeta {
+			data = nested
+		}
+	}
+
+	value, err := secretField(data, key)
+	if err != nil {
+		return "", 0, err
+	}
+	return value, time.Duration(body.LeaseDuration) * time.Second, nil
+}
+
+//------------------------------------------------------------------------------
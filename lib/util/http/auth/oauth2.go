@@ -0,0 +1,158 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+//------------------------------------------------------------------------------
+
+// OAuth2Config holds the configuration parameters for an OAuth2 client
+// credentials exchange.
+type OAuth2Config struct {
+	Enabled      bool     `json:"enabled" yaml:"enabled"`
+	ClientKey    string   `json:"client_key" yaml:"client_key"`
+	ClientSecret string   `json:"client_secret" yaml:"client_secret"`
+	TokenURL     string   `json:"token_url" yaml:"token_url"`
+	Scopes       []string `json:"scopes" yaml:"scopes"`
+}
+
+// NewOAuth2Config returns a new OAuth2Config with default values.
+func NewOAuth2Config() OAuth2Config {
+	return OAuth2Config{
+		Enabled:      false,
+		ClientKey:    "",
+		ClientSecret: "",
+		TokenURL:     "",
+		Scopes:       []string{},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// oauth2RefreshMargin is how long before the expiry of a token it is
+// refreshed.
+const oauth2RefreshMargin = 30 * time.Second
+
+// OAuth2TokenSource obtains access tokens from an OAuth2 token endpoint using
+// the client credentials grant. Tokens are cached and refreshed shortly before
+// they expire.
+type OAuth2TokenSource struct {
+	conf   OAuth2Config
+	client http.Client
+
+	mut     sync.Mutex
+	token   string
+	expires time.Time
+	nowFn   func() time.Time
+}
+
+// NewTokenSource creates a token source from the config, where token requests
+// are made with the provided client.
+func (o OAuth2Config) NewTokenSource(client http.Client) *OAuth2TokenSource {
+	return &OAuth2TokenSource{
+		conf:   o,
+		client: client,
+		nowFn:  time.Now,
+	}
+}
+
+type oauth2TokenResponse struct {
+	AccessToken string `json:"access_token"`
+	ExpiresIn   int64  `json:"expires_in"`
+}
+
+// Token returns a cached access token, or requests a new one from the token
+// endpoint when the cached token is absent or about to expire.
+func (o *OAuth2TokenSource) Token() (string, error) {
+	o.mut.Lock()
+	defer o.mut.Unlock()
+
+	if len(o.token) > 0 && (o.expires.IsZero() || o.nowFn().Add(oauth2RefreshMargin).Before(o.expires)) {
+		return o.token, nil
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "client_credentials")
+	if len(o.conf.Scopes) > 0 {
+		form.Set("scope", strings.Join(o.conf.Scopes, " "))
+	}
+
+	req, err := http.NewRequest("POST", o.conf.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(url.QueryEscape(o.conf.ClientKey), url.QueryEscape(o.conf.ClientSecret))
+
+	res, err := o.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to request token: %v", err)
+	}
+	defer res.Body.Close()
+
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read token response: %v", err)
+	}
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return "", fmt.Errorf("token endpoint returned status %v: %s", res.StatusCode, body)
+	}
+
+	var tRes oauth2TokenResponse
+	if err = json.Unmarshal(body, &tRes); err != nil {
+		return "", fmt.Errorf("failed to parse token response: %v", err)
+	}
+	if len(tRes.AccessToken) == 0 {
+		return "", errors.New("token response did not contain an access_token")
+	}
+
+	o.token = tRes.AccessToken
+	o.expires = time.Time{}
+	if tRes.ExpiresIn > 0 {
+		o.expires = o.nowFn().Add(time.Duration(tRes.ExpiresIn) * time.Second)
+	}
+	return o.token, nil
+}
+
+// Sign method to set a bearer token on an HTTP request when OAuth2 is enabled.
+func (o *OAuth2TokenSource) Sign(req *http.Request) error {
+	if !o.conf.Enabled {
+		return nil
+	}
+	token, err := o.Token()
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	return nil
+}
+
+//------------------------------------------------------------------------------
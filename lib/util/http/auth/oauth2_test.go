@@ -0,0 +1,90 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package auth
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestOAuth2TokenRefresh(t *testing.T) {
+	var reqs int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&reqs, 1)
+		fmt.Fprintf(w, `{"access_token":"token%v","expires_in":60}`, n)
+	}))
+	defer ts.Close()
+
+	conf := NewOAuth2Config()
+	conf.Enabled = true
+	conf.TokenURL = ts.URL
+
+	now := time.Now()
+	src := conf.NewTokenSource(http.Client{})
+	src.nowFn = func() time.Time { return now }
+
+	check := func(exp string) {
+		t.Helper()
+		req, _ := http.NewRequest("GET", "http://example.com", nil)
+		if err := src.Sign(req); err != nil {
+			t.Fatal(err)
+		}
+		if act := req.Header.Get("Authorization"); act != exp {
+			t.Errorf("Wrong auth header: %v != %v", act, exp)
+		}
+	}
+
+	check("Bearer token1")
+	now = now.Add(20 * time.Second)
+	check("Bearer token1")
+	now = now.Add(20 * time.Second)
+	check("Bearer token2")
+}
+
+func TestOAuth2TokenError(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "nope", http.StatusUnauthorized)
+	}))
+	defer ts.Close()
+
+	conf := NewOAuth2Config()
+	conf.Enabled = true
+	conf.TokenURL = ts.URL
+
+	req, _ := http.NewRequest("GET", "http://example.com", nil)
+	if err := conf.NewTokenSource(http.Client{}).Sign(req); err == nil {
+		t.Error("Expected error")
+	}
+}
+
+func TestOAuth2Disabled(t *testing.T) {
+	req, _ := http.NewRequest("GET", "http://example.com", nil)
+	if err := NewOAuth2Config().NewTokenSource(http.Client{}).Sign(req); err != nil {
+		t.Fatal(err)
+	}
+	if act := req.Header.Get("Authorization"); len(act) > 0 {
+		t.Errorf("Unexpected auth header: %v", act)
+	}
+}
@@ -34,7 +34,9 @@ import (
 
 	"github.com/Jeffail/benthos/lib/log"
 	"github.com/Jeffail/benthos/lib/message"
+	"github.com/Jeffail/benthos/lib/message/metadata"
 	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/tracing"
 	"github.com/Jeffail/benthos/lib/types"
 	"github.com/Jeffail/benthos/lib/util/http/auth"
 	"github.com/Jeffail/benthos/lib/util/text"
@@ -46,17 +48,20 @@ import (
 
 // Config is a configuration struct for an HTTP client.
 type Config struct {
-	URL          string            `json:"url" yaml:"url"`
-	Verb         string            `json:"verb" yaml:"verb"`
-	Headers      map[string]string `json:"headers" yaml:"headers"`
-	RateLimit    string            `json:"rate_limit" yaml:"rate_limit"`
-	TimeoutMS    int64             `json:"timeout_ms" yaml:"timeout_ms"`
-	RetryMS      int64             `json:"retry_period_ms" yaml:"retry_period_ms"`
-	MaxBackoffMS int64             `json:"max_retry_backoff_ms" yaml:"max_retry_backoff_ms"`
-	NumRetries   int               `json:"retries" yaml:"retries"`
-	BackoffOn    []int             `json:"backoff_on" yaml:"backoff_on"`
-	DropOn       []int             `json:"drop_on" yaml:"drop_on"`
-	TLS          tls.Config        `json:"tls" yaml:"tls"`
+	URL          string                 `json:"url" yaml:"url"`
+	Verb         string                 `json:"verb" yaml:"verb"`
+	Headers      map[string]string      `json:"headers" yaml:"headers"`
+	Metadata     metadata.MappingConfig `json:"metadata" yaml:"metadata"`
+	RateLimit    string                 `json:"rate_limit" yaml:"rate_limit"`
+	TimeoutMS    int64                  `json:"timeout_ms" yaml:"timeout_ms"`
+	RetryMS      int64                  `json:"retry_period_ms" yaml:"retry_period_ms"`
+	MaxBackoffMS int64                  `json:"max_retry_backoff_ms" yaml:"max_retry_backoff_ms"`
+	NumRetries   int                    `json:"retries" yaml:"retries"`
+	BackoffOn    []int                  `json:"backoff_on" yaml:"backoff_on"`
+	DropOn       []int                  `json:"drop_on" yaml:"drop_on"`
+	FailOn       []int                  `json:"fail_on" yaml:"fail_on"`
+	TLS          tls.Config             `json:"tls" yaml:"tls"`
+	OAuth2       auth.OAuth2Config      `json:"oauth2" yaml:"oauth2"`
 	auth.Config  `json:",inline" yaml:",inline"`
 }
 
@@ -68,6 +73,7 @@ func NewConfig() Config {
 		Headers: map[string]string{
 			"Content-Type": "application/octet-stream",
 		},
+		Metadata:     metadata.NewMappingConfig(),
 		RateLimit:    "",
 		TimeoutMS:    5000,
 		RetryMS:      1000,
@@ -75,7 +81,9 @@ func NewConfig() Config {
 		NumRetries:   3,
 		BackoffOn:    []int{429},
 		DropOn:       []int{},
+		FailOn:       []int{},
 		TLS:          tls.NewConfig(),
+		OAuth2:       auth.NewOAuth2Config(),
 		Config:       auth.NewConfig(),
 	}
 }
@@ -88,9 +96,12 @@ type Type struct {
 
 	backoffOn map[int]struct{}
 	dropOn    map[int]struct{}
+	failOn    map[int]struct{}
 
 	url     *text.InterpolatedString
 	headers map[string]*text.InterpolatedString
+	meta    *metadata.Mapping
+	oauth2  *auth.OAuth2TokenSource
 
 	conf          Config
 	retryThrottle *throttle.Type
@@ -100,6 +111,8 @@ type Type struct {
 	stats metrics.Type
 	mgr   types.Manager
 
+	tracingComponent string
+
 	mCount    metrics.StatCounter
 	mErr      metrics.StatCounter
 	mErrReq   metrics.StatCounter
@@ -126,7 +139,11 @@ func New(conf Config, opts ...func(*Type)) (*Type, error) {
 		mgr:       types.NoopMgr(),
 		backoffOn: map[int]struct{}{},
 		dropOn:    map[int]struct{}{},
+		failOn:    map[int]struct{}{},
 		headers:   map[string]*text.InterpolatedString{},
+		meta:      metadata.NewMapping(conf.Metadata),
+
+		tracingComponent: "http_client",
 	}
 
 	h.client.Timeout = time.Duration(h.conf.TimeoutMS) * time.Millisecond
@@ -140,12 +157,17 @@ func New(conf Config, opts ...func(*Type)) (*Type, error) {
 		}
 	}
 
+	h.oauth2 = conf.OAuth2.NewTokenSource(h.client)
+
 	for _, c := range conf.BackoffOn {
 		h.backoffOn[c] = struct{}{}
 	}
 	for _, c := range conf.DropOn {
 		h.dropOn[c] = struct{}{}
 	}
+	for _, c := range conf.FailOn {
+		h.failOn[c] = struct{}{}
+	}
 
 	for k, v := range conf.Headers {
 		h.headers[k] = text.NewInterpolatedString(v)
@@ -214,6 +236,14 @@ func OptSetManager(mgr types.Manager) func(*Type) {
 	}
 }
 
+// OptSetTracingComponent sets the component name used for selecting the
+// formats of trace propagation headers. Defaults to http_client.
+func OptSetTracingComponent(name string) func(*Type) {
+	return func(t *Type) {
+		t.tracingComponent = name
+	}
+}
+
 //------------------------------------------------------------------------------
 
 func (h *Type) incrCode(code int) {
@@ -263,7 +293,15 @@ func (h *Type) waitForAccess() bool {
 
 // CreateRequest creates an HTTP request out of a single message.
 func (h *Type) CreateRequest(msg types.Message) (req *http.Request, err error) {
-	url := h.url.Get(msg)
+	return h.createRequest(msg, "")
+}
+
+// createRequest creates an HTTP request out of a single message and a target
+// URL, if the URL is empty then the configured URL is used.
+func (h *Type) createRequest(msg types.Message, url string) (req *http.Request, err error) {
+	if len(url) == 0 {
+		url = h.url.Get(msg)
+	}
 
 	if msg == nil || msg.Len() == 0 {
 		if req, err = http.NewRequest(h.conf.Verb, url, nil); err == nil {
@@ -277,6 +315,12 @@ func (h *Type) CreateRequest(msg types.Message) (req *http.Request, err error) {
 			for k, v := range h.headers {
 				req.Header.Add(k, v.Get(msg))
 			}
+			h.meta.Iter(msg.Get(0).Metadata(), func(k, v string) error {
+				if len(req.Header.Get(k)) == 0 {
+					req.Header.Set(k, v)
+				}
+				return nil
+			})
 		}
 	} else {
 		body := &bytes.Buffer{}
@@ -287,10 +331,17 @@ func (h *Type) CreateRequest(msg types.Message) (req *http.Request, err error) {
 			if v, exists := h.headers["Content-Type"]; exists {
 				contentType = v.Get(msg)
 			}
-			var part io.Writer
-			if part, err = writer.CreatePart(textproto.MIMEHeader{
+			headers := textproto.MIMEHeader{
 				"Content-Type": []string{contentType},
-			}); err == nil {
+			}
+			h.meta.Iter(msg.Get(i).Metadata(), func(k, v string) error {
+				if len(headers.Get(k)) == 0 {
+					headers.Set(k, v)
+				}
+				return nil
+			})
+			var part io.Writer
+			if part, err = writer.CreatePart(headers); err == nil {
 				_, err = io.Copy(part, bytes.NewReader(msg.Get(i).Get()))
 			}
 		}
@@ -305,7 +356,23 @@ func (h *Type) CreateRequest(msg types.Message) (req *http.Request, err error) {
 		}
 	}
 
-	err = h.conf.Config.Sign(req)
+	// Propagate the trace of the first message part unless the headers have
+	// been explicitly configured.
+	if err == nil && msg != nil && msg.Len() > 0 {
+		for k, v := range tracing.InjectHeaders(h.tracingComponent, msg.Get(0).Metadata()) {
+			if len(req.Header.Get(k)) == 0 {
+				req.Header.Set(k, v)
+			}
+		}
+	}
+
+	if err != nil {
+		return
+	}
+	if err = h.conf.Config.Sign(req); err != nil {
+		return
+	}
+	err = h.oauth2.Sign(req)
 	return
 }
 
@@ -373,28 +440,39 @@ func (h *Type) ParseResponse(res *http.Response) (resMsg types.Message, err erro
 
 // checkStatus compares a returned status code against configured logic
 // determining whether the send is resolved, and if not whether the retry should
-// be linear.
-func (h *Type) checkStatus(code int) (resolved bool, linearRetry bool) {
+// be linear or whether the failure is permanent and should not be retried.
+func (h *Type) checkStatus(code int) (resolved bool, linearRetry bool, permanent bool) {
 	if _, exists := h.dropOn[code]; exists {
-		return true, false
+		return true, false, false
+	}
+	if _, exists := h.failOn[code]; exists {
+		return false, false, true
 	}
 	if _, exists := h.backoffOn[code]; exists {
-		return false, false
+		return false, false, false
 	}
 	if code < 200 || code > 299 {
-		return false, true
+		return false, true, false
 	}
-	return true, false
+	return true, false, false
 }
 
 // Do attempts to create and perform an HTTP request from a message payload.
 // This attempt may include retries, and if all retries fail an error is
 // returned.
 func (h *Type) Do(msg types.Message) (res *http.Response, err error) {
+	return h.DoURL(msg, "")
+}
+
+// DoURL attempts to create and perform an HTTP request from a message payload
+// to a specific URL rather than the configured one, which is used when the URL
+// is empty. This attempt may include retries, and if all retries fail an error
+// is returned.
+func (h *Type) DoURL(msg types.Message, url string) (res *http.Response, err error) {
 	h.mCount.Incr(1)
 
 	var req *http.Request
-	if req, err = h.CreateRequest(msg); err != nil {
+	if req, err = h.createRequest(msg, url); err != nil {
 		h.mErrReq.Incr(1)
 		h.mErr.Incr(1)
 		return nil, err
@@ -405,10 +483,11 @@ func (h *Type) Do(msg types.Message) (res *http.Response, err error) {
 	if !h.waitForAccess() {
 		return nil, types.ErrTypeClosed
 	}
-	rateLimited := false
+	rateLimited, permanent := false, false
 	if res, err = h.client.Do(req); err == nil {
 		h.incrCode(res.StatusCode)
-		if resolved, linear := h.checkStatus(res.StatusCode); !resolved {
+		var resolved, linear bool
+		if resolved, linear, permanent = h.checkStatus(res.StatusCode); !resolved {
 			rateLimited = !linear
 			err = types.ErrUnexpectedHTTPRes{Code: res.StatusCode, S: res.Status}
 			if res.Body != nil {
@@ -418,11 +497,11 @@ func (h *Type) Do(msg types.Message) (res *http.Response, err error) {
 	}
 
 	i, j := 0, h.conf.NumRetries
-	for i < j && err != nil {
+	for i < j && err != nil && !permanent {
 		h.mErrRes.Incr(1)
 		h.mErr.Incr(1)
 
-		req, err = h.CreateRequest(msg)
+		req, err = h.createRequest(msg, url)
 		if err != nil {
 			h.mErrReq.Incr(1)
 			h.mErr.Incr(1)
@@ -443,7 +522,8 @@ func (h *Type) Do(msg types.Message) (res *http.Response, err error) {
 		rateLimited = false
 		if res, err = h.client.Do(req); err == nil {
 			h.incrCode(res.StatusCode)
-			if resolved, linear := h.checkStatus(res.StatusCode); !resolved {
+			var resolved, linear bool
+			if resolved, linear, permanent = h.checkStatus(res.StatusCode); !resolved {
 				rateLimited = !linear
 				err = types.ErrUnexpectedHTTPRes{Code: res.StatusCode, S: res.Status}
 				if res.Body != nil {
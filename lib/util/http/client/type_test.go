@@ -30,6 +30,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"net/textproto"
+	"reflect"
 	"strings"
 	"sync/atomic"
 	"testing"
@@ -71,6 +72,35 @@ func TestHTTPClientRetries(t *testing.T) {
 	}
 }
 
+func TestHTTPClientFailOn(t *testing.T) {
+	var reqCount uint32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddUint32(&reqCount, 1)
+		http.Error(w, "test error", http.StatusBadRequest)
+		return
+	}))
+	defer ts.Close()
+
+	conf := NewConfig()
+	conf.URL = ts.URL + "/testpost"
+	conf.RetryMS = 1
+	conf.NumRetries = 3
+	conf.FailOn = []int{400}
+
+	h, err := New(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := h.Send(message.New([][]byte{[]byte("test")})); err == nil {
+		t.Error("Expected error from permanent failure")
+	}
+
+	if exp, act := uint32(1), atomic.LoadUint32(&reqCount); exp != act {
+		t.Errorf("Wrong count of HTTP attempts: %v != %v", exp, act)
+	}
+}
+
 func TestHTTPClientSendBasic(t *testing.T) {
 	nTestLoops := 1000
 
@@ -123,6 +153,63 @@ func TestHTTPClientSendBasic(t *testing.T) {
 	}
 }
 
+func TestHTTPClientOAuth2(t *testing.T) {
+	var tokenReqs int32
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&tokenReqs, 1)
+		if user, pass, _ := r.BasicAuth(); user != "foo" || pass != "bar" {
+			http.Error(w, "bad credentials", http.StatusUnauthorized)
+			return
+		}
+		if exp, act := "client_credentials", r.FormValue("grant_type"); exp != act {
+			t.Errorf("Wrong grant type: %v != %v", act, exp)
+		}
+		if exp, act := "read write", r.FormValue("scope"); exp != act {
+			t.Errorf("Wrong scope: %v != %v", act, exp)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"access_token":"baz","token_type":"bearer","expires_in":3600}`))
+	}))
+	defer tokenServer.Close()
+
+	authChan := make(chan string, 1)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authChan <- r.Header.Get("Authorization")
+	}))
+	defer ts.Close()
+
+	conf := NewConfig()
+	conf.URL = ts.URL + "/testpost"
+	conf.OAuth2.Enabled = true
+	conf.OAuth2.ClientKey = "foo"
+	conf.OAuth2.ClientSecret = "bar"
+	conf.OAuth2.TokenURL = tokenServer.URL
+	conf.OAuth2.Scopes = []string{"read", "write"}
+
+	h, err := New(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 3; i++ {
+		if _, err = h.Send(message.New([][]byte{[]byte("hello world")})); err != nil {
+			t.Fatal(err)
+		}
+		select {
+		case auth := <-authChan:
+			if exp := "Bearer baz"; auth != exp {
+				t.Errorf("Wrong auth header: %v != %v", auth, exp)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("Action timed out")
+		}
+	}
+
+	if exp, act := int32(1), atomic.LoadInt32(&tokenReqs); exp != act {
+		t.Errorf("Wrong count of token requests: %v != %v", act, exp)
+	}
+}
+
 func TestHTTPClientSendInterpolate(t *testing.T) {
 	nTestLoops := 1000
 
@@ -192,6 +279,50 @@ func TestHTTPClientSendInterpolate(t *testing.T) {
 	}
 }
 
+func TestHTTPClientMetadataHeaders(t *testing.T) {
+	conf := NewConfig()
+	conf.URL = "http://localhost:4195/post"
+	conf.Headers["X-Static"] = "foo"
+	conf.Metadata.IncludePrefixes = []string{"app_"}
+	conf.Metadata.ExcludePrefixes = []string{"app_secret"}
+	conf.Metadata.Rename = map[string]string{
+		"app_id":     "X-App-ID",
+		"app_static": "X-Static",
+	}
+
+	h, err := New(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testMsg := message.New([][]byte{[]byte("hello world")})
+	testMsg.Get(0).Metadata().
+		Set("app_id", "1").
+		Set("app_name", "foo").
+		Set("app_secret", "2").
+		Set("app_static", "bar").
+		Set("other", "3")
+
+	req, err := h.CreateRequest(testMsg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exp := map[string]string{
+		"X-App-Id":     "1",
+		"App_name":     "foo",
+		"X-Static":     "foo",
+		"Content-Type": "application/octet-stream",
+	}
+	act := map[string]string{}
+	for k := range req.Header {
+		act[k] = req.Header.Get(k)
+	}
+	if !reflect.DeepEqual(exp, act) {
+		t.Errorf("Wrong headers: %v != %v", act, exp)
+	}
+}
+
 func TestHTTPClientSendMultipart(t *testing.T) {
 	nTestLoops := 1000
 
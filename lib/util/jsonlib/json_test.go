@@ -0,0 +1,66 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package jsonlib
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestCompatibleWithStandardLibrary(t *testing.T) {
+	docs := []string{
+		`{"foo":"bar","baz":[1,2.5,true,null,{"nested":"value"}]}`,
+		`["a","b"]`,
+		`"just a string"`,
+		`10`,
+	}
+
+	for _, doc := range docs {
+		var exp, act interface{}
+		if err := json.Unmarshal([]byte(doc), &exp); err != nil {
+			t.Fatal(err)
+		}
+		if err := Unmarshal([]byte(doc), &act); err != nil {
+			t.Fatalf("%v: %v", Name, err)
+		}
+		if !reflect.DeepEqual(exp, act) {
+			t.Errorf("Wrong parsed result from %v: %v != %v", Name, act, exp)
+		}
+
+		expBytes, err := json.Marshal(exp)
+		if err != nil {
+			t.Fatal(err)
+		}
+		actBytes, err := Marshal(act)
+		if err != nil {
+			t.Fatalf("%v: %v", Name, err)
+		}
+		if string(expBytes) != string(actBytes) {
+			t.Errorf("Wrong serialised result from %v: %s != %s", Name, actBytes, expBytes)
+		}
+	}
+
+	var v interface{}
+	if err := Unmarshal([]byte(`{"foo":`), &v); err == nil {
+		t.Errorf("Expected error from %v with invalid JSON", Name)
+	}
+}
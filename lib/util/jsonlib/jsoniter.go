@@ -0,0 +1,47 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// +build JSONITER
+
+package jsonlib
+
+import (
+	jsoniter "github.com/json-iterator/go"
+)
+
+//------------------------------------------------------------------------------
+
+// Name is the name of the JSON library in use.
+const Name = "json-iterator"
+
+var api = jsoniter.ConfigCompatibleWithStandardLibrary
+
+// Marshal returns the JSON encoding of v.
+func Marshal(v interface{}) ([]byte, error) {
+	return api.Marshal(v)
+}
+
+// Unmarshal parses JSON encoded data and stores the result in the value
+// pointed to by v.
+func Unmarshal(data []byte, v interface{}) error {
+	return api.Unmarshal(data, v)
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,197 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package sasl
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"hash"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+//------------------------------------------------------------------------------
+
+// SCRAMClient implements the client side of a SCRAM exchange as described in
+// RFC 5802, and satisfies the sarama.SCRAMClient interface.
+type SCRAMClient struct {
+	hashFn func() hash.Hash
+	nonce  func() (string, error)
+
+	user     string
+	password string
+	authzID  string
+
+	step            int
+	clientNonce     string
+	clientFirstBare string
+	serverSignature []byte
+	done            bool
+}
+
+// NewSCRAMClient creates a SCRAM client using a hash function, e.g. sha256.New
+// for SCRAM-SHA-256.
+func NewSCRAMClient(hashFn func() hash.Hash) *SCRAMClient {
+	return &SCRAMClient{
+		hashFn: hashFn,
+		nonce:  randomNonce,
+	}
+}
+
+func randomNonce() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawStdEncoding.EncodeToString(b), nil
+}
+
+// saslName escapes a user name as required by the SCRAM protocol.
+func saslName(name string) string {
+	return strings.Replace(strings.Replace(name, "=", "=3D", -1), ",", "=2C", -1)
+}
+
+// parseAttributes parses a SCRAM message of comma separated key=value pairs.
+func parseAttributes(msg string) map[string]string {
+	attrs := map[string]string{}
+	for _, kv := range strings.Split(msg, ",") {
+		if len(kv) > 1 && kv[1] == '=' {
+			attrs[kv[:1]] = kv[2:]
+		}
+	}
+	return attrs
+}
+
+func (s *SCRAMClient) hmac(key []byte, data string) []byte {
+	h := hmac.New(s.hashFn, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+//------------------------------------------------------------------------------
+
+// Begin prepares the client for a SCRAM exchange.
+func (s *SCRAMClient) Begin(user, password, authzID string) error {
+	nonce, err := s.nonce()
+	if err != nil {
+		return err
+	}
+	s.user, s.password, s.authzID = user, password, authzID
+	s.clientNonce = nonce
+	s.step = 0
+	s.done = false
+	return nil
+}
+
+// Step processes a challenge from the server and returns the response.
+func (s *SCRAMClient) Step(challenge string) (string, error) {
+	s.step++
+	switch s.step {
+	case 1:
+		return s.clientFirst(), nil
+	case 2:
+		return s.clientFinal(challenge)
+	case 3:
+		return "", s.verifyServerFinal(challenge)
+	}
+	return "", errors.New("unexpected SCRAM challenge after exchange completed")
+}
+
+// Done returns true once the server has been verified.
+func (s *SCRAMClient) Done() bool {
+	return s.done
+}
+
+//------------------------------------------------------------------------------
+
+func (s *SCRAMClient) gs2Header() string {
+	if len(s.authzID) > 0 {
+		return "n,a=" + saslName(s.authzID) + ","
+	}
+	return "n,,"
+}
+
+func (s *SCRAMClient) clientFirst() string {
+	s.clientFirstBare = "n=" + saslName(s.user) + ",r=" + s.clientNonce
+	return s.gs2Header() + s.clientFirstBare
+}
+
+func (s *SCRAMClient) clientFinal(serverFirst string) (string, error) {
+	attrs := parseAttributes(serverFirst)
+	if e, exists := attrs["e"]; exists {
+		return "", fmt.Errorf("server error: %v", e)
+	}
+
+	nonce := attrs["r"]
+	if !strings.HasPrefix(nonce, s.clientNonce) || len(nonce) == len(s.clientNonce) {
+		return "", errors.New("server nonce does not extend client nonce")
+	}
+	salt, err := base64.StdEncoding.DecodeString(attrs["s"])
+	if err != nil {
+		return "", fmt.Errorf("failed to decode salt: %v", err)
+	}
+	iterations, err := strconv.Atoi(attrs["i"])
+	if err != nil || iterations < 1 {
+		return "", fmt.Errorf("invalid iteration count: %v", attrs["i"])
+	}
+
+	saltedPassword := pbkdf2.Key([]byte(s.password), salt, iterations, s.hashFn().Size(), s.hashFn)
+	clientKey := s.hmac(saltedPassword, "Client Key")
+	storedKey := s.hashFn()
+	storedKey.Write(clientKey)
+
+	clientFinalNoProof := "c=" + base64.StdEncoding.EncodeToString([]byte(s.gs2Header())) + ",r=" + nonce
+	authMessage := s.clientFirstBare + "," + serverFirst + "," + clientFinalNoProof
+
+	clientSignature := s.hmac(storedKey.Sum(nil), authMessage)
+	proof := make([]byte, len(clientKey))
+	for i := range clientKey {
+		proof[i] = clientKey[i] ^ clientSignature[i]
+	}
+
+	serverKey := s.hmac(saltedPassword, "Server Key")
+	s.serverSignature = s.hmac(serverKey, authMessage)
+
+	return clientFinalNoProof + ",p=" + base64.StdEncoding.EncodeToString(proof), nil
+}
+
+func (s *SCRAMClient) verifyServerFinal(serverFinal string) error {
+	attrs := parseAttributes(serverFinal)
+	if e, exists := attrs["e"]; exists {
+		return fmt.Errorf("server error: %v", e)
+	}
+	signature, err := base64.StdEncoding.DecodeString(attrs["v"])
+	if err != nil {
+		return fmt.Errorf("failed to decode server signature: %v", err)
+	}
+	if !hmac.Equal(signature, s.serverSignature) {
+		return errors.New("server signature does not match")
+	}
+	s.done = true
+	return nil
+}
+
+//------------------------------------------------------------------------------
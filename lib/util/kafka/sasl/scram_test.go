@@ -0,0 +1,96 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package sasl
+
+import (
+	"crypto/sha256"
+	"testing"
+)
+
+func TestSCRAMSHA256RFC7677(t *testing.T) {
+	c := NewSCRAMClient(sha256.New)
+	c.nonce = func() (string, error) {
+		return "rOprNGfwEbeRWgbNEkqO", nil
+	}
+
+	if err := c.Begin("user", "pencil", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := c.Step("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp, act := "n,,n=user,r=rOprNGfwEbeRWgbNEkqO", res; exp != act {
+		t.Errorf("Wrong client first: %v != %v", act, exp)
+	}
+
+	res, err = c.Step("r=rOprNGfwEbeRWgbNEkqO%hvYDpWUa2RaTCAfuxFIlj)hNlF$k0,s=W22ZaJ0SNY7soEsUEjb6gQ==,i=4096")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp, act := "c=biws,r=rOprNGfwEbeRWgbNEkqO%hvYDpWUa2RaTCAfuxFIlj)hNlF$k0,p=dHzbZapWIk4jUhN+Ute9ytag9zjfMHgsqmmiz7AndVQ=", res; exp != act {
+		t.Errorf("Wrong client final: %v != %v", act, exp)
+	}
+	if c.Done() {
+		t.Error("Expected not done")
+	}
+
+	if _, err = c.Step("v=6rriTRBi23WpRR/wtup+mMhUZUn/dB5nLTJRsjl95G4="); err != nil {
+		t.Fatal(err)
+	}
+	if !c.Done() {
+		t.Error("Expected done")
+	}
+}
+
+func TestSCRAMErrors(t *testing.T) {
+	c := NewSCRAMClient(sha256.New)
+	c.nonce = func() (string, error) {
+		return "foo", nil
+	}
+
+	if err := c.Begin("user", "pencil", ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Step(""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Step("r=bar,s=W22ZaJ0SNY7soEsUEjb6gQ==,i=4096"); err == nil {
+		t.Error("Expected error from mismatched nonce")
+	}
+
+	if err := c.Begin("user", "pencil", ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Step(""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Step("r=foobar,s=W22ZaJ0SNY7soEsUEjb6gQ==,i=4096"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Step("v=6rriTRBi23WpRR/wtup+mMhUZUn/dB5nLTJRsjl95G4="); err == nil {
+		t.Error("Expected error from bad server signature")
+	}
+	if c.Done() {
+		t.Error("Expected not done")
+	}
+}
@@ -0,0 +1,140 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package sasl
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Shopify/sarama"
+)
+
+//------------------------------------------------------------------------------
+
+type staticTokenProvider string
+
+func (s staticTokenProvider) Token() (*sarama.AccessToken, error) {
+	return &sarama.AccessToken{Token: string(s)}, nil
+}
+
+//------------------------------------------------------------------------------
+
+// refreshMargin is how long before the expiry of a token it is refreshed.
+const refreshMargin = 30 * time.Second
+
+// EndpointTokenProvider obtains OAUTHBEARER tokens from an OAuth 2.0 token
+// endpoint using the client credentials grant. Tokens are cached and refreshed
+// shortly before they expire.
+type EndpointTokenProvider struct {
+	url          string
+	clientID     string
+	clientSecret string
+	scopes       []string
+	client       http.Client
+
+	mut     sync.Mutex
+	token   string
+	expires time.Time
+	nowFn   func() time.Time
+}
+
+// NewEndpointTokenProvider creates a new EndpointTokenProvider.
+func NewEndpointTokenProvider(
+	url, clientID, clientSecret string,
+	scopes []string,
+	timeout time.Duration,
+) *EndpointTokenProvider {
+	return &EndpointTokenProvider{
+		url:          url,
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		scopes:       scopes,
+		client:       http.Client{Timeout: timeout},
+		nowFn:        time.Now,
+	}
+}
+
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	ExpiresIn   int64  `json:"expires_in"`
+}
+
+// Token returns a cached token, or requests a new one from the endpoint when
+// the cached token is absent or about to expire.
+func (e *EndpointTokenProvider) Token() (*sarama.AccessToken, error) {
+	e.mut.Lock()
+	defer e.mut.Unlock()
+
+	if len(e.token) > 0 && (e.expires.IsZero() || e.nowFn().Add(refreshMargin).Before(e.expires)) {
+		return &sarama.AccessToken{Token: e.token}, nil
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "client_credentials")
+	if len(e.scopes) > 0 {
+		form.Set("scope", strings.Join(e.scopes, " "))
+	}
+
+	req, err := http.NewRequest("POST", e.url, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(url.QueryEscape(e.clientID), url.QueryEscape(e.clientSecret))
+
+	res, err := e.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to request token: %v", err)
+	}
+	defer res.Body.Close()
+
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read token response: %v", err)
+	}
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return nil, fmt.Errorf("token endpoint returned status %v: %s", res.StatusCode, body)
+	}
+
+	var tRes tokenResponse
+	if err = json.Unmarshal(body, &tRes); err != nil {
+		return nil, fmt.Errorf("failed to parse token response: %v", err)
+	}
+	if len(tRes.AccessToken) == 0 {
+		return nil, errors.New("token response did not contain an access_token")
+	}
+
+	e.token = tRes.AccessToken
+	e.expires = time.Time{}
+	if tRes.ExpiresIn > 0 {
+		e.expires = e.nowFn().Add(time.Duration(tRes.ExpiresIn) * time.Second)
+	}
+	return &sarama.AccessToken{Token: e.token}, nil
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,198 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package sasl
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/util/kerberos"
+	"github.com/Shopify/sarama"
+)
+
+//------------------------------------------------------------------------------
+
+// Documentation is a markdown description of how and why to use SASL settings.
+const Documentation = `### SASL
+
+SASL authentication can be enabled by setting the ` + "`mechanism`" + ` field of
+the ` + "`sasl`" + ` section to one of ` + "`PLAIN`" + `, ` + "`SCRAM-SHA-256`" + `,
+` + "`SCRAM-SHA-512`" + `, ` + "`OAUTHBEARER`" + ` or ` + "`GSSAPI`" + `. The ` + "`PLAIN`" + ` and
+SCRAM mechanisms use the ` + "`user`" + ` and ` + "`password`" + ` fields:
+
+` + "``` yaml" + `
+sasl:
+  mechanism: SCRAM-SHA-512
+  user: foo
+  password: bar
+` + "```" + `
+
+The ` + "`OAUTHBEARER`" + ` mechanism either uses a static token set with the
+field ` + "`access_token`" + `, or obtains tokens from an OAuth 2.0 token
+endpoint using the client credentials grant, in which case tokens are refreshed
+before they expire:
+
+` + "``` yaml" + `
+sasl:
+  mechanism: OAUTHBEARER
+  token_endpoint:
+    url: https://auth.example.com/oauth2/token
+    client_id: foo
+    client_secret: bar
+    scopes: [ kafka ]
+` + "```" + `
+
+The ` + "`GSSAPI`" + ` mechanism authenticates with Kerberos using the
+` + "`kerberos`" + ` section, where ` + "`service_name`" + ` is the Kerberos
+service name of the brokers:
+
+` + "``` yaml" + `
+sasl:
+  mechanism: GSSAPI
+  kerberos:
+    principal: benthos@EXAMPLE.COM
+    keytab_file: /etc/security/keytabs/benthos.keytab
+    config_file: /etc/krb5.conf
+    service_name: kafka
+` + "```" + `
+
+The principal may include the realm, otherwise it is read from the
+` + "`realm`" + ` field. When ` + "`keytab_file`" + ` is empty the
+` + "`password`" + ` field of the ` + "`kerberos`" + ` section is used instead.`
+
+//------------------------------------------------------------------------------
+
+// Mechanism names supported by Config.
+const (
+	MechanismNone        = "none"
+	MechanismPlain       = sarama.SASLTypePlaintext
+	MechanismSCRAMSHA256 = sarama.SASLTypeSCRAMSHA256
+	MechanismSCRAMSHA512 = sarama.SASLTypeSCRAMSHA512
+	MechanismOAuthBearer = sarama.SASLTypeOAuth
+	MechanismGSSAPI      = sarama.SASLTypeGSSAPI
+)
+
+// TokenEndpointConfig contains configuration fields for obtaining OAUTHBEARER
+// tokens from an OAuth 2.0 token endpoint.
+type TokenEndpointConfig struct {
+	URL          string   `json:"url" yaml:"url"`
+	ClientID     string   `json:"client_id" yaml:"client_id"`
+	ClientSecret string   `json:"client_secret" yaml:"client_secret"`
+	Scopes       []string `json:"scopes" yaml:"scopes"`
+	TimeoutMS    int      `json:"timeout_ms" yaml:"timeout_ms"`
+}
+
+// Config contains configuration fields for SASL authentication with Kafka.
+type Config struct {
+	Mechanism     string              `json:"mechanism" yaml:"mechanism"`
+	User          string              `json:"user" yaml:"user"`
+	Password      string              `json:"password" yaml:"password"`
+	AccessToken   string              `json:"access_token" yaml:"access_token"`
+	TokenEndpoint TokenEndpointConfig `json:"token_endpoint" yaml:"token_endpoint"`
+	Kerberos      kerberos.Config     `json:"kerberos" yaml:"kerberos"`
+}
+
+// NewConfig creates a new Config with default values.
+func NewConfig() Config {
+	krbConf := kerberos.NewConfig()
+	krbConf.ServiceName = "kafka"
+	return Config{
+		Mechanism:   MechanismNone,
+		User:        "",
+		Password:    "",
+		AccessToken: "",
+		TokenEndpoint: TokenEndpointConfig{
+			URL:          "",
+			ClientID:     "",
+			ClientSecret: "",
+			Scopes:       []string{},
+			TimeoutMS:    5000,
+		},
+		Kerberos: krbConf,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// Apply sets the SASL fields of a sarama config according to the Config.
+func (c Config) Apply(conf *sarama.Config) error {
+	switch c.Mechanism {
+	case MechanismNone, "":
+		return nil
+	case MechanismPlain:
+	case MechanismSCRAMSHA256:
+		conf.Net.SASL.SCRAMClientGeneratorFunc = func() sarama.SCRAMClient {
+			return NewSCRAMClient(sha256.New)
+		}
+	case MechanismSCRAMSHA512:
+		conf.Net.SASL.SCRAMClientGeneratorFunc = func() sarama.SCRAMClient {
+			return NewSCRAMClient(sha512.New)
+		}
+	case MechanismOAuthBearer:
+		if len(c.TokenEndpoint.URL) > 0 {
+			conf.Net.SASL.TokenProvider = NewEndpointTokenProvider(
+				c.TokenEndpoint.URL,
+				c.TokenEndpoint.ClientID,
+				c.TokenEndpoint.ClientSecret,
+				c.TokenEndpoint.Scopes,
+				time.Duration(c.TokenEndpoint.TimeoutMS)*time.Millisecond,
+			)
+		} else if len(c.AccessToken) > 0 {
+			conf.Net.SASL.TokenProvider = staticTokenProvider(c.AccessToken)
+		} else {
+			return errors.New("mechanism OAUTHBEARER requires either an access_token or a token_endpoint url")
+		}
+	case MechanismGSSAPI:
+		user, realm, err := c.Kerberos.UserAndRealm()
+		if err != nil {
+			return err
+		}
+		gssConf := sarama.GSSAPIConfig{
+			KerberosConfigPath: c.Kerberos.ConfigFile,
+			ServiceName:        c.Kerberos.ServiceName,
+			Username:           user,
+			Realm:              realm,
+		}
+		if len(c.Kerberos.KeytabFile) > 0 {
+			gssConf.AuthType = sarama.KRB5_KEYTAB_AUTH
+			gssConf.KeyTabPath = c.Kerberos.KeytabFile
+		} else if len(c.Kerberos.Password) > 0 {
+			gssConf.AuthType = sarama.KRB5_USER_AUTH
+			gssConf.Password = c.Kerberos.Password
+		} else {
+			return errors.New("mechanism GSSAPI requires either a kerberos keytab_file or password")
+		}
+		conf.Net.SASL.GSSAPI = gssConf
+	default:
+		return fmt.Errorf("unrecognised SASL mechanism: %v", c.Mechanism)
+	}
+
+	conf.Net.SASL.Enable = true
+	conf.Net.SASL.Mechanism = sarama.SASLMechanism(c.Mechanism)
+	conf.Net.SASL.User = c.User
+	conf.Net.SASL.Password = c.Password
+	return nil
+}
+
+//------------------------------------------------------------------------------
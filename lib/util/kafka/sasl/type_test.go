@@ -0,0 +1,180 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package sasl
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Shopify/sarama"
+)
+
+func TestApplyMechanisms(t *testing.T) {
+	type testCase struct {
+		conf      Config
+		mechanism sarama.SASLMechanism
+		scram     bool
+		token     bool
+	}
+
+	plain := NewConfig()
+	plain.Mechanism = MechanismPlain
+	plain.User = "foo"
+	plain.Password = "bar"
+
+	scram := NewConfig()
+	scram.Mechanism = MechanismSCRAMSHA512
+
+	oauth := NewConfig()
+	oauth.Mechanism = MechanismOAuthBearer
+	oauth.AccessToken = "baz"
+
+	tests := []testCase{
+		{conf: plain, mechanism: sarama.SASLTypePlaintext},
+		{conf: scram, mechanism: sarama.SASLTypeSCRAMSHA512, scram: true},
+		{conf: oauth, mechanism: sarama.SASLTypeOAuth, token: true},
+	}
+
+	for _, test := range tests {
+		conf := sarama.NewConfig()
+		if err := test.conf.Apply(conf); err != nil {
+			t.Fatal(err)
+		}
+		if !conf.Net.SASL.Enable {
+			t.Errorf("%v: SASL not enabled", test.mechanism)
+		}
+		if exp, act := test.mechanism, conf.Net.SASL.Mechanism; exp != act {
+			t.Errorf("Wrong mechanism: %v != %v", act, exp)
+		}
+		if exp, act := test.scram, conf.Net.SASL.SCRAMClientGeneratorFunc != nil; exp != act {
+			t.Errorf("%v: wrong SCRAM generator presence: %v != %v", test.mechanism, act, exp)
+		}
+		if exp, act := test.token, conf.Net.SASL.TokenProvider != nil; exp != act {
+			t.Errorf("%v: wrong token provider presence: %v != %v", test.mechanism, act, exp)
+		}
+	}
+
+	conf := sarama.NewConfig()
+	if err := NewConfig().Apply(conf); err != nil {
+		t.Fatal(err)
+	}
+	if conf.Net.SASL.Enable {
+		t.Error("Expected SASL to be disabled by default")
+	}
+
+	bad := NewConfig()
+	bad.Mechanism = "nope"
+	if err := bad.Apply(conf); err == nil {
+		t.Error("Expected error from unrecognised mechanism")
+	}
+
+	bad.Mechanism = MechanismOAuthBearer
+	if err := bad.Apply(conf); err == nil {
+		t.Error("Expected error from OAUTHBEARER without a token source")
+	}
+
+	bad.Mechanism = MechanismGSSAPI
+	bad.Kerberos.Principal = "foo@EXAMPLE.COM"
+	if err := bad.Apply(conf); err == nil {
+		t.Error("Expected error from GSSAPI without a keytab or password")
+	}
+}
+
+func TestApplyGSSAPI(t *testing.T) {
+	c := NewConfig()
+	c.Mechanism = MechanismGSSAPI
+	c.Kerberos.Principal = "foo@EXAMPLE.COM"
+	c.Kerberos.KeytabFile = "/etc/foo.keytab"
+
+	conf := sarama.NewConfig()
+	if err := c.Apply(conf); err != nil {
+		t.Fatal(err)
+	}
+	if exp, act := sarama.SASLMechanism(sarama.SASLTypeGSSAPI), conf.Net.SASL.Mechanism; exp != act {
+		t.Errorf("Wrong mechanism: %v != %v", act, exp)
+	}
+	exp := sarama.GSSAPIConfig{
+		AuthType:           sarama.KRB5_KEYTAB_AUTH,
+		KeyTabPath:         "/etc/foo.keytab",
+		KerberosConfigPath: "/etc/krb5.conf",
+		ServiceName:        "kafka",
+		Username:           "foo",
+		Realm:              "EXAMPLE.COM",
+	}
+	if act := conf.Net.SASL.GSSAPI; exp != act {
+		t.Errorf("Wrong GSSAPI config: %+v != %+v", act, exp)
+	}
+}
+
+func TestEndpointTokenProvider(t *testing.T) {
+	reqCount := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reqCount++
+		if user, pass, _ := r.BasicAuth(); user != "foo" || pass != "bar" {
+			http.Error(w, "bad credentials", http.StatusUnauthorized)
+			return
+		}
+		if exp, act := "client_credentials", r.FormValue("grant_type"); exp != act {
+			t.Errorf("Wrong grant type: %v != %v", act, exp)
+		}
+		if exp, act := "a b", r.FormValue("scope"); exp != act {
+			t.Errorf("Wrong scope: %v != %v", act, exp)
+		}
+		fmt.Fprintf(w, `{"access_token":"token%v","expires_in":60}`, reqCount)
+	}))
+	defer ts.Close()
+
+	now := time.Unix(1000, 0)
+	p := NewEndpointTokenProvider(ts.URL, "foo", "bar", []string{"a", "b"}, time.Second)
+	p.nowFn = func() time.Time { return now }
+
+	tok, err := p.Token()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp, act := "token1", tok.Token; exp != act {
+		t.Errorf("Wrong token: %v != %v", act, exp)
+	}
+
+	now = now.Add(20 * time.Second)
+	if tok, err = p.Token(); err != nil {
+		t.Fatal(err)
+	}
+	if exp, act := "token1", tok.Token; exp != act {
+		t.Errorf("Wrong cached token: %v != %v", act, exp)
+	}
+
+	now = now.Add(20 * time.Second)
+	if tok, err = p.Token(); err != nil {
+		t.Fatal(err)
+	}
+	if exp, act := "token2", tok.Token; exp != act {
+		t.Errorf("Wrong refreshed token: %v != %v", act, exp)
+	}
+
+	p = NewEndpointTokenProvider(ts.URL, "foo", "baz", nil, time.Second)
+	if _, err = p.Token(); err == nil {
+		t.Error("Expected error from bad credentials")
+	}
+}
@@ -0,0 +1,131 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package kerberos
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	krb "gopkg.in/jcmturner/gokrb5.v7/client"
+	krbconfig "gopkg.in/jcmturner/gokrb5.v7/config"
+	"gopkg.in/jcmturner/gokrb5.v7/keytab"
+)
+
+//------------------------------------------------------------------------------
+
+// Documentation is a markdown description of how and why to use Kerberos
+// settings.
+const Documentation = `### Kerberos
+
+Kerberos (GSSAPI) authentication uses the principal set in the
+` + "`principal`" + ` field, which may include the realm in the form
+` + "`user@REALM`" + `, otherwise the realm is taken from the ` + "`realm`" + `
+field. Credentials are read from the keytab at ` + "`keytab_file`" + ` when it
+is set, otherwise the ` + "`password`" + ` field is used. The Kerberos client
+is configured with the krb5 config file at ` + "`config_file`" + `:
+
+` + "``` yaml" + `
+kerberos:
+  principal: benthos@EXAMPLE.COM
+  realm: ""
+  keytab_file: /etc/security/keytabs/benthos.keytab
+  password: ""
+  config_file: /etc/krb5.conf
+` + "```" + ``
+
+//------------------------------------------------------------------------------
+
+// Config contains configuration fields for Kerberos authentication.
+type Config struct {
+	Principal   string `json:"principal" yaml:"principal"`
+	Realm       string `json:"realm" yaml:"realm"`
+	KeytabFile  string `json:"keytab_file" yaml:"keytab_file"`
+	Password    string `json:"password" yaml:"password"`
+	ConfigFile  string `json:"config_file" yaml:"config_file"`
+	ServiceName string `json:"service_name" yaml:"service_name"`
+}
+
+// NewConfig creates a new Config with default values.
+func NewConfig() Config {
+	return Config{
+		Principal:   "",
+		Realm:       "",
+		KeytabFile:  "",
+		Password:    "",
+		ConfigFile:  "/etc/krb5.conf",
+		ServiceName: "",
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// UserAndRealm returns the user name and realm of the configured principal,
+// where a realm within the principal takes precedence over the realm field.
+func (c Config) UserAndRealm() (user, realm string, err error) {
+	if len(c.Principal) == 0 {
+		return "", "", errors.New("a principal must be specified")
+	}
+	user, realm = c.Principal, c.Realm
+	if i := strings.LastIndex(c.Principal, "@"); i >= 0 {
+		user, realm = c.Principal[:i], c.Principal[i+1:]
+	}
+	if len(user) == 0 {
+		return "", "", fmt.Errorf("principal '%v' has an empty user name", c.Principal)
+	}
+	if len(realm) == 0 {
+		return "", "", fmt.Errorf("no realm specified for principal '%v'", c.Principal)
+	}
+	return user, realm, nil
+}
+
+// Client creates a Kerberos client from the config and logs in with it.
+func (c Config) Client() (*krb.Client, error) {
+	user, realm, err := c.UserAndRealm()
+	if err != nil {
+		return nil, err
+	}
+
+	krbConf, err := krbconfig.Load(c.ConfigFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load krb5 config: %v", err)
+	}
+
+	var client *krb.Client
+	if len(c.KeytabFile) > 0 {
+		kt, err := keytab.Load(c.KeytabFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load keytab: %v", err)
+		}
+		client = krb.NewClientWithKeytab(user, realm, kt, krbConf)
+	} else if len(c.Password) > 0 {
+		client = krb.NewClientWithPassword(user, realm, c.Password, krbConf)
+	} else {
+		return nil, errors.New("either a keytab_file or a password must be specified")
+	}
+
+	if err = client.Login(); err != nil {
+		return nil, fmt.Errorf("failed to login to kerberos: %v", err)
+	}
+	return client, nil
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,80 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package kerberos
+
+import (
+	"testing"
+)
+
+func TestUserAndRealm(t *testing.T) {
+	tests := []struct {
+		principal string
+		realm     string
+		user      string
+		expRealm  string
+		err       bool
+	}{
+		{principal: "foo@EXAMPLE.COM", user: "foo", expRealm: "EXAMPLE.COM"},
+		{principal: "foo@EXAMPLE.COM", realm: "OTHER.COM", user: "foo", expRealm: "EXAMPLE.COM"},
+		{principal: "foo/host@EXAMPLE.COM", user: "foo/host", expRealm: "EXAMPLE.COM"},
+		{principal: "foo", realm: "OTHER.COM", user: "foo", expRealm: "OTHER.COM"},
+		{principal: "foo", err: true},
+		{principal: "@EXAMPLE.COM", err: true},
+		{principal: "", err: true},
+	}
+
+	for _, test := range tests {
+		conf := NewConfig()
+		conf.Principal = test.principal
+		conf.Realm = test.realm
+
+		user, realm, err := conf.UserAndRealm()
+		if test.err {
+			if err == nil {
+				t.Errorf("Expected error for %+v", test)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Unexpected error for %+v: %v", test, err)
+			continue
+		}
+		if user != test.user {
+			t.Errorf("Wrong user: %v != %v", user, test.user)
+		}
+		if realm != test.expRealm {
+			t.Errorf("Wrong realm: %v != %v", realm, test.expRealm)
+		}
+	}
+}
+
+func TestClientErrors(t *testing.T) {
+	conf := NewConfig()
+	if _, err := conf.Client(); err == nil {
+		t.Error("Expected error without a principal")
+	}
+
+	conf.Principal = "foo@EXAMPLE.COM"
+	conf.ConfigFile = "./does_not_exist.conf"
+	if _, err := conf.Client(); err == nil {
+		t.Error("Expected error from missing krb5 config")
+	}
+}
@@ -0,0 +1,119 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package lineage
+
+import (
+	"os"
+	"sync"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/gofrs/uuid"
+)
+
+//------------------------------------------------------------------------------
+
+// Config contains configuration fields for stamping lineage metadata.
+type Config struct {
+	Enabled bool   `json:"enabled" yaml:"enabled"`
+	Prefix  string `json:"prefix" yaml:"prefix"`
+}
+
+// NewConfig creates a Config with default values.
+func NewConfig() Config {
+	return Config{
+		Enabled: false,
+		Prefix:  "lineage_",
+	}
+}
+
+//------------------------------------------------------------------------------
+
+var (
+	instanceID     string
+	instanceIDOnce sync.Once
+)
+
+// InstanceID returns an identifier of this Benthos instance, which consists of
+// the hostname of the machine followed by a random UUID generated the first
+// time it is called.
+func InstanceID() string {
+	instanceIDOnce.Do(func() {
+		hostname, _ := os.Hostname()
+		if u4, err := uuid.NewV4(); err == nil {
+			instanceID = hostname + "-" + u4.String()
+		} else {
+			instanceID = hostname
+		}
+	})
+	return instanceID
+}
+
+//------------------------------------------------------------------------------
+
+// Stamp is a types.Processor that sets a fixed set of metadata fields, along
+// with the current time, on each part of a message.
+type Stamp struct {
+	fields    map[string]string
+	timeKey   string
+	overwrite bool
+	now       func() time.Time
+}
+
+// NewStamp creates a Stamp that sets metadata fields to values and the key
+// timeKey to the time each message is processed in RFC 3339 format. When
+// overwrite is false the fields are only set on parts that do not already have
+// them, which preserves lineage stamped by components further upstream.
+func NewStamp(fields map[string]string, timeKey string, overwrite bool) *Stamp {
+	return &Stamp{
+		fields:    fields,
+		timeKey:   timeKey,
+		overwrite: overwrite,
+		now:       time.Now,
+	}
+}
+
+// ProcessMessage sets the lineage metadata fields of each part of a message.
+func (s *Stamp) ProcessMessage(msg types.Message) ([]types.Message, types.Response) {
+	ts := s.now().Format(time.RFC3339Nano)
+
+	newMsg := msg.Copy()
+	newMsg.Iter(func(i int, p types.Part) error {
+		meta := p.Metadata()
+		set := func(k, v string) {
+			if s.overwrite || len(meta.Get(k)) == 0 {
+				meta.Set(k, v)
+			}
+		}
+		for k, v := range s.fields {
+			set(k, v)
+		}
+		if len(s.timeKey) > 0 {
+			set(s.timeKey, ts)
+		}
+		return nil
+	})
+
+	msgs := [1]types.Message{newMsg}
+	return msgs[:], nil
+}
+
+//------------------------------------------------------------------------------
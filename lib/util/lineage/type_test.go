@@ -0,0 +1,81 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package lineage
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/message"
+)
+
+func TestInstanceID(t *testing.T) {
+	id := InstanceID()
+	if len(id) == 0 {
+		t.Fatal("Empty instance ID")
+	}
+	if exp, act := id, InstanceID(); exp != act {
+		t.Errorf("Instance ID changed: %v != %v", act, exp)
+	}
+}
+
+func TestStamp(t *testing.T) {
+	ts := time.Date(2019, 1, 2, 3, 4, 5, 6, time.UTC)
+
+	msg := message.New([][]byte{[]byte("foo"), []byte("bar")})
+	msg.Get(1).Metadata().Set("a", "existing")
+
+	for _, overwrite := range []bool{false, true} {
+		s := NewStamp(map[string]string{"a": "1", "b": "2"}, "at", overwrite)
+		s.now = func() time.Time { return ts }
+
+		msgs, res := s.ProcessMessage(msg)
+		if res != nil {
+			t.Fatal(res.Error())
+		}
+		if exp, act := 1, len(msgs); exp != act {
+			t.Fatalf("Wrong count of messages: %v != %v", act, exp)
+		}
+
+		expA := "existing"
+		if overwrite {
+			expA = "1"
+		}
+		for i, exp := range []map[string]string{
+			{"a": "1", "b": "2", "at": "2019-01-02T03:04:05.000000006Z"},
+			{"a": expA, "b": "2", "at": "2019-01-02T03:04:05.000000006Z"},
+		} {
+			for k, v := range exp {
+				if act := msgs[0].Get(i).Metadata().Get(k); v != act {
+					t.Errorf("Wrong metadata %v of part %v: %v != %v", k, i, act, v)
+				}
+			}
+		}
+		if act := msg.Get(0).Metadata().Get("a"); len(act) > 0 {
+			t.Errorf("Original message was modified: %v", act)
+		}
+	}
+
+	if !strings.Contains(InstanceID(), "-") {
+		t.Errorf("Unexpected instance ID format: %v", InstanceID())
+	}
+}
@@ -0,0 +1,65 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package pool
+
+import (
+	"bytes"
+	"sync"
+)
+
+//------------------------------------------------------------------------------
+
+// maxPooledCap is the capacity above which buffers are dropped rather than
+// returned to the pool, so that occasional large payloads are not held in
+// memory indefinitely.
+const maxPooledCap = 4 * 1024 * 1024
+
+var buffers = sync.Pool{
+	New: func() interface{} {
+		return &bytes.Buffer{}
+	},
+}
+
+// GetBuffer returns an empty buffer from the pool.
+func GetBuffer() *bytes.Buffer {
+	return buffers.Get().(*bytes.Buffer)
+}
+
+// PutBuffer resets a buffer and returns it to the pool. Slices obtained from
+// the buffer must not be used after calling PutBuffer.
+func PutBuffer(b *bytes.Buffer) {
+	if b.Cap() > maxPooledCap {
+		return
+	}
+	b.Reset()
+	buffers.Put(b)
+}
+
+// Detach copies the contents of a buffer obtained with GetBuffer into a new
+// slice of exactly the right size, and returns the buffer to the pool.
+func Detach(b *bytes.Buffer) []byte {
+	out := make([]byte, b.Len())
+	copy(out, b.Bytes())
+	PutBuffer(b)
+	return out
+}
+
+//------------------------------------------------------------------------------
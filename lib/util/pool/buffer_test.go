@@ -0,0 +1,70 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package pool
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestDetach(t *testing.T) {
+	buf := GetBuffer()
+	if buf.Len() != 0 {
+		t.Errorf("Expected empty buffer, got %v bytes", buf.Len())
+	}
+	buf.WriteString("hello world")
+
+	out := Detach(buf)
+	if exp, act := "hello world", string(out); exp != act {
+		t.Errorf("Wrong result: %v != %v", act, exp)
+	}
+	if exp, act := len(out), cap(out); exp != act {
+		t.Errorf("Expected exact capacity: %v != %v", act, exp)
+	}
+
+	// Writing to a recycled buffer must not affect detached contents.
+	buf = GetBuffer()
+	buf.WriteString("overwritten")
+	if exp, act := "hello world", string(out); exp != act {
+		t.Errorf("Detached contents changed: %v != %v", act, exp)
+	}
+	PutBuffer(buf)
+}
+
+func TestPutBufferLarge(t *testing.T) {
+	buf := bytes.NewBuffer(make([]byte, 0, maxPooledCap+1))
+	PutBuffer(buf)
+	for i := 0; i < 10; i++ {
+		if GetBuffer() == buf {
+			t.Fatal("Oversized buffer was pooled")
+		}
+	}
+}
+
+func BenchmarkDetach(b *testing.B) {
+	payload := bytes.Repeat([]byte("hello world "), 1000)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		buf := GetBuffer()
+		buf.Write(payload)
+		Detach(buf)
+	}
+}
@@ -22,7 +22,9 @@ package text
 
 import (
 	"bytes"
+	"crypto/rand"
 	"encoding/json"
+	"fmt"
 	"os"
 	"regexp"
 	"strconv"
@@ -32,6 +34,7 @@ import (
 
 	"github.com/Jeffail/benthos/lib/types"
 	"github.com/Jeffail/gabs"
+	"github.com/gofrs/uuid"
 )
 
 //------------------------------------------------------------------------------
@@ -107,13 +110,74 @@ func metadataMapFunction(msg Message, arg string) []byte {
 	return result
 }
 
+const defaultTimestampLayout = "Mon Jan 2 15:04:05 -0700 MST 2006"
+
+// formatTimestamp formats a time with either a Go layout or, when the layout
+// contains a % character, a strftime layout.
+func formatTimestamp(t time.Time, layout string) []byte {
+	if len(layout) == 0 {
+		layout = defaultTimestampLayout
+	}
+	if strings.Contains(layout, "%") {
+		return strftime(t, layout)
+	}
+	return []byte(t.Format(layout))
+}
+
+var locations = map[string]*time.Location{}
+var locationsMux = &sync.Mutex{}
+
+func loadLocation(name string) (*time.Location, error) {
+	locationsMux.Lock()
+	defer locationsMux.Unlock()
+
+	if loc, exists := locations[name]; exists {
+		return loc, nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, err
+	}
+	locations[name] = loc
+	return loc, nil
+}
+
+func timestampTZFunction(_ Message, arg string) []byte {
+	zone, layout := arg, ""
+	if i := strings.IndexByte(arg, ','); i != -1 {
+		zone, layout = arg[:i], arg[i+1:]
+	}
+	loc, err := loadLocation(zone)
+	if err != nil {
+		return []byte("")
+	}
+	return formatTimestamp(time.Now().In(loc), layout)
+}
+
+const nanoidAlphabet = "_-0123456789abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
+
+func nanoidFunction(_ Message, arg string) []byte {
+	size, err := strconv.Atoi(arg)
+	if err != nil || size <= 0 {
+		size = 21
+	}
+	id := make([]byte, size)
+	if _, err = rand.Read(id); err != nil {
+		return []byte("")
+	}
+	for i, b := range id {
+		id[i] = nanoidAlphabet[b&63]
+	}
+	return id
+}
+
 //------------------------------------------------------------------------------
 
 var functionRegex *regexp.Regexp
 
 func init() {
 	var err error
-	functionRegex, err = regexp.Compile(`\${![a-z_]+(:[^}]+)?}`)
+	functionRegex, err = regexp.Compile(`\${![a-z0-9_]+(:[^}]+)?}`)
 	if err != nil {
 		panic(err)
 	}
@@ -140,11 +204,12 @@ var functionVars = map[string]func(msg Message, arg string) []byte{
 		return []byte(tStr)
 	},
 	"timestamp": func(_ Message, arg string) []byte {
-		if len(arg) == 0 {
-			arg = "Mon Jan 2 15:04:05 -0700 MST 2006"
-		}
-		return []byte(time.Now().Format(arg))
+		return formatTimestamp(time.Now(), arg)
+	},
+	"timestamp_utc": func(_ Message, arg string) []byte {
+		return formatTimestamp(time.Now().UTC(), arg)
 	},
+	"timestamp_tz": timestampTZFunction,
 	"hostname": func(_ Message, arg string) []byte {
 		hn, _ := os.Hostname()
 		return []byte(hn)
@@ -168,6 +233,20 @@ var functionVars = map[string]func(msg Message, arg string) []byte{
 
 		return []byte(strconv.FormatUint(count, 10))
 	},
+	"uuid_v4": func(_ Message, arg string) []byte {
+		u4, err := uuid.NewV4()
+		if err != nil {
+			return []byte("")
+		}
+		return []byte(u4.String())
+	},
+	"nanoid": nanoidFunction,
+	"batch_size": func(msg Message, arg string) []byte {
+		if msg == nil {
+			return []byte("0")
+		}
+		return []byte(strconv.Itoa(msg.Len()))
+	},
 	"json_field":           jsonFieldFunction,
 	"metadata":             metadataFunction,
 	"metadata_json_object": metadataMapFunction,
@@ -179,6 +258,37 @@ func ContainsFunctionVariables(inBytes []byte) bool {
 	return functionRegex.Find(inBytes) != nil
 }
 
+// ValidateFunctionVariables checks that each function variable pattern within
+// inBytes is well formed and refers to a known function, returning an error
+// describing the first problem found.
+func ValidateFunctionVariables(inBytes []byte) error {
+	offset := 0
+	for {
+		i := bytes.Index(inBytes[offset:], []byte("${!"))
+		if i == -1 {
+			return nil
+		}
+		i += offset
+		loc := functionRegex.FindIndex(inBytes[i:])
+		if loc == nil || loc[0] != 0 {
+			end := bytes.IndexByte(inBytes[i:], '}')
+			if end == -1 {
+				return fmt.Errorf("unterminated function variable: %s", inBytes[i:])
+			}
+			return fmt.Errorf("malformed function variable: %s", inBytes[i:i+end+1])
+		}
+		content := inBytes[i : i+loc[1]]
+		name := content[3 : len(content)-1]
+		if colonIndex := bytes.IndexByte(name, ':'); colonIndex != -1 {
+			name = name[:colonIndex]
+		}
+		if _, exists := functionVars[string(name)]; !exists {
+			return fmt.Errorf("unrecognised function variable: %s", content)
+		}
+		offset = i + loc[1]
+	}
+}
+
 func escapeBytes(in []byte) []byte {
 	quoted := strconv.QuoteToASCII(string(in))
 	if len(quoted) < 3 {
@@ -23,6 +23,7 @@ package text
 import (
 	"fmt"
 	"os"
+	"regexp"
 	"strconv"
 	"testing"
 	"time"
@@ -358,3 +359,124 @@ func TestCountersFunction(t *testing.T) {
 		}
 	}
 }
+
+func TestValidateFunctionVariables(t *testing.T) {
+	tests := map[string]string{
+		"foo":                                "",
+		"foo ${!echo:bar} ${!count:baz}":     "",
+		"${!json_field:foo.bar,0}":           "",
+		"foo ${!nope}":                       "unrecognised function variable: ${!nope}",
+		"foo ${!echo:bar} ${!nope:baz} bar":  "unrecognised function variable: ${!nope:baz}",
+		"foo ${!Echo} bar":                   "malformed function variable: ${!Echo}",
+		"foo ${!echo":                        "unterminated function variable: ${!echo",
+		"${!echo:bar}${!metadata:foo} ${!ec": "unterminated function variable: ${!ec",
+	}
+
+	for input, exp := range tests {
+		var act string
+		if err := ValidateFunctionVariables([]byte(input)); err != nil {
+			act = err.Error()
+		}
+		if exp != act {
+			t.Errorf("Wrong results for input (%v): %v != %v", input, act, exp)
+		}
+	}
+}
+
+func TestTimestampLayoutFunctions(t *testing.T) {
+	now := time.Now()
+
+	tStamp := string(ReplaceFunctionVariables(nil, []byte("${!timestamp:%Y-%m-%dT%H:%M:%S%z}")))
+	tThen, err := time.Parse("2006-01-02T15:04:05-0700", tStamp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tThen.Sub(now).Seconds() > 5.0 {
+		t.Errorf("Timestamps too far out of sync: %v and %v", tThen, now)
+	}
+
+	tStamp = string(ReplaceFunctionVariables(nil, []byte("${!timestamp_utc:2006-01-02T15:04:05Z07:00}")))
+	if tThen, err = time.Parse(time.RFC3339, tStamp); err != nil {
+		t.Fatal(err)
+	}
+	if _, offset := tThen.Zone(); offset != 0 {
+		t.Errorf("Timestamp not in UTC: %v", tStamp)
+	}
+
+	tStamp = string(ReplaceFunctionVariables(nil, []byte("${!timestamp_tz:Etc/GMT-3,%H:%M %z}")))
+	if exp, act := now.UTC().Add(time.Hour*3).Format("15:04")+" +0300", tStamp; exp != act {
+		t.Errorf("Wrong result: %v != %v", act, exp)
+	}
+
+	tStamp = string(ReplaceFunctionVariables(nil, []byte("${!timestamp_tz:Etc/GMT-3,Mon, 02 Jan 2006 15:04 MST}")))
+	if _, err = time.Parse("Mon, 02 Jan 2006 15:04 MST", tStamp); err != nil {
+		t.Error(err)
+	}
+
+	tStamp = string(ReplaceFunctionVariables(nil, []byte("foo ${!timestamp_tz:Not/AZone} bar")))
+	if exp, act := "foo  bar", tStamp; exp != act {
+		t.Errorf("Wrong result: %v != %v", act, exp)
+	}
+}
+
+func TestStrftime(t *testing.T) {
+	tStamp := time.Date(2018, time.March, 4, 17, 5, 9, 123456789, time.UTC)
+
+	tests := map[string]string{
+		"%Y-%m-%d %H:%M:%S":   "2018-03-04 17:05:09",
+		"%y/%b/%e %I%p":       "18/Mar/ 4 05PM",
+		"%A %B %j %u %w":      "Sunday March 063 7 0",
+		"%F %T.%f %Z":         "2018-03-04 17:05:09.123456 UTC",
+		"%s":                  "1520183109",
+		"100%% %q literal %":  "100% %q literal %",
+		"logs/%Y/%m/%d/x.log": "logs/2018/03/04/x.log",
+	}
+
+	for layout, exp := range tests {
+		if act := string(strftime(tStamp, layout)); exp != act {
+			t.Errorf("Wrong result for layout (%v): %v != %v", layout, act, exp)
+		}
+	}
+}
+
+func TestUUIDAndNanoidFunctions(t *testing.T) {
+	uuidRegex := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+	first := string(ReplaceFunctionVariables(nil, []byte("${!uuid_v4}")))
+	second := string(ReplaceFunctionVariables(nil, []byte("${!uuid_v4}")))
+	if !uuidRegex.MatchString(first) {
+		t.Errorf("Invalid UUID: %v", first)
+	}
+	if first == second {
+		t.Errorf("Duplicate UUIDs: %v", first)
+	}
+
+	nanoidRegex := regexp.MustCompile(`^[0-9A-Za-z_-]+$`)
+
+	id := string(ReplaceFunctionVariables(nil, []byte("${!nanoid}")))
+	if exp, act := 21, len(id); exp != act {
+		t.Errorf("Wrong nanoid length: %v != %v", act, exp)
+	}
+	if !nanoidRegex.MatchString(id) {
+		t.Errorf("Invalid nanoid: %v", id)
+	}
+
+	id = string(ReplaceFunctionVariables(nil, []byte("${!nanoid:8}")))
+	if exp, act := 8, len(id); exp != act {
+		t.Errorf("Wrong nanoid length: %v != %v", act, exp)
+	}
+}
+
+func TestBatchSizeFunction(t *testing.T) {
+	msg := message.New([][]byte{[]byte("foo"), []byte("bar"), []byte("baz")})
+
+	act := string(ReplaceFunctionVariables(msg, []byte("size: ${!batch_size}")))
+	if exp := "size: 3"; exp != act {
+		t.Errorf("Wrong result: %v != %v", act, exp)
+	}
+
+	act = string(ReplaceFunctionVariables(nil, []byte("size: ${!batch_size}")))
+	if exp := "size: 0"; exp != act {
+		t.Errorf("Wrong result: %v != %v", act, exp)
+	}
+}
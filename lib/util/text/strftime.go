@@ -0,0 +1,102 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package text
+
+import (
+	"bytes"
+	"strconv"
+	"time"
+)
+
+//------------------------------------------------------------------------------
+
+// strftimeLayouts maps strftime directives to equivalent Go time layouts.
+var strftimeLayouts = map[byte]string{
+	'a': "Mon",
+	'A': "Monday",
+	'b': "Jan",
+	'B': "January",
+	'c': "Mon Jan  2 15:04:05 2006",
+	'd': "02",
+	'D': "01/02/06",
+	'e': "_2",
+	'F': "2006-01-02",
+	'h': "Jan",
+	'H': "15",
+	'I': "03",
+	'm': "01",
+	'M': "04",
+	'p': "PM",
+	'R': "15:04",
+	'S': "05",
+	'T': "15:04:05",
+	'y': "06",
+	'Y': "2006",
+	'z': "-0700",
+	'Z': "MST",
+}
+
+// strftime formats a time according to a strftime style layout. Directives
+// that are not recognised are written as they are.
+func strftime(t time.Time, layout string) []byte {
+	var buf bytes.Buffer
+	for i := 0; i < len(layout); i++ {
+		c := layout[i]
+		if c != '%' || i == len(layout)-1 {
+			buf.WriteByte(c)
+			continue
+		}
+		i++
+		d := layout[i]
+		if goLayout, exists := strftimeLayouts[d]; exists {
+			buf.WriteString(t.Format(goLayout))
+			continue
+		}
+		switch d {
+		case '%':
+			buf.WriteByte('%')
+		case 'f':
+			buf.WriteString(strconv.Itoa(t.Nanosecond()/1000 + 1000000)[1:])
+		case 'j':
+			buf.WriteString(strconv.Itoa(t.YearDay() + 1000)[1:])
+		case 'n':
+			buf.WriteByte('\n')
+		case 's':
+			buf.WriteString(strconv.FormatInt(t.Unix(), 10))
+		case 't':
+			buf.WriteByte('\t')
+		case 'u':
+			wd := int(t.Weekday())
+			if wd == 0 {
+				wd = 7
+			}
+			buf.WriteString(strconv.Itoa(wd))
+		case 'w':
+			buf.WriteString(strconv.Itoa(int(t.Weekday())))
+		default:
+			buf.WriteByte('%')
+			buf.WriteByte(d)
+		}
+	}
+	return buf.Bytes()
+}
+
+//------------------------------------------------------------------------------
@@ -48,6 +48,23 @@ func TestInterpolatedString(t *testing.T) {
 	}
 }
 
+func TestInterpolatedStringLockedParts(t *testing.T) {
+	msg := message.New([][]byte{
+		[]byte(`{"foo":"first"}`),
+		[]byte(`{"foo":"second"}`),
+	})
+	msg.Get(0).Metadata().Set("bar", "a")
+	msg.Get(1).Metadata().Set("bar", "b")
+
+	str := NewInterpolatedString("${!json_field:foo}/${!metadata:bar}")
+	if exp, act := "first/a", str.Get(message.Lock(msg, 0)); exp != act {
+		t.Errorf("Wrong result: %v != %v", act, exp)
+	}
+	if exp, act := "second/b", str.Get(message.Lock(msg, 1)); exp != act {
+		t.Errorf("Wrong result: %v != %v", act, exp)
+	}
+}
+
 func TestInterpolatedBytes(t *testing.T) {
 	b := NewInterpolatedBytes([]byte("foobar"))
 	if exp, act := "foobar", string(b.Get(message.New(nil))); exp != act {
@@ -23,6 +23,8 @@ package tls
 import (
 	"crypto/tls"
 	"crypto/x509"
+	"errors"
+	"fmt"
 	"io/ioutil"
 )
 
@@ -33,13 +35,20 @@ const Documentation = `### TLS
 
 Custom TLS settings can be used to override system defaults. This includes
 providing a collection of root certificate authorities, providing a list of
-client certificates to use for client verification and skipping certificate
+client certificates to use for client verification, setting the minimum TLS
+version (` + "`1.0`" + `, ` + "`1.1`" + ` or ` + "`1.2`" + `), overriding the
+server name used for verification and SNI, and skipping certificate
 verification.
 
-Client certificates can either be added by file or by raw contents:
+Root certificate authorities and client certificates can either be added by file
+or by raw PEM contents:
 
 ` + "``` yaml" + `
 enabled: true
+root_cas_file: ./ca.pem
+root_cas: ""
+min_version: "1.2"
+server_name: ""
 client_certs:
   - cert_file: ./example.pem
     key_file: ./example.key
@@ -61,7 +70,10 @@ type ClientCertConfig struct {
 type Config struct {
 	Enabled            bool               `json:"enabled" yaml:"enabled"`
 	RootCAsFile        string             `json:"root_cas_file" yaml:"root_cas_file"`
+	RootCAs            string             `json:"root_cas" yaml:"root_cas"`
 	InsecureSkipVerify bool               `json:"skip_cert_verify" yaml:"skip_cert_verify"`
+	MinVersion         string             `json:"min_version" yaml:"min_version"`
+	ServerName         string             `json:"server_name" yaml:"server_name"`
 	ClientCertificates []ClientCertConfig `json:"client_certs" yaml:"client_certs"`
 }
 
@@ -70,13 +82,22 @@ func NewConfig() Config {
 	return Config{
 		Enabled:            false,
 		RootCAsFile:        "",
+		RootCAs:            "",
 		InsecureSkipVerify: false,
+		MinVersion:         "",
+		ServerName:         "",
 		ClientCertificates: []ClientCertConfig{},
 	}
 }
 
 //------------------------------------------------------------------------------
 
+var minVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+}
+
 // Get returns a valid *tls.Config based on the configuration values of Config.
 func (c *Config) Get() (*tls.Config, error) {
 	var rootCAs *x509.CertPool
@@ -89,6 +110,22 @@ func (c *Config) Get() (*tls.Config, error) {
 		rootCAs = x509.NewCertPool()
 		rootCAs.AppendCertsFromPEM(caCert)
 	}
+	if len(c.RootCAs) > 0 {
+		if rootCAs == nil {
+			rootCAs = x509.NewCertPool()
+		}
+		if !rootCAs.AppendCertsFromPEM([]byte(c.RootCAs)) {
+			return nil, errors.New("failed to parse root_cas PEM contents")
+		}
+	}
+
+	var minVersion uint16
+	if len(c.MinVersion) > 0 {
+		var exists bool
+		if minVersion, exists = minVersions[c.MinVersion]; !exists {
+			return nil, fmt.Errorf("unrecognised min_version: %v", c.MinVersion)
+		}
+	}
 
 	clientCerts := []tls.Certificate{}
 
@@ -104,6 +141,8 @@ func (c *Config) Get() (*tls.Config, error) {
 		InsecureSkipVerify: c.InsecureSkipVerify,
 		RootCAs:            rootCAs,
 		Certificates:       clientCerts,
+		MinVersion:         minVersion,
+		ServerName:         c.ServerName,
 	}, nil
 }
 
@@ -0,0 +1,110 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package tls
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+)
+
+func createCertPEM(t *testing.T) string {
+	t.Helper()
+
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "benthos test"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &tmpl, &tmpl, &priv.PublicKey, priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+}
+
+func TestGetFields(t *testing.T) {
+	conf := NewConfig()
+	conf.Enabled = true
+	conf.RootCAs = createCertPEM(t)
+	conf.MinVersion = "1.2"
+	conf.ServerName = "foo.example.com"
+	conf.InsecureSkipVerify = true
+
+	tConf, err := conf.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tConf.RootCAs == nil {
+		t.Error("Expected root CAs")
+	}
+	if exp, act := uint16(tls.VersionTLS12), tConf.MinVersion; exp != act {
+		t.Errorf("Wrong min version: %v != %v", act, exp)
+	}
+	if exp, act := "foo.example.com", tConf.ServerName; exp != act {
+		t.Errorf("Wrong server name: %v != %v", act, exp)
+	}
+	if !tConf.InsecureSkipVerify {
+		t.Error("Expected skip verify")
+	}
+}
+
+func TestGetDefaults(t *testing.T) {
+	conf := NewConfig()
+	tConf, err := conf.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tConf.RootCAs != nil {
+		t.Error("Expected system root CAs")
+	}
+	if exp, act := uint16(0), tConf.MinVersion; exp != act {
+		t.Errorf("Wrong min version: %v != %v", act, exp)
+	}
+}
+
+func TestGetErrors(t *testing.T) {
+	conf := NewConfig()
+	conf.MinVersion = "0.9"
+	if _, err := conf.Get(); err == nil {
+		t.Error("Expected error from bad min version")
+	}
+
+	conf = NewConfig()
+	conf.RootCAs = "not a cert"
+	if _, err := conf.Get(); err == nil {
+		t.Error("Expected error from bad root CAs")
+	}
+}